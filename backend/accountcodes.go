@@ -28,6 +28,7 @@ import (
 //
 // There are different types of account codes:
 // - regular: for unified accounts
+// - multisig: for multisig accounts, which are numbered separately from the regular accounts
 // - split: for the individual accounts split from a unified account, if the keystore does not support unified accounts, such as the BitBox01.
 // - erc20: for ERC20 token accounts
 
@@ -37,6 +38,12 @@ func regularAccountCode(rootFingerprint []byte, coinCode coin.Code, accountNumbe
 	return accountsTypes.Code(fmt.Sprintf("v0-%x-%s-%d", rootFingerprint, coinCode, accountNumber))
 }
 
+// multisigAccountCode returns an account code for a multisig account based on the root fingerprint
+// of the keystore, a coin code and the account number of the key of the keystore.
+func multisigAccountCode(rootFingerprint []byte, coinCode coin.Code, accountNumber uint16) accountsTypes.Code {
+	return accountsTypes.Code(fmt.Sprintf("v0-%x-%s-multisig-%d", rootFingerprint, coinCode, accountNumber))
+}
+
 // splitAccountCode returns an account code for split accounts, made by exploding a unified account
 // into one account per signing configuration. This only applies to BTC/LTC.
 func splitAccountCode(parentCode accountsTypes.Code, scriptType signing.ScriptType) accountsTypes.Code {
@@ -21,6 +21,7 @@ import (
 	"math/big"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
@@ -28,6 +29,8 @@ import (
 	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/bitsurance"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/descriptor"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/xpubimport"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
@@ -57,6 +60,14 @@ const hardenedKeystart uint32 = hdkeychain.HardenedKeyStart
 // limit, but simply use a hard limit for simplicity.
 const accountsHardLimit = 5
 
+// accountInitWorkers is the maximum number of accounts which are initialized and synced
+// concurrently after they were loaded.
+const accountInitWorkers = 4
+
+// accountSyncSlotTimeout is how long an account may block one of the accountInitWorkers while
+// syncing, see initializeAccount().
+const accountSyncSlotTimeout = time.Minute
+
 // AccountsList is an accounts.Interface slice which implements a lookup method.
 type AccountsList []accounts.Interface
 
@@ -283,6 +294,34 @@ func (backend *Backend) AccountsTotalBalanceByKeystore() (map[string]KeystoreTot
 	return totalAmounts, nil
 }
 
+// AccountsTotalBalance returns the total balance of all active accounts across all keystores,
+// converted to the main fiat currency. Like the accounts shown to the user, it leaves out the
+// accounts which are hidden because they are unused.
+func (backend *Backend) AccountsTotalBalance() (*KeystoreTotalAmount, error) {
+	fiat := backend.Config().AppConfig().Backend.MainFiat
+	total := new(big.Rat)
+	for _, account := range backend.Accounts() {
+		if account.Config().Config.Inactive || account.Config().Config.HiddenBecauseUnused {
+			continue
+		}
+		if account.FatalError() {
+			continue
+		}
+		if err := account.Initialize(); err != nil {
+			return nil, err
+		}
+		fiatValue, err := backend.accountFiatBalance(account, fiat)
+		if err != nil {
+			return nil, err
+		}
+		total.Add(total, fiatValue)
+	}
+	return &KeystoreTotalAmount{
+		FiatUnit: fiat,
+		Total:    coinpkg.FormatAsCurrency(total, fiat),
+	}, nil
+}
+
 // LookupInsuredAccounts queries the insurance status of specified or all active BTC accounts
 // and updates the internal state based on the retrieved information. If the accountCode is
 // provided, it checks the insurance status for that specific account; otherwise, it checks
@@ -495,6 +534,13 @@ func findHiddenAccount(
 	return result, nil
 }
 
+// isMultisigAccount returns true if the account is a multisig account. Multisig accounts are
+// numbered separately from the singlesig accounts, see nextMultisigAccountNumber().
+func isMultisigAccount(account *config.Account) bool {
+	return len(account.SigningConfigurations) > 0 &&
+		account.SigningConfigurations[0].BitcoinMultisig != nil
+}
+
 // nextAccountNumber checks if an account for the given coin can be added, and if so, returns the
 // account number of the new account.
 func nextAccountNumber(coinCode coinpkg.Code, keystore keystore.Keystore, accountsConfig *config.AccountsConfig) (uint16, error) {
@@ -510,7 +556,7 @@ func nextAccountNumber(coinCode coinpkg.Code, keystore keystore.Keystore, accoun
 		if !account.SigningConfigurations.ContainsRootFingerprint(rootFingerprint) {
 			continue
 		}
-		if len(account.SigningConfigurations) == 0 {
+		if len(account.SigningConfigurations) == 0 || isMultisigAccount(account) {
 			continue
 		}
 		accountNumber, err := account.SigningConfigurations[0].AccountNumber()
@@ -609,9 +655,201 @@ func (backend *Backend) CreateAndPersistAccountConfig(
 	return accountCode, nil
 }
 
+// ImportWatchonlyAccount adds a watch-only account from the extended public key export file of
+// another hardware wallet or from output descriptors, see the xpubimport package. The keystore is persisted with watchonly
+// enabled, so the account is loaded even though the keystore can't be connected to the app.
+//
+// `name` is the account name, shown to the user. If empty, a default name will be set.
+func (backend *Backend) ImportWatchonlyAccount(
+	coinCode coinpkg.Code, name string, exportFile []byte) (accountsTypes.Code, error) {
+	switch coinCode {
+	case coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeRBTC:
+	default:
+		return "", errp.Newf("Importing export files is not supported for %s", coinCode)
+	}
+	coin, err := backend.Coin(coinCode)
+	if err != nil {
+		return "", err
+	}
+	export, err := xpubimport.Parse(exportFile, coin.(*btc.Coin).Net())
+	if err != nil {
+		return "", err
+	}
+	accountNumber, err := export.Configurations[0].AccountNumber()
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = defaultAccountName(coin, accountNumber)
+	}
+	accountCode := regularAccountCode(export.RootFingerprint, coinCode, accountNumber)
+	if export.Configurations[0].BitcoinMultisig != nil {
+		accountCode = multisigAccountCode(export.RootFingerprint, coinCode, accountNumber)
+	}
+	err = backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		keystore := accountsConfig.GetOrAddKeystore(export.RootFingerprint)
+		keystore.Watchonly = true
+		if keystore.Name == "" {
+			keystore.Name = name
+		}
+		watch := true
+		return backend.persistAccount(config.Account{
+			Watch:                 &watch,
+			CoinCode:              coinCode,
+			Name:                  name,
+			Code:                  accountCode,
+			SigningConfigurations: export.Configurations,
+		}, accountsConfig)
+	})
+	if err != nil {
+		return "", err
+	}
+	backend.ReinitializeAccounts()
+	return accountCode, nil
+}
+
+// maxMultisigAccountNameLength is the maximum length of the name under which a multisig account is
+// registered on the BitBox02.
+const maxMultisigAccountNameLength = 30
+
+// nextMultisigAccountNumber returns the account number of the key of the keystore in a new multisig
+// account, which is part of its BIP48 keypath.
+func nextMultisigAccountNumber(
+	coinCode coinpkg.Code, rootFingerprint []byte, accountsConfig *config.AccountsConfig) (uint16, error) {
+	nextAccountNumber := uint16(0)
+	for _, account := range accountsConfig.Accounts {
+		if coinCode != account.CoinCode || !isMultisigAccount(account) ||
+			!account.SigningConfigurations.ContainsRootFingerprint(rootFingerprint) {
+			continue
+		}
+		accountNumber, err := account.SigningConfigurations[0].AccountNumber()
+		if err != nil {
+			continue
+		}
+		if accountNumber+1 > nextAccountNumber {
+			nextAccountNumber = accountNumber + 1
+		}
+	}
+	if nextAccountNumber >= accountsHardLimit {
+		return 0, errp.WithStack(errAccountLimitReached)
+	}
+	return nextAccountNumber, nil
+}
+
+// CreateMultisigAccount adds a multisig account of the keystore, whose transactions are cosigned
+// with PSBTs. The key of the keystore is the BIP48 P2WSH key of the next multisig account number,
+// m/48'/coin'/account'/2'. `cosigners` are the keys of the other cosigners with their key origin,
+// see descriptor.ParseKey(). The account is registered on the keystore under its name, which the
+// user confirms on the device together with the cosigner keys.
+//
+// `name` is the account name, shown to the user. If empty, a default name will be set.
+func (backend *Backend) CreateMultisigAccount(
+	coinCode coinpkg.Code,
+	name string,
+	threshold int,
+	cosigners []string,
+	keystore keystore.Keystore,
+) (accountsTypes.Code, error) {
+	switch coinCode {
+	case coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeRBTC:
+	default:
+		return "", errp.Newf("Multisig accounts are not supported for %s", coinCode)
+	}
+	coin, err := backend.Coin(coinCode)
+	if err != nil {
+		return "", err
+	}
+	if !keystore.SupportsAccount(coin, signing.ScriptTypeP2WSH) {
+		return "", errp.New("The keystore does not support multisig accounts")
+	}
+	rootFingerprint, err := keystore.RootFingerprint()
+	if err != nil {
+		return "", err
+	}
+	accountsConfig := backend.config.AccountsConfig()
+	accountNumber, err := nextMultisigAccountNumber(coinCode, rootFingerprint, &accountsConfig)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = coin.Name() + " multisig"
+		if accountNumber > 0 {
+			name = fmt.Sprintf("%s %d", name, accountNumber+1)
+		}
+	}
+	if len(name) > maxMultisigAccountNameLength {
+		return "", errp.Newf("The name of a multisig account can have at most %d characters",
+			maxMultisigAccountNameLength)
+	}
+
+	bip44Coin := 1 + hardenedKeystart
+	if coinCode == coinpkg.CodeBTC {
+		bip44Coin = hardenedKeystart
+	}
+	// The script type 2' denotes P2WSH in BIP48.
+	keypath := signing.NewAbsoluteKeypathFromUint32(
+		48+hardenedKeystart, bip44Coin, uint32(accountNumber)+hardenedKeystart, 2+hardenedKeystart)
+	extendedPublicKey, err := keystore.ExtendedPublicKey(coin, keypath)
+	if err != nil {
+		return "", err
+	}
+	keyInfos := []signing.KeyInfo{{
+		RootFingerprint:   rootFingerprint,
+		AbsoluteKeypath:   keypath,
+		ExtendedPublicKey: extendedPublicKey,
+	}}
+	for _, cosigner := range cosigners {
+		keyInfo, err := descriptor.ParseKey(cosigner, coin.(*btc.Coin).Net())
+		if err != nil {
+			return "", err
+		}
+		keyInfos = append(keyInfos, *keyInfo)
+	}
+	configuration, err := signing.NewBitcoinMultisigConfiguration(threshold, keyInfos, 0)
+	if err != nil {
+		return "", err
+	}
+	if err := keystore.RegisterMultisig(coin, configuration, name); err != nil {
+		return "", err
+	}
+
+	accountCode := multisigAccountCode(rootFingerprint, coinCode, accountNumber)
+	backend.log.
+		WithField("accountCode", accountCode).
+		WithField("threshold", threshold).
+		WithField("cosigners", len(cosigners)).
+		Info("Persisting new multisig account config")
+	err = backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		var accountWatch *bool
+		if accountsConfig.IsKeystoreWatchonly(rootFingerprint) {
+			t := true
+			accountWatch = &t
+		}
+		return backend.persistAccount(config.Account{
+			Watch:                 accountWatch,
+			CoinCode:              coinCode,
+			Name:                  name,
+			Code:                  accountCode,
+			SigningConfigurations: signing.Configurations{configuration},
+		}, accountsConfig)
+	})
+	if err != nil {
+		return "", err
+	}
+	backend.ReinitializeAccounts()
+	return accountCode, nil
+}
+
 // SetAccountActive activates/deactivates an account.
 func (backend *Backend) SetAccountActive(accountCode accountsTypes.Code, active bool) error {
-	err := backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+	return backend.SetAccountActiveAtRevision(accountCode, active, "")
+}
+
+// SetAccountActiveAtRevision is like SetAccountActive(), but fails with config.ErrConflict if the
+// accounts config changed since the given revision, see config.Config.ModifyAccountsConfigAtRevision().
+func (backend *Backend) SetAccountActiveAtRevision(
+	accountCode accountsTypes.Code, active bool, revision string) error {
+	err := backend.config.ModifyAccountsConfigAtRevision(revision, func(accountsConfig *config.AccountsConfig) error {
 		acct := accountsConfig.Lookup(accountCode)
 		if acct == nil {
 			return errp.Newf("Could not find account %s", accountCode)
@@ -629,7 +867,14 @@ func (backend *Backend) SetAccountActive(accountCode accountsTypes.Code, active
 // SetTokenActive activates/deactivates an token on an account. `tokenCode` must be an ERC20 token
 // code, e.g. "eth-erc20-usdt", "eth-erc20-bat", etc.
 func (backend *Backend) SetTokenActive(accountCode accountsTypes.Code, tokenCode string, active bool) error {
-	err := backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+	return backend.SetTokenActiveAtRevision(accountCode, tokenCode, active, "")
+}
+
+// SetTokenActiveAtRevision is like SetTokenActive(), but fails with config.ErrConflict if the
+// accounts config changed since the given revision.
+func (backend *Backend) SetTokenActiveAtRevision(
+	accountCode accountsTypes.Code, tokenCode string, active bool, revision string) error {
+	err := backend.config.ModifyAccountsConfigAtRevision(revision, func(accountsConfig *config.AccountsConfig) error {
 		acct := accountsConfig.Lookup(accountCode)
 		if acct == nil {
 			return errp.Newf("Could not find account %s", accountCode)
@@ -645,10 +890,16 @@ func (backend *Backend) SetTokenActive(accountCode accountsTypes.Code, tokenCode
 
 // RenameAccount renames an account in the accounts database.
 func (backend *Backend) RenameAccount(accountCode accountsTypes.Code, name string) error {
+	return backend.RenameAccountAtRevision(accountCode, name, "")
+}
+
+// RenameAccountAtRevision is like RenameAccount(), but fails with config.ErrConflict if the
+// accounts config changed since the given revision.
+func (backend *Backend) RenameAccountAtRevision(accountCode accountsTypes.Code, name string, revision string) error {
 	if name == "" {
 		return errp.New("Name cannot be empty")
 	}
-	err := backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+	err := backend.config.ModifyAccountsConfigAtRevision(revision, func(accountsConfig *config.AccountsConfig) error {
 		acct := accountsConfig.Lookup(accountCode)
 		if acct == nil {
 			return errp.Newf("Could not find account %s", accountCode)
@@ -724,9 +975,30 @@ func (backend *Backend) addAccount(account accounts.Interface) {
 	if backend.onAccountInit != nil {
 		backend.onAccountInit(account)
 	}
+	accountConfig := account.Config().Config
+	if backend.config.AppConfig().Backend.LazyAccountInit && !accountConfig.HiddenBecauseUnused {
+		backend.lazyAccounts[accountConfig.Code] = account
+		return
+	}
 	go backend.checkAccountUsed(account)
 }
 
+// InitializeAccounts initializes the given accounts in the background if their initialization was
+// deferred in the lazy account initialization mode. EventInitialized is fired for each account
+// once it is initialized. Accounts which are already initialized or being initialized are
+// skipped.
+func (backend *Backend) InitializeAccounts(codes []accountsTypes.Code) {
+	defer backend.accountsAndKeystoreLock.Lock()()
+	for _, code := range codes {
+		account, ok := backend.lazyAccounts[code]
+		if !ok {
+			continue
+		}
+		delete(backend.lazyAccounts, code)
+		go backend.checkAccountUsed(account)
+	}
+}
+
 // The accountsAndKeystoreLock must be held when calling this function.
 func (backend *Backend) createAndAddAccount(coin coinpkg.Coin, persistedConfig *config.Account) {
 	if backend.accounts.lookup(persistedConfig.Code) != nil {
@@ -841,15 +1113,21 @@ func (backend *Backend) createAndAddAccount(coin coinpkg.Coin, persistedConfig *
 			}
 			if account != nil && event == accountsTypes.EventSyncDone {
 				backend.notifyNewTxs(account)
+				go backend.invoices.Check(persistedConfig.Code)
+				go backend.checkAccountEvents(account)
+				go backend.saveAccountCheckpoint(account)
+				go backend.detectInternalTransfers()
 			}
 		},
 		RateUpdater: backend.ratesUpdater,
 		GetNotifier: func(configurations signing.Configurations) accounts.Notifier {
 			return backend.notifier.ForAccount(persistedConfig.Code)
 		},
-		GetSaveFilename:  backend.environment.GetSaveFilename,
-		UnsafeSystemOpen: backend.environment.SystemOpen,
-		BtcCurrencyUnit:  backend.config.AppConfig().Backend.BtcUnit,
+		GetSaveFilename:     backend.environment.GetSaveFilename,
+		UnsafeSystemOpen:    backend.environment.SystemOpen,
+		BtcCurrencyUnit:     backend.config.AppConfig().Backend.BtcUnit,
+		GetAppConfig:        backend.config.AppConfig,
+		InterruptedSignings: backend.interruptedSignings,
 	}
 
 	switch specificCoin := coin.(type) {
@@ -1097,11 +1375,25 @@ func (backend *Backend) persistETHAccountConfig(
 	}, accountsConfig)
 }
 
+// isAccountWatchonly returns true if the account should be loaded even if its keystore is not
+// connected, either because of its watchonly setting or because of the global watch-only mode,
+// see config.Backend.WatchonlyAll.
+func (backend *Backend) isAccountWatchonly(
+	accountsConfig *config.AccountsConfig, account *config.Account) (bool, error) {
+	if backend.config.AppConfig().Backend.WatchonlyAll && !account.HiddenBecauseUnused {
+		return true, nil
+	}
+	return accountsConfig.IsAccountWatchonly(account)
+}
+
 // The accountsAndKeystoreLock must be held when calling this function.
 func (backend *Backend) initPersistedAccounts() {
+	if backend.closed {
+		return
+	}
 	// Only load accounts which belong to connected keystores or for which watchonly is enabled.
 	keystoreConnectedOrWatch := func(accountsConfig *config.AccountsConfig, account *config.Account) bool {
-		isWatch, err := accountsConfig.IsAccountWatchonly(account)
+		isWatch, err := backend.isAccountWatchonly(accountsConfig, account)
 		if err != nil {
 			backend.log.WithError(err).Error("Can't determine watch status of account")
 		} else if isWatch {
@@ -1141,7 +1433,7 @@ outer:
 		// inserted with the same seed as a Multi, we will need to catch that mismatch when the
 		// keystore will be used to e.g. display an Ethereum address etc.
 		if backend.keystore != nil {
-			isWatch, err := persistedAccounts.IsAccountWatchonly(account)
+			isWatch, err := backend.isAccountWatchonly(&persistedAccounts, account)
 			if err != nil {
 				backend.log.WithError(err).Error("Could not retrieve root fingerprint")
 				continue
@@ -1328,7 +1620,8 @@ func (backend *Backend) uninitAccounts(force bool) {
 			}
 		}
 
-		isWatchonly, err := backend.config.AccountsConfig().IsAccountWatchonly(account.Config().Config)
+		accountsConfig := backend.config.AccountsConfig()
+		isWatchonly, err := backend.isAccountWatchonly(&accountsConfig, account.Config().Config)
 		if err != nil {
 			backend.log.WithError(err).Error("could not retrieve keystore fingerprint")
 			isWatchonly = false
@@ -1341,6 +1634,7 @@ func (backend *Backend) uninitAccounts(force bool) {
 		if backend.onAccountUninit != nil {
 			backend.onAccountUninit(account)
 		}
+		delete(backend.lazyAccounts, account.Config().Config.Code)
 		account.Close()
 	}
 	backend.accounts = keep
@@ -1394,7 +1688,8 @@ func (backend *Backend) maybeAddHiddenUnusedAccounts() {
 			if coinCode != accountConfig.CoinCode {
 				continue
 			}
-			if !accountConfig.SigningConfigurations.ContainsRootFingerprint(rootFingerprint) {
+			if !accountConfig.SigningConfigurations.ContainsRootFingerprint(rootFingerprint) ||
+				isMultisigAccount(accountConfig) {
 				continue
 			}
 			accountNumber, err := accountConfig.SigningConfigurations[0].AccountNumber()
@@ -1473,6 +1768,27 @@ func (backend *Backend) maybeAddHiddenUnusedAccounts() {
 	}
 }
 
+// initializeAccount initializes the account in the background after it was added and returns its
+// transactions once it is synced. At most accountInitWorkers accounts are initialized and synced at
+// the same time, so that users with many accounts see the balances of the first accounts quickly
+// instead of all accounts competing for the connection. An account which takes longer than
+// accountSyncSlotTimeout to sync, e.g. because its server is unreachable, stops blocking the
+// others. EventInitialized is fired once the account is initialized.
+func (backend *Backend) initializeAccount(account accounts.Interface) (accounts.OrderedTransactions, error) {
+	backend.accountInitSlots <- struct{}{}
+	var releaseOnce sync.Once
+	release := func() { releaseOnce.Do(func() { <-backend.accountInitSlots }) }
+	defer release()
+	if err := account.Initialize(); err != nil {
+		return nil, err
+	}
+	account.Config().OnEvent(accountsTypes.EventInitialized)
+	timer := time.AfterFunc(accountSyncSlotTimeout, release)
+	defer timer.Stop()
+	// Waits until the account is synced.
+	return account.Transactions()
+}
+
 func (backend *Backend) checkAccountUsed(account accounts.Interface) {
 	if backend.tstCheckAccountUsed != nil {
 		if !backend.tstCheckAccountUsed(account) {
@@ -1480,11 +1796,7 @@ func (backend *Backend) checkAccountUsed(account accounts.Interface) {
 		}
 	}
 	log := backend.log.WithField("accountCode", account.Config().Config.Code)
-	if err := account.Initialize(); err != nil {
-		log.WithError(err).Error("error initializing account")
-		return
-	}
-	txs, err := account.Transactions()
+	txs, err := backend.initializeAccount(account)
 	if err != nil {
 		log.WithError(err).Error("discoverAccount")
 		return
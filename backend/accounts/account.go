@@ -18,6 +18,8 @@ package accounts
 import (
 	"io"
 
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
@@ -32,6 +34,22 @@ type AddressList struct {
 	Addresses  []Address
 }
 
+// ChangePolicy determines the script type of the change output of a BTC/LTC transaction. Using
+// the same script type as the payment output makes it harder for chain observers to tell apart the
+// change output from the payment output.
+type ChangePolicy string
+
+const (
+	// ChangePolicyDefault picks the change script type based on the spent UTXOs and the available
+	// subaccounts.
+	ChangePolicyDefault ChangePolicy = ""
+	// ChangePolicyMatchRecipient uses the script type of the recipient address for the change, if
+	// the account has a subaccount of that script type. Falls back to ChangePolicyDefault otherwise.
+	ChangePolicyMatchRecipient ChangePolicy = "matchRecipient"
+	// ChangePolicyNewest always uses the newest script type available in the account.
+	ChangePolicyNewest ChangePolicy = "newest"
+)
+
 // TxProposalArgs are the arguments needed when creating a tx proposal.
 type TxProposalArgs struct {
 	RecipientAddress string
@@ -41,6 +59,15 @@ type TxProposalArgs struct {
 	CustomFee     string
 	SelectedUTXOs map[wire.OutPoint]struct{}
 	Note          string
+	// ChangePolicy only applies to BTC/LTC.
+	ChangePolicy ChangePolicy
+	// AllowHighFee disables rejecting BTC/LTC proposals for their fee being too high, see
+	// errors.ErrFeeTooHigh. The user must have explicitly confirmed the high fee.
+	AllowHighFee bool
+	// ProposalID identifies the send dialog making the proposal, e.g. a random ID per frontend
+	// window. If enabled in config.Backend.LockProposalUTXOs, the inputs of a BTC/LTC proposal are
+	// reserved for it until it is sent, released or the reservation times out.
+	ProposalID string
 }
 
 // Interface is the API of a Account.
@@ -74,7 +101,14 @@ type Interface interface {
 	Balance() (*Balance, error)
 	// SendTx signs and sends the active tx proposal, set by TxProposal. Errors if none
 	// available. The note, if set by ProposeTxNote(), is persisted for the transaction.
+	//
+	// If the keystore disconnects while signing, keystore.ErrKeystoreDisconnected is returned and
+	// the proposal is kept. Without an active tx proposal, e.g. after the keystore reconnected,
+	// SendTx() signs and sends the kept proposal.
 	SendTx() error
+	// HasInterruptedSigning returns true if there is a tx proposal whose signing was interrupted
+	// by the keystore disconnecting, see SendTx().
+	HasInterruptedSigning() bool
 	FeeTargets() ([]FeeTarget, FeeTargetCode)
 	TxProposal(*TxProposalArgs) (coin.Amount, coin.Amount, coin.Amount, error)
 	// GetUnusedReceiveAddresses gets a list of list of receive addresses. The result can be one
@@ -90,9 +124,44 @@ type Interface interface {
 	ProposeTxNote(string)
 	// SetTxNote sets a tx note and refreshes the account.
 	SetTxNote(txID string, note string) error
+	// TxSpam returns whether the user marked a transaction as spam or as not spam. The second
+	// return value is false if the user did not mark the transaction.
+	TxSpam(txID string) (bool, bool)
+	// SetTxSpam marks a transaction as spam or as not spam, overriding the spam heuristics, and
+	// refreshes the account.
+	SetTxSpam(txID string, spam bool) error
+	// TxOrigin returns where the funds of a transaction came from, or nil if not known.
+	TxOrigin(txID string) *notes.TxOrigin
+	// SetTxOrigin sets the origin of a transaction and refreshes the account. nil removes it.
+	SetTxOrigin(txID string, origin *notes.TxOrigin) error
+	// TxInternalTransfer returns the code of the other loaded account if the transaction is a
+	// transfer between the account and that one, or the empty string otherwise.
+	TxInternalTransfer(txID string) types.Code
+	// SetTxInternalTransfer marks the transaction as an internal transfer with the given account
+	// and refreshes the account if the mark changed. An empty code removes the mark.
+	SetTxInternalTransfer(txID string, counterparty types.Code) error
+	// TxCategory returns the category of a transaction, or the empty string if it has none.
+	TxCategory(txID string) string
+	// SetTxCategory sets the category of a transaction and refreshes the account. An empty
+	// category removes it.
+	SetTxCategory(txID string, category string) error
+	// AddressLabel returns the label of an address, or the empty string if there is none.
+	AddressLabel(address string) string
+	// SetAddressLabel sets the label of an address and refreshes the account. An empty label
+	// removes it.
+	SetAddressLabel(address string, label string) error
+
+	// Checkpoint returns the balance and the transactions of the account as of its last sync in a
+	// previous or in the current session, or nil if there is none. It is available before the
+	// account is synced.
+	Checkpoint() *Checkpoint
+	// SaveCheckpoint persists the balance and the most recent transactions as the checkpoint.
+	SaveCheckpoint(balance *Balance, transactions OrderedTransactions) error
 
 	// ExportCSV exports the given transaction in CSV format (comma-separated).
 	ExportCSV(w io.Writer, transactions []*TransactionData) error
+	// Export exports the given transactions in the given format.
+	Export(w io.Writer, format ExportFormat, transactions []*TransactionData) error
 }
 
 // Info holds account information.
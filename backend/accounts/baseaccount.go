@@ -54,6 +54,18 @@ type AccountConfig struct {
 	UnsafeSystemOpen func(filename string) error
 	// BtcCurrencyUnit is the unit which should be used to format fiat amounts values expressed in BTC..
 	BtcCurrencyUnit coin.BtcUnit
+	// GetAppConfig returns the current app config. Can be nil, see AppConfig().
+	GetAppConfig func() config.AppConfig
+	// InterruptedSignings keeps the tx proposals whose signing was interrupted. Can be nil.
+	InterruptedSignings *InterruptedSignings
+}
+
+// AppConfig returns the current app config, or the default app config if GetAppConfig is nil.
+func (accountConfig *AccountConfig) AppConfig() config.AppConfig {
+	if accountConfig.GetAppConfig == nil {
+		return config.NewDefaultAppConfig()
+	}
+	return accountConfig.GetAppConfig()
 }
 
 // BaseAccount is an account struct with common functionality to all coin accounts.
@@ -76,6 +88,11 @@ type BaseAccount struct {
 	proposedTxNote   string
 	proposedTxNoteMu sync.Mutex
 
+	// checkpoint is the last known state of the account, see Checkpoint. nil if there is none.
+	checkpoint         *Checkpoint
+	checkpointFilename string
+	checkpointMu       sync.Mutex
+
 	log *logrus.Entry
 }
 
@@ -109,6 +126,16 @@ func (account *BaseAccount) Coin() coin.Coin {
 	return account.coin
 }
 
+// ConfirmationThreshold returns the number of confirmations after which transactions of the given
+// coin are considered complete, as configured by the user, or zero if the default of the coin
+// applies. See config.Backend.ConfirmationThresholds.
+func (account *BaseAccount) ConfirmationThreshold(code coin.Code) int {
+	if account.config.GetAppConfig == nil {
+		return 0
+	}
+	return account.config.GetAppConfig().Backend.ConfirmationThresholds[string(code)]
+}
+
 // Synced implements Interface.
 func (account *BaseAccount) Synced() bool {
 	return account.synced.Load()
@@ -152,6 +179,11 @@ func (account *BaseAccount) Initialize(accountIdentifier string) error {
 		return err
 	}
 
+	account.loadCheckpoint(path.Join(
+		account.config.DBFolder,
+		fmt.Sprintf("%s-checkpoint.json", accountIdentifier),
+	))
+
 	// An account syncdone event is generated when new rates are available. This allows the frontend
 	// to reload the relevant data.
 	if account.config.RateUpdater != nil {
@@ -254,6 +286,84 @@ func (account *BaseAccount) TxNote(txID string) string {
 	return account.notes.TxNote(txID)
 }
 
+// SetTxSpam implements accounts.Account.
+func (account *BaseAccount) SetTxSpam(txID string, spam bool) error {
+	if err := account.notes.SetTxSpam(txID, spam); err != nil {
+		return err
+	}
+	// Prompt refresh.
+	account.config.OnEvent(types.EventStatusChanged)
+	return nil
+}
+
+// TxSpam implements accounts.Account.
+func (account *BaseAccount) TxSpam(txID string) (bool, bool) {
+	return account.notes.TxSpam(txID)
+}
+
+// SetTxOrigin implements accounts.Account.
+func (account *BaseAccount) SetTxOrigin(txID string, origin *notes.TxOrigin) error {
+	if err := account.notes.SetTxOrigin(txID, origin); err != nil {
+		return err
+	}
+	// Prompt refresh.
+	account.config.OnEvent(types.EventStatusChanged)
+	return nil
+}
+
+// TxOrigin implements accounts.Account.
+func (account *BaseAccount) TxOrigin(txID string) *notes.TxOrigin {
+	return account.notes.TxOrigin(txID)
+}
+
+// SetTxCategory implements accounts.Account.
+func (account *BaseAccount) SetTxCategory(txID string, category string) error {
+	if err := account.notes.SetTxCategory(txID, category); err != nil {
+		return err
+	}
+	// Prompt refresh.
+	account.config.OnEvent(types.EventStatusChanged)
+	return nil
+}
+
+// TxCategory implements accounts.Account.
+func (account *BaseAccount) TxCategory(txID string) string {
+	return account.notes.TxCategory(txID)
+}
+
+// SetAddressLabel implements accounts.Account.
+func (account *BaseAccount) SetAddressLabel(address string, label string) error {
+	if err := account.notes.SetAddressLabel(address, label); err != nil {
+		return err
+	}
+	// Prompt refresh.
+	account.config.OnEvent(types.EventStatusChanged)
+	return nil
+}
+
+// AddressLabel implements accounts.Account.
+func (account *BaseAccount) AddressLabel(address string) string {
+	return account.notes.AddressLabel(address)
+}
+
+// SetTxInternalTransfer implements accounts.Account.
+func (account *BaseAccount) SetTxInternalTransfer(txID string, counterparty types.Code) error {
+	if account.notes.TxInternalTransfer(txID) == string(counterparty) {
+		return nil
+	}
+	if err := account.notes.SetTxInternalTransfer(txID, string(counterparty)); err != nil {
+		return err
+	}
+	// Prompt refresh.
+	account.config.OnEvent(types.EventStatusChanged)
+	return nil
+}
+
+// TxInternalTransfer implements accounts.Account.
+func (account *BaseAccount) TxInternalTransfer(txID string) types.Code {
+	return types.Code(account.notes.TxInternalTransfer(txID))
+}
+
 // ExportCSV implements accounts.Account.
 func (account *BaseAccount) ExportCSV(w io.Writer, transactions []*TransactionData) error {
 	writer := csv.NewWriter(w)
@@ -266,6 +376,11 @@ func (account *BaseAccount) ExportCSV(w io.Writer, transactions []*TransactionDa
 		"Address",
 		"Transaction ID",
 		"Note",
+		"Origin",
+		"Origin order ID",
+		"Internal transfer",
+		"Fiat value",
+		"Fiat currency",
 	})
 	if err != nil {
 		return errp.WithStack(err)
@@ -287,6 +402,15 @@ func (account *BaseAccount) ExportCSV(w io.Writer, transactions []*TransactionDa
 		if transaction.Timestamp != nil {
 			timeString = transaction.Timestamp.Format(time.RFC3339)
 		}
+		internalTransfer := ""
+		if account.TxInternalTransfer(transaction.InternalID) != "" {
+			internalTransfer = "yes"
+		}
+		var originExchange, originOrderID string
+		if origin := account.TxOrigin(transaction.InternalID); origin != nil {
+			originExchange, originOrderID = origin.Exchange, origin.OrderID
+		}
+		fiat := account.exportFiat()
 		for _, addressAndAmount := range transaction.Addresses {
 			if transactionType == "sent" && addressAndAmount.Ours {
 				transactionType = "sent_to_yourself"
@@ -300,6 +424,11 @@ func (account *BaseAccount) ExportCSV(w io.Writer, transactions []*TransactionDa
 				addressAndAmount.Address,
 				transaction.TxID,
 				account.TxNote(transaction.InternalID),
+				originExchange,
+				originOrderID,
+				internalTransfer,
+				account.exportFiatValue(addressAndAmount.Amount, transaction.Timestamp, fiat),
+				fiat,
 			})
 			if err != nil {
 				return errp.WithStack(err)
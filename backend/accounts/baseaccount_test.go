@@ -16,16 +16,20 @@ package accounts
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"os"
 	"path"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
@@ -120,6 +124,12 @@ func TestBaseAccount(t *testing.T) {
 		SmallestUnitFunc: func() string {
 			return "satoshi"
 		},
+		DecimalsFunc: func(isFee bool) uint {
+			return 8
+		},
+		UnitFunc: func(isFee bool) string {
+			return "TBTC"
+		},
 	}
 	account := NewBaseAccount(cfg, mockCoin, logging.Get().WithGroup("baseaccount_test"))
 	require.NoError(t, account.Initialize(accountIdentifier))
@@ -201,17 +211,19 @@ func TestBaseAccount(t *testing.T) {
 			return result.String()
 		}
 
-		const header = "Time,Type,Amount,Unit,Fee,Address,Transaction ID,Note\n"
+		const header = "Time,Type,Amount,Unit,Fee,Address,Transaction ID,Note,Origin,Origin order ID,Internal transfer,Fiat value,Fiat currency\n"
 
 		require.Equal(t, header, export(nil))
 
 		require.NoError(t, account.SetTxNote("some-internal-tx-id", "some note, with a comma"))
+		require.NoError(t, account.SetTxOrigin("some-internal-tx-id", &notes.TxOrigin{Exchange: "Kraken", OrderID: "W-1"}))
+		require.NoError(t, account.SetTxInternalTransfer("some-internal-tx-id", "other-account"))
 		fee := coin.NewAmountFromInt64(101)
 		timestamp := time.Date(2020, 2, 30, 16, 44, 20, 0, time.UTC)
 		require.Equal(t,
 			header+
-				`2020-03-01T16:44:20Z,sent,123,satoshi,101,some-address,some-tx-id,"some note, with a comma"
-2020-03-01T16:44:20Z,sent_to_yourself,456,satoshi,,another-address,some-tx-id,"some note, with a comma"
+				`2020-03-01T16:44:20Z,sent,123,satoshi,101,some-address,some-tx-id,"some note, with a comma",Kraken,W-1,yes,,
+2020-03-01T16:44:20Z,sent_to_yourself,456,satoshi,,another-address,some-tx-id,"some note, with a comma",Kraken,W-1,yes,,
 `,
 			export([]*TransactionData{
 				{
@@ -236,4 +248,148 @@ func TestBaseAccount(t *testing.T) {
 			}))
 
 	})
+
+	t.Run("export", func(t *testing.T) {
+		export := func(format ExportFormat, transactions []*TransactionData) string {
+			var result bytes.Buffer
+			require.NoError(t, account.Export(&result, format, transactions))
+			return result.String()
+		}
+
+		require.NoError(t, account.SetTxNote("export-receive", "salary\nMarch"))
+		fee := coin.NewAmountFromInt64(1000)
+		timestamp := time.Date(2024, 3, 1, 16, 44, 20, 0, time.UTC)
+		transactions := []*TransactionData{
+			{
+				Type:       TxTypeReceive,
+				Status:     TxStatusComplete,
+				TxID:       "receive-tx-id",
+				InternalID: "export-receive",
+				Timestamp:  &timestamp,
+				Amount:     coin.NewAmountFromInt64(150000000),
+				Addresses: []AddressAndAmount{
+					{Address: "our-address", Amount: coin.NewAmountFromInt64(150000000), Ours: true},
+				},
+			},
+			{
+				Type:       TxTypeSend,
+				Status:     TxStatusComplete,
+				TxID:       "send-tx-id",
+				InternalID: "export-send",
+				Timestamp:  &timestamp,
+				Fee:        &fee,
+				Amount:     coin.NewAmountFromInt64(5000),
+				Addresses: []AddressAndAmount{
+					{Address: "<their-address>", Amount: coin.NewAmountFromInt64(5000), Ours: false},
+				},
+			},
+			{
+				// Unconfirmed and undated, skipped in OFX and QIF.
+				Type:       TxTypeReceive,
+				Status:     TxStatusPending,
+				TxID:       "pending-tx-id",
+				InternalID: "export-pending",
+				Amount:     coin.NewAmountFromInt64(1),
+			},
+		}
+
+		require.Equal(t, ExportFormatCSV, ExportFormat("csv"))
+		require.True(t, ExportFormatQIF.Valid())
+		require.False(t, ExportFormat("xls").Valid())
+		require.Error(t, account.Export(&bytes.Buffer{}, "xls", transactions))
+
+		var jsonResult map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(export(ExportFormatJSON, transactions)), &jsonResult))
+		require.Equal(t, "Test", jsonResult["account"])
+		require.Equal(t, "TBTC", jsonResult["unit"])
+		jsonTransactions := jsonResult["transactions"].([]interface{})
+		require.Len(t, jsonTransactions, 3)
+		require.Equal(t, map[string]interface{}{
+			"time":    "2024-03-01T16:44:20Z",
+			"type":    "sent",
+			"status":  "complete",
+			"amount":  "0.00005000",
+			"fee":     "0.00001000",
+			"feeUnit": "TBTC",
+			"txID":    "send-tx-id",
+			"note":    "",
+			"addresses": []interface{}{
+				map[string]interface{}{"address": "<their-address>", "amount": "0.00005000", "ours": false},
+			},
+		}, jsonTransactions[1])
+		require.Nil(t, jsonTransactions[2].(map[string]interface{})["time"])
+
+		require.Equal(t, `!Type:Bank
+D03/01/2024
+T1.50000000
+Pour-address
+Nreceive-tx-id
+Msalary March
+^
+D03/01/2024
+T-0.00006000
+P<their-address>
+Nsend-tx-id
+^
+`, export(ExportFormatQIF, transactions))
+
+		ofx := export(ExportFormatOFX, transactions)
+		require.True(t, strings.HasPrefix(ofx, "OFXHEADER:100\n"))
+		require.Contains(t, ofx, "<CURDEF>TBTC\n")
+		require.Contains(t, ofx, "<ACCTID>test\n")
+		require.Contains(t, ofx, "<DTSTART>20240301164420\n<DTEND>20240301164420\n")
+		require.Contains(t, ofx, `<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240301164420
+<TRNAMT>1.50000000
+<FITID>export-receive
+<NAME>our-address
+<MEMO>salary March
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240301164420
+<TRNAMT>-0.00006000
+<FITID>export-send
+<NAME>&lt;their-address&gt;
+</STMTTRN>
+</BANKTRANLIST>`)
+		require.NotContains(t, ofx, "export-pending")
+	})
+}
+
+func TestExportFiatValue(t *testing.T) {
+	ratesUpdater := rates.MockRateUpdater()
+	defer ratesUpdater.Stop()
+	appConfig := config.NewDefaultAppConfig()
+	account := NewBaseAccount(&AccountConfig{
+		Config:       &config.Account{Code: "btc"},
+		RateUpdater:  ratesUpdater,
+		GetAppConfig: func() config.AppConfig { return appConfig },
+	}, &mocks.CoinMock{
+		CodeFunc: func() coin.Code { return coin.CodeBTC },
+		ToUnitFunc: func(amount coin.Amount, isFee bool) float64 {
+			return float64(amount.BigInt().Int64()) / 1e8
+		},
+	}, logging.Get().WithGroup("baseaccount_test"))
+
+	fiat := account.exportFiat()
+	require.Equal(t, "USD", fiat)
+	at := time.Unix(1598918700, 0)
+	amount := coin.NewAmountFromInt64(150000000)
+	require.Equal(t, "3.00", account.exportFiatValue(amount, &at, fiat))
+
+	// No rate known at the time.
+	before := time.Unix(1500000000, 0)
+	require.Equal(t, "", account.exportFiatValue(amount, &before, fiat))
+	require.Equal(t, "", account.exportFiatValue(amount, nil, fiat))
+}
+
+func TestAccountConfigAppConfig(t *testing.T) {
+	require.Equal(t, config.NewDefaultAppConfig(), (&AccountConfig{}).AppConfig())
+
+	appConfig := config.NewDefaultAppConfig()
+	appConfig.Backend.SpendUnconfirmedChange = false
+	accountConfig := &AccountConfig{GetAppConfig: func() config.AppConfig { return appConfig }}
+	require.False(t, accountConfig.AppConfig().Backend.SpendUnconfirmedChange)
 }
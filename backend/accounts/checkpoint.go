@@ -0,0 +1,111 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// checkpointMaxTransactions is the number of most recent transactions kept in a checkpoint.
+const checkpointMaxTransactions = 100
+
+// Checkpoint is the last known balance and the most recent transactions of an account. It is
+// persisted after each sync, so that it can be shown right away when the app starts, while the
+// account is still syncing.
+type Checkpoint struct {
+	Available coin.Amount `json:"available"`
+	Incoming  coin.Amount `json:"incoming"`
+	// Transactions are the most recent transactions, newest first.
+	Transactions OrderedTransactions `json:"transactions"`
+	// CreatedAt is when the account was synced.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Balance returns the balance of the checkpoint.
+func (checkpoint *Checkpoint) Balance() *Balance {
+	return NewBalance(checkpoint.Available, checkpoint.Incoming)
+}
+
+func readCheckpoint(filename string) (*Checkpoint, error) {
+	jsonBytes, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errp.WithStack(err)
+	}
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(jsonBytes, &checkpoint); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return &checkpoint, nil
+}
+
+func writeCheckpoint(checkpoint *Checkpoint, filename string) error {
+	jsonBytes, err := json.Marshal(checkpoint)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	return errp.WithStack(os.WriteFile(filename, jsonBytes, 0600))
+}
+
+// loadCheckpoint loads the persisted checkpoint. A checkpoint which can't be read is ignored, as
+// it is only a cache.
+func (account *BaseAccount) loadCheckpoint(filename string) {
+	account.checkpointMu.Lock()
+	defer account.checkpointMu.Unlock()
+	account.checkpointFilename = filename
+	checkpoint, err := readCheckpoint(filename)
+	if err != nil {
+		account.log.WithError(err).Error("Could not read the account checkpoint")
+		return
+	}
+	account.checkpoint = checkpoint
+}
+
+// SaveCheckpoint implements accounts.Interface.
+func (account *BaseAccount) SaveCheckpoint(balance *Balance, transactions OrderedTransactions) error {
+	if len(transactions) > checkpointMaxTransactions {
+		transactions = transactions[:checkpointMaxTransactions]
+	}
+	checkpoint := &Checkpoint{
+		Available:    balance.Available(),
+		Incoming:     balance.Incoming(),
+		Transactions: transactions,
+		CreatedAt:    time.Now(),
+	}
+	account.checkpointMu.Lock()
+	defer account.checkpointMu.Unlock()
+	if account.checkpointFilename == "" {
+		return errp.New("account not initialized")
+	}
+	if err := writeCheckpoint(checkpoint, account.checkpointFilename); err != nil {
+		return err
+	}
+	account.checkpoint = checkpoint
+	return nil
+}
+
+// Checkpoint implements accounts.Interface.
+func (account *BaseAccount) Checkpoint() *Checkpoint {
+	account.checkpointMu.Lock()
+	defer account.checkpointMu.Unlock()
+	return account.checkpoint
+}
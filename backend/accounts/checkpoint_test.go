@@ -0,0 +1,84 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpoint(t *testing.T) {
+	cfg := &AccountConfig{
+		Config:      &config.Account{Code: "btc-1"},
+		DBFolder:    test.TstTempDir("checkpoint_test_dbfolder"),
+		NotesFolder: test.TstTempDir("checkpoint_test_notesfolder"),
+		OnEvent:     func(types.Event) {},
+	}
+	newAccount := func() *BaseAccount {
+		account := NewBaseAccount(cfg, nil, logging.Get().WithGroup("checkpoint_test"))
+		require.NoError(t, account.Initialize("account-btc-1"))
+		return account
+	}
+
+	account := newAccount()
+	require.Nil(t, account.Checkpoint())
+
+	timestamp := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fee := coin.NewAmountFromInt64(100)
+	transactions := OrderedTransactions{}
+	for i := 0; i < checkpointMaxTransactions+5; i++ {
+		transactions = append(transactions, &TransactionData{
+			TxID:       fmt.Sprintf("tx-%d", i),
+			InternalID: fmt.Sprintf("tx-%d", i),
+			Type:       TxTypeReceive,
+			Status:     TxStatusComplete,
+			Amount:     coin.NewAmountFromInt64(int64(1000 + i)),
+			Fee:        &fee,
+			Timestamp:  &timestamp,
+			Addresses:  []AddressAndAmount{{Address: "address", Amount: coin.NewAmountFromInt64(1000), Ours: true}},
+		})
+	}
+	balance := NewBalance(coin.NewAmountFromInt64(123456), coin.NewAmountFromInt64(789))
+	require.NoError(t, account.SaveCheckpoint(balance, transactions))
+	require.Equal(t, balance, account.Checkpoint().Balance())
+
+	// The checkpoint is loaded by the account recreated in the next session.
+	checkpoint := newAccount().Checkpoint()
+	require.NotNil(t, checkpoint)
+	require.Equal(t, int64(123456), checkpoint.Balance().Available().BigInt().Int64())
+	require.Equal(t, int64(789), checkpoint.Balance().Incoming().BigInt().Int64())
+	require.Len(t, checkpoint.Transactions, checkpointMaxTransactions)
+	tx := checkpoint.Transactions[1]
+	require.Equal(t, "tx-1", tx.TxID)
+	require.Equal(t, TxTypeReceive, tx.Type)
+	require.Equal(t, TxStatusComplete, tx.Status)
+	require.Equal(t, int64(1001), tx.Amount.BigInt().Int64())
+	require.Equal(t, int64(100), tx.Fee.BigInt().Int64())
+	require.True(t, timestamp.Equal(*tx.Timestamp))
+	require.Equal(t, transactions[1].Addresses[0].Address, tx.Addresses[0].Address)
+	require.True(t, tx.Addresses[0].Ours)
+
+	// Checkpoints can only be saved after the account is initialized.
+	uninitialized := NewBaseAccount(cfg, nil, logging.Get().WithGroup("checkpoint_test"))
+	require.Error(t, uninitialized.SaveCheckpoint(balance, transactions))
+}
@@ -31,6 +31,21 @@ var (
 	// ErrInvalidAddress is used when the recipient address is invalid or does not match the correct
 	// network.
 	ErrInvalidAddress = TxValidationError("invalidAddress")
+	// ErrInvalidAddressWrongNetwork is used when the recipient address is valid, but for a different
+	// network, e.g. a testnet address in a mainnet account.
+	ErrInvalidAddressWrongNetwork = TxValidationError("invalidAddressWrongNetwork")
+	// ErrInvalidAddressBech32Variant is used when a segwit address is encoded with the wrong bech32
+	// variant. Segwit v0 addresses must use bech32, segwit v1+ (e.g. Taproot) must use bech32m.
+	ErrInvalidAddressBech32Variant = TxValidationError("invalidAddressBech32Variant")
+	// ErrInvalidAddressEthereum is used when an Ethereum address is entered in a Bitcoin-based
+	// account.
+	ErrInvalidAddressEthereum = TxValidationError("invalidAddressEthereum")
+	// ErrInvalidAddressTaprootNotSupported is used when a Taproot address is entered for a coin
+	// that did not activate Taproot.
+	ErrInvalidAddressTaprootNotSupported = TxValidationError("invalidAddressTaprootNotSupported")
+	// ErrInvalidAddressOtherCoin is used when the recipient address is invalid for the account, but
+	// valid for a different loaded coin, e.g. a LTC address in a BTC account.
+	ErrInvalidAddressOtherCoin = TxValidationError("invalidAddressOtherCoin")
 	// ErrInvalidAmount is used when the user entered amount is malformatted or not positive.
 	ErrInvalidAmount = TxValidationError("invalidAmount")
 	// ErrInsufficientFunds is returned when there are not enough funds to cover the target amount
@@ -39,6 +54,40 @@ var (
 	// ErrFeeTooLow is returned when the custom fee the user entered is too low to be able to
 	// broadcast the transaction.
 	ErrFeeTooLow = TxValidationError("feeTooLow")
+	// ErrInvalidFeeRate is returned when the custom fee the user entered is not a valid decimal
+	// number, or is more precise than supported.
+	ErrInvalidFeeRate = TxValidationError("invalidFeeRate")
+	// ErrFeeTooHigh is returned when the fee is much higher than the economical fee estimate or
+	// than a large share of the sent amount, which usually points to a malformed custom fee. The
+	// user can explicitly allow it, see TxProposalArgs.AllowHighFee.
+	ErrFeeTooHigh = TxValidationError("feeTooHigh")
+	// ErrSelectedUTXOUnavailable is returned when one of the coins selected to fund the tx can't be
+	// spent, e.g. because it was spent already, because it is unconfirmed change and spending
+	// unconfirmed change is disabled, or because it is reserved by another pending proposal.
+	ErrSelectedUTXOUnavailable = TxValidationError("selectedUTXOUnavailable")
+	// ErrDustAmount is returned when an output of the transaction is worth less than what it costs
+	// to spend it, so that the network would not relay the transaction.
+	ErrDustAmount = TxValidationError("dustAmount")
+	// ErrTxTooLarge is returned when the transaction exceeds the max standard transaction weight,
+	// e.g. because it spends too many coins, so that the network would not relay it.
+	ErrTxTooLarge = TxValidationError("txTooLarge")
+	// ErrPSBTInvalid is returned when an imported PSBT can't be parsed or its signatures are
+	// invalid.
+	ErrPSBTInvalid = TxValidationError("psbtInvalid")
+	// ErrPSBTForeignInput is returned when an imported PSBT spends a coin which is not a spendable
+	// coin of the account.
+	ErrPSBTForeignInput = TxValidationError("psbtForeignInput")
+	// ErrPSBTIncomplete is returned when an imported PSBT is missing signatures.
+	ErrPSBTIncomplete = TxValidationError("psbtIncomplete")
+	// ErrMultisigCosigning is returned when a transaction of a multisig account is to be sent
+	// directly. It needs the signatures of the cosigners, which are collected in a PSBT.
+	ErrMultisigCosigning = TxValidationError("multisigCosigning")
+	// ErrFeeBumpUnavailable is returned when the fee of a transaction can't be bumped, e.g. because
+	// it is confirmed, does not signal replace-by-fee or spends coins of others.
+	ErrFeeBumpUnavailable = TxValidationError("feeBumpUnavailable")
+	// ErrFeeBumpTooLow is returned when the fee rate of the replacement is not higher than the one
+	// of the transaction to be replaced.
+	ErrFeeBumpTooLow = TxValidationError("feeBumpTooLow")
 	// ErrAccountNotsynced is used when the account sync has not successfully finished.
 	ErrAccountNotsynced = TxValidationError("accountNotSynced")
 
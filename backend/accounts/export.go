@@ -0,0 +1,334 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// ExportFormat is a file format in which the transactions of an account can be exported.
+type ExportFormat string
+
+const (
+	// ExportFormatCSV is a comma-separated file with one row per transaction output, see
+	// ExportCSV().
+	ExportFormatCSV ExportFormat = "csv"
+	// ExportFormatJSON is a structured JSON document with one entry per transaction.
+	ExportFormatJSON ExportFormat = "json"
+	// ExportFormatOFX is an Open Financial Exchange bank statement, which can be imported by
+	// accounting software like GnuCash or Quicken.
+	ExportFormatOFX ExportFormat = "ofx"
+	// ExportFormatQIF is a Quicken Interchange Format file, an older format still supported by
+	// most accounting software.
+	ExportFormatQIF ExportFormat = "qif"
+)
+
+// Valid returns true if the format is one of the supported export formats.
+func (format ExportFormat) Valid() bool {
+	switch format {
+	case ExportFormatCSV, ExportFormatJSON, ExportFormatOFX, ExportFormatQIF:
+		return true
+	}
+	return false
+}
+
+// exportTxTypes are the names of the transaction types in the exports.
+var exportTxTypes = map[TxType]string{
+	TxTypeReceive:  "received",
+	TxTypeSend:     "sent",
+	TxTypeSendSelf: "sent_to_yourself",
+}
+
+// formatExportAmount formats the amount in the standard unit of the coin, e.g. "0.00100000" for
+// 100000 satoshi. Unlike coin.FormatAmount(), the result does not depend on the unit chosen by
+// the user, so that the exports can be imported reliably.
+func formatExportAmount(c coin.Coin, amount *big.Int, isFee bool) string {
+	decimals := c.Decimals(isFee)
+	exp := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	return new(big.Rat).SetFrac(amount, exp).FloatString(int(decimals))
+}
+
+// exportTimestamp returns the confirmation time of the transaction, or the time it was created if
+// it is not confirmed yet. nil if neither is known.
+func exportTimestamp(transaction *TransactionData) *time.Time {
+	if transaction.Timestamp != nil {
+		return transaction.Timestamp
+	}
+	return transaction.CreatedTimestamp
+}
+
+// exportFiat returns the main fiat currency, in which the exports state the fiat values.
+func (account *BaseAccount) exportFiat() string {
+	if account.config.GetAppConfig == nil {
+		return ""
+	}
+	return account.config.GetAppConfig().Backend.MainFiat
+}
+
+// exportFiatValue returns the value of the amount in the fiat currency at the given time, or the
+// empty string if the historical rate is not known.
+func (account *BaseAccount) exportFiatValue(amount coin.Amount, at *time.Time, fiat string) string {
+	if at == nil || fiat == "" || account.config.RateUpdater == nil {
+		return ""
+	}
+	rate := account.config.RateUpdater.HistoricalPriceAt(string(account.Coin().Code()), fiat, *at)
+	if rate == 0 {
+		return ""
+	}
+	value := new(big.Rat).Mul(
+		new(big.Rat).SetFloat64(account.Coin().ToUnit(amount, false)),
+		new(big.Rat).SetFloat64(rate))
+	return coin.FormatAsPlainCurrency(value, fiat)
+}
+
+// Export implements accounts.Account.
+func (account *BaseAccount) Export(w io.Writer, format ExportFormat, transactions []*TransactionData) error {
+	switch format {
+	case ExportFormatCSV:
+		return account.ExportCSV(w, transactions)
+	case ExportFormatJSON:
+		return account.exportJSON(w, transactions)
+	case ExportFormatOFX:
+		return account.exportOFX(w, transactions)
+	case ExportFormatQIF:
+		return account.exportQIF(w, transactions)
+	default:
+		return errp.Newf("unknown export format %q", format)
+	}
+}
+
+func (account *BaseAccount) exportJSON(w io.Writer, transactions []*TransactionData) error {
+	type jsonAddress struct {
+		Address string `json:"address"`
+		Amount  string `json:"amount"`
+		Ours    bool   `json:"ours"`
+		Label   string `json:"label,omitempty"`
+	}
+	type jsonTransaction struct {
+		Time             *time.Time      `json:"time"`
+		Type             string          `json:"type"`
+		Status           TxStatus        `json:"status"`
+		Amount           string          `json:"amount"`
+		Fee              *string         `json:"fee"`
+		FeeUnit          string          `json:"feeUnit"`
+		TxID             string          `json:"txID"`
+		Note             string          `json:"note"`
+		Category         string          `json:"category,omitempty"`
+		Origin           *notes.TxOrigin `json:"origin,omitempty"`
+		InternalTransfer types.Code      `json:"internalTransfer,omitempty"`
+		Addresses        []jsonAddress   `json:"addresses"`
+	}
+	type jsonExport struct {
+		Account      string            `json:"account"`
+		Coin         coin.Code         `json:"coin"`
+		Unit         string            `json:"unit"`
+		Transactions []jsonTransaction `json:"transactions"`
+	}
+	c := account.Coin()
+	result := jsonExport{
+		Account:      account.Config().Config.Name,
+		Coin:         c.Code(),
+		Unit:         c.Unit(false),
+		Transactions: []jsonTransaction{},
+	}
+	for _, transaction := range transactions {
+		var fee *string
+		if transaction.Fee != nil {
+			formatted := formatExportAmount(c, transaction.Fee.BigInt(), transaction.FeeIsDifferentUnit)
+			fee = &formatted
+		}
+		addresses := make([]jsonAddress, len(transaction.Addresses))
+		for i, addressAndAmount := range transaction.Addresses {
+			addresses[i] = jsonAddress{
+				Address: addressAndAmount.Address,
+				Amount:  formatExportAmount(c, addressAndAmount.Amount.BigInt(), false),
+				Ours:    addressAndAmount.Ours,
+				Label:   account.AddressLabel(addressAndAmount.Address),
+			}
+		}
+		result.Transactions = append(result.Transactions, jsonTransaction{
+			Time:             exportTimestamp(transaction),
+			Type:             exportTxTypes[transaction.Type],
+			Status:           transaction.Status,
+			Amount:           formatExportAmount(c, transaction.Amount.BigInt(), false),
+			Fee:              fee,
+			FeeUnit:          c.Unit(transaction.FeeIsDifferentUnit),
+			TxID:             transaction.TxID,
+			Note:             account.TxNote(transaction.InternalID),
+			Category:         account.TxCategory(transaction.InternalID),
+			Origin:           account.TxOrigin(transaction.InternalID),
+			InternalTransfer: account.TxInternalTransfer(transaction.InternalID),
+			Addresses:        addresses,
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return errp.WithStack(encoder.Encode(result))
+}
+
+// exportPayee returns the counterparty shown in the OFX and QIF exports: the first address which
+// is not ours, or the first address for transactions to ourselves.
+func exportPayee(transaction *TransactionData) string {
+	for _, addressAndAmount := range transaction.Addresses {
+		if !addressAndAmount.Ours {
+			return addressAndAmount.Address
+		}
+	}
+	if len(transaction.Addresses) > 0 {
+		return transaction.Addresses[0].Address
+	}
+	return ""
+}
+
+// ofxEscape escapes the characters which are not allowed in OFX element values.
+var ofxEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\n", " ", "\r", "")
+
+// ofxMaxNameLen is the maximum length of the NAME element of a statement transaction.
+const ofxMaxNameLen = 32
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405")
+}
+
+// exportOFX writes an OFX 1.0.2 bank statement. Amounts are in the standard unit of the coin,
+// which is used as the currency of the statement. Transactions which are neither confirmed nor
+// have a known creation time are skipped, as they cannot be dated.
+func (account *BaseAccount) exportOFX(w io.Writer, transactions []*TransactionData) error {
+	c := account.Coin()
+	var start, end *time.Time
+	var body strings.Builder
+	for _, transaction := range transactions {
+		timestamp := exportTimestamp(transaction)
+		if timestamp == nil {
+			continue
+		}
+		if start == nil || timestamp.Before(*start) {
+			start = timestamp
+		}
+		if end == nil || timestamp.After(*end) {
+			end = timestamp
+		}
+		change := transaction.balanceChange()
+		trnType := "CREDIT"
+		if change.Sign() < 0 {
+			trnType = "DEBIT"
+		}
+		name := ofxEscape.Replace(exportPayee(transaction))
+		if len(name) > ofxMaxNameLen {
+			name = name[:ofxMaxNameLen]
+		}
+		fmt.Fprintf(&body, "<STMTTRN>\n<TRNTYPE>%s\n<DTPOSTED>%s\n<TRNAMT>%s\n<FITID>%s\n<NAME>%s\n",
+			trnType, ofxTime(*timestamp), formatExportAmount(c, change, false),
+			ofxEscape.Replace(transaction.InternalID), name)
+		if note := account.TxNote(transaction.InternalID); note != "" {
+			fmt.Fprintf(&body, "<MEMO>%s\n", ofxEscape.Replace(note))
+		}
+		body.WriteString("</STMTTRN>\n")
+	}
+	now := time.Now()
+	if start == nil {
+		start, end = &now, &now
+	}
+	_, err := fmt.Fprintf(w, `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+SECURITY:NONE
+ENCODING:UTF-8
+CHARSET:NONE
+COMPRESSION:NONE
+OLDFILEUID:NONE
+NEWFILEUID:NONE
+
+<OFX>
+<SIGNONMSGSRSV1>
+<SONRS>
+<STATUS>
+<CODE>0
+<SEVERITY>INFO
+</STATUS>
+<DTSERVER>%s
+<LANGUAGE>ENG
+</SONRS>
+</SIGNONMSGSRSV1>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<TRNUID>0
+<STATUS>
+<CODE>0
+<SEVERITY>INFO
+</STATUS>
+<STMTRS>
+<CURDEF>%s
+<BANKACCTFROM>
+<BANKID>BitBoxApp
+<ACCTID>%s
+<ACCTTYPE>CHECKING
+</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>%s
+<DTEND>%s
+%s</BANKTRANLIST>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`,
+		ofxTime(now),
+		ofxEscape.Replace(c.Unit(false)),
+		ofxEscape.Replace(string(account.Config().Config.Code)),
+		ofxTime(*start),
+		ofxTime(*end),
+		body.String(),
+	)
+	return errp.WithStack(err)
+}
+
+// qifEscape removes line breaks, as every line of a QIF file is one field.
+var qifEscape = strings.NewReplacer("\n", " ", "\r", "")
+
+// exportQIF writes a QIF bank account file. Amounts are in the standard unit of the coin.
+// Transactions which cannot be dated are skipped, see exportOFX().
+func (account *BaseAccount) exportQIF(w io.Writer, transactions []*TransactionData) error {
+	var result strings.Builder
+	result.WriteString("!Type:Bank\n")
+	for _, transaction := range transactions {
+		timestamp := exportTimestamp(transaction)
+		if timestamp == nil {
+			continue
+		}
+		fmt.Fprintf(&result, "D%s\nT%s\nP%s\nN%s\n",
+			timestamp.UTC().Format("01/02/2006"),
+			formatExportAmount(account.Coin(), transaction.balanceChange(), false),
+			qifEscape.Replace(exportPayee(transaction)),
+			qifEscape.Replace(transaction.TxID),
+		)
+		if note := account.TxNote(transaction.InternalID); note != "" {
+			fmt.Fprintf(&result, "M%s\n", qifEscape.Replace(note))
+		}
+		result.WriteString("^\n")
+	}
+	_, err := io.WriteString(w, result.String())
+	return errp.WithStack(err)
+}
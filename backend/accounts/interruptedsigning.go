@@ -0,0 +1,132 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts
+
+import (
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+)
+
+// interruptedSigningExpiry is how long an interrupted signing can be resumed. Older proposals are
+// dropped, so that a stale proposal is not signed long after the user moved on.
+const interruptedSigningExpiry = 10 * time.Minute
+
+type interruptedSigning struct {
+	proposal    interface{}
+	note        string
+	interrupted time.Time
+}
+
+// InterruptedSignings keeps the tx proposals whose signing was interrupted because the keystore
+// disconnected, so that they can be signed once it is connected again. The accounts are recreated
+// when the keystore reconnects, so the proposals are kept outside of them, by account code.
+//
+// A nil *InterruptedSignings keeps nothing.
+type InterruptedSignings struct {
+	signings map[types.Code]interruptedSigning
+	lock     locker.Locker
+	// now returns the current time, can be replaced in tests.
+	now func() time.Time
+}
+
+// NewInterruptedSignings creates a new InterruptedSignings instance.
+func NewInterruptedSignings() *InterruptedSignings {
+	return &InterruptedSignings{
+		signings: map[types.Code]interruptedSigning{},
+		now:      time.Now,
+	}
+}
+
+// expired returns true if the signing can't be resumed anymore, see interruptedSigningExpiry.
+func (s *InterruptedSignings) expired(signing interruptedSigning) bool {
+	return s.now().Sub(signing.interrupted) > interruptedSigningExpiry
+}
+
+func (s *InterruptedSignings) store(code types.Code, signing interruptedSigning) {
+	if s == nil {
+		return
+	}
+	defer s.lock.Lock()()
+	signing.interrupted = s.now()
+	s.signings[code] = signing
+}
+
+// take removes and returns the interrupted signing of the account. An expired signing is removed,
+// but not returned.
+func (s *InterruptedSignings) take(code types.Code) (interruptedSigning, bool) {
+	if s == nil {
+		return interruptedSigning{}, false
+	}
+	defer s.lock.Lock()()
+	signing, ok := s.signings[code]
+	delete(s.signings, code)
+	if ok && s.expired(signing) {
+		return interruptedSigning{}, false
+	}
+	return signing, ok
+}
+
+func (s *InterruptedSignings) has(code types.Code) bool {
+	if s == nil {
+		return false
+	}
+	defer s.lock.RLock()()
+	signing, ok := s.signings[code]
+	return ok && !s.expired(signing)
+}
+
+// SigningInterrupted keeps the tx proposal, whose signing failed because the keystore disconnected,
+// and the proposed tx note, and fires EventSigningInterrupted. The proposal is returned by
+// TakeInterruptedProposal(), also by the account recreated when the keystore reconnects.
+func (account *BaseAccount) SigningInterrupted(proposal interface{}) {
+	account.proposedTxNoteMu.Lock()
+	note := account.proposedTxNote
+	account.proposedTxNoteMu.Unlock()
+	account.config.InterruptedSignings.store(account.config.Config.Code, interruptedSigning{
+		proposal: proposal,
+		note:     note,
+	})
+	account.log.Info("Signing interrupted, the tx proposal is kept to resume signing")
+	account.config.OnEvent(types.EventSigningInterrupted)
+}
+
+// TakeInterruptedProposal returns and forgets the proposal kept by SigningInterrupted(). Unless a
+// note was proposed since, its proposed tx note is restored. Returns nil if there is none, or if
+// it expired.
+func (account *BaseAccount) TakeInterruptedProposal() interface{} {
+	signing, ok := account.config.InterruptedSignings.take(account.config.Config.Code)
+	if !ok {
+		return nil
+	}
+	account.proposedTxNoteMu.Lock()
+	defer account.proposedTxNoteMu.Unlock()
+	if account.proposedTxNote == "" {
+		account.proposedTxNote = signing.note
+	}
+	return signing.proposal
+}
+
+// DropInterruptedProposal forgets the proposal kept by SigningInterrupted(), if any. It is called
+// when a new tx is proposed, which replaces the interrupted one.
+func (account *BaseAccount) DropInterruptedProposal() {
+	account.config.InterruptedSignings.take(account.config.Config.Code)
+}
+
+// HasInterruptedSigning implements accounts.Interface.
+func (account *BaseAccount) HasInterruptedSigning() bool {
+	return account.config.InterruptedSignings.has(account.config.Config.Code)
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterruptedSigning(t *testing.T) {
+	interruptedSignings := NewInterruptedSignings()
+	var events []types.Event
+	newAccount := func(code types.Code) *BaseAccount {
+		return NewBaseAccount(&AccountConfig{
+			Config:              &config.Account{Code: code},
+			OnEvent:             func(event types.Event) { events = append(events, event) },
+			InterruptedSignings: interruptedSignings,
+		}, nil, logging.Get().WithGroup("interruptedsigning_test"))
+	}
+
+	account := newAccount("btc-1")
+	require.False(t, account.HasInterruptedSigning())
+	require.Nil(t, account.TakeInterruptedProposal())
+
+	proposal := &struct{ amount int }{amount: 42}
+	account.ProposeTxNote("rent")
+	account.SigningInterrupted(proposal)
+	require.Equal(t, []types.Event{types.EventSigningInterrupted}, events)
+	require.True(t, account.HasInterruptedSigning())
+	require.False(t, newAccount("btc-2").HasInterruptedSigning())
+
+	// The account is recreated when the keystore reconnects.
+	account = newAccount("btc-1")
+	require.True(t, account.HasInterruptedSigning())
+	require.Equal(t, proposal, account.TakeInterruptedProposal())
+	require.Equal(t, "rent", account.GetAndClearProposedTxNote())
+	require.False(t, account.HasInterruptedSigning())
+	require.Nil(t, account.TakeInterruptedProposal())
+
+	// A note proposed since is not overwritten.
+	account.ProposeTxNote("rent")
+	account.SigningInterrupted(proposal)
+	account = newAccount("btc-1")
+	account.ProposeTxNote("groceries")
+	require.Equal(t, proposal, account.TakeInterruptedProposal())
+	require.Equal(t, "groceries", account.GetAndClearProposedTxNote())
+
+	// A new tx proposal replaces the interrupted one.
+	account.SigningInterrupted(proposal)
+	account = newAccount("btc-1")
+	account.DropInterruptedProposal()
+	require.False(t, account.HasInterruptedSigning())
+	require.Nil(t, account.TakeInterruptedProposal())
+
+	// An interrupted signing expires.
+	now := time.Now()
+	interruptedSignings.now = func() time.Time { return now }
+	account.SigningInterrupted(proposal)
+	now = now.Add(interruptedSigningExpiry)
+	require.True(t, account.HasInterruptedSigning())
+	now = now.Add(time.Second)
+	require.False(t, account.HasInterruptedSigning())
+	require.Nil(t, account.TakeInterruptedProposal())
+
+	// Without a store, nothing is kept.
+	account = NewBaseAccount(&AccountConfig{
+		Config:  &config.Account{Code: "btc-1"},
+		OnEvent: func(types.Event) {},
+	}, nil, logging.Get().WithGroup("interruptedsigning_test"))
+	account.SigningInterrupted(proposal)
+	require.False(t, account.HasInterruptedSigning())
+	require.Nil(t, account.TakeInterruptedProposal())
+}
@@ -5,6 +5,8 @@ package mocks
 
 import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
 	"io"
@@ -21,12 +23,18 @@ var _ accounts.Interface = &InterfaceMock{}
 //
 //		// make and configure a mocked accounts.Interface
 //		mockedInterface := &InterfaceMock{
+//			AddressLabelFunc: func(address string) string {
+//				panic("mock out the AddressLabel method")
+//			},
 //			BalanceFunc: func() (*accounts.Balance, error) {
 //				panic("mock out the Balance method")
 //			},
 //			CanVerifyAddressesFunc: func() (bool, bool, error) {
 //				panic("mock out the CanVerifyAddresses method")
 //			},
+//			CheckpointFunc: func() *accounts.Checkpoint {
+//				panic("mock out the Checkpoint method")
+//			},
 //			CloseFunc: func()  {
 //				panic("mock out the Close method")
 //			},
@@ -36,6 +44,9 @@ var _ accounts.Interface = &InterfaceMock{}
 //			ConfigFunc: func() *accounts.AccountConfig {
 //				panic("mock out the Config method")
 //			},
+//			ExportFunc: func(w io.Writer, format accounts.ExportFormat, transactions []*accounts.TransactionData) error {
+//				panic("mock out the Export method")
+//			},
 //			ExportCSVFunc: func(w io.Writer, transactions []*accounts.TransactionData) error {
 //				panic("mock out the ExportCSV method")
 //			},
@@ -51,6 +62,9 @@ var _ accounts.Interface = &InterfaceMock{}
 //			GetUnusedReceiveAddressesFunc: func() []accounts.AddressList {
 //				panic("mock out the GetUnusedReceiveAddresses method")
 //			},
+//			HasInterruptedSigningFunc: func() bool {
+//				panic("mock out the HasInterruptedSigning method")
+//			},
 //			InfoFunc: func() *accounts.Info {
 //				panic("mock out the Info method")
 //			},
@@ -69,24 +83,54 @@ var _ accounts.Interface = &InterfaceMock{}
 //			ProposeTxNoteFunc: func(s string)  {
 //				panic("mock out the ProposeTxNote method")
 //			},
+//			SaveCheckpointFunc: func(balance *accounts.Balance, transactions accounts.OrderedTransactions) error {
+//				panic("mock out the SaveCheckpoint method")
+//			},
 //			SendTxFunc: func() error {
 //				panic("mock out the SendTx method")
 //			},
+//			SetAddressLabelFunc: func(address string, label string) error {
+//				panic("mock out the SetAddressLabel method")
+//			},
+//			SetTxCategoryFunc: func(txID string, category string) error {
+//				panic("mock out the SetTxCategory method")
+//			},
+//			SetTxInternalTransferFunc: func(txID string, counterparty types.Code) error {
+//				panic("mock out the SetTxInternalTransfer method")
+//			},
 //			SetTxNoteFunc: func(txID string, note string) error {
 //				panic("mock out the SetTxNote method")
 //			},
+//			SetTxOriginFunc: func(txID string, origin *notes.TxOrigin) error {
+//				panic("mock out the SetTxOrigin method")
+//			},
+//			SetTxSpamFunc: func(txID string, spam bool) error {
+//				panic("mock out the SetTxSpam method")
+//			},
 //			SyncedFunc: func() bool {
 //				panic("mock out the Synced method")
 //			},
 //			TransactionsFunc: func() (accounts.OrderedTransactions, error) {
 //				panic("mock out the Transactions method")
 //			},
+//			TxCategoryFunc: func(txID string) string {
+//				panic("mock out the TxCategory method")
+//			},
+//			TxInternalTransferFunc: func(txID string) types.Code {
+//				panic("mock out the TxInternalTransfer method")
+//			},
 //			TxNoteFunc: func(txID string) string {
 //				panic("mock out the TxNote method")
 //			},
+//			TxOriginFunc: func(txID string) *notes.TxOrigin {
+//				panic("mock out the TxOrigin method")
+//			},
 //			TxProposalFunc: func(txProposalArgs *accounts.TxProposalArgs) (coin.Amount, coin.Amount, coin.Amount, error) {
 //				panic("mock out the TxProposal method")
 //			},
+//			TxSpamFunc: func(txID string) (bool, bool) {
+//				panic("mock out the TxSpam method")
+//			},
 //			VerifyAddressFunc: func(addressID string) (bool, error) {
 //				panic("mock out the VerifyAddress method")
 //			},
@@ -97,12 +141,18 @@ var _ accounts.Interface = &InterfaceMock{}
 //
 //	}
 type InterfaceMock struct {
+	// AddressLabelFunc mocks the AddressLabel method.
+	AddressLabelFunc func(address string) string
+
 	// BalanceFunc mocks the Balance method.
 	BalanceFunc func() (*accounts.Balance, error)
 
 	// CanVerifyAddressesFunc mocks the CanVerifyAddresses method.
 	CanVerifyAddressesFunc func() (bool, bool, error)
 
+	// CheckpointFunc mocks the Checkpoint method.
+	CheckpointFunc func() *accounts.Checkpoint
+
 	// CloseFunc mocks the Close method.
 	CloseFunc func()
 
@@ -112,6 +162,9 @@ type InterfaceMock struct {
 	// ConfigFunc mocks the Config method.
 	ConfigFunc func() *accounts.AccountConfig
 
+	// ExportFunc mocks the Export method.
+	ExportFunc func(w io.Writer, format accounts.ExportFormat, transactions []*accounts.TransactionData) error
+
 	// ExportCSVFunc mocks the ExportCSV method.
 	ExportCSVFunc func(w io.Writer, transactions []*accounts.TransactionData) error
 
@@ -127,6 +180,9 @@ type InterfaceMock struct {
 	// GetUnusedReceiveAddressesFunc mocks the GetUnusedReceiveAddresses method.
 	GetUnusedReceiveAddressesFunc func() []accounts.AddressList
 
+	// HasInterruptedSigningFunc mocks the HasInterruptedSigning method.
+	HasInterruptedSigningFunc func() bool
+
 	// InfoFunc mocks the Info method.
 	InfoFunc func() *accounts.Info
 
@@ -145,35 +201,73 @@ type InterfaceMock struct {
 	// ProposeTxNoteFunc mocks the ProposeTxNote method.
 	ProposeTxNoteFunc func(s string)
 
+	// SaveCheckpointFunc mocks the SaveCheckpoint method.
+	SaveCheckpointFunc func(balance *accounts.Balance, transactions accounts.OrderedTransactions) error
+
 	// SendTxFunc mocks the SendTx method.
 	SendTxFunc func() error
 
+	// SetAddressLabelFunc mocks the SetAddressLabel method.
+	SetAddressLabelFunc func(address string, label string) error
+
+	// SetTxCategoryFunc mocks the SetTxCategory method.
+	SetTxCategoryFunc func(txID string, category string) error
+
+	// SetTxInternalTransferFunc mocks the SetTxInternalTransfer method.
+	SetTxInternalTransferFunc func(txID string, counterparty types.Code) error
+
 	// SetTxNoteFunc mocks the SetTxNote method.
 	SetTxNoteFunc func(txID string, note string) error
 
+	// SetTxOriginFunc mocks the SetTxOrigin method.
+	SetTxOriginFunc func(txID string, origin *notes.TxOrigin) error
+
+	// SetTxSpamFunc mocks the SetTxSpam method.
+	SetTxSpamFunc func(txID string, spam bool) error
+
 	// SyncedFunc mocks the Synced method.
 	SyncedFunc func() bool
 
 	// TransactionsFunc mocks the Transactions method.
 	TransactionsFunc func() (accounts.OrderedTransactions, error)
 
+	// TxCategoryFunc mocks the TxCategory method.
+	TxCategoryFunc func(txID string) string
+
+	// TxInternalTransferFunc mocks the TxInternalTransfer method.
+	TxInternalTransferFunc func(txID string) types.Code
+
 	// TxNoteFunc mocks the TxNote method.
 	TxNoteFunc func(txID string) string
 
+	// TxOriginFunc mocks the TxOrigin method.
+	TxOriginFunc func(txID string) *notes.TxOrigin
+
 	// TxProposalFunc mocks the TxProposal method.
 	TxProposalFunc func(txProposalArgs *accounts.TxProposalArgs) (coin.Amount, coin.Amount, coin.Amount, error)
 
+	// TxSpamFunc mocks the TxSpam method.
+	TxSpamFunc func(txID string) (bool, bool)
+
 	// VerifyAddressFunc mocks the VerifyAddress method.
 	VerifyAddressFunc func(addressID string) (bool, error)
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// AddressLabel holds details about calls to the AddressLabel method.
+		AddressLabel []struct {
+			// Address is the address argument value.
+			Address string
+		}
 		// Balance holds details about calls to the Balance method.
 		Balance []struct {
 		}
 		// CanVerifyAddresses holds details about calls to the CanVerifyAddresses method.
 		CanVerifyAddresses []struct {
 		}
+		// Checkpoint holds details about calls to the Checkpoint method.
+		Checkpoint []struct {
+		}
 		// Close holds details about calls to the Close method.
 		Close []struct {
 		}
@@ -183,6 +277,15 @@ type InterfaceMock struct {
 		// Config holds details about calls to the Config method.
 		Config []struct {
 		}
+		// Export holds details about calls to the Export method.
+		Export []struct {
+			// W is the w argument value.
+			W io.Writer
+			// Format is the format argument value.
+			Format accounts.ExportFormat
+			// Transactions is the transactions argument value.
+			Transactions []*accounts.TransactionData
+		}
 		// ExportCSV holds details about calls to the ExportCSV method.
 		ExportCSV []struct {
 			// W is the w argument value.
@@ -202,6 +305,9 @@ type InterfaceMock struct {
 		// GetUnusedReceiveAddresses holds details about calls to the GetUnusedReceiveAddresses method.
 		GetUnusedReceiveAddresses []struct {
 		}
+		// HasInterruptedSigning holds details about calls to the HasInterruptedSigning method.
+		HasInterruptedSigning []struct {
+		}
 		// Info holds details about calls to the Info method.
 		Info []struct {
 		}
@@ -224,9 +330,37 @@ type InterfaceMock struct {
 			// S is the s argument value.
 			S string
 		}
+		// SaveCheckpoint holds details about calls to the SaveCheckpoint method.
+		SaveCheckpoint []struct {
+			// Balance is the balance argument value.
+			Balance *accounts.Balance
+			// Transactions is the transactions argument value.
+			Transactions accounts.OrderedTransactions
+		}
 		// SendTx holds details about calls to the SendTx method.
 		SendTx []struct {
 		}
+		// SetAddressLabel holds details about calls to the SetAddressLabel method.
+		SetAddressLabel []struct {
+			// Address is the address argument value.
+			Address string
+			// Label is the label argument value.
+			Label string
+		}
+		// SetTxCategory holds details about calls to the SetTxCategory method.
+		SetTxCategory []struct {
+			// TxID is the txID argument value.
+			TxID string
+			// Category is the category argument value.
+			Category string
+		}
+		// SetTxInternalTransfer holds details about calls to the SetTxInternalTransfer method.
+		SetTxInternalTransfer []struct {
+			// TxID is the txID argument value.
+			TxID string
+			// Counterparty is the counterparty argument value.
+			Counterparty types.Code
+		}
 		// SetTxNote holds details about calls to the SetTxNote method.
 		SetTxNote []struct {
 			// TxID is the txID argument value.
@@ -234,53 +368,133 @@ type InterfaceMock struct {
 			// Note is the note argument value.
 			Note string
 		}
+		// SetTxOrigin holds details about calls to the SetTxOrigin method.
+		SetTxOrigin []struct {
+			// TxID is the txID argument value.
+			TxID string
+			// Origin is the origin argument value.
+			Origin *notes.TxOrigin
+		}
+		// SetTxSpam holds details about calls to the SetTxSpam method.
+		SetTxSpam []struct {
+			// TxID is the txID argument value.
+			TxID string
+			// Spam is the spam argument value.
+			Spam bool
+		}
 		// Synced holds details about calls to the Synced method.
 		Synced []struct {
 		}
 		// Transactions holds details about calls to the Transactions method.
 		Transactions []struct {
 		}
+		// TxCategory holds details about calls to the TxCategory method.
+		TxCategory []struct {
+			// TxID is the txID argument value.
+			TxID string
+		}
+		// TxInternalTransfer holds details about calls to the TxInternalTransfer method.
+		TxInternalTransfer []struct {
+			// TxID is the txID argument value.
+			TxID string
+		}
 		// TxNote holds details about calls to the TxNote method.
 		TxNote []struct {
 			// TxID is the txID argument value.
 			TxID string
 		}
+		// TxOrigin holds details about calls to the TxOrigin method.
+		TxOrigin []struct {
+			// TxID is the txID argument value.
+			TxID string
+		}
 		// TxProposal holds details about calls to the TxProposal method.
 		TxProposal []struct {
 			// TxProposalArgs is the txProposalArgs argument value.
 			TxProposalArgs *accounts.TxProposalArgs
 		}
+		// TxSpam holds details about calls to the TxSpam method.
+		TxSpam []struct {
+			// TxID is the txID argument value.
+			TxID string
+		}
 		// VerifyAddress holds details about calls to the VerifyAddress method.
 		VerifyAddress []struct {
 			// AddressID is the addressID argument value.
 			AddressID string
 		}
 	}
+	lockAddressLabel              sync.RWMutex
 	lockBalance                   sync.RWMutex
 	lockCanVerifyAddresses        sync.RWMutex
+	lockCheckpoint                sync.RWMutex
 	lockClose                     sync.RWMutex
 	lockCoin                      sync.RWMutex
 	lockConfig                    sync.RWMutex
+	lockExport                    sync.RWMutex
 	lockExportCSV                 sync.RWMutex
 	lockFatalError                sync.RWMutex
 	lockFeeTargets                sync.RWMutex
 	lockFilesFolder               sync.RWMutex
 	lockGetUnusedReceiveAddresses sync.RWMutex
+	lockHasInterruptedSigning     sync.RWMutex
 	lockInfo                      sync.RWMutex
 	lockInitialize                sync.RWMutex
 	lockNotifier                  sync.RWMutex
 	lockObserve                   sync.RWMutex
 	lockOffline                   sync.RWMutex
 	lockProposeTxNote             sync.RWMutex
+	lockSaveCheckpoint            sync.RWMutex
 	lockSendTx                    sync.RWMutex
+	lockSetAddressLabel           sync.RWMutex
+	lockSetTxCategory             sync.RWMutex
+	lockSetTxInternalTransfer     sync.RWMutex
 	lockSetTxNote                 sync.RWMutex
+	lockSetTxOrigin               sync.RWMutex
+	lockSetTxSpam                 sync.RWMutex
 	lockSynced                    sync.RWMutex
 	lockTransactions              sync.RWMutex
+	lockTxCategory                sync.RWMutex
+	lockTxInternalTransfer        sync.RWMutex
 	lockTxNote                    sync.RWMutex
+	lockTxOrigin                  sync.RWMutex
 	lockTxProposal                sync.RWMutex
+	lockTxSpam                    sync.RWMutex
 	lockVerifyAddress             sync.RWMutex
 }
 
+// AddressLabel calls AddressLabelFunc.
+func (mock *InterfaceMock) AddressLabel(address string) string {
+	if mock.AddressLabelFunc == nil {
+		panic("InterfaceMock.AddressLabelFunc: method is nil but Interface.AddressLabel was just called")
+	}
+	callInfo := struct {
+		Address string
+	}{
+		Address: address,
+	}
+	mock.lockAddressLabel.Lock()
+	mock.calls.AddressLabel = append(mock.calls.AddressLabel, callInfo)
+	mock.lockAddressLabel.Unlock()
+	return mock.AddressLabelFunc(address)
+}
+
+// AddressLabelCalls gets all the calls that were made to AddressLabel.
+// Check the length with:
+//
+//	len(mockedInterface.AddressLabelCalls())
+func (mock *InterfaceMock) AddressLabelCalls() []struct {
+	Address string
+} {
+	var calls []struct {
+		Address string
+	}
+	mock.lockAddressLabel.RLock()
+	calls = mock.calls.AddressLabel
+	mock.lockAddressLabel.RUnlock()
+	return calls
+}
+
 // Balance calls BalanceFunc.
 func (mock *InterfaceMock) Balance() (*accounts.Balance, error) {
 	if mock.BalanceFunc == nil {
@@ -335,6 +549,33 @@ func (mock *InterfaceMock) CanVerifyAddressesCalls() []struct {
 	return calls
 }
 
+// Checkpoint calls CheckpointFunc.
+func (mock *InterfaceMock) Checkpoint() *accounts.Checkpoint {
+	if mock.CheckpointFunc == nil {
+		panic("InterfaceMock.CheckpointFunc: method is nil but Interface.Checkpoint was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockCheckpoint.Lock()
+	mock.calls.Checkpoint = append(mock.calls.Checkpoint, callInfo)
+	mock.lockCheckpoint.Unlock()
+	return mock.CheckpointFunc()
+}
+
+// CheckpointCalls gets all the calls that were made to Checkpoint.
+// Check the length with:
+//
+//	len(mockedInterface.CheckpointCalls())
+func (mock *InterfaceMock) CheckpointCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockCheckpoint.RLock()
+	calls = mock.calls.Checkpoint
+	mock.lockCheckpoint.RUnlock()
+	return calls
+}
+
 // Close calls CloseFunc.
 func (mock *InterfaceMock) Close() {
 	if mock.CloseFunc == nil {
@@ -416,6 +657,46 @@ func (mock *InterfaceMock) ConfigCalls() []struct {
 	return calls
 }
 
+// Export calls ExportFunc.
+func (mock *InterfaceMock) Export(w io.Writer, format accounts.ExportFormat, transactions []*accounts.TransactionData) error {
+	if mock.ExportFunc == nil {
+		panic("InterfaceMock.ExportFunc: method is nil but Interface.Export was just called")
+	}
+	callInfo := struct {
+		W            io.Writer
+		Format       accounts.ExportFormat
+		Transactions []*accounts.TransactionData
+	}{
+		W:            w,
+		Format:       format,
+		Transactions: transactions,
+	}
+	mock.lockExport.Lock()
+	mock.calls.Export = append(mock.calls.Export, callInfo)
+	mock.lockExport.Unlock()
+	return mock.ExportFunc(w, format, transactions)
+}
+
+// ExportCalls gets all the calls that were made to Export.
+// Check the length with:
+//
+//	len(mockedInterface.ExportCalls())
+func (mock *InterfaceMock) ExportCalls() []struct {
+	W            io.Writer
+	Format       accounts.ExportFormat
+	Transactions []*accounts.TransactionData
+} {
+	var calls []struct {
+		W            io.Writer
+		Format       accounts.ExportFormat
+		Transactions []*accounts.TransactionData
+	}
+	mock.lockExport.RLock()
+	calls = mock.calls.Export
+	mock.lockExport.RUnlock()
+	return calls
+}
+
 // ExportCSV calls ExportCSVFunc.
 func (mock *InterfaceMock) ExportCSV(w io.Writer, transactions []*accounts.TransactionData) error {
 	if mock.ExportCSVFunc == nil {
@@ -560,6 +841,33 @@ func (mock *InterfaceMock) GetUnusedReceiveAddressesCalls() []struct {
 	return calls
 }
 
+// HasInterruptedSigning calls HasInterruptedSigningFunc.
+func (mock *InterfaceMock) HasInterruptedSigning() bool {
+	if mock.HasInterruptedSigningFunc == nil {
+		panic("InterfaceMock.HasInterruptedSigningFunc: method is nil but Interface.HasInterruptedSigning was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockHasInterruptedSigning.Lock()
+	mock.calls.HasInterruptedSigning = append(mock.calls.HasInterruptedSigning, callInfo)
+	mock.lockHasInterruptedSigning.Unlock()
+	return mock.HasInterruptedSigningFunc()
+}
+
+// HasInterruptedSigningCalls gets all the calls that were made to HasInterruptedSigning.
+// Check the length with:
+//
+//	len(mockedInterface.HasInterruptedSigningCalls())
+func (mock *InterfaceMock) HasInterruptedSigningCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockHasInterruptedSigning.RLock()
+	calls = mock.calls.HasInterruptedSigning
+	mock.lockHasInterruptedSigning.RUnlock()
+	return calls
+}
+
 // Info calls InfoFunc.
 func (mock *InterfaceMock) Info() *accounts.Info {
 	if mock.InfoFunc == nil {
@@ -732,6 +1040,42 @@ func (mock *InterfaceMock) ProposeTxNoteCalls() []struct {
 	return calls
 }
 
+// SaveCheckpoint calls SaveCheckpointFunc.
+func (mock *InterfaceMock) SaveCheckpoint(balance *accounts.Balance, transactions accounts.OrderedTransactions) error {
+	if mock.SaveCheckpointFunc == nil {
+		panic("InterfaceMock.SaveCheckpointFunc: method is nil but Interface.SaveCheckpoint was just called")
+	}
+	callInfo := struct {
+		Balance      *accounts.Balance
+		Transactions accounts.OrderedTransactions
+	}{
+		Balance:      balance,
+		Transactions: transactions,
+	}
+	mock.lockSaveCheckpoint.Lock()
+	mock.calls.SaveCheckpoint = append(mock.calls.SaveCheckpoint, callInfo)
+	mock.lockSaveCheckpoint.Unlock()
+	return mock.SaveCheckpointFunc(balance, transactions)
+}
+
+// SaveCheckpointCalls gets all the calls that were made to SaveCheckpoint.
+// Check the length with:
+//
+//	len(mockedInterface.SaveCheckpointCalls())
+func (mock *InterfaceMock) SaveCheckpointCalls() []struct {
+	Balance      *accounts.Balance
+	Transactions accounts.OrderedTransactions
+} {
+	var calls []struct {
+		Balance      *accounts.Balance
+		Transactions accounts.OrderedTransactions
+	}
+	mock.lockSaveCheckpoint.RLock()
+	calls = mock.calls.SaveCheckpoint
+	mock.lockSaveCheckpoint.RUnlock()
+	return calls
+}
+
 // SendTx calls SendTxFunc.
 func (mock *InterfaceMock) SendTx() error {
 	if mock.SendTxFunc == nil {
@@ -759,6 +1103,114 @@ func (mock *InterfaceMock) SendTxCalls() []struct {
 	return calls
 }
 
+// SetAddressLabel calls SetAddressLabelFunc.
+func (mock *InterfaceMock) SetAddressLabel(address string, label string) error {
+	if mock.SetAddressLabelFunc == nil {
+		panic("InterfaceMock.SetAddressLabelFunc: method is nil but Interface.SetAddressLabel was just called")
+	}
+	callInfo := struct {
+		Address string
+		Label   string
+	}{
+		Address: address,
+		Label:   label,
+	}
+	mock.lockSetAddressLabel.Lock()
+	mock.calls.SetAddressLabel = append(mock.calls.SetAddressLabel, callInfo)
+	mock.lockSetAddressLabel.Unlock()
+	return mock.SetAddressLabelFunc(address, label)
+}
+
+// SetAddressLabelCalls gets all the calls that were made to SetAddressLabel.
+// Check the length with:
+//
+//	len(mockedInterface.SetAddressLabelCalls())
+func (mock *InterfaceMock) SetAddressLabelCalls() []struct {
+	Address string
+	Label   string
+} {
+	var calls []struct {
+		Address string
+		Label   string
+	}
+	mock.lockSetAddressLabel.RLock()
+	calls = mock.calls.SetAddressLabel
+	mock.lockSetAddressLabel.RUnlock()
+	return calls
+}
+
+// SetTxCategory calls SetTxCategoryFunc.
+func (mock *InterfaceMock) SetTxCategory(txID string, category string) error {
+	if mock.SetTxCategoryFunc == nil {
+		panic("InterfaceMock.SetTxCategoryFunc: method is nil but Interface.SetTxCategory was just called")
+	}
+	callInfo := struct {
+		TxID     string
+		Category string
+	}{
+		TxID:     txID,
+		Category: category,
+	}
+	mock.lockSetTxCategory.Lock()
+	mock.calls.SetTxCategory = append(mock.calls.SetTxCategory, callInfo)
+	mock.lockSetTxCategory.Unlock()
+	return mock.SetTxCategoryFunc(txID, category)
+}
+
+// SetTxCategoryCalls gets all the calls that were made to SetTxCategory.
+// Check the length with:
+//
+//	len(mockedInterface.SetTxCategoryCalls())
+func (mock *InterfaceMock) SetTxCategoryCalls() []struct {
+	TxID     string
+	Category string
+} {
+	var calls []struct {
+		TxID     string
+		Category string
+	}
+	mock.lockSetTxCategory.RLock()
+	calls = mock.calls.SetTxCategory
+	mock.lockSetTxCategory.RUnlock()
+	return calls
+}
+
+// SetTxInternalTransfer calls SetTxInternalTransferFunc.
+func (mock *InterfaceMock) SetTxInternalTransfer(txID string, counterparty types.Code) error {
+	if mock.SetTxInternalTransferFunc == nil {
+		panic("InterfaceMock.SetTxInternalTransferFunc: method is nil but Interface.SetTxInternalTransfer was just called")
+	}
+	callInfo := struct {
+		TxID         string
+		Counterparty types.Code
+	}{
+		TxID:         txID,
+		Counterparty: counterparty,
+	}
+	mock.lockSetTxInternalTransfer.Lock()
+	mock.calls.SetTxInternalTransfer = append(mock.calls.SetTxInternalTransfer, callInfo)
+	mock.lockSetTxInternalTransfer.Unlock()
+	return mock.SetTxInternalTransferFunc(txID, counterparty)
+}
+
+// SetTxInternalTransferCalls gets all the calls that were made to SetTxInternalTransfer.
+// Check the length with:
+//
+//	len(mockedInterface.SetTxInternalTransferCalls())
+func (mock *InterfaceMock) SetTxInternalTransferCalls() []struct {
+	TxID         string
+	Counterparty types.Code
+} {
+	var calls []struct {
+		TxID         string
+		Counterparty types.Code
+	}
+	mock.lockSetTxInternalTransfer.RLock()
+	calls = mock.calls.SetTxInternalTransfer
+	mock.lockSetTxInternalTransfer.RUnlock()
+	return calls
+}
+
 // SetTxNote calls SetTxNoteFunc.
 func (mock *InterfaceMock) SetTxNote(txID string, note string) error {
 	if mock.SetTxNoteFunc == nil {
@@ -795,6 +1247,78 @@ func (mock *InterfaceMock) SetTxNoteCalls() []struct {
 	return calls
 }
 
+// SetTxOrigin calls SetTxOriginFunc.
+func (mock *InterfaceMock) SetTxOrigin(txID string, origin *notes.TxOrigin) error {
+	if mock.SetTxOriginFunc == nil {
+		panic("InterfaceMock.SetTxOriginFunc: method is nil but Interface.SetTxOrigin was just called")
+	}
+	callInfo := struct {
+		TxID   string
+		Origin *notes.TxOrigin
+	}{
+		TxID:   txID,
+		Origin: origin,
+	}
+	mock.lockSetTxOrigin.Lock()
+	mock.calls.SetTxOrigin = append(mock.calls.SetTxOrigin, callInfo)
+	mock.lockSetTxOrigin.Unlock()
+	return mock.SetTxOriginFunc(txID, origin)
+}
+
+// SetTxOriginCalls gets all the calls that were made to SetTxOrigin.
+// Check the length with:
+//
+//	len(mockedInterface.SetTxOriginCalls())
+func (mock *InterfaceMock) SetTxOriginCalls() []struct {
+	TxID   string
+	Origin *notes.TxOrigin
+} {
+	var calls []struct {
+		TxID   string
+		Origin *notes.TxOrigin
+	}
+	mock.lockSetTxOrigin.RLock()
+	calls = mock.calls.SetTxOrigin
+	mock.lockSetTxOrigin.RUnlock()
+	return calls
+}
+
+// SetTxSpam calls SetTxSpamFunc.
+func (mock *InterfaceMock) SetTxSpam(txID string, spam bool) error {
+	if mock.SetTxSpamFunc == nil {
+		panic("InterfaceMock.SetTxSpamFunc: method is nil but Interface.SetTxSpam was just called")
+	}
+	callInfo := struct {
+		TxID string
+		Spam bool
+	}{
+		TxID: txID,
+		Spam: spam,
+	}
+	mock.lockSetTxSpam.Lock()
+	mock.calls.SetTxSpam = append(mock.calls.SetTxSpam, callInfo)
+	mock.lockSetTxSpam.Unlock()
+	return mock.SetTxSpamFunc(txID, spam)
+}
+
+// SetTxSpamCalls gets all the calls that were made to SetTxSpam.
+// Check the length with:
+//
+//	len(mockedInterface.SetTxSpamCalls())
+func (mock *InterfaceMock) SetTxSpamCalls() []struct {
+	TxID string
+	Spam bool
+} {
+	var calls []struct {
+		TxID string
+		Spam bool
+	}
+	mock.lockSetTxSpam.RLock()
+	calls = mock.calls.SetTxSpam
+	mock.lockSetTxSpam.RUnlock()
+	return calls
+}
+
 // Synced calls SyncedFunc.
 func (mock *InterfaceMock) Synced() bool {
 	if mock.SyncedFunc == nil {
@@ -849,6 +1373,70 @@ func (mock *InterfaceMock) TransactionsCalls() []struct {
 	return calls
 }
 
+// TxCategory calls TxCategoryFunc.
+func (mock *InterfaceMock) TxCategory(txID string) string {
+	if mock.TxCategoryFunc == nil {
+		panic("InterfaceMock.TxCategoryFunc: method is nil but Interface.TxCategory was just called")
+	}
+	callInfo := struct {
+		TxID string
+	}{
+		TxID: txID,
+	}
+	mock.lockTxCategory.Lock()
+	mock.calls.TxCategory = append(mock.calls.TxCategory, callInfo)
+	mock.lockTxCategory.Unlock()
+	return mock.TxCategoryFunc(txID)
+}
+
+// TxCategoryCalls gets all the calls that were made to TxCategory.
+// Check the length with:
+//
+//	len(mockedInterface.TxCategoryCalls())
+func (mock *InterfaceMock) TxCategoryCalls() []struct {
+	TxID string
+} {
+	var calls []struct {
+		TxID string
+	}
+	mock.lockTxCategory.RLock()
+	calls = mock.calls.TxCategory
+	mock.lockTxCategory.RUnlock()
+	return calls
+}
+
+// TxInternalTransfer calls TxInternalTransferFunc.
+func (mock *InterfaceMock) TxInternalTransfer(txID string) types.Code {
+	if mock.TxInternalTransferFunc == nil {
+		panic("InterfaceMock.TxInternalTransferFunc: method is nil but Interface.TxInternalTransfer was just called")
+	}
+	callInfo := struct {
+		TxID string
+	}{
+		TxID: txID,
+	}
+	mock.lockTxInternalTransfer.Lock()
+	mock.calls.TxInternalTransfer = append(mock.calls.TxInternalTransfer, callInfo)
+	mock.lockTxInternalTransfer.Unlock()
+	return mock.TxInternalTransferFunc(txID)
+}
+
+// TxInternalTransferCalls gets all the calls that were made to TxInternalTransfer.
+// Check the length with:
+//
+//	len(mockedInterface.TxInternalTransferCalls())
+func (mock *InterfaceMock) TxInternalTransferCalls() []struct {
+	TxID string
+} {
+	var calls []struct {
+		TxID string
+	}
+	mock.lockTxInternalTransfer.RLock()
+	calls = mock.calls.TxInternalTransfer
+	mock.lockTxInternalTransfer.RUnlock()
+	return calls
+}
+
 // TxNote calls TxNoteFunc.
 func (mock *InterfaceMock) TxNote(txID string) string {
 	if mock.TxNoteFunc == nil {
@@ -881,6 +1469,38 @@ func (mock *InterfaceMock) TxNoteCalls() []struct {
 	return calls
 }
 
+// TxOrigin calls TxOriginFunc.
+func (mock *InterfaceMock) TxOrigin(txID string) *notes.TxOrigin {
+	if mock.TxOriginFunc == nil {
+		panic("InterfaceMock.TxOriginFunc: method is nil but Interface.TxOrigin was just called")
+	}
+	callInfo := struct {
+		TxID string
+	}{
+		TxID: txID,
+	}
+	mock.lockTxOrigin.Lock()
+	mock.calls.TxOrigin = append(mock.calls.TxOrigin, callInfo)
+	mock.lockTxOrigin.Unlock()
+	return mock.TxOriginFunc(txID)
+}
+
+// TxOriginCalls gets all the calls that were made to TxOrigin.
+// Check the length with:
+//
+//	len(mockedInterface.TxOriginCalls())
+func (mock *InterfaceMock) TxOriginCalls() []struct {
+	TxID string
+} {
+	var calls []struct {
+		TxID string
+	}
+	mock.lockTxOrigin.RLock()
+	calls = mock.calls.TxOrigin
+	mock.lockTxOrigin.RUnlock()
+	return calls
+}
+
 // TxProposal calls TxProposalFunc.
 func (mock *InterfaceMock) TxProposal(txProposalArgs *accounts.TxProposalArgs) (coin.Amount, coin.Amount, coin.Amount, error) {
 	if mock.TxProposalFunc == nil {
@@ -913,6 +1533,38 @@ func (mock *InterfaceMock) TxProposalCalls() []struct {
 	return calls
 }
 
+// TxSpam calls TxSpamFunc.
+func (mock *InterfaceMock) TxSpam(txID string) (bool, bool) {
+	if mock.TxSpamFunc == nil {
+		panic("InterfaceMock.TxSpamFunc: method is nil but Interface.TxSpam was just called")
+	}
+	callInfo := struct {
+		TxID string
+	}{
+		TxID: txID,
+	}
+	mock.lockTxSpam.Lock()
+	mock.calls.TxSpam = append(mock.calls.TxSpam, callInfo)
+	mock.lockTxSpam.Unlock()
+	return mock.TxSpamFunc(txID)
+}
+
+// TxSpamCalls gets all the calls that were made to TxSpam.
+// Check the length with:
+//
+//	len(mockedInterface.TxSpamCalls())
+func (mock *InterfaceMock) TxSpamCalls() []struct {
+	TxID string
+} {
+	var calls []struct {
+		TxID string
+	}
+	mock.lockTxSpam.RLock()
+	calls = mock.calls.TxSpam
+	mock.lockTxSpam.RUnlock()
+	return calls
+}
+
 // VerifyAddress calls VerifyAddressFunc.
 func (mock *InterfaceMock) VerifyAddress(addressID string) (bool, error) {
 	if mock.VerifyAddressFunc == nil {
@@ -18,52 +18,73 @@ package notes
 import (
 	"encoding/json"
 	"os"
+	"strings"
 	"sync"
 
+	utilConfig "github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 )
 
 // maxNoteLen is the maximum length per note.
 const maxNoteLen = 1024
 
+// maxCategoryLen is the maximum length of a transaction category.
+const maxCategoryLen = 128
+
 // Data is the notes JSON data serialized to disk.
 type Data struct {
-	// More fields to be added when we can label more stuff, e.g. receive addresses, utxos, etc.
+	// More fields to be added when we can label more stuff, e.g. utxos, etc.
 
 	// a map of transaction ID to transaction note.
 	TransactionNotes map[string]string `json:"transactions"`
+	// a map of transaction ID to whether the user marked the transaction as spam (true) or not spam
+	// (false), overriding the spam heuristics.
+	TransactionSpam map[string]bool `json:"transactionSpam,omitempty"`
+	// a map of transaction ID to where the funds came from, e.g. an exchange withdrawal.
+	TransactionOrigins map[string]TxOrigin `json:"transactionOrigins,omitempty"`
+	// a map of transaction ID to the code of the other loaded account on the other side of an
+	// internal transfer.
+	TransactionInternalTransfers map[string]string `json:"transactionInternalTransfers,omitempty"`
+	// a map of address to address label, e.g. to remember whom a receive address was given to.
+	AddressLabels map[string]string `json:"addressLabels,omitempty"`
+	// a map of transaction ID to the category of the transaction, e.g. "Groceries", or
+	// "Income:Salary". Subcategories are separated by a colon.
+	TransactionCategories map[string]string `json:"transactionCategories,omitempty"`
+}
+
+// TxOrigin describes where the funds of a received transaction came from.
+type TxOrigin struct {
+	// Exchange is the name of the exchange the funds were withdrawn from.
+	Exchange string `json:"exchange"`
+	// OrderID is the ID of the withdrawal at the exchange. Can be empty.
+	OrderID string `json:"orderID,omitempty"`
 }
 
 // read deserializes the json files into notes. If the file does not exist yet, no error is
-// returned, and the struct is retruned with default values.
+// returned, and the struct is retruned with default values. A corrupt file is recovered from the
+// previous version, see utilConfig.ReadFileWithRecovery().
 func read(filename string) (*Data, error) {
-	file, err := os.Open(filename)
+	jsonBytes, err := utilConfig.ReadFileWithRecovery(filename, json.Valid)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return &Data{}, nil
 		}
-		return nil, errp.WithStack(err)
+		return nil, err
 	}
-	defer file.Close() //nolint:errcheck
 	var notes Data
-	if err := json.NewDecoder(file).Decode(&notes); err != nil {
+	if err := json.Unmarshal(jsonBytes, &notes); err != nil {
 		return nil, errp.WithStack(err)
 	}
 	return &notes, nil
 }
 
+// write atomically persists the notes, so that they are not lost if the app crashes while writing.
 func write(data *Data, filename string) error {
-	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	jsonBytes, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
-		return err
-	}
-	defer func() { _ = file.Close() }()
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(data); err != nil {
 		return errp.WithStack(err)
 	}
-	return nil
+	return utilConfig.WriteFileAtomic(filename, append(jsonBytes, '\n'), 0600)
 }
 
 // Notes is a high level helper for notes, allowing you to read and set notes for transactions.
@@ -118,6 +139,146 @@ func (notes *Notes) TxNote(txID string) string {
 	return notes.data.TransactionNotes[txID]
 }
 
+// SetTxSpam marks a transaction as spam or not spam, overriding the spam heuristics.
+func (notes *Notes) SetTxSpam(txID string, spam bool) error {
+	notes.dataMu.Lock()
+	defer notes.dataMu.Unlock()
+
+	if notes.data.TransactionSpam == nil {
+		notes.data.TransactionSpam = map[string]bool{}
+	}
+	notes.data.TransactionSpam[txID] = spam
+	return write(notes.data, notes.filename)
+}
+
+// TxSpam returns whether the user marked a transaction as spam. The second return value is false
+// if the user did not mark the transaction.
+func (notes *Notes) TxSpam(txID string) (bool, bool) {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	spam, ok := notes.data.TransactionSpam[txID]
+	return spam, ok
+}
+
+// SetTxOrigin stores the origin of a transaction. nil removes it.
+func (notes *Notes) SetTxOrigin(txID string, origin *TxOrigin) error {
+	notes.dataMu.Lock()
+	defer notes.dataMu.Unlock()
+
+	if origin == nil {
+		delete(notes.data.TransactionOrigins, txID)
+		return write(notes.data, notes.filename)
+	}
+	if len(origin.Exchange)+len(origin.OrderID) > maxNoteLen {
+		return errp.Newf("Length of the origin must be smaller than %d", maxNoteLen)
+	}
+	if notes.data.TransactionOrigins == nil {
+		notes.data.TransactionOrigins = map[string]TxOrigin{}
+	}
+	notes.data.TransactionOrigins[txID] = *origin
+	return write(notes.data, notes.filename)
+}
+
+// TxOrigin returns the origin of a transaction, or nil if it is not known.
+func (notes *Notes) TxOrigin(txID string) *TxOrigin {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	origin, ok := notes.data.TransactionOrigins[txID]
+	if !ok {
+		return nil
+	}
+	return &origin
+}
+
+// SetTxInternalTransfer marks a transaction as an internal transfer with the account of the given
+// code. An empty code removes the mark. The file is only written if the mark changed.
+func (notes *Notes) SetTxInternalTransfer(txID string, accountCode string) error {
+	notes.dataMu.Lock()
+	defer notes.dataMu.Unlock()
+
+	if notes.data.TransactionInternalTransfers[txID] == accountCode {
+		return nil
+	}
+	if accountCode == "" {
+		delete(notes.data.TransactionInternalTransfers, txID)
+	} else {
+		if notes.data.TransactionInternalTransfers == nil {
+			notes.data.TransactionInternalTransfers = map[string]string{}
+		}
+		notes.data.TransactionInternalTransfers[txID] = accountCode
+	}
+	return write(notes.data, notes.filename)
+}
+
+// TxInternalTransfer returns the code of the account on the other side of an internal transfer, or
+// the empty string if the transaction is not an internal transfer.
+func (notes *Notes) TxInternalTransfer(txID string) string {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	return notes.data.TransactionInternalTransfers[txID]
+}
+
+// SetAddressLabel stores a label for an address. An empty label removes the entry.
+func (notes *Notes) SetAddressLabel(address string, label string) error {
+	notes.dataMu.Lock()
+	defer notes.dataMu.Unlock()
+
+	if len(label) > maxNoteLen {
+		return errp.Newf("Length of label must be smaller than %d. Got %d", maxNoteLen, len(label))
+	}
+
+	if label == "" {
+		delete(notes.data.AddressLabels, address)
+	} else {
+		if notes.data.AddressLabels == nil {
+			notes.data.AddressLabels = map[string]string{}
+		}
+		notes.data.AddressLabels[address] = label
+	}
+	return write(notes.data, notes.filename)
+}
+
+// AddressLabel fetches the label of an address. Returns the empty string if no label was found.
+func (notes *Notes) AddressLabel(address string) string {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	return notes.data.AddressLabels[address]
+}
+
+// SetTxCategory stores the category of a transaction. Surrounding whitespace is removed. An empty
+// category removes the entry.
+func (notes *Notes) SetTxCategory(txID string, category string) error {
+	notes.dataMu.Lock()
+	defer notes.dataMu.Unlock()
+
+	category = strings.TrimSpace(category)
+	if len(category) > maxCategoryLen {
+		return errp.Newf("Length of category must be smaller than %d. Got %d", maxCategoryLen, len(category))
+	}
+
+	if category == "" {
+		delete(notes.data.TransactionCategories, txID)
+	} else {
+		if notes.data.TransactionCategories == nil {
+			notes.data.TransactionCategories = map[string]string{}
+		}
+		notes.data.TransactionCategories[txID] = category
+	}
+	return write(notes.data, notes.filename)
+}
+
+// TxCategory fetches the category of a transaction. Returns the empty string if it has none.
+func (notes *Notes) TxCategory(txID string) string {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	return notes.data.TransactionCategories[txID]
+}
+
 // Data retrieves all stored notes. You must not modify the returned object.
 func (notes *Notes) Data() *Data {
 	notes.dataMu.RLock()
@@ -69,6 +69,29 @@ func TestNotesPersisted(t *testing.T) {
 	require.Equal(t, "", notes.TxNote("some-tx-id"))
 }
 
+// TestTxSpam checks that spam markings are persisted.
+func TestTxSpam(t *testing.T) {
+	filename := test.TstTempFile("account-notes")
+	notes, err := LoadNotes(filename)
+	require.NoError(t, err)
+
+	_, ok := notes.TxSpam("tx-id-1")
+	require.False(t, ok)
+
+	require.NoError(t, notes.SetTxSpam("tx-id-1", true))
+	require.NoError(t, notes.SetTxSpam("tx-id-2", false))
+
+	// Reload notes.
+	notes, err = LoadNotes(filename)
+	require.NoError(t, err)
+	spam, ok := notes.TxSpam("tx-id-1")
+	require.True(t, ok)
+	require.True(t, spam)
+	spam, ok = notes.TxSpam("tx-id-2")
+	require.True(t, ok)
+	require.False(t, spam)
+}
+
 // TestMaxLen checks that notes that are too long are rejected.
 func TestMaxLen(t *testing.T) {
 	filename := test.TstTempFile("account-notes")
@@ -114,3 +137,62 @@ func TestMergeLegacy(t *testing.T) {
 		},
 		notes.Data())
 }
+
+// TestTxOrigin checks that transaction origins are persisted.
+func TestTxOrigin(t *testing.T) {
+	filename := test.TstTempFile("account-notes")
+	notes, err := LoadNotes(filename)
+	require.NoError(t, err)
+
+	require.Nil(t, notes.TxOrigin("tx-id"))
+	origin := &TxOrigin{Exchange: "Kraken", OrderID: "ABC-123"}
+	require.NoError(t, notes.SetTxOrigin("tx-id", origin))
+
+	notes, err = LoadNotes(filename)
+	require.NoError(t, err)
+	require.Equal(t, origin, notes.TxOrigin("tx-id"))
+
+	require.NoError(t, notes.SetTxOrigin("tx-id", nil))
+	require.Nil(t, notes.TxOrigin("tx-id"))
+}
+
+// TestAddressLabels checks that address labels are persisted and removed by an empty label.
+func TestAddressLabels(t *testing.T) {
+	filename := test.TstTempFile("account-notes")
+	notes, err := LoadNotes(filename)
+	require.NoError(t, err)
+
+	require.Equal(t, "", notes.AddressLabel("address-1"))
+	require.NoError(t, notes.SetAddressLabel("address-1", "given to Alice"))
+	require.Error(t, notes.SetAddressLabel("address-2", strings.Repeat("x", 1025)))
+
+	notes, err = LoadNotes(filename)
+	require.NoError(t, err)
+	require.Equal(t, "given to Alice", notes.AddressLabel("address-1"))
+	require.Equal(t, "", notes.AddressLabel("address-2"))
+
+	require.NoError(t, notes.SetAddressLabel("address-1", ""))
+	require.Equal(t, "", notes.AddressLabel("address-1"))
+	require.Empty(t, notes.Data().AddressLabels)
+}
+
+// TestTxCategories checks that transaction categories are trimmed, persisted and removed by an
+// empty category.
+func TestTxCategories(t *testing.T) {
+	filename := test.TstTempFile("account-notes")
+	notes, err := LoadNotes(filename)
+	require.NoError(t, err)
+
+	require.Equal(t, "", notes.TxCategory("tx-id"))
+	require.NoError(t, notes.SetTxCategory("tx-id", " Food:Groceries "))
+	require.Error(t, notes.SetTxCategory("tx-id-2", strings.Repeat("x", 129)))
+
+	notes, err = LoadNotes(filename)
+	require.NoError(t, err)
+	require.Equal(t, "Food:Groceries", notes.TxCategory("tx-id"))
+	require.Equal(t, "", notes.TxCategory("tx-id-2"))
+
+	require.NoError(t, notes.SetTxCategory("tx-id", "  "))
+	require.Equal(t, "", notes.TxCategory("tx-id"))
+	require.Empty(t, notes.Data().TransactionCategories)
+}
@@ -122,6 +122,16 @@ type TransactionData struct {
 	Gas     uint64
 	Nonce   *uint64
 	IsErc20 bool
+	// MethodID is the selector of the called contract method, hex encoded with 0x prefix, or empty
+	// if no contract method was called.
+	MethodID string
+	// ContractName and MethodName describe the called contract and method for display, if they are
+	// known, see the contracts package.
+	ContractName string
+	MethodName   string
+	// Spam is true if the transaction is likely an unsolicited token transfer, e.g. one used for
+	// address poisoning. The user can override this, see Interface.TxSpam().
+	Spam bool
 }
 
 // isConfirmed returns true if the transaction has at least one confirmation.
@@ -129,6 +139,26 @@ func (tx *TransactionData) isConfirmed() bool {
 	return tx.Height > 0
 }
 
+// ApplyConfirmationThreshold sets the number of confirmations needed for the transactions to be
+// complete and updates their status accordingly. Failed transactions remain failed. A threshold of
+// zero keeps the default threshold of the coin, which the transactions already have.
+func ApplyConfirmationThreshold(transactions []*TransactionData, threshold int) {
+	if threshold <= 0 {
+		return
+	}
+	for _, tx := range transactions {
+		tx.NumConfirmationsComplete = threshold
+		if tx.Status == TxStatusFailed {
+			continue
+		}
+		if tx.NumConfirmations >= threshold {
+			tx.Status = TxStatusComplete
+		} else {
+			tx.Status = TxStatusPending
+		}
+	}
+}
+
 // byHeight defines the methods needed to satisify sort.Interface to sort transactions by their
 // height. Special case for unconfirmed transactions (height <=0), which come last. If the height
 // is the same for two txs, they are sorted by the created (first seen) time instead.
@@ -161,32 +191,40 @@ func NewOrderedTransactions(txs []*TransactionData) OrderedTransactions {
 	balance := big.NewInt(0)
 	for i := len(txs) - 1; i >= 0; i-- {
 		tx := txs[i]
-		switch tx.Type {
-		case TxTypeReceive:
-			if tx.Status != TxStatusFailed {
-				balance.Add(balance, tx.Amount.BigInt())
-			}
-		case TxTypeSend:
-			if tx.Status != TxStatusFailed {
-				balance.Sub(balance, tx.Amount.BigInt())
-			}
-			// Subtract fee as well. Ethereum: it is deducted even if the tx failed, as the tx was
-			// mined.
-			if tx.Fee != nil && !tx.FeeIsDifferentUnit {
-				balance.Sub(balance, tx.Fee.BigInt())
-			}
-		case TxTypeSendSelf:
-			// Subtract only fee. Ethereum: it is deducted even if the tx failed, as the tx was
-			// mined.
-			if tx.Fee != nil && !tx.FeeIsDifferentUnit {
-				balance.Sub(balance, tx.Fee.BigInt())
-			}
-		}
+		balance.Add(balance, tx.balanceChange())
 		tx.Balance = coin.NewAmount(balance)
 	}
 	return txs
 }
 
+// balanceChange returns by how much the transaction changes the balance of the account, negative
+// for outgoing transactions.
+func (tx *TransactionData) balanceChange() *big.Int {
+	change := big.NewInt(0)
+	switch tx.Type {
+	case TxTypeReceive:
+		if tx.Status != TxStatusFailed {
+			change.Add(change, tx.Amount.BigInt())
+		}
+	case TxTypeSend:
+		if tx.Status != TxStatusFailed {
+			change.Sub(change, tx.Amount.BigInt())
+		}
+		// Subtract fee as well. Ethereum: it is deducted even if the tx failed, as the tx was
+		// mined.
+		if tx.Fee != nil && !tx.FeeIsDifferentUnit {
+			change.Sub(change, tx.Fee.BigInt())
+		}
+	case TxTypeSendSelf:
+		// Subtract only fee. Ethereum: it is deducted even if the tx failed, as the tx was
+		// mined.
+		if tx.Fee != nil && !tx.FeeIsDifferentUnit {
+			change.Sub(change, tx.Fee.BigInt())
+		}
+	}
+	return change
+}
+
 // TimeseriesEntry contains the balance of the account at the given time.
 type TimeseriesEntry struct {
 	Time  time.Time
@@ -213,3 +213,41 @@ func TestOrderedTransactionsWithFailedTransactions(t *testing.T) {
 		require.Equal(t, coin.NewAmountFromInt64(expectedBalances[i]), ordered[i].Balance, i)
 	}
 }
+
+func TestApplyConfirmationThreshold(t *testing.T) {
+	newTxs := func() []*TransactionData {
+		return []*TransactionData{
+			{NumConfirmations: 0, NumConfirmationsComplete: 6, Status: TxStatusPending},
+			{NumConfirmations: 3, NumConfirmationsComplete: 6, Status: TxStatusPending},
+			{NumConfirmations: 6, NumConfirmationsComplete: 6, Status: TxStatusComplete},
+			{NumConfirmations: 2, NumConfirmationsComplete: 6, Status: TxStatusFailed},
+		}
+	}
+	status := func(txs []*TransactionData) []TxStatus {
+		result := []TxStatus{}
+		for _, tx := range txs {
+			result = append(result, tx.Status)
+		}
+		return result
+	}
+
+	// Zero keeps the defaults.
+	txs := newTxs()
+	ApplyConfirmationThreshold(txs, 0)
+	require.Equal(t, newTxs(), txs)
+
+	txs = newTxs()
+	ApplyConfirmationThreshold(txs, 3)
+	require.Equal(t,
+		[]TxStatus{TxStatusPending, TxStatusComplete, TxStatusComplete, TxStatusFailed},
+		status(txs))
+	for _, tx := range txs {
+		require.Equal(t, 3, tx.NumConfirmationsComplete)
+	}
+
+	txs = newTxs()
+	ApplyConfirmationThreshold(txs, 10)
+	require.Equal(t,
+		[]TxStatus{TxStatusPending, TxStatusPending, TxStatusPending, TxStatusFailed},
+		status(txs))
+}
@@ -21,6 +21,10 @@ const (
 	// EventStatusChanged is fired when the status changes. Check the status using Initialized().
 	EventStatusChanged Event = "statusChanged"
 
+	// EventInitialized is fired when the account was initialized in the background after it was
+	// loaded, and its status and balance can be queried.
+	EventInitialized Event = "initialized"
+
 	// EventSyncStarted is fired when syncing with the blockchain starts. This happens in the very
 	// beginning for the initial sync, and repeatedly afterwards when the wallet is updated (new
 	// transactions, confirmations, etc.).
@@ -31,4 +35,13 @@ const (
 
 	// EventHeadersSynced is fired when the headers finished syncing.
 	EventHeadersSynced Event = "headersSynced"
+
+	// EventReorg is fired when a chain reorganization replaced blocks containing transactions of
+	// the account. Their confirmations are checked again and transactions which vanished are
+	// removed, which can change the balance.
+	EventReorg Event = "reorg"
+
+	// EventSigningInterrupted is fired when the keystore disconnected while signing a tx. The tx
+	// proposal is kept and can be signed once the keystore is connected again.
+	EventSigningInterrupted Event = "signingInterrupted"
 )
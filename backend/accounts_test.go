@@ -18,10 +18,12 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsMocks "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/mocks"
 	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
@@ -1409,6 +1411,31 @@ func TestWatchonly(t *testing.T) {
 		// Accounts, including the newly added ones, remain loaded.
 		checkShownAccountsLen(t, b, 5, 5)
 	})
+
+	// Global watch-only mode - all persisted accounts are loaded without keystore, independent of
+	// the keystore's watchonly setting. Disabling it removes them again.
+	t.Run("", func(t *testing.T) {
+		b := newBackend(t, testnetDisabled, regtestDisabled)
+		defer b.Close()
+
+		b.registerKeystore(makeBitBox02Multi())
+		checkShownAccountsLen(t, b, 3, 3)
+		b.DeregisterKeystore()
+		checkShownAccountsLen(t, b, 0, 3)
+
+		require.NoError(t, b.SetWatchonlyAll(true))
+		require.True(t, b.Config().AppConfig().Backend.WatchonlyAll)
+		checkShownAccountsLen(t, b, 3, 3)
+
+		// Accounts remain loaded when the keystore is connected and disconnected again.
+		b.registerKeystore(makeBitBox02Multi())
+		checkShownAccountsLen(t, b, 3, 3)
+		b.DeregisterKeystore()
+		checkShownAccountsLen(t, b, 3, 3)
+
+		require.NoError(t, b.SetWatchonlyAll(false))
+		checkShownAccountsLen(t, b, 0, 3)
+	})
 }
 
 func TestAccountsByKeystore(t *testing.T) {
@@ -1509,4 +1536,209 @@ func TestAccountsTotalBalanceByKeystore(t *testing.T) {
 
 	require.NotNil(t, totalBalance[hex.EncodeToString(ks2Fingerprint)])
 	require.Equal(t, "0.13", totalBalance[hex.EncodeToString(ks2Fingerprint)].Total)
+
+	// Unlike the totals by keystore, the total leaves out the hidden unused accounts, like the
+	// per-account balances of /accounts/balances.
+	total, err := b.AccountsTotalBalance()
+	require.NoError(t, err)
+	require.Equal(t, "0.04", total.Total)
+}
+
+func TestImportWatchonlyAccount(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	btcCoin, err := b.Coin(coinpkg.CodeBTC)
+	require.NoError(t, err)
+	xpub, err := keystoreHelper2().ExtendedPublicKey(btcCoin, mustKeypath("m/84'/0'/1'"))
+	require.NoError(t, err)
+	exportFile := []byte(fmt.Sprintf(
+		`{"ExtPubKey": "%s", "MasterFingerprint": "0F056943", "AccountKeyPath": "m/84'/0'/1'"}`, xpub))
+
+	_, err = b.ImportWatchonlyAccount(coinpkg.CodeLTC, "", exportFile)
+	require.Error(t, err)
+
+	accountCode, err := b.ImportWatchonlyAccount(coinpkg.CodeBTC, "", exportFile)
+	require.NoError(t, err)
+	require.Equal(t, accountsTypes.Code("v0-0f056943-btc-1"), accountCode)
+
+	// The account is loaded without a keystore being connected.
+	checkShownAccountsLen(t, b, 1, 1)
+	acct := b.Config().AccountsConfig().Lookup(accountCode)
+	require.NotNil(t, acct)
+	require.Equal(t, "Bitcoin 2", acct.Name)
+	require.Equal(t, signing.ScriptTypeP2WPKH, acct.SigningConfigurations[0].ScriptType())
+	isWatch, err := b.Config().AccountsConfig().IsAccountWatchonly(acct)
+	require.NoError(t, err)
+	require.True(t, isWatch)
+
+	_, err = b.ImportWatchonlyAccount(coinpkg.CodeBTC, "", exportFile)
+	require.Equal(t, errAccountAlreadyExists, errp.Cause(err))
+}
+
+func TestCreateMultisigAccount(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	btcCoin, err := b.Coin(coinpkg.CodeBTC)
+	require.NoError(t, err)
+	cosignerXpub, err := keystoreHelper2().ExtendedPublicKey(btcCoin, mustKeypath("m/48'/0'/0'/2'"))
+	require.NoError(t, err)
+	cosignerFingerprint, err := keystoreHelper2().RootFingerprint()
+	require.NoError(t, err)
+	cosigner := fmt.Sprintf("[%x/48'/0'/0'/2']%s", cosignerFingerprint, cosignerXpub)
+
+	ks := makeBitBox02Multi()
+	var registered []string
+	ks.RegisterMultisigFunc = func(coin coinpkg.Coin, configuration *signing.Configuration, name string) error {
+		registered = append(registered, name)
+		return nil
+	}
+	b.registerKeystore(ks)
+
+	noMultisig := makeBitBox02Multi()
+	noMultisig.SupportsAccountFunc = func(coin coinpkg.Coin, meta interface{}) bool {
+		return meta.(signing.ScriptType) != signing.ScriptTypeP2WSH
+	}
+	_, err = b.CreateMultisigAccount(coinpkg.CodeBTC, "", 2, []string{cosigner}, noMultisig)
+	require.Error(t, err)
+	_, err = b.CreateMultisigAccount(coinpkg.CodeLTC, "", 2, []string{cosigner}, ks)
+	require.Error(t, err)
+	_, err = b.CreateMultisigAccount(coinpkg.CodeBTC, "", 3, []string{cosigner}, ks)
+	require.Error(t, err)
+	_, err = b.CreateMultisigAccount(coinpkg.CodeBTC, "", 2, []string{cosignerXpub.String()}, ks)
+	require.Error(t, err)
+	_, err = b.CreateMultisigAccount(coinpkg.CodeBTC, "A very long multisig vault name", 2, []string{cosigner}, ks)
+	require.Error(t, err)
+	require.Empty(t, registered)
+
+	accountCode, err := b.CreateMultisigAccount(coinpkg.CodeBTC, "", 2, []string{cosigner}, ks)
+	require.NoError(t, err)
+	require.Equal(t, accountsTypes.Code("v0-b767476f-btc-multisig-0"), accountCode)
+	require.Equal(t, []string{"Bitcoin multisig"}, registered)
+	acct := b.Config().AccountsConfig().Lookup(accountCode)
+	require.NotNil(t, acct)
+	require.Len(t, acct.SigningConfigurations, 1)
+	multisig := acct.SigningConfigurations[0].BitcoinMultisig
+	require.NotNil(t, multisig)
+	require.Equal(t, 2, multisig.Threshold)
+	require.Equal(t, "m/48'/0'/0'/2'", multisig.KeyInfos[0].AbsoluteKeypath.Encode())
+	require.Equal(t, cosignerXpub.String(), multisig.KeyInfos[1].ExtendedPublicKey.String())
+
+	// Multisig accounts are numbered separately and don't change the next regular account.
+	accountCode, err = b.CreateMultisigAccount(coinpkg.CodeBTC, "Vault", 2, []string{cosigner}, ks)
+	require.NoError(t, err)
+	require.Equal(t, accountsTypes.Code("v0-b767476f-btc-multisig-1"), accountCode)
+	multisigAccounts := config.AccountsConfig{}
+	for _, account := range b.Config().AccountsConfig().Accounts {
+		if isMultisigAccount(account) {
+			multisigAccounts.Accounts = append(multisigAccounts.Accounts, account)
+		}
+	}
+	require.Len(t, multisigAccounts.Accounts, 2)
+	accountNumber, err := nextAccountNumber(coinpkg.CodeBTC, ks, &multisigAccounts)
+	require.NoError(t, err)
+	require.Equal(t, uint16(0), accountNumber)
+
+	// Aborting the registration on the device does not add the account.
+	ks.RegisterMultisigFunc = func(coin coinpkg.Coin, configuration *signing.Configuration, name string) error {
+		return errp.ErrUserAbort
+	}
+	_, err = b.CreateMultisigAccount(coinpkg.CodeBTC, "", 2, []string{cosigner}, ks)
+	require.Equal(t, errp.ErrUserAbort, errp.Cause(err))
+	require.Nil(t, b.Config().AccountsConfig().Lookup("v0-b767476f-btc-multisig-2"))
+}
+
+func TestInitializeAccountConcurrency(t *testing.T) {
+	b := &Backend{accountInitSlots: make(chan struct{}, accountInitWorkers)}
+
+	const numAccounts = 10
+	var running, maxRunning atomic.Int32
+	release := make(chan struct{})
+	events := make(chan accountsTypes.Event, numAccounts)
+	config := &accounts.AccountConfig{
+		OnEvent: func(event accountsTypes.Event) { events <- event },
+	}
+	account := &accountsMocks.InterfaceMock{
+		ConfigFunc:     func() *accounts.AccountConfig { return config },
+		InitializeFunc: func() error { return nil },
+		// The workers are kept busy until the accounts are synced.
+		TransactionsFunc: func() (accounts.OrderedTransactions, error) {
+			n := running.Add(1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			<-release
+			running.Add(-1)
+			return nil, nil
+		},
+	}
+
+	for i := 0; i < numAccounts; i++ {
+		go func() {
+			_, err := b.initializeAccount(account)
+			assert.NoError(t, err)
+		}()
+	}
+	require.Eventually(t, func() bool { return running.Load() == accountInitWorkers },
+		time.Second, time.Millisecond)
+	// The synced accounts fired EventInitialized before they started syncing, the others are
+	// still waiting.
+	for i := 0; i < accountInitWorkers; i++ {
+		require.Equal(t, accountsTypes.EventInitialized, <-events)
+	}
+	require.Empty(t, events)
+	close(release)
+	for i := accountInitWorkers; i < numAccounts; i++ {
+		require.Equal(t, accountsTypes.EventInitialized, <-events)
+	}
+	require.Equal(t, int32(accountInitWorkers), maxRunning.Load())
+}
+
+func TestLazyAccountInit(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+	require.NoError(t, b.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		appConfig.Backend.LazyAccountInit = true
+		return nil
+	}))
+	checked := make(chan accountsTypes.Code, 100)
+	b.tstCheckAccountUsed = func(account accounts.Interface) bool {
+		checked <- account.Config().Config.Code
+		return false
+	}
+
+	b.registerKeystore(makeBitBox02Multi())
+
+	checkedCodes := func() []accountsTypes.Code {
+		var codes []accountsTypes.Code
+		for {
+			select {
+			case code := <-checked:
+				codes = append(codes, code)
+			case <-time.After(50 * time.Millisecond):
+				return codes
+			}
+		}
+	}
+
+	// Only the accounts which are hidden because they are unused are scanned in the background, for
+	// account discovery.
+	backgroundChecked := checkedCodes()
+	require.NotEmpty(t, backgroundChecked)
+	for _, code := range backgroundChecked {
+		require.True(t, b.Accounts().lookup(code).Config().Config.HiddenBecauseUnused)
+	}
+	code := accountsTypes.Code("v0-b767476f-btc-0")
+	require.NotNil(t, b.Accounts().lookup(code))
+	require.False(t, b.Accounts().lookup(code).Config().Config.HiddenBecauseUnused)
+
+	// Accounts are initialized on request, only once.
+	b.InitializeAccounts([]accountsTypes.Code{code, "unknown-account-code"})
+	require.Equal(t, []accountsTypes.Code{code}, checkedCodes())
+	b.InitializeAccounts([]accountsTypes.Code{code})
+	require.Empty(t, checkedCodes())
 }
@@ -0,0 +1,111 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+)
+
+// maxExploredAddresses is the maximum number of addresses derived by one call of
+// ExploreAddresses().
+const maxExploredAddresses = 100
+
+var (
+	errExplorerNoKeystore errp.ErrorCode = "keystoreUnavailable"
+	// errExplorerUnsupported is returned if the coin is not a Bitcoin-like coin or if the keystore
+	// does not support the script type for the coin.
+	errExplorerUnsupported    errp.ErrorCode = "unsupportedScriptType"
+	errExplorerInvalidKeypath errp.ErrorCode = "invalidKeypath"
+	errExplorerInvalidCount   errp.ErrorCode = "invalidCount"
+)
+
+// ExploreAddressesArgs are the arguments of ExploreAddresses().
+type ExploreAddressesArgs struct {
+	CoinCode   coinpkg.Code       `json:"coinCode"`
+	ScriptType signing.ScriptType `json:"scriptType"`
+	// Keypath is the keypath of the account, e.g. "m/84'/0'/0'". The addresses are derived at
+	// <keypath>/<0 or 1 for change>/<index>.
+	Keypath    string `json:"keypath"`
+	Change     bool   `json:"change"`
+	StartIndex uint32 `json:"startIndex"`
+	Count      int    `json:"count"`
+}
+
+// ExploredAddress is an address derived by ExploreAddresses().
+type ExploredAddress struct {
+	Keypath string `json:"keypath"`
+	Address string `json:"address"`
+}
+
+// ExploreAddresses derives the addresses of the connected keystore at an arbitrary keypath and
+// script type, without adding an account. This helps to locate funds which were sent to addresses
+// of derivations the app does not use, e.g. if they were received using a different wallet.
+func (backend *Backend) ExploreAddresses(args ExploreAddressesArgs) ([]ExploredAddress, error) {
+	if args.Count < 1 || args.Count > maxExploredAddresses {
+		return nil, errp.WithStack(errExplorerInvalidCount)
+	}
+	if uint64(args.StartIndex)+uint64(args.Count) > uint64(hdkeychain.HardenedKeyStart) {
+		return nil, errp.WithStack(errExplorerInvalidCount)
+	}
+	keystore := backend.Keystore()
+	if keystore == nil {
+		return nil, errp.WithStack(errExplorerNoKeystore)
+	}
+	coin, err := backend.Coin(args.CoinCode)
+	if err != nil {
+		return nil, err
+	}
+	btcCoin, ok := coin.(*btc.Coin)
+	if !ok || !keystore.SupportsAccount(coin, args.ScriptType) {
+		return nil, errp.WithStack(errExplorerUnsupported)
+	}
+	keypath, err := signing.NewAbsoluteKeypath(args.Keypath)
+	if err != nil {
+		return nil, errp.WithStack(errExplorerInvalidKeypath)
+	}
+	rootFingerprint, err := keystore.RootFingerprint()
+	if err != nil {
+		return nil, err
+	}
+	extendedPublicKey, err := keystore.ExtendedPublicKey(coin, keypath)
+	if err != nil {
+		return nil, err
+	}
+	configuration := signing.NewBitcoinConfiguration(
+		args.ScriptType, rootFingerprint, keypath, extendedPublicKey)
+
+	var change uint32
+	if args.Change {
+		change = 1
+	}
+	log := backend.log.WithField("coin", args.CoinCode)
+	result := make([]ExploredAddress, args.Count)
+	for i := range result {
+		relativeKeypath := signing.NewEmptyRelativeKeypath().
+			Child(change, signing.NonHardened).
+			Child(args.StartIndex+uint32(i), signing.NonHardened)
+		address := addresses.NewAccountAddress(configuration, relativeKeypath, btcCoin.Net(), log)
+		result[i] = ExploredAddress{
+			Keypath: address.AbsoluteKeypath().Encode(),
+			Address: address.EncodeForHumans(),
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExploreAddresses(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	args := ExploreAddressesArgs{
+		CoinCode:   coinpkg.CodeBTC,
+		ScriptType: signing.ScriptTypeP2WPKH,
+		Keypath:    "m/84'/0'/5'",
+		Change:     true,
+		StartIndex: 3,
+		Count:      2,
+	}
+	_, err := b.ExploreAddresses(args)
+	require.Equal(t, errExplorerNoKeystore, errp.Cause(err))
+
+	b.registerKeystore(makeBitBox02Multi())
+	exploredAddresses, err := b.ExploreAddresses(args)
+	require.NoError(t, err)
+	require.Len(t, exploredAddresses, 2)
+
+	// Derive the expected address independently.
+	keypath, err := signing.NewAbsoluteKeypath("m/84'/0'/5'/1/4")
+	require.NoError(t, err)
+	xpub, err := keystoreHelper1().ExtendedPublicKey(nil, keypath)
+	require.NoError(t, err)
+	publicKey, err := xpub.ECPubKey()
+	require.NoError(t, err)
+	expectedAddress, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(publicKey.SerializeCompressed()), &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t,
+		ExploredAddress{Keypath: "m/84'/0'/5'/1/4", Address: expectedAddress.EncodeAddress()},
+		exploredAddresses[1])
+	require.Equal(t, "m/84'/0'/5'/1/3", exploredAddresses[0].Keypath)
+
+	t.Run("errors", func(t *testing.T) {
+		invalid := func(modify func(args *ExploreAddressesArgs)) error {
+			invalidArgs := args
+			modify(&invalidArgs)
+			_, err := b.ExploreAddresses(invalidArgs)
+			return errp.Cause(err)
+		}
+		require.Equal(t, errExplorerInvalidCount,
+			invalid(func(args *ExploreAddressesArgs) { args.Count = 0 }))
+		require.Equal(t, errExplorerInvalidCount,
+			invalid(func(args *ExploreAddressesArgs) { args.Count = maxExploredAddresses + 1 }))
+		require.Equal(t, errExplorerInvalidCount,
+			invalid(func(args *ExploreAddressesArgs) { args.StartIndex = 1<<31 - 1 }))
+		require.Equal(t, errExplorerInvalidKeypath,
+			invalid(func(args *ExploreAddressesArgs) { args.Keypath = "84'/0'" }))
+		// The keystore does not support P2PKH.
+		require.Equal(t, errExplorerUnsupported,
+			invalid(func(args *ExploreAddressesArgs) { args.ScriptType = signing.ScriptTypeP2PKH }))
+		require.Equal(t, errExplorerUnsupported,
+			invalid(func(args *ExploreAddressesArgs) { args.CoinCode = coinpkg.CodeETH }))
+	})
+}
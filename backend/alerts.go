@@ -0,0 +1,169 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"math/big"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+)
+
+// AccountAlertType is the type of an account alert.
+type AccountAlertType string
+
+const (
+	// AccountAlertLowBalance is raised when the balance drops below the low balance threshold.
+	AccountAlertLowBalance AccountAlertType = "lowBalance"
+	// AccountAlertLargeOutflow is raised for a new outgoing transaction above the large outflow
+	// threshold.
+	AccountAlertLargeOutflow AccountAlertType = "largeOutflow"
+)
+
+// AccountAlert is sent to the frontend when an alert is raised, so it can notify the user.
+type AccountAlert struct {
+	Type        AccountAlertType   `json:"type"`
+	AccountCode accountsTypes.Code `json:"accountCode"`
+	AccountName string             `json:"accountName"`
+	// Amount is the balance for lowBalance and the sent amount for largeOutflow alerts.
+	Amount    string `json:"amount"`
+	Threshold string `json:"threshold"`
+	Unit      string `json:"unit"`
+	TxID      string `json:"txID,omitempty"`
+}
+
+// AccountAlertsInfo are the alert thresholds of an account in the unit of the account. Empty
+// values are disabled.
+type AccountAlertsInfo struct {
+	LowBalance   string `json:"lowBalance"`
+	LargeOutflow string `json:"largeOutflow"`
+	Unit         string `json:"unit"`
+}
+
+// AccountAlertsArgs are the alert thresholds to set, in the unit of the account (e.g. "0.5" for
+// BTC). Empty values disable the alert.
+type AccountAlertsArgs struct {
+	LowBalance   string `json:"lowBalance"`
+	LargeOutflow string `json:"largeOutflow"`
+}
+
+// alertThresholds are the parsed alert thresholds of an account, in the smallest unit. nil
+// thresholds are disabled.
+type alertThresholds struct {
+	lowBalance   *big.Int
+	largeOutflow *big.Int
+}
+
+// alertThresholds returns the configured alert thresholds of an account.
+func (backend *Backend) alertThresholds(accountCode accountsTypes.Code) alertThresholds {
+	thresholds := alertThresholds{}
+	persistedAccount := backend.config.AccountsConfig().Lookup(accountCode)
+	if persistedAccount == nil || persistedAccount.Alerts == nil {
+		return thresholds
+	}
+	parse := func(name string, value string) *big.Int {
+		if value == "" {
+			return nil
+		}
+		threshold, ok := new(big.Int).SetString(value, 10)
+		if !ok {
+			backend.log.Errorf("invalid %s alert of account %s", name, accountCode)
+			return nil
+		}
+		return threshold
+	}
+	thresholds.lowBalance = parse("low balance", persistedAccount.Alerts.LowBalance)
+	thresholds.largeOutflow = parse("large outflow", persistedAccount.Alerts.LargeOutflow)
+	return thresholds
+}
+
+// raiseAccountAlert notifies the frontend about an alert.
+func (backend *Backend) raiseAccountAlert(alert AccountAlert) {
+	backend.log.Infof("Account alert %s for account %s", alert.Type, alert.AccountCode)
+	backend.Notify(observable.Event{
+		Subject: "account-alert",
+		Action:  action.Replace,
+		Object:  alert,
+	})
+}
+
+// AccountAlerts returns the alert thresholds of an account.
+func (backend *Backend) AccountAlerts(accountCode accountsTypes.Code) (*AccountAlertsInfo, error) {
+	account := backend.Accounts().lookup(accountCode)
+	if account == nil {
+		return nil, errp.Newf("unknown account: %s", accountCode)
+	}
+	coin := account.Coin()
+	format := func(threshold *big.Int) string {
+		if threshold == nil {
+			return ""
+		}
+		return coin.FormatAmount(coinpkg.NewAmount(threshold), false)
+	}
+	thresholds := backend.alertThresholds(accountCode)
+	return &AccountAlertsInfo{
+		LowBalance:   format(thresholds.lowBalance),
+		LargeOutflow: format(thresholds.largeOutflow),
+		Unit:         coin.GetFormatUnit(false),
+	}, nil
+}
+
+// SetAccountAlerts sets the alert thresholds of an account.
+func (backend *Backend) SetAccountAlerts(accountCode accountsTypes.Code, args AccountAlertsArgs) error {
+	account := backend.Accounts().lookup(accountCode)
+	if account == nil {
+		return errp.Newf("unknown account: %s", accountCode)
+	}
+	parse := func(amount string) (string, error) {
+		if amount == "" {
+			return "", nil
+		}
+		parsed, err := account.Coin().ParseAmount(amount)
+		if err != nil {
+			return "", err
+		}
+		if parsed.BigInt().Sign() <= 0 {
+			return "", errp.New("the amount must be positive")
+		}
+		return parsed.BigInt().String(), nil
+	}
+	lowBalance, err := parse(args.LowBalance)
+	if err != nil {
+		return err
+	}
+	largeOutflow, err := parse(args.LargeOutflow)
+	if err != nil {
+		return err
+	}
+	return backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		acct := accountsConfig.Lookup(accountCode)
+		if acct == nil {
+			return errp.Newf("Could not find account %s", accountCode)
+		}
+		if lowBalance == "" && largeOutflow == "" {
+			acct.Alerts = nil
+			return nil
+		}
+		acct.Alerts = &config.AccountAlerts{
+			LowBalance:   lowBalance,
+			LargeOutflow: largeOutflow,
+		}
+		return nil
+	})
+}
@@ -371,6 +371,10 @@ func (backend *Backend) aoppChooseAccount(code accountsTypes.Code) {
 				backend.aoppSetError(errAOPPSigningAborted)
 				return
 			}
+			if errp.Cause(err) == keystore.ErrDeviceBusy {
+				backend.aoppSetError(keystore.ErrDeviceBusy)
+				return
+			}
 			log.WithError(err).Error("signing error")
 			backend.aoppSetError(errAOPPUnknown)
 			return
@@ -387,6 +391,10 @@ func (backend *Backend) aoppChooseAccount(code accountsTypes.Code) {
 				backend.aoppSetError(errAOPPSigningAborted)
 				return
 			}
+			if errp.Cause(err) == keystore.ErrDeviceBusy {
+				backend.aoppSetError(keystore.ErrDeviceBusy)
+				return
+			}
 			log.WithError(err).Error("signing error")
 			backend.aoppSetError(errAOPPUnknown)
 			return
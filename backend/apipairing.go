@@ -0,0 +1,70 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/apipairing"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox02-api-go/api/firmware"
+)
+
+var (
+	// errAPIPairingNoKeystore is the pairing error if no keystore is connected to confirm it.
+	errAPIPairingNoKeystore errp.ErrorCode = "keystoreUnavailable"
+	// errAPIPairingUnsupported is the pairing error if the keystore can't sign messages.
+	errAPIPairingUnsupported errp.ErrorCode = "messageSigningNotSupported"
+)
+
+// APIPairing returns the manager of the remote frontends paired with the API.
+func (backend *Backend) APIPairing() *apipairing.Manager {
+	return backend.apiPairing
+}
+
+// confirmAPIPairing shows the pairing message on the connected keystore by signing it with the
+// first BTC or ETH key, which the user must confirm. The keys are always the mainnet ones, as the
+// signature is only kept as a record of the approval.
+func (backend *Backend) confirmAPIPairing(message string) ([]byte, error) {
+	ks := backend.Keystore()
+	if ks == nil {
+		return nil, errp.WithStack(errAPIPairingNoKeystore)
+	}
+	switch {
+	case ks.CanSignMessage(coinpkg.CodeBTC):
+		keypath, err := signing.NewAbsoluteKeypath("m/84'/0'/0'/0/0")
+		if err != nil {
+			return nil, err
+		}
+		signature, err := ks.SignBTCMessage([]byte(message), keypath, signing.ScriptTypeP2WPKH)
+		if firmware.IsErrorAbort(err) || errp.Cause(err) == keystore.ErrSigningAborted {
+			return nil, errp.WithStack(apipairing.ErrRejected)
+		}
+		return signature, err
+	case ks.CanSignMessage(coinpkg.CodeETH):
+		keypath, err := signing.NewAbsoluteKeypath("m/44'/60'/0'/0/0")
+		if err != nil {
+			return nil, err
+		}
+		signature, err := ks.SignETHMessage([]byte(message), keypath)
+		if errp.Cause(err) == keystore.ErrSigningAborted {
+			return nil, errp.WithStack(apipairing.ErrRejected)
+		}
+		return signature, err
+	default:
+		return nil, errp.WithStack(errAPIPairingUnsupported)
+	}
+}
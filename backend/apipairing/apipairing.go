@@ -0,0 +1,473 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package apipairing pairs remote frontends with the backend API, e.g. a browser on another machine
+// when the backend runs headless, without sharing the main API token. A remote frontend requests a
+// pairing and shows a short code. The pairing message containing the code is signed by the
+// connected keystore, so the user approves the pairing by comparing the code on the device and
+// confirming. The remote frontend then receives a token scoped to the requested access. The token
+// is only handed out once and only its hash is persisted.
+package apipairing
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/sirupsen/logrus"
+)
+
+// Scope is the access granted to a paired frontend.
+type Scope string
+
+const (
+	// ScopeReadOnly allows viewing the accounts, balances and transactions, see readOnlyPaths and
+	// readOnlyAccountPaths.
+	ScopeReadOnly Scope = "read-only"
+	// ScopeFull allows all requests except the ones in mainTokenPathPrefixes.
+	ScopeFull Scope = "full"
+)
+
+// Valid returns true if the scope is one of the supported scopes.
+func (scope Scope) Valid() bool {
+	return scope == ScopeReadOnly || scope == ScopeFull
+}
+
+// mainTokenPathPrefixes are the API paths which are only accessible with the main token: managing
+// the paired frontends, and the local APIs for other apps and devices, whose status contains
+// pairing secrets and whose approvals must not be granted remotely.
+var mainTokenPathPrefixes = []string{
+	"/api/pairing/clients",
+	"/api/companion/",
+	"/api/extensions/",
+	"/api/hww-bridge/",
+}
+
+// readOnlyPaths are the API paths allowed by ScopeReadOnly with GET requests.
+var readOnlyPaths = map[string]struct{}{
+	"/api/version":                     {},
+	"/api/native-locale":               {},
+	"/api/detect-dark-theme":           {},
+	"/api/keystores":                   {},
+	"/api/accounts":                    {},
+	"/api/accounts/balance":            {},
+	"/api/accounts/balances":           {},
+	"/api/accounts/coins-balance":      {},
+	"/api/accounts/total-balance":      {},
+	"/api/accounts/alerts":             {},
+	"/api/account-summary":             {},
+	"/api/supported-coins":             {},
+	"/api/rates":                       {},
+	"/api/coins/convert-to-plain-fiat": {},
+	"/api/coins/convert-from-fiat":     {},
+	"/api/coins/convert-to-fiat-at":    {},
+}
+
+// accountPathPrefix is the API path of the account endpoints, followed by the account code and
+// the endpoint.
+const accountPathPrefix = "/api/account/"
+
+// readOnlyAccountPaths are the account endpoints allowed by ScopeReadOnly with GET requests.
+var readOnlyAccountPaths = map[string]struct{}{
+	"status":                   {},
+	"info":                     {},
+	"balance":                  {},
+	"balance-breakdown":        {},
+	"transactions":             {},
+	"transaction":              {},
+	"eth-pending-transactions": {},
+}
+
+// allowsReadOnly returns true if the path is one of readOnlyPaths or readOnlyAccountPaths.
+func allowsReadOnly(path string) bool {
+	if _, ok := readOnlyPaths[path]; ok {
+		return true
+	}
+	if !strings.HasPrefix(path, accountPathPrefix) {
+		return false
+	}
+	code, endpoint, ok := strings.Cut(strings.TrimPrefix(path, accountPathPrefix), "/")
+	if !ok || code == "" {
+		return false
+	}
+	_, ok = readOnlyAccountPaths[endpoint]
+	return ok
+}
+
+// allows returns true if the scope allows the request with the given method and path.
+func (scope Scope) allows(method string, path string) bool {
+	for _, prefix := range mainTokenPathPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+	switch scope {
+	case ScopeFull:
+		return true
+	case ScopeReadOnly:
+		return (method == http.MethodGet || method == http.MethodHead) && allowsReadOnly(path)
+	default:
+		return false
+	}
+}
+
+// RequestStatus is the state of a pairing request.
+type RequestStatus string
+
+const (
+	// RequestStatusPending means the pairing waits for the confirmation on the keystore.
+	RequestStatusPending RequestStatus = "pending"
+	// RequestStatusApproved means the user confirmed the pairing and a token was issued.
+	RequestStatusApproved RequestStatus = "approved"
+	// RequestStatusRejected means the user rejected the pairing on the keystore.
+	RequestStatusRejected RequestStatus = "rejected"
+	// RequestStatusFailed means the pairing could not be confirmed, e.g. because no keystore is
+	// connected. See Request.Error.
+	RequestStatusFailed RequestStatus = "failed"
+	// RequestStatusExpired means the pairing was not confirmed in time.
+	RequestStatusExpired RequestStatus = "expired"
+)
+
+const (
+	// requestTTL is how long a pairing request can be confirmed and its result fetched.
+	requestTTL = 5 * time.Minute
+	// maxNameLength is the max length of the name of a remote frontend, which is shown on the
+	// keystore.
+	maxNameLength = 40
+)
+
+var (
+	// ErrPairingPending is returned when requesting a pairing while another one waits for
+	// confirmation. Only one pairing can be confirmed on the keystore at a time.
+	ErrPairingPending errp.ErrorCode = "pairingPending"
+	// ErrUnknownRequest is returned for pairing requests which don't exist or expired.
+	ErrUnknownRequest errp.ErrorCode = "unknownPairingRequest"
+	// ErrUnknownClient is returned when referring to a paired frontend which does not exist.
+	ErrUnknownClient errp.ErrorCode = "unknownPairedClient"
+	// ErrInvalidName is returned if the name of the remote frontend is empty, too long or can't be
+	// shown on the keystore.
+	ErrInvalidName errp.ErrorCode = "invalidPairingName"
+	// ErrInvalidScope is returned for unknown scopes.
+	ErrInvalidScope errp.ErrorCode = "invalidPairingScope"
+	// ErrRejected must be returned by the ConfirmFunc if the user rejected the pairing.
+	ErrRejected errp.ErrorCode = "pairingRejected"
+)
+
+// ConfirmFunc shows the pairing message on the keystore and returns the keystore's signature of
+// it once the user confirmed. It returns ErrRejected if the user rejected the pairing.
+type ConfirmFunc func(message string) ([]byte, error)
+
+// Client is a paired remote frontend.
+type Client struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Scope     Scope     `json:"scope"`
+	CreatedAt time.Time `json:"createdAt"`
+	// TokenHash is the hex encoded SHA256 hash of the token issued to the frontend.
+	TokenHash string `json:"tokenHash"`
+	// Message is the pairing message confirmed on the keystore and Signature is the base64 encoded
+	// signature of it by the keystore.
+	Message   string `json:"message"`
+	Signature string `json:"signature"`
+}
+
+// Request is the state of a pairing request as seen by the remote frontend.
+type Request struct {
+	ID     string        `json:"id"`
+	Name   string        `json:"name"`
+	Scope  Scope         `json:"scope"`
+	Code   string        `json:"code"`
+	Status RequestStatus `json:"status"`
+	// Error is an error code if the status is RequestStatusFailed.
+	Error string `json:"error,omitempty"`
+	// Token is the issued API token. It is only set once, in the first status returned after the
+	// approval.
+	Token string `json:"token,omitempty"`
+
+	createdAt time.Time
+}
+
+// Manager handles the pairing requests and keeps the registry of paired frontends. The zero value
+// is not usable, use NewManager().
+type Manager struct {
+	observable.Implementation
+
+	file    *config.File
+	confirm ConfirmFunc
+
+	clients  []*Client
+	requests map[string]*Request
+	mu       locker.Locker
+
+	log *logrus.Entry
+}
+
+// NewManager creates a new manager. The paired frontends are persisted in the given file. confirm
+// is called to confirm a pairing on the keystore.
+func NewManager(filename string, confirm ConfirmFunc) *Manager {
+	manager := &Manager{
+		file:     config.NewFile(filepath.Dir(filename), filepath.Base(filename)),
+		confirm:  confirm,
+		clients:  []*Client{},
+		requests: map[string]*Request{},
+		log:      logging.Get().WithGroup("apipairing"),
+	}
+	if manager.file.Exists() {
+		if err := manager.file.ReadJSON(&manager.clients); err != nil {
+			manager.log.WithError(err).Error("Could not load the paired frontends")
+			manager.clients = []*Client{}
+		}
+	}
+	return manager
+}
+
+func (manager *Manager) notifyClients() {
+	manager.Notify(observable.Event{
+		Subject: "pairing/clients",
+		Action:  action.Reload,
+	})
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func randomHex(numBytes int) (string, error) {
+	value := make([]byte, numBytes)
+	if _, err := rand.Read(value); err != nil {
+		return "", errp.WithStack(err)
+	}
+	return hex.EncodeToString(value), nil
+}
+
+// randomCode returns a random six digit code, e.g. "012 345".
+func randomCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", errp.WithStack(err)
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	return code[:3] + " " + code[3:], nil
+}
+
+func validName(name string) bool {
+	if name == "" || len(name) > maxNameLength {
+		return false
+	}
+	// The keystore only shows printable ASCII messages as text.
+	for _, char := range name {
+		if char < ' ' || char > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// pairingMessage is the message confirmed and signed on the keystore.
+func pairingMessage(request *Request) string {
+	return fmt.Sprintf("Pair BitBoxApp frontend %q, access: %s. Code: %s",
+		request.Name, request.Scope, request.Code)
+}
+
+// removeExpired removes the requests which are older than requestTTL. Must be called with the lock
+// held.
+func (manager *Manager) removeExpired() {
+	for id, request := range manager.requests {
+		if time.Since(request.createdAt) > requestTTL {
+			delete(manager.requests, id)
+		}
+	}
+}
+
+// Request starts a pairing of a remote frontend with the given name and scope. The returned request
+// contains the code to be shown by the remote frontend. The user is asked to confirm the pairing
+// on the keystore in the background. Its result is polled with RequestStatus().
+func (manager *Manager) Request(name string, scope Scope) (*Request, error) {
+	name = strings.TrimSpace(name)
+	if !validName(name) {
+		return nil, errp.WithStack(ErrInvalidName)
+	}
+	if !scope.Valid() {
+		return nil, errp.WithStack(ErrInvalidScope)
+	}
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	code, err := randomCode()
+	if err != nil {
+		return nil, err
+	}
+	request := &Request{
+		ID:        id,
+		Name:      name,
+		Scope:     scope,
+		Code:      code,
+		Status:    RequestStatusPending,
+		createdAt: time.Now(),
+	}
+
+	unlock := manager.mu.Lock()
+	manager.removeExpired()
+	for _, other := range manager.requests {
+		if other.Status == RequestStatusPending {
+			unlock()
+			return nil, errp.WithStack(ErrPairingPending)
+		}
+	}
+	manager.requests[id] = request
+	result := *request
+	unlock()
+
+	manager.log.WithField("name", name).WithField("scope", scope).Info("pairing requested")
+	go manager.confirmRequest(request.ID, pairingMessage(request))
+	return &result, nil
+}
+
+// confirmRequest asks the user to confirm the pairing on the keystore and issues the token.
+func (manager *Manager) confirmRequest(id string, message string) {
+	signature, confirmErr := manager.confirm(message)
+
+	defer manager.mu.Lock()()
+	request, ok := manager.requests[id]
+	if !ok {
+		return
+	}
+	log := manager.log.WithField("name", request.Name)
+	switch {
+	case time.Since(request.createdAt) > requestTTL:
+		request.Status = RequestStatusExpired
+		return
+	case errp.Cause(confirmErr) == ErrRejected:
+		log.Info("pairing rejected")
+		request.Status = RequestStatusRejected
+		return
+	case confirmErr != nil:
+		log.WithError(confirmErr).Error("pairing could not be confirmed")
+		request.Status = RequestStatusFailed
+		request.Error = "unknown"
+		if errCode, ok := errp.Cause(confirmErr).(errp.ErrorCode); ok {
+			request.Error = string(errCode)
+		}
+		return
+	}
+
+	clientID, err := randomHex(8)
+	if err != nil {
+		request.Status = RequestStatusFailed
+		request.Error = "unknown"
+		return
+	}
+	token, err := randomHex(32)
+	if err != nil {
+		request.Status = RequestStatusFailed
+		request.Error = "unknown"
+		return
+	}
+	client := &Client{
+		ID:        clientID,
+		Name:      request.Name,
+		Scope:     request.Scope,
+		CreatedAt: time.Now(),
+		TokenHash: hashToken(token),
+		Message:   message,
+		Signature: base64.StdEncoding.EncodeToString(signature),
+	}
+	clients := append(append([]*Client{}, manager.clients...), client)
+	if err := manager.file.WriteJSON(clients); err != nil {
+		log.WithError(err).Error("Could not persist the paired frontend")
+		request.Status = RequestStatusFailed
+		request.Error = "unknown"
+		return
+	}
+	manager.clients = clients
+	request.Status = RequestStatusApproved
+	request.Token = token
+	log.WithField("id", clientID).WithField("scope", request.Scope).Info("frontend paired")
+	go manager.notifyClients()
+}
+
+// RequestStatus returns the state of the pairing request with the given ID. Once approved, the
+// token is returned once and the request is removed.
+func (manager *Manager) RequestStatus(id string) (*Request, error) {
+	defer manager.mu.Lock()()
+	manager.removeExpired()
+	request, ok := manager.requests[id]
+	if !ok {
+		return nil, errp.WithStack(ErrUnknownRequest)
+	}
+	result := *request
+	if request.Status != RequestStatusPending {
+		delete(manager.requests, id)
+	}
+	return &result, nil
+}
+
+// Clients returns the paired frontends.
+func (manager *Manager) Clients() []*Client {
+	defer manager.mu.RLock()()
+	return append([]*Client{}, manager.clients...)
+}
+
+// Revoke unpairs the frontend, invalidating its token.
+func (manager *Manager) Revoke(id string) error {
+	defer manager.mu.Lock()()
+	clients := []*Client{}
+	for _, client := range manager.clients {
+		if client.ID != id {
+			clients = append(clients, client)
+		}
+	}
+	if len(clients) == len(manager.clients) {
+		return errp.WithStack(ErrUnknownClient)
+	}
+	if err := manager.file.WriteJSON(clients); err != nil {
+		return err
+	}
+	manager.clients = clients
+	manager.log.WithField("id", id).Info("paired frontend revoked")
+	go manager.notifyClients()
+	return nil
+}
+
+// Authorize returns true if the token was issued to a paired frontend whose scope allows the
+// request with the given method and API path.
+func (manager *Manager) Authorize(token string, method string, path string) bool {
+	if token == "" {
+		return false
+	}
+	hash := []byte(hashToken(token))
+	defer manager.mu.RLock()()
+	for _, client := range manager.clients {
+		if subtle.ConstantTimeCompare(hash, []byte(client.TokenHash)) == 1 {
+			return client.Scope.allows(method, path)
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apipairing
+
+import (
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForStatus polls the request until it is not pending anymore.
+func waitForStatus(t *testing.T, manager *Manager, id string) *Request {
+	t.Helper()
+	var request *Request
+	require.Eventually(t, func() bool {
+		var err error
+		request, err = manager.RequestStatus(id)
+		require.NoError(t, err)
+		return request.Status != RequestStatusPending
+	}, 5*time.Second, 10*time.Millisecond)
+	return request
+}
+
+func TestPairing(t *testing.T) {
+	filename := filepath.Join(test.TstTempDir("apipairing"), "api-pairing.json")
+	confirmations := make(chan string, 1)
+	approve := make(chan error)
+	confirm := func(message string) ([]byte, error) {
+		confirmations <- message
+		if err := <-approve; err != nil {
+			return nil, err
+		}
+		return []byte("signature"), nil
+	}
+	manager := NewManager(filename, confirm)
+
+	_, err := manager.Request("", ScopeFull)
+	require.Equal(t, ErrInvalidName, errp.Cause(err))
+	_, err = manager.Request("laptop\n", "admin")
+	require.Equal(t, ErrInvalidScope, errp.Cause(err))
+	_, err = manager.Request("laptop ✓", ScopeFull)
+	require.Equal(t, ErrInvalidName, errp.Cause(err))
+
+	request, err := manager.Request(" laptop ", ScopeReadOnly)
+	require.NoError(t, err)
+	require.Equal(t, "laptop", request.Name)
+	require.Equal(t, RequestStatusPending, request.Status)
+	require.Regexp(t, regexp.MustCompile(`^\d{3} \d{3}$`), request.Code)
+	require.Empty(t, request.Token)
+
+	// The code is shown on the keystore.
+	message := <-confirmations
+	require.Contains(t, message, request.Code)
+	require.Contains(t, message, `"laptop"`)
+	require.Contains(t, message, "read-only")
+
+	// Only one pairing can be confirmed at a time.
+	_, err = manager.Request("phone", ScopeFull)
+	require.Equal(t, ErrPairingPending, errp.Cause(err))
+
+	status, err := manager.RequestStatus(request.ID)
+	require.NoError(t, err)
+	require.Equal(t, RequestStatusPending, status.Status)
+
+	approve <- nil
+	status = waitForStatus(t, manager, request.ID)
+	require.Equal(t, RequestStatusApproved, status.Status)
+	token := status.Token
+	require.Len(t, token, 64)
+
+	// The token is only handed out once.
+	_, err = manager.RequestStatus(request.ID)
+	require.Equal(t, ErrUnknownRequest, errp.Cause(err))
+
+	clients := manager.Clients()
+	require.Len(t, clients, 1)
+	require.Equal(t, "laptop", clients[0].Name)
+	require.Equal(t, ScopeReadOnly, clients[0].Scope)
+	require.Equal(t, message, clients[0].Message)
+	require.Equal(t, "c2lnbmF0dXJl", clients[0].Signature)
+	require.NotContains(t, clients[0].TokenHash, token)
+
+	require.True(t, manager.Authorize(token, http.MethodGet, "/api/accounts"))
+	require.False(t, manager.Authorize(token, http.MethodPost, "/api/accounts/btc-0/sendtx"))
+	require.False(t, manager.Authorize(token, http.MethodGet, "/api/pairing/clients"))
+	require.True(t, manager.Authorize(token, http.MethodGet, "/api/account/btc-0/transactions"))
+	// Read-only GET requests are limited to viewing the accounts.
+	require.False(t, manager.Authorize(token, http.MethodGet, "/api/config"))
+	require.False(t, manager.Authorize(token, http.MethodGet, "/api/companion/host/status"))
+	require.False(t, manager.Authorize(token, http.MethodGet, "/api/account/btc-0/receive-addresses"))
+	require.False(t, manager.Authorize(token, http.MethodGet, "/api/account/btc-0/transactions/export"))
+	require.False(t, manager.Authorize(token, http.MethodGet, "/api/account//transactions"))
+	require.False(t, manager.Authorize("wrong", http.MethodGet, "/api/accounts"))
+	require.False(t, manager.Authorize("", http.MethodGet, "/api/accounts"))
+
+	// The paired frontends are persisted.
+	manager = NewManager(filename, confirm)
+	require.True(t, manager.Authorize(token, http.MethodGet, "/api/accounts"))
+
+	// A rejected pairing issues no token.
+	request, err = manager.Request("phone", ScopeFull)
+	require.NoError(t, err)
+	<-confirmations
+	approve <- errp.WithStack(ErrRejected)
+	status = waitForStatus(t, manager, request.ID)
+	require.Equal(t, RequestStatusRejected, status.Status)
+	require.Empty(t, status.Token)
+
+	// Failures are reported with their error code.
+	request, err = manager.Request("phone", ScopeFull)
+	require.NoError(t, err)
+	<-confirmations
+	approve <- errp.ErrorCode("keystoreUnavailable")
+	status = waitForStatus(t, manager, request.ID)
+	require.Equal(t, RequestStatusFailed, status.Status)
+	require.Equal(t, "keystoreUnavailable", status.Error)
+
+	// A full scope allows all requests except managing the paired frontends.
+	request, err = manager.Request("phone", ScopeFull)
+	require.NoError(t, err)
+	<-confirmations
+	approve <- nil
+	fullToken := waitForStatus(t, manager, request.ID).Token
+	require.True(t, manager.Authorize(fullToken, http.MethodPost, "/api/accounts/btc-0/sendtx"))
+	require.False(t, manager.Authorize(fullToken, http.MethodPost, "/api/pairing/clients/revoke"))
+	require.False(t, manager.Authorize(fullToken, http.MethodGet, "/api/companion/host/status"))
+	require.False(t, manager.Authorize(fullToken, http.MethodPost, "/api/hww-bridge/set-approval"))
+	require.True(t, manager.Authorize(fullToken, http.MethodGet, "/api/config"))
+	require.Len(t, manager.Clients(), 2)
+
+	require.Equal(t, ErrUnknownClient, errp.Cause(manager.Revoke("unknown")))
+	require.NoError(t, manager.Revoke(clients[0].ID))
+	require.False(t, manager.Authorize(token, http.MethodGet, "/api/accounts"))
+	require.True(t, manager.Authorize(fullToken, http.MethodGet, "/api/accounts"))
+	require.Len(t, manager.Clients(), 1)
+}
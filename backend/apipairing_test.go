@@ -0,0 +1,71 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/apipairing"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmAPIPairing(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	_, err := b.confirmAPIPairing("message")
+	require.Equal(t, errAPIPairingNoKeystore, errp.Cause(err))
+
+	ks := makeBitBox02Multi()
+	ks.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	canSign := map[coinpkg.Code]bool{}
+	ks.CanSignMessageFunc = func(code coinpkg.Code) bool { return canSign[code] }
+	var signErr error
+	ks.SignBTCMessageFunc = func(
+		message []byte, keypath signing.AbsoluteKeypath, scriptType signing.ScriptType) ([]byte, error) {
+		require.Equal(t, "message", string(message))
+		require.Equal(t, "m/84'/0'/0'/0/0", keypath.Encode())
+		require.Equal(t, signing.ScriptTypeP2WPKH, scriptType)
+		return []byte("btc signature"), signErr
+	}
+	ks.SignETHMessageFunc = func(message []byte, keypath signing.AbsoluteKeypath) ([]byte, error) {
+		require.Equal(t, "m/44'/60'/0'/0/0", keypath.Encode())
+		return []byte("eth signature"), signErr
+	}
+	b.registerKeystore(ks)
+
+	_, err = b.confirmAPIPairing("message")
+	require.Equal(t, errAPIPairingUnsupported, errp.Cause(err))
+
+	canSign[coinpkg.CodeETH] = true
+	signature, err := b.confirmAPIPairing("message")
+	require.NoError(t, err)
+	require.Equal(t, "eth signature", string(signature))
+
+	canSign[coinpkg.CodeBTC] = true
+	signature, err = b.confirmAPIPairing("message")
+	require.NoError(t, err)
+	require.Equal(t, "btc signature", string(signature))
+
+	signErr = errp.WithStack(keystore.ErrSigningAborted)
+	_, err = b.confirmAPIPairing("message")
+	require.Equal(t, apipairing.ErrRejected, errp.Cause(err))
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
+)
+
+// AppActivity is the visibility and idle state of the app, as reported by the frontend.
+type AppActivity struct {
+	// Visible is false if the app is hidden, e.g. minimized or in the background.
+	Visible bool `json:"visible"`
+	// Idle is true if the user did not interact with the app for a while.
+	Idle bool `json:"idle"`
+}
+
+func setCoinIdle(coin coinpkg.Coin, idle bool) {
+	if ethCoin, ok := coin.(*eth.Coin); ok {
+		ethCoin.SetIdle(idle)
+	}
+}
+
+func (backend *Backend) appIdle() bool {
+	defer backend.appIdleLock.RLock()()
+	return backend.isAppIdle
+}
+
+// SetAppActivity throttles the background syncing and the rates updates while the app is hidden
+// or idle, to save CPU and battery. When the app is used again, everything is updated immediately.
+//
+// BTC-based accounts are not affected, as they are notified of changes by the Electrum servers
+// instead of polling.
+func (backend *Backend) SetAppActivity(activity AppActivity) {
+	idle := !activity.Visible || activity.Idle
+	unlock := backend.appIdleLock.Lock()
+	changed := idle != backend.isAppIdle
+	backend.isAppIdle = idle
+	unlock()
+	if !changed {
+		return
+	}
+	backend.log.WithField("idle", idle).Debug("app activity changed")
+
+	backend.ratesUpdater.SetIdle(idle)
+	func() {
+		defer backend.coinsLock.RLock()()
+		for _, coin := range backend.coins {
+			setCoinIdle(coin, idle)
+		}
+	}()
+	if idle {
+		return
+	}
+	defer backend.accountsAndKeystoreLock.RLock()()
+	for _, account := range backend.accounts {
+		if ethAccount, ok := account.(*eth.Account); ok {
+			ethAccount.PollNow()
+		}
+	}
+}
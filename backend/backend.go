@@ -16,35 +16,53 @@
 package backend
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
 	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/apipairing"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/arguments"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/bandwidth"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/banners"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/buildinfo"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/electrum"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/contracts"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/etherscan"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/ltc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/companion"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bitbox"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bitbox02"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/device"
 	deviceevent "github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/device/event"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/usb"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/extensions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/feehistory"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/hwwbridge"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/invoices"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore/software"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/metadatasync"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rules"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/scheduledexport"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/sshtunnel"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/webhooks"
 	utilConfig "github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
@@ -56,6 +74,7 @@ import (
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/net/proxy"
 )
 
 func init() {
@@ -191,9 +210,19 @@ type Backend struct {
 	notifier *Notifier
 
 	devices map[string]device.Interface
+	// usbManager registers the plugged in devices. It is nil until Start() is called.
+	usbManager *usb.Manager
 
 	accountsAndKeystoreLock locker.Locker
 	accounts                AccountsList
+	// closed is true after Close() was called. Guarded by accountsAndKeystoreLock.
+	closed bool
+	// accountInitSlots limits how many accounts are initialized concurrently, see
+	// initializeAccount().
+	accountInitSlots chan struct{}
+	// lazyAccounts are the accounts which were not initialized yet because the lazy account
+	// initialization mode is enabled, see InitializeAccounts().
+	lazyAccounts map[accountsTypes.Code]accounts.Interface
 	// keystore is nil if no keystore is connected.
 	keystore keystore.Keystore
 
@@ -222,8 +251,81 @@ type Backend struct {
 	// can be a regular or, if Tor is enabled in the config, a SOCKS5 proxy client.
 	httpClient          *http.Client
 	etherScanHTTPClient *http.Client
+	contractRegistry    *contracts.Registry
 	ratesUpdater        *rates.RateUpdater
+	bandwidthMeter      *bandwidth.Meter
+	sshTunnels          *sshtunnel.Manager
 	banners             *banners.Banners
+	hwwBridge           *hwwbridge.Bridge
+	extensions          *extensions.Server
+	apiPairing          *apipairing.Manager
+	companionHost       *companion.Host
+	companionClient     *companion.Client
+	invoices            *invoices.Manager
+	webhooks            *webhooks.Dispatcher
+	scheduledExport     *scheduledexport.Scheduler
+	metadataSync        *metadatasync.Syncer
+	feeHistory          *feehistory.Recorder
+
+	// mobileDataSaverActive is true if the mobile data saver mode is applied, see
+	// UpdateMobileDataSaver().
+	mobileDataSaverActive bool
+	mobileDataSaverLock   locker.Locker
+
+	// isAppIdle is true if the app is hidden or not in use, see SetAppActivity().
+	isAppIdle   bool
+	appIdleLock locker.Locker
+
+	// accountEventsTrackers detect the account changes sent to webhooks, see checkAccountEvents().
+	accountEventsTrackers     map[accountsTypes.Code]*accountEventsTracker
+	accountEventsTrackersLock locker.Locker
+
+	// frontendSessionState is an opaque value stored by the frontend, see Session().
+	frontendSessionState     json.RawMessage
+	frontendSessionStateLock locker.Locker
+
+	// paymentRequests are payment URIs opened through the OS, waiting to be handled by the
+	// frontend.
+	paymentRequests      []*PaymentRequest
+	nextPaymentRequestID int
+	paymentRequestsLock  locker.Locker
+
+	// ruleEngine evaluates the automation rules, see evaluateRules().
+	ruleEngine *rules.Engine
+	// ruleDrafts are the drafts prepared by automation rules, waiting to be reviewed by the user.
+	ruleDrafts      []*RuleDraft
+	nextRuleDraftID int
+	ruleDraftsLock  locker.Locker
+
+	dataDirMoveStatus     DataDirMoveStatus
+	dataDirMoveStatusLock locker.Locker
+
+	clockSkew     ClockSkewStatus
+	clockSkewLock locker.Locker
+
+	// deviceCapabilities are the last known capabilities of the devices by device ID, see
+	// notifyDeviceCapabilities().
+	deviceCapabilities     map[string][]bitbox02.Capability
+	deviceCapabilitiesLock locker.Locker
+
+	// internalTransfersLock serializes the detection of internal transfers run after each sync.
+	internalTransfersLock locker.Locker
+
+	// interruptedSignings keeps the tx proposals whose signing was interrupted by the device
+	// disconnecting, as the accounts are recreated when it reconnects.
+	interruptedSignings *accounts.InterruptedSignings
+
+	buildInfo     *buildinfo.Info
+	buildInfoOnce sync.Once
+
+	// startupStatus is the readiness of the tasks started in the background, see
+	// runStartupTask().
+	startupStatus     map[StartupTask]StartupTaskStatus
+	startupStatusLock locker.Locker
+
+	// updateFile is the result of the update check at startup, nil if there is no update.
+	updateFile     *UpdateFile
+	updateFileLock locker.Locker
 
 	// For unit tests, called when `backend.checkAccountUsed()` is called.
 	tstCheckAccountUsed func(accounts.Interface) bool
@@ -234,20 +336,28 @@ type Backend struct {
 // NewBackend creates a new backend with the given arguments.
 func NewBackend(arguments *arguments.Arguments, environment Environment) (*Backend, error) {
 	log := logging.Get().WithGroup("backend")
+	if err := utilConfig.CustomAppDirError(); err != nil {
+		log.WithError(err).Error("Using the default data directory")
+	}
 	config, err := config.NewConfig(arguments.AppConfigFilename(), arguments.AccountsConfigFilename())
 	if err != nil {
 		return nil, errp.WithStack(err)
 	}
-	log.Infof("backend config: %+v", config.AppConfig().Backend)
+	log.Infof("backend config: %+v", config.AppConfig().Redacted().Backend)
 	log.Infof("frontend config: %+v", config.AppConfig().Frontend)
+	proxyConfig := config.AppConfig().Backend.Proxy
 	backendProxy := socksproxy.NewSocksProxy(
-		config.AppConfig().Backend.Proxy.UseProxy,
-		config.AppConfig().Backend.Proxy.ProxyAddress,
+		proxyConfig.UseProxy || proxyConfig.TorOnly,
+		proxyConfig.ProxyAddress,
 	)
 	hclient, err := backendProxy.GetHTTPClient()
 	if err != nil {
 		return nil, err
 	}
+	bandwidthMeter := bandwidth.NewMeter(environment.UsingMobileData, func() uint64 {
+		return config.AppConfig().Backend.MobileDataBudgetMB * 1000 * 1000
+	})
+	explorerClient := bandwidthMeter.Client(bandwidth.SubsystemExplorer, hclient)
 
 	backend := &Backend{
 		arguments:   arguments,
@@ -260,8 +370,15 @@ func NewBackend(arguments *arguments.Arguments, environment Environment) (*Backe
 		accounts: []accounts.Interface{},
 		aopp:     AOPP{State: aoppStateInactive},
 
+		accountInitSlots: make(chan struct{}, accountInitWorkers),
+		lazyAccounts:     map[accountsTypes.Code]accounts.Interface{},
+
+		accountEventsTrackers: map[accountsTypes.Code]*accountEventsTracker{},
+		ruleEngine:            rules.NewEngine(),
+		interruptedSignings:   accounts.NewInterruptedSignings(),
+
 		makeBtcAccount: func(config *accounts.AccountConfig, coin *btc.Coin, gapLimits *types.GapLimits, log *logrus.Entry) accounts.Interface {
-			return btc.NewAccount(config, coin, gapLimits, log, hclient)
+			return btc.NewAccount(config, coin, gapLimits, log, explorerClient)
 		},
 		makeEthAccount: func(config *accounts.AccountConfig, coin *eth.Coin, httpClient *http.Client, log *logrus.Entry) accounts.Interface {
 			return eth.NewAccount(config, coin, httpClient, log)
@@ -276,18 +393,89 @@ func NewBackend(arguments *arguments.Arguments, environment Environment) (*Backe
 	backend.notifier = notifier
 	backend.socksProxy = backendProxy
 	backend.httpClient = hclient
-	backend.etherScanHTTPClient = ratelimit.FromTransport(hclient.Transport, etherscan.CallInterval)
+	backend.bandwidthMeter = bandwidthMeter
+	backend.bandwidthMeter.Observe(backend.Notify)
+	backend.sshTunnels = sshtunnel.NewManager(backend.sshTunnelConfigs)
+	backend.sshTunnels.Observe(backend.Notify)
+	backend.etherScanHTTPClient = ratelimit.FromTransport(explorerClient.Transport, etherscan.CallInterval)
+
+	backend.contractRegistry = contracts.NewRegistry(
+		filepath.Join(arguments.CacheDirectoryPath(), "eth-contracts.json"))
 
 	ratesCache := filepath.Join(arguments.CacheDirectoryPath(), "exchangerates")
 	if err := os.MkdirAll(ratesCache, 0700); err != nil {
 		log.Errorf("RateUpdater DB cache dir: %v", err)
 	}
-	backend.ratesUpdater = rates.NewRateUpdater(hclient, ratesCache)
+	backend.ratesUpdater = rates.NewRateUpdater(
+		bandwidthMeter.Client(bandwidth.SubsystemRates, hclient), ratesCache)
 	backend.ratesUpdater.Observe(backend.Notify)
 
-	backend.banners = banners.NewBanners()
+	backend.banners = banners.NewBanners(
+		filepath.Join(arguments.MainDirectoryPath(), "banners-state.json"), Version, runtime.GOOS)
 	backend.banners.Observe(backend.Notify)
 
+	backend.hwwBridge = hwwbridge.NewBridge(
+		hwwbridge.DefaultAddress, backend.Keystore, backend.Coin, backend.signBridgePSBT)
+	backend.hwwBridge.Observe(backend.Notify)
+
+	backend.extensions = extensions.NewServer(
+		extensions.DefaultAddress,
+		filepath.Join(arguments.MainDirectoryPath(), "extensions.json"),
+		backend.portfolioBalances,
+		backend.ratesUpdater.LatestPrice,
+	)
+	backend.extensions.Observe(backend.Notify)
+
+	backend.apiPairing = apipairing.NewManager(
+		filepath.Join(arguments.MainDirectoryPath(), "api-pairing.json"),
+		backend.confirmAPIPairing,
+	)
+	backend.apiPairing.Observe(backend.Notify)
+
+	backend.companionHost = companion.NewHost(
+		arguments.MainDirectoryPath(),
+		fmt.Sprintf(":%d", companion.DefaultPort),
+		backend.portfolioBalances,
+	)
+	backend.companionHost.Observe(backend.Notify)
+	backend.companionClient = companion.NewClient(
+		filepath.Join(arguments.MainDirectoryPath(), "companion.json"))
+	backend.companionClient.Observe(backend.Notify)
+
+	backend.invoices = invoices.NewManager(
+		filepath.Join(arguments.MainDirectoryPath(), "invoices.json"),
+		backend.invoiceUnusedAddresses,
+		backend.invoiceReceived,
+		hclient,
+	)
+	backend.invoices.Observe(backend.Notify)
+
+	backend.scheduledExport = scheduledexport.NewScheduler(
+		filepath.Join(arguments.MainDirectoryPath(), "scheduled-export.json"),
+		backend.scheduledExportSettings,
+		backend.scheduledTransactionExports,
+		backend.settingsBackup,
+	)
+	backend.scheduledExport.Observe(backend.Notify)
+
+	backend.metadataSync = metadatasync.NewSyncer(
+		filepath.Join(arguments.MainDirectoryPath(), "metadata-sync.json"),
+		backend.metadataSyncSettings,
+		hclient,
+		backend.metadataSyncValues,
+		backend.applyMetadataSyncValue,
+	)
+	backend.metadataSync.Observe(backend.Notify)
+
+	backend.feeHistory = feehistory.NewRecorder(
+		filepath.Join(arguments.MainDirectoryPath(), "fee-history.json"),
+		backend.feeHistoryCoins,
+		backend.feeHistoryEstimate,
+	)
+	backend.feeHistory.Observe(backend.Notify)
+
+	backend.webhooks = webhooks.NewDispatcher(backend.webhookConfigs, hclient)
+
 	return backend, nil
 }
 
@@ -297,11 +485,16 @@ func NewBackend(arguments *arguments.Arguments, environment Environment) (*Backe
 // The accountsAndKeystoreLock must be held when calling this function.
 func (backend *Backend) configureHistoryExchangeRates() {
 	var coins []string
-	for _, acct := range backend.accounts {
-		coins = append(coins, string(acct.Coin().Code()))
+	// Chart history is not fetched in the mobile data saver mode.
+	if !backend.MobileDataSaverActive() {
+		for _, acct := range backend.accounts {
+			coins = append(coins, string(acct.Coin().Code()))
+		}
 	}
 	fiats := backend.config.AppConfig().Backend.FiatList
 	backend.ratesUpdater.ReconfigureHistory(coins, fiats)
+	backend.ratesUpdater.SetLatestCurrencies(
+		append([]string{backend.config.AppConfig().Backend.MainFiat}, fiats...))
 }
 
 func (backend *Backend) notifyNewTxs(account accounts.Interface) {
@@ -327,6 +520,28 @@ func (backend *Backend) notifyNewTxs(account accounts.Interface) {
 	}
 }
 
+// saveAccountCheckpoint persists the balance and the recent transactions of the synced account, so
+// that they can be shown right away at the next startup, see accounts.Checkpoint.
+func (backend *Backend) saveAccountCheckpoint(account accounts.Interface) {
+	if account.FatalError() || !account.Synced() {
+		return
+	}
+	log := backend.log.WithField("accountCode", account.Config().Config.Code)
+	balance, err := account.Balance()
+	if err != nil {
+		log.WithError(err).Error("Could not get the balance for the account checkpoint")
+		return
+	}
+	transactions, err := account.Transactions()
+	if err != nil {
+		log.WithError(err).Error("Could not get the transactions for the account checkpoint")
+		return
+	}
+	if err := account.SaveCheckpoint(balance, transactions); err != nil {
+		log.WithError(err).Error("Could not save the account checkpoint")
+	}
+}
+
 // Config returns the app config.
 func (backend *Backend) Config() *config.Config {
 	return backend.config
@@ -411,20 +626,12 @@ func (backend *Backend) DefaultAppConfig() config.AppConfig {
 }
 
 func (backend *Backend) defaultProdServers(code coinpkg.Code) []*config.ServerInfo {
-	switch code {
-	case coinpkg.CodeBTC:
-		return backend.config.AppConfig().Backend.BTC.ElectrumServers
-	case coinpkg.CodeTBTC:
-		return backend.config.AppConfig().Backend.TBTC.ElectrumServers
-	case coinpkg.CodeRBTC:
-		return backend.config.AppConfig().Backend.RBTC.ElectrumServers
-	case coinpkg.CodeLTC:
-		return backend.config.AppConfig().Backend.LTC.ElectrumServers
-	case coinpkg.CodeTLTC:
-		return backend.config.AppConfig().Backend.TLTC.ElectrumServers
-	default:
+	appConfig := backend.config.AppConfig()
+	servers, ok := appConfig.Backend.ElectrumServers(code)
+	if !ok {
 		panic(errp.Newf("The given code %s is unknown.", code))
 	}
+	return servers
 }
 
 func defaultDevServers(code coinpkg.Code) []*config.ServerInfo {
@@ -483,28 +690,28 @@ func (backend *Backend) Coin(code coinpkg.Code) (coinpkg.Coin, error) {
 	}
 	dbFolder := backend.arguments.CacheDirectoryPath()
 
-	erc20Token := erc20TokenByCode(code)
+	erc20Token := backend.erc20TokenByCode(code)
 	btcFormatUnit := backend.config.AppConfig().Backend.BtcUnit
 	switch {
 	case code == coinpkg.CodeRBTC:
 		servers := backend.defaultElectrumXServers(code)
-		coin = btc.NewCoin(coinpkg.CodeRBTC, "Bitcoin Regtest", "RBTC", coinpkg.BtcUnitDefault, &chaincfg.RegressionNetParams, dbFolder, servers, "", backend.socksProxy)
+		coin = btc.NewCoin(coinpkg.CodeRBTC, "Bitcoin Regtest", "RBTC", coinpkg.BtcUnitDefault, &chaincfg.RegressionNetParams, dbFolder, servers, "", backend.electrumDialer())
 	case code == coinpkg.CodeTBTC:
 		servers := backend.defaultElectrumXServers(code)
 		coin = btc.NewCoin(coinpkg.CodeTBTC, "Bitcoin Testnet", "TBTC", btcFormatUnit, &chaincfg.TestNet3Params, dbFolder, servers,
-			"https://blockstream.info/testnet/tx/", backend.socksProxy)
+			"https://blockstream.info/testnet/tx/", backend.electrumDialer())
 	case code == coinpkg.CodeBTC:
 		servers := backend.defaultElectrumXServers(code)
 		coin = btc.NewCoin(coinpkg.CodeBTC, "Bitcoin", "BTC", btcFormatUnit, &chaincfg.MainNetParams, dbFolder, servers,
-			"https://blockstream.info/tx/", backend.socksProxy)
+			"https://blockstream.info/tx/", backend.electrumDialer())
 	case code == coinpkg.CodeTLTC:
 		servers := backend.defaultElectrumXServers(code)
 		coin = btc.NewCoin(coinpkg.CodeTLTC, "Litecoin Testnet", "TLTC", coinpkg.BtcUnitDefault, &ltc.TestNet4Params, dbFolder, servers,
-			"https://sochain.com/tx/LTCTEST/", backend.socksProxy)
+			"https://sochain.com/tx/LTCTEST/", backend.electrumDialer())
 	case code == coinpkg.CodeLTC:
 		servers := backend.defaultElectrumXServers(code)
 		coin = btc.NewCoin(coinpkg.CodeLTC, "Litecoin", "LTC", coinpkg.BtcUnitDefault, &ltc.MainNetParams, dbFolder, servers,
-			"https://blockchair.com/litecoin/transaction/", backend.socksProxy)
+			"https://blockchair.com/litecoin/transaction/", backend.electrumDialer())
 	case code == coinpkg.CodeETH:
 		etherScan := etherscan.NewEtherScan("https://api.etherscan.io/api", backend.etherScanHTTPClient)
 		coin = eth.NewCoin(etherScan, code, "Ethereum", "ETH", "ETH", params.MainnetChainConfig,
@@ -533,11 +740,48 @@ func (backend *Backend) Coin(code coinpkg.Code) (coinpkg.Coin, error) {
 	default:
 		return nil, errp.Newf("unknown coin code %s", code)
 	}
+	if code == coinpkg.CodeETH || erc20Token != nil {
+		// The registry only contains mainnet contracts.
+		coin.(*eth.Coin).SetContractRegistry(backend.contractRegistry)
+	}
+	setCoinDataSaver(coin, backend.MobileDataSaverActive())
+	setCoinIdle(coin, backend.appIdle())
 	backend.coins[code] = coin
 	coin.Observe(backend.Notify)
 	return coin, nil
 }
 
+// LikelyCoinForAddress returns the first loaded coin, other than `exclude`, for which the address
+// is valid. This is used to warn the user if they try to send to an address belonging to a
+// different coin, e.g. a LTC address in a BTC account. ERC20 tokens are not considered, as their
+// addresses are the same as the ones of their parent chain.
+func (backend *Backend) LikelyCoinForAddress(address string, exclude coinpkg.Code) (coinpkg.Coin, bool) {
+	defer backend.coinsLock.RLock()()
+	codes := make([]string, 0, len(backend.coins))
+	for code := range backend.coins {
+		codes = append(codes, string(code))
+	}
+	// Sorted for a deterministic result, as some addresses are valid for multiple coins, e.g.
+	// legacy testnet addresses.
+	sort.Strings(codes)
+	for _, code := range codes {
+		if coinpkg.Code(code) == exclude {
+			continue
+		}
+		switch specificCoin := backend.coins[coinpkg.Code(code)].(type) {
+		case *btc.Coin:
+			if _, err := specificCoin.DecodeAddress(address); err == nil {
+				return specificCoin, true
+			}
+		case *eth.Coin:
+			if specificCoin.ERC20Token() == nil && eth.IsValidEthAddress(address) {
+				return specificCoin, true
+			}
+		}
+	}
+	return nil, false
+}
+
 // Testing returns whether this backend is for testing only.
 func (backend *Backend) Testing() bool {
 	return backend.arguments.Testing()
@@ -580,29 +824,76 @@ func (backend *Backend) OnDeviceUninit(f func(string)) {
 // Start starts the background services. It returns a channel of events to handle by the library
 // client.
 func (backend *Backend) Start() <-chan interface{} {
-	usb.NewManager(
+	backend.usbManager = usb.NewManager(
 		backend.arguments.MainDirectoryPath(),
 		backend.arguments.BitBox02DirectoryPath(),
 		backend.socksProxy,
 		backend.environment.DeviceInfos,
 		backend.Register,
-		backend.Deregister).Start()
+		backend.Deregister)
+	backend.usbManager.Start()
 
-	httpClient, err := backend.socksProxy.GetHTTPClient()
-	if err != nil {
-		backend.log.Error(err.Error())
-	} else {
-		go backend.banners.Init(httpClient)
-	}
+	// The tasks depending on third-party servers run in the background, so that the accounts are
+	// loaded right away even if the servers are slow or unreachable.
+	backend.runStartupTask(StartupTaskBanners, func() error {
+		httpClient, err := backend.socksProxy.GetHTTPClient()
+		if err != nil {
+			return err
+		}
+		backend.banners.Init(httpClient)
+		return nil
+	})
+	backend.runStartupTask(StartupTaskUpdate, backend.checkForUpdateAtStartup)
+	backend.runStartupTask(StartupTaskBuildInfo, func() error {
+		backend.BuildInfo()
+		return nil
+	})
+	go backend.checkClockSkew()
 
-	defer backend.accountsAndKeystoreLock.Lock()()
+	backend.UpdateMobileDataSaver()
+
+	unlock := backend.accountsAndKeystoreLock.Lock()
 	backend.initPersistedAccounts()
 	backend.emitAccountsStatusChanged()
+	unlock()
 
 	backend.ratesUpdater.StartCurrentRates()
-	backend.configureHistoryExchangeRates()
+	backend.runStartupTask(StartupTaskRates, func() error {
+		defer backend.accountsAndKeystoreLock.RLock()()
+		if backend.closed {
+			return errp.New("backend closed")
+		}
+		backend.configureHistoryExchangeRates()
+		return nil
+	})
+	backend.bandwidthMeter.Start()
 
 	backend.environment.OnAuthSettingChanged(backend.config.AppConfig().Backend.Authentication)
+
+	if backend.config.AppConfig().Backend.HWWBridgeEnabled {
+		if err := backend.hwwBridge.Start(); err != nil {
+			backend.log.WithError(err).Error("Could not start the HWW bridge")
+		}
+	}
+	if backend.config.AppConfig().Backend.ExtensionsEnabled {
+		if err := backend.extensions.Start(); err != nil {
+			backend.log.WithError(err).Error("Could not start the extension API")
+		}
+	}
+	if backend.config.AppConfig().Backend.CompanionHostEnabled {
+		if err := backend.companionHost.Start(); err != nil {
+			backend.log.WithError(err).Error("Could not start the companion host")
+		}
+	}
+	if backend.config.AppConfig().Backend.Proxy.TorOnly {
+		backend.log.Info("Tor-only mode: not connecting to the companion host")
+	} else {
+		backend.companionClient.Start()
+	}
+	backend.invoices.Start()
+	backend.scheduledExport.Start()
+	backend.metadataSync.Start()
+	backend.feeHistory.Start()
 	return backend.events
 }
 
@@ -611,6 +902,16 @@ func (backend *Backend) DevicesRegistered() map[string]device.Interface {
 	return backend.devices
 }
 
+// RescanDevices re-enumerates the plugged in devices, registering them again, e.g. if a device is
+// plugged in but was not detected.
+func (backend *Backend) RescanDevices() {
+	if backend.usbManager == nil {
+		return
+	}
+	backend.log.Info("Rescanning devices")
+	backend.usbManager.Rescan()
+}
+
 // HTTPClient is a getter method for the HTTPClient instance.
 func (backend *Backend) HTTPClient() *http.Client {
 	return backend.httpClient
@@ -626,6 +927,10 @@ func (backend *Backend) Keystore() keystore.Keystore {
 // if another keystore is already registered, it will be replaced.
 func (backend *Backend) registerKeystore(keystore keystore.Keystore) {
 	defer backend.accountsAndKeystoreLock.Lock()()
+	if backend.closed {
+		backend.log.Info("not registering the keystore, the backend is closed")
+		return
+	}
 	// Only for logging, if there is an error we continue anyway.
 	fingerprint, err := keystore.RootFingerprint()
 	if err != nil {
@@ -733,9 +1038,11 @@ func (backend *Backend) Register(theDevice device.Interface) error {
 	backend.onDeviceInit(theDevice)
 	if err := theDevice.Init(backend.Testing()); err != nil {
 		backend.onDeviceUninit(theDevice.Identifier())
+		delete(backend.devices, theDevice.Identifier())
 		return err
 	}
 	theDevice.Observe(backend.Notify)
+	backend.notifyDeviceCapabilities(theDevice)
 
 	// Old-school
 	select {
@@ -789,16 +1096,34 @@ func (backend *Backend) RatesUpdater() *rates.RateUpdater {
 	return backend.ratesUpdater
 }
 
+// electrumDialer returns the dialer for the Electrum connections, accounting their traffic. Servers
+// with a configured SSH tunnel are reached through the tunnel.
+func (backend *Backend) electrumDialer() proxy.Dialer {
+	return backend.bandwidthMeter.Dialer(
+		bandwidth.SubsystemElectrum, backend.sshTunnels.Dialer(backend.socksProxy.GetTCPProxyDialer()))
+}
+
+// UpdateContractRegistry replaces the update of the registry of known Ethereum contracts, which is
+// used to describe the contract calls in the transaction history. The accounts show the new names
+// after their next update.
+func (backend *Backend) UpdateContractRegistry(jsonBytes []byte) error {
+	return backend.contractRegistry.Update(jsonBytes)
+}
+
+// BandwidthStatus returns the network traffic per subsystem and the mobile data usage.
+func (backend *Backend) BandwidthStatus() *bandwidth.Status {
+	return backend.bandwidthMeter.Status()
+}
+
 // DownloadCert downloads the first element of the remote certificate chain.
 func (backend *Backend) DownloadCert(server string) (string, error) {
-	return electrum.DownloadCert(server, backend.socksProxy.GetTCPProxyDialer())
+	return electrum.DownloadCert(server, backend.sshTunnels.Dialer(backend.socksProxy.GetTCPProxyDialer()))
 }
 
 // CheckElectrumServer checks if a connection can be established with the electrum server, and
 // whether the server is an electrum server.
 func (backend *Backend) CheckElectrumServer(serverInfo *config.ServerInfo) error {
-	return electrum.CheckElectrumServer(
-		serverInfo, backend.log, backend.socksProxy.GetTCPProxyDialer())
+	return electrum.CheckElectrumServer(serverInfo, backend.log, backend.serverDialer(serverInfo))
 }
 
 // RegisterTestKeystore adds a keystore derived deterministically from a PIN, for convenience in
@@ -838,11 +1163,38 @@ func (backend *Backend) Environment() Environment {
 // Close shuts down the backend. After this, no other method should be called.
 func (backend *Backend) Close() error {
 	defer backend.accountsAndKeystoreLock.Lock()()
+	return backend.closeLocked()
+}
+
+// closeLocked stops all services and closes the accounts and databases, see Close(). Calling it
+// again does nothing. The accountsAndKeystoreLock must be held when calling this function.
+func (backend *Backend) closeLocked() error {
+	if backend.closed {
+		return nil
+	}
+	backend.closed = true
 
 	errors := []string{}
 
 	backend.ratesUpdater.Stop()
 
+	if err := backend.hwwBridge.Stop(); err != nil {
+		errors = append(errors, err.Error())
+	}
+	if err := backend.extensions.Stop(); err != nil {
+		errors = append(errors, err.Error())
+	}
+	if err := backend.companionHost.Stop(); err != nil {
+		errors = append(errors, err.Error())
+	}
+	backend.companionClient.Stop()
+	backend.invoices.Stop()
+	backend.scheduledExport.Stop()
+	backend.metadataSync.Stop()
+	backend.feeHistory.Stop()
+	backend.sshTunnels.Close()
+	backend.bandwidthMeter.Stop()
+
 	backend.uninitAccounts(true)
 
 	for _, coin := range backend.coins {
@@ -859,14 +1211,26 @@ func (backend *Backend) Close() error {
 	return nil
 }
 
+// BuildInfo returns the build metadata of the running binary. The binary is verified against the
+// published release the first time it is called.
+func (backend *Backend) BuildInfo() *buildinfo.Info {
+	backend.buildInfoOnce.Do(func() {
+		backend.buildInfo = buildinfo.Read(Version.String())
+		backend.log.WithField("commit", backend.buildInfo.Commit).
+			WithField("verification", backend.buildInfo.Verification).
+			Info("Build info")
+	})
+	return backend.buildInfo
+}
+
 // Banners returns the banners instance.
 func (backend *Backend) Banners() *banners.Banners {
 	return backend.banners
 }
 
-// HandleURI handles an external URI click for registered protocols, e.g. 'aopp:?...' URIs.  The uri
-// param can be any string, as it is potentially passed without any validation from the calling
-// platform.
+// HandleURI handles an external URI click for registered protocols, e.g. 'aopp:?...' or
+// 'bitcoin:...' URIs.  The uri param can be any string, as it is potentially passed without any
+// validation from the calling platform.
 func (backend *Backend) HandleURI(uri string) {
 	u, err := url.Parse(uri)
 	if err != nil {
@@ -876,6 +1240,10 @@ func (backend *Backend) HandleURI(uri string) {
 	switch u.Scheme {
 	case "aopp":
 		backend.handleAOPP(*u)
+	case "bitcoin", "litecoin", "ethereum":
+		if err := backend.handlePaymentURI(uri, u); err != nil {
+			backend.log.WithError(err).Warningf("Handling payment URI failed: %s", uri)
+		}
 	default:
 		backend.log.Warningf("Unknown URI scheme: %s", uri)
 	}
@@ -951,6 +1319,22 @@ func (backend *Backend) SetWatchonly(rootFingerprint []byte, watchonly bool) err
 	)
 }
 
+// SetWatchonlyAll enables or disables the global watch-only mode, see config.Backend.WatchonlyAll.
+// The accounts are reloaded, so that all accounts are shown without a connected keystore when
+// enabling, and the accounts of disconnected keystores without watchonly setting are removed when
+// disabling.
+func (backend *Backend) SetWatchonlyAll(enabled bool) error {
+	err := backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		appConfig.Backend.WatchonlyAll = enabled
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	backend.ReinitializeAccounts()
+	return nil
+}
+
 // ExportLogs function copy and save log.txt file to help users provide it to support while troubleshooting.
 func (backend *Backend) ExportLogs() error {
 	name := fmt.Sprintf("%s-log.txt", time.Now().Format("2006-01-02-at-15-04-05"))
@@ -35,6 +35,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/rpcclient/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/usb"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
 	keystoremock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore/software"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
@@ -468,3 +469,62 @@ func TestRegisterKeystore(t *testing.T) {
 	require.Nil(t, b.Accounts().lookup("v0-66666666-ltc-0"))
 	require.NotNil(t, b.Accounts().lookup("v0-66666666-eth-0"))
 }
+
+func TestLikelyCoinForAddress(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	likelyCoin, ok := b.LikelyCoinForAddress("ltc1qzr0n0a4xs0404fy5l7pl7pj8yj8q34ml27rlcs", coinpkg.CodeBTC)
+	require.True(t, ok)
+	require.Equal(t, coinpkg.CodeLTC, likelyCoin.Code())
+
+	likelyCoin, ok = b.LikelyCoinForAddress("0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1", coinpkg.CodeBTC)
+	require.True(t, ok)
+	require.Equal(t, coinpkg.CodeETH, likelyCoin.Code())
+
+	// Only valid for the excluded coin.
+	_, ok = b.LikelyCoinForAddress("1GM1Wp6t3hJf6U5aq6dG62Pg3c9ePbiUQ9", coinpkg.CodeBTC)
+	require.False(t, ok)
+
+	_, ok = b.LikelyCoinForAddress("not an address", coinpkg.CodeBTC)
+	require.False(t, ok)
+}
+
+func TestSession(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	session := b.Session()
+	require.Nil(t, session.Keystore)
+	require.Empty(t, session.Devices)
+	require.Empty(t, session.Accounts)
+	require.False(t, session.Syncing)
+	require.Nil(t, session.FrontendState)
+
+	ks := makeBitBox02Multi()
+	ks.TypeFunc = func() keystore.Type { return keystore.TypeHardware }
+	b.registerKeystore(ks)
+	for i, account := range b.Accounts() {
+		synced := i != 0
+		accountMock := account.(*accountsMocks.InterfaceMock)
+		accountMock.SyncedFunc = func() bool { return synced }
+		accountMock.FatalErrorFunc = func() bool { return false }
+	}
+
+	rootFingerprint, err := ks.RootFingerprint()
+	require.NoError(t, err)
+	session = b.Session()
+	require.Equal(t, &SessionKeystore{
+		RootFingerprint: rootFingerprint,
+		Name:            "Mock name",
+		Type:            keystore.TypeHardware,
+	}, session.Keystore)
+	require.NotEmpty(t, session.Accounts)
+	require.False(t, session.Accounts[0].Synced)
+	require.True(t, session.Syncing)
+
+	require.NoError(t, b.SetFrontendSessionState([]byte(`{"route":"/account/v0-55555555-btc-0"}`)))
+	require.JSONEq(t, `{"route":"/account/v0-55555555-btc-0"}`, string(b.Session().FrontendState))
+	require.Error(t, b.SetFrontendSessionState([]byte(`{invalid`)))
+	require.JSONEq(t, `{"route":"/account/v0-55555555-btc-0"}`, string(b.Session().FrontendState))
+}
@@ -0,0 +1,315 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bandwidth accounts the network traffic of the backend per subsystem, so the user can see
+// which parts of the app use their data plan. While on a mobile data connection, the traffic is
+// compared against a budget, and an event is sent once the budget is exceeded.
+package bandwidth
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/proxy"
+)
+
+// checkInterval is how often the traffic is sampled to account for the mobile data usage.
+const checkInterval = time.Minute
+
+// Subsystem is a part of the backend which uses the network.
+type Subsystem string
+
+const (
+	// SubsystemElectrum are the connections to the Electrum servers.
+	SubsystemElectrum Subsystem = "electrum"
+	// SubsystemRates are the exchange rates requests.
+	SubsystemRates Subsystem = "rates"
+	// SubsystemExplorer are the requests to block explorers, e.g. Etherscan or the mempool.space
+	// fee estimation.
+	SubsystemExplorer Subsystem = "explorer"
+)
+
+// Usage is the cumulative traffic of one endpoint of a subsystem since the app started, in bytes.
+type Usage struct {
+	Subsystem Subsystem `json:"subsystem"`
+	// Endpoint is the address of an Electrum server or the host of HTTP requests.
+	Endpoint string `json:"endpoint"`
+	Sent     uint64 `json:"sent"`
+	Received uint64 `json:"received"`
+}
+
+// Status contains the traffic of all subsystems and the mobile data usage.
+type Status struct {
+	Usage    []*Usage `json:"usage"`
+	Sent     uint64   `json:"sent"`
+	Received uint64   `json:"received"`
+	// MobileData is the traffic while on a mobile data connection, in bytes.
+	MobileData uint64 `json:"mobileData"`
+	// MobileDataBudget is the budget for MobileData, in bytes. 0 means no budget.
+	MobileDataBudget         uint64 `json:"mobileDataBudget"`
+	MobileDataBudgetExceeded bool   `json:"mobileDataBudgetExceeded"`
+}
+
+type key struct {
+	subsystem Subsystem
+	endpoint  string
+}
+
+type counter struct {
+	sent     atomic.Uint64
+	received atomic.Uint64
+}
+
+// Meter counts the traffic of dialers and HTTP clients wrapped by it. The zero value is not usable,
+// use NewMeter().
+type Meter struct {
+	observable.Implementation
+
+	usingMobileData func() bool
+	// mobileDataBudget returns the mobile data budget in bytes, 0 meaning no budget.
+	mobileDataBudget func() uint64
+
+	counters map[key]*counter
+	// mobileData is the traffic accounted to mobile data, see check().
+	mobileData uint64
+	// lastTotal is the total traffic at the last check().
+	lastTotal uint64
+	// budgetExceeded is true once the mobile data budget was exceeded, so that the event is only
+	// sent once.
+	budgetExceeded bool
+	mu             locker.Locker
+	quit           chan struct{}
+
+	log *logrus.Entry
+}
+
+// NewMeter creates a new meter. usingMobileData and mobileDataBudget are used to account and limit
+// the traffic on mobile data connections.
+func NewMeter(usingMobileData func() bool, mobileDataBudget func() uint64) *Meter {
+	return &Meter{
+		usingMobileData:  usingMobileData,
+		mobileDataBudget: mobileDataBudget,
+		counters:         map[key]*counter{},
+		log:              logging.Get().WithGroup("bandwidth"),
+	}
+}
+
+func (meter *Meter) counter(subsystem Subsystem, endpoint string) *counter {
+	defer meter.mu.Lock()()
+	k := key{subsystem: subsystem, endpoint: endpoint}
+	c, ok := meter.counters[k]
+	if !ok {
+		c = &counter{}
+		meter.counters[k] = c
+	}
+	return c
+}
+
+// Usage returns the traffic of all endpoints, sorted by subsystem and endpoint.
+func (meter *Meter) Usage() []*Usage {
+	defer meter.mu.RLock()()
+	result := []*Usage{}
+	for k, c := range meter.counters {
+		result = append(result, &Usage{
+			Subsystem: k.subsystem,
+			Endpoint:  k.endpoint,
+			Sent:      c.sent.Load(),
+			Received:  c.received.Load(),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Subsystem != result[j].Subsystem {
+			return result[i].Subsystem < result[j].Subsystem
+		}
+		return result[i].Endpoint < result[j].Endpoint
+	})
+	return result
+}
+
+// Status returns the traffic of all subsystems and the mobile data usage.
+func (meter *Meter) Status() *Status {
+	status := &Status{Usage: meter.Usage()}
+	for _, usage := range status.Usage {
+		status.Sent += usage.Sent
+		status.Received += usage.Received
+	}
+	defer meter.mu.RLock()()
+	status.MobileData = meter.mobileData
+	status.MobileDataBudget = meter.mobileDataBudget()
+	status.MobileDataBudgetExceeded = meter.budgetExceeded
+	return status
+}
+
+// check accounts the traffic since the last check to mobile data if the user is on a mobile data
+// connection now. Sampling is good enough here, as the network type rarely changes.
+func (meter *Meter) check() {
+	usingMobileData := meter.usingMobileData()
+	var total uint64
+	for _, usage := range meter.Usage() {
+		total += usage.Sent + usage.Received
+	}
+
+	unlock := meter.mu.Lock()
+	if usingMobileData {
+		meter.mobileData += total - meter.lastTotal
+	}
+	meter.lastTotal = total
+	budget := meter.mobileDataBudget()
+	exceeded := budget > 0 && meter.mobileData > budget
+	changed := exceeded != meter.budgetExceeded
+	meter.budgetExceeded = exceeded
+	mobileData := meter.mobileData
+	unlock()
+
+	if changed {
+		if exceeded {
+			meter.log.
+				WithField("mobileData", mobileData).
+				WithField("budget", budget).
+				Warning("mobile data budget exceeded")
+		}
+		meter.Notify(observable.Event{
+			Subject: "diagnostics/bandwidth",
+			Action:  action.Reload,
+		})
+	}
+}
+
+// Start periodically accounts the mobile data usage until Stop() is called.
+func (meter *Meter) Start() {
+	unlock := meter.mu.Lock()
+	if meter.quit != nil {
+		unlock()
+		return
+	}
+	quit := make(chan struct{})
+	meter.quit = quit
+	unlock()
+
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				meter.check()
+			case <-quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops accounting the mobile data usage.
+func (meter *Meter) Stop() {
+	defer meter.mu.Lock()()
+	if meter.quit != nil {
+		close(meter.quit)
+		meter.quit = nil
+	}
+}
+
+type meteredConn struct {
+	net.Conn
+	counter *counter
+}
+
+// Read implements net.Conn.
+func (conn *meteredConn) Read(b []byte) (int, error) {
+	n, err := conn.Conn.Read(b)
+	conn.counter.received.Add(uint64(n))
+	return n, err
+}
+
+// Write implements net.Conn.
+func (conn *meteredConn) Write(b []byte) (int, error) {
+	n, err := conn.Conn.Write(b)
+	conn.counter.sent.Add(uint64(n))
+	return n, err
+}
+
+type meteredDialer struct {
+	meter     *Meter
+	subsystem Subsystem
+	dialer    proxy.Dialer
+}
+
+// Dial implements proxy.Dialer.
+func (dialer *meteredDialer) Dial(network, address string) (net.Conn, error) {
+	conn, err := dialer.dialer.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &meteredConn{Conn: conn, counter: dialer.meter.counter(dialer.subsystem, address)}, nil
+}
+
+// Dialer wraps dialer so that the traffic of its connections is accounted to the subsystem, per
+// dialed address.
+func (meter *Meter) Dialer(subsystem Subsystem, dialer proxy.Dialer) proxy.Dialer {
+	return &meteredDialer{meter: meter, subsystem: subsystem, dialer: dialer}
+}
+
+type meteredBody struct {
+	io.ReadCloser
+	counter *counter
+}
+
+// Read implements io.Reader.
+func (body *meteredBody) Read(b []byte) (int, error) {
+	n, err := body.ReadCloser.Read(b)
+	body.counter.received.Add(uint64(n))
+	return n, err
+}
+
+type meteredTransport struct {
+	meter     *Meter
+	subsystem Subsystem
+	base      http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (transport *meteredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := transport.meter.counter(transport.subsystem, req.URL.Host)
+	if req.ContentLength > 0 {
+		c.sent.Add(uint64(req.ContentLength))
+	}
+	res, err := transport.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = &meteredBody{ReadCloser: res.Body, counter: c}
+	return res, nil
+}
+
+// Client returns a copy of client whose traffic is accounted to the subsystem, per host. The
+// connections of HTTP clients can be shared between subsystems, so only the request and response
+// bodies are counted, not the headers and TLS overhead.
+func (meter *Meter) Client(subsystem Subsystem, client *http.Client) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	metered := *client
+	metered.Transport = &meteredTransport{meter: meter, subsystem: subsystem, base: base}
+	return &metered
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bandwidth
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/stretchr/testify/require"
+)
+
+type pipeDialer struct {
+	server func(net.Conn)
+}
+
+func (dialer *pipeDialer) Dial(network, address string) (net.Conn, error) {
+	client, server := net.Pipe()
+	go dialer.server(server)
+	return client, nil
+}
+
+func TestDialer(t *testing.T) {
+	meter := NewMeter(func() bool { return false }, func() uint64 { return 0 })
+	dialer := meter.Dialer(SubsystemElectrum, &pipeDialer{server: func(conn net.Conn) {
+		buf := make([]byte, 5)
+		_, _ = io.ReadFull(conn, buf)
+		_, _ = conn.Write([]byte("hello world"))
+		conn.Close()
+	}})
+	conn, err := dialer.Dial("tcp", "electrum.example.com:50002")
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	response, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(response))
+	require.NoError(t, conn.Close())
+
+	require.Equal(t, []*Usage{{
+		Subsystem: SubsystemElectrum,
+		Endpoint:  "electrum.example.com:50002",
+		Sent:      5,
+		Received:  11,
+	}}, meter.Usage())
+}
+
+func TestClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	meter := NewMeter(func() bool { return false }, func() uint64 { return 0 })
+	client := meter.Client(SubsystemRates, &http.Client{})
+	response, err := client.Post(server.URL, "text/plain", strings.NewReader("abc"))
+	require.NoError(t, err)
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, "0123456789", string(body))
+
+	status := meter.Status()
+	require.Equal(t, []*Usage{{
+		Subsystem: SubsystemRates,
+		Endpoint:  serverURL.Host,
+		Sent:      3,
+		Received:  10,
+	}}, status.Usage)
+	require.Equal(t, uint64(3), status.Sent)
+	require.Equal(t, uint64(10), status.Received)
+}
+
+func TestMobileDataBudget(t *testing.T) {
+	usingMobileData := false
+	meter := NewMeter(func() bool { return usingMobileData }, func() uint64 { return 100 })
+	var events []observable.Event
+	meter.Observe(func(event observable.Event) { events = append(events, event) })
+	c := meter.counter(SubsystemExplorer, "example.com")
+
+	// Traffic on WiFi does not count.
+	c.received.Add(1000)
+	meter.check()
+	require.Equal(t, uint64(0), meter.Status().MobileData)
+
+	usingMobileData = true
+	c.received.Add(60)
+	meter.check()
+	require.Equal(t, uint64(60), meter.Status().MobileData)
+	require.False(t, meter.Status().MobileDataBudgetExceeded)
+	require.Empty(t, events)
+
+	c.sent.Add(60)
+	meter.check()
+	status := meter.Status()
+	require.Equal(t, uint64(120), status.MobileData)
+	require.Equal(t, uint64(100), status.MobileDataBudget)
+	require.True(t, status.MobileDataBudgetExceeded)
+	require.Len(t, events, 1)
+	require.Equal(t, "diagnostics/bandwidth", events[0].Subject)
+
+	// The event is only sent once.
+	c.sent.Add(60)
+	meter.check()
+	require.Len(t, events, 1)
+}
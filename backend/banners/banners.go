@@ -17,12 +17,16 @@ package banners
 import (
 	"encoding/json"
 	"net/http"
+	"path/filepath"
+	"sort"
 
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/BitBoxSwiss/bitbox02-api-go/util/semver"
 	"github.com/sirupsen/logrus"
 )
 
@@ -43,6 +47,23 @@ const (
 	TypeInfo TypeCode = "info"
 )
 
+// severityOrder orders the messages, most severe first. Messages without type are shown as warnings.
+var severityOrder = map[TypeCode]int{
+	TypeWarning: 0,
+	TypeInfo:    1,
+	TypeSuccess: 2,
+}
+
+func (message *Message) severity() int {
+	if message.Type == nil {
+		return severityOrder[TypeWarning]
+	}
+	if s, ok := severityOrder[*message.Type]; ok {
+		return s
+	}
+	return len(severityOrder)
+}
+
 const (
 	// KeyBitBox01 is the message key for the event when a BitBox01 gets connected.
 	KeyBitBox01 MessageKey = "bitbox01"
@@ -65,6 +86,24 @@ type Message struct {
 	// trace of dismissed banners (see status.tsx for details).
 	Dismissible *bool     `json:"dismissible"`
 	Type        *TypeCode `json:"type"`
+
+	// MinVersion and MaxVersion, if present, limit the message to app versions in this inclusive
+	// range, so that messages can be staged for specific releases.
+	MinVersion *semver.SemVer `json:"minVersion,omitempty"`
+	MaxVersion *semver.SemVer `json:"maxVersion,omitempty"`
+	// Platforms, if not empty, limits the message to these platforms, named like Go's
+	// runtime.GOOS, e.g. "windows", "darwin", "linux", "android" or "ios".
+	Platforms []string `json:"platforms,omitempty"`
+
+	// Read is true if the user acknowledged the message, see MarkRead(). It is not part of the
+	// remote banners json.
+	Read bool `json:"read"`
+}
+
+// state is the persisted read receipts and dismissals, keyed by message ID.
+type state struct {
+	Read      map[string]bool `json:"read"`
+	Dismissed map[string]bool `json:"dismissed"`
 }
 
 // Banners fetches banner information from remote.
@@ -75,21 +114,46 @@ type Banners struct {
 	banners struct {
 		BitBox01 *Message `json:"bitbox01"`
 		BitBox02 *Message `json:"bitbox02"`
+		// Messages are shown in the app independent of a connected device.
+		Messages []*Message `json:"messages"`
 	}
+	// version and platform are matched against the targeting of the messages.
+	version  *semver.SemVer
+	platform string
 
 	active     map[MessageKey]struct{}
 	activeLock locker.Locker
 
+	file      *config.File
+	state     state
+	stateLock locker.Locker
+
 	log *logrus.Entry
 }
 
-// NewBanners makes a new Banners instance.
-func NewBanners() *Banners {
-	return &Banners{
-		url:    bannersURL,
-		active: map[MessageKey]struct{}{},
-		log:    logging.Get().WithGroup("banners"),
+// NewBanners makes a new Banners instance. The read receipts and dismissals are persisted in the
+// given file. Messages are only shown if they target the given app version and platform.
+func NewBanners(filename string, version *semver.SemVer, platform string) *Banners {
+	banners := &Banners{
+		url:      bannersURL,
+		version:  version,
+		platform: platform,
+		active:   map[MessageKey]struct{}{},
+		file:     config.NewFile(filepath.Dir(filename), filepath.Base(filename)),
+		log:      logging.Get().WithGroup("banners"),
+	}
+	if banners.file.Exists() {
+		if err := banners.file.ReadJSON(&banners.state); err != nil {
+			banners.log.WithError(err).Error("Could not load the banners state")
+		}
 	}
+	if banners.state.Read == nil {
+		banners.state.Read = map[string]bool{}
+	}
+	if banners.state.Dismissed == nil {
+		banners.state.Dismissed = map[string]bool{}
+	}
+	return banners
 }
 
 func (banners *Banners) init(httpClient *http.Client) error {
@@ -106,6 +170,10 @@ func (banners *Banners) init(httpClient *http.Client) error {
 	if err := json.NewDecoder(response.Body).Decode(&banners.banners); err != nil {
 		return errp.WithStack(err)
 	}
+	banners.Notify(observable.Event{
+		Subject: "banners",
+		Action:  action.Reload,
+	})
 	return nil
 }
 
@@ -141,6 +209,40 @@ func (banners *Banners) Deactivate(key MessageKey) {
 	})
 }
 
+// targeted returns true if the message targets this app version and platform.
+func (banners *Banners) targeted(message *Message) bool {
+	if message.MinVersion != nil && !banners.version.AtLeast(message.MinVersion) {
+		return false
+	}
+	if message.MaxVersion != nil && !message.MaxVersion.AtLeast(banners.version) {
+		return false
+	}
+	if len(message.Platforms) == 0 {
+		return true
+	}
+	for _, platform := range message.Platforms {
+		if platform == banners.platform {
+			return true
+		}
+	}
+	return false
+}
+
+// visible returns a copy of the message with the read receipt, or nil if the message is not
+// targeted at this app or was dismissed.
+func (banners *Banners) visible(message *Message) *Message {
+	if message == nil || !banners.targeted(message) {
+		return nil
+	}
+	defer banners.stateLock.RLock()()
+	if banners.state.Dismissed[message.ID] {
+		return nil
+	}
+	result := *message
+	result.Read = banners.state.Read[message.ID]
+	return &result
+}
+
 // GetMessage gets a message for a key if it was activated. nil otherwise, or if no msg exists.
 func (banners *Banners) GetMessage(key MessageKey) *Message {
 	defer banners.activeLock.RLock()()
@@ -151,11 +253,66 @@ func (banners *Banners) GetMessage(key MessageKey) *Message {
 
 	switch key {
 	case KeyBitBox01:
-		return banners.banners.BitBox01
+		return banners.visible(banners.banners.BitBox01)
 	case KeyBitBox02:
-		return banners.banners.BitBox02
+		return banners.visible(banners.banners.BitBox02)
 	default:
 		banners.log.Errorf("unrecognized key: %s", key)
 		return nil
 	}
 }
+
+// Messages returns the messages to show in the app, most severe first. Messages which were
+// dismissed or do not target this app version and platform are omitted.
+func (banners *Banners) Messages() []*Message {
+	result := []*Message{}
+	for _, message := range banners.banners.Messages {
+		if visible := banners.visible(message); visible != nil {
+			result = append(result, visible)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].severity() < result[j].severity()
+	})
+	return result
+}
+
+// updateState changes the read receipts or dismissals, persists them and makes the frontend reload
+// the banners.
+func (banners *Banners) updateState(f func(*state)) error {
+	unlock := banners.stateLock.Lock()
+	f(&banners.state)
+	err := banners.file.WriteJSON(banners.state)
+	unlock()
+	if err != nil {
+		return err
+	}
+	for _, subject := range []string{"banners", "banners/" + string(KeyBitBox01), "banners/" + string(KeyBitBox02)} {
+		banners.Notify(observable.Event{
+			Subject: subject,
+			Action:  action.Reload,
+		})
+	}
+	return nil
+}
+
+// MarkRead records that the user has seen the message with the given ID.
+func (banners *Banners) MarkRead(id string) error {
+	if id == "" {
+		return errp.New("missing message id")
+	}
+	return banners.updateState(func(state *state) {
+		state.Read[id] = true
+	})
+}
+
+// Dismiss hides the message with the given ID permanently.
+func (banners *Banners) Dismiss(id string) error {
+	if id == "" {
+		return errp.New("missing message id")
+	}
+	return banners.updateState(func(state *state) {
+		state.Read[id] = true
+		state.Dismissed[id] = true
+	})
+}
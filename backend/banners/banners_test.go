@@ -17,10 +17,12 @@ package banners
 import (
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/BitBoxSwiss/bitbox02-api-go/util/semver"
 	"github.com/stretchr/testify/require"
 )
 
@@ -44,7 +46,7 @@ func TestInit(t *testing.T) {
 	}))
 	defer server.Close()
 
-	banners := NewBanners()
+	banners := NewBanners(filepath.Join(t.TempDir(), "banners-state.json"), semver.NewSemVer(4, 42, 0), "linux")
 	banners.url = server.URL
 	banners.Init(server.Client())
 	require.Nil(t, banners.GetMessage(KeyBitBox01))
@@ -69,3 +71,75 @@ func TestInit(t *testing.T) {
 	require.Equal(t, "some-link", msg.Link.Href)
 
 }
+
+func TestMessages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, err := rw.Write([]byte(`
+{
+    "bitbox02": {
+        "id": "bb02",
+        "message": { "en": "bitbox02 msg" },
+        "dismissible": true,
+        "platforms": ["linux"]
+    },
+    "messages": [
+        { "id": "success", "message": { "en": "success" }, "type": "success" },
+        { "id": "info", "message": { "en": "info" }, "type": "info", "dismissible": true },
+        { "id": "warning", "message": { "en": "warning" } },
+        { "id": "old", "message": { "en": "old" }, "maxVersion": "4.41.9" },
+        { "id": "new", "message": { "en": "new" }, "minVersion": "4.43.0" },
+        { "id": "staged", "message": { "en": "staged" }, "type": "info", "minVersion": "4.42.0", "maxVersion": "4.42.0" },
+        { "id": "android", "message": { "en": "android" }, "platforms": ["android", "ios"] }
+    ]
+}
+		`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	filename := filepath.Join(t.TempDir(), "banners-state.json")
+	newBanners := func() *Banners {
+		banners := NewBanners(filename, semver.NewSemVer(4, 42, 0), "linux")
+		banners.url = server.URL
+		banners.Init(server.Client())
+		return banners
+	}
+	ids := func(messages []*Message) []string {
+		result := []string{}
+		for _, message := range messages {
+			result = append(result, message.ID)
+		}
+		return result
+	}
+
+	banners := newBanners()
+	// Most severe first, untargeted messages are omitted.
+	require.Equal(t, []string{"warning", "info", "staged", "success"}, ids(banners.Messages()))
+	require.False(t, banners.Messages()[1].Read)
+
+	require.NoError(t, banners.MarkRead("info"))
+	require.True(t, banners.Messages()[1].Read)
+	require.Error(t, banners.MarkRead(""))
+
+	require.NoError(t, banners.Dismiss("info"))
+	require.Equal(t, []string{"warning", "staged", "success"}, ids(banners.Messages()))
+
+	banners.Activate(KeyBitBox02)
+	require.Equal(t, "bb02", banners.GetMessage(KeyBitBox02).ID)
+	require.NoError(t, banners.Dismiss("bb02"))
+	require.Nil(t, banners.GetMessage(KeyBitBox02))
+
+	// The dismissals are persisted.
+	banners = newBanners()
+	require.Equal(t, []string{"warning", "staged", "success"}, ids(banners.Messages()))
+	banners.Activate(KeyBitBox02)
+	require.Nil(t, banners.GetMessage(KeyBitBox02))
+
+	// Platform targeting.
+	android := NewBanners(filepath.Join(t.TempDir(), "banners-state.json"), semver.NewSemVer(4, 43, 1), "android")
+	android.url = server.URL
+	android.Init(server.Client())
+	require.Equal(t, []string{"warning", "new", "android", "info", "success"}, ids(android.Messages()))
+	android.Activate(KeyBitBox02)
+	require.Nil(t, android.GetMessage(KeyBitBox02))
+}
@@ -56,6 +56,10 @@ var (
 	globalToken string
 
 	globalShutdown func()
+
+	// globalPendingURIs are URIs received before the backend was started, e.g. the URI which
+	// launched the app. They are handled as soon as the backend is running.
+	globalPendingURIs []string
 )
 
 type response struct {
@@ -109,13 +113,18 @@ func BackendCall(queryID int, jsonQuery string) {
 	}(globalHandlers, globalCommunication)
 }
 
-// HandleURI handles an external URI click for registered protocols, e.g. 'aopp:?...' URIs. The
-// schemes are registered and handled on each platform (e.g. .desktop entry on Linux, Info.plist on
-// macOS, etc.). All platforms then call this function to handle the URI in the backend.
+// HandleURI handles an external URI click for registered protocols, e.g. 'aopp:?...' or
+// 'bitcoin:...' URIs. The schemes are registered and handled on each platform (e.g. .desktop entry
+// on Linux, Info.plist on macOS and iOS, the manifest on Android, etc.). All platforms then call
+// this function to handle the URI in the backend.
+//
+// If the backend is not running yet, e.g. when the app was cold-started by the URI, the URI is
+// queued and handled once the backend is started in Serve().
 func HandleURI(uri string) {
-	mu.RLock()
-	defer mu.RUnlock()
+	mu.Lock()
+	defer mu.Unlock()
 	if globalBackend == nil {
+		globalPendingURIs = append(globalPendingURIs, uri)
 		return
 	}
 	globalBackend.HandleURI(uri)
@@ -164,6 +173,7 @@ func UsingMobileDataChanged() {
 		Subject: "using-mobile-data",
 		Action:  action.Reload,
 	})
+	globalBackend.UpdateMobileDataSaver()
 }
 
 // BackendEnvironment implements backend.Environment.
@@ -310,6 +320,11 @@ func Serve(
 	globalHandlers = handlers.NewHandlers(globalBackend,
 		handlers.NewConnectionData(-1, globalToken))
 
+	for _, uri := range globalPendingURIs {
+		globalBackend.HandleURI(uri)
+	}
+	globalPendingURIs = nil
+
 	events := globalHandlers.Events()
 	go func() {
 		for {
@@ -15,6 +15,7 @@
 package bridgecommon_test
 
 import (
+	"encoding/json"
 	"log"
 	"testing"
 	"time"
@@ -34,6 +35,17 @@ func (c communication) PushNotify(msg string) {
 	log.Println("PushNotify:", msg)
 }
 
+// responseRecorder is a NativeCommunication which passes the responses to backend calls on.
+type responseRecorder struct {
+	responses chan string
+}
+
+func (c responseRecorder) Respond(queryID int, response string) {
+	c.responses <- response
+}
+
+func (c responseRecorder) PushNotify(msg string) {}
+
 type environment struct{}
 
 func (e environment) NotifyUser(msg string) {
@@ -99,4 +111,41 @@ func TestServeShutdownServe(t *testing.T) {
 	case <-time.After(time.Second):
 		require.Fail(t, "could not Serve twice")
 	}
+	bridgecommon.Shutdown()
+}
+
+// TestHandleURI checks that a payment URI opened through the OS is queued for the frontend, both
+// if it cold-starts the app before the backend is running and when the backend is running.
+func TestHandleURI(t *testing.T) {
+	const coldStartURI = "bitcoin:bc1qxy2kgdygjrsqtzq2n0yrf2493p83kkfjhx0wlh?amount=0.001"
+	const runningURI = "litecoin:ltc1qg82tq7dc5x8n9ahfnd4cmdmzps58a3ehtg6sdq?amount=1.5"
+
+	bridgecommon.HandleURI(coldStartURI)
+	recorder := responseRecorder{responses: make(chan string, 1)}
+	bridgecommon.Serve(
+		false,
+		nil,
+		recorder,
+		environment{},
+	)
+	defer bridgecommon.Shutdown()
+	bridgecommon.HandleURI(runningURI)
+
+	bridgecommon.BackendCall(1, `{"method":"GET","endpoint":"payment-requests"}`)
+	var response string
+	select {
+	case response = <-recorder.responses:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no response")
+	}
+	var paymentRequests []struct {
+		URI    string `json:"uri"`
+		Amount string `json:"amount"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(response), &paymentRequests))
+	require.Len(t, paymentRequests, 2)
+	require.Equal(t, coldStartURI, paymentRequests[0].URI)
+	require.Equal(t, "0.001", paymentRequests[0].Amount)
+	require.Equal(t, runningURI, paymentRequests[1].URI)
+	require.Equal(t, "1.5", paymentRequests[1].Amount)
 }
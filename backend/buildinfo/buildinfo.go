@@ -0,0 +1,176 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package buildinfo provides the build metadata of the running binary and checks its hash against
+// the hashes of the published release, for users verifying reproducible builds.
+package buildinfo
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// These are set at build time using `-ldflags "-X ..."`.
+var (
+	// buildTime is the time of the build, e.g. derived from SOURCE_DATE_EPOCH for reproducible
+	// builds.
+	buildTime string
+	// releasePublicKey is the hex encoded ed25519 public key the release manifest is signed with.
+	// If empty, the binary can't be verified.
+	releasePublicKey string
+)
+
+const (
+	// manifestFilename is the file next to the executable listing the SHA256 hashes of the
+	// binaries of the release. It is signed by the signature in manifestFilename + ".sig".
+	manifestFilename = "release-hashes.json"
+)
+
+// VerificationStatus is the result of comparing the binary hash with the published release.
+type VerificationStatus string
+
+const (
+	// VerificationUnavailable means there is no public key or no signed manifest to verify against,
+	// e.g. for development builds.
+	VerificationUnavailable VerificationStatus = "unavailable"
+	// VerificationMatch means the binary hash is listed in the signed manifest of this version.
+	VerificationMatch VerificationStatus = "match"
+	// VerificationMismatch means the manifest is valid, but does not list the binary hash.
+	VerificationMismatch VerificationStatus = "mismatch"
+	// VerificationInvalidSignature means the manifest is not signed by the release key, or is of
+	// a different version.
+	VerificationInvalidSignature VerificationStatus = "invalidSignature"
+)
+
+// Info is the build metadata of the running binary.
+type Info struct {
+	Version string `json:"version"`
+	// Commit is the git commit the binary was built from, empty if unknown.
+	Commit string `json:"commit"`
+	// CommitTime is the time of the commit in RFC3339, empty if unknown.
+	CommitTime string `json:"commitTime"`
+	// Modified is true if the binary was built with uncommitted changes.
+	Modified bool `json:"modified"`
+	// BuildTime is empty if unknown.
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+	// BinaryHash is the hex encoded SHA256 hash of the executable, empty if it could not be read.
+	BinaryHash   string             `json:"binaryHash"`
+	Verification VerificationStatus `json:"verification"`
+}
+
+type manifest struct {
+	Version string `json:"version"`
+	// SHA256 are the hex encoded hashes of the binaries of all platforms.
+	SHA256 []string `json:"sha256"`
+}
+
+// verify checks the binary hash against the manifest, which must be signed by the public key and
+// be of the given version.
+func verify(
+	binaryHash string, manifestBytes, signature []byte, publicKey ed25519.PublicKey, version string,
+) VerificationStatus {
+	if !ed25519.Verify(publicKey, manifestBytes, signature) {
+		return VerificationInvalidSignature
+	}
+	var parsed manifest
+	if err := json.Unmarshal(manifestBytes, &parsed); err != nil || parsed.Version != version {
+		return VerificationInvalidSignature
+	}
+	for _, hash := range parsed.SHA256 {
+		if hash == binaryHash {
+			return VerificationMatch
+		}
+	}
+	return VerificationMismatch
+}
+
+func hashFile(filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", errp.WithStack(err)
+	}
+	defer func() { _ = file.Close() }()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", errp.WithStack(err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// verifyExecutable verifies the executable, whose hash is binaryHash, against the signed manifest
+// next to it.
+func verifyExecutable(executable, binaryHash, version string) VerificationStatus {
+	publicKey, err := hex.DecodeString(releasePublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize || binaryHash == "" {
+		return VerificationUnavailable
+	}
+	manifestPath := filepath.Join(filepath.Dir(executable), manifestFilename)
+	manifestBytes, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return VerificationUnavailable
+	}
+	signatureHex, err := os.ReadFile(manifestPath + ".sig")
+	if err != nil {
+		return VerificationUnavailable
+	}
+	signature, err := hex.DecodeString(string(bytes.TrimSpace(signatureHex)))
+	if err != nil {
+		return VerificationInvalidSignature
+	}
+	return verify(binaryHash, manifestBytes, signature, publicKey, version)
+}
+
+// Read collects the build metadata of the running binary and verifies it against the published
+// release. It hashes the executable, so it should not be called repeatedly.
+func Read(version string) *Info {
+	info := &Info{
+		Version:      version,
+		BuildTime:    buildTime,
+		GoVersion:    runtime.Version(),
+		Verification: VerificationUnavailable,
+	}
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range buildInfo.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.time":
+				info.CommitTime = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return info
+	}
+	info.BinaryHash, err = hashFile(executable)
+	if err != nil {
+		return info
+	}
+	info.Verification = verifyExecutable(executable, info.BinaryHash, version)
+	return info
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buildinfo
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	manifestBytes := []byte(`{"version":"4.42.0","sha256":["aa","bb"]}`)
+	signature := ed25519.Sign(privateKey, manifestBytes)
+
+	require.Equal(t, VerificationMatch, verify("bb", manifestBytes, signature, publicKey, "4.42.0"))
+	require.Equal(t, VerificationMismatch, verify("cc", manifestBytes, signature, publicKey, "4.42.0"))
+	// Manifest of a different version.
+	require.Equal(t, VerificationInvalidSignature,
+		verify("bb", manifestBytes, signature, publicKey, "4.43.0"))
+	// Tampered manifest.
+	require.Equal(t, VerificationInvalidSignature,
+		verify("cc", []byte(`{"version":"4.42.0","sha256":["cc"]}`), signature, publicKey, "4.42.0"))
+	otherPublicKey, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	require.Equal(t, VerificationInvalidSignature,
+		verify("bb", manifestBytes, signature, otherPublicKey, "4.42.0"))
+}
+
+func TestVerifyExecutable(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	dir := test.TstTempDir("buildinfo")
+	defer func() { _ = os.RemoveAll(dir) }()
+	executable := filepath.Join(dir, "app")
+	require.NoError(t, os.WriteFile(executable, []byte("binary"), 0600))
+	binaryHash, err := hashFile(executable)
+	require.NoError(t, err)
+
+	// No public key.
+	require.Equal(t, VerificationUnavailable, verifyExecutable(executable, binaryHash, "1.0.0"))
+
+	defer func(previous string) { releasePublicKey = previous }(releasePublicKey)
+	releasePublicKey = hex.EncodeToString(publicKey)
+	// No manifest.
+	require.Equal(t, VerificationUnavailable, verifyExecutable(executable, binaryHash, "1.0.0"))
+
+	manifestBytes := []byte(`{"version":"1.0.0","sha256":["` + binaryHash + `"]}`)
+	manifestPath := filepath.Join(dir, manifestFilename)
+	require.NoError(t, os.WriteFile(manifestPath, manifestBytes, 0600))
+	require.NoError(t, os.WriteFile(manifestPath+".sig",
+		[]byte(hex.EncodeToString(ed25519.Sign(privateKey, manifestBytes))+"\n"), 0600))
+	require.Equal(t, VerificationMatch, verifyExecutable(executable, binaryHash, "1.0.0"))
+
+	require.NoError(t, os.WriteFile(executable, []byte("modified binary"), 0600))
+	binaryHash, err = hashFile(executable)
+	require.NoError(t, err)
+	require.Equal(t, VerificationMismatch, verifyExecutable(executable, binaryHash, "1.0.0"))
+}
+
+func TestRead(t *testing.T) {
+	info := Read("1.2.3")
+	require.Equal(t, "1.2.3", info.Version)
+	require.Equal(t, runtime.Version(), info.GoVersion)
+	require.Len(t, info.BinaryHash, 64)
+	require.Equal(t, VerificationUnavailable, info.Verification)
+}
@@ -52,6 +52,30 @@ type RatChartEntry struct {
 	RatValue *big.Rat
 }
 
+// ChartCoinMissing annotates a coin whose value is missing from the chart, because there are no
+// historical exchange rates for it in the time range of its transactions.
+type ChartCoinMissing struct {
+	Coin coin.Code `json:"coin"`
+	// Until is the unix timestamp until which the coin is missing from the chart. Zero if the coin
+	// is missing entirely.
+	Until int64 `json:"until"`
+}
+
+// chartSeriesStart returns the time from which an account with the given earliest transaction
+// time can be shown in the daily chart, given the earliest available exchange rate. If rates are
+// only available after the first transaction, the account is shown from the first full day with
+// rates, instead of counting it as zero for the days without rates.
+func chartSeriesStart(earliestTxTime, earliestPriceAvailable time.Time) time.Time {
+	start := earliestTxTime.Truncate(24 * time.Hour)
+	if start.Before(earliestPriceAvailable) {
+		start = earliestPriceAvailable.Truncate(24 * time.Hour)
+		if start.Before(earliestPriceAvailable) {
+			start = start.Add(24 * time.Hour)
+		}
+	}
+	return start
+}
+
 // Chart has all data needed to show a time-based chart of their assets to the user.
 type Chart struct {
 	// If true, we are missing historical exchange rates or block headers needed to compute the
@@ -72,6 +96,14 @@ type Chart struct {
 	IsUpToDate bool `json:"chartIsUpToDate"`
 	// Latest rate timestamp available among all enabled coins.
 	LastTimestamp int64 `json:"lastTimestamp"`
+	// CoinsMissing lists the coins which are partially or entirely missing from the chart because
+	// of missing historical exchange rates. The chart is still shown with the other coins.
+	CoinsMissing []ChartCoinMissing `json:"chartCoinsMissing"`
+	// Derived series, only included if requested, see AddDerivedSeries().
+	Derived *ChartDerived `json:"chartDerived,omitempty"`
+	// ClockSkewed is true if the local clock is wrong, in which case the chart data can be missing
+	// or misplaced, as it is bucketed by the local time.
+	ClockSkewed bool `json:"clockSkewed"`
 }
 
 func (backend *Backend) addChartData(
@@ -119,12 +151,30 @@ func (backend *Backend) ChartData() (*Chart, error) {
 
 	fiat := backend.Config().AppConfig().Backend.MainFiat
 
+	// Coins without any historical rates yet are left out of the chart, so they don't hold back
+	// the chart of the other coins.
+	coinsWithRates := []string{}
+	for _, coinCode := range backend.allCoinCodes() {
+		if !backend.RatesUpdater().HistoryLatestTimestamp(coinCode, fiat).IsZero() {
+			coinsWithRates = append(coinsWithRates, coinCode)
+		}
+	}
 	// Chart data until this point in time.
-	until := backend.RatesUpdater().HistoryLatestTimestampAll(backend.allCoinCodes(), fiat)
+	until := backend.RatesUpdater().HistoryLatestTimestampAll(coinsWithRates, fiat)
 	if until.IsZero() {
 		chartDataMissing = true
 		backend.log.Info("ChartDataMissing, until is zero")
 	}
+	// Coins which are missing from the chart, and until when. The zero time means entirely.
+	coinsMissing := map[coin.Code]time.Time{}
+	markCoinMissing := func(coinCode coin.Code, missingUntil time.Time) {
+		previous, ok := coinsMissing[coinCode]
+		if !ok || (!previous.IsZero() && (missingUntil.IsZero() || missingUntil.After(previous))) {
+			coinsMissing[coinCode] = missingUntil
+		}
+	}
+	// True if at least one account contributes to the chart.
+	hasChartData := false
 	isUpToDate := time.Since(until) < 2*time.Hour
 	lastTimestamp := until.UnixMilli()
 
@@ -189,18 +239,29 @@ func (backend *Backend) ChartData() (*Chart, error) {
 			// Ignore the chart for this account, there is no timed transaction.
 			continue
 		}
-		if earliestPriceAvailable.IsZero() || earliestTxTime.Before(earliestPriceAvailable) {
-			chartDataMissing = true
+		if earliestPriceAvailable.IsZero() || earliestPriceAvailable.After(until) {
+			backend.log.WithField("coin", account.Coin().Code()).Info("Chart: no rates for coin")
+			markCoinMissing(account.Coin().Code(), time.Time{})
+			continue
+		}
+		dailyFrom := chartSeriesStart(earliestTxTime, earliestPriceAvailable)
+		if earliestTxTime.Before(earliestPriceAvailable) {
 			backend.log.
 				WithField("coin", account.Coin().Code()).
 				WithField("earliestTxTime", earliestTxTime).
 				WithField("earliestPriceAvailable", earliestPriceAvailable).
-				Info("ChartDataMissing")
-			continue
+				Info("Chart: rates for coin partially missing")
+			markCoinMissing(account.Coin().Code(), dailyFrom)
+		}
+		if hourlyFrom.Before(earliestPriceAvailable) {
+			hourlyFrom = earliestPriceAvailable.Truncate(time.Hour)
+			if hourlyFrom.Before(earliestPriceAvailable) {
+				hourlyFrom = hourlyFrom.Add(time.Hour)
+			}
 		}
 
 		timeseriesDaily, err := txs.Timeseries(
-			earliestTxTime.Truncate(24*time.Hour),
+			dailyFrom,
 			until,
 			24*time.Hour,
 		)
@@ -228,8 +289,25 @@ func (backend *Backend) ChartData() (*Chart, error) {
 
 		backend.addChartData(account.Coin().Code(), fiat, coinDecimals, timeseriesDaily, chartEntriesDaily)
 		backend.addChartData(account.Coin().Code(), fiat, coinDecimals, timeseriesHourly, chartEntriesHourly)
+		hasChartData = true
+	}
 
+	// Without any account contributing, there is no chart to show yet.
+	if !hasChartData && len(coinsMissing) > 0 {
+		backend.log.Info("ChartDataMissing, rates missing for all coins")
+		chartDataMissing = true
+	}
+	chartCoinsMissing := []ChartCoinMissing{}
+	for coinCode, missingUntil := range coinsMissing {
+		entry := ChartCoinMissing{Coin: coinCode}
+		if !missingUntil.IsZero() {
+			entry.Until = missingUntil.Unix()
+		}
+		chartCoinsMissing = append(chartCoinsMissing, entry)
 	}
+	sort.Slice(chartCoinsMissing, func(i, j int) bool {
+		return chartCoinsMissing[i].Coin < chartCoinsMissing[j].Coin
+	})
 
 	toSortedSlice := func(s map[int64]RatChartEntry, fiat string) []ChartEntry {
 		result := make([]ChartEntry, len(s))
@@ -296,5 +374,7 @@ func (backend *Backend) ChartData() (*Chart, error) {
 		FormattedTotal: formattedChartTotal,
 		IsUpToDate:     isUpToDate,
 		LastTimestamp:  lastTimestamp,
+		CoinsMissing:   chartCoinsMissing,
+		ClockSkewed:    backend.ClockSkew().Skewed,
 	}, nil
 }
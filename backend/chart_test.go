@@ -0,0 +1,35 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChartSeriesStart(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2024, 3, d, h, 0, 0, 0, time.UTC) }
+
+	// Rates available before the first transaction.
+	require.Equal(t, day(5, 0), chartSeriesStart(day(5, 13), day(1, 0)))
+	// Rates available from the beginning of the day of the first transaction.
+	require.Equal(t, day(5, 0), chartSeriesStart(day(5, 13), day(5, 0)))
+	// Rates only available from the middle of a later day: start at the next full day.
+	require.Equal(t, day(9, 0), chartSeriesStart(day(5, 13), day(8, 6)))
+	// Rates only available from the beginning of a later day.
+	require.Equal(t, day(8, 0), chartSeriesStart(day(5, 13), day(8, 0)))
+}
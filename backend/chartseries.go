@@ -0,0 +1,106 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+)
+
+// chartMovingAverageWindow is the time window of the moving average of the chart.
+const chartMovingAverageWindow = 7 * 24 * time.Hour
+
+// ChartPercentEntry is one data point of a percentage series.
+type ChartPercentEntry struct {
+	Time int64 `json:"time"`
+	// Percent is the relative change, e.g. 12.5 for +12.5%.
+	Percent float64 `json:"percent"`
+}
+
+// ChartDerived contains series derived from the chart points, so the frontend does not have to
+// compute them itself.
+type ChartDerived struct {
+	// 7 day moving average of the daily and hourly points.
+	MovingAverageDaily  []ChartEntry `json:"movingAverageDaily"`
+	MovingAverageHourly []ChartEntry `json:"movingAverageHourly"`
+	// Change of the daily and hourly points relative to the first point at or after the start of
+	// the range. Points before the start of the range, and points before the first non-zero value,
+	// are not included, as the change is undefined for them.
+	PercentChangeDaily  []ChartPercentEntry `json:"percentChangeDaily"`
+	PercentChangeHourly []ChartPercentEntry `json:"percentChangeHourly"`
+}
+
+// chartMovingAverage returns for each entry the average of the entries in the window ending at the
+// entry, including it. The entries must be sorted by time.
+func chartMovingAverage(entries []ChartEntry, window time.Duration, fiat string) []ChartEntry {
+	result := make([]ChartEntry, len(entries))
+	windowSeconds := int64(window.Seconds())
+	sum := 0.0
+	first := 0
+	for i, entry := range entries {
+		sum += entry.Value
+		for entries[first].Time <= entry.Time-windowSeconds {
+			sum -= entries[first].Value
+			first++
+		}
+		average := sum / float64(i-first+1)
+		result[i] = ChartEntry{
+			Time:           entry.Time,
+			Value:          average,
+			FormattedValue: coin.FormatAsCurrency(new(big.Rat).SetFloat64(average), fiat),
+		}
+	}
+	return result
+}
+
+// chartPercentChange returns the change of the entries relative to the first entry at or after
+// rangeStart (unix timestamp) with a non-zero value. The entries must be sorted by time.
+func chartPercentChange(entries []ChartEntry, rangeStart int64) []ChartPercentEntry {
+	result := []ChartPercentEntry{}
+	var startValue float64
+	for _, entry := range entries {
+		if entry.Time < rangeStart {
+			continue
+		}
+		if startValue == 0 {
+			if entry.Value == 0 {
+				continue
+			}
+			startValue = entry.Value
+		}
+		result = append(result, ChartPercentEntry{
+			Time:    entry.Time,
+			Percent: (entry.Value - startValue) / startValue * 100,
+		})
+	}
+	return result
+}
+
+// AddDerivedSeries computes the derived series of the chart. rangeStart is the unix timestamp of
+// the start of the range the percentage change refers to, zero for the whole chart. Nothing is
+// computed if the chart data is missing.
+func (chart *Chart) AddDerivedSeries(rangeStart int64) {
+	if chart.DataMissing {
+		return
+	}
+	chart.Derived = &ChartDerived{
+		MovingAverageDaily:  chartMovingAverage(chart.DataDaily, chartMovingAverageWindow, chart.Fiat),
+		MovingAverageHourly: chartMovingAverage(chart.DataHourly, chartMovingAverageWindow, chart.Fiat),
+		PercentChangeDaily:  chartPercentChange(chart.DataDaily, rangeStart),
+		PercentChangeHourly: chartPercentChange(chart.DataHourly, rangeStart),
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChartMovingAverage(t *testing.T) {
+	const day = 24 * 60 * 60
+	entries := []ChartEntry{
+		{Time: 0, Value: 2},
+		{Time: day, Value: 4},
+		{Time: 2 * day, Value: 6},
+		{Time: 3 * day, Value: 8},
+	}
+	result := chartMovingAverage(entries, 2*24*time.Hour, "USD")
+	require.Len(t, result, 4)
+	require.Equal(t, int64(0), result[0].Time)
+	require.Equal(t, 2.0, result[0].Value)
+	require.Equal(t, 3.0, result[1].Value)
+	// The point at the start of the window is excluded.
+	require.Equal(t, 5.0, result[2].Value)
+	require.Equal(t, 7.0, result[3].Value)
+	require.Equal(t, "7.00", result[3].FormattedValue)
+
+	require.Empty(t, chartMovingAverage(nil, time.Hour, "USD"))
+}
+
+func TestChartPercentChange(t *testing.T) {
+	entries := []ChartEntry{
+		{Time: 10, Value: 0},
+		{Time: 20, Value: 100},
+		{Time: 30, Value: 150},
+		{Time: 40, Value: 75},
+	}
+	// Leading zeroes are skipped.
+	require.Equal(t, []ChartPercentEntry{
+		{Time: 20, Percent: 0},
+		{Time: 30, Percent: 50},
+		{Time: 40, Percent: -25},
+	}, chartPercentChange(entries, 0))
+	// Relative to the range start.
+	require.Equal(t, []ChartPercentEntry{
+		{Time: 30, Percent: 0},
+		{Time: 40, Percent: -50},
+	}, chartPercentChange(entries, 25))
+	require.Empty(t, chartPercentChange(entries, 50))
+}
+
+func TestChartAddDerivedSeries(t *testing.T) {
+	chart := &Chart{DataMissing: true}
+	chart.AddDerivedSeries(0)
+	require.Nil(t, chart.Derived)
+
+	chart = &Chart{
+		DataDaily:  []ChartEntry{{Time: 1, Value: 1}, {Time: 2, Value: 2}},
+		DataHourly: []ChartEntry{},
+		Fiat:       "EUR",
+	}
+	chart.AddDerivedSeries(0)
+	require.NotNil(t, chart.Derived)
+	require.Len(t, chart.Derived.MovingAverageDaily, 2)
+	require.Empty(t, chart.Derived.MovingAverageHourly)
+	require.Equal(t, []ChartPercentEntry{{Time: 1, Percent: 0}, {Time: 2, Percent: 100}},
+		chart.Derived.PercentChangeDaily)
+}
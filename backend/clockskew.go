@@ -0,0 +1,123 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/diagnose"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+)
+
+const (
+	clockSourceServer       = "server"
+	clockSourceBlockHeaders = "blockHeaders"
+)
+
+// ClockSkewStatus describes how the local clock compares to a reference clock. A wrong clock can
+// break TLS connections, and shows wrong "time ago" values and missing chart data, as the chart is
+// bucketed by the local time.
+type ClockSkewStatus struct {
+	// Skewed is true if the local clock is considered wrong.
+	Skewed bool `json:"skewed"`
+	// Seconds is the local time minus the reference time. Only valid if Skewed is true.
+	Seconds int64 `json:"seconds"`
+	// Source is the reference clock: "server" for the Date header of our server, "blockHeaders"
+	// for the timestamp of the latest verified Bitcoin block header. Empty if the clock has not
+	// been checked yet.
+	Source string `json:"source"`
+}
+
+// ClockSkew returns the result of the latest clock check.
+func (backend *Backend) ClockSkew() ClockSkewStatus {
+	defer backend.clockSkewLock.RLock()()
+	return backend.clockSkew
+}
+
+func (backend *Backend) setClockSkew(status ClockSkewStatus) {
+	unlock := backend.clockSkewLock.Lock()
+	changed := status != backend.clockSkew
+	backend.clockSkew = status
+	unlock()
+	if !changed {
+		return
+	}
+	if status.Skewed {
+		backend.log.WithField("seconds", status.Seconds).WithField("source", status.Source).
+			Warning("Local clock is skewed")
+	}
+	backend.Notify(observable.Event{
+		Subject: "clock-skew",
+		Action:  action.Reload,
+	})
+}
+
+// latestBlockTime returns the timestamp of the latest verified block header among the loaded
+// Bitcoin-based accounts.
+func (backend *Backend) latestBlockTime() (time.Time, bool) {
+	var latest time.Time
+	for _, account := range backend.Accounts() {
+		coin, ok := account.Coin().(*btc.Coin)
+		if !ok || coin.Headers() == nil {
+			continue
+		}
+		header, err := coin.Headers().VerifiedHeaderByHeight(coin.Headers().TipHeight())
+		if err != nil || header == nil {
+			continue
+		}
+		if header.Timestamp.After(latest) {
+			latest = header.Timestamp
+		}
+	}
+	return latest, !latest.IsZero()
+}
+
+// checkClock compares the local clock with the Date header of our server, or with the latest
+// verified block header if our server cannot be reached, and stores the result. The returned
+// problem is nil if no skew was detected. The returned error is the one of the server request.
+func (backend *Backend) checkClock() (*diagnose.Problem, error) {
+	now := time.Now()
+	serverTime, err := diagnose.ServerTime(backend.httpClient, diagnoseURL)
+	if err == nil {
+		problem := diagnose.ClockSkew(now, serverTime)
+		status := ClockSkewStatus{Skewed: problem != nil, Source: clockSourceServer}
+		if status.Skewed {
+			status.Seconds = int64(now.Sub(serverTime).Seconds())
+		}
+		backend.setClockSkew(status)
+		return problem, nil
+	}
+	blockTime, ok := backend.latestBlockTime()
+	if !ok {
+		return nil, err
+	}
+	problem := diagnose.BlockTimeSkew(now, blockTime)
+	status := ClockSkewStatus{Skewed: problem != nil, Source: clockSourceBlockHeaders}
+	if status.Skewed {
+		status.Seconds = int64(now.Sub(blockTime).Seconds())
+	}
+	backend.setClockSkew(status)
+	return problem, err
+}
+
+// checkClockSkew checks the clock in the background, so the frontend can warn about a skewed clock
+// before the user runs into TLS errors or a chart which stays empty.
+func (backend *Backend) checkClockSkew() {
+	if _, err := backend.checkClock(); err != nil {
+		backend.log.WithError(err).Info("Could not check the clock")
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"path"
 	"sort"
 	"sync/atomic"
+	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
 	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
@@ -62,6 +63,10 @@ const (
 	// mempoolSpaceMirror is Shift server that mirrors "https://mempool.space/api/v1/fees/recommended"
 	// rest call.
 	mempoolSpaceMirror = "https://fees1.shiftcrypto.io"
+
+	// dataSaverMempoolFeesMaxAge is how long fetched mempool.space fees are reused in the data
+	// saver mode.
+	dataSaverMempoolFeesMaxAge = 10 * time.Minute
 )
 
 type subaccount struct {
@@ -107,9 +112,16 @@ type Account struct {
 	activeTxProposal     *maketx.TxProposal
 	activeTxProposalLock locker.Locker
 
-	// Access this only via getMinRelayFeeRate(). sat/kB.
-	minRelayFeeRate     *btcutil.Amount
-	minRelayFeeRateLock locker.Locker
+	// utxoReservations are the outputs reserved by pending tx proposals, if
+	// config.Backend.LockProposalUTXOs is enabled.
+	utxoReservations     utxoReservations
+	utxoReservationsLock locker.Locker
+
+	// mempoolFees are the last fetched mempool.space fees, reused in the data saver mode for
+	// dataSaverMempoolFeesMaxAge.
+	mempoolFees          *accounts.MempoolSpaceFees
+	mempoolFeesFetchedAt time.Time
+	mempoolFeesLock      locker.Locker
 
 	// true when initialized (Initialize() was called).
 	initialized     bool
@@ -144,6 +156,8 @@ func NewAccount(
 		dbSubfolder:    "", // set in Initialize()
 		forceGapLimits: forceGapLimits,
 
+		utxoReservations: utxoReservations{},
+
 		log:        log,
 		httpClient: httpClient,
 	}
@@ -245,23 +259,26 @@ func (account *Account) gapLimits(
 	})
 }
 
-// getMinRelayFeeRate fetches the min relay fee from the server and returns it. The value is cached
-// so that subsequent calls are instant. This is important as this function can be called many times
-// in succession when validating tx proposals.
+// getMinRelayFeeRate returns the min relay fee of the connected server. The value is cached by the
+// coin, see Coin.NetworkPolicies(), so that this function can be called many times in succession
+// when validating tx proposals.
 func (account *Account) getMinRelayFeeRate() (btcutil.Amount, error) {
-	defer account.minRelayFeeRateLock.Lock()()
-	cached := account.minRelayFeeRate
-	if cached != nil {
-		return *cached, nil
-	}
-
-	feeRate, err := account.coin.Blockchain().RelayFee()
+	policies, err := account.coin.NetworkPolicies()
 	if err != nil {
 		return 0, err
 	}
-	account.minRelayFeeRate = &feeRate
-	account.log.Infof("min relay fee rate: %s", feeRate)
-	return feeRate, nil
+	return policies.MinRelayFeeRate, nil
+}
+
+// isMultisig returns true if the account is a multisig account, whose transactions need the
+// signatures of the cosigners.
+func (account *Account) isMultisig() bool {
+	for _, signingConfiguration := range account.Config().Config.SigningConfigurations {
+		if signingConfiguration.BitcoinMultisig != nil {
+			return true
+		}
+	}
+	return false
 }
 
 func (account *Account) isInitialized() bool {
@@ -318,7 +335,6 @@ func (account *Account) Initialize() error {
 			// as we need to synchronize with the new backend.
 			account.ResetSynced()
 			account.SetOffline(nil)
-			account.minRelayFeeRate = nil
 			account.log.Debug("Connection to blockchain backend established")
 		}
 	}
@@ -327,8 +343,11 @@ func (account *Account) Initialize() error {
 	account.coin.Blockchain().RegisterOnConnectionErrorChangedEvent(onConnectionStatusChanged)
 	theHeaders := account.coin.Headers()
 	theHeaders.SubscribeEvent(func(event headers.Event) {
-		if event == headers.EventSynced {
+		switch event {
+		case headers.EventSynced:
 			account.Config().OnEvent(accountsTypes.EventHeadersSynced)
+		case headers.EventReorg:
+			account.onReorg()
 		}
 	})
 	account.transactions = transactions.NewTransactions(
@@ -393,6 +412,12 @@ func (account *Account) Info() *accounts.Info {
 	isInsuredAccount := account.Config().Config.InsuranceStatus == string(bitsurance.ActiveStatus)
 	var signingConfigurations []*signing.Configuration
 	for _, subacc := range account.subaccounts {
+		if subacc.signingConfiguration.BitcoinMultisig != nil {
+			// The cosigner keys are shown as is, as multisig descriptors use the plain xpub/tpub
+			// version.
+			signingConfigurations = append(signingConfigurations, subacc.signingConfiguration)
+			continue
+		}
 		isNativeSegwit := subacc.signingConfiguration.ScriptType() == signing.ScriptTypeP2WPKH
 		// hiding legacy/taproot xpubs as an insured account should only receive on native segwit.
 		if isInsuredAccount && !isNativeSegwit {
@@ -433,6 +458,36 @@ func (account *Account) onNewHeader(header *electrumTypes.Header) {
 	account.log.WithField("block-height", header.Height).Debug("Received new header")
 }
 
+// onReorg is called when a reorg replaced blocks which were synced before. The txs in these blocks
+// are verified again, and the history of their addresses is fetched again to pick up txs which
+// moved to a different block or vanished. The frontend is notified, so it can explain the
+// changes of the balance.
+func (account *Account) onReorg() {
+	if account.isClosed() {
+		account.log.Debug("Ignoring reorg after the account was closed")
+		return
+	}
+	height := account.coin.Headers().ReorgHeight()
+	if height < 0 {
+		return
+	}
+	affectedAddresses := account.transactions.RevalidateFrom(height)
+	if len(affectedAddresses) == 0 {
+		return
+	}
+	account.log.WithField("height", height).Info("Repairing the transaction history after a reorg")
+	for _, scriptHashHex := range affectedAddresses {
+		address := account.getAddress(scriptHashHex)
+		if address == nil {
+			continue
+		}
+		// The empty status differs from the status of any address with txs, so the history is
+		// fetched again.
+		go account.onAddressStatus(address, "")
+	}
+	account.Config().OnEvent(accountsTypes.EventReorg)
+}
+
 // FatalError returns true if the account had a fatal error.
 func (account *Account) FatalError() bool {
 	return account.fatalError.Load()
@@ -447,8 +502,15 @@ func (account *Account) Close() {
 	}
 	account.BaseAccount.Close()
 	account.log.Info("Closed account")
-	// TODO: deregister from json RPC client. The client can be closed when no account uses
-	// the client any longer.
+	// The blockchain client is shared by all accounts of the coin, so we only stop the address
+	// notifications of this account.
+	for _, subacc := range account.subaccounts {
+		for _, chain := range []*addresses.AddressChain{subacc.receiveAddresses, subacc.changeAddresses} {
+			for _, address := range chain.Addresses() {
+				account.coin.Blockchain().ScriptHashUnsubscribe(address.PubkeyScriptHashHex())
+			}
+		}
+	}
 	account.ResetSynced()
 	if account.transactions != nil {
 		account.transactions.Close()
@@ -475,6 +537,25 @@ func (account *Account) Notifier() accounts.Notifier {
 	return account.notifier
 }
 
+// fetchMempoolFees fetches the mempool.space fees. In the data saver mode, recently fetched fees are
+// reused. Returns nil if the fees could not be fetched.
+func (account *Account) fetchMempoolFees() *accounts.MempoolSpaceFees {
+	defer account.mempoolFeesLock.Lock()()
+	if account.coin.DataSaver() && account.mempoolFees != nil &&
+		time.Since(account.mempoolFeesFetchedAt) < dataSaverMempoolFeesMaxAge {
+		return account.mempoolFees
+	}
+	mempoolFees := &accounts.MempoolSpaceFees{}
+	_, err := util.APIGet(account.httpClient, mempoolSpaceMirror, "", 1000, mempoolFees)
+	if err != nil {
+		account.log.WithError(err).Errorf("Fetching fees from %s failed", mempoolSpaceMirror)
+		return nil
+	}
+	account.mempoolFees = mempoolFees
+	account.mempoolFeesFetchedAt = time.Now()
+	return mempoolFees
+}
+
 // feeTargets fetches the available fees. For mainnet BTC it uses mempool.space estimation.
 //
 // For the other coins or in case mempool.space is not available it fallbacks on Bitcoin Core.
@@ -484,12 +565,7 @@ func (account *Account) feeTargets() []*FeeTarget {
 	// for mainnet BTC we fetch mempool.space fees, as they should be more reliable.
 	var mempoolFees *accounts.MempoolSpaceFees
 	if account.coin.Code() == coin.CodeBTC {
-		mempoolFees = &accounts.MempoolSpaceFees{}
-		_, err := util.APIGet(account.httpClient, mempoolSpaceMirror, "", 1000, mempoolFees)
-		if err != nil {
-			mempoolFees = nil
-			account.log.WithError(err).Errorf("Fetching fees from %s failed", mempoolSpaceMirror)
-		}
+		mempoolFees = account.fetchMempoolFees()
 	}
 
 	// feeTargets must be sorted by ascending priority.
@@ -717,15 +793,15 @@ func (account *Account) Transactions() (accounts.OrderedTransactions, error) {
 	if account.fatalError.Load() {
 		return nil, errp.New("can't call Transactions() after a fatal error")
 	}
-	return account.transactions.Transactions(
+	transactions, err := account.transactions.Transactions(
 		func(scriptHashHex blockchain.ScriptHashHex) bool {
-			for _, subacc := range account.subaccounts {
-				if subacc.changeAddresses.LookupByScriptHashHex(scriptHashHex) != nil {
-					return true
-				}
-			}
-			return false
+			return account.lookupChangeAddress(scriptHashHex) != nil
 		})
+	if err != nil {
+		return nil, err
+	}
+	accounts.ApplyConfirmationThreshold(transactions, account.ConfirmationThreshold(account.coin.Code()))
+	return transactions, nil
 }
 
 // GetUnusedReceiveAddresses returns a number of unused addresses. Returns nil if the account is not initialized.
@@ -791,10 +867,14 @@ func (account *Account) VerifyAddress(addressID string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	if canVerifyAddress {
-		return true, keystore.VerifyAddress(address.Configuration, account.Coin())
+	if !canVerifyAddress {
+		return false, nil
 	}
-	return false, nil
+	if address.AccountConfiguration.BitcoinMultisig != nil {
+		return true, keystore.VerifyMultisigAddress(
+			account.Coin(), address.AccountConfiguration, address.Configuration.AbsoluteKeypath())
+	}
+	return true, keystore.VerifyAddress(address.Configuration, account.Coin())
 }
 
 // CanVerifyAddresses wraps Keystores().CanVerifyAddresses(), see that function for documentation.
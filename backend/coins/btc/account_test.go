@@ -16,28 +16,37 @@
 package btc_test
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"math/big"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
 	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	blockchainMock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/message"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
 	keystoremock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
-	"github.com/BitBoxSwiss/bitbox-wallet-app/util/socksproxy"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/proxy"
 )
 
 func mockKeystore() *keystoremock.KeystoreMock {
@@ -59,7 +68,7 @@ func mockAccount(t *testing.T, accountConfig *config.Account) *btc.Account {
 	defer func() { _ = os.RemoveAll(dbFolder) }()
 
 	coin := btc.NewCoin(
-		code, "Bitcoin Testnet", unit, coin.BtcUnitDefault, net, dbFolder, nil, explorer, socksproxy.NewSocksProxy(false, ""))
+		code, "Bitcoin Testnet", unit, coin.BtcUnitDefault, net, dbFolder, nil, explorer, proxy.Direct)
 
 	blockchainMock := &blockchainMock.BlockchainMock{}
 	blockchainMock.MockRegisterOnConnectionErrorChangedEvent = func(f func(error)) {}
@@ -198,3 +207,152 @@ func TestSignAddress(t *testing.T) {
 	require.Equal(t, base64.StdEncoding.EncodeToString([]byte("signature")), signature)
 
 }
+
+func TestReceiveAddressUsage(t *testing.T) {
+	account := mockAccount(t, nil)
+	_, err := account.ReceiveAddressUsage()
+	require.Error(t, err)
+	require.NoError(t, account.Initialize())
+
+	usages, err := account.ReceiveAddressUsage()
+	require.NoError(t, err)
+	require.Len(t, usages, 20)
+	for i, usage := range usages {
+		require.False(t, usage.Used())
+		require.Equal(t, btcutil.Amount(0), usage.TotalReceived)
+		require.Nil(t, usage.FirstUsed)
+		require.Equal(t, signing.ScriptTypeP2WPKH, usage.ScriptType)
+		require.Equal(t, fmt.Sprintf("m/84'/1'/0'/0/%d", i), usage.Keypath.Encode())
+	}
+
+	firstUsed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	lastUsed := firstUsed.Add(time.Hour)
+	usages[0].TxCount = 2
+	usages[0].TotalReceived = 150000
+	usages[0].FirstUsed = &firstUsed
+	usages[0].LastUsed = &lastUsed
+	var buf bytes.Buffer
+	require.NoError(t, account.ExportReceiveAddressUsageCSV(&buf, usages[:2]))
+	require.Equal(t,
+		"Address,Script Type,Keypath,Used,Transactions,Total Received,Unit,First Used,Last Used\n"+
+			usages[0].Address+",p2wpkh,m/84'/1'/0'/0/0,true,2,150000,satoshi,2024-01-02T03:04:05Z,2024-01-02T04:04:05Z\n"+
+			usages[1].Address+",p2wpkh,m/84'/1'/0'/0/1,false,0,0,satoshi,,\n",
+		buf.String())
+}
+
+func TestSnapshot(t *testing.T) {
+	account := mockAccount(t, nil)
+	_, err := account.Snapshot(0)
+	require.Error(t, err)
+	require.NoError(t, account.Initialize())
+	// The mocked blockchain does not report a chain tip.
+	_, err = account.Snapshot(0)
+	require.Error(t, err)
+
+	snapshot := &btc.Snapshot{
+		Coin:        coin.CodeTBTC,
+		AccountCode: "accountcode",
+		AccountName: "accountname",
+		BlockHeight: 100,
+		CreatedAt:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Balance:     150000,
+		Unit:        "satoshi",
+		UTXOs:       []*btc.SnapshotUTXO{},
+	}
+	attestation, err := account.AttestSnapshot(snapshot)
+	require.NoError(t, err)
+	snapshotJSON, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	require.JSONEq(t, string(snapshotJSON), string(attestation.Snapshot))
+	hash := sha256.Sum256(snapshotJSON)
+	require.Equal(t, hex.EncodeToString(hash[:]), attestation.SnapshotHash)
+	require.Equal(t,
+		"Balance attestation\nAccount: accountname\nBlock height: 100\nBalance: 0.00150000 TBTC\nSnapshot SHA256: "+
+			attestation.SnapshotHash,
+		attestation.Message)
+	usages, err := account.ReceiveAddressUsage()
+	require.NoError(t, err)
+	require.Equal(t, usages[0].Address, attestation.Address)
+	require.Equal(t, "m/84'/1'/0'/0/0", attestation.Keypath.Encode())
+	require.Equal(t, signing.ScriptTypeP2WPKH, attestation.ScriptType)
+	require.Equal(t, base64.StdEncoding.EncodeToString([]byte("signature")), attestation.Signature)
+}
+
+func TestAddressList(t *testing.T) {
+	account := mockAccount(t, nil)
+	_, err := account.UpcomingReceiveAddresses("", 5)
+	require.Error(t, err)
+	require.NoError(t, account.Initialize())
+
+	_, err = account.UpcomingReceiveAddresses(signing.ScriptTypeP2TR, 5)
+	require.Error(t, err)
+	_, err = account.UpcomingReceiveAddresses("", 0)
+	require.Error(t, err)
+	// Only the addresses within the gap limit are watched.
+	_, err = account.UpcomingReceiveAddresses("", 21)
+	require.Equal(t, btc.ErrAddressListTooLong, errp.Cause(err))
+
+	addressList, err := account.UpcomingReceiveAddresses(signing.ScriptTypeP2WPKH, 3)
+	require.NoError(t, err)
+	require.Equal(t, signing.ScriptTypeP2WPKH, addressList.ScriptType)
+	require.Len(t, addressList.Addresses, 3)
+	usages, err := account.ReceiveAddressUsage()
+	require.NoError(t, err)
+	for i, address := range addressList.Addresses {
+		require.Equal(t, usages[i].Address, address.Address)
+		require.Equal(t, fmt.Sprintf("m/84'/1'/0'/0/%d", i), address.Keypath.Encode())
+	}
+
+	attestation, err := account.AttestAddressList(addressList)
+	require.NoError(t, err)
+	addressListJSON, err := json.Marshal(addressList)
+	require.NoError(t, err)
+	require.JSONEq(t, string(addressListJSON), string(attestation.AddressList))
+	hash := sha256.Sum256(addressListJSON)
+	require.Equal(t, hex.EncodeToString(hash[:]), attestation.AddressListHash)
+	require.Equal(t,
+		"Address list\nAccount: accountname\nAddresses: 3\nFrom m/84'/1'/0'/0/0\nTo m/84'/1'/0'/0/2\nList SHA256: "+
+			attestation.AddressListHash,
+		attestation.Message)
+	require.Equal(t, usages[0].Address, attestation.Address)
+	require.Equal(t, base64.StdEncoding.EncodeToString([]byte("signature")), attestation.Signature)
+}
+
+func TestSignMessage(t *testing.T) {
+	account := mockAccount(t, nil)
+	require.NoError(t, account.Initialize())
+	usages, err := account.ReceiveAddressUsage()
+	require.NoError(t, err)
+
+	signedMessage, err := account.SignMessage(usages[3].Address, "Hello there")
+	require.NoError(t, err)
+	require.Equal(t, &btc.SignedMessage{
+		Address:   usages[3].Address,
+		Format:    message.FormatLegacy,
+		Signature: base64.StdEncoding.EncodeToString([]byte("signature")),
+	}, signedMessage)
+
+	signedMessage, err = account.SignMessage("", "Hello there")
+	require.NoError(t, err)
+	require.Equal(t, usages[0].Address, signedMessage.Address)
+
+	_, err = account.SignMessage("tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", "Hello there")
+	require.Equal(t, btc.ErrAddressNotInAccount, errp.Cause(err))
+	_, err = account.SignMessage("invalid", "Hello there")
+	require.Equal(t, message.ErrInvalidAddress, errp.Cause(err))
+}
+
+func TestDescriptors(t *testing.T) {
+	account := mockAccount(t, nil)
+	require.NoError(t, account.Initialize())
+
+	descriptors, err := account.Descriptors()
+	require.NoError(t, err)
+	require.Len(t, descriptors, 1)
+	require.Equal(t, signing.ScriptTypeP2WPKH, descriptors[0].ScriptType)
+	xpub := account.Config().Config.SigningConfigurations[0].ExtendedPublicKey().String()
+	require.True(t, strings.HasPrefix(
+		descriptors[0].Receive, "wpkh([01020304/84'/1'/0']"+xpub+"/0/*)#"), descriptors[0].Receive)
+	require.True(t, strings.HasPrefix(
+		descriptors[0].Change, "wpkh([01020304/84'/1'/0']"+xpub+"/1/*)#"), descriptors[0].Change)
+}
@@ -15,12 +15,16 @@
 package addresses
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"fmt"
+	"sort"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
 	ourbtcutil "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/util"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
@@ -39,7 +43,8 @@ type AccountAddress struct {
 	// Configuration contains the absolute keypath and the extended public keys of the address.
 	Configuration *signing.Configuration
 
-	// redeemScript stores the redeem script of a BIP16 P2SH output or nil if address type is P2PKH.
+	// redeemScript stores the redeem script of a BIP16 P2SH output, the witness script of a P2WSH
+	// output or nil if address type is P2PKH.
 	redeemScript []byte
 
 	log *logrus.Entry
@@ -97,6 +102,16 @@ func NewAccountAddress(
 		if err != nil {
 			log.WithError(err).Panic("Failed to get p2tr addr")
 		}
+	case signing.ScriptTypeP2WSH:
+		redeemScript, err = sortedMultisigScript(configuration.BitcoinMultisig)
+		if err != nil {
+			log.WithError(err).Panic("Failed to build the multisig witness script.")
+		}
+		witnessScriptHash := sha256.Sum256(redeemScript)
+		address, err = btcutil.NewAddressWitnessScriptHash(witnessScriptHash[:], net)
+		if err != nil {
+			log.WithError(err).Panic("Failed to get p2wsh addr. from witness script.")
+		}
 	default:
 		log.Panic(fmt.Sprintf("Unrecognized script type: %s", configuration.ScriptType()))
 	}
@@ -110,6 +125,32 @@ func NewAccountAddress(
 	}
 }
 
+// sortedMultisigScript returns the CHECKMULTISIG script of the keys of the multisig
+// configuration, sorted lexicographically by their compressed public keys (BIP67).
+func sortedMultisigScript(multisig *signing.BitcoinMultisig) ([]byte, error) {
+	publicKeys := make([][]byte, len(multisig.KeyInfos))
+	for i, keyInfo := range multisig.KeyInfos {
+		publicKey, err := keyInfo.ExtendedPublicKey.ECPubKey()
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		publicKeys[i] = publicKey.SerializeCompressed()
+	}
+	sort.Slice(publicKeys, func(i, j int) bool { return bytes.Compare(publicKeys[i], publicKeys[j]) < 0 })
+	builder := txscript.NewScriptBuilder().AddInt64(int64(multisig.Threshold))
+	for _, publicKey := range publicKeys {
+		builder.AddData(publicKey)
+	}
+	script, err := builder.
+		AddInt64(int64(len(publicKeys))).
+		AddOp(txscript.OP_CHECKMULTISIG).
+		Script()
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return script, nil
+}
+
 // ID implements accounts.Address.
 func (address *AccountAddress) ID() string {
 	return string(address.PubkeyScriptHashHex())
@@ -151,6 +192,8 @@ func (address *AccountAddress) ScriptForHashToSign() (bool, []byte) {
 		return true, address.redeemScript
 	case signing.ScriptTypeP2WPKH:
 		return true, address.PubkeyScript()
+	case signing.ScriptTypeP2WSH:
+		return true, address.redeemScript
 	default:
 		address.log.Panic("Unrecognized address type.")
 	}
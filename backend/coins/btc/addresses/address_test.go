@@ -15,7 +15,10 @@
 package addresses_test
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"os"
+	"sort"
 	"testing"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
@@ -24,8 +27,10 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
 	testlog "github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -110,3 +115,60 @@ func TestAddressP2TR(t *testing.T) {
 		require.Equal(t, test.expectedAddress, addr.EncodeForHumans())
 	}
 }
+
+func TestAddressP2WSHMultisig(t *testing.T) {
+	keypath, err := signing.NewAbsoluteKeypath("m/48'/1'/0'/2'")
+	require.NoError(t, err)
+	keyInfos := make([]signing.KeyInfo, 3)
+	for i := range keyInfos {
+		xprv, err := hdkeychain.NewMaster(bytes.Repeat([]byte{byte(i + 1)}, 32), net)
+		require.NoError(t, err)
+		xpub, err := xprv.Neuter()
+		require.NoError(t, err)
+		keyInfos[i] = signing.KeyInfo{
+			RootFingerprint:   []byte{byte(i), 2, 3, 4},
+			AbsoluteKeypath:   keypath,
+			ExtendedPublicKey: xpub,
+		}
+	}
+	newAddress := func(keyInfos []signing.KeyInfo, ourKeyIndex int) *addresses.AccountAddress {
+		configuration, err := signing.NewBitcoinMultisigConfiguration(2, keyInfos, ourKeyIndex)
+		require.NoError(t, err)
+		relKeypath, err := signing.NewRelativeKeypath("0/5")
+		require.NoError(t, err)
+		return addresses.NewAccountAddress(
+			configuration, relKeypath, net, logging.Get().WithGroup("addresses_test"))
+	}
+	address := newAddress(keyInfos, 1)
+	require.Equal(t, "m/48'/1'/0'/2'/0/5", address.AbsoluteKeypath().Encode())
+	require.Equal(t, signing.ScriptTypeP2WSH, address.Configuration.ScriptType())
+
+	// Build the expected 2-of-3 sorted multisig script independently.
+	publicKeys := make([]*btcutil.AddressPubKey, len(keyInfos))
+	for i, keyInfo := range keyInfos {
+		derived, err := keyInfo.ExtendedPublicKey.Derive(0)
+		require.NoError(t, err)
+		derived, err = derived.Derive(5)
+		require.NoError(t, err)
+		publicKey, err := derived.ECPubKey()
+		require.NoError(t, err)
+		publicKeys[i], err = btcutil.NewAddressPubKey(publicKey.SerializeCompressed(), net)
+		require.NoError(t, err)
+	}
+	sort.Slice(publicKeys, func(i, j int) bool {
+		return bytes.Compare(publicKeys[i].ScriptAddress(), publicKeys[j].ScriptAddress()) < 0
+	})
+	witnessScript, err := txscript.MultiSigScript(publicKeys, 2)
+	require.NoError(t, err)
+	witnessScriptHash := sha256.Sum256(witnessScript)
+	expectedAddress, err := btcutil.NewAddressWitnessScriptHash(witnessScriptHash[:], net)
+	require.NoError(t, err)
+	require.Equal(t, expectedAddress.EncodeAddress(), address.EncodeForHumans())
+	isSegwit, scriptForHashToSign := address.ScriptForHashToSign()
+	require.True(t, isSegwit)
+	require.Equal(t, witnessScript, scriptForHashToSign)
+
+	// The order of the cosigners does not change the address.
+	reversedKeyInfos := []signing.KeyInfo{keyInfos[2], keyInfos[1], keyInfos[0]}
+	require.Equal(t, address.EncodeForHumans(), newAddress(reversedKeyInfos, 0).EncodeForHumans())
+}
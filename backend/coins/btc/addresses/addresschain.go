@@ -73,6 +73,14 @@ func (addresses *AddressChain) GetUnused() ([]*AccountAddress, error) {
 	return addresses.addresses[len(addresses.addresses)-unusedTailCount:], nil
 }
 
+// Addresses returns all addresses derived so far, in derivation order.
+func (addresses *AddressChain) Addresses() []*AccountAddress {
+	defer addresses.addressesLock.RLock()()
+	result := make([]*AccountAddress, len(addresses.addresses))
+	copy(result, addresses.addresses)
+	return result
+}
+
 // addAddress appends a new address at the end of the chain.
 func (addresses *AddressChain) addAddress() *AccountAddress {
 	addresses.log.Debug("Add new address to chain")
@@ -161,3 +161,17 @@ func (s *addressChainTestSuite) TestEnsureAddresses() {
 	require.NoError(s.T(), err)
 	require.Len(s.T(), addrs, 0)
 }
+
+func (s *addressChainTestSuite) TestAddresses() {
+	s.isAddressUsed = func(*addresses.AccountAddress) bool { return false }
+	require.Empty(s.T(), s.addresses.Addresses())
+	newAddresses, err := s.addresses.EnsureAddresses()
+	require.NoError(s.T(), err)
+	firstAddress := newAddresses[0]
+	s.isAddressUsed = func(addr *addresses.AccountAddress) bool {
+		return addr == firstAddress
+	}
+	moreAddresses, err := s.addresses.EnsureAddresses()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), append(newAddresses, moreAddresses...), s.addresses.Addresses())
+}
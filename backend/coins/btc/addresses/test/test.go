@@ -66,3 +66,40 @@ func GetAddress(scriptType signing.ScriptType) *addresses.AccountAddress {
 		logging.Get().WithGroup("addresses_test"),
 	)
 }
+
+// GetMultisigAddress returns a dummy P2WSH address of a threshold-of-numKeys multisig
+// configuration.
+func GetMultisigAddress(threshold int, numKeys int) *addresses.AccountAddress {
+	keypath, err := signing.NewAbsoluteKeypath("m/48'/1'/0'/2'")
+	if err != nil {
+		panic(err)
+	}
+	keyInfos := make([]signing.KeyInfo, numKeys)
+	for i := range keyInfos {
+		seed := make([]byte, hdkeychain.RecommendedSeedLen)
+		seed[0] = byte(i)
+		xprv, err := hdkeychain.NewMaster(seed, net)
+		if err != nil {
+			panic(err)
+		}
+		xpub, err := xprv.Neuter()
+		if err != nil {
+			panic(err)
+		}
+		keyInfos[i] = signing.KeyInfo{
+			RootFingerprint:   []byte{byte(i), 2, 3, 4},
+			AbsoluteKeypath:   keypath,
+			ExtendedPublicKey: xpub,
+		}
+	}
+	configuration, err := signing.NewBitcoinMultisigConfiguration(threshold, keyInfos, 0)
+	if err != nil {
+		panic(err)
+	}
+	return addresses.NewAccountAddress(
+		configuration,
+		signing.NewEmptyRelativeKeypath(),
+		net,
+		logging.Get().WithGroup("addresses_test"),
+	)
+}
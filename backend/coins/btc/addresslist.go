@@ -0,0 +1,153 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// ErrAddressListTooLong is returned by UpcomingReceiveAddresses() if more addresses are requested
+// than the account watches. Only watched addresses are exported, as payments to addresses beyond
+// the gap limit would not be found. The gap limit can be increased in the account settings.
+const ErrAddressListTooLong errp.ErrorCode = "gapLimitExceeded"
+
+// ListedAddress is a receive address in an address list.
+type ListedAddress struct {
+	Address string                  `json:"address"`
+	Keypath signing.AbsoluteKeypath `json:"keypath"`
+}
+
+// AddressList is a list of unused receive addresses of an account, e.g. to pre-load them into an
+// invoicing system.
+type AddressList struct {
+	Coin        coin.Code          `json:"coin"`
+	AccountCode accountsTypes.Code `json:"accountCode"`
+	AccountName string             `json:"accountName"`
+	ScriptType  signing.ScriptType `json:"scriptType"`
+	CreatedAt   time.Time          `json:"createdAt"`
+	Addresses   []*ListedAddress   `json:"addresses"`
+}
+
+// AddressListAttestation is an address list signed with the private key of an account address,
+// proving that the listed addresses belong to the account.
+type AddressListAttestation struct {
+	// AddressList is the JSON encoded address list. AddressListHash is the hex encoded SHA256 hash
+	// of its compact encoding.
+	AddressList     json.RawMessage `json:"addressList"`
+	AddressListHash string          `json:"addressListHash"`
+	// Message is the signed message, committing to the address list hash.
+	Message    string                  `json:"message"`
+	Address    string                  `json:"address"`
+	ScriptType signing.ScriptType      `json:"scriptType"`
+	Keypath    signing.AbsoluteKeypath `json:"keypath"`
+	// Signature is the base64 encoded message signature, as used by Electrum and Bitcoin Core.
+	Signature string `json:"signature"`
+}
+
+// UpcomingReceiveAddresses returns the next count unused receive addresses of the given script
+// type, in derivation order. If scriptType is empty, the first address type of the account is
+// used. At most as many addresses as the receive gap limit can be listed.
+func (account *Account) UpcomingReceiveAddresses(
+	scriptType signing.ScriptType, count int) (*AddressList, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account not initialized")
+	}
+	if count < 1 {
+		return nil, errp.Newf("invalid count %d", count)
+	}
+	accountConfig := account.Config().Config
+	signingConfigIdx := 0
+	if scriptType != "" {
+		signingConfigIdx = accountConfig.SigningConfigurations.FindScriptType(scriptType)
+		if signingConfigIdx == -1 {
+			return nil, errp.Newf("the account has no %s addresses", scriptType)
+		}
+	}
+	account.Synchronizer.WaitSynchronized()
+	subacc := account.subaccounts[signingConfigIdx]
+	unusedAddresses, err := subacc.receiveAddresses.GetUnused()
+	if err != nil {
+		return nil, err
+	}
+	if count > len(unusedAddresses) {
+		return nil, errp.WithStack(ErrAddressListTooLong)
+	}
+	addressList := &AddressList{
+		Coin:        account.coin.Code(),
+		AccountCode: accountConfig.Code,
+		AccountName: accountConfig.Name,
+		ScriptType:  subacc.signingConfiguration.ScriptType(),
+		CreatedAt:   time.Now().UTC(),
+		Addresses:   make([]*ListedAddress, count),
+	}
+	for i, address := range unusedAddresses[:count] {
+		addressList.Addresses[i] = &ListedAddress{
+			Address: address.EncodeForHumans(),
+			Keypath: address.AbsoluteKeypath(),
+		}
+	}
+	return addressList, nil
+}
+
+// addressListMessage is the message signed to attest an address list. It is shown on the device,
+// so it is kept short and readable.
+func addressListMessage(addressList *AddressList, addressListHash string) string {
+	message := fmt.Sprintf("Address list\nAccount: %s\nAddresses: %d",
+		addressList.AccountName, len(addressList.Addresses))
+	if len(addressList.Addresses) != 0 {
+		message += fmt.Sprintf("\nFrom %s\nTo %s",
+			addressList.Addresses[0].Keypath.Encode(),
+			addressList.Addresses[len(addressList.Addresses)-1].Keypath.Encode())
+	}
+	return message + "\nList SHA256: " + addressListHash
+}
+
+// AttestAddressList signs the address list with the first receive address of the account, using
+// the connected keystore.
+func (account *Account) AttestAddressList(addressList *AddressList) (*AddressListAttestation, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account not initialized")
+	}
+	addressListJSON, err := json.Marshal(addressList)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	hash := sha256.Sum256(addressListJSON)
+	addressListHash := hex.EncodeToString(hash[:])
+	message := addressListMessage(addressList, addressListHash)
+
+	address, signature, err := account.signAttestation(message)
+	if err != nil {
+		return nil, err
+	}
+	return &AddressListAttestation{
+		AddressList:     addressListJSON,
+		AddressListHash: addressListHash,
+		Message:         message,
+		Address:         address.EncodeForHumans(),
+		ScriptType:      address.Configuration.ScriptType(),
+		Keypath:         address.AbsoluteKeypath(),
+		Signature:       signature,
+	}, nil
+}
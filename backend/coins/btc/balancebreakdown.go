@@ -0,0 +1,173 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"sort"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+// BalancePart is the available and incoming balance of a part of the account.
+type BalancePart struct {
+	Available btcutil.Amount
+	Incoming  btcutil.Amount
+}
+
+func (part *BalancePart) add(value btcutil.Amount, incoming bool) {
+	if incoming {
+		part.Incoming += value
+	} else {
+		part.Available += value
+	}
+}
+
+func (part *BalancePart) total() btcutil.Amount {
+	return part.Available + part.Incoming
+}
+
+// ScriptTypeBalance is the balance held in addresses of one script type.
+type ScriptTypeBalance struct {
+	BalancePart
+	ScriptType signing.ScriptType
+}
+
+// AddressBalance is the balance held in one address.
+type AddressBalance struct {
+	BalancePart
+	Address    string
+	Keypath    signing.AbsoluteKeypath
+	ScriptType signing.ScriptType
+	// Change is true for change addresses, false for receive addresses.
+	Change bool
+	// OutputCount is the number of unspent outputs in the address.
+	OutputCount int
+}
+
+// BalanceBreakdown shows where the balance of an account sits, e.g. to prepare migrating to other
+// script types or consolidating coins.
+type BalanceBreakdown struct {
+	Receive BalancePart
+	Change  BalancePart
+	// ScriptTypes are ordered by the order of the signing configurations of the account.
+	ScriptTypes []*ScriptTypeBalance
+	// Addresses are ordered by their total balance, largest first.
+	Addresses []*AddressBalance
+}
+
+// balanceBreakdownInput is an unspent output to be included in the breakdown.
+type balanceBreakdownInput struct {
+	value    btcutil.Amount
+	incoming bool
+	address  *AddressBalance
+}
+
+// computeBalanceBreakdown sums up the given outputs. The address fields of the inputs are used to
+// identify and describe the addresses, their balances are ignored.
+func computeBalanceBreakdown(
+	outputs []balanceBreakdownInput, scriptTypes []signing.ScriptType) *BalanceBreakdown {
+	breakdown := &BalanceBreakdown{
+		ScriptTypes: make([]*ScriptTypeBalance, len(scriptTypes)),
+		Addresses:   []*AddressBalance{},
+	}
+	byScriptType := map[signing.ScriptType]*ScriptTypeBalance{}
+	for i, scriptType := range scriptTypes {
+		breakdown.ScriptTypes[i] = &ScriptTypeBalance{ScriptType: scriptType}
+		byScriptType[scriptType] = breakdown.ScriptTypes[i]
+	}
+	byAddress := map[string]*AddressBalance{}
+	for _, output := range outputs {
+		if output.address.Change {
+			breakdown.Change.add(output.value, output.incoming)
+		} else {
+			breakdown.Receive.add(output.value, output.incoming)
+		}
+		scriptTypeBalance, ok := byScriptType[output.address.ScriptType]
+		if !ok {
+			scriptTypeBalance = &ScriptTypeBalance{ScriptType: output.address.ScriptType}
+			byScriptType[output.address.ScriptType] = scriptTypeBalance
+			breakdown.ScriptTypes = append(breakdown.ScriptTypes, scriptTypeBalance)
+		}
+		scriptTypeBalance.add(output.value, output.incoming)
+
+		addressBalance, ok := byAddress[output.address.Address]
+		if !ok {
+			addressBalance = &AddressBalance{
+				Address:    output.address.Address,
+				Keypath:    output.address.Keypath,
+				ScriptType: output.address.ScriptType,
+				Change:     output.address.Change,
+			}
+			byAddress[output.address.Address] = addressBalance
+			breakdown.Addresses = append(breakdown.Addresses, addressBalance)
+		}
+		addressBalance.add(output.value, output.incoming)
+		addressBalance.OutputCount++
+	}
+	sort.SliceStable(breakdown.Addresses, func(i, j int) bool {
+		left, right := breakdown.Addresses[i], breakdown.Addresses[j]
+		if left.total() != right.total() {
+			return left.total() > right.total()
+		}
+		return left.Address < right.Address
+	})
+	return breakdown
+}
+
+// BalanceBreakdown returns the balance of the account broken down by receive and change
+// addresses, by script type and by address.
+func (account *Account) BalanceBreakdown() (*BalanceBreakdown, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account not initialized")
+	}
+	unspentOutputs, err := account.transactions.UnspentOutputs()
+	if err != nil {
+		return nil, err
+	}
+	outputs := make([]balanceBreakdownInput, 0, len(unspentOutputs))
+	for _, output := range unspentOutputs {
+		scriptHashHex := blockchain.NewScriptHashHex(output.PkScript)
+		address := account.getAddress(scriptHashHex)
+		if address == nil {
+			account.log.Error("Unspent output does not belong to an address of the account")
+			continue
+		}
+		change := false
+		for _, subacc := range account.subaccounts {
+			if subacc.changeAddresses.LookupByScriptHashHex(scriptHashHex) != nil {
+				change = true
+				break
+			}
+		}
+		outputs = append(outputs, balanceBreakdownInput{
+			value:    btcutil.Amount(output.Value),
+			incoming: output.Incoming,
+			address: &AddressBalance{
+				Address:    address.EncodeForHumans(),
+				Keypath:    address.AbsoluteKeypath(),
+				ScriptType: address.Configuration.ScriptType(),
+				Change:     change,
+			},
+		})
+	}
+	scriptTypes := []signing.ScriptType{}
+	for _, subacc := range account.subaccounts {
+		scriptTypes = append(scriptTypes, subacc.signingConfiguration.ScriptType())
+	}
+	return computeBalanceBreakdown(outputs, scriptTypes), nil
+}
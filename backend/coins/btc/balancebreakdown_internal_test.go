@@ -0,0 +1,74 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeBalanceBreakdown(t *testing.T) {
+	scriptTypes := []signing.ScriptType{signing.ScriptTypeP2WPKH, signing.ScriptTypeP2TR}
+	require.Equal(t,
+		&BalanceBreakdown{
+			ScriptTypes: []*ScriptTypeBalance{
+				{ScriptType: signing.ScriptTypeP2WPKH},
+				{ScriptType: signing.ScriptTypeP2TR},
+			},
+			Addresses: []*AddressBalance{},
+		},
+		computeBalanceBreakdown(nil, scriptTypes))
+
+	receive1 := &AddressBalance{Address: "receive1", ScriptType: signing.ScriptTypeP2WPKH}
+	receive2 := &AddressBalance{Address: "receive2", ScriptType: signing.ScriptTypeP2TR}
+	change1 := &AddressBalance{Address: "change1", ScriptType: signing.ScriptTypeP2WPKH, Change: true}
+	legacy := &AddressBalance{Address: "legacy", ScriptType: signing.ScriptTypeP2PKH}
+	breakdown := computeBalanceBreakdown([]balanceBreakdownInput{
+		{value: 1000, address: receive1},
+		{value: 500, incoming: true, address: receive1},
+		{value: 2000, address: receive2},
+		{value: 300, address: change1},
+		{value: 200, address: change1},
+		{value: 10, address: legacy},
+	}, scriptTypes)
+
+	require.Equal(t, BalancePart{Available: 3010, Incoming: 500}, breakdown.Receive)
+	require.Equal(t, BalancePart{Available: 500}, breakdown.Change)
+
+	require.Len(t, breakdown.ScriptTypes, 3)
+	require.Equal(t, &ScriptTypeBalance{
+		BalancePart: BalancePart{Available: 1500, Incoming: 500},
+		ScriptType:  signing.ScriptTypeP2WPKH,
+	}, breakdown.ScriptTypes[0])
+	require.Equal(t, btcutil.Amount(2000), breakdown.ScriptTypes[1].Available)
+	// Script types which are not configured in the account come last.
+	require.Equal(t, signing.ScriptTypeP2PKH, breakdown.ScriptTypes[2].ScriptType)
+
+	require.Len(t, breakdown.Addresses, 4)
+	require.Equal(t, "receive2", breakdown.Addresses[0].Address)
+	require.Equal(t, &AddressBalance{
+		BalancePart: BalancePart{Available: 1000, Incoming: 500},
+		Address:     "receive1",
+		ScriptType:  signing.ScriptTypeP2WPKH,
+		OutputCount: 2,
+	}, breakdown.Addresses[1])
+	require.Equal(t, "change1", breakdown.Addresses[2].Address)
+	require.True(t, breakdown.Addresses[2].Change)
+	require.Equal(t, 2, breakdown.Addresses[2].OutputCount)
+	require.Equal(t, "legacy", breakdown.Addresses[3].Address)
+}
@@ -19,6 +19,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/block-client-go/electrum/types"
@@ -109,6 +110,9 @@ type Interface interface {
 	ScriptHashGetHistory(ScriptHashHex) (TxHistory, error)
 	TransactionGet(chainhash.Hash) (*wire.MsgTx, error)
 	ScriptHashSubscribe(func() func(), ScriptHashHex, func(string))
+	// ScriptHashUnsubscribe stops the notifications of a script hash subscribed with
+	// ScriptHashSubscribe.
+	ScriptHashUnsubscribe(ScriptHashHex)
 	HeadersSubscribe(func(*types.Header))
 	TransactionBroadcast(*wire.MsgTx) error
 	RelayFee() (btcutil.Amount, error)
@@ -119,3 +123,34 @@ type Interface interface {
 	ConnectionError() error
 	RegisterOnConnectionErrorChangedEvent(func(error))
 }
+
+// ConnectionInfo describes the connection to the blockchain index backend.
+type ConnectionInfo struct {
+	// Server is the server which is connected, empty if no server is connected.
+	Server string
+	// Latency is the time it took to connect to Server, including the TLS handshake and the
+	// protocol negotiation.
+	Latency time.Duration
+}
+
+// ConnectionInfoProvider is implemented by the blockchain index backends which can report the
+// server they are connected to.
+type ConnectionInfoProvider interface {
+	ConnectionInfo() ConnectionInfo
+}
+
+// FeeHistogramEntry is one entry of the fee histogram of a mempool, see
+// https://electrumx-spesmilo.readthedocs.io/en/latest/protocol-methods.html#mempool-get-fee-histogram.
+type FeeHistogramEntry struct {
+	// FeeRate is the fee rate in sat/vB.
+	FeeRate float64
+	// VSize is the total virtual size of the mempool transactions paying between FeeRate and the
+	// fee rate of the previous entry.
+	VSize int64
+}
+
+// FeeHistogramProvider is implemented by the blockchain index backends which can report the fee
+// histogram of their mempool. The entries are sorted by descending fee rate.
+type FeeHistogramProvider interface {
+	FeeHistogram() ([]FeeHistogramEntry, error)
+}
@@ -167,6 +167,11 @@ func (_m *Interface) ScriptHashSubscribe(_a0 func() func(), _a1 blockchain.Scrip
 	_m.Called(_a0, _a1, _a2)
 }
 
+// ScriptHashUnsubscribe provides a mock function with given fields: _a0
+func (_m *Interface) ScriptHashUnsubscribe(_a0 blockchain.ScriptHashHex) {
+	_m.Called(_a0)
+}
+
 // TransactionBroadcast provides a mock function with given fields: _a0
 func (_m *Interface) TransactionBroadcast(_a0 *wire.MsgTx) error {
 	ret := _m.Called(_a0)
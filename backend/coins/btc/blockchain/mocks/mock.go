@@ -26,17 +26,19 @@ import (
 
 // BlockchainMock implements blockchain.Interface for use in tests.
 type BlockchainMock struct {
-	MockScriptHashGetHistory func(blockchain.ScriptHashHex) (blockchain.TxHistory, error)
-	MockTransactionGet       func(chainhash.Hash) (*wire.MsgTx, error)
-	MockScriptHashSubscribe  func(func() func(), blockchain.ScriptHashHex, func(string))
-	MockHeadersSubscribe     func(func(*types.Header))
-	MockTransactionBroadcast func(*wire.MsgTx) error
-	MockRelayFee             func() (btcutil.Amount, error)
-	MockEstimateFee          func(int) (btcutil.Amount, error)
-	MockHeaders              func(int, int) (*blockchain.HeadersResult, error)
-	MockGetMerkle            func(chainhash.Hash, int) (*blockchain.GetMerkleResult, error)
-	MockClose                func()
-	MockConnectionError      func() error
+	MockScriptHashGetHistory  func(blockchain.ScriptHashHex) (blockchain.TxHistory, error)
+	MockTransactionGet        func(chainhash.Hash) (*wire.MsgTx, error)
+	MockScriptHashSubscribe   func(func() func(), blockchain.ScriptHashHex, func(string))
+	MockScriptHashUnsubscribe func(blockchain.ScriptHashHex)
+	MockHeadersSubscribe      func(func(*types.Header))
+	MockTransactionBroadcast  func(*wire.MsgTx) error
+	MockRelayFee              func() (btcutil.Amount, error)
+	MockEstimateFee           func(int) (btcutil.Amount, error)
+	MockHeaders               func(int, int) (*blockchain.HeadersResult, error)
+	MockGetMerkle             func(chainhash.Hash, int) (*blockchain.GetMerkleResult, error)
+	MockClose                 func()
+	MockConnectionError       func() error
+	MockFeeHistogram          func() ([]blockchain.FeeHistogramEntry, error)
 
 	MockRegisterOnConnectionErrorChangedEvent func(func(error))
 }
@@ -64,6 +66,13 @@ func (b *BlockchainMock) ScriptHashSubscribe(setupAndTeardown func() func(), s b
 	}
 }
 
+// ScriptHashUnsubscribe implements Interface.
+func (b *BlockchainMock) ScriptHashUnsubscribe(s blockchain.ScriptHashHex) {
+	if b.MockScriptHashUnsubscribe != nil {
+		b.MockScriptHashUnsubscribe(s)
+	}
+}
+
 // HeadersSubscribe implements Interface.
 func (b *BlockchainMock) HeadersSubscribe(success func(*types.Header)) {
 	if b.MockHeadersSubscribe != nil {
@@ -135,3 +144,11 @@ func (b *BlockchainMock) RegisterOnConnectionErrorChangedEvent(f func(error)) {
 		b.MockRegisterOnConnectionErrorChangedEvent(f)
 	}
 }
+
+// FeeHistogram implements blockchain.FeeHistogramProvider.
+func (b *BlockchainMock) FeeHistogram() ([]blockchain.FeeHistogramEntry, error) {
+	if b.MockFeeHistogram != nil {
+		return b.MockFeeHistogram()
+	}
+	return nil, errors.New("fee histogram not available")
+}
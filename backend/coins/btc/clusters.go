@@ -0,0 +1,177 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"sort"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// AddressCluster is a set of own addresses which are publicly linkable, because they were spent
+// together in the same transaction.
+type AddressCluster struct {
+	Addresses []string `json:"addresses"`
+	// TxIDs are the transactions linking the addresses.
+	TxIDs []string `json:"txIDs"`
+}
+
+// ClusterAnalysis is the result of the common-input-ownership clustering of the account history.
+type ClusterAnalysis struct {
+	// Clusters contains the clusters of two or more addresses, largest first.
+	Clusters []*AddressCluster `json:"clusters"`
+	// UsedAddresses is the number of addresses with at least one transaction.
+	UsedAddresses int `json:"usedAddresses"`
+	// LinkedAddresses is the number of addresses which are part of a cluster.
+	LinkedAddresses int `json:"linkedAddresses"`
+}
+
+// spendingTx is a transaction and the own addresses it spends from.
+type spendingTx struct {
+	txID      string
+	addresses []string
+}
+
+// clusterAddresses groups the addresses spent together, using union-find. Addresses which are
+// linked through several transactions end up in the same cluster.
+func clusterAddresses(txs []spendingTx) []*AddressCluster {
+	parent := map[string]string{}
+	var find func(string) string
+	find = func(address string) string {
+		if parent[address] != address {
+			parent[address] = find(parent[address])
+		}
+		return parent[address]
+	}
+	for _, tx := range txs {
+		for _, address := range tx.addresses {
+			if _, ok := parent[address]; !ok {
+				parent[address] = address
+			}
+		}
+		for _, address := range tx.addresses[1:] {
+			parent[find(address)] = find(tx.addresses[0])
+		}
+	}
+
+	clusters := map[string]*AddressCluster{}
+	for address := range parent {
+		root := find(address)
+		cluster, ok := clusters[root]
+		if !ok {
+			cluster = &AddressCluster{Addresses: []string{}, TxIDs: []string{}}
+			clusters[root] = cluster
+		}
+		cluster.Addresses = append(cluster.Addresses, address)
+	}
+	for _, tx := range txs {
+		if len(tx.addresses) > 1 {
+			cluster := clusters[find(tx.addresses[0])]
+			cluster.TxIDs = append(cluster.TxIDs, tx.txID)
+		}
+	}
+
+	result := []*AddressCluster{}
+	for _, cluster := range clusters {
+		if len(cluster.Addresses) < 2 {
+			continue
+		}
+		sort.Strings(cluster.Addresses)
+		sort.Strings(cluster.TxIDs)
+		result = append(result, cluster)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if len(result[i].Addresses) != len(result[j].Addresses) {
+			return len(result[i].Addresses) > len(result[j].Addresses)
+		}
+		return result[i].Addresses[0] < result[j].Addresses[0]
+	})
+	return result
+}
+
+// AddressClusters applies the common-input-ownership heuristic to the history of the account: all
+// inputs of a transaction are assumed to belong to the same owner, so their addresses are
+// publicly linkable. Only own addresses are reported.
+func (account *Account) AddressClusters() (*ClusterAnalysis, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account not initialized")
+	}
+	if account.fatalError.Load() {
+		return nil, errp.New("can't call AddressClusters() after a fatal error")
+	}
+	account.Synchronizer.WaitSynchronized()
+	return transactions.DBView(account.db, func(dbTx transactions.DBTxInterface) (*ClusterAnalysis, error) {
+		txHashes, err := dbTx.Transactions()
+		if err != nil {
+			return nil, err
+		}
+		txs := []spendingTx{}
+		for _, txHash := range txHashes {
+			txInfo, err := dbTx.TxInfo(txHash)
+			if err != nil {
+				return nil, err
+			}
+			if txInfo == nil {
+				continue
+			}
+			seen := map[string]bool{}
+			spentFrom := []string{}
+			for _, txIn := range txInfo.Tx.TxIn {
+				prevOut, err := dbTx.Output(txIn.PreviousOutPoint)
+				if err != nil {
+					return nil, err
+				}
+				if prevOut == nil {
+					// Not ours.
+					continue
+				}
+				address := account.getAddress(blockchain.NewScriptHashHex(prevOut.PkScript))
+				if address == nil {
+					continue
+				}
+				encoded := address.EncodeForHumans()
+				if !seen[encoded] {
+					seen[encoded] = true
+					spentFrom = append(spentFrom, encoded)
+				}
+			}
+			if len(spentFrom) > 0 {
+				txs = append(txs, spendingTx{txID: txHash.String(), addresses: spentFrom})
+			}
+		}
+
+		analysis := &ClusterAnalysis{Clusters: clusterAddresses(txs)}
+		for _, cluster := range analysis.Clusters {
+			analysis.LinkedAddresses += len(cluster.Addresses)
+		}
+		for _, subacc := range account.subaccounts {
+			for _, chain := range []*addresses.AddressChain{subacc.receiveAddresses, subacc.changeAddresses} {
+				for _, address := range chain.Addresses() {
+					history, err := dbTx.AddressHistory(address.PubkeyScriptHashHex())
+					if err != nil {
+						return nil, err
+					}
+					if len(history) > 0 {
+						analysis.UsedAddresses++
+					}
+				}
+			}
+		}
+		return analysis, nil
+	})
+}
@@ -0,0 +1,40 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterAddresses(t *testing.T) {
+	require.Empty(t, clusterAddresses(nil))
+
+	clusters := clusterAddresses([]spendingTx{
+		// Spending from a single address links nothing.
+		{txID: "tx1", addresses: []string{"a"}},
+		{txID: "tx2", addresses: []string{"b", "c"}},
+		{txID: "tx3", addresses: []string{"d", "e"}},
+		// Links both clusters above.
+		{txID: "tx4", addresses: []string{"e", "c"}},
+		{txID: "tx5", addresses: []string{"f", "g"}},
+		{txID: "tx6", addresses: []string{"a"}},
+	})
+	require.Equal(t, []*AddressCluster{
+		{Addresses: []string{"b", "c", "d", "e"}, TxIDs: []string{"tx2", "tx3", "tx4"}},
+		{Addresses: []string{"f", "g"}, TxIDs: []string{"tx5"}},
+	}, clusters)
+}
@@ -20,6 +20,7 @@ import (
 	"math/big"
 	"os"
 	"path"
+	"regexp"
 	"sync"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
@@ -28,15 +29,18 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/electrum"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/headers"
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/ltc"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
-	"github.com/BitBoxSwiss/bitbox-wallet-app/util/socksproxy"
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/bech32"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/net/proxy"
 )
 
 // Coin models a Bitcoin-related coin.
@@ -58,6 +62,15 @@ type Coin struct {
 	blockchain blockchain.Interface
 	headers    *headers.Headers
 
+	// dataSaver is the data saver mode, see SetDataSaver(). dataSaverLock also guards setting
+	// headers.
+	dataSaver     bool
+	dataSaverLock locker.Locker
+
+	// policies caches the network policies, see NetworkPolicies().
+	policies     *NetworkPolicies
+	policiesLock locker.Locker
+
 	log *logrus.Entry
 }
 
@@ -71,7 +84,7 @@ func NewCoin(
 	dbFolder string,
 	servers []*config.ServerInfo,
 	blockExplorerTxPrefix string,
-	socksProxy socksproxy.SocksProxy,
+	dialer proxy.Dialer,
 ) *Coin {
 	log := logging.Get().WithGroup("coin").WithField("code", code)
 	coin := &Coin{
@@ -86,7 +99,7 @@ func NewCoin(
 			return electrum.NewElectrumConnection(
 				servers,
 				log,
-				socksProxy.GetTCPProxyDialer(),
+				dialer,
 			)
 		},
 		log: log,
@@ -105,6 +118,12 @@ func (coin *Coin) Initialize() {
 	coin.initOnce.Do(func() {
 		// Init blockchain
 		coin.blockchain = coin.makeBlockchain()
+		coin.blockchain.RegisterOnConnectionErrorChangedEvent(func(err error) {
+			if err == nil {
+				// The policies of the newly connected server might differ.
+				coin.resetNetworkPolicies()
+			}
+		})
 
 		// Init Headers
 
@@ -120,11 +139,14 @@ func (coin *Coin) Initialize() {
 		if err != nil {
 			coin.log.WithError(err).Panic("Could not open headers DB")
 		}
+		unlock := coin.dataSaverLock.Lock()
 		coin.headers = headers.NewHeaders(
 			coin.net,
 			db,
 			coin.blockchain,
 			coin.log)
+		coin.headers.SetDataSaver(coin.dataSaver)
+		unlock()
 		coin.headers.Initialize()
 		coin.headers.SubscribeEvent(func(event headers.Event) {
 			if event == headers.EventSyncing || event == headers.EventSynced {
@@ -142,6 +164,22 @@ func (coin *Coin) Initialize() {
 	})
 }
 
+// SetDataSaver enables or disables the data saver mode, in which catching up with the headers is
+// deferred and the accounts fetch fee estimations less often.
+func (coin *Coin) SetDataSaver(enabled bool) {
+	defer coin.dataSaverLock.Lock()()
+	coin.dataSaver = enabled
+	if coin.headers != nil {
+		coin.headers.SetDataSaver(enabled)
+	}
+}
+
+// DataSaver returns true if the data saver mode is enabled.
+func (coin *Coin) DataSaver() bool {
+	defer coin.dataSaverLock.RLock()()
+	return coin.dataSaver
+}
+
 // Name implements coinpkg.Coin.
 func (coin *Coin) Name() string {
 	return coin.name
@@ -249,15 +287,66 @@ func (coin *Coin) SmallestUnit() string {
 	}
 }
 
+// FeeSubUnit implements coinpkg.Coin.
+func (coin *Coin) FeeSubUnit() (string, uint) {
+	switch coin.code {
+	case coinpkg.CodeLTC, coinpkg.CodeTLTC:
+		return "litoshi", 0
+	case coinpkg.CodeTBTC:
+		return "tsat", 0
+	default:
+		return "sat", 0
+	}
+}
+
+// knownNets are the networks of all supported Bitcoin-based coins. They are used to detect addresses
+// that are valid, but for the wrong network.
+var knownNets = []*chaincfg.Params{
+	&chaincfg.MainNetParams,
+	&chaincfg.TestNet3Params,
+	&chaincfg.RegressionNetParams,
+	&ltc.MainNetParams,
+	&ltc.TestNet4Params,
+}
+
+// ethAddressRegex matches a hex encoded Ethereum address.
+var ethAddressRegex = regexp.MustCompile(`^0[xX][0-9a-fA-F]{40}$`)
+
+// invalidAddressError returns the most specific error describing why the address could not be
+// decoded, so that the user can be given an actionable hint.
+func (coin *Coin) invalidAddressError(address string) error {
+	if ethAddressRegex.MatchString(address) {
+		return errors.ErrInvalidAddressEthereum
+	}
+	hrp, data, version, err := bech32.DecodeGeneric(address)
+	if err == nil && len(data) > 0 && hrp == coin.net.Bech32HRPSegwit {
+		witnessVersion := data[0]
+		if (witnessVersion == 0 && version != bech32.Version0) ||
+			(witnessVersion != 0 && version != bech32.VersionM) {
+			return errors.ErrInvalidAddressBech32Variant
+		}
+	}
+	for _, net := range knownNets {
+		if net == coin.net {
+			continue
+		}
+		otherAddress, err := btcutil.DecodeAddress(address, net)
+		if err == nil && otherAddress.IsForNet(net) {
+			return errors.ErrInvalidAddressWrongNetwork
+		}
+	}
+	return errors.ErrInvalidAddress
+}
+
 // DecodeAddress decodes a btc/ltc address, checking that the format matches the account coin
 // type.
 func (coin *Coin) DecodeAddress(address string) (btcutil.Address, error) {
 	btcAddress, err := btcutil.DecodeAddress(address, coin.Net())
 	if err != nil {
-		return nil, errp.WithStack(errors.ErrInvalidAddress)
+		return nil, errp.WithStack(coin.invalidAddressError(address))
 	}
 	if !btcAddress.IsForNet(coin.Net()) {
-		return nil, errp.WithStack(errors.ErrInvalidAddress)
+		return nil, errp.WithStack(errors.ErrInvalidAddressWrongNetwork)
 	}
 	if _, ok := btcAddress.(*btcutil.AddressTaproot); ok {
 		switch coin.code {
@@ -265,12 +354,38 @@ func (coin *Coin) DecodeAddress(address string) (btcutil.Address, error) {
 			// Taproot activated on Bitcoin.
 		default:
 			// Taproot not activated on other coins.
-			return nil, errp.WithStack(errors.ErrInvalidAddress)
+			return nil, errp.WithStack(errors.ErrInvalidAddressTaprootNotSupported)
 		}
 	}
 	return btcAddress, nil
 }
 
+// EstimateFee estimates the fee of a transaction with the given virtual size (in vbytes) to be
+// confirmed within the given number of blocks. If no estimate is available, the min relay fee is
+// used instead. The coin is initialized if needed, so it can be used without any account.
+func (coin *Coin) EstimateFee(vsize int, blocks int) (btcutil.Amount, error) {
+	coin.Initialize()
+	policies, policiesErr := coin.NetworkPolicies()
+	feeRatePerKb, err := coin.blockchain.EstimateFee(blocks)
+	if err != nil {
+		if policiesErr != nil {
+			return 0, errp.WithStack(errors.ErrFeesNotAvailable)
+		}
+		feeRatePerKb = policies.MinRelayFeeRate
+	}
+	if policiesErr == nil && feeRatePerKb < policies.MinRelayFeeRate {
+		feeRatePerKb = policies.MinRelayFeeRate
+	}
+	return feeRatePerKb * btcutil.Amount(vsize) / 1000, nil
+}
+
+// TipHeight returns the height of the latest verified block header. The coin is initialized if
+// needed, so it can be used without any account.
+func (coin *Coin) TipHeight() int {
+	coin.Initialize()
+	return coin.headers.TipHeight()
+}
+
 // Close implements coinpkg.Coin.
 func (coin *Coin) Close() error {
 	coin.log.Info("closing coin")
@@ -27,12 +27,13 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/ltc"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
-	"github.com/BitBoxSwiss/bitbox-wallet-app/util/socksproxy"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
 	"github.com/BitBoxSwiss/block-client-go/electrum/types"
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/proxy"
 )
 
 const (
@@ -59,7 +60,7 @@ func (s *testSuite) SetupTest() {
 	s.dbFolder = test.TstTempDir("btc-dbfolder")
 
 	s.coin = btc.NewCoin(s.code, "Some coin", s.unit, coin.BtcUnitDefault, s.net, s.dbFolder, nil,
-		explorer, socksproxy.NewSocksProxy(false, ""))
+		explorer, proxy.Direct)
 	blockchainMock := &blockchainMock.BlockchainMock{}
 	blockchainMock.MockHeadersSubscribe = func(
 		result func(*types.Header)) {
@@ -203,9 +204,103 @@ func (s *testSuite) TestDecodeAddress() {
 		require.NoError(s.T(), err, validAddress)
 		require.Equal(s.T(), validAddress, addr.EncodeAddress())
 	}
+	// All invalid addresses above are valid on a different network.
 	for _, invalidAddress := range invalidAddresses {
 		_, err := s.coin.DecodeAddress(invalidAddress)
-		require.Equal(s.T(), errors.ErrInvalidAddress, errp.Cause(err), invalidAddress)
+		require.Equal(s.T(), errors.ErrInvalidAddressWrongNetwork, errp.Cause(err), invalidAddress)
 	}
 
+	_, err := s.coin.DecodeAddress("0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1")
+	require.Equal(s.T(), errors.ErrInvalidAddressEthereum, errp.Cause(err))
+
+	_, err = s.coin.DecodeAddress("not an address")
+	require.Equal(s.T(), errors.ErrInvalidAddress, errp.Cause(err))
+
+	switch s.code {
+	case coin.CodeBTC:
+		// Bech32m instead of Bech32 (BIP-350 test vector).
+		_, err = s.coin.DecodeAddress("bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh")
+		require.Equal(s.T(), errors.ErrInvalidAddressBech32Variant, errp.Cause(err))
+		// Bech32 instead of Bech32m (the p2tr address above encoded with a bech32 checksum).
+		_, err = s.coin.DecodeAddress("bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqr9a0ap")
+		require.Equal(s.T(), errors.ErrInvalidAddressBech32Variant, errp.Cause(err))
+	case coin.CodeTBTC:
+		// Bech32m instead of Bech32 (BIP-350 test vector).
+		_, err = s.coin.DecodeAddress("tb1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq24jc47")
+		require.Equal(s.T(), errors.ErrInvalidAddressBech32Variant, errp.Cause(err))
+		// Bech32 instead of Bech32m (BIP-350 test vector).
+		_, err = s.coin.DecodeAddress("tb1z0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqglt7rf")
+		require.Equal(s.T(), errors.ErrInvalidAddressBech32Variant, errp.Cause(err))
+	}
+}
+
+func TestEstimateFee(t *testing.T) {
+	dbFolder := test.TstTempDir("btc-dbfolder")
+	defer func() { _ = os.RemoveAll(dbFolder) }()
+
+	var estimateErr error
+	btcCoin := btc.NewCoin(coin.CodeBTC, "Bitcoin", "BTC", coin.BtcUnitDefault, &chaincfg.MainNetParams,
+		dbFolder, nil, explorer, proxy.Direct)
+	btcCoin.TstSetMakeBlockchain(func() blockchain.Interface {
+		return &blockchainMock.BlockchainMock{
+			MockHeadersSubscribe: func(result func(*types.Header)) {},
+			MockRelayFee:         func() (btcutil.Amount, error) { return 1000, nil },
+			MockEstimateFee: func(blocks int) (btcutil.Amount, error) {
+				require.Equal(t, 6, blocks)
+				return 20000, estimateErr
+			},
+		}
+	})
+
+	fee, err := btcCoin.EstimateFee(250, 6)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(5000), fee)
+
+	// Fallback to the min relay fee.
+	estimateErr = errp.New("no estimate")
+	fee, err = btcCoin.EstimateFee(250, 6)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(250), fee)
+}
+
+func TestNetworkPolicies(t *testing.T) {
+	dbFolder := test.TstTempDir("btc-dbfolder")
+	defer func() { _ = os.RemoveAll(dbFolder) }()
+
+	relayFeeCalls := 0
+	var onConnectionErrorChanged func(error)
+	ltcCoin := btc.NewCoin(coin.CodeLTC, "Litecoin", "LTC", coin.BtcUnitDefault, &ltc.MainNetParams,
+		dbFolder, nil, explorer, proxy.Direct)
+	ltcCoin.TstSetMakeBlockchain(func() blockchain.Interface {
+		return &blockchainMock.BlockchainMock{
+			MockHeadersSubscribe: func(result func(*types.Header)) {},
+			MockRegisterOnConnectionErrorChangedEvent: func(f func(error)) {
+				onConnectionErrorChanged = f
+			},
+			MockRelayFee: func() (btcutil.Amount, error) {
+				relayFeeCalls++
+				return 10000, nil
+			},
+		}
+	})
+
+	policies, err := ltcCoin.NetworkPolicies()
+	require.NoError(t, err)
+	require.Equal(t, &btc.NetworkPolicies{
+		MinRelayFeeRate:           10000,
+		MinRelayFeeRateSource:     btc.PolicySourceServer,
+		DustRelayFeeRate:          30000,
+		DustRelayFeeRateSource:    btc.PolicySourceDefault,
+		MaxStandardTxWeight:       400000,
+		MaxStandardTxWeightSource: btc.PolicySourceDefault,
+	}, policies)
+
+	// Cached until reconnecting.
+	_, err = ltcCoin.NetworkPolicies()
+	require.NoError(t, err)
+	require.Equal(t, 1, relayFeeCalls)
+	onConnectionErrorChanged(nil)
+	_, err = ltcCoin.NetworkPolicies()
+	require.NoError(t, err)
+	require.Equal(t, 2, relayFeeCalls)
 }
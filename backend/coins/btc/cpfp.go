@@ -0,0 +1,141 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// CPFPProposal is a proposed child tx speeding up the confirmation of an unconfirmed parent tx
+// (child-pays-for-parent).
+type CPFPProposal struct {
+	// Amount is the amount the child tx sends back to the account.
+	Amount btcutil.Amount
+	// Fee is the fee of the child tx.
+	Fee btcutil.Amount
+	// ParentFeeRatePerKb is the fee rate of the parent tx alone.
+	ParentFeeRatePerKb btcutil.Amount
+	// PackageFeeRatePerKb is the combined fee rate of the parent and the child tx, which is what
+	// miners consider when including the parent.
+	PackageFeeRatePerKb btcutil.Amount
+}
+
+// txFee returns the fee of the tx, fetching the transactions of its inputs, which can be spent
+// outputs of others.
+func txFee(tx *wire.MsgTx, getPrevTx func(chainhash.Hash) (*wire.MsgTx, error)) (btcutil.Amount, error) {
+	fee := btcutil.Amount(0)
+	for _, txIn := range tx.TxIn {
+		prevTx, err := getPrevTx(txIn.PreviousOutPoint.Hash)
+		if err != nil {
+			return 0, err
+		}
+		if int(txIn.PreviousOutPoint.Index) >= len(prevTx.TxOut) {
+			return 0, errp.New("tx spends a non-existing output")
+		}
+		fee += btcutil.Amount(prevTx.TxOut[txIn.PreviousOutPoint.Index].Value)
+	}
+	for _, txOut := range tx.TxOut {
+		fee -= btcutil.Amount(txOut.Value)
+	}
+	return fee, nil
+}
+
+// CPFPProposal creates a tx spending the unconfirmed outputs of the account in the tx with the
+// given ID back to the account, paying a fee such that both txs together pay the fee rate of the
+// fee target in args. This speeds up incoming txs whose sender paid a low fee. The other fields
+// of args except AllowHighFee are ignored. Like TxProposal(), the proposal is stored internally and
+// can be signed and sent with SendTx().
+//
+// If the parent tx itself spends unconfirmed outputs, the fees of those ancestors are not taken
+// into account.
+func (account *Account) CPFPProposal(txID string, args *accounts.TxProposalArgs) (*CPFPProposal, error) {
+	defer account.activeTxProposalLock.Lock()()
+
+	account.log.Debug("Proposing child-pays-for-parent transaction")
+	txHash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	parentTx, parentOutputs, err := account.transactions.UnconfirmedTxOutputs(*txHash)
+	if err != nil {
+		return nil, err
+	}
+	if parentTx == nil {
+		return nil, errp.WithStack(errors.ErrFeeBumpUnavailable)
+	}
+	parentFee, err := txFee(parentTx, account.coin.Blockchain().TransactionGet)
+	if err != nil {
+		return nil, err
+	}
+	parentVSize := mempool.GetTxVirtualSize(btcutil.NewTx(parentTx))
+	utxos := make(map[wire.OutPoint]maketx.UTXO, len(parentOutputs))
+	for outPoint, txOut := range parentOutputs {
+		utxos[outPoint] = maketx.UTXO{
+			TxOut:         txOut,
+			Configuration: account.getAddress(blockchain.NewScriptHashHex(txOut.PkScript)).Configuration,
+		}
+	}
+	changeAddress, err := account.pickChangeAddress(utxos, nil, accounts.ChangePolicyDefault)
+	if err != nil {
+		return nil, err
+	}
+	feeRatePerKb, err := account.getFeePerKb(args)
+	if err != nil {
+		return nil, err
+	}
+	txProposal, err := maketx.NewTxCPFP(
+		account.coin,
+		int(parentVSize),
+		parentFee,
+		utxos,
+		changeAddress,
+		feeRatePerKb,
+		account.log,
+	)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := account.coin.NetworkPolicies()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPolicies(txProposal, utxos, policies); err != nil {
+		return nil, err
+	}
+	if !args.AllowHighFee {
+		if err := account.checkFee(txProposal, feeRatePerKb); err != nil {
+			return nil, err
+		}
+	}
+
+	account.activeTxProposal = txProposal
+
+	childVSize := btcutil.Amount((maketx.EstimateWeight(txProposal.Transaction, utxos) + 3) / 4)
+	account.log.WithField("fee", txProposal.Fee).Debug("Returning child-pays-for-parent fee")
+	return &CPFPProposal{
+		Amount:              txProposal.Amount,
+		Fee:                 txProposal.Fee,
+		ParentFeeRatePerKb:  parentFee * 1000 / btcutil.Amount(parentVSize),
+		PackageFeeRatePerKb: (parentFee + txProposal.Fee) * 1000 / (btcutil.Amount(parentVSize) + childVSize),
+	}, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxFee(t *testing.T) {
+	prevTx := wire.NewMsgTx(wire.TxVersion)
+	prevTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	prevTx.AddTxOut(wire.NewTxOut(1000, nil))
+	prevTx.AddTxOut(wire.NewTxOut(50000, nil))
+	getPrevTx := func(hash chainhash.Hash) (*wire.MsgTx, error) {
+		require.Equal(t, prevTx.TxHash(), hash)
+		return prevTx, nil
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: prevTx.TxHash(), Index: 0}, nil, nil))
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: prevTx.TxHash(), Index: 1}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(30000, nil))
+	tx.AddTxOut(wire.NewTxOut(20000, nil))
+	fee, err := txFee(tx, getPrevTx)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(1000), fee)
+
+	tx.TxIn[0].PreviousOutPoint.Index = 2
+	_, err = txFee(tx, getPrevTx)
+	require.Error(t, err)
+}
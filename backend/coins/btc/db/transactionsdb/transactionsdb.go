@@ -236,6 +236,21 @@ func (tx *Tx) MarkTxVerified(txHash chainhash.Hash, headerTimestamp time.Time) e
 	})
 }
 
+// MarkTxUnverified implements transactions.DBTxInterface.
+func (tx *Tx) MarkTxUnverified(txHash chainhash.Hash) error {
+	bucketUnverifiedTransactions, err := tx.tx.CreateBucketIfNotExists([]byte(bucketUnverifiedTransactionsKey))
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if err := bucketUnverifiedTransactions.Put(txHash[:], nil); err != nil {
+		return errp.WithStack(err)
+	}
+	return tx.modifyTx(txHash[:], func(walletTx *transactions.DBTxInfo) {
+		walletTx.Verified = nil
+		walletTx.HeaderTimestamp = nil
+	})
+}
+
 // PutInput implements transactions.DBTxInterface.
 func (tx *Tx) PutInput(outPoint wire.OutPoint, txHash chainhash.Hash) error {
 	bucketInputs, err := tx.tx.CreateBucketIfNotExists([]byte(bucketInputsKey))
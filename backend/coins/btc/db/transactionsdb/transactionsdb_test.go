@@ -262,6 +262,16 @@ func TestTxQuick(t *testing.T) {
 				require.True(t,
 					!txInfo.CreatedTimestamp.After(now) || *txInfo.CreatedTimestamp == now)
 
+				require.NoError(t, tx.MarkTxUnverified(txHash))
+				allUnverifiedTxHashes[txHash] = struct{}{}
+				require.True(t, checkTxHashes())
+				txInfo, err = tx.TxInfo(txHash)
+				require.NoError(t, err)
+				require.Nil(t, txInfo.Verified)
+				require.Nil(t, txInfo.HeaderTimestamp)
+				require.NoError(t, tx.MarkTxVerified(txHash, expectedHeaderTimestamp))
+				delete(allUnverifiedTxHashes, txHash)
+
 				tx.DeleteTx(txHash)
 				delete(allTxHashes, txHash)
 				require.True(t, checkTxHashes())
@@ -0,0 +1,100 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package descriptor
+
+import (
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+const (
+	checksumInputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	checksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	checksumLength  = 8
+)
+
+var checksumGenerator = [5]uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd}
+
+func checksumPolymod(c uint64, value int) uint64 {
+	top := c >> 35
+	c = (c&0x7ffffffff)<<5 ^ uint64(value)
+	for i, generator := range checksumGenerator {
+		if (top>>i)&1 == 1 {
+			c ^= generator
+		}
+	}
+	return c
+}
+
+// Checksum computes the BIP380 checksum of a descriptor (without the '#' separator).
+func Checksum(descriptor string) (string, error) {
+	c := uint64(1)
+	class, classCount := 0, 0
+	for _, char := range descriptor {
+		position := strings.IndexRune(checksumInputCharset, char)
+		if position == -1 {
+			return "", errp.Newf("invalid character %q in descriptor", char)
+		}
+		// Emit a symbol for the position inside the group, for every character.
+		c = checksumPolymod(c, position&31)
+		// Accumulate the group numbers, and emit a symbol for every three characters.
+		class = class*3 + position>>5
+		classCount++
+		if classCount == 3 {
+			c = checksumPolymod(c, class)
+			class, classCount = 0, 0
+		}
+	}
+	if classCount > 0 {
+		c = checksumPolymod(c, class)
+	}
+	for i := 0; i < checksumLength; i++ {
+		c = checksumPolymod(c, 0)
+	}
+	c ^= 1
+	result := make([]byte, checksumLength)
+	for i := range result {
+		result[i] = checksumCharset[(c>>(5*(checksumLength-1-i)))&31]
+	}
+	return string(result), nil
+}
+
+// addChecksum appends the '#' separator and the checksum to the descriptor.
+func addChecksum(descriptor string) (string, error) {
+	checksum, err := Checksum(descriptor)
+	if err != nil {
+		return "", err
+	}
+	return descriptor + "#" + checksum, nil
+}
+
+// stripChecksum verifies and removes the checksum of a descriptor, if it has one.
+func stripChecksum(descriptor string) (string, error) {
+	index := strings.LastIndex(descriptor, "#")
+	if index == -1 {
+		return descriptor, nil
+	}
+	checksum, err := Checksum(descriptor[:index])
+	if err != nil {
+		return "", err
+	}
+	if checksum != descriptor[index+1:] {
+		return "", errp.WithStack(ErrInvalidChecksum)
+	}
+	return descriptor[:index], nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package descriptor converts between signing configurations and output script descriptors
+// (BIP380-BIP386), as used by Bitcoin Core and many other wallets.
+//
+// Supported are the descriptors of the app's account types: pkh(KEY), sh(wpkh(KEY)), wpkh(KEY),
+// tr(KEY) without script tree, and wsh(sortedmulti(k,KEY,...)). KEY must be an extended public key
+// with its key origin and the derivation /0/*, /1/* or /<0;1>/*, e.g.
+// [f23ab1c4/84'/0'/0']xpub.../0/*.
+package descriptor
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+const (
+	// ErrInvalidChecksum is returned if the checksum of a descriptor does not match.
+	ErrInvalidChecksum errp.ErrorCode = "invalidDescriptorChecksum"
+	// ErrUnsupported is returned for valid descriptors which can't be represented as an account,
+	// e.g. descriptors of single keys or with a taproot script tree.
+	ErrUnsupported errp.ErrorCode = "unsupportedDescriptor"
+)
+
+// Derivations of the keys of a descriptor. The app derives both the receive and the change
+// addresses, so it does not matter which of them is given.
+var keyDerivations = []string{"/0/*", "/1/*", "/<0;1>/*"}
+
+// Descriptors are the descriptors of the receive and change addresses of a signing configuration.
+type Descriptors struct {
+	Receive string `json:"receive"`
+	Change  string `json:"change"`
+}
+
+// Encode returns the descriptors of a Bitcoin signing configuration, including their checksums.
+func Encode(configuration *signing.Configuration) (*Descriptors, error) {
+	var descriptors [2]string
+	for change := range descriptors {
+		derivation := fmt.Sprintf("/%d/*", change)
+		var descriptor string
+		switch {
+		case configuration.BitcoinSimple != nil:
+			key := encodeKey(&configuration.BitcoinSimple.KeyInfo, derivation)
+			switch configuration.ScriptType() {
+			case signing.ScriptTypeP2PKH:
+				descriptor = "pkh(" + key + ")"
+			case signing.ScriptTypeP2WPKHP2SH:
+				descriptor = "sh(wpkh(" + key + "))"
+			case signing.ScriptTypeP2WPKH:
+				descriptor = "wpkh(" + key + ")"
+			case signing.ScriptTypeP2TR:
+				descriptor = "tr(" + key + ")"
+			default:
+				return nil, errp.Newf("unsupported script type %s", configuration.ScriptType())
+			}
+		case configuration.BitcoinMultisig != nil:
+			keys := make([]string, len(configuration.BitcoinMultisig.KeyInfos))
+			for i := range configuration.BitcoinMultisig.KeyInfos {
+				keys[i] = encodeKey(&configuration.BitcoinMultisig.KeyInfos[i], derivation)
+			}
+			descriptor = fmt.Sprintf("wsh(sortedmulti(%d,%s))",
+				configuration.BitcoinMultisig.Threshold, strings.Join(keys, ","))
+		default:
+			return nil, errp.New("descriptors are only supported for Bitcoin configurations")
+		}
+		descriptor, err := addChecksum(descriptor)
+		if err != nil {
+			return nil, err
+		}
+		descriptors[change] = descriptor
+	}
+	return &Descriptors{Receive: descriptors[0], Change: descriptors[1]}, nil
+}
+
+func encodeKey(keyInfo *signing.KeyInfo, derivation string) string {
+	origin := hex.EncodeToString(keyInfo.RootFingerprint)
+	if keypath := strings.TrimPrefix(keyInfo.AbsoluteKeypath.Encode(), "m/"); keypath != "" {
+		origin += "/" + keypath
+	}
+	return "[" + origin + "]" + keyInfo.ExtendedPublicKey.String() + derivation
+}
+
+// Parse parses a descriptor into a signing configuration. The checksum is optional, but verified if
+// present. The keys must belong to the given network. For multisig descriptors, the first key is
+// assumed to be the key of the keystore.
+func Parse(descriptor string, net *chaincfg.Params) (*signing.Configuration, error) {
+	descriptor, err := stripChecksum(strings.TrimSpace(descriptor))
+	if err != nil {
+		return nil, err
+	}
+	parseSingle := func(scriptType signing.ScriptType, prefix, suffix string) (*signing.Configuration, error) {
+		keyInfo, err := parseKey(descriptor[len(prefix):len(descriptor)-len(suffix)], net)
+		if err != nil {
+			return nil, err
+		}
+		return signing.NewBitcoinConfiguration(
+			scriptType, keyInfo.RootFingerprint, keyInfo.AbsoluteKeypath, keyInfo.ExtendedPublicKey), nil
+	}
+	hasAffixes := func(prefix, suffix string) bool {
+		return len(descriptor) >= len(prefix)+len(suffix) &&
+			strings.HasPrefix(descriptor, prefix) && strings.HasSuffix(descriptor, suffix)
+	}
+	switch {
+	case hasAffixes("pkh(", ")"):
+		return parseSingle(signing.ScriptTypeP2PKH, "pkh(", ")")
+	case hasAffixes("sh(wpkh(", "))"):
+		return parseSingle(signing.ScriptTypeP2WPKHP2SH, "sh(wpkh(", "))")
+	case hasAffixes("wpkh(", ")"):
+		return parseSingle(signing.ScriptTypeP2WPKH, "wpkh(", ")")
+	case hasAffixes("tr(", ")"):
+		if strings.Contains(descriptor, ",") {
+			// Script trees are not supported.
+			return nil, errp.WithStack(ErrUnsupported)
+		}
+		return parseSingle(signing.ScriptTypeP2TR, "tr(", ")")
+	case hasAffixes("wsh(sortedmulti(", "))"):
+		return parseMultisig(descriptor[len("wsh(sortedmulti("):len(descriptor)-len("))")], net)
+	default:
+		// This includes wsh(multi(...)), as the addresses of multisig accounts are derived with
+		// sorted keys (BIP67).
+		return nil, errp.WithStack(ErrUnsupported)
+	}
+}
+
+func parseMultisig(arguments string, net *chaincfg.Params) (*signing.Configuration, error) {
+	parts := strings.Split(arguments, ",")
+	if len(parts) < 2 {
+		return nil, errp.New("missing multisig keys")
+	}
+	threshold, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, errp.Newf("invalid multisig threshold: %s", parts[0])
+	}
+	keyInfos := make([]signing.KeyInfo, len(parts)-1)
+	for i, part := range parts[1:] {
+		keyInfo, err := parseKey(part, net)
+		if err != nil {
+			return nil, err
+		}
+		keyInfos[i] = *keyInfo
+	}
+	return signing.NewBitcoinMultisigConfiguration(threshold, keyInfos, 0)
+}
+
+// parseKey parses an extended public key with key origin and derivation, e.g.
+// [f23ab1c4/84'/0'/0']xpub.../0/*.
+func parseKey(key string, net *chaincfg.Params) (*signing.KeyInfo, error) {
+	for _, derivation := range keyDerivations {
+		if strings.HasSuffix(key, derivation) {
+			return ParseKey(strings.TrimSuffix(key, derivation), net)
+		}
+	}
+	return nil, errp.WithStack(ErrUnsupported)
+}
+
+// ParseKey parses an extended public key with key origin, e.g. [f23ab1c4/48'/0'/0'/2']xpub..., as
+// exported for the cosigners of multisig accounts. The key must belong to the given network.
+func ParseKey(key string, net *chaincfg.Params) (*signing.KeyInfo, error) {
+	key = strings.TrimSpace(key)
+	if !strings.HasPrefix(key, "[") {
+		return nil, errp.Newf("the key %s has no key origin", key)
+	}
+	originEnd := strings.Index(key, "]")
+	if originEnd == -1 {
+		return nil, errp.Newf("invalid key origin: %s", key)
+	}
+	origin, xpub := key[1:originEnd], key[originEnd+1:]
+	fingerprint, keypathString, _ := strings.Cut(origin, "/")
+	rootFingerprint, err := hex.DecodeString(fingerprint)
+	if err != nil || len(rootFingerprint) != 4 {
+		return nil, errp.Newf("invalid root fingerprint: %s", fingerprint)
+	}
+	keypath, err := signing.NewAbsoluteKeypath(
+		"m/" + strings.NewReplacer("h", "'", "H", "'").Replace(keypathString))
+	if err != nil {
+		return nil, err
+	}
+	extendedPublicKey, err := hdkeychain.NewKeyFromString(xpub)
+	if err != nil {
+		return nil, errp.WithMessage(err, "invalid extended public key")
+	}
+	if extendedPublicKey.IsPrivate() {
+		return nil, errp.New("the key is a private key")
+	}
+	if !extendedPublicKey.IsForNet(net) {
+		return nil, errp.Newf("the extended public key is not for %s", net.Name)
+	}
+	elements := keypath.ToUInt32()
+	if int(extendedPublicKey.Depth()) != len(elements) ||
+		(len(elements) > 0 && extendedPublicKey.ChildIndex() != elements[len(elements)-1]) {
+		return nil, errp.Newf("the extended public key does not match the key origin %s", origin)
+	}
+	return &signing.KeyInfo{
+		RootFingerprint:   rootFingerprint,
+		AbsoluteKeypath:   keypath,
+		ExtendedPublicKey: extendedPublicKey,
+	}, nil
+}
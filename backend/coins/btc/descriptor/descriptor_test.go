@@ -0,0 +1,173 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+// accountKeyInfo derives the account key at the keypath from a root key built from the seed byte.
+func accountKeyInfo(t *testing.T, seed byte, keypath string) signing.KeyInfo {
+	t.Helper()
+	root, err := hdkeychain.NewMaster(append(make([]byte, 31), seed), &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	absoluteKeypath, err := signing.NewAbsoluteKeypath(keypath)
+	require.NoError(t, err)
+	accountKey, err := absoluteKeypath.Derive(root)
+	require.NoError(t, err)
+	accountKey, err = accountKey.Neuter()
+	require.NoError(t, err)
+	return signing.KeyInfo{
+		RootFingerprint:   []byte{0xf2, 0x3a, 0xb1, seed},
+		AbsoluteKeypath:   absoluteKeypath,
+		ExtendedPublicKey: accountKey,
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	// Test vectors of BIP380.
+	checksum, err := Checksum("raw(deadbeef)")
+	require.NoError(t, err)
+	require.Equal(t, "89f8spxm", checksum)
+	checksum, err = Checksum("pkh([d34db33f/44'/0'/0']xpub6ERApfZwUNrhLCkDtcHTcxd75RbzS1ed54G1LkBUHQVHQKqhMkhgbmJbZRkrgZw4koxb5JaHWkY4ALHY2grBGRjaDMzQLcgJvLJuZZvRcEL/1/*)")
+	require.NoError(t, err)
+	require.Equal(t, "ml40v0wf", checksum)
+
+	_, err = Checksum("raw(deadbeef)\n")
+	require.Error(t, err)
+}
+
+func TestEncodeParse(t *testing.T) {
+	for _, test := range []struct {
+		scriptType signing.ScriptType
+		keypath    string
+		prefix     string
+	}{
+		{signing.ScriptTypeP2PKH, "m/44'/1'/0'", "pkh("},
+		{signing.ScriptTypeP2WPKHP2SH, "m/49'/1'/1'", "sh(wpkh("},
+		{signing.ScriptTypeP2WPKH, "m/84'/1'/0'", "wpkh("},
+		{signing.ScriptTypeP2TR, "m/86'/1'/0'", "tr("},
+	} {
+		keyInfo := accountKeyInfo(t, 1, test.keypath)
+		configuration := signing.NewBitcoinConfiguration(
+			test.scriptType, keyInfo.RootFingerprint, keyInfo.AbsoluteKeypath, keyInfo.ExtendedPublicKey)
+		descriptors, err := Encode(configuration)
+		require.NoError(t, err)
+		key := fmt.Sprintf("[f23ab101%s]%s",
+			strings.TrimPrefix(test.keypath, "m"), keyInfo.ExtendedPublicKey.String())
+		require.True(t, strings.HasPrefix(descriptors.Receive, test.prefix+key+"/0/*)"), descriptors.Receive)
+		require.True(t, strings.HasPrefix(descriptors.Change, test.prefix+key+"/1/*)"), descriptors.Change)
+
+		for _, descriptor := range []string{descriptors.Receive, descriptors.Change} {
+			parsed, err := Parse(descriptor, &chaincfg.TestNet3Params)
+			require.NoError(t, err)
+			require.Equal(t, configuration.String(), parsed.String())
+			require.Equal(t, keyInfo.ExtendedPublicKey.String(), parsed.ExtendedPublicKey().String())
+
+			// Wrong network.
+			_, err = Parse(descriptor, &chaincfg.MainNetParams)
+			require.Error(t, err)
+		}
+	}
+}
+
+func TestParseMultisig(t *testing.T) {
+	keyInfos := []signing.KeyInfo{
+		accountKeyInfo(t, 1, "m/48'/1'/0'/2'"),
+		accountKeyInfo(t, 2, "m/48'/1'/0'/2'"),
+		accountKeyInfo(t, 3, "m/48'/1'/0'/2'"),
+	}
+	configuration, err := signing.NewBitcoinMultisigConfiguration(2, keyInfos, 0)
+	require.NoError(t, err)
+	descriptors, err := Encode(configuration)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(descriptors.Receive, "wsh(sortedmulti(2,[f23ab101/48'/1'/0'/2']"))
+
+	parsed, err := Parse(descriptors.Receive, &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	require.Equal(t, signing.ScriptTypeP2WSH, parsed.ScriptType())
+	require.Equal(t, configuration.String(), parsed.String())
+	require.Len(t, parsed.BitcoinMultisig.KeyInfos, 3)
+	reencoded, err := Encode(parsed)
+	require.NoError(t, err)
+	require.Equal(t, descriptors, reencoded)
+}
+
+func TestParseKey(t *testing.T) {
+	keyInfo := accountKeyInfo(t, 1, "m/48'/1'/0'/2'")
+	key := "[f23ab101/48h/1h/0h/2h]" + keyInfo.ExtendedPublicKey.String()
+	parsed, err := ParseKey(" "+key+"\n", &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	require.Equal(t, keyInfo.String(), parsed.String())
+
+	_, err = ParseKey(key+"/0/*", &chaincfg.TestNet3Params)
+	require.Error(t, err)
+	_, err = ParseKey(keyInfo.ExtendedPublicKey.String(), &chaincfg.TestNet3Params)
+	require.Error(t, err)
+	_, err = ParseKey(key, &chaincfg.MainNetParams)
+	require.Error(t, err)
+	_, err = ParseKey("[f23ab101/48h/1h/0h]"+keyInfo.ExtendedPublicKey.String(), &chaincfg.TestNet3Params)
+	require.Error(t, err)
+}
+
+func TestParseFormats(t *testing.T) {
+	keyInfo := accountKeyInfo(t, 1, "m/84'/1'/0'")
+	tpub := keyInfo.ExtendedPublicKey.String()
+
+	for _, descriptor := range []string{
+		// No checksum.
+		fmt.Sprintf("wpkh([f23ab101/84'/1'/0']%s/0/*)", tpub),
+		// h instead of ', multipath derivation.
+		fmt.Sprintf("wpkh([f23ab101/84h/1h/0h]%s/<0;1>/*)", tpub),
+	} {
+		parsed, err := Parse(descriptor, &chaincfg.TestNet3Params)
+		require.NoError(t, err)
+		require.Equal(t, signing.ScriptTypeP2WPKH, parsed.ScriptType())
+		require.Equal(t, "m/84'/1'/0'", parsed.AbsoluteKeypath().Encode())
+	}
+
+	valid, err := addChecksum(fmt.Sprintf("wpkh([f23ab101/84'/1'/0']%s/0/*)", tpub))
+	require.NoError(t, err)
+	_, err = Parse(valid[:len(valid)-1]+"q", &chaincfg.TestNet3Params)
+	require.Equal(t, ErrInvalidChecksum, errp.Cause(err))
+
+	for _, descriptor := range []string{
+		// Unsorted multisig.
+		fmt.Sprintf("wsh(multi(1,[f23ab101/84'/1'/0']%s/0/*))", tpub),
+		// Script tree.
+		fmt.Sprintf("tr([f23ab101/84'/1'/0']%s/0/*,pk(%s/1/*))", tpub, tpub),
+		// Single derived key.
+		fmt.Sprintf("wpkh([f23ab101/84'/1'/0']%s/0/5)", tpub),
+		"wpkh(02f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9)",
+		"addr(tb1qxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx)",
+	} {
+		_, err := Parse(descriptor, &chaincfg.TestNet3Params)
+		require.Error(t, err, descriptor)
+	}
+
+	// Key origin does not match the key.
+	_, err = Parse(fmt.Sprintf("wpkh([f23ab101/84'/1'/1']%s/0/*)", tpub), &chaincfg.TestNet3Params)
+	require.Error(t, err)
+	_, err = Parse(fmt.Sprintf("wpkh([f23ab101]%s/0/*)", tpub), &chaincfg.TestNet3Params)
+	require.Error(t, err)
+}
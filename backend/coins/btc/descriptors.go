@@ -0,0 +1,45 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/descriptor"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+)
+
+// AccountDescriptors are the output descriptors of one signing configuration of the account.
+type AccountDescriptors struct {
+	ScriptType signing.ScriptType `json:"scriptType"`
+	descriptor.Descriptors
+}
+
+// Descriptors returns the output descriptors of the account, one entry per signing configuration.
+// They can be imported into other wallets, e.g. into Bitcoin Core using `importdescriptors`, to
+// watch the account.
+func (account *Account) Descriptors() ([]AccountDescriptors, error) {
+	signingConfigurations := account.Config().Config.SigningConfigurations
+	result := make([]AccountDescriptors, len(signingConfigurations))
+	for i, signingConfiguration := range signingConfigurations {
+		descriptors, err := descriptor.Encode(signingConfiguration)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = AccountDescriptors{
+			ScriptType:  signingConfiguration.ScriptType(),
+			Descriptors: *descriptors,
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package electrum
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/BitBoxSwiss/bitbox02-api-go/util/semver"
+	"github.com/stretchr/testify/require"
+)
+
+// serveFakeElectrum answers the requests made by BenchmarkServer() on the connection.
+func serveFakeElectrum(conn net.Conn, tipHeight int) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var request struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			return
+		}
+		var result interface{}
+		switch request.Method {
+		case "server.version":
+			result = []string{"ElectrumX 1.16.0", "1.4"}
+		case "blockchain.relayfee":
+			result = 0.00001
+		case "blockchain.headers.subscribe":
+			result = map[string]interface{}{"height": tipHeight, "hex": ""}
+		default:
+			result = nil
+		}
+		response, err := json.Marshal(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      request.ID,
+			"result":  result,
+		})
+		if err != nil {
+			return
+		}
+		if _, err := conn.Write(append(response, '\n')); err != nil {
+			return
+		}
+	}
+}
+
+func TestBenchmarkServer(t *testing.T) {
+	SetClientSoftwareVersion(semver.NewSemVer(1, 0, 0))
+	serverInfo := &config.ServerInfo{Server: "electrum.example.org:50001", TLS: false}
+	dialer := &test.Dialer{DialFn: func(network, addr string) (net.Conn, error) {
+		require.Equal(t, serverInfo.Server, addr)
+		client, server := net.Pipe()
+		go serveFakeElectrum(server, 840000)
+		return client, nil
+	}}
+	result, err := BenchmarkServer(serverInfo, dialer)
+	require.NoError(t, err)
+	require.Equal(t, 840000, result.TipHeight)
+	require.Positive(t, result.Connect)
+	require.Positive(t, result.Ping)
+
+	unreachable := &test.Dialer{DialFn: func(network, addr string) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}}
+	_, err = BenchmarkServer(serverInfo, unreachable)
+	require.Error(t, err)
+}
+
+func TestPreferredServerIndex(t *testing.T) {
+	require.Equal(t, -1, preferredServerIndex(nil))
+	servers := []*config.ServerInfo{{Server: "a"}, {Server: "b"}}
+	require.Equal(t, -1, preferredServerIndex(servers))
+	servers[1].Preferred = true
+	require.Equal(t, 1, preferredServerIndex(servers))
+}
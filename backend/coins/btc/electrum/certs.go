@@ -0,0 +1,148 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package electrum
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"golang.org/x/net/proxy"
+)
+
+// CertificateInfo describes a certificate for display.
+type CertificateInfo struct {
+	Subject string `json:"subject"`
+	Issuer  string `json:"issuer"`
+	// Fingerprint is the hex encoded SHA256 hash of the DER encoded certificate.
+	Fingerprint string    `json:"fingerprint"`
+	NotBefore   time.Time `json:"notBefore"`
+	NotAfter    time.Time `json:"notAfter"`
+	// IsCA is true if the certificate is a CA certificate, e.g. the root CA of the Shift servers,
+	// which covers all certificates it issued.
+	IsCA bool `json:"isCA"`
+}
+
+// Expired returns true if the certificate is not valid anymore at the given time.
+func (info *CertificateInfo) Expired(now time.Time) bool {
+	return now.After(info.NotAfter)
+}
+
+func newCertificateInfo(cert *x509.Certificate) *CertificateInfo {
+	fingerprint := sha256.Sum256(cert.Raw)
+	return &CertificateInfo{
+		Subject:     cert.Subject.String(),
+		Issuer:      cert.Issuer.String(),
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		NotBefore:   cert.NotBefore,
+		NotAfter:    cert.NotAfter,
+		IsCA:        cert.IsCA,
+	}
+}
+
+// ParseCertificate parses the first certificate of a PEM encoded certificate, as stored in
+// `config.ServerInfo.PEMCert`.
+func ParseCertificate(pemCert string) (*CertificateInfo, error) {
+	block, _ := pem.Decode([]byte(pemCert))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errp.New("no PEM encoded certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return newCertificateInfo(cert), nil
+}
+
+func encodeCertificatePEM(cert *x509.Certificate) string {
+	certificatePEMBytes := &bytes.Buffer{}
+	if err := pem.Encode(certificatePEMBytes, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}); err != nil {
+		panic(err)
+	}
+	return certificatePEMBytes.String()
+}
+
+func newCertPool(rootCert string) (*x509.CertPool, error) {
+	caCertPool := x509.NewCertPool()
+	if ok := caCertPool.AppendCertsFromPEM([]byte(rootCert)); !ok {
+		return nil, errp.New("Failed to append CA cert as trusted cert")
+	}
+	return caCertPool, nil
+}
+
+// verifyCertificateChain checks that the certificate chain presented by a server is signed by a
+// certificate of the pool of pinned certificates. The hostname is not verified, as the pinned
+// certificate is usually self-signed.
+func verifyCertificateChain(certs []*x509.Certificate, caCertPool *x509.CertPool) error {
+	if len(certs) == 0 {
+		return errp.New("no remote certs")
+	}
+	opts := x509.VerifyOptions{
+		Roots:         caCertPool,
+		CurrentTime:   time.Now(),
+		DNSName:       "", // <- skip hostname verification
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(opts)
+	return err
+}
+
+// CertificateCheck is the result of comparing the certificate a server currently presents with the
+// pinned certificate.
+type CertificateCheck struct {
+	// Remote is the first certificate of the chain presented by the server.
+	Remote *CertificateInfo `json:"remote"`
+	// Matches is true if the server would be accepted with the pinned certificate.
+	Matches bool `json:"matches"`
+	// VerifyError explains why the server would be rejected if Matches is false.
+	VerifyError string `json:"verifyError,omitempty"`
+
+	remotePEM string
+}
+
+// RemotePEM returns the PEM encoded remote certificate, to be pinned if the user approves it.
+func (check *CertificateCheck) RemotePEM() string {
+	return check.remotePEM
+}
+
+// CheckCertificate downloads the certificate chain of the server and verifies it against the
+// pinned certificate.
+func CheckCertificate(server string, pemCert string, dialer proxy.Dialer) (*CertificateCheck, error) {
+	certs, err := downloadCertChain(server, dialer)
+	if err != nil {
+		return nil, err
+	}
+	check := &CertificateCheck{
+		Remote:    newCertificateInfo(certs[0]),
+		Matches:   true,
+		remotePEM: encodeCertificatePEM(certs[0]),
+	}
+	caCertPool, err := newCertPool(pemCert)
+	if err == nil {
+		err = verifyCertificateChain(certs, caCertPool)
+	}
+	if err != nil {
+		check.Matches = false
+		check.VerifyError = err.Error()
+	}
+	return check, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package electrum
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/require"
+)
+
+// O=Shift Crypto, CN=ShiftCrypto R1.
+const otherCert = `-----BEGIN CERTIFICATE-----
+MIIBgjCCASigAwIBAgIRANNQEcOCloIRzU4p/vwUSJEwCgYIKoZIzj0EAwIwMDEV
+MBMGA1UEChMMU2hpZnQgQ3J5cHRvMRcwFQYDVQQDEw5TaGlmdENyeXB0byBSMTAe
+Fw0yMDEyMDgyMzU5MzZaFw0zMDEyMDYyMzU5MzZaMDAxFTATBgNVBAoTDFNoaWZ0
+IENyeXB0bzEXMBUGA1UEAxMOU2hpZnRDcnlwdG8gUjEwWTATBgcqhkjOPQIBBggq
+hkjOPQMBBwNCAARr5MqlIwZF1Vm4Ng5Smlb4ZuQ1wIwCVxl9zcX5kgMmaJFlTPpk
+8jj3KE4+DXkxixuHarJgdamGP/SYawG69HyMoyMwITAOBgNVHQ8BAf8EBAMCAoQw
+DwYDVR0TAQH/BAUwAwEB/zAKBggqhkjOPQQDAgNIADBFAiEApDfR07EgYNtXuXM1
+YFnRE7QtJtiu97fYZk3Qu8W3/TECIAtCHAzvA2AX/eX4fNTjUho/9y1qF9GAsnNN
+hbWFoWMI
+-----END CERTIFICATE-----
+`
+
+func TestParseCertificate(t *testing.T) {
+	info, err := ParseCertificate(otherCert)
+	require.NoError(t, err)
+	require.Equal(t, "CN=ShiftCrypto R1,O=Shift Crypto", info.Subject)
+	require.Equal(t, info.Subject, info.Issuer)
+	require.True(t, info.IsCA)
+	require.Len(t, info.Fingerprint, 64)
+	require.Equal(t, 2030, info.NotAfter.Year())
+	require.False(t, info.Expired(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	require.True(t, info.Expired(time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	_, err = ParseCertificate("")
+	require.Error(t, err)
+	_, err = ParseCertificate("-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n")
+	require.Error(t, err)
+}
+
+func TestCheckCertificate(t *testing.T) {
+	fakeNode := &test.TCPServer{}
+	fakeNode.StartTLS(func(conn net.Conn) {
+		_, _ = io.Copy(io.Discard, conn)
+		conn.Close()
+	})
+	defer fakeNode.Close()
+
+	pinned, err := ParseCertificate(test.TCPServerCertPub)
+	require.NoError(t, err)
+
+	check, err := CheckCertificate("localhost:123", test.TCPServerCertPub, fakeNode.Dialer())
+	require.NoError(t, err)
+	require.True(t, check.Matches)
+	require.Empty(t, check.VerifyError)
+	require.Equal(t, pinned, check.Remote)
+	remote, err := ParseCertificate(check.RemotePEM())
+	require.NoError(t, err)
+	require.Equal(t, pinned, remote)
+
+	// The server rotated its certificate.
+	check, err = CheckCertificate("localhost:123", otherCert, fakeNode.Dialer())
+	require.NoError(t, err)
+	require.False(t, check.Matches)
+	require.NotEmpty(t, check.VerifyError)
+	require.Equal(t, pinned, check.Remote)
+}
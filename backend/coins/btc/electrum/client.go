@@ -107,11 +107,14 @@ func (c *client) ScriptHashGetHistory(scriptHashHex blockchain.ScriptHashHex) (
 	return history, nil
 }
 
+// ScriptHashSubscribe subscribes to the script hash. Cancelling ctx aborts a pending subscription
+// call.
 func (c *client) ScriptHashSubscribe(
+	ctx context.Context,
 	scriptHashHex blockchain.ScriptHashHex,
 	success func(string, error),
 ) {
-	c.client.ScriptHashSubscribe(context.Background(), string(scriptHashHex), success)
+	c.client.ScriptHashSubscribe(ctx, string(scriptHashHex), success)
 }
 
 func (c *client) TransactionBroadcast(transaction *wire.MsgTx) error {
@@ -15,13 +15,13 @@
 package electrum
 
 import (
-	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/pem"
 	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
@@ -29,6 +29,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox02-api-go/util/semver"
 	"github.com/BitBoxSwiss/block-client-go/electrum"
+	"github.com/BitBoxSwiss/block-client-go/electrum/types"
 	"github.com/BitBoxSwiss/block-client-go/failover"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/proxy"
@@ -77,9 +78,9 @@ func newTLSConnection(address string, rootCert string, dialer proxy.Dialer) (*tl
 		return nil, errp.WithMessage(err, fmt.Sprintf("Invalid server address %q", address))
 	}
 
-	caCertPool := x509.NewCertPool()
-	if ok := caCertPool.AppendCertsFromPEM([]byte(rootCert)); !ok {
-		return nil, errp.New("Failed to append CA cert as trusted cert")
+	caCertPool, err := newCertPool(rootCert)
+	if err != nil {
+		return nil, err
 	}
 	conn, err := dialer.Dial("tcp", address)
 	if err != nil {
@@ -98,30 +99,14 @@ func newTLSConnection(address string, rootCert string, dialer proxy.Dialer) (*tl
 
 			// If this is the first handshake on a connection, process and
 			// (optionally) verify the server's certificates.
-			certs := make([]*x509.Certificate, len(rawCerts))
-			for i, asn1Data := range rawCerts {
-				cert, err := x509.ParseCertificate(asn1Data)
-				if err != nil {
-					return errp.New("bitbox/electrum: failed to parse certificate from server: " + err.Error())
-				}
-				certs[i] = cert
-			}
-
-			opts := x509.VerifyOptions{
-				Roots:         caCertPool,
-				CurrentTime:   time.Now(),
-				DNSName:       "", // <- skip hostname verification
-				Intermediates: x509.NewCertPool(),
+			certs, err := parseCertificates(rawCerts)
+			if err != nil {
+				return err
 			}
-
-			for i, cert := range certs {
-				if i == 0 {
-					continue
-				}
-				opts.Intermediates.AddCert(cert)
+			if err := verifyCertificateChain(certs, caCertPool); err != nil {
+				return errp.WithMessage(err, "the server certificate does not match the pinned certificate")
 			}
-			_, err := certs[0].Verify(opts)
-			return err
+			return nil
 		},
 	})
 	return tlsConn, nil
@@ -135,6 +120,17 @@ func newTCPConnection(address string, dialer proxy.Dialer) (net.Conn, error) {
 	return conn, nil
 }
 
+// preferredServerIndex returns the index of the first preferred server, or -1 if no server is
+// preferred, in which case the failover client starts with a random server.
+func preferredServerIndex(serverInfos []*config.ServerInfo) int {
+	for i, serverInfo := range serverInfos {
+		if serverInfo.Preferred {
+			return i
+		}
+	}
+	return -1
+}
+
 // NewElectrumConnection connects to an Electrum server and returns a ElectrumClient instance to
 // communicate with it.
 func NewElectrumConnection(serverInfos []*config.ServerInfo, log *logrus.Entry, dialer proxy.Dialer) blockchain.Interface {
@@ -150,6 +146,9 @@ func NewElectrumConnection(serverInfos []*config.ServerInfo, log *logrus.Entry,
 
 	servers := []*failover.Server[*client]{}
 	retryTimeout := 30 * time.Second
+	// latencies are the connect durations by server name, see blockchain.ConnectionInfo.
+	latencies := map[string]time.Duration{}
+	var latenciesMu sync.Mutex
 
 	for _, serverInfo := range serverInfos {
 		serverInfo := serverInfo
@@ -158,6 +157,7 @@ func NewElectrumConnection(serverInfos []*config.ServerInfo, log *logrus.Entry,
 			Connect: func() (*client, error) {
 				log := log.WithField("server", serverInfo.String())
 				log.Info("Trying to connect to backend")
+				started := time.Now()
 				c, err := electrum.Connect(&electrum.Options{
 					SoftwareVersion: softwareVersion,
 					// Slightly less than PingInterval according to the `electrum.Options` docs - a
@@ -172,6 +172,9 @@ func NewElectrumConnection(serverInfos []*config.ServerInfo, log *logrus.Entry,
 					log.WithError(err).Error("Failover: backend is down")
 					return nil, err
 				}
+				latenciesMu.Lock()
+				latencies[serverInfo.Server] = time.Since(started)
+				latenciesMu.Unlock()
 				log.
 					WithField("server-version", c.ServerVersion().String()).
 					Infof("Successfully connected to backend %s", serverInfo.Server)
@@ -179,14 +182,24 @@ func NewElectrumConnection(serverInfos []*config.ServerInfo, log *logrus.Entry,
 			},
 		})
 	}
+	var startIndex func() int
+	if index := preferredServerIndex(serverInfos); index >= 0 {
+		startIndex = func() int { return index }
+	}
 	var fclient *failoverClient
 	fclient = newFailoverClient(&failover.Options[*client]{
 		Servers:      servers,
+		StartIndex:   startIndex,
 		RetryTimeout: retryTimeout,
 		OnConnect: func(server *failover.Server[*client]) {
 			fclient.setConnectionError(nil)
+			latenciesMu.Lock()
+			latency := latencies[server.Name]
+			latenciesMu.Unlock()
+			fclient.setConnectionInfo(blockchain.ConnectionInfo{Server: server.Name, Latency: latency})
 		},
 		OnDisconnect: func(server *failover.Server[*client], err error) {
+			fclient.clearConnectionInfo(server.Name)
 			log.
 				WithError(err).
 				WithField("server", server.String()).
@@ -205,19 +218,31 @@ func NewElectrumConnection(serverInfos []*config.ServerInfo, log *logrus.Entry,
 	return fclient
 }
 
-// DownloadCert downloads the first element of the remote certificate chain.
-func DownloadCert(server string, dialer proxy.Dialer) (string, error) {
+func parseCertificates(rawCerts [][]byte) ([]*x509.Certificate, error) {
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, asn1Data := range rawCerts {
+		cert, err := x509.ParseCertificate(asn1Data)
+		if err != nil {
+			return nil, errp.New("bitbox/electrum: failed to parse certificate from server: " + err.Error())
+		}
+		certs[i] = cert
+	}
+	return certs, nil
+}
+
+// downloadCertChain downloads the remote certificate chain without verifying it.
+func downloadCertChain(server string, dialer proxy.Dialer) ([]*x509.Certificate, error) {
 	// hostname is used as server name in SNI client hello during the handshake.
 	// It is set to empty string by tls.Client if address is an IP address.
 	hostname, _, err := net.SplitHostPort(server)
 	if err != nil {
-		return "", errp.WithMessage(err, fmt.Sprintf("Invalid server address %q", server))
+		return nil, errp.WithMessage(err, fmt.Sprintf("Invalid server address %q", server))
 	}
 
-	var pemCert []byte
+	var certs []*x509.Certificate
 	conn, err := dialer.Dial("tcp", server)
 	if err != nil {
-		return "", errp.WithStack(err)
+		return nil, errp.WithStack(err)
 	}
 
 	tlsConn := tls.Client(conn, &tls.Config{
@@ -229,22 +254,26 @@ func DownloadCert(server string, dialer proxy.Dialer) (string, error) {
 			if len(rawCerts) == 0 {
 				return errp.New("no remote certs")
 			}
-
-			certificatePEM := &pem.Block{Type: "CERTIFICATE", Bytes: rawCerts[0]}
-			certificatePEMBytes := &bytes.Buffer{}
-			if err := pem.Encode(certificatePEMBytes, certificatePEM); err != nil {
-				panic(err)
-			}
-			pemCert = certificatePEMBytes.Bytes()
-			return nil
+			var err error
+			certs, err = parseCertificates(rawCerts)
+			return err
 		},
 	})
 	err = tlsConn.Handshake()
 	if err != nil {
-		return "", errp.WithStack(err)
+		return nil, errp.WithStack(err)
 	}
 	_ = tlsConn.Close()
-	return string(pemCert), nil
+	return certs, nil
+}
+
+// DownloadCert downloads the first element of the remote certificate chain.
+func DownloadCert(server string, dialer proxy.Dialer) (string, error) {
+	certs, err := downloadCertChain(server, dialer)
+	if err != nil {
+		return "", err
+	}
+	return encodeCertificatePEM(certs[0]), nil
 }
 
 // CheckElectrumServer checks if a tls connection can be established with the electrum server, and
@@ -264,3 +293,60 @@ func CheckElectrumServer(serverInfo *config.ServerInfo, log *logrus.Entry, diale
 	client.Close()
 	return nil
 }
+
+// benchmarkTimeout bounds each request made by BenchmarkServer().
+const benchmarkTimeout = 10 * time.Second
+
+// ServerBenchmark is the result of BenchmarkServer().
+type ServerBenchmark struct {
+	// Connect is the time it took to connect, including the TLS handshake and the protocol
+	// negotiation.
+	Connect time.Duration
+	// Ping is the round trip time of a cheap request (blockchain.relayfee).
+	Ping time.Duration
+	// TipHeight is the height of the tip of the server.
+	TipHeight int
+}
+
+// BenchmarkServer connects to the Electrum server and measures how fast it responds.
+func BenchmarkServer(serverInfo *config.ServerInfo, dialer proxy.Dialer) (*ServerBenchmark, error) {
+	started := time.Now()
+	client, err := electrum.Connect(&electrum.Options{
+		SoftwareVersion: softwareVersion,
+		MethodTimeout:   benchmarkTimeout,
+		PingInterval:    -1,
+		Dial: func() (net.Conn, error) {
+			return establishConnection(serverInfo, dialer)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	result := &ServerBenchmark{Connect: time.Since(started)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), benchmarkTimeout)
+	defer cancel()
+	started = time.Now()
+	if _, err := client.RelayFee(ctx); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	result.Ping = time.Since(started)
+
+	tipHeight := make(chan int, 1)
+	client.HeadersSubscribe(ctx, func(header *types.Header, err error) {
+		if err != nil {
+			return
+		}
+		select {
+		case tipHeight <- header.Height:
+		default:
+		}
+	})
+	select {
+	case result.TipHeight = <-tipHeight:
+	case <-ctx.Done():
+		return nil, errp.New("timeout waiting for the tip of the server")
+	}
+	return result, nil
+}
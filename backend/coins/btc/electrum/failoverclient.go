@@ -15,6 +15,7 @@
 package electrum
 
 import (
+	"context"
 	"sync"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
@@ -33,8 +34,19 @@ type failoverClient struct {
 
 	connectionError                   error
 	onConnectionErrorChangedCallbacks []func(error)
-	// covers connectionError and onConnectionErrorChangedCallbacks.
+	connectionInfo                    blockchain.ConnectionInfo
+	// covers connectionError, onConnectionErrorChangedCallbacks and connectionInfo.
 	mu sync.RWMutex
+
+	scriptHashSubscriptions   map[blockchain.ScriptHashHex][]*scriptHashSubscription
+	scriptHashSubscriptionsMu sync.Mutex
+}
+
+// scriptHashSubscription is a subscription made with ScriptHashSubscribe. Its context is cancelled
+// when unsubscribing.
+type scriptHashSubscription struct {
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // newFailoverClient creates a new failover client.
@@ -42,6 +54,7 @@ func newFailoverClient(opts *failover.Options[*client]) *failoverClient {
 	return &failoverClient{
 		failover:                          failover.New[*client](opts),
 		onConnectionErrorChangedCallbacks: []func(error){},
+		scriptHashSubscriptions:           map[blockchain.ScriptHashHex][]*scriptHashSubscription{},
 	}
 }
 
@@ -62,6 +75,28 @@ func (f *failoverClient) ConnectionError() error {
 	return f.connectionError
 }
 
+func (f *failoverClient) setConnectionInfo(info blockchain.ConnectionInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.connectionInfo = info
+}
+
+// clearConnectionInfo clears the connection info if the given server is the connected one.
+func (f *failoverClient) clearConnectionInfo(server string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.connectionInfo.Server == server {
+		f.connectionInfo = blockchain.ConnectionInfo{}
+	}
+}
+
+// ConnectionInfo implements blockchain.ConnectionInfoProvider.
+func (f *failoverClient) ConnectionInfo() blockchain.ConnectionInfo {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.connectionInfo
+}
+
 func (f *failoverClient) RegisterOnConnectionErrorChangedEvent(callback func(error)) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -119,30 +154,57 @@ func (f *failoverClient) ScriptHashSubscribe(
 	setupAndTeardown func() func(),
 	scriptHashHex blockchain.ScriptHashHex,
 	result func(status string)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	subscription := &scriptHashSubscription{ctx: ctx, cancel: cancel}
+	f.scriptHashSubscriptionsMu.Lock()
+	f.scriptHashSubscriptions[scriptHashHex] = append(f.scriptHashSubscriptions[scriptHashHex], subscription)
+	f.scriptHashSubscriptionsMu.Unlock()
+
 	failover.Subscribe(
 		f.failover,
 		// This is called the first time `ScriptHashSubscribe()` is called for the current server,
 		// and again everytime a new server is connected (failover).
 		func(c *client, result func(string, error)) {
+			if ctx.Err() != nil {
+				// Unsubscribed, no need to subscribe on the new server.
+				return
+			}
 			// Do something before and after subscribing on a server.
 			teardown := setupAndTeardown()
 			// The callback will be called once after subscribing and then more times when the server pushes
 			// notifications. We teardown the subscription setup once.
 			once := sync.Once{}
-			c.ScriptHashSubscribe(scriptHashHex, func(status string, err error) {
+			c.ScriptHashSubscribe(ctx, scriptHashHex, func(status string, err error) {
 				defer once.Do(teardown)
 				result(status, err)
 			})
 		},
 		func(status string, err error) {
 			if err != nil {
-				// Can only happen if the failover client is closed.
+				// Can only happen if the failover client is closed or if the subscription was
+				// cancelled.
+				return
+			}
+			if ctx.Err() != nil {
 				return
 			}
 			result(status)
 		})
 }
 
+// ScriptHashUnsubscribe ends the subscriptions to the script hash, also on servers connected later
+// on. The electrum client does not support blockchain.scripthash.unsubscribe, so the notifications
+// the servers keep sending are dropped.
+func (f *failoverClient) ScriptHashUnsubscribe(scriptHashHex blockchain.ScriptHashHex) {
+	f.scriptHashSubscriptionsMu.Lock()
+	subscriptions := f.scriptHashSubscriptions[scriptHashHex]
+	delete(f.scriptHashSubscriptions, scriptHashHex)
+	f.scriptHashSubscriptionsMu.Unlock()
+	for _, subscription := range subscriptions {
+		subscription.cancel()
+	}
+}
+
 func (f *failoverClient) TransactionBroadcast(transaction *wire.MsgTx) error {
 	_, err := failover.Call(f.failover, func(c *client) (struct{}, error) {
 		return struct{}{}, c.TransactionBroadcast(transaction)
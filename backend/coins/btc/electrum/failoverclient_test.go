@@ -0,0 +1,124 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package electrum
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/block-client-go/electrum"
+	"github.com/BitBoxSwiss/block-client-go/failover"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSubscriptionServer answers server.version and blockchain.scripthash.subscribe, and pushes
+// script hash notifications with notify().
+type fakeSubscriptionServer struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+}
+
+func (s *fakeSubscriptionServer) write(message map[string]interface{}) {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		panic(err)
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, _ = s.conn.Write(append(messageBytes, '\n'))
+}
+
+func (s *fakeSubscriptionServer) serve() {
+	defer s.conn.Close()
+	scanner := bufio.NewScanner(s.conn)
+	for scanner.Scan() {
+		var request struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			return
+		}
+		var result interface{}
+		switch request.Method {
+		case "server.version":
+			result = []string{"ElectrumX 1.16.0", "1.4"}
+		case "blockchain.scripthash.subscribe":
+			result = "status1"
+		}
+		s.write(map[string]interface{}{"jsonrpc": "2.0", "id": request.ID, "result": result})
+	}
+}
+
+func (s *fakeSubscriptionServer) notify(scriptHashHex string, status string) {
+	s.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "blockchain.scripthash.subscribe",
+		"params":  []string{scriptHashHex, status},
+	})
+}
+
+func TestFailoverClientScriptHashUnsubscribe(t *testing.T) {
+	serverCh := make(chan *fakeSubscriptionServer, 1)
+	fclient := newFailoverClient(&failover.Options[*client]{
+		Servers: []*failover.Server[*client]{{
+			Name: "electrum.example.org:50001",
+			Connect: func() (*client, error) {
+				c, err := electrum.Connect(&electrum.Options{
+					SoftwareVersion: "BitBoxApp/test",
+					MethodTimeout:   5 * time.Second,
+					PingInterval:    -1,
+					Dial: func() (net.Conn, error) {
+						clientConn, serverConn := net.Pipe()
+						server := &fakeSubscriptionServer{conn: serverConn}
+						go server.serve()
+						serverCh <- server
+						return clientConn, nil
+					},
+				})
+				if err != nil {
+					return nil, err
+				}
+				return &client{client: c}, nil
+			},
+		}},
+	})
+	defer fclient.Close()
+
+	const scriptHashHex = blockchain.ScriptHashHex("abcd")
+	statusCh := make(chan string, 10)
+	fclient.ScriptHashSubscribe(
+		func() func() { return func() {} },
+		scriptHashHex,
+		func(status string) { statusCh <- status })
+	require.Equal(t, "status1", <-statusCh)
+	server := <-serverCh
+
+	server.notify(string(scriptHashHex), "status2")
+	require.Equal(t, "status2", <-statusCh)
+
+	fclient.ScriptHashUnsubscribe(scriptHashHex)
+	server.notify(string(scriptHashHex), "status3")
+	select {
+	case status := <-statusCh:
+		require.Fail(t, "notification after unsubscribing", status)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
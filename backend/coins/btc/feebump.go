@@ -0,0 +1,109 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// newFeeBumpTx creates a tx replacing the unconfirmed tx with the given ID, paying the fee rate of
+// the fee target in args. The other fields of args except AllowHighFee are ignored.
+func (account *Account) newFeeBumpTx(txID string, args *accounts.TxProposalArgs) (*maketx.TxProposal, error) {
+	txHash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	tx, spentOutputs, err := account.transactions.ReplaceableTx(*txHash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, errp.WithStack(errors.ErrFeeBumpUnavailable)
+	}
+	utxos := make(map[wire.OutPoint]maketx.UTXO, len(spentOutputs))
+	for outPoint, txOut := range spentOutputs {
+		utxos[outPoint] = maketx.UTXO{
+			TxOut:         txOut,
+			Configuration: account.getAddress(blockchain.NewScriptHashHex(txOut.PkScript)).Configuration,
+		}
+	}
+	var changeAddress *addresses.AccountAddress
+	for _, txOut := range tx.TxOut {
+		if address := account.lookupChangeAddress(blockchain.NewScriptHashHex(txOut.PkScript)); address != nil {
+			changeAddress = address
+			break
+		}
+	}
+	feeRatePerKb, err := account.getFeePerKb(args)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := account.coin.NetworkPolicies()
+	if err != nil {
+		return nil, err
+	}
+	txProposal, err := maketx.NewTxFeeBump(
+		account.coin,
+		tx,
+		utxos,
+		changeAddress,
+		feeRatePerKb,
+		policies.MinRelayFeeRate,
+		account.log,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPolicies(txProposal, utxos, policies); err != nil {
+		return nil, err
+	}
+	if !args.AllowHighFee {
+		if err := account.checkFee(txProposal, feeRatePerKb); err != nil {
+			return nil, err
+		}
+	}
+	return txProposal, nil
+}
+
+// FeeBumpProposal creates a tx replacing the unconfirmed outgoing tx with the given ID using RBF
+// (BIP125), paying a higher fee, and returns the output amount, the new fee and the total for
+// display in the UI. Like TxProposal(), the proposal is stored internally and can be signed and
+// sent with SendTx(). The note of the replaced tx is carried over to the replacement.
+func (account *Account) FeeBumpProposal(txID string, args *accounts.TxProposalArgs) (
+	coin.Amount, coin.Amount, coin.Amount, error) {
+	defer account.activeTxProposalLock.Lock()()
+
+	account.log.Debug("Proposing fee bump")
+	txProposal, err := account.newFeeBumpTx(txID, args)
+	if err != nil {
+		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
+	}
+
+	account.activeTxProposal = txProposal
+	account.BaseAccount.ProposeTxNote(account.TxNote(txID))
+
+	account.log.WithField("fee", txProposal.Fee).Debug("Returning fee bump fee")
+	return coin.NewAmountFromInt64(int64(txProposal.Amount)),
+		coin.NewAmountFromInt64(int64(txProposal.Fee)),
+		coin.NewAmountFromInt64(int64(txProposal.Total())), nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"math"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/util"
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+const (
+	// mempoolSpaceMempoolBlocks are the next blocks projected from the mempool.space mempool, see
+	// https://mempool.space/docs/api/rest#get-mempool-blocks-fees.
+	mempoolSpaceMempoolBlocks = "https://mempool.space/api/v1/fees/mempool-blocks"
+
+	// maxBlockVSize is the maximum virtual size of a block.
+	maxBlockVSize = 1000000
+)
+
+// feeEstimateBlocks are the confirmation targets in blocks for which FeeEstimates() estimates the
+// fee rate.
+var feeEstimateBlocks = []int{1, 2, 3, 6, 12, 24}
+
+// FeeEstimateSource is the source of a fee estimate. See the constants below.
+type FeeEstimateSource string
+
+const (
+	// FeeEstimateSourceElectrumMempool means that the fee rate was estimated from the fee histogram
+	// of the mempool of the connected Electrum server.
+	FeeEstimateSourceElectrumMempool FeeEstimateSource = "electrumMempool"
+
+	// FeeEstimateSourceMempoolSpace means that the fee rate was estimated from the next blocks
+	// projected by mempool.space.
+	FeeEstimateSourceMempoolSpace FeeEstimateSource = "mempoolSpace"
+
+	// FeeEstimateSourceNode means that the fee rate was estimated by the node behind the connected
+	// Electrum server.
+	FeeEstimateSourceNode FeeEstimateSource = "node"
+
+	// FeeEstimateSourceMinRelayFee means that no estimate was available and the min relay fee rate is
+	// used instead.
+	FeeEstimateSourceMinRelayFee FeeEstimateSource = "minRelayFee"
+)
+
+// FeeEstimate is the estimated fee rate for a transaction to be confirmed within a number of
+// blocks.
+type FeeEstimate struct {
+	Blocks       int
+	FeeRatePerKb btcutil.Amount
+	Source       FeeEstimateSource
+}
+
+// mempoolBlock is one of the projected blocks of the mempool.space mempool.
+type mempoolBlock struct {
+	BlockVSize float64 `json:"blockVSize"`
+	MedianFee  float64 `json:"medianFee"`
+}
+
+// feeRatePerKb converts a fee rate in sat/vB to sat/kvB.
+func feeRatePerKb(feeRate float64) btcutil.Amount {
+	return btcutil.Amount(math.Round(feeRate * 1000))
+}
+
+// estimateFeeRateFromHistogram returns the fee rate needed to be included in the next `blocks`
+// blocks, assuming that the mempool transactions are mined by descending fee rate and that no new
+// transactions arrive. The histogram must be sorted by descending fee rate. Zero is returned if the
+// whole mempool fits into these blocks.
+func estimateFeeRateFromHistogram(histogram []blockchain.FeeHistogramEntry, blocks int) btcutil.Amount {
+	var depth int64
+	for _, entry := range histogram {
+		depth += entry.VSize
+		if depth >= int64(blocks)*maxBlockVSize {
+			return feeRatePerKb(entry.FeeRate)
+		}
+	}
+	return 0
+}
+
+// estimateFeeRateFromMempoolBlocks returns the median fee rate of the last of the next `blocks`
+// projected blocks. Zero is returned if fewer blocks are projected, i.e. if the whole mempool fits
+// into these blocks.
+func estimateFeeRateFromMempoolBlocks(mempoolBlocks []mempoolBlock, blocks int) btcutil.Amount {
+	if blocks > len(mempoolBlocks) {
+		return 0
+	}
+	return feeRatePerKb(mempoolBlocks[blocks-1].MedianFee)
+}
+
+// fetchMempoolBlocks fetches the next blocks projected by mempool.space. Returns nil if they could
+// not be fetched.
+func (account *Account) fetchMempoolBlocks() []mempoolBlock {
+	mempoolBlocks := []mempoolBlock{}
+	_, err := util.APIGet(account.httpClient, mempoolSpaceMempoolBlocks, "", 100000, &mempoolBlocks)
+	if err != nil {
+		account.log.WithError(err).Errorf("Fetching fees from %s failed", mempoolSpaceMempoolBlocks)
+		return nil
+	}
+	return mempoolBlocks
+}
+
+// FeeEstimates returns the estimated fee rates for 1, 2, 3, 6, 12 and 24 blocks. The fee rates are
+// estimated from the mempool of the connected Electrum server if it provides its fee histogram.
+// Otherwise, the projected blocks of mempool.space are used for mainnet BTC, and the estimates of
+// the server's node for the other coins. Estimates below the min relay fee rate are raised to it.
+func (account *Account) FeeEstimates() []*FeeEstimate {
+	var histogram []blockchain.FeeHistogramEntry
+	if provider, ok := account.coin.Blockchain().(blockchain.FeeHistogramProvider); ok {
+		var err error
+		histogram, err = provider.FeeHistogram()
+		if err != nil {
+			account.log.WithError(err).Debug("Fee histogram not available")
+			histogram = nil
+		}
+	}
+	var mempoolBlocks []mempoolBlock
+	if histogram == nil && account.coin.Code() == coin.CodeBTC {
+		mempoolBlocks = account.fetchMempoolBlocks()
+	}
+	minRelayFeeRate, minRelayFeeRateErr := account.getMinRelayFeeRate()
+
+	estimates := []*FeeEstimate{}
+	for _, blocks := range feeEstimateBlocks {
+		estimate := &FeeEstimate{Blocks: blocks}
+		switch {
+		case histogram != nil:
+			estimate.FeeRatePerKb = estimateFeeRateFromHistogram(histogram, blocks)
+			estimate.Source = FeeEstimateSourceElectrumMempool
+		case mempoolBlocks != nil:
+			estimate.FeeRatePerKb = estimateFeeRateFromMempoolBlocks(mempoolBlocks, blocks)
+			estimate.Source = FeeEstimateSourceMempoolSpace
+		default:
+			nodeFeeRatePerKb, err := account.coin.Blockchain().EstimateFee(blocks)
+			if err != nil {
+				if minRelayFeeRateErr != nil {
+					continue
+				}
+				nodeFeeRatePerKb = minRelayFeeRate
+				estimate.Source = FeeEstimateSourceMinRelayFee
+			} else {
+				estimate.Source = FeeEstimateSourceNode
+			}
+			estimate.FeeRatePerKb = nodeFeeRatePerKb
+		}
+		if minRelayFeeRateErr == nil && estimate.FeeRatePerKb < minRelayFeeRate {
+			estimate.FeeRatePerKb = minRelayFeeRate
+		}
+		if estimate.FeeRatePerKb == 0 {
+			continue
+		}
+		estimates = append(estimates, estimate)
+	}
+	return estimates
+}
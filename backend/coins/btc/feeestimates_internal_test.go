@@ -0,0 +1,52 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateFeeRateFromHistogram(t *testing.T) {
+	histogram := []blockchain.FeeHistogramEntry{
+		{FeeRate: 50, VSize: 400000},
+		{FeeRate: 20.5, VSize: 700000},
+		{FeeRate: 10, VSize: 1000000},
+		{FeeRate: 2, VSize: 500000},
+	}
+	require.Equal(t, btcutil.Amount(20500), estimateFeeRateFromHistogram(histogram, 1))
+	require.Equal(t, btcutil.Amount(10000), estimateFeeRateFromHistogram(histogram, 2))
+	require.Equal(t, btcutil.Amount(0), estimateFeeRateFromHistogram(histogram, 3))
+	require.Equal(t, btcutil.Amount(0), estimateFeeRateFromHistogram(nil, 1))
+
+	// The mempool exactly fills the block.
+	histogram = []blockchain.FeeHistogramEntry{{FeeRate: 3.25, VSize: maxBlockVSize}}
+	require.Equal(t, btcutil.Amount(3250), estimateFeeRateFromHistogram(histogram, 1))
+}
+
+func TestEstimateFeeRateFromMempoolBlocks(t *testing.T) {
+	mempoolBlocks := []mempoolBlock{
+		{BlockVSize: 997000, MedianFee: 15.2},
+		{BlockVSize: 998000, MedianFee: 8},
+		{BlockVSize: 300000, MedianFee: 1.5},
+	}
+	require.Equal(t, btcutil.Amount(15200), estimateFeeRateFromMempoolBlocks(mempoolBlocks, 1))
+	require.Equal(t, btcutil.Amount(8000), estimateFeeRateFromMempoolBlocks(mempoolBlocks, 2))
+	require.Equal(t, btcutil.Amount(1500), estimateFeeRateFromMempoolBlocks(mempoolBlocks, 3))
+	require.Equal(t, btcutil.Amount(0), estimateFeeRateFromMempoolBlocks(mempoolBlocks, 6))
+}
@@ -17,25 +17,30 @@ package handlers
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"math/big"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/util"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/etherscan"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/paymenturi"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
@@ -44,18 +49,24 @@ import (
 	"github.com/btcsuite/btcd/wire"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
+	qrcode "github.com/skip2/go-qrcode"
 )
 
 // Handlers provides a web api to the account.
 type Handlers struct {
 	account accounts.Interface
-	log     *logrus.Entry
+	// likelyCoinForAddress returns a loaded coin other than `exclude` for which the address is
+	// valid.
+	likelyCoinForAddress func(address string, exclude coin.Code) (coin.Coin, bool)
+	log                  *logrus.Entry
 }
 
 // NewHandlers creates a new Handlers instance.
 func NewHandlers(
-	handleFunc func(string, func(*http.Request) (interface{}, error)) *mux.Route, log *logrus.Entry) *Handlers {
-	handlers := &Handlers{log: log}
+	handleFunc func(string, func(*http.Request) (interface{}, error)) *mux.Route,
+	likelyCoinForAddress func(address string, exclude coin.Code) (coin.Coin, bool),
+	log *logrus.Entry) *Handlers {
+	handlers := &Handlers{likelyCoinForAddress: likelyCoinForAddress, log: log}
 
 	handleFunc("/init", handlers.postInit).Methods("POST")
 	handleFunc("/status", handlers.getAccountStatus).Methods("GET")
@@ -64,21 +75,49 @@ func NewHandlers(
 	handleFunc("/export", handlers.ensureAccountInitialized(handlers.postExportTransactions)).Methods("POST")
 	handleFunc("/info", handlers.ensureAccountInitialized(handlers.getAccountInfo)).Methods("GET")
 	handleFunc("/utxos", handlers.ensureAccountInitialized(handlers.getUTXOs)).Methods("GET")
+	handleFunc("/receive-address-usage", handlers.ensureAccountInitialized(handlers.getReceiveAddressUsage)).Methods("GET")
+	handleFunc("/receive-address-usage/export", handlers.ensureAccountInitialized(handlers.postExportReceiveAddressUsage)).Methods("POST")
+	handleFunc("/address-clusters", handlers.ensureAccountInitialized(handlers.getAddressClusters)).Methods("GET")
+	handleFunc("/utxo-stats", handlers.ensureAccountInitialized(handlers.getUTXOStats)).Methods("GET")
+	handleFunc("/tx-size-estimate", handlers.ensureAccountInitialized(handlers.postTxSizeEstimate)).Methods("POST")
+	handleFunc("/balance-breakdown", handlers.ensureAccountInitialized(handlers.getBalanceBreakdown)).Methods("GET")
+	handleFunc("/snapshot", handlers.ensureAccountInitialized(handlers.getSnapshot)).Methods("GET")
+	handleFunc("/snapshot/export", handlers.ensureAccountInitialized(handlers.postExportBalanceAttestation)).Methods("POST")
+	handleFunc("/address-list/export", handlers.ensureAccountInitialized(handlers.postExportAddressList)).Methods("POST")
+	handleFunc("/descriptors", handlers.ensureAccountInitialized(handlers.getDescriptors)).Methods("GET")
 	handleFunc("/balance", handlers.ensureAccountInitialized(handlers.getAccountBalance)).Methods("GET")
+	handleFunc("/checkpoint", handlers.ensureAccountInitialized(handlers.getAccountCheckpoint)).Methods("GET")
 	handleFunc("/sendtx", handlers.ensureAccountInitialized(handlers.postAccountSendTx)).Methods("POST")
+	handleFunc("/interrupted-signing", handlers.ensureAccountInitialized(handlers.getInterruptedSigning)).Methods("GET")
+	handleFunc("/psbt", handlers.ensureAccountInitialized(handlers.getExportPSBT)).Methods("GET")
+	handleFunc("/psbt", handlers.ensureAccountInitialized(handlers.postImportPSBT)).Methods("POST")
+	handleFunc("/psbt/sign", handlers.ensureAccountInitialized(handlers.postSignPSBT)).Methods("POST")
 	handleFunc("/fee-targets", handlers.ensureAccountInitialized(handlers.getAccountFeeTargets)).Methods("GET")
+	handleFunc("/fee-estimates", handlers.ensureAccountInitialized(handlers.getFeeEstimates)).Methods("GET")
 	handleFunc("/tx-proposal", handlers.ensureAccountInitialized(handlers.postAccountTxProposal)).Methods("POST")
+	handleFunc("/utxo-reservations", handlers.ensureAccountInitialized(handlers.getUTXOReservations)).Methods("GET")
+	handleFunc("/utxo-reservations/release", handlers.ensureAccountInitialized(handlers.postReleaseUTXOReservations)).Methods("POST")
+	handleFunc("/fee-bump-proposal", handlers.ensureAccountInitialized(handlers.postFeeBumpProposal)).Methods("POST")
+	handleFunc("/cpfp-proposal", handlers.ensureAccountInitialized(handlers.postCPFPProposal)).Methods("POST")
 	handleFunc("/receive-addresses", handlers.ensureAccountInitialized(handlers.getReceiveAddresses)).Methods("GET")
+	handleFunc("/receive-uri", handlers.ensureAccountInitialized(handlers.getReceiveURI)).Methods("GET")
 	handleFunc("/verify-address", handlers.ensureAccountInitialized(handlers.postVerifyAddress)).Methods("POST")
 	handleFunc("/verify-extended-public-key", handlers.ensureAccountInitialized(handlers.postVerifyExtendedPublicKey)).Methods("POST")
 	handleFunc("/sign-address", handlers.ensureAccountInitialized(handlers.postSignBTCAddress)).Methods("POST")
+	handleFunc("/sign-message", handlers.ensureAccountInitialized(handlers.postSignMessage)).Methods("POST")
 	handleFunc("/has-secure-output", handlers.ensureAccountInitialized(handlers.getHasSecureOutput)).Methods("GET")
 	handleFunc("/propose-tx-note", handlers.ensureAccountInitialized(handlers.postProposeTxNote)).Methods("POST")
 	handleFunc("/notes/tx", handlers.ensureAccountInitialized(handlers.postSetTxNote)).Methods("POST")
+	handleFunc("/notes/address", handlers.ensureAccountInitialized(handlers.postSetAddressLabel)).Methods("POST")
+	handleFunc("/notes/tx-category", handlers.ensureAccountInitialized(handlers.postSetTxCategory)).Methods("POST")
+	handleFunc("/notes/tx-spam", handlers.ensureAccountInitialized(handlers.postSetTxSpam)).Methods("POST")
 	handleFunc("/connect-keystore", handlers.ensureAccountInitialized(handlers.postConnectKeystore)).Methods("POST")
 	handleFunc("/eth-sign-msg", handlers.ensureAccountInitialized(handlers.postEthSignMsg)).Methods("POST")
 	handleFunc("/eth-sign-typed-msg", handlers.ensureAccountInitialized(handlers.postEthSignTypedMsg)).Methods("POST")
 	handleFunc("/eth-sign-wallet-connect-tx", handlers.ensureAccountInitialized(handlers.postEthSignWalletConnectTx)).Methods("POST")
+	handleFunc("/eth-pending-transactions", handlers.ensureAccountInitialized(handlers.getEthPendingTransactions)).Methods("GET")
+	handleFunc("/eth-replacement-proposal", handlers.ensureAccountInitialized(handlers.postEthReplacementProposal)).Methods("POST")
+	handleFunc("/eth-wallet-connect-tx-warnings", handlers.ensureAccountInitialized(handlers.postEthWalletConnectTxWarnings)).Methods("POST")
 	return handlers
 }
 
@@ -98,10 +137,31 @@ type FormattedAmount struct {
 	Amount      string            `json:"amount"`
 	Unit        string            `json:"unit"`
 	Conversions map[string]string `json:"conversions"`
+	// RatesInfo describes the rates used for the conversions. It is nil if the conversions are based
+	// on historical rates or if no rates are available.
+	RatesInfo *rates.LatestPriceInfo `json:"ratesInfo,omitempty"`
+	// SubUnit is the fee in the conventional sub-unit of the coin, e.g. sat, litoshi or gwei. It is
+	// only set for fees.
+	SubUnit *SubUnitAmount `json:"subUnit,omitempty"`
+}
+
+// SubUnitAmount is an amount or a fee rate in a sub-unit, e.g. "1234 sat", "2.5 sat/vB" or
+// "21 gwei".
+type SubUnitAmount struct {
+	Amount string `json:"amount"`
+	Unit   string `json:"unit"`
 }
 
 func (handlers *Handlers) formatAmountAsJSON(amount coin.Amount, isFee bool) FormattedAmount {
 	accountCoin := handlers.account.Coin()
+	var subUnit *SubUnitAmount
+	if isFee {
+		unit, _ := accountCoin.FeeSubUnit()
+		subUnit = &SubUnitAmount{
+			Amount: coin.FormatFeeSubUnit(accountCoin, amount),
+			Unit:   unit,
+		}
+	}
 	return FormattedAmount{
 		Amount: accountCoin.FormatAmount(amount, isFee),
 		Unit:   accountCoin.GetFormatUnit(isFee),
@@ -112,7 +172,24 @@ func (handlers *Handlers) formatAmountAsJSON(amount coin.Amount, isFee bool) For
 			handlers.account.Config().RateUpdater,
 			util.FormatBtcAsSat(handlers.account.Config().BtcCurrencyUnit),
 		),
+		RatesInfo: handlers.account.Config().RateUpdater.LatestPriceInfo(),
+		SubUnit:   subUnit,
+	}
+}
+
+// formatFeeRateAsJSON formats the fee per vbyte for BTC/LTC, e.g. "2.5 sat/vB", or the fee per gas
+// (the gas price) for ETH, e.g. "21 gwei". Returns nil if the size is not known.
+func (handlers *Handlers) formatFeeRateAsJSON(fee coin.Amount, size uint64) *SubUnitAmount {
+	accountCoin := handlers.account.Coin()
+	rate, ok := coin.FormatFeeRateSubUnit(accountCoin, fee, size)
+	if !ok {
+		return nil
 	}
+	unit, _ := accountCoin.FeeSubUnit()
+	if _, isBTC := accountCoin.(*btc.Coin); isBTC {
+		unit += "/vB"
+	}
+	return &SubUnitAmount{Amount: rate, Unit: unit}
 }
 
 func (handlers *Handlers) formatAmountAtTimeAsJSON(amount coin.Amount, timeStamp *time.Time) *FormattedAmount {
@@ -146,9 +223,25 @@ type Transaction struct {
 	Amount                   FormattedAmount   `json:"amount"`
 	AmountAtTime             *FormattedAmount  `json:"amountAtTime"`
 	Fee                      FormattedAmount   `json:"fee"`
-	Time                     *string           `json:"time"`
-	Addresses                []string          `json:"addresses"`
-	Note                     string            `json:"note"`
+	// FeeRate is the fee per vbyte for BTC/LTC and the gas price for ETH, in the fee sub-unit. nil
+	// if unknown.
+	FeeRate   *SubUnitAmount `json:"feeRate"`
+	Time      *string        `json:"time"`
+	Addresses []string       `json:"addresses"`
+	Note      string         `json:"note"`
+	// Category is the category of the transaction set by the user, e.g. "Groceries". Used in the
+	// ledger export.
+	Category string `json:"category"`
+	// AddressLabels maps the addresses of the transaction that have a label to their label.
+	AddressLabels map[string]string `json:"addressLabels"`
+	// Spam is true if the transaction is likely spam or was marked as such by the user. These are
+	// hidden by default.
+	Spam bool `json:"spam"`
+	// Origin is where the funds came from, e.g. an imported exchange withdrawal. nil if unknown.
+	Origin *notes.TxOrigin `json:"origin"`
+	// InternalTransfer is the code of the loaded account on the other side if the transaction is a
+	// transfer between the accounts, and empty otherwise.
+	InternalTransfer types.Code `json:"internalTransfer"`
 
 	// BTC specific fields.
 	VSize        int64           `json:"vsize"`
@@ -159,6 +252,9 @@ type Transaction struct {
 	// ETH specific fields
 	Gas   uint64  `json:"gas"`
 	Nonce *uint64 `json:"nonce"`
+	// ContractName and MethodName describe the called contract method, if known.
+	ContractName string `json:"contractName,omitempty"`
+	MethodName   string `json:"methodName,omitempty"`
 }
 
 func (handlers *Handlers) ensureAccountInitialized(h func(*http.Request) (interface{}, error)) func(*http.Request) (interface{}, error) {
@@ -189,8 +285,12 @@ func (handlers *Handlers) getTxInfoJSON(txInfo *accounts.TransactionData, detail
 	}
 
 	addresses := []string{}
+	addressLabels := map[string]string{}
 	for _, addressAndAmount := range txInfo.Addresses {
 		addresses = append(addresses, addressAndAmount.Address)
+		if label := handlers.account.AddressLabel(addressAndAmount.Address); label != "" {
+			addressLabels[addressAndAmount.Address] = label
+		}
 	}
 	txInfoJSON := Transaction{
 		TxID:                     txInfo.TxID,
@@ -207,6 +307,20 @@ func (handlers *Handlers) getTxInfoJSON(txInfo *accounts.TransactionData, detail
 		Time:      formattedTime,
 		Addresses: addresses,
 		Note:      handlers.account.TxNote(txInfo.InternalID),
+		Category:  handlers.account.TxCategory(txInfo.InternalID),
+		Spam:      txInfo.Spam,
+		Origin:    handlers.account.TxOrigin(txInfo.InternalID),
+
+		AddressLabels: addressLabels,
+
+		InternalTransfer: handlers.account.TxInternalTransfer(txInfo.InternalID),
+
+		ContractName: txInfo.ContractName,
+		MethodName:   txInfo.MethodName,
+	}
+
+	if spam, ok := handlers.account.TxSpam(txInfo.InternalID); ok {
+		txInfoJSON.Spam = spam
 	}
 
 	if detail {
@@ -221,9 +335,15 @@ func (handlers *Handlers) getTxInfoJSON(txInfo *accounts.TransactionData, detail
 			if feeRatePerKb != nil {
 				txInfoJSON.FeeRatePerKb = handlers.formatBTCAmountAsJSON(*feeRatePerKb, true)
 			}
+			if txInfo.Fee != nil && txInfo.VSize > 0 {
+				txInfoJSON.FeeRate = handlers.formatFeeRateAsJSON(*txInfo.Fee, uint64(txInfo.VSize))
+			}
 		case *eth.Coin:
 			txInfoJSON.Gas = txInfo.Gas
 			txInfoJSON.Nonce = txInfo.Nonce
+			if txInfo.Fee != nil {
+				txInfoJSON.FeeRate = handlers.formatFeeRateAsJSON(*txInfo.Fee, txInfo.Gas)
+			}
 		}
 	}
 	return txInfoJSON
@@ -240,10 +360,6 @@ func (handlers *Handlers) getAccountTransactions(*http.Request) (interface{}, er
 	}
 	result.Transactions = []Transaction{}
 	for _, txInfo := range txs {
-		if txInfo.IsErc20 && big.NewInt(0).Cmp(txInfo.Amount.BigInt()) == 0 {
-			// skipping 0 amount erc20 txs to mitigate Address Poisoning attack
-			continue
-		}
 		result.Transactions = append(result.Transactions, handlers.getTxInfoJSON(txInfo, false))
 	}
 	result.Success = true
@@ -266,12 +382,27 @@ func (handlers *Handlers) getAccountTransaction(r *http.Request) (interface{}, e
 	return nil, nil
 }
 
-func (handlers *Handlers) postExportTransactions(*http.Request) (interface{}, error) {
+func (handlers *Handlers) postExportTransactions(r *http.Request) (interface{}, error) {
 	type result struct {
 		Success      bool   `json:"success"`
 		ErrorMessage string `json:"errorMessage"`
 	}
-	name := fmt.Sprintf("%s-%s-export.csv", time.Now().Format("2006-01-02-at-15-04-05"), handlers.account.Config().Config.Code)
+	var args struct {
+		Format accounts.ExportFormat `json:"format"`
+	}
+	// The body is optional, the default is CSV.
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+			return nil, errp.WithStack(err)
+		}
+	}
+	if args.Format == "" {
+		args.Format = accounts.ExportFormatCSV
+	}
+	if !args.Format.Valid() {
+		return nil, errp.Newf("unknown export format %q", args.Format)
+	}
+	name := fmt.Sprintf("%s-%s-export.%s", time.Now().Format("2006-01-02-at-15-04-05"), handlers.account.Config().Config.Code, args.Format)
 	exportsDir, err := config.ExportsDir()
 	if err != nil {
 		handlers.log.WithError(err).Error("error exporting account")
@@ -295,7 +426,7 @@ func (handlers *Handlers) postExportTransactions(*http.Request) (interface{}, er
 		handlers.log.WithError(err).Error("error creating file")
 		return result{Success: false, ErrorMessage: err.Error()}, nil
 	}
-	if err := handlers.account.ExportCSV(file, transactions); err != nil {
+	if err := handlers.account.Export(file, args.Format, transactions); err != nil {
 		_ = file.Close()
 		handlers.log.WithError(err).Error("error writing file")
 		return result{Success: false, ErrorMessage: err.Error()}, nil
@@ -324,66 +455,819 @@ func (handlers *Handlers) getUTXOs(*http.Request) (interface{}, error) {
 		return result, errp.New("Interface must be of type btc.Account")
 	}
 
-	addressCounts := make(map[string]int)
-
-	for _, output := range t.SpendableOutputs() {
-		address := output.Address.EncodeForHumans()
-		addressCounts[address]++
+	addressCounts := make(map[string]int)
+
+	for _, output := range t.SpendableOutputs() {
+		address := output.Address.EncodeForHumans()
+		addressCounts[address]++
+	}
+
+	for _, output := range t.SpendableOutputs() {
+		address := output.Address.EncodeForHumans()
+		addressReused := addressCounts[address] > 1
+
+		result = append(result,
+			map[string]interface{}{
+				"outPoint":      output.OutPoint.String(),
+				"txId":          output.OutPoint.Hash.String(),
+				"txOutput":      output.OutPoint.Index,
+				"amount":        handlers.formatBTCAmountAsJSON(btcutil.Amount(output.TxOut.Value), false),
+				"address":       address,
+				"scriptType":    output.Address.Configuration.ScriptType(),
+				"note":          handlers.account.TxNote(output.OutPoint.Hash.String()),
+				"addressReused": addressReused,
+				"unconfirmed":   output.Unconfirmed,
+			})
+	}
+
+	return result, nil
+}
+
+func (handlers *Handlers) getReceiveAddressUsage(*http.Request) (interface{}, error) {
+	type jsonUsage struct {
+		Address       string                  `json:"address"`
+		ScriptType    signing.ScriptType      `json:"scriptType"`
+		Keypath       signing.AbsoluteKeypath `json:"keypath"`
+		Used          bool                    `json:"used"`
+		TxCount       int                     `json:"txCount"`
+		TotalReceived FormattedAmount         `json:"totalReceived"`
+		FirstUsed     *time.Time              `json:"firstUsed"`
+		LastUsed      *time.Time              `json:"lastUsed"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	usages, err := btcAccount.ReceiveAddressUsage()
+	if err != nil {
+		return nil, err
+	}
+	result := []jsonUsage{}
+	for _, usage := range usages {
+		result = append(result, jsonUsage{
+			Address:       usage.Address,
+			ScriptType:    usage.ScriptType,
+			Keypath:       usage.Keypath,
+			Used:          usage.Used(),
+			TxCount:       usage.TxCount,
+			TotalReceived: handlers.formatBTCAmountAsJSON(usage.TotalReceived, false),
+			FirstUsed:     usage.FirstUsed,
+			LastUsed:      usage.LastUsed,
+		})
+	}
+	return result, nil
+}
+
+func (handlers *Handlers) postExportReceiveAddressUsage(*http.Request) (interface{}, error) {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return result{Success: false, ErrorMessage: "Interface must be of type btc.Account"}, nil
+	}
+	name := fmt.Sprintf("%s-%s-addresses.csv", time.Now().Format("2006-01-02-at-15-04-05"), handlers.account.Config().Config.Code)
+	exportsDir, err := config.ExportsDir()
+	if err != nil {
+		handlers.log.WithError(err).Error("error exporting addresses")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	suggestedPath := filepath.Join(exportsDir, name)
+	path := handlers.account.Config().GetSaveFilename(suggestedPath)
+	if path == "" {
+		return nil, nil
+	}
+	handlers.log.Infof("Export receive addresses to %s.", path)
+
+	usages, err := btcAccount.ReceiveAddressUsage()
+	if err != nil {
+		handlers.log.WithError(err).Error("error getting the receive address usage")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		handlers.log.WithError(err).Error("error creating file")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	if err := btcAccount.ExportReceiveAddressUsageCSV(file, usages); err != nil {
+		_ = file.Close()
+		handlers.log.WithError(err).Error("error writing file")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	if err := file.Close(); err != nil {
+		handlers.log.WithError(err).Error("error closing file")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	if err := handlers.account.Config().UnsafeSystemOpen(path); err != nil {
+		handlers.log.WithError(err).Error("error opening file")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return result{Success: true}, nil
+}
+
+func (handlers *Handlers) getAddressClusters(*http.Request) (interface{}, error) {
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	return btcAccount.AddressClusters()
+}
+
+func (handlers *Handlers) getUTXOStats(r *http.Request) (interface{}, error) {
+	type result struct {
+		Count        int             `json:"count"`
+		Total        FormattedAmount `json:"total"`
+		Smallest     FormattedAmount `json:"smallest"`
+		Largest      FormattedAmount `json:"largest"`
+		MedianAge    int             `json:"medianAge"`
+		FeeRatePerKb FormattedAmount `json:"feeRatePerKb"`
+		DustCount    int             `json:"dustCount"`
+		DustTotal    FormattedAmount `json:"dustTotal"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	var feeTargetCode accounts.FeeTargetCode
+	if feeTarget := r.URL.Query().Get("feeTarget"); feeTarget != "" {
+		var err error
+		feeTargetCode, err = accounts.NewFeeTargetCode(feeTarget)
+		if err != nil {
+			return nil, errp.WithMessage(err, "Failed to retrieve fee target code")
+		}
+	}
+	stats, err := btcAccount.UTXOStats(feeTargetCode, r.URL.Query().Get("customFee"))
+	if err != nil {
+		return nil, err
+	}
+	return result{
+		Count:        stats.Count,
+		Total:        handlers.formatBTCAmountAsJSON(stats.Total, false),
+		Smallest:     handlers.formatBTCAmountAsJSON(stats.Smallest, false),
+		Largest:      handlers.formatBTCAmountAsJSON(stats.Largest, false),
+		MedianAge:    stats.MedianAge,
+		FeeRatePerKb: handlers.formatBTCAmountAsJSON(stats.FeeRatePerKb, true),
+		DustCount:    stats.DustCount,
+		DustTotal:    handlers.formatBTCAmountAsJSON(stats.DustTotal, false),
+	}, nil
+}
+
+// postTxSizeEstimate estimates the size and fees of a tx without creating a proposal, see
+// btc.Account.EstimateTxSize().
+func (handlers *Handlers) postTxSizeEstimate(r *http.Request) (interface{}, error) {
+	var input struct {
+		Inputs  map[signing.ScriptType]int `json:"inputs"`
+		UTXOs   []string                   `json:"utxos"`
+		Outputs map[signing.ScriptType]int `json:"outputs"`
+	}
+	type jsonFee struct {
+		FeeTarget accounts.FeeTargetCode `json:"feeTarget"`
+		FeeRate   *SubUnitAmount         `json:"feeRate"`
+		Fee       FormattedAmount        `json:"fee"`
+	}
+	type result struct {
+		Success      bool             `json:"success"`
+		ErrorMessage string           `json:"errorMessage,omitempty"`
+		ErrorCode    string           `json:"errorCode,omitempty"`
+		VSize        int              `json:"vsize,omitempty"`
+		InputValue   *FormattedAmount `json:"inputValue,omitempty"`
+		Fees         []jsonFee        `json:"fees,omitempty"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return result{Success: false, ErrorMessage: "Interface must be of type btc.Account"}, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	args := &btc.TxSizeEstimateArgs{InputCounts: input.Inputs, OutputCounts: input.Outputs}
+	for _, outPointString := range input.UTXOs {
+		outPoint, err := util.ParseOutPoint([]byte(outPointString))
+		if err != nil {
+			return result{Success: false, ErrorMessage: err.Error()}, nil
+		}
+		args.UTXOs = append(args.UTXOs, *outPoint)
+	}
+	estimate, err := btcAccount.EstimateTxSize(args)
+	if err != nil {
+		if validationErr, ok := errp.Cause(err).(errors.TxValidationError); ok {
+			return result{Success: false, ErrorCode: string(validationErr)}, nil
+		}
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	fees := []jsonFee{}
+	for _, fee := range estimate.Fees {
+		fees = append(fees, jsonFee{
+			FeeTarget: fee.FeeTargetCode,
+			FeeRate:   handlers.formatFeeRateAsJSON(coin.NewAmountFromInt64(int64(fee.FeeRatePerKb)), 1000),
+			Fee:       handlers.formatBTCAmountAsJSON(fee.Fee, true),
+		})
+	}
+	inputValue := handlers.formatBTCAmountAsJSON(estimate.InputValue, false)
+	return result{
+		Success:    true,
+		VSize:      estimate.VSize,
+		InputValue: &inputValue,
+		Fees:       fees,
+	}, nil
+}
+
+func (handlers *Handlers) getDescriptors(*http.Request) (interface{}, error) {
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	return btcAccount.Descriptors()
+}
+
+func (handlers *Handlers) getBalanceBreakdown(*http.Request) (interface{}, error) {
+	type balancePart struct {
+		Available FormattedAmount `json:"available"`
+		Incoming  FormattedAmount `json:"incoming"`
+	}
+	type scriptTypeBalance struct {
+		balancePart
+		ScriptType signing.ScriptType `json:"scriptType"`
+	}
+	type addressBalance struct {
+		balancePart
+		Address     string                  `json:"address"`
+		Keypath     signing.AbsoluteKeypath `json:"keypath"`
+		ScriptType  signing.ScriptType      `json:"scriptType"`
+		Change      bool                    `json:"change"`
+		OutputCount int                     `json:"outputCount"`
+	}
+	type result struct {
+		Receive     balancePart         `json:"receive"`
+		Change      balancePart         `json:"change"`
+		ScriptTypes []scriptTypeBalance `json:"scriptTypes"`
+		Addresses   []addressBalance    `json:"addresses"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	breakdown, err := btcAccount.BalanceBreakdown()
+	if err != nil {
+		return nil, err
+	}
+	formatPart := func(part btc.BalancePart) balancePart {
+		return balancePart{
+			Available: handlers.formatBTCAmountAsJSON(part.Available, false),
+			Incoming:  handlers.formatBTCAmountAsJSON(part.Incoming, false),
+		}
+	}
+	response := result{
+		Receive:     formatPart(breakdown.Receive),
+		Change:      formatPart(breakdown.Change),
+		ScriptTypes: make([]scriptTypeBalance, len(breakdown.ScriptTypes)),
+		Addresses:   make([]addressBalance, len(breakdown.Addresses)),
+	}
+	for i, scriptType := range breakdown.ScriptTypes {
+		response.ScriptTypes[i] = scriptTypeBalance{
+			balancePart: formatPart(scriptType.BalancePart),
+			ScriptType:  scriptType.ScriptType,
+		}
+	}
+	for i, address := range breakdown.Addresses {
+		response.Addresses[i] = addressBalance{
+			balancePart: formatPart(address.BalancePart),
+			Address:     address.Address,
+			Keypath:     address.Keypath,
+			ScriptType:  address.ScriptType,
+			Change:      address.Change,
+			OutputCount: address.OutputCount,
+		}
+	}
+	return response, nil
+}
+
+func (handlers *Handlers) getSnapshot(r *http.Request) (interface{}, error) {
+	type result struct {
+		Success      bool            `json:"success"`
+		ErrorMessage string          `json:"errorMessage,omitempty"`
+		Snapshot     *btc.Snapshot   `json:"snapshot,omitempty"`
+		Balance      FormattedAmount `json:"balance"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return result{Success: false, ErrorMessage: "Interface must be of type btc.Account"}, nil
+	}
+	blockHeight := 0
+	if value := r.URL.Query().Get("blockHeight"); value != "" {
+		var err error
+		blockHeight, err = strconv.Atoi(value)
+		if err != nil {
+			return result{Success: false, ErrorMessage: "invalid block height"}, nil
+		}
+	}
+	snapshot, err := btcAccount.Snapshot(blockHeight)
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return result{
+		Success:  true,
+		Snapshot: snapshot,
+		Balance:  handlers.formatBTCAmountAsJSON(snapshot.Balance, false),
+	}, nil
+}
+
+// postExportBalanceAttestation signs a snapshot of the account at the requested block height with
+// the device and writes it as JSON to a file chosen by the user.
+func (handlers *Handlers) postExportBalanceAttestation(r *http.Request) (interface{}, error) {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage"`
+		ErrorCode    string `json:"errorCode,omitempty"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return result{Success: false, ErrorMessage: "Interface must be of type btc.Account"}, nil
+	}
+	var request struct {
+		BlockHeight int `json:"blockHeight"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	snapshot, err := btcAccount.Snapshot(request.BlockHeight)
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	name := fmt.Sprintf("%s-%s-attestation-%d.json",
+		time.Now().Format("2006-01-02-at-15-04-05"), handlers.account.Config().Config.Code, snapshot.BlockHeight)
+	exportsDir, err := config.ExportsDir()
+	if err != nil {
+		handlers.log.WithError(err).Error("error exporting balance attestation")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	suggestedPath := filepath.Join(exportsDir, name)
+	path := handlers.account.Config().GetSaveFilename(suggestedPath)
+	if path == "" {
+		return nil, nil
+	}
+
+	attestation, err := btcAccount.AttestSnapshot(snapshot)
+	if firmware.IsErrorAbort(err) {
+		return result{Success: false}, nil
+	}
+	if errp.Cause(err) == keystore.ErrDeviceBusy {
+		return result{Success: false, ErrorCode: keystore.ErrDeviceBusy.Error()}, nil
+	}
+	if err != nil {
+		handlers.log.WithError(err).Error("error signing the snapshot")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	attestationJSON, err := json.MarshalIndent(attestation, "", "  ")
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	handlers.log.Infof("Export balance attestation to %s.", path)
+	if err := os.WriteFile(path, attestationJSON, 0600); err != nil {
+		handlers.log.WithError(err).Error("error writing file")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	if err := handlers.account.Config().UnsafeSystemOpen(path); err != nil {
+		handlers.log.WithError(err).Error("error opening file")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return result{Success: true}, nil
+}
+
+// postExportAddressList signs a list of the next unused receive addresses with the device and
+// writes it as JSON to a file chosen by the user.
+func (handlers *Handlers) postExportAddressList(r *http.Request) (interface{}, error) {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage"`
+		ErrorCode    string `json:"errorCode,omitempty"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return result{Success: false, ErrorMessage: "Interface must be of type btc.Account"}, nil
+	}
+	var request struct {
+		ScriptType signing.ScriptType `json:"scriptType"`
+		Count      int                `json:"count"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	addressList, err := btcAccount.UpcomingReceiveAddresses(request.ScriptType, request.Count)
+	if err != nil {
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return result{Success: false, ErrorCode: string(errCode)}, nil
+		}
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	name := fmt.Sprintf("%s-%s-addresses.json",
+		time.Now().Format("2006-01-02-at-15-04-05"), handlers.account.Config().Config.Code)
+	exportsDir, err := config.ExportsDir()
+	if err != nil {
+		handlers.log.WithError(err).Error("error exporting address list")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	suggestedPath := filepath.Join(exportsDir, name)
+	path := handlers.account.Config().GetSaveFilename(suggestedPath)
+	if path == "" {
+		return nil, nil
+	}
+
+	attestation, err := btcAccount.AttestAddressList(addressList)
+	if firmware.IsErrorAbort(err) {
+		return result{Success: false}, nil
+	}
+	if errp.Cause(err) == keystore.ErrDeviceBusy {
+		return result{Success: false, ErrorCode: keystore.ErrDeviceBusy.Error()}, nil
+	}
+	if err != nil {
+		handlers.log.WithError(err).Error("error signing the address list")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	attestationJSON, err := json.MarshalIndent(attestation, "", "  ")
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	handlers.log.Infof("Export address list to %s.", path)
+	if err := os.WriteFile(path, attestationJSON, 0600); err != nil {
+		handlers.log.WithError(err).Error("error writing file")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	if err := handlers.account.Config().UnsafeSystemOpen(path); err != nil {
+		handlers.log.WithError(err).Error("error opening file")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return result{Success: true}, nil
+}
+
+func (handlers *Handlers) formatBalanceAsJSON(balance *accounts.Balance) map[string]interface{} {
+	return map[string]interface{}{
+		"hasAvailable": balance.Available().BigInt().Sign() > 0,
+		"available":    handlers.formatAmountAsJSON(balance.Available(), false),
+		"hasIncoming":  balance.Incoming().BigInt().Sign() > 0,
+		"incoming":     handlers.formatAmountAsJSON(balance.Incoming(), false),
+	}
+}
+
+func (handlers *Handlers) getAccountBalance(*http.Request) (interface{}, error) {
+	balance, err := handlers.account.Balance()
+	if err != nil {
+		return nil, err
+	}
+	return handlers.formatBalanceAsJSON(balance), nil
+}
+
+// getAccountCheckpoint returns the balance and the recent transactions as of the last sync, to be
+// shown while the account is syncing. Returns nil if there is no checkpoint.
+func (handlers *Handlers) getAccountCheckpoint(*http.Request) (interface{}, error) {
+	checkpoint := handlers.account.Checkpoint()
+	if checkpoint == nil {
+		return nil, nil
+	}
+	transactions := []Transaction{}
+	for _, txInfo := range checkpoint.Transactions {
+		transactions = append(transactions, handlers.getTxInfoJSON(txInfo, false))
+	}
+	return map[string]interface{}{
+		"balance":      handlers.formatBalanceAsJSON(checkpoint.Balance()),
+		"transactions": transactions,
+		"createdAt":    checkpoint.CreatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+type sendTxInput struct {
+	accounts.TxProposalArgs
+}
+
+func (input *sendTxInput) UnmarshalJSON(jsonBytes []byte) error {
+	jsonBody := struct {
+		Address   string `json:"address"`
+		SendAll   string `json:"sendAll"`
+		FeeTarget string `json:"feeTarget"`
+		// Provided in Sat/vByte for BTC/LTC and in Gwei for ETH.
+		CustomFee string `json:"customFee"`
+		Amount    string `json:"amount"`
+		// Alternative to Amount, in the smallest unit, e.g. sat or wei, as an integer string.
+		AmountSmallestUnit string   `json:"amountSmallestUnit"`
+		SelectedUTXOS      []string `json:"selectedUTXOS"`
+		Note               string   `json:"note"`
+		Counter            int      `json:"counter"`
+		// See accounts.ChangePolicy. Only applies to BTC/LTC.
+		ChangePolicy string `json:"changePolicy"`
+		AllowHighFee bool   `json:"allowHighFee"`
+		// See accounts.TxProposalArgs.ProposalID.
+		ProposalID string `json:"proposalId"`
+	}{}
+	if err := json.Unmarshal(jsonBytes, &jsonBody); err != nil {
+		return errp.WithStack(err)
+	}
+	input.RecipientAddress = jsonBody.Address
+	var err error
+	input.FeeTargetCode, err = accounts.NewFeeTargetCode(jsonBody.FeeTarget)
+	if err != nil {
+		return errp.WithMessage(err, "Failed to retrieve fee target code")
+	}
+	if input.FeeTargetCode == accounts.FeeTargetCodeCustom {
+		input.CustomFee = jsonBody.CustomFee
+	}
+	if jsonBody.Amount != "" && jsonBody.AmountSmallestUnit != "" {
+		return errp.New("amount and amountSmallestUnit are mutually exclusive")
+	}
+	switch {
+	case jsonBody.SendAll == "yes":
+		input.Amount = coin.NewSendAmountAll()
+	case jsonBody.AmountSmallestUnit != "":
+		input.Amount = coin.NewSendAmountSmallestUnit(jsonBody.AmountSmallestUnit)
+	default:
+		input.Amount = coin.NewSendAmount(jsonBody.Amount)
+	}
+	input.SelectedUTXOs = map[wire.OutPoint]struct{}{}
+	for _, outPointString := range jsonBody.SelectedUTXOS {
+		outPoint, err := util.ParseOutPoint([]byte(outPointString))
+		if err != nil {
+			return err
+		}
+		input.SelectedUTXOs[*outPoint] = struct{}{}
+	}
+	input.Note = jsonBody.Note
+	input.AllowHighFee = jsonBody.AllowHighFee
+	input.ProposalID = jsonBody.ProposalID
+	switch policy := accounts.ChangePolicy(jsonBody.ChangePolicy); policy {
+	case accounts.ChangePolicyDefault, accounts.ChangePolicyMatchRecipient, accounts.ChangePolicyNewest:
+		input.ChangePolicy = policy
+	default:
+		return errp.Newf("Unknown change policy: %s", jsonBody.ChangePolicy)
+	}
+	return nil
+}
+
+// getUTXOReservations returns the outputs reserved by pending tx proposals, see
+// config.Backend.LockProposalUTXOs.
+func (handlers *Handlers) getUTXOReservations(*http.Request) (interface{}, error) {
+	type reservation struct {
+		OutPoint   string    `json:"outPoint"`
+		ProposalID string    `json:"proposalId"`
+		ExpiresAt  time.Time `json:"expiresAt"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	reservations := btcAccount.UTXOReservations()
+	result := make([]reservation, len(reservations))
+	for i, utxoReservation := range reservations {
+		result[i] = reservation{
+			OutPoint:   utxoReservation.OutPoint.String(),
+			ProposalID: utxoReservation.ProposalID,
+			ExpiresAt:  utxoReservation.ExpiresAt,
+		}
+	}
+	return result, nil
+}
+
+// postReleaseUTXOReservations releases the outputs reserved by a tx proposal which is not sent,
+// e.g. when its send dialog is closed.
+func (handlers *Handlers) postReleaseUTXOReservations(r *http.Request) (interface{}, error) {
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	var request struct {
+		ProposalID string `json:"proposalId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	btcAccount.ReleaseUTXOReservations(request.ProposalID)
+	return nil, nil
+}
+
+func (handlers *Handlers) postAccountSendTx(r *http.Request) (interface{}, error) {
+	err := handlers.account.SendTx()
+	if errp.Cause(err) == keystore.ErrSigningAborted || errp.Cause(err) == errp.ErrUserAbort {
+		return map[string]interface{}{"success": false, "aborted": true}, nil
+	}
+	if err != nil {
+		handlers.log.WithError(err).Error("Failed to send transaction")
+		result := map[string]interface{}{"success": false, "errorMessage": err.Error()}
+		if strings.Contains(err.Error(), etherscan.ERC20GasErr) {
+			result["errorCode"] = errors.ERC20InsufficientGasFunds.Error()
+		}
+		if errp.Cause(err) == keystore.ErrDeviceBusy {
+			result["errorCode"] = keystore.ErrDeviceBusy.Error()
+		}
+		if errp.Cause(err) == keystore.ErrKeystoreDisconnected {
+			// The proposal is kept, calling this endpoint again once the device is reconnected
+			// resumes signing.
+			result["errorCode"] = "keystoreDisconnected"
+		}
+		if errp.Cause(err) == errors.ErrMultisigCosigning {
+			result["errorCode"] = errors.ErrMultisigCosigning.Error()
+		}
+		return result, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+// getInterruptedSigning returns whether there is a tx proposal whose signing was interrupted by
+// the device disconnecting, which can be signed and sent by calling the `sendtx` endpoint.
+func (handlers *Handlers) getInterruptedSigning(*http.Request) (interface{}, error) {
+	return handlers.account.HasInterruptedSigning(), nil
+}
+
+// getExportPSBT returns the active tx proposal as an unsigned PSBT, to be signed by external tools.
+func (handlers *Handlers) getExportPSBT(*http.Request) (interface{}, error) {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		PSBT         string `json:"psbt,omitempty"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return result{Success: false, ErrorMessage: "PSBTs are only supported by BTC based accounts."}, nil
+	}
+	encoded, err := btcAccount.ExportPSBT()
+	if err != nil {
+		handlers.log.WithError(err).Error("Failed to export PSBT")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return result{Success: true, PSBT: encoded}, nil
+}
+
+// postImportPSBT finalizes and broadcasts a PSBT signed by external tools.
+func (handlers *Handlers) postImportPSBT(r *http.Request) (interface{}, error) {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		ErrorCode    string `json:"errorCode,omitempty"`
+		TxID         string `json:"txID,omitempty"`
+	}
+	var input struct {
+		PSBT string `json:"psbt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return result{Success: false, ErrorMessage: "PSBTs are only supported by BTC based accounts."}, nil
+	}
+	txID, err := btcAccount.ImportPSBT(input.PSBT)
+	if validationErr, ok := errp.Cause(err).(errors.TxValidationError); ok {
+		return result{Success: false, ErrorCode: validationErr.Error(), ErrorMessage: err.Error()}, nil
+	}
+	if err != nil {
+		handlers.log.WithError(err).Error("Failed to import PSBT")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return result{Success: true, TxID: txID}, nil
+}
+
+// postSignPSBT signs a PSBT with the keystore of the account, e.g. to cosign a transaction of a
+// multisig account. If no PSBT is given, the active tx proposal is signed. complete is true if the
+// PSBT has all signatures and can be broadcasted with the `psbt` POST endpoint.
+func (handlers *Handlers) postSignPSBT(r *http.Request) (interface{}, error) {
+	type result struct {
+		Success      bool   `json:"success"`
+		Aborted      bool   `json:"aborted,omitempty"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		ErrorCode    string `json:"errorCode,omitempty"`
+		PSBT         string `json:"psbt,omitempty"`
+		Complete     bool   `json:"complete"`
+	}
+	var input struct {
+		PSBT string `json:"psbt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return result{Success: false, ErrorMessage: "PSBTs are only supported by BTC based accounts."}, nil
+	}
+	encoded := input.PSBT
+	if encoded == "" {
+		var err error
+		encoded, err = btcAccount.ExportPSBT()
+		if err != nil {
+			handlers.log.WithError(err).Error("Failed to export PSBT")
+			return result{Success: false, ErrorMessage: err.Error()}, nil
+		}
+	}
+	signed, complete, err := btcAccount.SignPSBT(encoded)
+	if errp.Cause(err) == keystore.ErrSigningAborted {
+		return result{Success: false, Aborted: true}, nil
+	}
+	if errp.Cause(err) == keystore.ErrDeviceBusy {
+		return result{Success: false, ErrorCode: keystore.ErrDeviceBusy.Error()}, nil
+	}
+	if validationErr, ok := errp.Cause(err).(errors.TxValidationError); ok {
+		return result{Success: false, ErrorCode: validationErr.Error(), ErrorMessage: err.Error()}, nil
+	}
+	if err != nil {
+		handlers.log.WithError(err).Error("Failed to sign PSBT")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return result{Success: true, PSBT: signed, Complete: complete}, nil
+}
+
+func txProposalError(err error) (interface{}, error) {
+	if validationErr, ok := errp.Cause(err).(errors.TxValidationError); ok {
+		return map[string]interface{}{
+			"success":   false,
+			"errorCode": validationErr.Error(),
+		}, nil
 	}
+	return nil, errp.WithMessage(err, "Failed to create transaction proposal")
+}
 
-	for _, output := range t.SpendableOutputs() {
-		address := output.Address.EncodeForHumans()
-		addressReused := addressCounts[address] > 1
-
-		result = append(result,
-			map[string]interface{}{
-				"outPoint":      output.OutPoint.String(),
-				"txId":          output.OutPoint.Hash.String(),
-				"txOutput":      output.OutPoint.Index,
-				"amount":        handlers.formatBTCAmountAsJSON(btcutil.Amount(output.TxOut.Value), false),
-				"address":       address,
-				"scriptType":    output.Address.Configuration.ScriptType(),
-				"note":          handlers.account.TxNote(output.OutPoint.Hash.String()),
-				"addressReused": addressReused,
-			})
+// otherCoinAddressError checks if an invalid recipient address is valid for a different loaded coin.
+// If so, an error response identifying the likely intended coin is returned.
+func (handlers *Handlers) otherCoinAddressError(err error, address string) (interface{}, bool) {
+	switch errp.Cause(err) {
+	case errors.ErrInvalidAddress,
+		errors.ErrInvalidAddressWrongNetwork,
+		errors.ErrInvalidAddressEthereum:
+	default:
+		return nil, false
 	}
-
-	return result, nil
+	if handlers.likelyCoinForAddress == nil {
+		return nil, false
+	}
+	likelyCoin, ok := handlers.likelyCoinForAddress(address, handlers.account.Coin().Code())
+	if !ok {
+		return nil, false
+	}
+	return map[string]interface{}{
+		"success":        false,
+		"errorCode":      errors.ErrInvalidAddressOtherCoin.Error(),
+		"likelyCoinCode": likelyCoin.Code(),
+		"likelyCoinName": likelyCoin.Name(),
+	}, true
 }
 
-func (handlers *Handlers) getAccountBalance(*http.Request) (interface{}, error) {
-	balance, err := handlers.account.Balance()
+func (handlers *Handlers) postAccountTxProposal(r *http.Request) (interface{}, error) {
+	var input sendTxInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return txProposalError(errp.WithStack(err))
+	}
+	outputAmount, fee, total, err := handlers.account.TxProposal(&input.TxProposalArgs)
 	if err != nil {
-		return nil, err
+		if result, ok := handlers.otherCoinAddressError(err, input.RecipientAddress); ok {
+			return result, nil
+		}
+		return txProposalError(err)
 	}
-	return map[string]interface{}{
-		"hasAvailable": balance.Available().BigInt().Sign() > 0,
-		"available":    handlers.formatAmountAsJSON(balance.Available(), false),
-		"hasIncoming":  balance.Incoming().BigInt().Sign() > 0,
-		"incoming":     handlers.formatAmountAsJSON(balance.Incoming(), false),
-	}, nil
+	result := map[string]interface{}{
+		"success": true,
+		"amount":  handlers.formatAmountAsJSON(outputAmount, false),
+		"fee":     handlers.formatAmountAsJSON(fee, true),
+		"total":   handlers.formatAmountAsJSON(total, false),
+	}
+	if btcAccount, ok := handlers.account.(*btc.Account); ok {
+		if details := btcAccount.ActiveTxProposalDetails(); details != nil {
+			result["changeRoundingFee"] = handlers.formatBTCAmountAsJSON(details.ChangeRoundingFee, true)
+			result["privacyScore"] = details.PrivacyScore
+			result["spendsUnconfirmedChange"] = details.SpendsUnconfirmedChange
+			if details.VSize > 0 {
+				result["feeRate"] = handlers.formatFeeRateAsJSON(fee, uint64(details.VSize))
+			}
+		}
+	}
+	if ethAccount, ok := handlers.account.(*eth.Account); ok {
+		result["warnings"] = ethAccount.ActiveTxProposalWarnings()
+		if feeRate := handlers.formatFeeRateAsJSON(fee, ethAccount.ActiveTxProposalGasLimit()); feeRate != nil {
+			result["feeRate"] = feeRate
+		}
+	}
+	return result, nil
 }
 
-type sendTxInput struct {
+// feeBumpInput is the input to speed up an unconfirmed tx, see postFeeBumpProposal(),
+// postCPFPProposal() and postEthReplacementProposal().
+type feeBumpInput struct {
+	TxID string
+	// Cancel is only used by postEthReplacementProposal().
+	Cancel bool
 	accounts.TxProposalArgs
 }
 
-func (input *sendTxInput) UnmarshalJSON(jsonBytes []byte) error {
+func (input *feeBumpInput) UnmarshalJSON(jsonBytes []byte) error {
 	jsonBody := struct {
-		Address   string `json:"address"`
-		SendAll   string `json:"sendAll"`
+		TxID      string `json:"txID"`
 		FeeTarget string `json:"feeTarget"`
-		// Provided in Sat/vByte for BTC/LTC and in Gwei for ETH.
-		CustomFee     string   `json:"customFee"`
-		Amount        string   `json:"amount"`
-		SelectedUTXOS []string `json:"selectedUTXOS"`
-		Note          string   `json:"note"`
-		Counter       int      `json:"counter"`
+		// Provided in Sat/vByte.
+		CustomFee    string `json:"customFee"`
+		AllowHighFee bool   `json:"allowHighFee"`
+		Cancel       bool   `json:"cancel"`
 	}{}
 	if err := json.Unmarshal(jsonBytes, &jsonBody); err != nil {
 		return errp.WithStack(err)
 	}
-	input.RecipientAddress = jsonBody.Address
+	input.TxID = jsonBody.TxID
+	input.Cancel = jsonBody.Cancel
 	var err error
 	input.FeeTargetCode, err = accounts.NewFeeTargetCode(jsonBody.FeeTarget)
 	if err != nil {
@@ -392,63 +1276,102 @@ func (input *sendTxInput) UnmarshalJSON(jsonBytes []byte) error {
 	if input.FeeTargetCode == accounts.FeeTargetCodeCustom {
 		input.CustomFee = jsonBody.CustomFee
 	}
-	if jsonBody.SendAll == "yes" {
-		input.Amount = coin.NewSendAmountAll()
-	} else {
-		input.Amount = coin.NewSendAmount(jsonBody.Amount)
+	input.AllowHighFee = jsonBody.AllowHighFee
+	return nil
+}
+
+// postFeeBumpProposal proposes a tx replacing an unconfirmed outgoing tx with a higher fee. Like a tx
+// proposal, it is signed and broadcast using the `sendtx` endpoint.
+func (handlers *Handlers) postFeeBumpProposal(r *http.Request) (interface{}, error) {
+	var input feeBumpInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return txProposalError(errp.WithStack(err))
 	}
-	input.SelectedUTXOs = map[wire.OutPoint]struct{}{}
-	for _, outPointString := range jsonBody.SelectedUTXOS {
-		outPoint, err := util.ParseOutPoint([]byte(outPointString))
-		if err != nil {
-			return err
-		}
-		input.SelectedUTXOs[*outPoint] = struct{}{}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return txProposalError(errp.WithStack(errors.ErrFeeBumpUnavailable))
 	}
-	input.Note = jsonBody.Note
-	return nil
+	outputAmount, fee, total, err := btcAccount.FeeBumpProposal(input.TxID, &input.TxProposalArgs)
+	if err != nil {
+		return txProposalError(err)
+	}
+	return map[string]interface{}{
+		"success": true,
+		"amount":  handlers.formatAmountAsJSON(outputAmount, false),
+		"fee":     handlers.formatAmountAsJSON(fee, true),
+		"total":   handlers.formatAmountAsJSON(total, false),
+	}, nil
 }
 
-func (handlers *Handlers) postAccountSendTx(r *http.Request) (interface{}, error) {
-	err := handlers.account.SendTx()
-	if errp.Cause(err) == keystore.ErrSigningAborted || errp.Cause(err) == errp.ErrUserAbort {
-		return map[string]interface{}{"success": false, "aborted": true}, nil
+// postEthReplacementProposal proposes a tx replacing a pending outgoing ETH tx with the same nonce,
+// either re-sending it with a higher fee or cancelling it with a 0-value transfer to ourselves.
+// Like a tx proposal, it is signed and broadcast using the `sendtx` endpoint.
+func (handlers *Handlers) postEthReplacementProposal(r *http.Request) (interface{}, error) {
+	var input feeBumpInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return txProposalError(errp.WithStack(err))
+	}
+	ethAccount, ok := handlers.account.(*eth.Account)
+	if !ok {
+		return txProposalError(errp.WithStack(errors.ErrFeeBumpUnavailable))
 	}
+	outputAmount, fee, total, err := ethAccount.ReplacementProposal(input.TxID, input.Cancel, &input.TxProposalArgs)
 	if err != nil {
-		handlers.log.WithError(err).Error("Failed to send transaction")
-		result := map[string]interface{}{"success": false, "errorMessage": err.Error()}
-		if strings.Contains(err.Error(), etherscan.ERC20GasErr) {
-			result["errorCode"] = errors.ERC20InsufficientGasFunds.Error()
-		}
-		return result, nil
+		return txProposalError(err)
 	}
-	return map[string]interface{}{"success": true}, nil
+	result := map[string]interface{}{
+		"success":  true,
+		"amount":   handlers.formatAmountAsJSON(outputAmount, false),
+		"fee":      handlers.formatAmountAsJSON(fee, true),
+		"total":    handlers.formatAmountAsJSON(total, false),
+		"warnings": ethAccount.ActiveTxProposalWarnings(),
+	}
+	if feeRate := handlers.formatFeeRateAsJSON(fee, ethAccount.ActiveTxProposalGasLimit()); feeRate != nil {
+		result["feeRate"] = feeRate
+	}
+	return result, nil
 }
 
-func txProposalError(err error) (interface{}, error) {
-	if validationErr, ok := errp.Cause(err).(errors.TxValidationError); ok {
-		return map[string]interface{}{
-			"success":   false,
-			"errorCode": validationErr.Error(),
-		}, nil
+// getEthPendingTransactions returns the pending outgoing txs of an ETH account with their nonces.
+// These can be replaced using postEthReplacementProposal().
+func (handlers *Handlers) getEthPendingTransactions(*http.Request) (interface{}, error) {
+	type pendingTransaction struct {
+		TxID  string `json:"txID"`
+		Nonce uint64 `json:"nonce"`
 	}
-	return nil, errp.WithMessage(err, "Failed to create transaction proposal")
+	result := []pendingTransaction{}
+	ethAccount, ok := handlers.account.(*eth.Account)
+	if !ok {
+		return result, nil
+	}
+	for _, tx := range ethAccount.PendingTransactions() {
+		result = append(result, pendingTransaction{TxID: tx.TxID, Nonce: tx.Nonce})
+	}
+	return result, nil
 }
 
-func (handlers *Handlers) postAccountTxProposal(r *http.Request) (interface{}, error) {
-	var input sendTxInput
+// postCPFPProposal proposes a child tx spending the unconfirmed outputs of an incoming tx back to
+// the account with a high fee, so that both confirm sooner. Like a tx proposal, it is signed and
+// broadcast using the `sendtx` endpoint.
+func (handlers *Handlers) postCPFPProposal(r *http.Request) (interface{}, error) {
+	var input feeBumpInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		return txProposalError(errp.WithStack(err))
 	}
-	outputAmount, fee, total, err := handlers.account.TxProposal(&input.TxProposalArgs)
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return txProposalError(errp.WithStack(errors.ErrFeeBumpUnavailable))
+	}
+	proposal, err := btcAccount.CPFPProposal(input.TxID, &input.TxProposalArgs)
 	if err != nil {
 		return txProposalError(err)
 	}
 	return map[string]interface{}{
-		"success": true,
-		"amount":  handlers.formatAmountAsJSON(outputAmount, false),
-		"fee":     handlers.formatAmountAsJSON(fee, true),
-		"total":   handlers.formatAmountAsJSON(total, false),
+		"success":             true,
+		"amount":              handlers.formatBTCAmountAsJSON(proposal.Amount, false),
+		"fee":                 handlers.formatBTCAmountAsJSON(proposal.Fee, true),
+		"parentFeeRatePerKb":  handlers.formatBTCAmountAsJSON(proposal.ParentFeeRatePerKb, true),
+		"packageFeeRatePerKb": handlers.formatBTCAmountAsJSON(proposal.PackageFeeRatePerKb, true),
 	}, nil
 }
 
@@ -472,6 +1395,34 @@ func (handlers *Handlers) getAccountFeeTargets(*http.Request) (interface{}, erro
 	}, nil
 }
 
+// getFeeEstimates returns the estimated fee rates per number of blocks to confirm a transaction,
+// see btc.Account.FeeEstimates().
+func (handlers *Handlers) getFeeEstimates(*http.Request) (interface{}, error) {
+	type jsonFeeEstimate struct {
+		Blocks  int                   `json:"blocks"`
+		FeeRate *SubUnitAmount        `json:"feeRate"`
+		Source  btc.FeeEstimateSource `json:"source"`
+	}
+	type result struct {
+		Success      bool              `json:"success"`
+		ErrorMessage string            `json:"errorMessage,omitempty"`
+		FeeEstimates []jsonFeeEstimate `json:"feeEstimates"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return result{Success: false, ErrorMessage: "Interface must be of type btc.Account"}, nil
+	}
+	feeEstimates := []jsonFeeEstimate{}
+	for _, feeEstimate := range btcAccount.FeeEstimates() {
+		feeEstimates = append(feeEstimates, jsonFeeEstimate{
+			Blocks:  feeEstimate.Blocks,
+			FeeRate: handlers.formatFeeRateAsJSON(coin.NewAmountFromInt64(int64(feeEstimate.FeeRatePerKb)), 1000),
+			Source:  feeEstimate.Source,
+		})
+	}
+	return result{Success: true, FeeEstimates: feeEstimates}, nil
+}
+
 func (handlers *Handlers) postInit(*http.Request) (interface{}, error) {
 	if handlers.account == nil {
 		return nil, errp.New("/init called even though account was not added yet")
@@ -513,6 +1464,7 @@ func (handlers *Handlers) getReceiveAddresses(*http.Request) (interface{}, error
 	type jsonAddress struct {
 		Address   string `json:"address"`
 		AddressID string `json:"addressID"`
+		Label     string `json:"label"`
 	}
 	type jsonAddressList struct {
 		ScriptType *signing.ScriptType `json:"scriptType"`
@@ -525,6 +1477,7 @@ func (handlers *Handlers) getReceiveAddresses(*http.Request) (interface{}, error
 			addrs = append(addrs, jsonAddress{
 				Address:   address.EncodeForHumans(),
 				AddressID: address.ID(),
+				Label:     handlers.account.AddressLabel(address.EncodeForHumans()),
 			})
 		}
 		addressList = append(addressList, jsonAddressList{
@@ -535,18 +1488,109 @@ func (handlers *Handlers) getReceiveAddresses(*http.Request) (interface{}, error
 	return addressList, nil
 }
 
+// paymentURI composes the BIP-21 or EIP-681 URI requesting a payment of the amount to the address.
+// amount is nil if the payer chooses the amount.
+func (handlers *Handlers) paymentURI(address string, amount *coin.Amount, label string) (string, error) {
+	var amountInt *big.Int
+	if amount != nil {
+		amountInt = amount.BigInt()
+	}
+	switch accountCoin := handlers.account.Coin().(type) {
+	case *btc.Coin:
+		scheme, ok := paymenturi.BIP21Scheme(accountCoin.Code())
+		if !ok {
+			return "", errp.Newf("unsupported coin %s", accountCoin.Code())
+		}
+		return paymenturi.BIP21(scheme, address, amountInt, label), nil
+	case *eth.Coin:
+		if token := accountCoin.ERC20Token(); token != nil {
+			return paymenturi.EIP681Token(
+				token.ContractAddress().Hex(), address, accountCoin.ChainID(), amountInt, label), nil
+		}
+		return paymenturi.EIP681(address, accountCoin.ChainID(), amountInt, label), nil
+	default:
+		return "", errp.Newf("unsupported coin %s", accountCoin.Code())
+	}
+}
+
+// getReceiveURI returns the payment URI requesting an optional amount, in the unit the amounts are
+// entered in, with an optional label, to an unused receive address, together with its QR code.
+func (handlers *Handlers) getReceiveURI(r *http.Request) (interface{}, error) {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		URI          string `json:"uri,omitempty"`
+		QRCode       string `json:"qrCode,omitempty"`
+	}
+	query := r.URL.Query()
+	addressID := query.Get("addressID")
+	var address string
+	for _, addresses := range handlers.account.GetUnusedReceiveAddresses() {
+		for _, receiveAddress := range addresses.Addresses {
+			if receiveAddress.ID() == addressID {
+				address = receiveAddress.EncodeForHumans()
+			}
+		}
+	}
+	if address == "" {
+		return result{Success: false, ErrorMessage: "unknown receive address"}, nil
+	}
+	var amount *coin.Amount
+	if amountString := strings.TrimSpace(query.Get("amount")); amountString != "" {
+		parsedAmount, err := handlers.account.Coin().ParseAmount(amountString)
+		if err != nil || parsedAmount.BigInt().Sign() <= 0 {
+			return result{Success: false, ErrorMessage: "invalid amount"}, nil
+		}
+		amount = &parsedAmount
+	}
+	uri, err := handlers.paymentURI(address, amount, strings.TrimSpace(query.Get("label")))
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	qr, err := qrcode.New(uri, qrcode.Medium)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	png, err := qr.PNG(256)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return result{
+		Success: true,
+		URI:     uri,
+		QRCode:  "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// postVerifyAddress shows the address on the device. verified is false if the keystore can't
+// verify addresses.
 func (handlers *Handlers) postVerifyAddress(r *http.Request) (interface{}, error) {
+	type result struct {
+		Success      bool   `json:"success"`
+		Verified     bool   `json:"verified"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		ErrorCode    string `json:"errorCode,omitempty"`
+	}
 	var addressID string
 	if err := json.NewDecoder(r.Body).Decode(&addressID); err != nil {
 		return nil, errp.WithStack(err)
 	}
-	return handlers.account.VerifyAddress(addressID)
+	verified, err := handlers.account.VerifyAddress(addressID)
+	if errp.Cause(err) == keystore.ErrDeviceBusy {
+		return result{Success: false, ErrorCode: keystore.ErrDeviceBusy.Error()}, nil
+	}
+	if err != nil {
+		handlers.log.WithError(err).Error("Failed to verify address")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return result{Success: true, Verified: verified}, nil
 }
 
 func (handlers *Handlers) postVerifyExtendedPublicKey(r *http.Request) (interface{}, error) {
 	type result struct {
 		Success      bool   `json:"success"`
 		ErrorMessage string `json:"errorMessage"`
+		ErrorCode    string `json:"errorCode,omitempty"`
 	}
 	var input struct {
 		SigningConfigIndex int `json:"signingConfigIndex"`
@@ -566,6 +1610,9 @@ func (handlers *Handlers) postVerifyExtendedPublicKey(r *http.Request) (interfac
 	if errp.Cause(err) == context.Canceled {
 		return result{Success: true}, nil
 	}
+	if errp.Cause(err) == keystore.ErrDeviceBusy {
+		return result{Success: false, ErrorCode: keystore.ErrDeviceBusy.Error()}, nil
+	}
 	if err != nil {
 		return result{Success: false, ErrorMessage: err.Error()}, nil
 	}
@@ -610,6 +1657,42 @@ func (handlers *Handlers) postSetTxNote(r *http.Request) (interface{}, error) {
 	return nil, handlers.account.SetTxNote(args.InternalTxID, args.Note)
 }
 
+func (handlers *Handlers) postSetTxCategory(r *http.Request) (interface{}, error) {
+	var args struct {
+		InternalTxID string `json:"internalTxID"`
+		Category     string `json:"category"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return nil, errp.WithStack(err)
+	}
+
+	return nil, handlers.account.SetTxCategory(args.InternalTxID, args.Category)
+}
+
+func (handlers *Handlers) postSetAddressLabel(r *http.Request) (interface{}, error) {
+	var args struct {
+		Address string `json:"address"`
+		Label   string `json:"label"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return nil, errp.WithStack(err)
+	}
+
+	return nil, handlers.account.SetAddressLabel(args.Address, args.Label)
+}
+
+func (handlers *Handlers) postSetTxSpam(r *http.Request) (interface{}, error) {
+	var args struct {
+		InternalTxID string `json:"internalTxID"`
+		Spam         bool   `json:"spam"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return nil, errp.WithStack(err)
+	}
+
+	return nil, handlers.account.SetTxSpam(args.InternalTxID, args.Spam)
+}
+
 func (handlers *Handlers) postConnectKeystore(r *http.Request) (interface{}, error) {
 	type response struct {
 		Success bool `json:"success"`
@@ -624,6 +1707,7 @@ type signingResponse struct {
 	Signature    string `json:"signature"`
 	Aborted      bool   `json:"aborted"`
 	ErrorMessage string `json:"errorMessage"`
+	ErrorCode    string `json:"errorCode,omitempty"`
 }
 
 func (handlers *Handlers) postEthSignMsg(r *http.Request) (interface{}, error) {
@@ -639,6 +1723,9 @@ func (handlers *Handlers) postEthSignMsg(r *http.Request) (interface{}, error) {
 	if errp.Cause(err) == keystore.ErrSigningAborted || errp.Cause(err) == errp.ErrUserAbort {
 		return signingResponse{Success: false, Aborted: true}, nil
 	}
+	if errp.Cause(err) == keystore.ErrDeviceBusy {
+		return signingResponse{Success: false, ErrorCode: keystore.ErrDeviceBusy.Error()}, nil
+	}
 	if err != nil {
 		handlers.log.WithError(err).Error("Failed to sign message")
 		result := signingResponse{Success: false, ErrorMessage: err.Error()}
@@ -666,6 +1753,9 @@ func (handlers *Handlers) postEthSignTypedMsg(r *http.Request) (interface{}, err
 	if errp.Cause(err) == keystore.ErrSigningAborted || errp.Cause(err) == errp.ErrUserAbort {
 		return signingResponse{Success: false, Aborted: true}, nil
 	}
+	if errp.Cause(err) == keystore.ErrDeviceBusy {
+		return signingResponse{Success: false, ErrorCode: keystore.ErrDeviceBusy.Error()}, nil
+	}
 	if err != nil {
 		handlers.log.WithError(err).Error("Failed to sign typed data")
 		result := signingResponse{Success: false, ErrorMessage: err.Error()}
@@ -682,9 +1772,10 @@ func (handlers *Handlers) postEthSignTypedMsg(r *http.Request) (interface{}, err
 // ChainId is needed to allow signing all supported EVM networks via the BBApp.
 func (handlers *Handlers) postEthSignWalletConnectTx(r *http.Request) (interface{}, error) {
 	var args struct {
-		Send    bool                  `json:"send"`
-		ChainId uint64                `json:"chainId"`
-		Tx      eth.WalletConnectArgs `json:"tx"`
+		Send           bool                  `json:"send"`
+		ChainId        uint64                `json:"chainId"`
+		Tx             eth.WalletConnectArgs `json:"tx"`
+		AcceptWarnings bool                  `json:"acceptWarnings"`
 	}
 	type response struct {
 		Success bool   `json:"success"`
@@ -698,10 +1789,13 @@ func (handlers *Handlers) postEthSignWalletConnectTx(r *http.Request) (interface
 	if !ok {
 		return signingResponse{Success: false, ErrorMessage: "Must be an ETH based account"}, nil
 	}
-	txHash, rawTx, err := ethAccount.EthSignWalletConnectTx(args.Send, args.ChainId, args.Tx)
+	txHash, rawTx, err := ethAccount.EthSignWalletConnectTx(args.Send, args.ChainId, args.Tx, args.AcceptWarnings)
 	if errp.Cause(err) == keystore.ErrSigningAborted || errp.Cause(err) == errp.ErrUserAbort {
 		return signingResponse{Success: false, Aborted: true}, nil
 	}
+	if errp.Cause(err) == keystore.ErrDeviceBusy {
+		return signingResponse{Success: false, ErrorCode: keystore.ErrDeviceBusy.Error()}, nil
+	}
 	if err != nil {
 		handlers.log.WithError(err).Error("Failed to send transaction")
 		result := signingResponse{Success: false, ErrorMessage: err.Error()}
@@ -714,6 +1808,27 @@ func (handlers *Handlers) postEthSignWalletConnectTx(r *http.Request) (interface
 	}, nil
 }
 
+func (handlers *Handlers) postEthWalletConnectTxWarnings(r *http.Request) (interface{}, error) {
+	type response struct {
+		Success      bool            `json:"success"`
+		Warnings     []eth.TxWarning `json:"warnings"`
+		ErrorMessage string          `json:"errorMessage,omitempty"`
+	}
+	var tx eth.WalletConnectArgs
+	if err := json.NewDecoder(r.Body).Decode(&tx); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	ethAccount, ok := handlers.account.(*eth.Account)
+	if !ok {
+		return response{Success: false, ErrorMessage: "Must be an ETH based account"}, nil
+	}
+	warnings, err := ethAccount.WalletConnectTxWarnings(tx)
+	if err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return response{Success: true, Warnings: warnings}, nil
+}
+
 func (handlers *Handlers) postSignBTCAddress(r *http.Request) (interface{}, error) {
 	type response struct {
 		Success      bool   `json:"success"`
@@ -751,9 +1866,51 @@ func (handlers *Handlers) postSignBTCAddress(r *http.Request) (interface{}, erro
 		if errp.Cause(err) == backend.ErrWrongKeystore {
 			return response{Success: false, ErrorCode: backend.ErrWrongKeystore.Error()}, nil
 		}
+		if errp.Cause(err) == keystore.ErrDeviceBusy {
+			return response{Success: false, ErrorCode: keystore.ErrDeviceBusy.Error()}, nil
+		}
 
 		handlers.log.WithField("code", account.Config().Config.Code).Error(err)
 		return response{Success: false, ErrorMessage: err.Error()}, nil
 	}
 	return response{Success: true, Address: address, Signature: signature}, nil
 }
+
+// postSignMessage signs a message with an address of the account to prove its ownership.
+func (handlers *Handlers) postSignMessage(r *http.Request) (interface{}, error) {
+	type response struct {
+		Success       bool               `json:"success"`
+		SignedMessage *btc.SignedMessage `json:"signedMessage,omitempty"`
+		ErrorMessage  string             `json:"errorMessage,omitempty"`
+		ErrorCode     string             `json:"errorCode,omitempty"`
+	}
+	var request struct {
+		Address string `json:"address"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	account, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return response{
+			Success:      false,
+			ErrorMessage: "An account must be BTC based to support message signing.",
+		}, nil
+	}
+	signedMessage, err := account.SignMessage(request.Address, request.Message)
+	if err != nil {
+		if firmware.IsErrorAbort(err) {
+			return response{Success: false, ErrorCode: errp.ErrUserAbort.Error()}, nil
+		}
+		if errp.Cause(err) == backend.ErrWrongKeystore {
+			return response{Success: false, ErrorCode: backend.ErrWrongKeystore.Error()}, nil
+		}
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return response{Success: false, ErrorCode: string(errCode)}, nil
+		}
+		handlers.log.WithField("code", account.Config().Config.Code).Error(err)
+		return response{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return response{Success: true, SignedMessage: signedMessage}, nil
+}
@@ -36,6 +36,10 @@ import (
 
 const reorgLimit = 100
 
+// dataSaverMaxHeaders is the maximum number of missing headers which are still downloaded in the
+// data saver mode, about one day of blocks.
+const dataSaverMaxHeaders = 144
+
 // Event instances are sent to the onEvent callback.
 type Event string
 
@@ -47,6 +51,10 @@ const (
 	EventSynced Event = "synced"
 	// EventNewTip is fired when a new tip is known.
 	EventNewTip Event = "newTip"
+	// EventReorg is fired when the headers of a chain reorganization were downloaded and some of
+	// our previous headers were replaced. The height of the first replaced header is returned by
+	// ReorgHeight().
+	EventReorg Event = "reorg"
 )
 
 // Interface represents the public API of this package.
@@ -57,6 +65,7 @@ type Interface interface {
 	SubscribeEvent(f func(Event)) func()
 	VerifiedHeaderByHeight(int) (*wire.BlockHeader, error)
 	TipHeight() int
+	ReorgHeight() int
 	Status() (*Status, error)
 }
 
@@ -76,6 +85,17 @@ type Headers struct {
 	tipAtInitTime int
 	kickChan      chan struct{}
 	quitChan      chan struct{}
+	// dataSaver defers catching up, see SetDataSaver().
+	dataSaver bool
+	// revertedHeaders are the hashes of the headers reverted in a reorg by height, until they are
+	// replaced by the re-downloaded headers.
+	revertedHeaders map[int]chainhash.Hash
+	// pendingReorgHeight is the height of the first re-downloaded header differing from the
+	// reverted one, or -1 if there is none (yet).
+	pendingReorgHeight int
+	// reorgHeight is the height of the first header replaced in the last reorg, or -1 if there was
+	// none yet.
+	reorgHeight int
 
 	eventCallbacks []func(Event)
 
@@ -114,6 +134,10 @@ func NewHeaders(
 		kickChan:        make(chan struct{}, 1),
 		quitChan:        make(chan struct{}),
 
+		revertedHeaders:    map[int]chainhash.Hash{},
+		pendingReorgHeight: -1,
+		reorgHeight:        -1,
+
 		eventCallbacks: []func(Event){},
 	}
 }
@@ -171,6 +195,14 @@ func (headers *Headers) TipHeight() int {
 	return headers.targetHeight
 }
 
+// ReorgHeight returns the height of the first header which was replaced in the last chain
+// reorganization, or -1 if there was none since the start. Transactions at or above this height
+// might have vanished or moved to a different block. See EventReorg.
+func (headers *Headers) ReorgHeight() int {
+	defer headers.lock.RLock()()
+	return headers.reorgHeight
+}
+
 // Initialize starts the syncing process.
 func (headers *Headers) Initialize() {
 	headers.tipAtInitTime = headers.tip()
@@ -205,6 +237,10 @@ func (headers *Headers) download() {
 			// TODO
 			return
 		}
+		if headers.dataSaver && headers.targetHeight-tip > dataSaverMaxHeaders {
+			headers.log.Debugf("Data saver mode: deferring the catch up from %d to %d", tip, headers.targetHeight)
+			return
+		}
 		headersResult, err := headers.blockchain.Headers(tip+1, headers.headersPerBatch)
 		if err != nil {
 			// TODO
@@ -377,6 +413,21 @@ func (headers *Headers) reorg(db DBInterface, tip int) {
 	if newTip < -1 {
 		newTip = -1
 	}
+	// Remember the reverted headers to find out which of them are replaced by the new chain. If
+	// there are still some from a previous reorg, these are kept, as they belong to the chain we
+	// had before.
+	for height := newTip + 1; height <= tip; height++ {
+		if _, ok := headers.revertedHeaders[height]; ok {
+			continue
+		}
+		header, err := db.HeaderByHeight(height)
+		if err != nil {
+			panic(err)
+		}
+		if header != nil {
+			headers.revertedHeaders[height] = header.BlockHash()
+		}
+	}
 	if err := db.RevertTo(newTip); err != nil {
 		panic(err)
 	}
@@ -391,6 +442,25 @@ func (headers *Headers) notifyEvent(event Event) {
 	}
 }
 
+// finishReorg is called when the reverted headers of a reorg were re-downloaded, or when there are
+// no more headers. Reverted headers which were not re-downloaded are gone, i.e. the new chain is
+// shorter. Fires EventReorg if any header was replaced.
+func (headers *Headers) finishReorg() {
+	for height := range headers.revertedHeaders {
+		if headers.pendingReorgHeight == -1 || height < headers.pendingReorgHeight {
+			headers.pendingReorgHeight = height
+		}
+	}
+	headers.revertedHeaders = map[int]chainhash.Hash{}
+	if headers.pendingReorgHeight == -1 {
+		return
+	}
+	headers.reorgHeight = headers.pendingReorgHeight
+	headers.pendingReorgHeight = -1
+	headers.log.Infof("Reorg replaced the headers from height %d", headers.reorgHeight)
+	headers.notifyEvent(EventReorg)
+}
+
 func (headers *Headers) processBatch(
 	db DBInterface, tip int, blockHeaders []*wire.BlockHeader, max int) error {
 	for _, header := range blockHeaders {
@@ -407,12 +477,23 @@ func (headers *Headers) processBatch(
 		if err := db.PutHeader(tip, header); err != nil {
 			return err
 		}
+		if revertedHash, ok := headers.revertedHeaders[tip]; ok {
+			delete(headers.revertedHeaders, tip)
+			if revertedHash != header.BlockHash() &&
+				(headers.pendingReorgHeight == -1 || tip < headers.pendingReorgHeight) {
+				headers.pendingReorgHeight = tip
+			}
+		}
 	}
 	if err := db.Flush(); err != nil {
 		// Ignore error, not critical.
 		headers.log.WithError(err).Error("Failed to flush")
 	}
-	if len(blockHeaders) == min(max, headers.headersPerBatch) {
+	synced := len(blockHeaders) != min(max, headers.headersPerBatch)
+	if len(headers.revertedHeaders) == 0 || synced {
+		headers.finishReorg()
+	}
+	if !synced {
 		// Received max number of headers per batch, so there might be more.
 		headers.kick()
 		headers.log.Debugf("Syncing headers; tip: %d", tip)
@@ -443,6 +524,18 @@ func (headers *Headers) VerifiedHeaderByHeight(height int) (*wire.BlockHeader, e
 	return headers.db.HeaderByHeight(height)
 }
 
+// SetDataSaver enables or disables the data saver mode. In this mode, new headers are only
+// downloaded if at most dataSaverMaxHeaders are missing, so that catching up after a long time
+// offline is deferred until the mode is disabled again.
+func (headers *Headers) SetDataSaver(enabled bool) {
+	unlock := headers.lock.Lock()
+	headers.dataSaver = enabled
+	unlock()
+	if !enabled {
+		headers.kick()
+	}
+}
+
 func (headers *Headers) kick() {
 	select {
 	case headers.kickChan <- struct{}{}:
@@ -20,6 +20,7 @@ import (
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain/mocks"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 )
@@ -45,3 +46,105 @@ func TestClose(t *testing.T) {
 	}
 
 }
+
+// memDB is an in-memory DBInterface.
+type memDB struct {
+	dbMock
+	headers []*wire.BlockHeader
+}
+
+func (db *memDB) PutHeader(height int, header *wire.BlockHeader) error {
+	db.headers = append(db.headers[:height], header)
+	return nil
+}
+
+func (db *memDB) HeaderByHeight(height int) (*wire.BlockHeader, error) {
+	if height < 0 || height >= len(db.headers) {
+		return nil, nil
+	}
+	return db.headers[height], nil
+}
+
+func (db *memDB) RevertTo(tip int) error {
+	db.headers = db.headers[:tip+1]
+	return nil
+}
+
+func (db *memDB) Tip() (int, error) {
+	return len(db.headers) - 1, nil
+}
+
+// makeChain returns the headers of a chain starting at the genesis block. The headers from
+// forkHeight on are different for different forks.
+func makeChain(length int, forkHeight int, fork uint32) []*wire.BlockHeader {
+	chain := []*wire.BlockHeader{&chaincfg.RegressionNetParams.GenesisBlock.Header}
+	for height := 1; height < length; height++ {
+		header := &wire.BlockHeader{
+			PrevBlock: chain[height-1].BlockHash(),
+			Timestamp: time.Unix(int64(1500000000+height*600), 0),
+			Nonce:     uint32(height),
+		}
+		if height >= forkHeight {
+			header.Bits = fork
+		}
+		chain = append(chain, header)
+	}
+	return chain
+}
+
+func TestReorg(t *testing.T) {
+	db := &memDB{}
+	headers := NewHeaders(
+		&chaincfg.RegressionNetParams,
+		db,
+		&mocks.BlockchainMock{},
+		(&logrus.Logger{}).WithField("group", "headers_test"),
+	)
+	events := make(chan Event, 10)
+	headers.SubscribeEvent(func(event Event) { events <- event })
+	waitFor := func(expected Event) {
+		for {
+			select {
+			case event := <-events:
+				if event == expected {
+					return
+				}
+			case <-time.After(5 * time.Second):
+				require.Fail(t, "event not fired", expected)
+				return
+			}
+		}
+	}
+	const max = 2016
+
+	chain := makeChain(150, 150, 0)
+	require.NoError(t, headers.processBatch(db, -1, chain, max))
+	waitFor(EventSynced)
+	require.Equal(t, -1, headers.ReorgHeight())
+
+	// The server now follows a longer chain forking at height 140. The first new header does not
+	// connect, so the headers are reverted and downloaded again.
+	newChain := makeChain(152, 140, 1)
+	require.NoError(t, headers.processBatch(db, 149, newChain[150:], max))
+	tip, err := db.Tip()
+	require.NoError(t, err)
+	require.Equal(t, 149-reorgLimit, tip)
+	require.Equal(t, -1, headers.ReorgHeight())
+
+	require.NoError(t, headers.processBatch(db, tip, newChain[tip+1:], max))
+	waitFor(EventReorg)
+	require.Equal(t, 140, headers.ReorgHeight())
+	require.Equal(t, newChain, db.headers)
+
+	// A reverted chain which is downloaded again unchanged is not a reorg.
+	headers.reorg(db, 151)
+	require.NoError(t, headers.processBatch(db, 151-reorgLimit, newChain[152-reorgLimit:], max))
+	waitFor(EventSynced)
+	require.Equal(t, 140, headers.ReorgHeight())
+
+	// The new chain is shorter: the headers which are not downloaded again are gone.
+	headers.reorg(db, 151)
+	require.NoError(t, headers.processBatch(db, 151-reorgLimit, newChain[152-reorgLimit:150], max))
+	waitFor(EventReorg)
+	require.Equal(t, 150, headers.ReorgHeight())
+}
@@ -16,6 +16,20 @@ func (_m *Interface) Initialize() {
 	_m.Called()
 }
 
+// ReorgHeight provides a mock function with given fields:
+func (_m *Interface) ReorgHeight() int {
+	ret := _m.Called()
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
 // Status provides a mock function with given fields:
 func (_m *Interface) Status() (*headers.Status, error) {
 	ret := _m.Called()
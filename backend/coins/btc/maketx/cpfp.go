@@ -0,0 +1,100 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx
+
+import (
+	mrand "math/rand"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sirupsen/logrus"
+)
+
+// NewTxCPFP creates a transaction which spends the given unconfirmed outputs of a parent
+// transaction to the change address (child-pays-for-parent). The fee is chosen such that the
+// parent and the child together pay the given fee rate, so that miners are incentivized to include
+// both. As the parent pays less than the fee rate, the child pays more than the fee rate for
+// itself.
+//
+// parentVSize and parentFee are the virtual size and the fee of the parent transaction.
+func NewTxCPFP(
+	coin coinpkg.Coin,
+	parentVSize int,
+	parentFee btcutil.Amount,
+	parentOutputs map[wire.OutPoint]UTXO,
+	changeAddress *addresses.AccountAddress,
+	feePerKb btcutil.Amount,
+	log *logrus.Entry,
+) (*TxProposal, error) {
+	if len(parentOutputs) == 0 {
+		return nil, errp.WithStack(errors.ErrInsufficientFunds)
+	}
+	if feePerKb*btcutil.Amount(parentVSize) <= parentFee*1000 {
+		return nil, errp.WithStack(errors.ErrFeeBumpTooLow)
+	}
+	selectedOutPoints := make([]wire.OutPoint, 0, len(parentOutputs))
+	for outPoint := range parentOutputs {
+		selectedOutPoints = append(selectedOutPoints, outPoint)
+	}
+	inputs := make([]*wire.TxIn, len(selectedOutPoints))
+	previousOutputs := make(PreviousOutputs, len(selectedOutPoints))
+	outputsSum := btcutil.Amount(0)
+	for i, outPoint := range selectedOutPoints {
+		outPoint := outPoint // avoids referencing the same variable across loop iterations
+		inputs[i] = wire.NewTxIn(&outPoint, nil, nil)
+		previousOutputs[outPoint] = &transactions.SpendableOutput{
+			TxOut: parentOutputs[outPoint].TxOut,
+		}
+		outputsSum += btcutil.Amount(parentOutputs[outPoint].TxOut.Value)
+	}
+
+	changePkScript := changeAddress.PubkeyScript()
+	childVSize := estimateTxSize(
+		toInputConfigurations(parentOutputs, selectedOutPoints),
+		len(changePkScript),
+		0)
+	fee := feeForSerializeSize(feePerKb, parentVSize+childVSize, log) - parentFee
+	amount := outputsSum - fee
+	if amount <= 0 || isDustAmount(amount, len(changePkScript), changeAddress.Configuration, feePerKb) {
+		return nil, errp.WithStack(errors.ErrInsufficientFunds)
+	}
+	unsignedTransaction := &wire.MsgTx{
+		Version:  wire.TxVersion,
+		TxIn:     inputs,
+		TxOut:    []*wire.TxOut{wire.NewTxOut(int64(amount), changePkScript)},
+		LockTime: 0,
+	}
+
+	secureRand := mrand.New(mrand.NewSource(secureSeed()))
+	shuffleTxInputsAndOutputs(unsignedTransaction, secureRand)
+
+	log.WithFields(logrus.Fields{"parent-fee": parentFee, "fee": fee}).
+		Debug("Preparing child-pays-for-parent transaction")
+
+	setRBF(coin, unsignedTransaction)
+	return &TxProposal{
+		Coin:            coin,
+		Amount:          amount,
+		Fee:             fee,
+		Transaction:     unsignedTransaction,
+		ChangeAddress:   changeAddress,
+		PreviousOutputs: previousOutputs,
+	}, nil
+}
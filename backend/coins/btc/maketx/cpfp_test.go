@@ -0,0 +1,83 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx_test
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	addressesTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses/test"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTxCPFP(t *testing.T) {
+	log := logging.Get().WithGroup("cpfpTest")
+	inputConfiguration, addressChain := addressesTest.NewAddressChain(
+		func(address *addresses.AccountAddress) (bool, error) {
+			return false, nil
+		},
+	)
+	someAddresses, err := addressChain.EnsureAddresses()
+	require.NoError(t, err)
+	changeAddress := someAddresses[0]
+
+	parentHash := chainhash.HashH([]byte(`parent-tx`))
+	parentOutputs := map[wire.OutPoint]maketx.UTXO{
+		{Hash: parentHash, Index: 0}: {
+			TxOut:         wire.NewTxOut(30000, someAddresses[1].PubkeyScript()),
+			Configuration: inputConfiguration,
+		},
+		{Hash: parentHash, Index: 2}: {
+			TxOut:         wire.NewTxOut(20000, someAddresses[2].PubkeyScript()),
+			Configuration: inputConfiguration,
+		},
+	}
+	const parentVSize = 200
+
+	// The parent pays 1 sat/vB, the package 20 sat/vB.
+	txProposal, err := maketx.NewTxCPFP(tbtc, parentVSize, 200, parentOutputs, changeAddress, 20000, log)
+	require.NoError(t, err)
+	childVSize := btcutil.Amount((maketx.EstimateWeight(txProposal.Transaction, parentOutputs) + 3) / 4)
+	require.Equal(t, 20*(parentVSize+childVSize)-200, txProposal.Fee)
+	require.Equal(t, 50000-txProposal.Fee, txProposal.Amount)
+	require.Equal(t, changeAddress, txProposal.ChangeAddress)
+	require.Len(t, txProposal.Transaction.TxIn, 2)
+	require.Len(t, txProposal.Transaction.TxOut, 1)
+	require.Equal(t, changeAddress.PubkeyScript(), txProposal.Transaction.TxOut[0].PkScript)
+	require.Equal(t, int64(txProposal.Amount), txProposal.Transaction.TxOut[0].Value)
+	for _, txIn := range txProposal.Transaction.TxIn {
+		require.Contains(t, parentOutputs, txIn.PreviousOutPoint)
+		require.Equal(t, uint32(wire.MaxTxInSequenceNum-2), txIn.Sequence)
+	}
+
+	// The parent already pays the target fee rate.
+	_, err = maketx.NewTxCPFP(tbtc, parentVSize, 4000, parentOutputs, changeAddress, 20000, log)
+	require.Equal(t, errors.ErrFeeBumpTooLow, errp.Cause(err))
+
+	// The outputs can't pay for the parent.
+	_, err = maketx.NewTxCPFP(tbtc, parentVSize, 200, parentOutputs, changeAddress, 300000, log)
+	require.Equal(t, errors.ErrInsufficientFunds, errp.Cause(err))
+
+	_, err = maketx.NewTxCPFP(
+		tbtc, parentVSize, 200, map[wire.OutPoint]maketx.UTXO{}, changeAddress, 20000, log)
+	require.Equal(t, errors.ErrInsufficientFunds, errp.Cause(err))
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sirupsen/logrus"
+)
+
+// signalsRBF returns true if the tx opts in to be replaceable, see
+// https://github.com/bitcoin/bips/blob/master/bip-0125.mediawiki#summary.
+func signalsRBF(tx *wire.MsgTx) bool {
+	for _, txIn := range tx.TxIn {
+		if txIn.Sequence < wire.MaxTxInSequenceNum-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredFeeBumpFee returns the fee a replacement of the given vsize must pay, which is the fee at
+// the given fee rate, but at least the fee of the replaced tx plus the relay fee of the
+// replacement (BIP125 rules 3 and 4).
+func requiredFeeBumpFee(
+	vsize int,
+	previousFee btcutil.Amount,
+	feePerKb btcutil.Amount,
+	minRelayFeePerKb btcutil.Amount,
+	log *logrus.Entry,
+) btcutil.Amount {
+	fee := feeForSerializeSize(feePerKb, vsize, log)
+	if minFee := previousFee + feeForSerializeSize(minRelayFeePerKb, vsize, log); fee < minFee {
+		return minFee
+	}
+	return fee
+}
+
+// NewTxFeeBump creates a transaction which replaces the given unconfirmed transaction using RBF,
+// paying the given fee rate. It spends the same inputs and pays the same recipients. The fee
+// increase is deducted from the change output, which is dropped if it becomes dust. A transaction
+// without change can only be bumped if it has a single output, e.g. when all coins were sent, in
+// which case the increase is deducted from that output.
+//
+// spentOutputs: the outputs spent by the inputs of the transaction.
+//
+// changeAddress: the address of the change output of the transaction, or nil if it has none.
+func NewTxFeeBump(
+	coin coinpkg.Coin,
+	tx *wire.MsgTx,
+	spentOutputs map[wire.OutPoint]UTXO,
+	changeAddress *addresses.AccountAddress,
+	feePerKb btcutil.Amount,
+	minRelayFeePerKb btcutil.Amount,
+	log *logrus.Entry,
+) (*TxProposal, error) {
+	if !supportsRBF(coin) || !signalsRBF(tx) {
+		return nil, errp.WithStack(errors.ErrFeeBumpUnavailable)
+	}
+	unsignedTransaction := tx.Copy()
+	previousOutputs := make(PreviousOutputs, len(unsignedTransaction.TxIn))
+	inputsSum := btcutil.Amount(0)
+	for _, txIn := range unsignedTransaction.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+		spentOutput, ok := spentOutputs[txIn.PreviousOutPoint]
+		if !ok {
+			return nil, errp.WithStack(errors.ErrFeeBumpUnavailable)
+		}
+		inputsSum += btcutil.Amount(spentOutput.TxOut.Value)
+		previousOutputs[txIn.PreviousOutPoint] = &transactions.SpendableOutput{
+			TxOut: spentOutput.TxOut,
+		}
+	}
+	outputsSum := btcutil.Amount(0)
+	changeIndex := -1
+	for index, txOut := range unsignedTransaction.TxOut {
+		outputsSum += btcutil.Amount(txOut.Value)
+		if changeAddress != nil && string(txOut.PkScript) == string(changeAddress.PubkeyScript()) {
+			changeIndex = index
+		}
+	}
+	if changeIndex < 0 {
+		changeAddress = nil
+	}
+	previousFee := inputsSum - outputsSum
+	vsize := (EstimateWeight(unsignedTransaction, spentOutputs) + 3) / 4
+	if feePerKb*btcutil.Amount(vsize) <= previousFee*1000 {
+		return nil, errp.WithStack(errors.ErrFeeBumpTooLow)
+	}
+	fee := requiredFeeBumpFee(vsize, previousFee, feePerKb, minRelayFeePerKb, log)
+	feeIncrease := fee - previousFee
+
+	switch {
+	case changeIndex >= 0:
+		changeOutput := unsignedTransaction.TxOut[changeIndex]
+		changeAmount := btcutil.Amount(changeOutput.Value) - feeIncrease
+		if changeAmount > 0 && !isDustAmount(
+			changeAmount, len(changeOutput.PkScript), changeAddress.Configuration, feePerKb) {
+			changeOutput.Value = int64(changeAmount)
+			break
+		}
+		if len(unsignedTransaction.TxOut) == 1 {
+			return nil, errp.WithStack(errors.ErrInsufficientFunds)
+		}
+		log.Info("change is dust")
+		unsignedTransaction.TxOut = append(
+			unsignedTransaction.TxOut[:changeIndex], unsignedTransaction.TxOut[changeIndex+1:]...)
+		changeAddress = nil
+		fee = previousFee + btcutil.Amount(changeOutput.Value)
+		vsize = (EstimateWeight(unsignedTransaction, spentOutputs) + 3) / 4
+		if fee < requiredFeeBumpFee(vsize, previousFee, feePerKb, minRelayFeePerKb, log) {
+			return nil, errp.WithStack(errors.ErrInsufficientFunds)
+		}
+	case len(unsignedTransaction.TxOut) == 1:
+		output := unsignedTransaction.TxOut[0]
+		if btcutil.Amount(output.Value) <= feeIncrease {
+			return nil, errp.WithStack(errors.ErrInsufficientFunds)
+		}
+		output.Value -= int64(feeIncrease)
+	default:
+		return nil, errp.WithStack(errors.ErrInsufficientFunds)
+	}
+
+	amount := btcutil.Amount(0)
+	for _, txOut := range unsignedTransaction.TxOut {
+		if changeAddress == nil || string(txOut.PkScript) != string(changeAddress.PubkeyScript()) {
+			amount += btcutil.Amount(txOut.Value)
+		}
+	}
+
+	log.WithFields(logrus.Fields{"previous-fee": previousFee, "fee": fee}).
+		Debug("Preparing fee bump transaction")
+
+	return &TxProposal{
+		Coin:            coin,
+		Amount:          amount,
+		Fee:             fee,
+		Transaction:     unsignedTransaction,
+		ChangeAddress:   changeAddress,
+		PreviousOutputs: previousOutputs,
+	}, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx_test
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	addressesTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses/test"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTxFeeBump(t *testing.T) {
+	log := logging.Get().WithGroup("feeBumpTest")
+	inputConfiguration, addressChain := addressesTest.NewAddressChain(
+		func(address *addresses.AccountAddress) (bool, error) {
+			return false, nil
+		},
+	)
+	someAddresses, err := addressChain.EnsureAddresses()
+	require.NoError(t, err)
+	changeAddress := someAddresses[0]
+	recipientPkScript := someAddresses[1].PubkeyScript()
+
+	outPoint := wire.OutPoint{Hash: chainhash.HashH([]byte(`some-tx`)), Index: 0}
+	spentOutputs := map[wire.OutPoint]maketx.UTXO{
+		outPoint: {
+			TxOut:         wire.NewTxOut(100000, someAddresses[2].PubkeyScript()),
+			Configuration: inputConfiguration,
+		},
+	}
+	newTx := func(sequence uint32, outputs ...*wire.TxOut) *wire.MsgTx {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+		tx.TxIn[0].Sequence = sequence
+		tx.TxIn[0].Witness = wire.TxWitness{[]byte{1}, []byte{2}}
+		for _, output := range outputs {
+			tx.AddTxOut(output)
+		}
+		return tx
+	}
+	const rbfSequence = wire.MaxTxInSequenceNum - 2
+	vsize := func(tx *wire.MsgTx) btcutil.Amount {
+		return btcutil.Amount((maketx.EstimateWeight(tx, spentOutputs) + 3) / 4)
+	}
+
+	t.Run("change", func(t *testing.T) {
+		tx := newTx(rbfSequence,
+			wire.NewTxOut(50000, recipientPkScript),
+			wire.NewTxOut(49000, changeAddress.PubkeyScript()))
+		txProposal, err := maketx.NewTxFeeBump(tbtc, tx, spentOutputs, changeAddress, 20000, 1000, log)
+		require.NoError(t, err)
+		expectedFee := 20 * vsize(txProposal.Transaction)
+		require.Equal(t, expectedFee, txProposal.Fee)
+		require.Equal(t, btcutil.Amount(50000), txProposal.Amount)
+		require.Equal(t, changeAddress, txProposal.ChangeAddress)
+		bumped := txProposal.Transaction
+		require.Equal(t, outPoint, bumped.TxIn[0].PreviousOutPoint)
+		require.Equal(t, uint32(rbfSequence), bumped.TxIn[0].Sequence)
+		require.Nil(t, bumped.TxIn[0].Witness)
+		require.Equal(t, int64(50000), bumped.TxOut[0].Value)
+		require.Equal(t, int64(50000)-int64(expectedFee), bumped.TxOut[1].Value)
+		require.Contains(t, txProposal.PreviousOutputs, outPoint)
+		// The original tx is not modified.
+		require.Equal(t, int64(49000), tx.TxOut[1].Value)
+	})
+
+	t.Run("min relay fee", func(t *testing.T) {
+		// The fee rate is barely higher, but the replacement must pay for its own relay on top of
+		// the previous fee.
+		tx := newTx(rbfSequence,
+			wire.NewTxOut(50000, recipientPkScript),
+			wire.NewTxOut(0, changeAddress.PubkeyScript()))
+		// 1 sat/vB.
+		previousFee := vsize(tx)
+		tx.TxOut[1].Value = 50000 - int64(previousFee)
+		txProposal, err := maketx.NewTxFeeBump(tbtc, tx, spentOutputs, changeAddress, 1100, 1000, log)
+		require.NoError(t, err)
+		require.Equal(t, previousFee+vsize(txProposal.Transaction), txProposal.Fee)
+	})
+
+	t.Run("change becomes dust", func(t *testing.T) {
+		tx := newTx(rbfSequence,
+			wire.NewTxOut(98000, recipientPkScript),
+			wire.NewTxOut(1000, changeAddress.PubkeyScript()))
+		txProposal, err := maketx.NewTxFeeBump(tbtc, tx, spentOutputs, changeAddress, 5000, 1000, log)
+		require.NoError(t, err)
+		require.Len(t, txProposal.Transaction.TxOut, 1)
+		require.Nil(t, txProposal.ChangeAddress)
+		require.Equal(t, btcutil.Amount(2000), txProposal.Fee)
+		require.Equal(t, btcutil.Amount(98000), txProposal.Amount)
+
+		// Dropping the change does not cover the new fee.
+		_, err = maketx.NewTxFeeBump(tbtc, tx, spentOutputs, changeAddress, 50000, 1000, log)
+		require.Equal(t, errors.ErrInsufficientFunds, errp.Cause(err))
+	})
+
+	t.Run("send all", func(t *testing.T) {
+		tx := newTx(rbfSequence, wire.NewTxOut(99000, recipientPkScript))
+		txProposal, err := maketx.NewTxFeeBump(tbtc, tx, spentOutputs, nil, 20000, 1000, log)
+		require.NoError(t, err)
+		expectedFee := 20 * vsize(txProposal.Transaction)
+		require.Equal(t, expectedFee, txProposal.Fee)
+		require.Equal(t, btcutil.Amount(100000)-expectedFee, txProposal.Amount)
+	})
+
+	t.Run("multiple recipients without change", func(t *testing.T) {
+		tx := newTx(rbfSequence,
+			wire.NewTxOut(50000, recipientPkScript),
+			wire.NewTxOut(49000, someAddresses[3].PubkeyScript()))
+		_, err := maketx.NewTxFeeBump(tbtc, tx, spentOutputs, changeAddress, 20000, 1000, log)
+		require.Equal(t, errors.ErrInsufficientFunds, errp.Cause(err))
+	})
+
+	t.Run("fee rate not higher", func(t *testing.T) {
+		tx := newTx(rbfSequence,
+			wire.NewTxOut(50000, recipientPkScript),
+			wire.NewTxOut(40000, changeAddress.PubkeyScript()))
+		_, err := maketx.NewTxFeeBump(tbtc, tx, spentOutputs, changeAddress, 20000, 1000, log)
+		require.Equal(t, errors.ErrFeeBumpTooLow, errp.Cause(err))
+	})
+
+	t.Run("not replaceable", func(t *testing.T) {
+		outputs := []*wire.TxOut{
+			wire.NewTxOut(50000, recipientPkScript),
+			wire.NewTxOut(49000, changeAddress.PubkeyScript()),
+		}
+		_, err := maketx.NewTxFeeBump(
+			tbtc, newTx(wire.MaxTxInSequenceNum, outputs...), spentOutputs, changeAddress, 20000, 1000, log)
+		require.Equal(t, errors.ErrFeeBumpUnavailable, errp.Cause(err))
+		// Litecoin does not have RBF.
+		_, err = maketx.NewTxFeeBump(
+			tltc, newTx(rbfSequence, outputs...), spentOutputs, changeAddress, 20000, 1000, log)
+		require.Equal(t, errors.ErrFeeBumpUnavailable, errp.Cause(err))
+		// Spends a coin which is not ours.
+		_, err = maketx.NewTxFeeBump(
+			tbtc, newTx(rbfSequence, outputs...), map[wire.OutPoint]maketx.UTXO{}, changeAddress, 20000, 1000, log)
+		require.Equal(t, errors.ErrFeeBumpUnavailable, errp.Cause(err))
+	})
+}
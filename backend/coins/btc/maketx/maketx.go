@@ -48,8 +48,11 @@ type TxProposal struct {
 	// Amount is the amount that is sent out. The fee is not included and is deducted on top.
 	Amount btcutil.Amount
 	// Fee is the mining fee used.
-	Fee         btcutil.Amount
-	Transaction *wire.MsgTx
+	Fee btcutil.Amount
+	// ChangeRoundingFee is the part of the fee that results from rounding the change amount. It is
+	// included in Fee.
+	ChangeRoundingFee btcutil.Amount
+	Transaction       *wire.MsgTx
 	// ChangeAddress is the address of the wallet to which the change of the transaction is sent.
 	ChangeAddress   *addresses.AccountAddress
 	PreviousOutputs PreviousOutputs
@@ -120,12 +123,17 @@ func toInputConfigurations(
 	return inputConfigurations
 }
 
+// supportsRBF returns true if the coin supports RBF (Replace-by-fee). Litecoin does not have RBF.
+func supportsRBF(coin coinpkg.Coin) bool {
+	return coin.Code() == coinpkg.CodeBTC ||
+		coin.Code() == coinpkg.CodeTBTC ||
+		coin.Code() == coinpkg.CodeRBTC
+}
+
 // Enable RBF (Replace-by-fee) for Bitcoin. Litecoin does not have RBF.
 func setRBF(coin coinpkg.Coin, tx *wire.MsgTx) {
 	for _, txIn := range tx.TxIn {
-		if coin.Code() == coinpkg.CodeBTC ||
-			coin.Code() == coinpkg.CodeTBTC ||
-			coin.Code() == coinpkg.CodeRBTC {
+		if supportsRBF(coin) {
 			// Enable RBF
 			// https://github.com/bitcoin/bips/blob/master/bip-0125.mediawiki#summary
 			// Locktime is also enabled by this (https://en.bitcoin.it/wiki/NLockTime), but we keep
@@ -187,16 +195,64 @@ func NewTxSpendAll(
 	}, nil
 }
 
+const (
+	// maxChangeRoundingFeeDivisor bounds the fee added by rounding the change to a fraction of the
+	// fee of the tx, see maxChangeRoundingFee().
+	maxChangeRoundingFeeDivisor = 10
+	// maxChangeRoundingFeeSat is the upper bound of the fee added by rounding the change.
+	maxChangeRoundingFeeSat btcutil.Amount = 1000
+)
+
+// maxChangeRoundingFee returns how much rounding the change may add to the fee of a tx: 10% of the
+// fee, but at most 1000 sat.
+func maxChangeRoundingFee(fee btcutil.Amount) btcutil.Amount {
+	maxExtraFee := fee / maxChangeRoundingFeeDivisor
+	if maxExtraFee > maxChangeRoundingFeeSat {
+		return maxChangeRoundingFeeSat
+	}
+	return maxExtraFee
+}
+
+// roundChangeAmount rounds the change amount down so that it has trailing zeros like the payment
+// amount. Otherwise, a round payment amount makes the change output trivially identifiable. The
+// number of trailing zeros is picked randomly, at most as many as the payment amount has, and such
+// that at most maxExtraFee is removed from the change. The change is returned unmodified if the
+// payment amount is not round.
+func roundChangeAmount(
+	changeAmount btcutil.Amount,
+	paymentAmount btcutil.Amount,
+	maxExtraFee btcutil.Amount,
+	secureRand *mrand.Rand,
+) btcutil.Amount {
+	units := []btcutil.Amount{}
+	for unit := btcutil.Amount(10); paymentAmount%unit == 0 && unit-1 <= maxExtraFee; unit *= 10 {
+		units = append(units, unit)
+	}
+	if len(units) == 0 {
+		return changeAmount
+	}
+	unit := units[secureRand.Intn(len(units))]
+	rounded := changeAmount - changeAmount%unit
+	if rounded <= 0 {
+		return changeAmount
+	}
+	return rounded
+}
+
 // NewTx creates a transaction from a set of unspent outputs, targeting an output value. A subset of
 // the unspent outputs is selected to cover the needed amount.
 //
 // changeAddress: a change output to this address is added if needed.
+//
+// roundChange: if true, the change amount is rounded to look like a payment amount, see
+// `roundChangeAmount()`. The difference, at most `maxChangeRoundingFee()`, is added to the fee.
 func NewTx(
 	coin coinpkg.Coin,
 	spendableOutputs map[wire.OutPoint]UTXO,
 	output *wire.TxOut,
 	feePerKb btcutil.Amount,
 	changeAddress *addresses.AccountAddress,
+	roundChange bool,
 	log *logrus.Entry,
 ) (*TxProposal, error) {
 	targetAmount := btcutil.Amount(output.Value)
@@ -241,13 +297,26 @@ func NewTx(
 			TxOut:    outputs,
 			LockTime: 0,
 		}
+		secureRand := mrand.New(mrand.NewSource(secureSeed()))
+
 		changeAmount := selectedOutputsSum - targetAmount - maxRequiredFee
 		changeIsDust := isDustAmount(
 			changeAmount, len(changePKScript), changeAddress.Configuration, feePerKb)
 		finalFee := maxRequiredFee
+		changeRoundingFee := btcutil.Amount(0)
 		if changeIsDust {
 			log.Info("change is dust")
 			finalFee = selectedOutputsSum - targetAmount
+		} else if roundChange {
+			roundedChangeAmount := roundChangeAmount(
+				changeAmount, targetAmount, maxChangeRoundingFee(maxRequiredFee), secureRand)
+			// Rounding must not turn the change into dust.
+			if !isDustAmount(
+				roundedChangeAmount, len(changePKScript), changeAddress.Configuration, feePerKb) {
+				changeRoundingFee = changeAmount - roundedChangeAmount
+				changeAmount = roundedChangeAmount
+				finalFee += changeRoundingFee
+			}
 		}
 		if changeAmount != 0 && !changeIsDust {
 			unsignedTransaction.TxOut = append(unsignedTransaction.TxOut,
@@ -256,19 +325,19 @@ func NewTx(
 			changeAddress = nil
 		}
 
-		secureRand := mrand.New(mrand.NewSource(secureSeed()))
 		shuffleTxInputsAndOutputs(unsignedTransaction, secureRand)
 
 		log.WithField("fee", finalFee).Debug("Preparing transaction")
 
 		setRBF(coin, unsignedTransaction)
 		return &TxProposal{
-			Coin:            coin,
-			Amount:          targetAmount,
-			Fee:             finalFee,
-			Transaction:     unsignedTransaction,
-			ChangeAddress:   changeAddress,
-			PreviousOutputs: previousOutputs,
+			Coin:              coin,
+			Amount:            targetAmount,
+			Fee:               finalFee,
+			ChangeRoundingFee: changeRoundingFee,
+			Transaction:       unsignedTransaction,
+			ChangeAddress:     changeAddress,
+			PreviousOutputs:   previousOutputs,
 		}, nil
 	}
 }
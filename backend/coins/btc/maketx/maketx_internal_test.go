@@ -18,6 +18,7 @@ import (
 	"math/rand"
 	"testing"
 
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/stretchr/testify/require"
 )
@@ -58,3 +59,41 @@ func TestShuffleTxInputsAndOutputs(t *testing.T) {
 	require.Equal(t, expectedSortedIns, tx.TxIn, "The transaction inputs were not successfully shuffled.")
 	require.Equal(t, expectedSortedOuts, tx.TxOut, "The transaction outputs were not successfully shuffled.")
 }
+
+func TestRoundChangeAmount(t *testing.T) {
+	secureRand := rand.New(rand.NewSource(1))
+
+	// Payment amount is not round, change is not modified.
+	require.Equal(t, btcutil.Amount(123456),
+		roundChangeAmount(123456, 100001, 1000, secureRand))
+
+	for i := 0; i < 100; i++ {
+		rounded := roundChangeAmount(123456, 100000, 1000, secureRand)
+		// The change is rounded to at least 10 and at most 1000, as the extra fee is bounded by
+		// 1000.
+		require.Contains(t, []btcutil.Amount{123450, 123400, 123000}, rounded)
+	}
+
+	// Extra fee too low to allow rounding.
+	require.Equal(t, btcutil.Amount(123456),
+		roundChangeAmount(123456, 100000, 8, secureRand))
+
+	// Rounding would remove the whole change.
+	require.Equal(t, btcutil.Amount(5),
+		roundChangeAmount(5, 100000, 1000, secureRand))
+}
+
+func TestMaxChangeRoundingFee(t *testing.T) {
+	require.Equal(t, btcutil.Amount(0), maxChangeRoundingFee(9))
+	require.Equal(t, btcutil.Amount(28), maxChangeRoundingFee(282))
+	require.Equal(t, btcutil.Amount(1000), maxChangeRoundingFee(10000))
+	require.Equal(t, btcutil.Amount(1000), maxChangeRoundingFee(1000000))
+
+	// The rounding never adds more than the cap to the fee, so the change is rounded to at most
+	// 10 sat for a fee of 282 sat.
+	secureRand := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		rounded := roundChangeAmount(123456, 100000, maxChangeRoundingFee(282), secureRand)
+		require.Equal(t, btcutil.Amount(123450), rounded)
+	}
+}
@@ -29,7 +29,6 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
-	"github.com/BitBoxSwiss/bitbox-wallet-app/util/socksproxy"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -37,12 +36,13 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/proxy"
 )
 
 var noDust = btcutil.Amount(0)
 
-var tltc = btc.NewCoin(coin.CodeTLTC, "Litecoin Testnet", "TBTC", coin.BtcUnitDefault, &chaincfg.TestNet3Params, ".", []*config.ServerInfo{}, "", socksproxy.NewSocksProxy(false, ""))
-var tbtc = btc.NewCoin(coin.CodeTBTC, "Bitcoin Testnet", "TBTC", coin.BtcUnitDefault, &chaincfg.TestNet3Params, ".", []*config.ServerInfo{}, "https://blockstream.info/testnet/tx/", socksproxy.NewSocksProxy(false, ""))
+var tltc = btc.NewCoin(coin.CodeTLTC, "Litecoin Testnet", "TBTC", coin.BtcUnitDefault, &chaincfg.TestNet3Params, ".", []*config.ServerInfo{}, "", proxy.Direct)
+var tbtc = btc.NewCoin(coin.CodeTBTC, "Bitcoin Testnet", "TBTC", coin.BtcUnitDefault, &chaincfg.TestNet3Params, ".", []*config.ServerInfo{}, "https://blockstream.info/testnet/tx/", proxy.Direct)
 
 // For reference, tx vsizes assuming two outputs (normal + change), for N inputs:
 // 1 inputs: 226
@@ -108,6 +108,7 @@ func (s *newTxSuite) newTx(
 		s.output(amount),
 		feePerKb,
 		s.changeAddress,
+		false,
 		s.log,
 	)
 }
@@ -263,6 +264,19 @@ func (s *newTxSuite) TestNewTxDust() {
 	}
 }
 
+func (s *newTxSuite) TestNewTxRoundChange() {
+	feePerKb := btcutil.Amount(20000) // 20 sat / vbyte
+	for i := 0; i < 20; i++ {
+		txProposal, err := maketx.NewTx(
+			s.coin, s.buildUTXO(9876543), s.output(1000000), feePerKb, s.changeAddress, true, s.log)
+		require.NoError(s.T(), err)
+		requiredFee := txProposal.Fee - txProposal.ChangeRoundingFee
+		// The change is rounded, which adds at most 10% to the fee.
+		require.Positive(s.T(), txProposal.ChangeRoundingFee)
+		require.LessOrEqual(s.T(), txProposal.ChangeRoundingFee, requiredFee/10)
+	}
+}
+
 func (s *newTxSuite) TestNewTxInsufficientFunds() {
 	const mBTC = 100000
 	amount := btcutil.Amount(1000 * mBTC) // 1 BTC
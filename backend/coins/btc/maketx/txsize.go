@@ -44,10 +44,30 @@ var witnessV0Size = wire.VarIntSerializeSize(2) +
 	wire.VarIntSerializeSize(signatureSize) + signatureSize +
 	wire.VarIntSerializeSize(pubkeySize) + pubkeySize
 
+// multisigWitnessSize returns the size of the witness spending a P2WSH multisig output:
+// <empty> <threshold serialized sigs> <witness script>. The empty item is consumed by a bug of
+// OP_CHECKMULTISIG.
+func multisigWitnessSize(multisig *signing.BitcoinMultisig) int {
+	// OP_m <OP_DATA_33 pubkey>... OP_n OP_CHECKMULTISIG
+	witnessScriptSize := 1 + len(multisig.KeyInfos)*(1+pubkeySize) + 1 + 1
+	return wire.VarIntSerializeSize(uint64(multisig.Threshold+2)) +
+		wire.VarIntSerializeSize(0) +
+		multisig.Threshold*(wire.VarIntSerializeSize(signatureSize)+signatureSize) +
+		wire.VarIntSerializeSize(uint64(witnessScriptSize)) + witnessScriptSize
+}
+
 // sigScriptWitnessSize returns the maximum possible sigscript/witness size for a given address type.
 // If there is no witness, 0 is returned.
 func sigScriptWitnessSize(configuration *signing.Configuration) (int, int) {
-	switch configuration.ScriptType() {
+	if configuration.BitcoinMultisig != nil {
+		return 0, multisigWitnessSize(configuration.BitcoinMultisig)
+	}
+	return scriptTypeSigScriptWitnessSize(configuration.ScriptType())
+}
+
+// scriptTypeSigScriptWitnessSize is like sigScriptWitnessSize() for a singlesig script type.
+func scriptTypeSigScriptWitnessSize(scriptType signing.ScriptType) (int, int) {
+	switch scriptType {
 	case signing.ScriptTypeP2PKH:
 		// OP_DATA_72
 		// 72 bytes of signature data (including SIGHASH op)
@@ -70,6 +90,14 @@ func sigScriptWitnessSize(configuration *signing.Configuration) (int, int) {
 	}
 }
 
+// InputVSize returns the worst case virtual size of an input spending from the given address type,
+// i.e. how much it adds to the size of a transaction.
+func InputVSize(configuration *signing.Configuration) int {
+	sigScriptSize, witnessSize := sigScriptWitnessSize(configuration)
+	weight := 4*calcInputSize(sigScriptSize) + witnessSize
+	return (weight + 3) / 4
+}
+
 // estimateTxSize gives the worst case tx size estimate. The unit of the result is vbyte (virtual
 // bytes), for the purpose of fee calculation.
 // https://en.bitcoin.it/wiki/Weight_units
@@ -129,3 +157,99 @@ func estimateTxSize(
 	}
 	return txWeight/4 + 1
 }
+
+// pkScriptSize returns the size of the pkScript of an output of the given script type.
+func pkScriptSize(scriptType signing.ScriptType) int {
+	switch scriptType {
+	case signing.ScriptTypeP2PKH:
+		// OP_DUP OP_HASH160 OP_DATA_20 <20 bytes> OP_EQUALVERIFY OP_CHECKSIG
+		return 25
+	case signing.ScriptTypeP2WPKHP2SH:
+		// OP_HASH160 OP_DATA_20 <20 bytes> OP_EQUAL
+		return 23
+	case signing.ScriptTypeP2WPKH:
+		// OP_0 OP_DATA_20 <20 bytes>
+		return 22
+	case signing.ScriptTypeP2TR:
+		// OP_1 OP_DATA_32 <32 bytes>
+		return 34
+	case signing.ScriptTypeP2WSH:
+		// OP_0 OP_DATA_32 <32 bytes>
+		return 34
+	default:
+		panic("unknown address type")
+	}
+}
+
+// EstimateVSize gives the worst case virtual size of a transaction spending inputs of the given
+// script types and inputs spending outputs of the given configurations to outputs of the given
+// script types, e.g. to plan a consolidation or a batch payment. The size of multisig inputs
+// depends on their configuration, so they can only be given by configuration. Unlike
+// estimateTxSize(), no change output is added.
+func EstimateVSize(
+	inputScriptTypes []signing.ScriptType,
+	inputConfigurations []*signing.Configuration,
+	outputScriptTypes []signing.ScriptType,
+) int {
+	const (
+		versionSize  = 4
+		lockTimeSize = 4
+		nonWitness   = 4
+	)
+	numInputs := len(inputScriptTypes) + len(inputConfigurations)
+	weight := nonWitness * (versionSize + lockTimeSize +
+		wire.VarIntSerializeSize(uint64(numInputs)) +
+		wire.VarIntSerializeSize(uint64(len(outputScriptTypes))))
+	for _, scriptType := range outputScriptTypes {
+		weight += nonWitness * outputSize(pkScriptSize(scriptType))
+	}
+	inputSizes := make([][2]int, 0, numInputs)
+	for _, scriptType := range inputScriptTypes {
+		sigScriptSize, witnessSize := scriptTypeSigScriptWitnessSize(scriptType)
+		inputSizes = append(inputSizes, [2]int{sigScriptSize, witnessSize})
+	}
+	for _, configuration := range inputConfigurations {
+		sigScriptSize, witnessSize := sigScriptWitnessSize(configuration)
+		inputSizes = append(inputSizes, [2]int{sigScriptSize, witnessSize})
+	}
+	isSegwitTx := false
+	inputsWithoutWitness := 0
+	for _, inputSize := range inputSizes {
+		sigScriptSize, witnessSize := inputSize[0], inputSize[1]
+		weight += nonWitness*calcInputSize(sigScriptSize) + witnessSize
+		if witnessSize > 0 {
+			isSegwitTx = true
+		} else {
+			inputsWithoutWitness++
+		}
+	}
+	if isSegwitTx {
+		// Segwit marker and flag, and the empty witnesses of the inputs without one.
+		weight += 2 + inputsWithoutWitness*wire.VarIntSerializeSize(0)
+	}
+	return (weight + 3) / 4
+}
+
+// EstimateWeight gives the worst case weight of the given unsigned transaction once its inputs are
+// signed. utxos must contain the outputs spent by the transaction.
+func EstimateWeight(tx *wire.MsgTx, utxos map[wire.OutPoint]UTXO) int {
+	// The inputs of the unsigned transaction have empty sigScripts, which are encoded as one byte.
+	weight := 4 * tx.SerializeSizeStripped()
+	isSegwitTx := false
+	inputsWithoutWitness := 0
+	for _, txIn := range tx.TxIn {
+		sigScriptSize, witnessSize := sigScriptWitnessSize(utxos[txIn.PreviousOutPoint].Configuration)
+		weight += 4 * (wire.VarIntSerializeSize(uint64(sigScriptSize)) + sigScriptSize - 1)
+		weight += witnessSize
+		if witnessSize > 0 {
+			isSegwitTx = true
+		} else {
+			inputsWithoutWitness++
+		}
+	}
+	if isSegwitTx {
+		// Segwit marker and flag, and the empty witnesses of the inputs without one.
+		weight += 2 + inputsWithoutWitness*wire.VarIntSerializeSize(0)
+	}
+	return weight
+}
@@ -24,6 +24,7 @@ import (
 	addressesTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses/test"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/mempool"
 	"github.com/btcsuite/btcd/wire"
@@ -89,9 +90,10 @@ func testEstimateTxSize(
 			inputAddress := addressesTest.GetAddress(inputScriptType)
 			sigScript, witness := inputAddress.SignatureScript(sig)
 			tx.TxIn = append(tx.TxIn, &wire.TxIn{
-				SignatureScript: sigScript,
-				Witness:         witness,
-				Sequence:        0,
+				PreviousOutPoint: wire.OutPoint{Index: uint32(len(tx.TxIn))},
+				SignatureScript:  sigScript,
+				Witness:          witness,
+				Sequence:         0,
 			})
 			inputConfigurations = append(inputConfigurations, inputAddress.Configuration)
 		}
@@ -112,6 +114,14 @@ func testEstimateTxSize(
 		len(outputPkScript), changePkScriptSize)
 	require.Equal(t, mempool.GetTxVirtualSize(btcutil.NewTx(tx)), int64(estimatedSize))
 
+	unsignedTx := tx.Copy()
+	utxos := map[wire.OutPoint]UTXO{}
+	for index, txIn := range unsignedTx.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+		utxos[txIn.PreviousOutPoint] = UTXO{Configuration: inputConfigurations[index]}
+	}
+	require.Equal(t, blockchain.GetTransactionWeight(btcutil.NewTx(tx)), int64(EstimateWeight(unsignedTx, utxos)))
 }
 
 func TestSigScriptWitnessSize(t *testing.T) {
@@ -133,6 +143,36 @@ func TestSigScriptWitnessSize(t *testing.T) {
 	}
 }
 
+func TestSigScriptWitnessSizeMultisig(t *testing.T) {
+	signature := makeSig()
+	// Including the SIGHASH op.
+	sig := append(signature.SerializeDER(), 1)
+	for _, keys := range [][2]int{{1, 2}, {2, 3}, {3, 5}, {15, 15}} {
+		address := test.GetMultisigAddress(keys[0], keys[1])
+		_, witnessScript := address.ScriptForHashToSign()
+		witness := wire.TxWitness{nil}
+		for i := 0; i < keys[0]; i++ {
+			witness = append(witness, sig)
+		}
+		witness = append(witness, witnessScript)
+		sigScriptSize, witnessSize := sigScriptWitnessSize(address.Configuration)
+		require.Equal(t, 0, sigScriptSize)
+		require.Equal(t, witness.SerializeSize(), witnessSize, address.Configuration.String())
+	}
+	require.Len(t, test.GetMultisigAddress(2, 3).PubkeyScript(), pkScriptSize(signing.ScriptTypeP2WSH))
+}
+
+func TestInputVSize(t *testing.T) {
+	for scriptType, expected := range map[signing.ScriptType]int{
+		signing.ScriptTypeP2PKH:      148,
+		signing.ScriptTypeP2WPKHP2SH: 91,
+		signing.ScriptTypeP2WPKH:     68,
+		signing.ScriptTypeP2TR:       58,
+	} {
+		require.Equal(t, expected, InputVSize(test.GetAddress(scriptType).Configuration), scriptType)
+	}
+}
+
 func TestEstimateTxSize(t *testing.T) {
 	scriptTypes := []signing.ScriptType{
 		signing.ScriptTypeP2PKH,
@@ -156,3 +196,42 @@ func TestEstimateTxSize(t *testing.T) {
 		}
 	}
 }
+
+func TestPkScriptSize(t *testing.T) {
+	for _, scriptType := range scriptTypes {
+		require.Len(t, test.GetAddress(scriptType).PubkeyScript(), pkScriptSize(scriptType), scriptType)
+	}
+}
+
+func TestEstimateVSize(t *testing.T) {
+	// Matches the estimate of a tx with one output and change.
+	for _, inputScriptTypes := range [][]signing.ScriptType{
+		{signing.ScriptTypeP2PKH},
+		{signing.ScriptTypeP2WPKH, signing.ScriptTypeP2WPKH},
+		scriptTypes,
+	} {
+		inputConfigurations := []*signing.Configuration{}
+		for _, scriptType := range inputScriptTypes {
+			inputConfigurations = append(inputConfigurations, test.GetAddress(scriptType).Configuration)
+		}
+		for _, outputScriptType := range scriptTypes {
+			require.Equal(t,
+				estimateTxSize(inputConfigurations,
+					pkScriptSize(outputScriptType), pkScriptSize(signing.ScriptTypeP2WPKH)),
+				EstimateVSize(inputScriptTypes, nil,
+					[]signing.ScriptType{outputScriptType, signing.ScriptTypeP2WPKH}))
+			require.Equal(t,
+				estimateTxSize(inputConfigurations,
+					pkScriptSize(outputScriptType), pkScriptSize(signing.ScriptTypeP2WPKH)),
+				EstimateVSize(nil, inputConfigurations,
+					[]signing.ScriptType{outputScriptType, signing.ScriptTypeP2WPKH}))
+		}
+	}
+	// A consolidation of ten P2WPKH inputs into one P2WPKH output.
+	inputScriptTypes := make([]signing.ScriptType, 10)
+	for i := range inputScriptTypes {
+		inputScriptTypes[i] = signing.ScriptTypeP2WPKH
+	}
+	require.Equal(t, 11+10*68+31,
+		EstimateVSize(inputScriptTypes, nil, []signing.ScriptType{signing.ScriptTypeP2WPKH}))
+}
@@ -0,0 +1,241 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package message verifies Bitcoin message signatures, as used to prove the ownership of an
+// address.
+package message
+
+import (
+	"bytes"
+	"encoding/base64"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Format is the format of a message signature.
+type Format string
+
+const (
+	// FormatLegacy is the signature format of Bitcoin Core's signmessage, extended by BIP137 and
+	// Electrum to segwit addresses.
+	FormatLegacy Format = "legacy"
+	// FormatBIP322 is the "simple" signature format of BIP322, a serialized witness stack.
+	FormatBIP322 Format = "bip322"
+)
+
+const (
+	// ErrInvalidAddress is returned if the address can't be decoded for the network.
+	ErrInvalidAddress errp.ErrorCode = "invalidAddress"
+	// ErrInvalidSignature is returned if the signature is malformed or does not match the address
+	// and message.
+	ErrInvalidSignature errp.ErrorCode = "invalidSignature"
+)
+
+const (
+	legacyMessageMagic = "Bitcoin Signed Message:\n"
+	bip322Tag          = "BIP0322-signed-message"
+
+	// compactSignatureSize is the size of a legacy signature: one header byte and the 64 byte
+	// signature.
+	compactSignatureSize = 65
+	// The header byte is 27 + recovery id, plus 4 for compressed P2PKH, 8 for P2WPKH-P2SH and 12
+	// for P2WPKH (BIP137).
+	compactHeaderMin           = 27
+	compactHeaderCompressedMin = 31
+	compactHeaderMax           = 42
+)
+
+// LegacyHash returns the hash signed by a legacy message signature.
+func LegacyHash(message []byte) []byte {
+	var buf bytes.Buffer
+	_ = wire.WriteVarString(&buf, 0, legacyMessageMagic)
+	_ = wire.WriteVarBytes(&buf, 0, message)
+	return chainhash.DoubleHashB(buf.Bytes())
+}
+
+// Verify verifies the base64 encoded signature of the message by the address. Both legacy and
+// BIP322 simple signatures are accepted. The format of the signature is returned if it is valid.
+func Verify(address string, message []byte, signature string, net *chaincfg.Params) (Format, error) {
+	decodedAddress, err := btcutil.DecodeAddress(address, net)
+	if err != nil || !decodedAddress.IsForNet(net) {
+		return "", errp.WithStack(ErrInvalidAddress)
+	}
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return "", errp.WithStack(ErrInvalidSignature)
+	}
+	if len(signatureBytes) == compactSignatureSize {
+		if err := VerifyLegacy(decodedAddress, message, signatureBytes); err == nil {
+			return FormatLegacy, nil
+		}
+	}
+	if err := VerifyBIP322(decodedAddress, message, signatureBytes); err != nil {
+		return "", err
+	}
+	return FormatBIP322, nil
+}
+
+// VerifyLegacy verifies a legacy signature. Like Electrum, the address type is taken from the
+// address, so a segwit address can be verified regardless of the header byte.
+func VerifyLegacy(address btcutil.Address, message []byte, signature []byte) error {
+	if len(signature) != compactSignatureSize ||
+		signature[0] < compactHeaderMin || signature[0] > compactHeaderMax {
+		return errp.WithStack(ErrInvalidSignature)
+	}
+	compactSignature := make([]byte, compactSignatureSize)
+	copy(compactSignature, signature)
+	if compactSignature[0] > compactHeaderCompressedMin+3 {
+		// Segwit headers are always for compressed keys.
+		compactSignature[0] = compactHeaderCompressedMin + (compactSignature[0]-compactHeaderMin)%4
+	}
+	publicKey, compressed, err := ecdsa.RecoverCompact(compactSignature, LegacyHash(message))
+	if err != nil {
+		return errp.WithStack(ErrInvalidSignature)
+	}
+	recoveredAddress, err := legacyAddress(address, publicKey, compressed)
+	if err != nil {
+		return err
+	}
+	if recoveredAddress.EncodeAddress() != address.EncodeAddress() {
+		return errp.WithStack(ErrInvalidSignature)
+	}
+	return nil
+}
+
+// legacyAddress returns the address of the public key of the same type as the given address.
+func legacyAddress(
+	address btcutil.Address, publicKey *btcec.PublicKey, compressed bool) (btcutil.Address, error) {
+	net := addressNet(address)
+	switch address.(type) {
+	case *btcutil.AddressPubKeyHash:
+		serializedPublicKey := publicKey.SerializeUncompressed()
+		if compressed {
+			serializedPublicKey = publicKey.SerializeCompressed()
+		}
+		return btcutil.NewAddressPubKeyHash(btcutil.Hash160(serializedPublicKey), net)
+	case *btcutil.AddressScriptHash:
+		witnessAddress, err := btcutil.NewAddressWitnessPubKeyHash(
+			btcutil.Hash160(publicKey.SerializeCompressed()), net)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		redeemScript, err := txscript.PayToAddrScript(witnessAddress)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		return btcutil.NewAddressScriptHash(redeemScript, net)
+	case *btcutil.AddressWitnessPubKeyHash:
+		return btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(publicKey.SerializeCompressed()), net)
+	default:
+		return nil, errp.Newf("legacy message signatures are not supported for %s",
+			address.EncodeAddress())
+	}
+}
+
+// addressNet returns the network parameters of the address, as the address types don't expose
+// them.
+func addressNet(address btcutil.Address) *chaincfg.Params {
+	for _, net := range []*chaincfg.Params{
+		&chaincfg.MainNetParams, &chaincfg.TestNet3Params, &chaincfg.RegressionNetParams,
+	} {
+		if address.IsForNet(net) {
+			return net
+		}
+	}
+	return &chaincfg.MainNetParams
+}
+
+// bip322ToSpend returns the virtual transaction committing to the message and the address, whose
+// output is spent by the signature (BIP322).
+func bip322ToSpend(messageHash *chainhash.Hash, pkScript []byte) (*wire.MsgTx, error) {
+	sigScript, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_0).
+		AddData(messageHash[:]).
+		Script()
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	toSpend := wire.NewMsgTx(0)
+	toSpend.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		SignatureScript:  sigScript,
+		Sequence:         0,
+	})
+	toSpend.AddTxOut(wire.NewTxOut(0, pkScript))
+	return toSpend, nil
+}
+
+// VerifyBIP322 verifies a BIP322 simple signature, which is the consensus encoded witness
+// spending the virtual output of the address. This covers all segwit addresses, including
+// taproot.
+func VerifyBIP322(address btcutil.Address, message []byte, signature []byte) error {
+	pkScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		return errp.WithStack(ErrInvalidAddress)
+	}
+	witness, err := readWitness(signature)
+	if err != nil {
+		return err
+	}
+	toSpend, err := bip322ToSpend(chainhash.TaggedHash([]byte(bip322Tag), message), pkScript)
+	if err != nil {
+		return err
+	}
+	toSign := wire.NewMsgTx(0)
+	toSign.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: toSpend.TxHash(), Index: 0},
+		Witness:          witness,
+		Sequence:         0,
+	})
+	toSign.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+
+	prevOutFetcher := txscript.NewCannedPrevOutputFetcher(pkScript, 0)
+	engine, err := txscript.NewEngine(
+		pkScript, toSign, 0, txscript.StandardVerifyFlags, nil,
+		txscript.NewTxSigHashes(toSign, prevOutFetcher), 0, prevOutFetcher)
+	if err != nil {
+		return errp.WithStack(ErrInvalidSignature)
+	}
+	if err := engine.Execute(); err != nil {
+		return errp.WithStack(ErrInvalidSignature)
+	}
+	return nil
+}
+
+// readWitness decodes a consensus encoded witness stack.
+func readWitness(serialized []byte) (wire.TxWitness, error) {
+	reader := bytes.NewReader(serialized)
+	count, err := wire.ReadVarInt(reader, 0)
+	if err != nil || count == 0 || count > uint64(len(serialized)) {
+		return nil, errp.WithStack(ErrInvalidSignature)
+	}
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(reader, 0, uint32(len(serialized)), "witness item")
+		if err != nil {
+			return nil, errp.WithStack(ErrInvalidSignature)
+		}
+	}
+	if reader.Len() != 0 {
+		return nil, errp.WithStack(ErrInvalidSignature)
+	}
+	return witness, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/message"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyBIP322(t *testing.T) {
+	// Test vectors from BIP322.
+	const address = "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l"
+	const emptySignature = "AkcwRAIgM2gBAQqvZX15ZiysmKmQpDrG83avLIT492QBzLnQIxYCIBaTpOaD20qRlEylyxFSeEA2ba9YOixpX8z46TSDtS40ASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="
+	const helloSignature = "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="
+	net := &chaincfg.MainNetParams
+
+	format, err := message.Verify(address, []byte(""), emptySignature, net)
+	require.NoError(t, err)
+	require.Equal(t, message.FormatBIP322, format)
+	format, err = message.Verify(address, []byte("Hello World"), helloSignature, net)
+	require.NoError(t, err)
+	require.Equal(t, message.FormatBIP322, format)
+
+	_, err = message.Verify(address, []byte("Hello World"), emptySignature, net)
+	require.Equal(t, message.ErrInvalidSignature, errp.Cause(err))
+	_, err = message.Verify(address, []byte(""), "AA==", net)
+	require.Equal(t, message.ErrInvalidSignature, errp.Cause(err))
+	_, err = message.Verify(address, []byte(""), "not base64", net)
+	require.Equal(t, message.ErrInvalidSignature, errp.Cause(err))
+	_, err = message.Verify(address, []byte(""), emptySignature, &chaincfg.TestNet3Params)
+	require.Equal(t, message.ErrInvalidAddress, errp.Cause(err))
+}
+
+func TestVerifyLegacy(t *testing.T) {
+	net := &chaincfg.TestNet3Params
+	privateKey, _ := btcec.PrivKeyFromBytes([]byte("012345678901234567890123456789ab"))
+	publicKeyHash := btcutil.Hash160(privateKey.PubKey().SerializeCompressed())
+
+	p2pkh, err := btcutil.NewAddressPubKeyHash(publicKeyHash, net)
+	require.NoError(t, err)
+	p2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(publicKeyHash, net)
+	require.NoError(t, err)
+	redeemScript, err := txscript.PayToAddrScript(p2wpkh)
+	require.NoError(t, err)
+	p2wpkhP2SH, err := btcutil.NewAddressScriptHash(redeemScript, net)
+	require.NoError(t, err)
+	uncompressedP2PKH, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(privateKey.PubKey().SerializeUncompressed()), net)
+	require.NoError(t, err)
+
+	msg := []byte("Hello World")
+	signature, err := ecdsa.SignCompact(privateKey, message.LegacyHash(msg), true)
+	require.NoError(t, err)
+	// The same signature with a BIP137 P2WPKH header.
+	segwitSignature := append([]byte{signature[0] + 8}, signature[1:]...)
+
+	for _, address := range []btcutil.Address{p2pkh, p2wpkh, p2wpkhP2SH} {
+		for _, sig := range [][]byte{signature, segwitSignature} {
+			format, err := message.Verify(
+				address.EncodeAddress(), msg, base64.StdEncoding.EncodeToString(sig), net)
+			require.NoError(t, err)
+			require.Equal(t, message.FormatLegacy, format)
+		}
+	}
+	require.Error(t, message.VerifyLegacy(uncompressedP2PKH, msg, signature))
+	require.Error(t, message.VerifyLegacy(p2wpkh, []byte("Hello"), signature))
+	require.Error(t, message.VerifyLegacy(p2wpkh, msg, signature[1:]))
+
+	uncompressedSignature, err := ecdsa.SignCompact(privateKey, message.LegacyHash(msg), false)
+	require.NoError(t, err)
+	require.NoError(t, message.VerifyLegacy(uncompressedP2PKH, msg, uncompressedSignature))
+	require.Error(t, message.VerifyLegacy(p2pkh, msg, uncompressedSignature))
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// PolicySource tells where the value of a network policy comes from.
+type PolicySource string
+
+const (
+	// PolicySourceServer means that the value was reported by the connected server.
+	PolicySourceServer PolicySource = "server"
+	// PolicySourceDefault means that the server does not report the value and the default of the
+	// reference node implementation is assumed.
+	PolicySourceDefault PolicySource = "default"
+)
+
+const (
+	// defaultMaxStandardTxWeight is MAX_STANDARD_TX_WEIGHT of Bitcoin Core and Litecoin Core.
+	defaultMaxStandardTxWeight = 400000
+	// defaultDustRelayFeeRateBTC is DUST_RELAY_TX_FEE of Bitcoin Core, in sat/kvB.
+	defaultDustRelayFeeRateBTC = 3000
+	// defaultDustRelayFeeRateLTC is DUST_RELAY_TX_FEE of Litecoin Core, in sat/kvB.
+	defaultDustRelayFeeRateLTC = 30000
+)
+
+// NetworkPolicies are the relay policies of the network which a transaction has to follow to be
+// accepted into the mempool. Fee rates are in sat/kvB.
+//
+// The Electrum protocol only exposes the min relay fee. The other policies are not configurable
+// in most nodes, so the defaults of the reference implementation are assumed.
+type NetworkPolicies struct {
+	MinRelayFeeRate           btcutil.Amount `json:"minRelayFeeRate"`
+	MinRelayFeeRateSource     PolicySource   `json:"minRelayFeeRateSource"`
+	DustRelayFeeRate          btcutil.Amount `json:"dustRelayFeeRate"`
+	DustRelayFeeRateSource    PolicySource   `json:"dustRelayFeeRateSource"`
+	MaxStandardTxWeight       int            `json:"maxStandardTxWeight"`
+	MaxStandardTxWeightSource PolicySource   `json:"maxStandardTxWeightSource"`
+}
+
+// NetworkPolicies queries the network policies from the connected server. The result is cached
+// until the connection to the server changes, so this can be called when validating every tx
+// proposal. The coin is initialized if needed, so it can be used without any account.
+func (coin *Coin) NetworkPolicies() (*NetworkPolicies, error) {
+	coin.Initialize()
+	defer coin.policiesLock.Lock()()
+	if coin.policies != nil {
+		policies := *coin.policies
+		return &policies, nil
+	}
+	minRelayFeeRate, err := coin.blockchain.RelayFee()
+	if err != nil {
+		return nil, err
+	}
+	dustRelayFeeRate := btcutil.Amount(defaultDustRelayFeeRateBTC)
+	switch coin.code {
+	case coinpkg.CodeLTC, coinpkg.CodeTLTC:
+		dustRelayFeeRate = defaultDustRelayFeeRateLTC
+	}
+	coin.policies = &NetworkPolicies{
+		MinRelayFeeRate:           minRelayFeeRate,
+		MinRelayFeeRateSource:     PolicySourceServer,
+		DustRelayFeeRate:          dustRelayFeeRate,
+		DustRelayFeeRateSource:    PolicySourceDefault,
+		MaxStandardTxWeight:       defaultMaxStandardTxWeight,
+		MaxStandardTxWeightSource: PolicySourceDefault,
+	}
+	coin.log.WithField("policies", *coin.policies).Info("network policies")
+	policies := *coin.policies
+	return &policies, nil
+}
+
+// resetNetworkPolicies clears the cached network policies, so they are queried again from the
+// newly connected server.
+func (coin *Coin) resetNetworkPolicies() {
+	defer coin.policiesLock.Lock()()
+	coin.policies = nil
+}
+
+// isDustOutput returns true if the output is worth less than what it costs to spend it at the
+// dust relay fee rate.
+func isDustOutput(txOut *wire.TxOut, dustRelayFeeRate btcutil.Amount) bool {
+	// mempool.IsDust expects the min relay fee, of which the dust threshold is three times.
+	return mempool.IsDust(txOut, dustRelayFeeRate/3)
+}
+
+// checkPolicies returns an error if the tx proposal would not be relayed by the network, because
+// it is too large or because it pays dust to the recipient.
+func checkPolicies(
+	txProposal *maketx.TxProposal,
+	utxos map[wire.OutPoint]maketx.UTXO,
+	policies *NetworkPolicies,
+) error {
+	for _, txOut := range txProposal.Transaction.TxOut {
+		if isDustOutput(txOut, policies.DustRelayFeeRate) {
+			return errp.WithStack(errors.ErrDustAmount)
+		}
+	}
+	if maketx.EstimateWeight(txProposal.Transaction, utxos) > policies.MaxStandardTxWeight {
+		return errp.WithStack(errors.ErrTxTooLarge)
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/util"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+// PrivacyIssueCode identifies a privacy issue of a transaction.
+type PrivacyIssueCode string
+
+const (
+	// PrivacyIssueAddressReuse means that some inputs are spent from addresses which received more
+	// than once, linking all payments to these addresses.
+	PrivacyIssueAddressReuse PrivacyIssueCode = "addressReuse"
+	// PrivacyIssueMergedClusters means that coins from several addresses are spent together,
+	// revealing that they have the same owner.
+	PrivacyIssueMergedClusters PrivacyIssueCode = "mergedClusters"
+	// PrivacyIssueRoundAmount means that the payment amount is round, so the change is the output
+	// with the non-round amount.
+	PrivacyIssueRoundAmount PrivacyIssueCode = "roundAmount"
+	// PrivacyIssueChangeDetectable means that the change has a different script type than the
+	// recipient, so the change is the output matching the inputs.
+	PrivacyIssueChangeDetectable PrivacyIssueCode = "changeDetectable"
+)
+
+const (
+	privacyPenaltyAddressReuse  = 30
+	privacyPenaltyMergedCluster = 10
+	privacyPenaltyMergedMax     = 30
+	privacyPenaltyRoundAmount   = 15
+	privacyPenaltyChangeType    = 20
+
+	// roundAmountUnit is the granularity in satoshi from which an amount counts as round, i.e.
+	// 0.001 BTC.
+	roundAmountUnit = 100000
+)
+
+// PrivacyIssue is a privacy issue of a transaction and how much it lowers the score.
+type PrivacyIssue struct {
+	Code        PrivacyIssueCode `json:"code"`
+	Penalty     int              `json:"penalty"`
+	Explanation string           `json:"explanation"`
+	// Addresses are the own addresses involved in the issue, if any.
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// PrivacyScore rates the privacy of a transaction from 0 (worst) to 100 (no known issues), using
+// simple chain analysis heuristics. It is meant as a hint, not a guarantee.
+type PrivacyScore struct {
+	Score  int             `json:"score"`
+	Issues []*PrivacyIssue `json:"issues"`
+}
+
+// privacyInput is an input of a transaction to be scored.
+type privacyInput struct {
+	address string
+	// receiveCount is the number of transactions which paid to the address of the input.
+	receiveCount int
+}
+
+// privacyTx contains what is needed to score a transaction.
+type privacyTx struct {
+	inputs []privacyInput
+	// amount is the payment amount. recipientScriptType is empty if the recipient address type is
+	// not known.
+	amount              btcutil.Amount
+	recipientScriptType signing.ScriptType
+	// hasChange is false if there is no change output. changeScriptType is only set if hasChange is
+	// true.
+	hasChange        bool
+	changeScriptType signing.ScriptType
+}
+
+// computePrivacyScore applies the privacy heuristics to a transaction.
+func computePrivacyScore(tx *privacyTx) *PrivacyScore {
+	issues := []*PrivacyIssue{}
+
+	reusedAddresses := []string{}
+	distinctAddresses := []string{}
+	seen := map[string]bool{}
+	for _, input := range tx.inputs {
+		if seen[input.address] {
+			continue
+		}
+		seen[input.address] = true
+		distinctAddresses = append(distinctAddresses, input.address)
+		if input.receiveCount > 1 {
+			reusedAddresses = append(reusedAddresses, input.address)
+		}
+	}
+	sort.Strings(reusedAddresses)
+	sort.Strings(distinctAddresses)
+
+	if len(reusedAddresses) > 0 {
+		issues = append(issues, &PrivacyIssue{
+			Code:    PrivacyIssueAddressReuse,
+			Penalty: privacyPenaltyAddressReuse,
+			Explanation: "Some coins are spent from addresses that received more than once. " +
+				"All payments to these addresses can be linked to this transaction.",
+			Addresses: reusedAddresses,
+		})
+	}
+	if len(distinctAddresses) > 1 {
+		penalty := privacyPenaltyMergedCluster * (len(distinctAddresses) - 1)
+		if penalty > privacyPenaltyMergedMax {
+			penalty = privacyPenaltyMergedMax
+		}
+		issues = append(issues, &PrivacyIssue{
+			Code:    PrivacyIssueMergedClusters,
+			Penalty: penalty,
+			Explanation: "Coins from several addresses are spent together. " +
+				"This reveals to everyone that these addresses belong to the same wallet.",
+			Addresses: distinctAddresses,
+		})
+	}
+	if tx.hasChange && tx.amount > 0 && tx.amount%roundAmountUnit == 0 {
+		issues = append(issues, &PrivacyIssue{
+			Code:    PrivacyIssueRoundAmount,
+			Penalty: privacyPenaltyRoundAmount,
+			Explanation: "The amount is round, which makes the change output easy to identify " +
+				"as the one with the non-round amount.",
+		})
+	}
+	if tx.hasChange && tx.recipientScriptType != "" && tx.changeScriptType != tx.recipientScriptType {
+		issues = append(issues, &PrivacyIssue{
+			Code:    PrivacyIssueChangeDetectable,
+			Penalty: privacyPenaltyChangeType,
+			Explanation: "The change uses a different address type than the recipient, " +
+				"which makes the change output easy to identify.",
+		})
+	}
+
+	score := 100
+	for _, issue := range issues {
+		score -= issue.Penalty
+	}
+	if score < 0 {
+		score = 0
+	}
+	return &PrivacyScore{Score: score, Issues: issues}
+}
+
+// txProposalPrivacyScore scores the privacy of a tx proposal.
+func (account *Account) txProposalPrivacyScore(txProposal *maketx.TxProposal) (*PrivacyScore, error) {
+	tx := &privacyTx{}
+	var changePkScript []byte
+	if txProposal.ChangeAddress != nil {
+		changePkScript = txProposal.ChangeAddress.PubkeyScript()
+	}
+	for _, txOut := range txProposal.Transaction.TxOut {
+		if changePkScript != nil && bytes.Equal(txOut.PkScript, changePkScript) {
+			tx.hasChange = true
+			tx.changeScriptType = txProposal.ChangeAddress.Configuration.ScriptType()
+			continue
+		}
+		tx.amount = btcutil.Amount(txOut.Value)
+		if address, err := util.AddressFromPkScript(txOut.PkScript, account.coin.Net()); err == nil {
+			if scriptType, ok := scriptTypeOfAddress(address); ok {
+				tx.recipientScriptType = scriptType
+			}
+		}
+	}
+
+	inputs, err := transactions.DBView(account.db, func(dbTx transactions.DBTxInterface) ([]privacyInput, error) {
+		inputs := []privacyInput{}
+		for _, txIn := range txProposal.Transaction.TxIn {
+			prevOut, ok := txProposal.PreviousOutputs[txIn.PreviousOutPoint]
+			if !ok {
+				continue
+			}
+			address := account.getAddress(blockchain.NewScriptHashHex(prevOut.PkScript))
+			if address == nil {
+				continue
+			}
+			receiveCount, err := addressReceiveCount(dbTx, address.PubkeyScriptHashHex(), address.PubkeyScript())
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, privacyInput{
+				address:      address.EncodeForHumans(),
+				receiveCount: receiveCount,
+			})
+		}
+		return inputs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	tx.inputs = inputs
+	return computePrivacyScore(tx), nil
+}
+
+// addressReceiveCount returns the number of transactions in the history of an address which pay to
+// it.
+func addressReceiveCount(
+	dbTx transactions.DBTxInterface,
+	scriptHashHex blockchain.ScriptHashHex,
+	pkScript []byte,
+) (int, error) {
+	history, err := dbTx.AddressHistory(scriptHashHex)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, entry := range history {
+		txInfo, err := dbTx.TxInfo(entry.TXHash.Hash())
+		if err != nil {
+			return 0, err
+		}
+		if txInfo == nil {
+			continue
+		}
+		for _, txOut := range txInfo.Tx.TxOut {
+			if bytes.Equal(txOut.PkScript, pkScript) {
+				count++
+				break
+			}
+		}
+	}
+	return count, nil
+}
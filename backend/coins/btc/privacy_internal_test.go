@@ -0,0 +1,88 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputePrivacyScore(t *testing.T) {
+	codes := func(score *PrivacyScore) []PrivacyIssueCode {
+		result := []PrivacyIssueCode{}
+		for _, issue := range score.Issues {
+			result = append(result, issue.Code)
+		}
+		return result
+	}
+
+	// A single fresh input, matching change and a non-round amount has no issues.
+	score := computePrivacyScore(&privacyTx{
+		inputs:              []privacyInput{{address: "a", receiveCount: 1}},
+		amount:              123456,
+		recipientScriptType: signing.ScriptTypeP2WPKH,
+		hasChange:           true,
+		changeScriptType:    signing.ScriptTypeP2WPKH,
+	})
+	require.Equal(t, 100, score.Score)
+	require.Empty(t, score.Issues)
+
+	// Without change, the amount and script types reveal nothing.
+	score = computePrivacyScore(&privacyTx{
+		inputs:              []privacyInput{{address: "a", receiveCount: 1}},
+		amount:              100000,
+		recipientScriptType: signing.ScriptTypeP2TR,
+	})
+	require.Equal(t, 100, score.Score)
+
+	score = computePrivacyScore(&privacyTx{
+		inputs: []privacyInput{
+			{address: "b", receiveCount: 2},
+			{address: "a", receiveCount: 1},
+			{address: "b", receiveCount: 2},
+		},
+		amount:              100000,
+		recipientScriptType: signing.ScriptTypeP2TR,
+		hasChange:           true,
+		changeScriptType:    signing.ScriptTypeP2WPKH,
+	})
+	require.Equal(t, []PrivacyIssueCode{
+		PrivacyIssueAddressReuse,
+		PrivacyIssueMergedClusters,
+		PrivacyIssueRoundAmount,
+		PrivacyIssueChangeDetectable,
+	}, codes(score))
+	require.Equal(t, []string{"b"}, score.Issues[0].Addresses)
+	require.Equal(t, []string{"a", "b"}, score.Issues[1].Addresses)
+	require.Equal(t, 10, score.Issues[1].Penalty)
+	require.Equal(t, 100-30-10-15-20, score.Score)
+
+	// The merged clusters penalty is capped and the score does not go below 0.
+	inputs := []privacyInput{}
+	for _, address := range []string{"a", "b", "c", "d", "e", "f"} {
+		inputs = append(inputs, privacyInput{address: address, receiveCount: 3})
+	}
+	score = computePrivacyScore(&privacyTx{
+		inputs:              inputs,
+		amount:              500000,
+		recipientScriptType: signing.ScriptTypeP2PKH,
+		hasChange:           true,
+		changeScriptType:    signing.ScriptTypeP2TR,
+	})
+	require.Equal(t, 30, score.Issues[1].Penalty)
+	require.Equal(t, 5, score.Score)
+}
@@ -0,0 +1,509 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// psbtFingerprint returns the root fingerprint as serialized in a PSBT.
+func psbtFingerprint(rootFingerprint []byte) uint32 {
+	if len(rootFingerprint) != 4 {
+		return 0
+	}
+	// PSBT serializes the fingerprint in little endian, so this keeps the byte order.
+	return binary.LittleEndian.Uint32(rootFingerprint)
+}
+
+// addPSBTDerivation adds the BIP32 derivation of the address, so that signers can find the key to
+// sign the input with, or verify that an output is change. For multisig addresses, the
+// derivations of the keys of all cosigners and the witness script are added.
+func addPSBTDerivation(
+	address *addresses.AccountAddress,
+	bip32Derivation *[]*psbt.Bip32Derivation,
+	taprootBip32Derivation *[]*psbt.TaprootBip32Derivation,
+	taprootInternalKey *[]byte,
+	redeemScript *[]byte,
+	witnessScript *[]byte,
+) {
+	if multisig := address.Configuration.BitcoinMultisig; multisig != nil {
+		*bip32Derivation = nil
+		for _, keyInfo := range multisig.KeyInfos {
+			publicKey, err := keyInfo.ExtendedPublicKey.ECPubKey()
+			if err != nil {
+				panic("Failed to convert an extended public key to a normal public key.")
+			}
+			*bip32Derivation = append(*bip32Derivation, &psbt.Bip32Derivation{
+				PubKey:               publicKey.SerializeCompressed(),
+				MasterKeyFingerprint: psbtFingerprint(keyInfo.RootFingerprint),
+				Bip32Path:            keyInfo.AbsoluteKeypath.ToUInt32(),
+			})
+		}
+		_, *witnessScript = address.ScriptForHashToSign()
+		return
+	}
+	fingerprint := psbtFingerprint(address.Configuration.BitcoinSimple.KeyInfo.RootFingerprint)
+	keypath := address.AbsoluteKeypath().ToUInt32()
+	publicKey := address.Configuration.PublicKey()
+	switch address.Configuration.ScriptType() {
+	case signing.ScriptTypeP2TR:
+		xOnlyPublicKey := schnorr.SerializePubKey(publicKey)
+		*taprootBip32Derivation = append(*taprootBip32Derivation, &psbt.TaprootBip32Derivation{
+			XOnlyPubKey:          xOnlyPublicKey,
+			MasterKeyFingerprint: fingerprint,
+			Bip32Path:            keypath,
+		})
+		*taprootInternalKey = xOnlyPublicKey
+	default:
+		*bip32Derivation = append(*bip32Derivation, &psbt.Bip32Derivation{
+			PubKey:               publicKey.SerializeCompressed(),
+			MasterKeyFingerprint: fingerprint,
+			Bip32Path:            keypath,
+		})
+		if address.Configuration.ScriptType() == signing.ScriptTypeP2WPKHP2SH {
+			_, *redeemScript = address.ScriptForHashToSign()
+		}
+	}
+}
+
+// newPSBT creates an unsigned BIP174 PSBT of the tx proposal. Except for Taproot inputs, the
+// previous transactions are included, as many signers require them to verify the input amounts.
+func newPSBT(
+	txProposal *maketx.TxProposal,
+	getAddress func(blockchain.ScriptHashHex) *addresses.AccountAddress,
+	getPrevTx func(chainhash.Hash) (*wire.MsgTx, error),
+) (*psbt.Packet, error) {
+	unsignedTx := txProposal.Transaction.Copy()
+	for _, txIn := range unsignedTx.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+	packet, err := psbt.NewFromUnsignedTx(unsignedTx)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	for index, txIn := range unsignedTx.TxIn {
+		spentOutput, ok := txProposal.PreviousOutputs[txIn.PreviousOutPoint]
+		if !ok {
+			return nil, errp.Newf("missing previous output of input %d", index)
+		}
+		address := getAddress(spentOutput.ScriptHashHex())
+		if address == nil {
+			return nil, errp.Newf("input %d does not spend an address of the account", index)
+		}
+		input := &packet.Inputs[index]
+		input.WitnessUtxo = spentOutput.TxOut
+		input.SighashType = txscript.SigHashAll
+		if address.Configuration.ScriptType() == signing.ScriptTypeP2TR {
+			input.SighashType = txscript.SigHashDefault
+		} else {
+			prevTx, err := getPrevTx(txIn.PreviousOutPoint.Hash)
+			if err != nil {
+				return nil, err
+			}
+			input.NonWitnessUtxo = prevTx
+		}
+		if address.Configuration.ScriptType() == signing.ScriptTypeP2PKH {
+			// Legacy inputs must not have a witness UTXO.
+			input.WitnessUtxo = nil
+		}
+		addPSBTDerivation(address, &input.Bip32Derivation, &input.TaprootBip32Derivation,
+			&input.TaprootInternalKey, &input.RedeemScript, &input.WitnessScript)
+	}
+	if changeAddress := txProposal.ChangeAddress; changeAddress != nil {
+		changeScript := changeAddress.PubkeyScript()
+		for index, txOut := range unsignedTx.TxOut {
+			if string(txOut.PkScript) != string(changeScript) {
+				continue
+			}
+			output := &packet.Outputs[index]
+			addPSBTDerivation(changeAddress, &output.Bip32Derivation, &output.TaprootBip32Derivation,
+				&output.TaprootInternalKey, &output.RedeemScript, &output.WitnessScript)
+		}
+	}
+	return packet, nil
+}
+
+// verifyPartialSig returns true if the partial signature of a multisig input is a valid signature
+// of the input by one of the keys of the witness script.
+func verifyPartialSig(
+	packet *psbt.Packet,
+	index int,
+	sigHashes *txscript.TxSigHashes,
+	amount int64,
+	partialSig *psbt.PartialSig,
+) bool {
+	witnessScript := packet.Inputs[index].WitnessScript
+	if len(partialSig.Signature) == 0 || !multisigScriptHasPublicKey(witnessScript, partialSig.PubKey) {
+		return false
+	}
+	hashType := txscript.SigHashType(partialSig.Signature[len(partialSig.Signature)-1])
+	expectedHashType := packet.Inputs[index].SighashType
+	if expectedHashType == 0 {
+		expectedHashType = txscript.SigHashAll
+	}
+	if hashType != expectedHashType {
+		return false
+	}
+	signature, err := ecdsa.ParseDERSignature(partialSig.Signature[:len(partialSig.Signature)-1])
+	if err != nil {
+		return false
+	}
+	publicKey, err := btcec.ParsePubKey(partialSig.PubKey)
+	if err != nil {
+		return false
+	}
+	sigHash, err := txscript.CalcWitnessSigHash(
+		witnessScript, sigHashes, hashType, packet.UnsignedTx, index, amount)
+	if err != nil {
+		return false
+	}
+	return signature.Verify(sigHash, publicKey)
+}
+
+// multisigScriptHasPublicKey returns true if the public key is one of the keys of the multisig
+// script.
+func multisigScriptHasPublicKey(script []byte, publicKey []byte) bool {
+	tokenizer := txscript.MakeScriptTokenizer(0, script)
+	for tokenizer.Next() {
+		if bytes.Equal(tokenizer.Data(), publicKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// multisigSignaturesComplete returns true if the multisig input has as many valid signatures as
+// required by its witness script. Signatures which don't verify against the input are dropped,
+// and so are additional signatures, as the input can only be finalized with exactly as many
+// signatures as required. The signatures are kept in order, so that our own signature, which
+// addPSBTSignatures() adds first, is kept.
+func multisigSignaturesComplete(
+	packet *psbt.Packet,
+	index int,
+	previousOutputs maketx.PreviousOutputs,
+	sigHashes *txscript.TxSigHashes,
+) (bool, error) {
+	input := &packet.Inputs[index]
+	_, numSignatures, err := txscript.CalcMultiSigStats(input.WitnessScript)
+	if err != nil {
+		return false, errp.WithMessage(errors.ErrPSBTInvalid, err.Error())
+	}
+	spentOutput, ok := previousOutputs[packet.UnsignedTx.TxIn[index].PreviousOutPoint]
+	if !ok {
+		return false, errp.WithStack(errors.ErrPSBTForeignInput)
+	}
+	partialSigs := []*psbt.PartialSig{}
+	for _, partialSig := range input.PartialSigs {
+		if verifyPartialSig(packet, index, sigHashes, spentOutput.Value, partialSig) {
+			partialSigs = append(partialSigs, partialSig)
+		}
+	}
+	input.PartialSigs = partialSigs
+	if len(input.PartialSigs) < numSignatures {
+		return false, nil
+	}
+	input.PartialSigs = input.PartialSigs[:numSignatures]
+	return true, nil
+}
+
+// isMultisigInput returns true if the input spends a multisig witness script.
+func isMultisigInput(input *psbt.PInput) bool {
+	return input.WitnessScript != nil &&
+		txscript.GetScriptClass(input.WitnessScript) == txscript.MultiSigTy
+}
+
+// isFinalized returns true if the input has a final sigScript or witness.
+func isFinalized(input *psbt.PInput) bool {
+	return input.FinalScriptSig != nil || input.FinalScriptWitness != nil
+}
+
+// finalizePSBT finalizes the signed inputs of the PSBT and returns the signed transaction. The
+// inputs must spend the given previous outputs, so that the amounts are not taken from the PSBT.
+func finalizePSBT(packet *psbt.Packet, previousOutputs maketx.PreviousOutputs) (*wire.MsgTx, error) {
+	for _, txIn := range packet.UnsignedTx.TxIn {
+		if _, ok := previousOutputs[txIn.PreviousOutPoint]; !ok {
+			return nil, errp.WithStack(errors.ErrPSBTForeignInput)
+		}
+	}
+	sigHashes := txscript.NewTxSigHashes(packet.UnsignedTx, previousOutputs)
+	for index, txIn := range packet.UnsignedTx.TxIn {
+		spentOutput := previousOutputs[txIn.PreviousOutPoint]
+		input := &packet.Inputs[index]
+		if !txscript.IsPayToPubKeyHash(spentOutput.PkScript) {
+			// The signatures are checked against our previous output below, not against the one in
+			// the PSBT.
+			input.WitnessUtxo = spentOutput.TxOut
+		}
+		if !isFinalized(input) && isMultisigInput(input) {
+			complete, err := multisigSignaturesComplete(packet, index, previousOutputs, sigHashes)
+			if err != nil {
+				return nil, err
+			}
+			if !complete {
+				return nil, errp.WithStack(errors.ErrPSBTIncomplete)
+			}
+		}
+		_, err := psbt.MaybeFinalize(packet, index)
+		if err == psbt.ErrNotFinalizable {
+			return nil, errp.WithStack(errors.ErrPSBTIncomplete)
+		}
+		if err != nil {
+			return nil, errp.WithMessage(errors.ErrPSBTInvalid, err.Error())
+		}
+	}
+	tx, err := psbt.Extract(packet)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := txValidityCheck(tx, previousOutputs, txscript.NewTxSigHashes(tx, previousOutputs)); err != nil {
+		return nil, errp.WithMessage(errors.ErrPSBTInvalid, err.Error())
+	}
+	return tx, nil
+}
+
+// ExportPSBT returns the active tx proposal, set by TxProposal(), as an unsigned base64 encoded
+// BIP174 PSBT, to be signed by external tools.
+func (account *Account) ExportPSBT() (string, error) {
+	unlock := account.activeTxProposalLock.RLock()
+	txProposal := account.activeTxProposal
+	unlock()
+	if txProposal == nil {
+		return "", errp.New("No active tx proposal")
+	}
+	packet, err := newPSBT(txProposal, account.getAddress, account.coin.Blockchain().TransactionGet)
+	if err != nil {
+		return "", err
+	}
+	encoded, err := packet.B64Encode()
+	if err != nil {
+		return "", errp.WithStack(err)
+	}
+	return encoded, nil
+}
+
+// ImportPSBT finalizes a base64 encoded PSBT signed by external tools and broadcasts the
+// transaction. All of its inputs must be spendable outputs of the account. Returns the transaction
+// ID.
+func (account *Account) ImportPSBT(encoded string) (string, error) {
+	if account.fatalError.Load() {
+		return "", errp.New("Can't import a PSBT of an account with a fatal error")
+	}
+	packet, err := psbt.NewFromRawBytes(strings.NewReader(strings.TrimSpace(encoded)), true)
+	if err != nil {
+		return "", errp.WithStack(errors.ErrPSBTInvalid)
+	}
+	spendableOutputs, err := account.transactions.SpendableOutputs()
+	if err != nil {
+		return "", err
+	}
+	tx, err := finalizePSBT(packet, maketx.PreviousOutputs(spendableOutputs))
+	if err != nil {
+		return "", err
+	}
+
+	account.log.Info("Transaction signed externally is broadcasted")
+	if err := account.coin.Blockchain().TransactionBroadcast(tx); err != nil {
+		return "", err
+	}
+	txID := tx.TxHash().String()
+	note := account.BaseAccount.GetAndClearProposedTxNote()
+	if err := account.SetTxNote(txID, note); err != nil {
+		// Not critical.
+		account.log.WithError(err).Error("Failed to save transaction note when importing a PSBT")
+	}
+	return txID, nil
+}
+
+// addPSBTSignatures adds the signatures of the proposed transaction, which must be the unsigned
+// transaction of the PSBT, to the PSBT. Singlesig inputs are finalized. Multisig inputs get our
+// signature as the first partial signature, followed by the ones of the cosigners, see
+// completePSBT().
+func addPSBTSignatures(packet *psbt.Packet, proposedTransaction *ProposedTransaction) error {
+	previousOutputs := proposedTransaction.TXProposal.PreviousOutputs
+	for index, txIn := range packet.UnsignedTx.TxIn {
+		spentOutput, ok := previousOutputs[txIn.PreviousOutPoint]
+		if !ok {
+			return errp.WithStack(errors.ErrPSBTForeignInput)
+		}
+		address := proposedTransaction.GetAccountAddress(spentOutput.ScriptHashHex())
+		signature := proposedTransaction.Signatures[index]
+		if address == nil || signature == nil {
+			return errp.New("Signature missing")
+		}
+		if address.Configuration.BitcoinMultisig != nil {
+			input := &packet.Inputs[index]
+			input.WitnessUtxo = spentOutput.TxOut
+			input.SighashType = txscript.SigHashAll
+			input.RedeemScript = nil
+			addPSBTDerivation(address, &input.Bip32Derivation, &input.TaprootBip32Derivation,
+				&input.TaprootInternalKey, &input.RedeemScript, &input.WitnessScript)
+			publicKey := address.Configuration.PublicKey().SerializeCompressed()
+			partialSigs := []*psbt.PartialSig{{
+				PubKey:    publicKey,
+				Signature: append(signature.SerializeDER(), byte(txscript.SigHashAll)),
+			}}
+			for _, partialSig := range input.PartialSigs {
+				if !bytes.Equal(partialSig.PubKey, publicKey) {
+					partialSigs = append(partialSigs, partialSig)
+				}
+			}
+			input.PartialSigs = partialSigs
+			continue
+		}
+		signatureScript, witness := address.SignatureScript(*signature)
+		// Finalized inputs only keep the UTXO fields, see BIP174.
+		input := psbt.PInput{
+			NonWitnessUtxo: packet.Inputs[index].NonWitnessUtxo,
+			FinalScriptSig: signatureScript,
+			Unknowns:       packet.Inputs[index].Unknowns,
+		}
+		if !txscript.IsPayToPubKeyHash(spentOutput.PkScript) {
+			input.WitnessUtxo = spentOutput.TxOut
+		}
+		if len(witness) > 0 {
+			var serializedWitness bytes.Buffer
+			if err := psbt.WriteTxWitness(&serializedWitness, witness); err != nil {
+				return errp.WithStack(err)
+			}
+			input.FinalScriptWitness = serializedWitness.Bytes()
+		}
+		packet.Inputs[index] = input
+	}
+	return nil
+}
+
+// completePSBT finalizes the multisig inputs of the PSBT which have enough signatures. It returns
+// true if all inputs are finalized, after checking that the transaction is valid.
+func completePSBT(packet *psbt.Packet, previousOutputs maketx.PreviousOutputs) (bool, error) {
+	complete := true
+	sigHashes := txscript.NewTxSigHashes(packet.UnsignedTx, previousOutputs)
+	for index := range packet.Inputs {
+		input := &packet.Inputs[index]
+		if isFinalized(input) {
+			continue
+		}
+		if !isMultisigInput(input) {
+			complete = false
+			continue
+		}
+		inputComplete, err := multisigSignaturesComplete(packet, index, previousOutputs, sigHashes)
+		if err != nil {
+			return false, err
+		}
+		if !inputComplete {
+			complete = false
+			continue
+		}
+		if err := psbt.Finalize(packet, index); err != nil {
+			return false, errp.WithMessage(errors.ErrPSBTInvalid, err.Error())
+		}
+	}
+	if !complete {
+		return false, nil
+	}
+	tx, err := psbt.Extract(packet)
+	if err != nil {
+		return false, errp.WithMessage(errors.ErrPSBTInvalid, err.Error())
+	}
+	if err := txValidityCheck(tx, previousOutputs, txscript.NewTxSigHashes(tx, previousOutputs)); err != nil {
+		return false, errp.WithMessage(errors.ErrPSBTInvalid, err.Error())
+	}
+	return true, nil
+}
+
+// SignPSBT signs a base64 encoded PSBT with the keystore of the account and returns it with our
+// signatures. All of its inputs must be spendable outputs of the account, so that the amounts
+// confirmed on the keystore are not taken from the PSBT. The inputs of multisig accounts are
+// finalized once the PSBT has the signatures of enough cosigners. complete is true if all inputs
+// are finalized, so that the transaction can be broadcasted with ImportPSBT(). To cosign the
+// active tx proposal, pass the PSBT returned by ExportPSBT().
+func (account *Account) SignPSBT(encoded string) (signed string, complete bool, err error) {
+	if account.fatalError.Load() {
+		return "", false, errp.New("Can't sign a PSBT with an account with a fatal error")
+	}
+	packet, err := psbt.NewFromRawBytes(strings.NewReader(strings.TrimSpace(encoded)), true)
+	if err != nil {
+		return "", false, errp.WithStack(errors.ErrPSBTInvalid)
+	}
+	spendableOutputs, err := account.transactions.SpendableOutputs()
+	if err != nil {
+		return "", false, err
+	}
+	previousOutputs := maketx.PreviousOutputs{}
+	var inputsValue btcutil.Amount
+	for _, txIn := range packet.UnsignedTx.TxIn {
+		spentOutput, ok := spendableOutputs[txIn.PreviousOutPoint]
+		if !ok {
+			return "", false, errp.WithStack(errors.ErrPSBTForeignInput)
+		}
+		previousOutputs[txIn.PreviousOutPoint] = spentOutput
+		inputsValue += btcutil.Amount(spentOutput.Value)
+	}
+	txProposal := &maketx.TxProposal{
+		Coin:            account.coin,
+		Transaction:     packet.UnsignedTx.Copy(),
+		PreviousOutputs: previousOutputs,
+	}
+	var outputsValue btcutil.Amount
+	for _, txOut := range txProposal.Transaction.TxOut {
+		outputsValue += btcutil.Amount(txOut.Value)
+		changeAddress := account.lookupChangeAddress(blockchain.NewScriptHashHex(txOut.PkScript))
+		if changeAddress != nil && txProposal.ChangeAddress == nil {
+			txProposal.ChangeAddress = changeAddress
+			continue
+		}
+		txProposal.Amount += btcutil.Amount(txOut.Value)
+	}
+	if outputsValue > inputsValue {
+		return "", false, errp.WithMessage(errors.ErrPSBTInvalid, "outputs exceed the inputs")
+	}
+	txProposal.Fee = inputsValue - outputsValue
+
+	account.log.Info("Signing PSBT")
+	proposedTransaction, err := account.signWithKeystore(txProposal, account.coin.Blockchain().TransactionGet)
+	if err != nil {
+		return "", false, err
+	}
+	if err := addPSBTSignatures(packet, proposedTransaction); err != nil {
+		return "", false, err
+	}
+	complete, err = completePSBT(packet, previousOutputs)
+	if err != nil {
+		return "", false, err
+	}
+	signed, err = packet.B64Encode()
+	if err != nil {
+		return "", false, errp.WithStack(err)
+	}
+	return signed, complete, nil
+}
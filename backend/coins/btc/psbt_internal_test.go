@@ -0,0 +1,293 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPSBT(t *testing.T) {
+	net := &chaincfg.TestNet3Params
+	xprv, err := hdkeychain.NewMaster(bytes.Repeat([]byte{1}, hdkeychain.RecommendedSeedLen), net)
+	require.NoError(t, err)
+	xpub, err := xprv.Neuter()
+	require.NoError(t, err)
+	configuration := signing.NewBitcoinConfiguration(
+		signing.ScriptTypeP2WPKH, []byte{1, 2, 3, 4}, signing.NewEmptyAbsoluteKeypath(), xpub)
+	log := logging.Get().WithGroup("psbt_test")
+	address := addresses.NewAccountAddress(
+		configuration, signing.NewEmptyRelativeKeypath().Child(0, false).Child(0, false), net, log)
+	changeAddress := addresses.NewAccountAddress(
+		configuration, signing.NewEmptyRelativeKeypath().Child(1, false).Child(0, false), net, log)
+
+	prevTx := wire.NewMsgTx(wire.TxVersion)
+	prevTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	prevTx.AddTxOut(wire.NewTxOut(100000, address.PubkeyScript()))
+	outPoint := wire.OutPoint{Hash: prevTx.TxHash(), Index: 0}
+	previousOutputs := maketx.PreviousOutputs{
+		outPoint: &transactions.SpendableOutput{TxOut: prevTx.TxOut[0]},
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(50000, []byte{txscript.OP_0, 20,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}))
+	tx.AddTxOut(wire.NewTxOut(49000, changeAddress.PubkeyScript()))
+	txProposal := &maketx.TxProposal{
+		Transaction:     tx,
+		ChangeAddress:   changeAddress,
+		PreviousOutputs: previousOutputs,
+	}
+
+	getAddress := func(scriptHashHex blockchain.ScriptHashHex) *addresses.AccountAddress {
+		if scriptHashHex == address.PubkeyScriptHashHex() {
+			return address
+		}
+		return nil
+	}
+	getPrevTx := func(hash chainhash.Hash) (*wire.MsgTx, error) {
+		require.Equal(t, prevTx.TxHash(), hash)
+		return prevTx, nil
+	}
+	newPacket := func() *psbt.Packet {
+		packet, err := newPSBT(txProposal, getAddress, getPrevTx)
+		require.NoError(t, err)
+		// Round trip through the encoding, like exporting and importing it.
+		encoded, err := packet.B64Encode()
+		require.NoError(t, err)
+		packet, err = psbt.NewFromRawBytes(bytes.NewReader([]byte(encoded)), true)
+		require.NoError(t, err)
+		return packet
+	}
+
+	packet := newPacket()
+	require.Len(t, packet.Inputs, 1)
+	input := packet.Inputs[0]
+	require.Equal(t, prevTx.TxOut[0], input.WitnessUtxo)
+	require.Equal(t, prevTx.TxHash(), input.NonWitnessUtxo.TxHash())
+	require.Len(t, input.Bip32Derivation, 1)
+	require.Equal(t, []uint32{0, 0}, input.Bip32Derivation[0].Bip32Path)
+	require.Equal(t, address.Configuration.PublicKey().SerializeCompressed(), input.Bip32Derivation[0].PubKey)
+	require.Empty(t, packet.Outputs[0].Bip32Derivation)
+	require.Len(t, packet.Outputs[1].Bip32Derivation, 1)
+	require.Equal(t, []uint32{1, 0}, packet.Outputs[1].Bip32Derivation[0].Bip32Path)
+
+	// Not signed.
+	_, err = finalizePSBT(newPacket(), previousOutputs)
+	require.Equal(t, errors.ErrPSBTIncomplete, errp.Cause(err))
+
+	// Spends a coin which is not ours.
+	_, err = finalizePSBT(newPacket(), maketx.PreviousOutputs{})
+	require.Equal(t, errors.ErrPSBTForeignInput, errp.Cause(err))
+
+	sign := func(packet *psbt.Packet, amount int64) {
+		privateKey, err := xprv.Derive(0)
+		require.NoError(t, err)
+		privateKey, err = privateKey.Derive(0)
+		require.NoError(t, err)
+		ecPrivateKey, err := privateKey.ECPrivKey()
+		require.NoError(t, err)
+		signatureTx := packet.UnsignedTx
+		fetcher := txscript.NewCannedPrevOutputFetcher(address.PubkeyScript(), amount)
+		signature, err := txscript.RawTxInWitnessSignature(
+			signatureTx, txscript.NewTxSigHashes(signatureTx, fetcher), 0, amount,
+			address.PubkeyScript(), txscript.SigHashAll, ecPrivateKey)
+		require.NoError(t, err)
+		packet.Inputs[0].PartialSigs = append(packet.Inputs[0].PartialSigs, &psbt.PartialSig{
+			PubKey:    address.Configuration.PublicKey().SerializeCompressed(),
+			Signature: signature,
+		})
+	}
+
+	// Signed for a different amount than the one of our coin.
+	packet = newPacket()
+	sign(packet, 200000)
+	_, err = finalizePSBT(packet, previousOutputs)
+	require.Equal(t, errors.ErrPSBTInvalid, errp.Cause(err))
+
+	packet = newPacket()
+	sign(packet, 100000)
+	signedTx, err := finalizePSBT(packet, previousOutputs)
+	require.NoError(t, err)
+	require.Equal(t, tx.TxHash(), signedTx.TxHash())
+	require.Len(t, signedTx.TxIn[0].Witness, 2)
+
+	// The signatures of the keystore are added as finalized inputs.
+	proposedTransaction := &ProposedTransaction{
+		TXProposal:        txProposal,
+		GetAccountAddress: getAddress,
+		Signatures: []*types.Signature{
+			parseSignature(t, signedTx.TxIn[0].Witness[0]),
+		},
+	}
+	packet = newPacket()
+	require.NoError(t, addPSBTSignatures(packet, proposedTransaction))
+	require.True(t, packet.IsComplete())
+	require.Empty(t, packet.Inputs[0].Bip32Derivation)
+	complete, err := completePSBT(packet, previousOutputs)
+	require.NoError(t, err)
+	require.True(t, complete)
+	extractedTx, err := psbt.Extract(packet)
+	require.NoError(t, err)
+	require.Equal(t, signedTx.WitnessHash(), extractedTx.WitnessHash())
+	proposedTransaction.TXProposal = &maketx.TxProposal{Transaction: tx}
+	require.Equal(t, errors.ErrPSBTForeignInput,
+		errp.Cause(addPSBTSignatures(newPacket(), proposedTransaction)))
+}
+
+// parseSignature parses a DER signature with the sighash type appended.
+func parseSignature(t *testing.T, signature []byte) *types.Signature {
+	t.Helper()
+	var parsed struct{ R, S *big.Int }
+	_, err := asn1.Unmarshal(signature[:len(signature)-1], &parsed)
+	require.NoError(t, err)
+	return &types.Signature{R: parsed.R, S: parsed.S}
+}
+
+func TestPSBTMultisig(t *testing.T) {
+	net := &chaincfg.TestNet3Params
+	var xprvs []*hdkeychain.ExtendedKey
+	var keyInfos []signing.KeyInfo
+	for i := byte(1); i <= 3; i++ {
+		xprv, err := hdkeychain.NewMaster(bytes.Repeat([]byte{i}, hdkeychain.RecommendedSeedLen), net)
+		require.NoError(t, err)
+		xpub, err := xprv.Neuter()
+		require.NoError(t, err)
+		xprvs = append(xprvs, xprv)
+		keyInfos = append(keyInfos, signing.KeyInfo{
+			RootFingerprint:   []byte{i, i, i, i},
+			AbsoluteKeypath:   signing.NewEmptyAbsoluteKeypath(),
+			ExtendedPublicKey: xpub,
+		})
+	}
+	configuration, err := signing.NewBitcoinMultisigConfiguration(2, keyInfos, 0)
+	require.NoError(t, err)
+	log := logging.Get().WithGroup("psbt_test")
+	address := addresses.NewAccountAddress(
+		configuration, signing.NewEmptyRelativeKeypath().Child(0, false).Child(0, false), net, log)
+	_, witnessScript := address.ScriptForHashToSign()
+
+	prevTx := wire.NewMsgTx(wire.TxVersion)
+	prevTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	prevTx.AddTxOut(wire.NewTxOut(100000, address.PubkeyScript()))
+	outPoint := wire.OutPoint{Hash: prevTx.TxHash(), Index: 0}
+	previousOutputs := maketx.PreviousOutputs{
+		outPoint: &transactions.SpendableOutput{TxOut: prevTx.TxOut[0]},
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(99000, []byte{txscript.OP_0, 20,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}))
+	txProposal := &maketx.TxProposal{Transaction: tx, PreviousOutputs: previousOutputs}
+	getAddress := func(scriptHashHex blockchain.ScriptHashHex) *addresses.AccountAddress {
+		if scriptHashHex == address.PubkeyScriptHashHex() {
+			return address
+		}
+		return nil
+	}
+	packet, err := newPSBT(txProposal, getAddress, func(chainhash.Hash) (*wire.MsgTx, error) {
+		return prevTx, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, witnessScript, packet.Inputs[0].WitnessScript)
+	require.Len(t, packet.Inputs[0].Bip32Derivation, 3)
+	require.Equal(t, uint32(0x02020202), packet.Inputs[0].Bip32Derivation[1].MasterKeyFingerprint)
+	require.Equal(t, []uint32{0, 0}, packet.Inputs[0].Bip32Derivation[1].Bip32Path)
+
+	// sign returns the signature of the cosigner with the given index, with the sighash type.
+	sign := func(index int) []byte {
+		privateKey, err := xprvs[index].Derive(0)
+		require.NoError(t, err)
+		privateKey, err = privateKey.Derive(0)
+		require.NoError(t, err)
+		ecPrivateKey, err := privateKey.ECPrivKey()
+		require.NoError(t, err)
+		signature, err := txscript.RawTxInWitnessSignature(
+			tx, txscript.NewTxSigHashes(tx, previousOutputs), 0, 100000,
+			witnessScript, txscript.SigHashAll, ecPrivateKey)
+		require.NoError(t, err)
+		return signature
+	}
+	proposedTransaction := &ProposedTransaction{
+		TXProposal:        txProposal,
+		GetAccountAddress: getAddress,
+		Signatures:        []*types.Signature{parseSignature(t, sign(0))},
+	}
+
+	// Our signature is added as a partial signature, twice signing does not add it twice.
+	require.NoError(t, addPSBTSignatures(packet, proposedTransaction))
+	require.NoError(t, addPSBTSignatures(packet, proposedTransaction))
+	require.Len(t, packet.Inputs[0].PartialSigs, 1)
+	require.Equal(t, address.Configuration.PublicKey().SerializeCompressed(),
+		packet.Inputs[0].PartialSigs[0].PubKey)
+	complete, err := completePSBT(packet, previousOutputs)
+	require.NoError(t, err)
+	require.False(t, complete)
+	_, err = finalizePSBT(packet, previousOutputs)
+	require.Equal(t, errors.ErrPSBTIncomplete, errp.Cause(err))
+
+	cosignerPublicKey := func(index int) []byte {
+		publicKey, err := address.Configuration.BitcoinMultisig.KeyInfos[index].ExtendedPublicKey.ECPubKey()
+		require.NoError(t, err)
+		return publicKey.SerializeCompressed()
+	}
+
+	// Signatures which don't verify are dropped.
+	packet.Inputs[0].PartialSigs = append(packet.Inputs[0].PartialSigs, &psbt.PartialSig{
+		PubKey:    cosignerPublicKey(2),
+		Signature: sign(1),
+	})
+	complete, err = completePSBT(packet, previousOutputs)
+	require.NoError(t, err)
+	require.False(t, complete)
+	require.Len(t, packet.Inputs[0].PartialSigs, 1)
+
+	// The signatures of two cosigners are more than needed, but the input is finalized with two,
+	// keeping ours.
+	for _, index := range []int{1, 2} {
+		packet.Inputs[0].PartialSigs = append(packet.Inputs[0].PartialSigs, &psbt.PartialSig{
+			PubKey:    cosignerPublicKey(index),
+			Signature: sign(index),
+		})
+	}
+	complete, err = completePSBT(packet, previousOutputs)
+	require.NoError(t, err)
+	require.True(t, complete)
+	signedTx, err := psbt.Extract(packet)
+	require.NoError(t, err)
+	require.Equal(t, tx.TxHash(), signedTx.TxHash())
+	// Empty dummy element, two signatures and the witness script.
+	require.Len(t, signedTx.TxIn[0].Witness, 4)
+	require.Contains(t, [][]byte{signedTx.TxIn[0].Witness[1], signedTx.TxIn[0].Witness[2]}, sign(0))
+}
@@ -0,0 +1,153 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+// ReceiveAddressUsage describes how a derived receive address was used.
+type ReceiveAddressUsage struct {
+	Address    string                  `json:"address"`
+	ScriptType signing.ScriptType      `json:"scriptType"`
+	Keypath    signing.AbsoluteKeypath `json:"keypath"`
+	// TxCount is the number of transactions touching the address, receiving to or spending from it.
+	TxCount int `json:"txCount"`
+	// TotalReceived is the sum of all outputs paying to the address, including unconfirmed ones.
+	TotalReceived btcutil.Amount `json:"totalReceived"`
+	// FirstUsed and LastUsed are the timestamps of the oldest and newest transaction touching the
+	// address. They are nil if the address is unused, or if no timestamp is known yet.
+	FirstUsed *time.Time `json:"firstUsed"`
+	LastUsed  *time.Time `json:"lastUsed"`
+}
+
+// Used returns true if there is at least one transaction touching the address.
+func (usage *ReceiveAddressUsage) Used() bool {
+	return usage.TxCount > 0
+}
+
+// ReceiveAddressUsage returns all receive addresses derived so far, including the unused ones in
+// the gap limit tail, with their usage. The addresses are ordered by subaccount and derivation
+// index.
+func (account *Account) ReceiveAddressUsage() ([]*ReceiveAddressUsage, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account not initialized")
+	}
+	if account.fatalError.Load() {
+		return nil, errp.New("can't call ReceiveAddressUsage() after a fatal error")
+	}
+	account.Synchronizer.WaitSynchronized()
+	return transactions.DBView(account.db, func(dbTx transactions.DBTxInterface) ([]*ReceiveAddressUsage, error) {
+		result := []*ReceiveAddressUsage{}
+		for _, subacc := range account.subaccounts {
+			for _, address := range subacc.receiveAddresses.Addresses() {
+				history, err := dbTx.AddressHistory(address.PubkeyScriptHashHex())
+				if err != nil {
+					return nil, err
+				}
+				usage := &ReceiveAddressUsage{
+					Address:    address.EncodeForHumans(),
+					ScriptType: address.Configuration.ScriptType(),
+					Keypath:    address.AbsoluteKeypath(),
+					TxCount:    len(history),
+				}
+				pkScript := address.PubkeyScript()
+				for _, entry := range history {
+					txInfo, err := dbTx.TxInfo(entry.TXHash.Hash())
+					if err != nil {
+						return nil, err
+					}
+					if txInfo == nil {
+						continue
+					}
+					for _, txOut := range txInfo.Tx.TxOut {
+						if bytes.Equal(txOut.PkScript, pkScript) {
+							usage.TotalReceived += btcutil.Amount(txOut.Value)
+						}
+					}
+					timestamp := txInfo.HeaderTimestamp
+					if timestamp == nil {
+						timestamp = txInfo.CreatedTimestamp
+					}
+					if timestamp == nil {
+						continue
+					}
+					if usage.FirstUsed == nil || timestamp.Before(*usage.FirstUsed) {
+						usage.FirstUsed = timestamp
+					}
+					if usage.LastUsed == nil || timestamp.After(*usage.LastUsed) {
+						usage.LastUsed = timestamp
+					}
+				}
+				result = append(result, usage)
+			}
+		}
+		return result, nil
+	})
+}
+
+// ExportReceiveAddressUsageCSV writes the receive address usage as CSV, with amounts in the
+// smallest unit of the coin.
+func (account *Account) ExportReceiveAddressUsageCSV(w io.Writer, usages []*ReceiveAddressUsage) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{
+		"Address",
+		"Script Type",
+		"Keypath",
+		"Used",
+		"Transactions",
+		"Total Received",
+		"Unit",
+		"First Used",
+		"Last Used",
+	})
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	unit := account.Coin().SmallestUnit()
+	for _, usage := range usages {
+		err := writer.Write([]string{
+			usage.Address,
+			string(usage.ScriptType),
+			usage.Keypath.Encode(),
+			strconv.FormatBool(usage.Used()),
+			strconv.Itoa(usage.TxCount),
+			strconv.FormatInt(int64(usage.TotalReceived), 10),
+			unit,
+			formatTime(usage.FirstUsed),
+			formatTime(usage.LastUsed),
+		})
+		if err != nil {
+			return errp.WithStack(err)
+		}
+	}
+	writer.Flush()
+	return errp.WithStack(writer.Error())
+}
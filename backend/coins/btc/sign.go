@@ -15,6 +15,7 @@
 package btc
 
 import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
@@ -40,17 +41,16 @@ type ProposedTransaction struct {
 	FormatUnit coin.BtcUnit
 }
 
-// signTransaction signs all inputs. It assumes all outputs spent belong to this
-// wallet. previousOutputs must contain all outputs which are spent by the transaction.
-func (account *Account) signTransaction(
+// signWithKeystore signs all inputs with the keystore of the account and returns the proposed
+// transaction containing the signatures. It assumes all outputs spent belong to this wallet.
+func (account *Account) signWithKeystore(
 	txProposal *maketx.TxProposal,
 	getPrevTx func(chainhash.Hash) (*wire.MsgTx, error),
-) error {
+) (*ProposedTransaction, error) {
 	signingConfigs := make([]*signing.Configuration, len(account.subaccounts))
 	for i, subacc := range account.subaccounts {
 		signingConfigs[i] = subacc.signingConfiguration
 	}
-	previousOutputs := txProposal.PreviousOutputs
 
 	proposedTransaction := &ProposedTransaction{
 		TXProposal:                   txProposal,
@@ -58,15 +58,33 @@ func (account *Account) signTransaction(
 		GetAccountAddress:            account.getAddress,
 		GetPrevTx:                    getPrevTx,
 		Signatures:                   make([]*types.Signature, len(txProposal.Transaction.TxIn)),
-		SigHashes:                    txscript.NewTxSigHashes(txProposal.Transaction, previousOutputs),
+		SigHashes:                    txscript.NewTxSigHashes(txProposal.Transaction, txProposal.PreviousOutputs),
 		FormatUnit:                   account.coin.formatUnit,
 	}
 
 	keystore, err := account.Config().ConnectKeystore()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if err := keystore.SignTransaction(proposedTransaction); err != nil {
+		return nil, err
+	}
+	return proposedTransaction, nil
+}
+
+// signTransaction signs all inputs. It assumes all outputs spent belong to this
+// wallet. previousOutputs must contain all outputs which are spent by the transaction.
+// Transactions of multisig accounts need the signatures of the cosigners, see SignPSBT().
+func (account *Account) signTransaction(
+	txProposal *maketx.TxProposal,
+	getPrevTx func(chainhash.Hash) (*wire.MsgTx, error),
+) error {
+	if account.isMultisig() {
+		return errp.WithStack(errors.ErrMultisigCosigning)
+	}
+	previousOutputs := txProposal.PreviousOutputs
+	proposedTransaction, err := account.signWithKeystore(txProposal, getPrevTx)
+	if err != nil {
 		return err
 	}
 
@@ -0,0 +1,90 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"encoding/base64"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/message"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/util"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// ErrAddressNotInAccount is returned by SignMessage() if the address does not belong to the
+// account.
+const ErrAddressNotInAccount errp.ErrorCode = "addressNotInAccount"
+
+// SignedMessage is a message signature made by SignMessage().
+type SignedMessage struct {
+	Address string         `json:"address"`
+	Format  message.Format `json:"format"`
+	// Signature is base64 encoded.
+	Signature string `json:"signature"`
+}
+
+// SignMessage signs the message with the private key of an address of the account, using the
+// connected keystore, to prove the ownership of the address. If address is empty, the first
+// unused native segwit receive address is used. The keystore signs in the legacy format, which
+// is not defined for taproot addresses.
+func (account *Account) SignMessage(address string, msg string) (*SignedMessage, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account not initialized")
+	}
+	if address == "" {
+		address, signature, err := SignBTCAddress(account, msg, signing.ScriptTypeP2WPKH)
+		if err != nil {
+			return nil, err
+		}
+		return &SignedMessage{Address: address, Format: message.FormatLegacy, Signature: signature}, nil
+	}
+	decodedAddress, err := account.coin.DecodeAddress(address)
+	if err != nil {
+		return nil, errp.WithStack(message.ErrInvalidAddress)
+	}
+	pkScript, err := util.PkScriptFromAddress(decodedAddress)
+	if err != nil {
+		return nil, err
+	}
+	accountAddress := account.getAddress(blockchain.NewScriptHashHex(pkScript))
+	if accountAddress == nil {
+		return nil, errp.WithStack(ErrAddressNotInAccount)
+	}
+	scriptType := accountAddress.Configuration.ScriptType()
+	if scriptType == signing.ScriptTypeP2TR {
+		return nil, errp.New("taproot addresses can't sign messages")
+	}
+	if scriptType == signing.ScriptTypeP2WSH {
+		return nil, errp.New("multisig addresses can't sign messages")
+	}
+	keystore, err := account.Config().ConnectKeystore()
+	if err != nil {
+		return nil, err
+	}
+	if !keystore.CanSignMessage(account.coin.Code()) {
+		return nil, errp.Newf("The connected device or keystore cannot sign messages for %s",
+			account.coin.Code())
+	}
+	signature, err := keystore.SignBTCMessage([]byte(msg), accountAddress.AbsoluteKeypath(), scriptType)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedMessage{
+		Address:   accountAddress.EncodeForHumans(),
+		Format:    message.FormatLegacy,
+		Signature: base64.StdEncoding.EncodeToString(signature),
+	}, nil
+}
@@ -0,0 +1,226 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+// SnapshotUTXO is an unspent output in a snapshot.
+type SnapshotUTXO struct {
+	OutPoint   string                  `json:"outPoint"`
+	Address    string                  `json:"address"`
+	ScriptType signing.ScriptType      `json:"scriptType"`
+	Keypath    signing.AbsoluteKeypath `json:"keypath"`
+	Value      btcutil.Amount          `json:"value"`
+	// Height is the height of the block the output was confirmed in.
+	Height int `json:"height"`
+}
+
+// Snapshot is the balance and the utxo set of an account at a block height, for audits. Amounts
+// are in the smallest unit of the coin.
+type Snapshot struct {
+	Coin        coin.Code          `json:"coin"`
+	AccountCode accountsTypes.Code `json:"accountCode"`
+	AccountName string             `json:"accountName"`
+	BlockHeight int                `json:"blockHeight"`
+	// BlockHash is empty if the header at BlockHeight is not synced yet.
+	BlockHash string          `json:"blockHash,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Balance   btcutil.Amount  `json:"balance"`
+	Unit      string          `json:"unit"`
+	UTXOs     []*SnapshotUTXO `json:"utxos"`
+}
+
+// BalanceAttestation is a snapshot signed with the private key of an account address, proving
+// that the account holder controlled the account when it was made.
+type BalanceAttestation struct {
+	// Snapshot is the JSON encoded snapshot. SnapshotHash is the hex encoded SHA256 hash of its
+	// compact encoding.
+	Snapshot     json.RawMessage `json:"snapshot"`
+	SnapshotHash string          `json:"snapshotHash"`
+	// Message is the signed message, committing to the snapshot hash.
+	Message    string                  `json:"message"`
+	Address    string                  `json:"address"`
+	ScriptType signing.ScriptType      `json:"scriptType"`
+	Keypath    signing.AbsoluteKeypath `json:"keypath"`
+	// Signature is the base64 encoded message signature, as used by Electrum and Bitcoin Core.
+	Signature string `json:"signature"`
+}
+
+// Snapshot returns the balance and the utxo set of the account at the given block height. Only
+// confirmed transactions are taken into account. If blockHeight is 0, the current chain tip is
+// used.
+func (account *Account) Snapshot(blockHeight int) (*Snapshot, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account not initialized")
+	}
+	if account.fatalError.Load() {
+		return nil, errp.New("can't call Snapshot() after a fatal error")
+	}
+	tipHeight := account.coin.TipHeight()
+	if tipHeight <= 0 {
+		return nil, errp.New("the chain tip is not known yet")
+	}
+	if blockHeight == 0 {
+		blockHeight = tipHeight
+	}
+	if blockHeight < 0 || blockHeight > tipHeight {
+		return nil, errp.Newf("the block height must be between 1 and %d", tipHeight)
+	}
+	account.Synchronizer.WaitSynchronized()
+	utxos, err := account.transactions.UnspentOutputsAt(blockHeight)
+	if err != nil {
+		return nil, err
+	}
+	accountConfig := account.Config().Config
+	snapshot := &Snapshot{
+		Coin:        account.coin.Code(),
+		AccountCode: accountConfig.Code,
+		AccountName: accountConfig.Name,
+		BlockHeight: blockHeight,
+		CreatedAt:   time.Now().UTC(),
+		Unit:        account.coin.SmallestUnit(),
+		UTXOs:       []*SnapshotUTXO{},
+	}
+	header, err := account.coin.Headers().VerifiedHeaderByHeight(blockHeight)
+	if err != nil {
+		return nil, err
+	}
+	if header != nil {
+		snapshot.BlockHash = header.BlockHash().String()
+	}
+	for outPoint, utxo := range utxos {
+		snapshotUTXO := &SnapshotUTXO{
+			OutPoint: outPoint.String(),
+			Value:    btcutil.Amount(utxo.Value),
+			Height:   utxo.Height,
+		}
+		if address := account.getAddress(blockchain.NewScriptHashHex(utxo.PkScript)); address != nil {
+			snapshotUTXO.Address = address.EncodeForHumans()
+			snapshotUTXO.ScriptType = address.Configuration.ScriptType()
+			snapshotUTXO.Keypath = address.AbsoluteKeypath()
+		}
+		snapshot.Balance += snapshotUTXO.Value
+		snapshot.UTXOs = append(snapshot.UTXOs, snapshotUTXO)
+	}
+	sort.Slice(snapshot.UTXOs, func(i, j int) bool {
+		if snapshot.UTXOs[i].Height != snapshot.UTXOs[j].Height {
+			return snapshot.UTXOs[i].Height < snapshot.UTXOs[j].Height
+		}
+		return snapshot.UTXOs[i].OutPoint < snapshot.UTXOs[j].OutPoint
+	})
+	return snapshot, nil
+}
+
+// snapshotMessage is the message signed to attest a snapshot. It is shown on the device, so it is
+// kept short and readable.
+func snapshotMessage(snapshot *Snapshot, formattedBalance string, snapshotHash string) string {
+	return fmt.Sprintf(
+		"Balance attestation\nAccount: %s\nBlock height: %d\nBalance: %s\nSnapshot SHA256: %s",
+		snapshot.AccountName,
+		snapshot.BlockHeight,
+		formattedBalance,
+		snapshotHash,
+	)
+}
+
+// messageSigningAddress returns the first receive address of the account which can sign messages.
+// Native segwit is preferred over wrapped segwit, as taproot addresses can't sign messages.
+func (account *Account) messageSigningAddress() (*addresses.AccountAddress, error) {
+	signingConfigs := account.Config().Config.SigningConfigurations
+	signingConfigIdx := signingConfigs.FindScriptType(signing.ScriptTypeP2WPKH)
+	if signingConfigIdx == -1 {
+		signingConfigIdx = signingConfigs.FindScriptType(signing.ScriptTypeP2WPKHP2SH)
+	}
+	if signingConfigIdx == -1 {
+		return nil, errp.New("the account has no address type which can sign messages")
+	}
+	return account.subaccounts[signingConfigIdx].receiveAddresses.Addresses()[0], nil
+}
+
+// signAttestation signs the message with the first receive address of the account which can sign
+// messages, using the connected keystore. It returns the signing address and the base64 encoded
+// signature.
+func (account *Account) signAttestation(message string) (*addresses.AccountAddress, string, error) {
+	keystore, err := account.Config().ConnectKeystore()
+	if err != nil {
+		return nil, "", err
+	}
+	if !keystore.CanSignMessage(account.coin.Code()) {
+		return nil, "", errp.Newf("The connected device or keystore cannot sign messages for %s",
+			account.coin.Code())
+	}
+	address, err := account.messageSigningAddress()
+	if err != nil {
+		return nil, "", err
+	}
+	signature, err := keystore.SignBTCMessage(
+		[]byte(message),
+		address.AbsoluteKeypath(),
+		address.Configuration.ScriptType(),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	return address, base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// AttestSnapshot signs the snapshot with the first receive address of the account, using the
+// connected keystore. Native segwit is preferred over wrapped segwit, as taproot addresses can't
+// sign messages.
+func (account *Account) AttestSnapshot(snapshot *Snapshot) (*BalanceAttestation, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account not initialized")
+	}
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	hash := sha256.Sum256(snapshotJSON)
+	snapshotHash := hex.EncodeToString(hash[:])
+	formattedBalance := fmt.Sprintf("%s %s",
+		account.coin.FormatAmount(coin.NewAmountFromInt64(int64(snapshot.Balance)), false),
+		account.coin.GetFormatUnit(false))
+	message := snapshotMessage(snapshot, formattedBalance, snapshotHash)
+
+	address, signature, err := account.signAttestation(message)
+	if err != nil {
+		return nil, err
+	}
+	return &BalanceAttestation{
+		Snapshot:     snapshotJSON,
+		SnapshotHash: snapshotHash,
+		Message:      message,
+		Address:      address.EncodeForHumans(),
+		ScriptType:   address.Configuration.ScriptType(),
+		Keypath:      address.AbsoluteKeypath(),
+		Signature:    signature,
+	}, nil
+}
@@ -17,7 +17,7 @@ package btc
 
 import (
 	"math/big"
-	"strconv"
+	"strings"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
@@ -27,10 +27,12 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/util"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/sirupsen/logrus"
 )
 
 // unitSatoshi is 1 BTC (default unit) in Satoshi.
@@ -41,22 +43,11 @@ const unitSatoshi = 1e8
 // `FeeTargetCodeCustom`.
 func (account *Account) getFeePerKb(args *accounts.TxProposalArgs) (btcutil.Amount, error) {
 	if args.FeeTargetCode == accounts.FeeTargetCodeCustom {
-		float, err := strconv.ParseFloat(args.CustomFee, 64)
-		if err != nil {
-			return 0, err
-		}
-		// Technically it is vKb (virtual Kb) since fees are computed from a transaction's weight
-		// (measured in weight units or virtual bytes), but we keep the `Kb` unit to be consistent
-		// with the rest of the codebase and Bitcoin Core.
 		minRelayFeeRate, err := account.getMinRelayFeeRate()
 		if err != nil {
 			return 0, err
 		}
-		feePerKb := btcutil.Amount(float * 1000)
-		if feePerKb < minRelayFeeRate {
-			return 0, errors.ErrFeeTooLow
-		}
-		return feePerKb, nil
+		return parseCustomFeeRate(args.CustomFee, minRelayFeeRate, args.AllowHighFee)
 	}
 	var feeTarget *FeeTarget
 	for _, target := range account.feeTargets() {
@@ -71,9 +62,77 @@ func (account *Account) getFeePerKb(args *accounts.TxProposalArgs) (btcutil.Amou
 	return *feeTarget.feeRatePerKb, nil
 }
 
+// maxCustomFeeRatePerKb is the highest custom fee rate accepted without TxProposalArgs.AllowHighFee,
+// 10000 sat/vB. It matches the default `-maxfeerate` of Bitcoin Core and protects against typos like
+// an extra zero when there is no fee estimate to compare with.
+const maxCustomFeeRatePerKb = btcutil.Amount(10000 * 1000)
+
+// parseCustomFeeRate parses a custom fee rate given in sat/vB with up to three decimal places and
+// returns it per kvB. The fee rate must be at least the min relay fee rate and, unless allowHighFee
+// is true, at most maxCustomFeeRatePerKb.
+func parseCustomFeeRate(
+	customFee string, minRelayFeeRate btcutil.Amount, allowHighFee bool) (btcutil.Amount, error) {
+	// Technically it is vKb (virtual Kb) since fees are computed from a transaction's weight
+	// (measured in weight units or virtual bytes), but we keep the `Kb` unit to be consistent
+	// with the rest of the codebase and Bitcoin Core.
+	amount, err := coin.NewAmountFromString(strings.TrimSpace(customFee), big.NewInt(1000))
+	if err != nil {
+		return 0, errp.WithStack(errors.ErrInvalidFeeRate)
+	}
+	feePerKbInt64, err := amount.Int64()
+	if err != nil || feePerKbInt64 < 0 {
+		return 0, errp.WithStack(errors.ErrInvalidFeeRate)
+	}
+	feePerKb := btcutil.Amount(feePerKbInt64)
+	if feePerKb < minRelayFeeRate || feePerKb == 0 {
+		return 0, errp.WithStack(errors.ErrFeeTooLow)
+	}
+	if !allowHighFee && feePerKb > maxCustomFeeRatePerKb {
+		return 0, errp.WithStack(errors.ErrFeeTooHigh)
+	}
+	return feePerKb, nil
+}
+
+// scriptTypeOfAddress returns the script type of the given address, or false if the address type
+// does not correspond to a script type supported by the signing configurations.
+func scriptTypeOfAddress(address btcutil.Address) (signing.ScriptType, bool) {
+	switch address.(type) {
+	case *btcutil.AddressPubKeyHash:
+		return signing.ScriptTypeP2PKH, true
+	case *btcutil.AddressScriptHash:
+		// Could be any P2SH script, but p2wpkh-p2sh is by far the most common one.
+		return signing.ScriptTypeP2WPKHP2SH, true
+	case *btcutil.AddressWitnessPubKeyHash:
+		return signing.ScriptTypeP2WPKH, true
+	case *btcutil.AddressTaproot:
+		return signing.ScriptTypeP2TR, true
+	default:
+		return "", false
+	}
+}
+
+// newestScriptTypes lists the script types from newest to oldest.
+var newestScriptTypes = []signing.ScriptType{
+	signing.ScriptTypeP2TR,
+	signing.ScriptTypeP2WPKH,
+	signing.ScriptTypeP2WPKHP2SH,
+	signing.ScriptTypeP2PKH,
+}
+
+// unusedChangeAddress returns the first unused change address of the subaccount at the given
+// index.
+func (account *Account) unusedChangeAddress(subaccountIndex int) (*addresses.AccountAddress, error) {
+	unusedAddresses, err := account.subaccounts[subaccountIndex].changeAddresses.GetUnused()
+	if err != nil {
+		return nil, err
+	}
+	return unusedAddresses[0], nil
+}
+
 // pickChangeAddress returns a suitable unused change address to be used when making a transaction.
 // If the account is a unified account with multiple subaccounts (script/address types), we choose
-// the change address type like this:
+// the change address type according to the change policy. With `accounts.ChangePolicyDefault`, it
+// works like this:
 //
 // - If there is at least one P2TR UTXO, the change address will be a P2TR change address.
 // - Otherwise we pick P2WPKH if available.
@@ -84,44 +143,52 @@ func (account *Account) getFeePerKb(args *accounts.TxProposalArgs) (btcutil.Amou
 // with Taproot changes. This ensures that also users who received on Taproot and broke their
 // watch-only tools can fix it by moving the coins back to P2WPKH, and not have them go a Taproot
 // change again by accident.
-func (account *Account) pickChangeAddress(utxos map[wire.OutPoint]maketx.UTXO) (*addresses.AccountAddress, error) {
+//
+// With `accounts.ChangePolicyMatchRecipient`, the change gets the same script type as the
+// recipient, so that the change output can't be identified by its script type. With
+// `accounts.ChangePolicyNewest`, the newest script type of the account is used.
+func (account *Account) pickChangeAddress(
+	utxos map[wire.OutPoint]maketx.UTXO,
+	recipient btcutil.Address,
+	policy accounts.ChangePolicy,
+) (*addresses.AccountAddress, error) {
 	if len(account.subaccounts) == 1 {
-		unusedAddresses, err := account.subaccounts[0].changeAddresses.GetUnused()
-		if err != nil {
-			return nil, err
+		return account.unusedChangeAddress(0)
+	}
+
+	signingConfigurations := account.subaccounts.signingConfigurations()
+	switch policy {
+	case accounts.ChangePolicyMatchRecipient:
+		if scriptType, ok := scriptTypeOfAddress(recipient); ok {
+			if index := signingConfigurations.FindScriptType(scriptType); index >= 0 {
+				return account.unusedChangeAddress(index)
+			}
+		}
+	case accounts.ChangePolicyNewest:
+		for _, scriptType := range newestScriptTypes {
+			if index := signingConfigurations.FindScriptType(scriptType); index >= 0 {
+				return account.unusedChangeAddress(index)
+			}
 		}
-		return unusedAddresses[0], nil
 	}
 
-	p2trIndex := account.subaccounts.signingConfigurations().FindScriptType(signing.ScriptTypeP2TR)
+	p2trIndex := signingConfigurations.FindScriptType(signing.ScriptTypeP2TR)
 	if p2trIndex >= 0 {
 		// Check if there is at least one taproot UTXO.
 		for _, utxo := range utxos {
 			if utxo.Configuration.ScriptType() == signing.ScriptTypeP2TR {
 				// Found a taproot UTXO.
-				unusedAddresses, err := account.subaccounts[p2trIndex].changeAddresses.GetUnused()
-				if err != nil {
-					return nil, err
-				}
-				return unusedAddresses[0], nil
+				return account.unusedChangeAddress(p2trIndex)
 			}
 		}
 	}
 
-	p2wpkhIndex := account.subaccounts.signingConfigurations().FindScriptType(signing.ScriptTypeP2WPKH)
+	p2wpkhIndex := signingConfigurations.FindScriptType(signing.ScriptTypeP2WPKH)
 	if p2wpkhIndex >= 0 {
-		unusedAddresses, err := account.subaccounts[p2wpkhIndex].changeAddresses.GetUnused()
-		if err != nil {
-			return nil, err
-		}
-		return unusedAddresses[0], nil
+		return account.unusedChangeAddress(p2wpkhIndex)
 	}
 
-	unusedAddresses, err := account.subaccounts[0].changeAddresses.GetUnused()
-	if err != nil {
-		return nil, err
-	}
-	return unusedAddresses[0], nil
+	return account.unusedChangeAddress(0)
 }
 
 // newTx creates a new tx to the given recipient address. It also returns a set of used account
@@ -145,14 +212,21 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (
 	if err != nil {
 		return nil, nil, err
 	}
+	spendUnconfirmedChange := account.Config().AppConfig().Backend.SpendUnconfirmedChange
 	wireUTXO := make(map[wire.OutPoint]maketx.UTXO, len(utxo))
 	for outPoint, txOut := range utxo {
+		if txOut.Unconfirmed && !spendUnconfirmedChange {
+			continue
+		}
 		// Apply coin control.
 		if len(args.SelectedUTXOs) != 0 {
 			if _, ok := args.SelectedUTXOs[outPoint]; !ok {
 				continue
 			}
 		}
+		if account.utxoReservedByOthers(outPoint, args.ProposalID) {
+			continue
+		}
 		wireUTXO[outPoint] = maketx.UTXO{
 			TxOut: txOut.TxOut,
 			Configuration: account.getAddress(
@@ -164,6 +238,12 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (
 		return nil, nil, err
 	}
 
+	// Fail instead of silently leaving out selected coins which can't be spent, so that the tx is
+	// funded from the coins the user chose. When sending all, exactly the selected coins are swept.
+	if err := checkSelectedUTXOsAvailable(args.SelectedUTXOs, wireUTXO); err != nil {
+		return nil, nil, err
+	}
+
 	var txProposal *maketx.TxProposal
 	if args.Amount.SendAll() {
 		txProposal, err = maketx.NewTxSpendAll(
@@ -191,7 +271,7 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (
 		if err != nil {
 			return nil, nil, errp.WithStack(errors.ErrInvalidAmount)
 		}
-		changeAddress, err := account.pickChangeAddress(wireUTXO)
+		changeAddress, err := account.pickChangeAddress(wireUTXO, address, args.ChangePolicy)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -202,17 +282,78 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (
 			wire.NewTxOut(parsedAmountInt64, pkScript),
 			feeRatePerKb,
 			changeAddress,
+			account.Config().AppConfig().Backend.RoundChange,
 			account.log,
 		)
 		if err != nil {
 			return nil, nil, err
 		}
 	}
+	policies, err := account.coin.NetworkPolicies()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkPolicies(txProposal, wireUTXO, policies); err != nil {
+		return nil, nil, err
+	}
+	if !args.AllowHighFee {
+		if err := account.checkFee(txProposal, feeRatePerKb); err != nil {
+			return nil, nil, err
+		}
+	}
 	account.log.Debugf("creating tx with %d inputs, %d outputs",
 		len(txProposal.Transaction.TxIn), len(txProposal.Transaction.TxOut))
 	return utxo, txProposal, nil
 }
 
+// checkSelectedUTXOsAvailable returns ErrSelectedUTXOUnavailable if one of the selected outpoints
+// is not among the available UTXOs.
+func checkSelectedUTXOsAvailable(
+	selectedUTXOs map[wire.OutPoint]struct{}, available map[wire.OutPoint]maketx.UTXO) error {
+	for outPoint := range selectedUTXOs {
+		if _, ok := available[outPoint]; !ok {
+			return errp.WithStack(errors.ErrSelectedUTXOUnavailable)
+		}
+	}
+	return nil
+}
+
+// feeTooHigh returns true if the fee rate exceeds maxEconomyMultiple times the economical fee rate,
+// or if the fee exceeds maxPercent percent of the sent amount. A limit of 0 disables the respective
+// check.
+func feeTooHigh(
+	fee, amount, feeRatePerKb, economyFeeRatePerKb btcutil.Amount,
+	maxEconomyMultiple, maxPercent float64,
+) bool {
+	if maxEconomyMultiple > 0 && economyFeeRatePerKb > 0 &&
+		float64(feeRatePerKb) > maxEconomyMultiple*float64(economyFeeRatePerKb) {
+		return true
+	}
+	return maxPercent > 0 && float64(fee) > maxPercent/100*float64(amount)
+}
+
+// checkFee returns ErrFeeTooHigh if the fee of the tx proposal is unreasonably high according to
+// the configured limits.
+func (account *Account) checkFee(txProposal *maketx.TxProposal, feeRatePerKb btcutil.Amount) error {
+	config := account.Config().AppConfig().Backend
+	// If there is no fee estimate, only the share of the amount is checked.
+	economyFeeRatePerKb, err := account.lowestFeeRate()
+	if err != nil {
+		economyFeeRatePerKb = 0
+	}
+	if feeTooHigh(txProposal.Fee, txProposal.Amount, feeRatePerKb, economyFeeRatePerKb,
+		config.MaxFeeEconomyMultiple, config.MaxFeePercent) {
+		account.log.WithFields(logrus.Fields{
+			"fee":          txProposal.Fee,
+			"amount":       txProposal.Amount,
+			"fee-rate":     feeRatePerKb,
+			"economy-rate": economyFeeRatePerKb,
+		}).Info("Rejecting tx proposal with a high fee")
+		return errp.WithStack(errors.ErrFeeTooHigh)
+	}
+	return nil
+}
+
 // getAddress returns the address in the account with the given `scriptHashHex`. Returns nil if the
 // address does not exist in the account.
 func (account *Account) getAddress(scriptHashHex blockchain.ScriptHashHex) *addresses.AccountAddress {
@@ -227,17 +368,35 @@ func (account *Account) getAddress(scriptHashHex blockchain.ScriptHashHex) *addr
 	return nil
 }
 
+// lookupChangeAddress returns the change address of the account with the given `scriptHashHex`.
+// Returns nil if it is not a change address of the account.
+func (account *Account) lookupChangeAddress(scriptHashHex blockchain.ScriptHashHex) *addresses.AccountAddress {
+	for _, subacc := range account.subaccounts {
+		if address := subacc.changeAddresses.LookupByScriptHashHex(scriptHashHex); address != nil {
+			return address
+		}
+	}
+	return nil
+}
+
 // SendTx implements accounts.Interface.
 func (account *Account) SendTx() error {
 	unlock := account.activeTxProposalLock.RLock()
 	txProposal := account.activeTxProposal
 	unlock()
+	interruptedTxProposal, _ := account.BaseAccount.TakeInterruptedProposal().(*maketx.TxProposal)
+	if txProposal == nil {
+		txProposal = interruptedTxProposal
+	}
 	if txProposal == nil {
 		return errp.New("No active tx proposal")
 	}
 
 	account.log.Info("Signing and sending transaction")
 	if err := account.signTransaction(txProposal, account.coin.Blockchain().TransactionGet); err != nil {
+		if errp.Cause(err) == keystore.ErrKeystoreDisconnected {
+			account.BaseAccount.SigningInterrupted(txProposal)
+		}
 		return errp.WithMessage(err, "Failed to sign transaction")
 	}
 
@@ -245,6 +404,7 @@ func (account *Account) SendTx() error {
 	if err := account.coin.Blockchain().TransactionBroadcast(txProposal.Transaction); err != nil {
 		return err
 	}
+	account.releaseTxInputs(txProposal.Transaction)
 
 	note := account.BaseAccount.GetAndClearProposedTxNote()
 	if err := account.SetTxNote(txProposal.Transaction.TxHash().String(), note); err != nil {
@@ -254,6 +414,70 @@ func (account *Account) SendTx() error {
 	return nil
 }
 
+// TxProposalDetails contains BTC specific details about a tx proposal, in addition to the ones
+// returned by TxProposal().
+type TxProposalDetails struct {
+	// ChangeRoundingFee is the part of the fee that results from rounding the change, see
+	// `config.Backend.RoundChange`.
+	ChangeRoundingFee btcutil.Amount
+	// PrivacyScore rates the privacy of the tx. It is nil if it could not be computed.
+	PrivacyScore *PrivacyScore
+	// SpendsUnconfirmedChange is true if the tx spends unconfirmed change, so it cannot confirm
+	// before the tx of the change does.
+	SpendsUnconfirmedChange bool
+	// VSize is the estimated virtual size of the tx once it is signed, in vbytes.
+	VSize int64
+}
+
+// txProposalVSize estimates the virtual size of the tx proposal once its inputs are signed.
+func (account *Account) txProposalVSize(txProposal *maketx.TxProposal) int64 {
+	utxos := make(map[wire.OutPoint]maketx.UTXO, len(txProposal.PreviousOutputs))
+	for outPoint, output := range txProposal.PreviousOutputs {
+		utxos[outPoint] = maketx.UTXO{
+			TxOut:         output.TxOut,
+			Configuration: account.getAddress(blockchain.NewScriptHashHex(output.TxOut.PkScript)).Configuration,
+		}
+	}
+	return int64((maketx.EstimateWeight(txProposal.Transaction, utxos) + 3) / 4)
+}
+
+// spendsUnconfirmedChange returns true if one of the inputs of the tx proposal is unconfirmed.
+func (account *Account) spendsUnconfirmedChange(txProposal *maketx.TxProposal) (bool, error) {
+	utxo, err := account.transactions.SpendableOutputs()
+	if err != nil {
+		return false, err
+	}
+	for _, txIn := range txProposal.Transaction.TxIn {
+		if output, ok := utxo[txIn.PreviousOutPoint]; ok && output.Unconfirmed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ActiveTxProposalDetails returns details about the active tx proposal, set by TxProposal(). Returns
+// nil if there is no active tx proposal.
+func (account *Account) ActiveTxProposalDetails() *TxProposalDetails {
+	defer account.activeTxProposalLock.RLock()()
+	if account.activeTxProposal == nil {
+		return nil
+	}
+	privacyScore, err := account.txProposalPrivacyScore(account.activeTxProposal)
+	if err != nil {
+		account.log.WithError(err).Error("Could not compute the privacy score of the tx proposal")
+	}
+	spendsUnconfirmedChange, err := account.spendsUnconfirmedChange(account.activeTxProposal)
+	if err != nil {
+		account.log.WithError(err).Error("Could not check the inputs of the tx proposal")
+	}
+	return &TxProposalDetails{
+		ChangeRoundingFee:       account.activeTxProposal.ChangeRoundingFee,
+		PrivacyScore:            privacyScore,
+		SpendsUnconfirmedChange: spendsUnconfirmedChange,
+		VSize:                   account.txProposalVSize(account.activeTxProposal),
+	}
+}
+
 // TxProposal creates a tx from the relevant input and returns information about it for display in
 // the UI (the output amount and the fee). At the same time, it validates the input. The proposal is
 // stored internally and can be signed and sent with SendTx().
@@ -264,12 +488,14 @@ func (account *Account) TxProposal(
 	defer account.activeTxProposalLock.Lock()()
 
 	account.log.Debug("Proposing transaction")
+	account.BaseAccount.DropInterruptedProposal()
 	_, txProposal, err := account.newTx(args)
 	if err != nil {
 		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
 	}
 
 	account.activeTxProposal = txProposal
+	account.reserveTxProposalInputs(args.ProposalID, txProposal.Transaction)
 
 	account.log.WithField("fee", txProposal.Fee).Debug("Returning fee")
 	return coin.NewAmountFromInt64(int64(txProposal.Amount)),
@@ -0,0 +1,292 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	addressesTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses/test"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	blockchainMock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/proxy"
+)
+
+func TestScriptTypeOfAddress(t *testing.T) {
+	net := &chaincfg.TestNet3Params
+
+	p2pkh, err := btcutil.NewAddressPubKeyHash(make([]byte, 20), net)
+	require.NoError(t, err)
+	p2sh, err := btcutil.NewAddressScriptHashFromHash(make([]byte, 20), net)
+	require.NoError(t, err)
+	p2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20), net)
+	require.NoError(t, err)
+	p2wsh, err := btcutil.NewAddressWitnessScriptHash(make([]byte, 32), net)
+	require.NoError(t, err)
+	p2tr, err := btcutil.NewAddressTaproot(make([]byte, 32), net)
+	require.NoError(t, err)
+
+	for address, expected := range map[btcutil.Address]signing.ScriptType{
+		p2pkh:  signing.ScriptTypeP2PKH,
+		p2sh:   signing.ScriptTypeP2WPKHP2SH,
+		p2wpkh: signing.ScriptTypeP2WPKH,
+		p2tr:   signing.ScriptTypeP2TR,
+	} {
+		scriptType, ok := scriptTypeOfAddress(address)
+		require.True(t, ok)
+		require.Equal(t, expected, scriptType)
+	}
+
+	_, ok := scriptTypeOfAddress(p2wsh)
+	require.False(t, ok)
+}
+
+// unifiedAccount returns an initialized testnet account with a P2WPKH-P2SH, a P2WPKH and a P2TR
+// subaccount, in that order.
+func unifiedAccount(t *testing.T) *Account {
+	t.Helper()
+	net := &chaincfg.TestNet3Params
+	dbFolder := test.TstTempDir("btc-unified-dbfolder")
+	btcCoin := NewCoin(
+		coin.CodeTBTC, "Bitcoin Testnet", "TBTC", coin.BtcUnitDefault, net, dbFolder, nil, "", proxy.Direct)
+	mockBlockchain := &blockchainMock.BlockchainMock{}
+	mockBlockchain.MockRegisterOnConnectionErrorChangedEvent = func(f func(error)) {}
+	btcCoin.TstSetMakeBlockchain(func() blockchain.Interface { return mockBlockchain })
+
+	master, err := hdkeychain.NewMaster(make([]byte, 32), net)
+	require.NoError(t, err)
+	signingConfigurations := signing.Configurations{}
+	for _, scriptType := range []signing.ScriptType{
+		signing.ScriptTypeP2WPKHP2SH, signing.ScriptTypeP2WPKH, signing.ScriptTypeP2TR,
+	} {
+		purpose := map[signing.ScriptType]string{
+			signing.ScriptTypeP2WPKHP2SH: "49'",
+			signing.ScriptTypeP2WPKH:     "84'",
+			signing.ScriptTypeP2TR:       "86'",
+		}[scriptType]
+		keypath, err := signing.NewAbsoluteKeypath("m/" + purpose + "/1'/0'")
+		require.NoError(t, err)
+		xprv, err := keypath.Derive(master)
+		require.NoError(t, err)
+		xpub, err := xprv.Neuter()
+		require.NoError(t, err)
+		signingConfigurations = append(signingConfigurations,
+			signing.NewBitcoinConfiguration(scriptType, []byte{1, 2, 3, 4}, keypath, xpub))
+	}
+	account := NewAccount(
+		&accounts.AccountConfig{
+			Config: &config.Account{
+				Code:                  "accountcode",
+				Name:                  "accountname",
+				SigningConfigurations: signingConfigurations,
+			},
+			DBFolder:    dbFolder,
+			NotesFolder: test.TstTempDir("btc-unified-notesfolder"),
+			OnEvent:     func(accountsTypes.Event) {},
+			GetNotifier: func(signing.Configurations) accounts.Notifier { return nil },
+		},
+		btcCoin, nil, logging.Get().WithGroup("transaction_internal_test"), nil,
+	)
+	require.NoError(t, account.Initialize())
+	t.Cleanup(account.Close)
+	return account
+}
+
+func TestPickChangeAddress(t *testing.T) {
+	account := unifiedAccount(t)
+	net := account.coin.Net()
+
+	p2pkhRecipient, err := btcutil.NewAddressPubKeyHash(make([]byte, 20), net)
+	require.NoError(t, err)
+	p2shRecipient, err := btcutil.NewAddressScriptHashFromHash(make([]byte, 20), net)
+	require.NoError(t, err)
+	p2wpkhRecipient, err := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20), net)
+	require.NoError(t, err)
+	p2trRecipient, err := btcutil.NewAddressTaproot(make([]byte, 32), net)
+	require.NoError(t, err)
+
+	utxos := func(scriptTypes ...signing.ScriptType) map[wire.OutPoint]maketx.UTXO {
+		result := map[wire.OutPoint]maketx.UTXO{}
+		for i, scriptType := range scriptTypes {
+			index := account.subaccounts.signingConfigurations().FindScriptType(scriptType)
+			result[wire.OutPoint{Index: uint32(i)}] = maketx.UTXO{
+				Configuration: account.subaccounts[index].signingConfiguration,
+			}
+		}
+		return result
+	}
+
+	checkChange := func(
+		expected signing.ScriptType,
+		utxos map[wire.OutPoint]maketx.UTXO,
+		recipient btcutil.Address,
+		policy accounts.ChangePolicy,
+	) {
+		t.Helper()
+		changeAddress, err := account.pickChangeAddress(utxos, recipient, policy)
+		require.NoError(t, err)
+		require.Equal(t, expected, changeAddress.Configuration.ScriptType())
+		// The first unused address of the change chain of the picked subaccount.
+		index := account.subaccounts.signingConfigurations().FindScriptType(expected)
+		unused, err := account.subaccounts[index].changeAddresses.GetUnused()
+		require.NoError(t, err)
+		require.Equal(t, unused[0], changeAddress)
+		require.True(t, strings.HasSuffix(changeAddress.AbsoluteKeypath().Encode(), "/1/0"))
+	}
+
+	t.Run("default", func(t *testing.T) {
+		// The change is P2TR if a P2TR UTXO is spent, and P2WPKH otherwise.
+		checkChange(signing.ScriptTypeP2TR,
+			utxos(signing.ScriptTypeP2WPKH, signing.ScriptTypeP2TR), p2wpkhRecipient, accounts.ChangePolicyDefault)
+		checkChange(signing.ScriptTypeP2WPKH,
+			utxos(signing.ScriptTypeP2WPKHP2SH), p2trRecipient, accounts.ChangePolicyDefault)
+	})
+
+	t.Run("match recipient", func(t *testing.T) {
+		checkChange(signing.ScriptTypeP2TR,
+			utxos(signing.ScriptTypeP2WPKH), p2trRecipient, accounts.ChangePolicyMatchRecipient)
+		checkChange(signing.ScriptTypeP2WPKHP2SH,
+			utxos(signing.ScriptTypeP2TR), p2shRecipient, accounts.ChangePolicyMatchRecipient)
+		checkChange(signing.ScriptTypeP2WPKH,
+			utxos(signing.ScriptTypeP2TR), p2wpkhRecipient, accounts.ChangePolicyMatchRecipient)
+		// The account has no P2PKH subaccount, so the default policy applies, matching the inputs.
+		checkChange(signing.ScriptTypeP2TR,
+			utxos(signing.ScriptTypeP2TR), p2pkhRecipient, accounts.ChangePolicyMatchRecipient)
+		checkChange(signing.ScriptTypeP2WPKH,
+			utxos(signing.ScriptTypeP2WPKHP2SH), p2pkhRecipient, accounts.ChangePolicyMatchRecipient)
+	})
+
+	t.Run("newest", func(t *testing.T) {
+		checkChange(signing.ScriptTypeP2TR,
+			utxos(signing.ScriptTypeP2WPKHP2SH), p2wpkhRecipient, accounts.ChangePolicyNewest)
+		checkChange(signing.ScriptTypeP2TR,
+			utxos(signing.ScriptTypeP2WPKH), p2shRecipient, accounts.ChangePolicyNewest)
+	})
+}
+
+func TestCheckSelectedUTXOsAvailable(t *testing.T) {
+	outPoint1 := wire.OutPoint{Hash: chainhash.Hash{1}, Index: 0}
+	outPoint2 := wire.OutPoint{Hash: chainhash.Hash{2}, Index: 1}
+	available := map[wire.OutPoint]maketx.UTXO{
+		outPoint1: {},
+		outPoint2: {},
+	}
+	// No coin control.
+	require.NoError(t, checkSelectedUTXOsAvailable(nil, available))
+	require.NoError(t, checkSelectedUTXOsAvailable(
+		map[wire.OutPoint]struct{}{outPoint1: {}, outPoint2: {}}, available))
+	err := checkSelectedUTXOsAvailable(
+		map[wire.OutPoint]struct{}{outPoint1: {}, {Hash: chainhash.Hash{3}}: {}}, available)
+	require.Equal(t, errors.ErrSelectedUTXOUnavailable, errp.Cause(err))
+}
+
+func TestFeeTooHigh(t *testing.T) {
+	// Reasonable fee.
+	require.False(t, feeTooHigh(1000, 100000, 5000, 1000, 10, 25))
+	// Fee rate more than 10 times the economical rate.
+	require.True(t, feeTooHigh(1000, 100000, 10001, 1000, 10, 25))
+	// Fee more than 25% of the amount.
+	require.True(t, feeTooHigh(25001, 100000, 5000, 1000, 10, 25))
+	require.False(t, feeTooHigh(25000, 100000, 5000, 1000, 10, 25))
+	// Disabled checks.
+	require.False(t, feeTooHigh(50000, 100000, 20000, 1000, 0, 0))
+	// Unknown economical fee rate.
+	require.False(t, feeTooHigh(1000, 100000, 20000, 0, 10, 25))
+}
+
+func TestParseCustomFeeRate(t *testing.T) {
+	const minRelayFeeRate = btcutil.Amount(1000)
+	for customFee, expected := range map[string]btcutil.Amount{
+		"1":         1000,
+		"2.5":       2500,
+		"12.345":    12345,
+		" 3 ":       3000,
+		"10000":     maxCustomFeeRatePerKb,
+		"10000.000": maxCustomFeeRatePerKb,
+	} {
+		feeRatePerKb, err := parseCustomFeeRate(customFee, minRelayFeeRate, false)
+		require.NoError(t, err, customFee)
+		require.Equal(t, expected, feeRatePerKb, customFee)
+	}
+
+	for _, customFee := range []string{"", "abc", "1.2345", "1/2", "-1", "NaN", "Inf", "1e100"} {
+		_, err := parseCustomFeeRate(customFee, minRelayFeeRate, false)
+		require.Equal(t, errors.ErrInvalidFeeRate, errp.Cause(err), customFee)
+	}
+
+	for _, customFee := range []string{"0", "0.999"} {
+		_, err := parseCustomFeeRate(customFee, minRelayFeeRate, false)
+		require.Equal(t, errors.ErrFeeTooLow, errp.Cause(err), customFee)
+	}
+	// Without a min relay fee, zero is still rejected.
+	_, err := parseCustomFeeRate("0", 0, false)
+	require.Equal(t, errors.ErrFeeTooLow, errp.Cause(err))
+
+	// Absurd fee rates must be explicitly allowed.
+	_, err = parseCustomFeeRate("10000.001", minRelayFeeRate, false)
+	require.Equal(t, errors.ErrFeeTooHigh, errp.Cause(err))
+	feeRatePerKb, err := parseCustomFeeRate("10000.001", minRelayFeeRate, true)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(10000001), feeRatePerKb)
+}
+
+func TestCheckPolicies(t *testing.T) {
+	address := addressesTest.GetAddress(signing.ScriptTypeP2WPKH)
+	policies := &NetworkPolicies{
+		MinRelayFeeRate:     1000,
+		DustRelayFeeRate:    3000,
+		MaxStandardTxWeight: 400000,
+	}
+	makeProposal := func(numInputs int, value int64) (*maketx.TxProposal, map[wire.OutPoint]maketx.UTXO) {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		utxos := map[wire.OutPoint]maketx.UTXO{}
+		for index := 0; index < numInputs; index++ {
+			outPoint := wire.OutPoint{Index: uint32(index)}
+			tx.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+			utxos[outPoint] = maketx.UTXO{Configuration: address.Configuration}
+		}
+		tx.AddTxOut(wire.NewTxOut(value, address.PubkeyScript()))
+		return &maketx.TxProposal{Transaction: tx}, utxos
+	}
+
+	txProposal, utxos := makeProposal(1, 294)
+	require.NoError(t, checkPolicies(txProposal, utxos, policies))
+
+	// The dust limit of P2WPKH outputs at 3 sat/vB is 294 sat.
+	txProposal, utxos = makeProposal(1, 293)
+	require.Equal(t, errors.ErrDustAmount, errp.Cause(checkPolicies(txProposal, utxos, policies)))
+
+	// 1400 P2WPKH inputs fit into the max standard tx weight, 1500 do not.
+	txProposal, utxos = makeProposal(1400, 1000)
+	require.NoError(t, checkPolicies(txProposal, utxos, policies))
+	txProposal, utxos = makeProposal(1500, 1000)
+	require.Equal(t, errors.ErrTxTooLarge, errp.Cause(checkPolicies(txProposal, utxos, policies)))
+}
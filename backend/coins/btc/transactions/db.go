@@ -71,6 +71,10 @@ type DBTxInterface interface {
 	// MarkTxVerified marks a tx as verified. Stores timestamp of the header this tx appears in.
 	MarkTxVerified(txHash chainhash.Hash, headerTimestamp time.Time) error
 
+	// MarkTxUnverified marks a verified tx as unverified again and removes the header timestamp,
+	// e.g. because its block was replaced in a reorg.
+	MarkTxUnverified(txHash chainhash.Hash) error
+
 	// PutInput stores a transaction input. It is referenced by the output it spends. The
 	// transaction hash of the transaction this input was found in is recorded. TODO: store slice of
 	// inputs along with the txhash they appear in. If there are more than one, a double spend is
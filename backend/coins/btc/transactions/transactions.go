@@ -32,9 +32,16 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// NumConfirmationsComplete is the default number of confirmations after which a transaction is
+// considered complete. The user can configure a different one, see
+// accounts.ApplyConfirmationThreshold().
+const NumConfirmationsComplete = 6
+
 // SpendableOutput is an unspent coin.
 type SpendableOutput struct {
 	*wire.TxOut
+	// Unconfirmed is true if the output is the unconfirmed change of one of our own transactions.
+	Unconfirmed bool
 }
 
 // ScriptHashHex returns the hash of the PkScript of the output, in hex format.
@@ -212,7 +219,8 @@ func (transactions *Transactions) SpendableOutputs() (map[wire.OutPoint]*Spendab
 
 				if confirmed || transactions.allInputsOurs(dbTx, txInfo.Tx) {
 					result[outPoint] = &SpendableOutput{
-						TxOut: txOut,
+						TxOut:       txOut,
+						Unconfirmed: !confirmed,
 					}
 				}
 			}
@@ -221,6 +229,178 @@ func (transactions *Transactions) SpendableOutputs() (map[wire.OutPoint]*Spendab
 	})
 }
 
+// UnspentOutput is an unspent output of the wallet.
+type UnspentOutput struct {
+	*wire.TxOut
+	// Incoming is true if the output is not spendable yet, see Balance().
+	Incoming bool
+}
+
+// UnspentOutputs returns all unspent outputs of the wallet, i.e. all outputs which make up the
+// available and the incoming balance.
+func (transactions *Transactions) UnspentOutputs() (map[wire.OutPoint]*UnspentOutput, error) {
+	transactions.synchronizer.WaitSynchronized()
+	return DBView(transactions.db, func(dbTx DBTxInterface) (map[wire.OutPoint]*UnspentOutput, error) {
+		outputs, err := dbTx.Outputs()
+		if err != nil {
+			return nil, err
+		}
+		result := map[wire.OutPoint]*UnspentOutput{}
+		for outPoint, txOut := range outputs {
+			if spent := transactions.isInputSpent(dbTx, outPoint); spent {
+				continue
+			}
+			txInfo, err := dbTx.TxInfo(outPoint.Hash)
+			if err != nil {
+				return nil, err
+			}
+			confirmed := txInfo.Height > 0
+			result[outPoint] = &UnspentOutput{
+				TxOut:    txOut,
+				Incoming: !confirmed && !transactions.allInputsOurs(dbTx, txInfo.Tx),
+			}
+		}
+		return result, nil
+	})
+}
+
+// ConfirmedOutput is an output of the wallet and the height of the block it was confirmed in.
+type ConfirmedOutput struct {
+	*wire.TxOut
+	Height int
+}
+
+// UnspentOutputsAt returns the utxo set of the wallet as it was at the given block height: all
+// outputs confirmed at or below the height which were not spent by a transaction confirmed at or
+// below the height. Unconfirmed transactions are ignored.
+func (transactions *Transactions) UnspentOutputsAt(height int) (map[wire.OutPoint]*ConfirmedOutput, error) {
+	transactions.synchronizer.WaitSynchronized()
+	return DBView(transactions.db, func(dbTx DBTxInterface) (map[wire.OutPoint]*ConfirmedOutput, error) {
+		outputs, err := dbTx.Outputs()
+		if err != nil {
+			return nil, err
+		}
+		confirmedAt := func(txHash chainhash.Hash) (bool, int, error) {
+			txInfo, err := dbTx.TxInfo(txHash)
+			if err != nil {
+				return false, 0, err
+			}
+			if txInfo == nil || txInfo.Height <= 0 || txInfo.Height > height {
+				return false, 0, nil
+			}
+			return true, txInfo.Height, nil
+		}
+		result := map[wire.OutPoint]*ConfirmedOutput{}
+		for outPoint, txOut := range outputs {
+			confirmed, outputHeight, err := confirmedAt(outPoint.Hash)
+			if err != nil {
+				return nil, err
+			}
+			if !confirmed {
+				continue
+			}
+			spendingTxHash, err := dbTx.Input(outPoint)
+			if err != nil {
+				return nil, err
+			}
+			if spendingTxHash != nil {
+				spent, _, err := confirmedAt(*spendingTxHash)
+				if err != nil {
+					return nil, err
+				}
+				if spent {
+					continue
+				}
+			}
+			result[outPoint] = &ConfirmedOutput{TxOut: txOut, Height: outputHeight}
+		}
+		return result, nil
+	})
+}
+
+// ReplaceableTx returns the transaction with the given hash and the outputs spent by it, if it can
+// be replaced by a transaction spending the same inputs: it must be unconfirmed, all of its inputs
+// must be ours and none of its outputs may be spent, as replacing it would evict the spending
+// transactions from the mempool. A nil transaction is returned otherwise.
+func (transactions *Transactions) ReplaceableTx(txHash chainhash.Hash) (
+	*wire.MsgTx, map[wire.OutPoint]*wire.TxOut, error) {
+	transactions.synchronizer.WaitSynchronized()
+	type result struct {
+		tx           *wire.MsgTx
+		spentOutputs map[wire.OutPoint]*wire.TxOut
+	}
+	r, err := DBView(transactions.db, func(dbTx DBTxInterface) (*result, error) {
+		txInfo, err := dbTx.TxInfo(txHash)
+		if err != nil {
+			return nil, err
+		}
+		if txInfo == nil || txInfo.Tx == nil || txInfo.Height > 0 {
+			return nil, nil
+		}
+		spentOutputs := map[wire.OutPoint]*wire.TxOut{}
+		for _, txIn := range txInfo.Tx.TxIn {
+			txOut, err := dbTx.Output(txIn.PreviousOutPoint)
+			if err != nil {
+				return nil, err
+			}
+			if txOut == nil {
+				return nil, nil
+			}
+			spentOutputs[txIn.PreviousOutPoint] = txOut
+		}
+		for index := range txInfo.Tx.TxOut {
+			if transactions.isInputSpent(dbTx, wire.OutPoint{Hash: txHash, Index: uint32(index)}) {
+				return nil, nil
+			}
+		}
+		return &result{tx: txInfo.Tx, spentOutputs: spentOutputs}, nil
+	})
+	if err != nil || r == nil {
+		return nil, nil, err
+	}
+	return r.tx, r.spentOutputs, nil
+}
+
+// UnconfirmedTxOutputs returns the unconfirmed transaction with the given hash and its unspent
+// outputs which belong to the wallet. A nil transaction is returned if the transaction is
+// confirmed or has no such outputs.
+func (transactions *Transactions) UnconfirmedTxOutputs(txHash chainhash.Hash) (
+	*wire.MsgTx, map[wire.OutPoint]*wire.TxOut, error) {
+	transactions.synchronizer.WaitSynchronized()
+	type result struct {
+		tx      *wire.MsgTx
+		outputs map[wire.OutPoint]*wire.TxOut
+	}
+	r, err := DBView(transactions.db, func(dbTx DBTxInterface) (*result, error) {
+		txInfo, err := dbTx.TxInfo(txHash)
+		if err != nil {
+			return nil, err
+		}
+		if txInfo == nil || txInfo.Tx == nil || txInfo.Height > 0 {
+			return nil, nil
+		}
+		outputs := map[wire.OutPoint]*wire.TxOut{}
+		for index := range txInfo.Tx.TxOut {
+			outPoint := wire.OutPoint{Hash: txHash, Index: uint32(index)}
+			txOut, err := dbTx.Output(outPoint)
+			if err != nil {
+				return nil, err
+			}
+			if txOut != nil && !transactions.isInputSpent(dbTx, outPoint) {
+				outputs[outPoint] = txOut
+			}
+		}
+		if len(outputs) == 0 {
+			return nil, nil
+		}
+		return &result{tx: txInfo.Tx, outputs: outputs}, nil
+	})
+	if err != nil || r == nil {
+		return nil, nil, err
+	}
+	return r.tx, r.outputs, nil
+}
+
 func (transactions *Transactions) isInputSpent(dbTx DBTxInterface, outPoint wire.OutPoint) bool {
 	input, err := dbTx.Input(outPoint)
 	if err != nil {
@@ -469,9 +649,8 @@ func (transactions *Transactions) txInfo(
 		numConfirmations = transactions.headersTipHeight - txInfo.Height + 1
 	}
 
-	const numConfirmationsComplete = 6
 	status := accounts.TxStatusPending
-	if numConfirmations >= numConfirmationsComplete {
+	if numConfirmations >= NumConfirmationsComplete {
 		status = accounts.TxStatusComplete
 	}
 	return &accounts.TransactionData{
@@ -480,7 +659,7 @@ func (transactions *Transactions) txInfo(
 		TxID:                     txInfo.TxHash.String(),
 		InternalID:               txInfo.TxHash.String(),
 		NumConfirmations:         numConfirmations,
-		NumConfirmationsComplete: numConfirmationsComplete,
+		NumConfirmationsComplete: NumConfirmationsComplete,
 		Height:                   txInfo.Height,
 		Status:                   status,
 		Type:                     txType,
@@ -226,6 +226,7 @@ func (s *transactionsSuite) TestSpendableOutputs() {
 	require.Len(s.T(), spendableOutputs, 2)
 	require.Contains(s.T(), spendableOutputs, wire.OutPoint{Hash: tx12.TxHash(), Index: 0})
 	require.Contains(s.T(), spendableOutputs, wire.OutPoint{Hash: tx22.TxHash(), Index: 0})
+	require.False(s.T(), spendableOutputs[wire.OutPoint{Hash: tx22.TxHash(), Index: 0}].Unconfirmed)
 	// Spend output generated from tx12 to an external address, the spend being unconfirmed => the
 	// output can't be spent anymore.
 	tx12Spend := newTx(tx12.TxHash(), 0, otherAddress, 1000)
@@ -256,6 +257,50 @@ func (s *transactionsSuite) TestSpendableOutputs() {
 	require.NotContains(s.T(), spendableOutputs, wire.OutPoint{Hash: tx22.TxHash(), Index: 0})
 	// Output from the spend tx address available.
 	require.Contains(s.T(), spendableOutputs, wire.OutPoint{Hash: tx22Spend.TxHash(), Index: 0})
+	require.True(s.T(), spendableOutputs[wire.OutPoint{Hash: tx22Spend.TxHash(), Index: 0}].Unconfirmed)
+}
+
+// TestUnspentOutputsAt checks that the utxo set at a block height only includes outputs confirmed
+// at or below the height, and ignores spends confirmed later.
+func (s *transactionsSuite) TestUnspentOutputsAt() {
+	addresses, err := s.addressChain.EnsureAddresses()
+	require.NoError(s.T(), err)
+	address := addresses[0]
+	otherAddress := addresses[2]
+	tx1 := newTx(chainhash.HashH(nil), 0, address, 1000)
+	tx2 := newTx(chainhash.HashH(nil), 1, address, 2000)
+	tx3 := newTx(chainhash.HashH(nil), 2, address, 3000)
+	tx1Spend := newTx(tx1.TxHash(), 0, otherAddress, 1000)
+	s.blockchainMock.RegisterTxs(tx1, tx2, tx3, tx1Spend)
+	s.headersMock.On("VerifiedHeaderByHeight", 10).Return(nil, nil).Once()
+	s.headersMock.On("VerifiedHeaderByHeight", 12).Return(nil, nil).Once()
+	s.headersMock.On("VerifiedHeaderByHeight", 14).Return(nil, nil).Once()
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 10},
+		{TXHash: blockchainpkg.TXHash(tx2.TxHash()), Height: 12},
+		{TXHash: blockchainpkg.TXHash(tx3.TxHash()), Height: 0},
+		{TXHash: blockchainpkg.TXHash(tx1Spend.TxHash()), Height: 14},
+	})
+
+	utxos, err := s.transactions.UnspentOutputsAt(9)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), utxos)
+
+	utxos, err = s.transactions.UnspentOutputsAt(13)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(),
+		map[wire.OutPoint]*transactions.ConfirmedOutput{
+			{Hash: tx1.TxHash(), Index: 0}: {TxOut: tx1.TxOut[0], Height: 10},
+			{Hash: tx2.TxHash(), Index: 0}: {TxOut: tx2.TxOut[0], Height: 12},
+		},
+		utxos,
+	)
+
+	// The spend of tx1 is confirmed at height 14.
+	utxos, err = s.transactions.UnspentOutputsAt(14)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), utxos, 1)
+	require.Contains(s.T(), utxos, wire.OutPoint{Hash: tx2.TxHash(), Index: 0})
 }
 
 func (s *transactionsSuite) TestBalance() {
@@ -371,3 +416,37 @@ func (s *transactionsSuite) TestRemoveTransaction() {
 	require.NoError(s.T(), err)
 	require.Len(s.T(), transactions, 2)
 }
+
+// TestRevalidateFrom checks that the addresses of the txs in the blocks replaced by a reorg are
+// returned, so that their history can be fetched again.
+func (s *transactionsSuite) TestRevalidateFrom() {
+	addresses, err := s.addressChain.EnsureAddresses()
+	require.NoError(s.T(), err)
+	address1 := addresses[0]
+	address2 := addresses[1]
+	address3 := addresses[2]
+	tx1 := newTx(chainhash.HashH(nil), 0, address1, 12)
+	tx2 := newTx(chainhash.HashH(nil), 1, address2, 34)
+	tx3 := newTx(chainhash.HashH(nil), 2, address3, 56)
+	s.blockchainMock.RegisterTxs(tx1, tx2, tx3)
+	s.headersMock.On("VerifiedHeaderByHeight", 10).Return(nil, nil)
+	s.headersMock.On("VerifiedHeaderByHeight", 12).Return(nil, nil)
+	s.updateAddressHistory(address1, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 10},
+	})
+	s.updateAddressHistory(address2, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx2.TxHash()), Height: 12},
+	})
+	// Unconfirmed txs are not affected by a reorg.
+	s.updateAddressHistory(address3, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx3.TxHash()), Height: 0},
+	})
+
+	require.Empty(s.T(), s.transactions.RevalidateFrom(13))
+	require.Equal(s.T(),
+		[]blockchainpkg.ScriptHashHex{address2.PubkeyScriptHashHex()},
+		s.transactions.RevalidateFrom(11))
+	require.ElementsMatch(s.T(),
+		[]blockchainpkg.ScriptHashHex{address1.PubkeyScriptHashHex(), address2.PubkeyScriptHashHex()},
+		s.transactions.RevalidateFrom(10))
+}
@@ -110,3 +110,44 @@ func (transactions *Transactions) verifyTransaction(txHash chainhash.Hash, heigh
 		transactions.log.WithError(err).Error("MarkTXVerified")
 	}
 }
+
+// RevalidateFrom is called after a reorg replaced the blocks from the given height on. The
+// transactions confirmed in these blocks are marked as unverified and verified again against the
+// new headers. The addresses touched by them are returned. Their history must be fetched again, as
+// some of the transactions might have moved to a different block or vanished.
+func (transactions *Transactions) RevalidateFrom(height int) []blockchain.ScriptHashHex {
+	addresses := map[blockchain.ScriptHashHex]struct{}{}
+	err := DBUpdate(transactions.db, func(dbTx DBTxInterface) error {
+		txHashes, err := dbTx.Transactions()
+		if err != nil {
+			return err
+		}
+		for _, txHash := range txHashes {
+			txInfo, err := dbTx.TxInfo(txHash)
+			if err != nil {
+				return err
+			}
+			if txInfo.Height <= 0 || txInfo.Height < height {
+				continue
+			}
+			if err := dbTx.MarkTxUnverified(txHash); err != nil {
+				return err
+			}
+			for address := range txInfo.Addresses {
+				addresses[blockchain.ScriptHashHex(address)] = struct{}{}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		transactions.log.WithError(err).Error("Failed to mark the transactions as unverified")
+		return nil
+	}
+	transactions.log.Infof("Revalidating the transactions from height %d", height)
+	transactions.verifyTransactions()
+	result := make([]blockchain.ScriptHashHex, 0, len(addresses))
+	for address := range addresses {
+		result = append(result, address)
+	}
+	return result
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"sort"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// maxEstimatedTxInOuts is the maximum number of inputs and of outputs of a tx estimated by
+// EstimateTxSize(). Standard txs are limited to 100kvB, which fits less than 1500 P2WPKH inputs.
+const maxEstimatedTxInOuts = 10000
+
+// TxSizeEstimateArgs describe the tx to be estimated by EstimateTxSize().
+type TxSizeEstimateArgs struct {
+	// InputCounts is the number of inputs per script type.
+	InputCounts map[signing.ScriptType]int
+	// UTXOs are spendable outputs of the account, which are spent in addition to InputCounts.
+	UTXOs []wire.OutPoint
+	// OutputCounts is the number of outputs per script type, including change.
+	OutputCounts map[signing.ScriptType]int
+}
+
+// TxFeeEstimate is the fee of an estimated tx at the fee rate of a fee target.
+type TxFeeEstimate struct {
+	FeeTargetCode accounts.FeeTargetCode
+	FeeRatePerKb  btcutil.Amount
+	Fee           btcutil.Amount
+}
+
+// TxSizeEstimate is the result of EstimateTxSize().
+type TxSizeEstimate struct {
+	// VSize is the worst case virtual size of the tx.
+	VSize int
+	// InputValue is the total value of the UTXOs in the args.
+	InputValue btcutil.Amount
+	// Fees are the fees of the tx at the fee rates of the fee targets which could be estimated.
+	Fees []TxFeeEstimate
+}
+
+// scriptTypesFromCounts returns the script types repeated by their counts, sorted by script type.
+func scriptTypesFromCounts(counts map[signing.ScriptType]int) ([]signing.ScriptType, error) {
+	sortedScriptTypes := make([]signing.ScriptType, 0, len(counts))
+	total := 0
+	for scriptType, count := range counts {
+		switch scriptType {
+		case signing.ScriptTypeP2PKH, signing.ScriptTypeP2WPKHP2SH,
+			signing.ScriptTypeP2WPKH, signing.ScriptTypeP2TR:
+		default:
+			return nil, errp.Newf("unknown script type %q", scriptType)
+		}
+		if count < 0 {
+			return nil, errp.Newf("invalid count %d", count)
+		}
+		total += count
+		if total > maxEstimatedTxInOuts {
+			return nil, errp.Newf("more than %d inputs or outputs", maxEstimatedTxInOuts)
+		}
+		sortedScriptTypes = append(sortedScriptTypes, scriptType)
+	}
+	sort.Slice(sortedScriptTypes, func(i, j int) bool { return sortedScriptTypes[i] < sortedScriptTypes[j] })
+	result := make([]signing.ScriptType, 0, total)
+	for _, scriptType := range sortedScriptTypes {
+		for i := 0; i < counts[scriptType]; i++ {
+			result = append(result, scriptType)
+		}
+	}
+	return result, nil
+}
+
+// estimateTxFees returns the fees of a tx of the given virtual size at the fee rates of the fee
+// targets. Fee targets which could not be estimated are skipped.
+func estimateTxFees(vsize int, feeTargets []*FeeTarget) []TxFeeEstimate {
+	fees := []TxFeeEstimate{}
+	for _, feeTarget := range feeTargets {
+		if feeTarget.feeRatePerKb == nil {
+			continue
+		}
+		fees = append(fees, TxFeeEstimate{
+			FeeTargetCode: feeTarget.code,
+			FeeRatePerKb:  *feeTarget.feeRatePerKb,
+			Fee:           *feeTarget.feeRatePerKb * btcutil.Amount(vsize) / 1000,
+		})
+	}
+	return fees
+}
+
+// EstimateTxSize estimates the virtual size of a tx and its fee at the current fee rates, without
+// creating a tx proposal. It helps planning consolidations and batch payments. The inputs are
+// given by their script types, or by spendable outputs of the account, or both.
+func (account *Account) EstimateTxSize(args *TxSizeEstimateArgs) (*TxSizeEstimate, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account not initialized")
+	}
+	inputScriptTypes, err := scriptTypesFromCounts(args.InputCounts)
+	if err != nil {
+		return nil, err
+	}
+	outputScriptTypes, err := scriptTypesFromCounts(args.OutputCounts)
+	if err != nil {
+		return nil, err
+	}
+	if len(args.UTXOs) > maxEstimatedTxInOuts-len(inputScriptTypes) {
+		return nil, errp.Newf("more than %d inputs", maxEstimatedTxInOuts)
+	}
+	var inputValue btcutil.Amount
+	var inputConfigurations []*signing.Configuration
+	if len(args.UTXOs) != 0 {
+		spendableOutputs := map[wire.OutPoint]*SpendableOutput{}
+		for _, output := range account.SpendableOutputs() {
+			spendableOutputs[output.OutPoint] = output
+		}
+		for _, outPoint := range args.UTXOs {
+			output, ok := spendableOutputs[outPoint]
+			if !ok {
+				return nil, errp.WithStack(errors.ErrSelectedUTXOUnavailable)
+			}
+			// Spending the same output twice would be invalid.
+			delete(spendableOutputs, outPoint)
+			inputValue += btcutil.Amount(output.Value)
+			inputConfigurations = append(inputConfigurations, output.Address.Configuration)
+		}
+	}
+	if len(inputScriptTypes)+len(inputConfigurations) == 0 || len(outputScriptTypes) == 0 {
+		return nil, errp.New("at least one input and one output are required")
+	}
+	vsize := maketx.EstimateVSize(inputScriptTypes, inputConfigurations, outputScriptTypes)
+	return &TxSizeEstimate{
+		VSize:      vsize,
+		InputValue: inputValue,
+		Fees:       estimateTxFees(vsize, account.feeTargets()),
+	}, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptTypesFromCounts(t *testing.T) {
+	scriptTypes, err := scriptTypesFromCounts(map[signing.ScriptType]int{
+		signing.ScriptTypeP2TR:   1,
+		signing.ScriptTypeP2WPKH: 2,
+		signing.ScriptTypeP2PKH:  0,
+	})
+	require.NoError(t, err)
+	require.Equal(t,
+		[]signing.ScriptType{signing.ScriptTypeP2TR, signing.ScriptTypeP2WPKH, signing.ScriptTypeP2WPKH},
+		scriptTypes)
+
+	scriptTypes, err = scriptTypesFromCounts(nil)
+	require.NoError(t, err)
+	require.Empty(t, scriptTypes)
+
+	_, err = scriptTypesFromCounts(map[signing.ScriptType]int{"p2wsh": 1})
+	require.Error(t, err)
+	_, err = scriptTypesFromCounts(map[signing.ScriptType]int{signing.ScriptTypeP2WPKH: -1})
+	require.Error(t, err)
+	_, err = scriptTypesFromCounts(map[signing.ScriptType]int{
+		signing.ScriptTypeP2WPKH: maxEstimatedTxInOuts,
+		signing.ScriptTypeP2TR:   1,
+	})
+	require.Error(t, err)
+}
+
+func TestEstimateTxFees(t *testing.T) {
+	feeRate := func(satPerVbyte btcutil.Amount) *btcutil.Amount {
+		feeRatePerKb := satPerVbyte * 1000
+		return &feeRatePerKb
+	}
+	require.Equal(t,
+		[]TxFeeEstimate{
+			{FeeTargetCode: accounts.FeeTargetCodeEconomy, FeeRatePerKb: 2000, Fee: 282},
+			{FeeTargetCode: accounts.FeeTargetCodeHigh, FeeRatePerKb: 25000, Fee: 3525},
+		},
+		estimateTxFees(141, []*FeeTarget{
+			{blocks: 24, code: accounts.FeeTargetCodeEconomy, feeRatePerKb: feeRate(2)},
+			{blocks: 6, code: accounts.FeeTargetCodeLow},
+			{blocks: 1, code: accounts.FeeTargetCodeHigh, feeRatePerKb: feeRate(25)},
+		}))
+	require.Empty(t, estimateTxFees(141, nil))
+}
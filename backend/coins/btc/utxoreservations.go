@@ -0,0 +1,138 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// utxoReservationTimeout is how long the inputs of a tx proposal stay reserved if the proposal is
+// neither sent nor released.
+const utxoReservationTimeout = 10 * time.Minute
+
+// UTXOReservation reserves an output of the account for a pending tx proposal, so that other
+// proposals do not select it. See config.Backend.LockProposalUTXOs.
+type UTXOReservation struct {
+	OutPoint wire.OutPoint
+	// ProposalID identifies the proposal, see accounts.TxProposalArgs.ProposalID.
+	ProposalID string
+	ExpiresAt  time.Time
+}
+
+// utxoReservations maps reserved outputs to their reservation.
+type utxoReservations map[wire.OutPoint]*UTXOReservation
+
+// prune removes the expired reservations.
+func (reservations utxoReservations) prune(now time.Time) {
+	for outPoint, reservation := range reservations {
+		if !now.Before(reservation.ExpiresAt) {
+			delete(reservations, outPoint)
+		}
+	}
+}
+
+// reservedByOthers returns true if the output is reserved by a proposal other than proposalID.
+func (reservations utxoReservations) reservedByOthers(
+	outPoint wire.OutPoint, proposalID string, now time.Time) bool {
+	reservation, ok := reservations[outPoint]
+	return ok && reservation.ProposalID != proposalID && now.Before(reservation.ExpiresAt)
+}
+
+// reserve replaces the reservations of the proposal with the given outputs.
+func (reservations utxoReservations) reserve(
+	proposalID string, outPoints []wire.OutPoint, now time.Time) {
+	reservations.release(proposalID)
+	for _, outPoint := range outPoints {
+		reservations[outPoint] = &UTXOReservation{
+			OutPoint:   outPoint,
+			ProposalID: proposalID,
+			ExpiresAt:  now.Add(utxoReservationTimeout),
+		}
+	}
+}
+
+// release removes the reservations of the proposal.
+func (reservations utxoReservations) release(proposalID string) {
+	for outPoint, reservation := range reservations {
+		if reservation.ProposalID == proposalID {
+			delete(reservations, outPoint)
+		}
+	}
+}
+
+// list returns the reservations which have not expired, ordered by expiry.
+func (reservations utxoReservations) list(now time.Time) []*UTXOReservation {
+	reservations.prune(now)
+	result := make([]*UTXOReservation, 0, len(reservations))
+	for _, reservation := range reservations {
+		copied := *reservation
+		result = append(result, &copied)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].ExpiresAt.Equal(result[j].ExpiresAt) {
+			return result[i].ExpiresAt.Before(result[j].ExpiresAt)
+		}
+		return result[i].OutPoint.String() < result[j].OutPoint.String()
+	})
+	return result
+}
+
+// reserveTxProposalInputs reserves the inputs of the tx proposal for proposalID, if UTXO locking is
+// enabled, replacing the previous reservations of the proposal.
+func (account *Account) reserveTxProposalInputs(proposalID string, tx *wire.MsgTx) {
+	if proposalID == "" || !account.Config().AppConfig().Backend.LockProposalUTXOs {
+		return
+	}
+	outPoints := make([]wire.OutPoint, len(tx.TxIn))
+	for i, txIn := range tx.TxIn {
+		outPoints[i] = txIn.PreviousOutPoint
+	}
+	defer account.utxoReservationsLock.Lock()()
+	account.utxoReservations.reserve(proposalID, outPoints, time.Now())
+}
+
+// releaseTxInputs removes the reservations of the inputs of a sent tx, as they are spent.
+func (account *Account) releaseTxInputs(tx *wire.MsgTx) {
+	defer account.utxoReservationsLock.Lock()()
+	for _, txIn := range tx.TxIn {
+		delete(account.utxoReservations, txIn.PreviousOutPoint)
+	}
+}
+
+// utxoReservedByOthers returns true if UTXO locking is enabled and the output is reserved by a
+// proposal other than proposalID.
+func (account *Account) utxoReservedByOthers(outPoint wire.OutPoint, proposalID string) bool {
+	if !account.Config().AppConfig().Backend.LockProposalUTXOs {
+		return false
+	}
+	defer account.utxoReservationsLock.RLock()()
+	return account.utxoReservations.reservedByOthers(outPoint, proposalID, time.Now())
+}
+
+// UTXOReservations returns the outputs reserved by pending tx proposals.
+func (account *Account) UTXOReservations() []*UTXOReservation {
+	defer account.utxoReservationsLock.Lock()()
+	return account.utxoReservations.list(time.Now())
+}
+
+// ReleaseUTXOReservations releases the outputs reserved by the proposal, e.g. when its send
+// dialog is closed.
+func (account *Account) ReleaseUTXOReservations(proposalID string) {
+	defer account.utxoReservationsLock.Lock()()
+	account.utxoReservations.release(proposalID)
+}
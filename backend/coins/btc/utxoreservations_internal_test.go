@@ -0,0 +1,58 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUTXOReservations(t *testing.T) {
+	outPoint := func(index uint32) wire.OutPoint {
+		return wire.OutPoint{Hash: chainhash.HashH([]byte("tx")), Index: index}
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	reservations := utxoReservations{}
+
+	reservations.reserve("a", []wire.OutPoint{outPoint(0), outPoint(1)}, now)
+	require.True(t, reservations.reservedByOthers(outPoint(0), "b", now))
+	require.True(t, reservations.reservedByOthers(outPoint(0), "", now))
+	require.False(t, reservations.reservedByOthers(outPoint(0), "a", now))
+	require.False(t, reservations.reservedByOthers(outPoint(2), "b", now))
+
+	// A new proposal of the same ID replaces its reservations.
+	later := now.Add(time.Minute)
+	reservations.reserve("a", []wire.OutPoint{outPoint(1)}, later)
+	require.False(t, reservations.reservedByOthers(outPoint(0), "b", later))
+	reservations.reserve("b", []wire.OutPoint{outPoint(2)}, now)
+	require.Equal(t,
+		[]*UTXOReservation{
+			{OutPoint: outPoint(2), ProposalID: "b", ExpiresAt: now.Add(utxoReservationTimeout)},
+			{OutPoint: outPoint(1), ProposalID: "a", ExpiresAt: later.Add(utxoReservationTimeout)},
+		},
+		reservations.list(now))
+
+	// Reservations time out.
+	expired := now.Add(utxoReservationTimeout)
+	require.False(t, reservations.reservedByOthers(outPoint(2), "a", expired))
+	require.Len(t, reservations.list(expired), 1)
+
+	reservations.release("a")
+	require.Empty(t, reservations.list(now))
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"sort"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+// UTXOStats are aggregate statistics of the utxo set of an account, to help deciding when to
+// consolidate coins.
+type UTXOStats struct {
+	Count    int
+	Total    btcutil.Amount
+	Smallest btcutil.Amount
+	Largest  btcutil.Amount
+	// MedianAge is the median number of confirmations. Unconfirmed outputs count as 0.
+	MedianAge int
+	// FeeRatePerKb is the fee rate used to determine dust.
+	FeeRatePerKb btcutil.Amount
+	// DustCount and DustTotal are about the outputs which cost at least as much in fees to spend as
+	// they are worth at FeeRatePerKb.
+	DustCount int
+	DustTotal btcutil.Amount
+}
+
+// utxoStatsInput is an output to be included in the stats.
+type utxoStatsInput struct {
+	value         btcutil.Amount
+	confirmations int
+	// inputVSize is the size of the input needed to spend the output.
+	inputVSize int
+}
+
+// computeUTXOStats computes the stats of the given outputs.
+func computeUTXOStats(utxos []utxoStatsInput, feeRatePerKb btcutil.Amount) *UTXOStats {
+	stats := &UTXOStats{Count: len(utxos), FeeRatePerKb: feeRatePerKb}
+	if len(utxos) == 0 {
+		return stats
+	}
+	confirmations := make([]int, 0, len(utxos))
+	stats.Smallest = utxos[0].value
+	for _, utxo := range utxos {
+		stats.Total += utxo.value
+		if utxo.value < stats.Smallest {
+			stats.Smallest = utxo.value
+		}
+		if utxo.value > stats.Largest {
+			stats.Largest = utxo.value
+		}
+		spendingFee := btcutil.Amount(int64(utxo.inputVSize) * int64(feeRatePerKb) / 1000)
+		if utxo.value <= spendingFee {
+			stats.DustCount++
+			stats.DustTotal += utxo.value
+		}
+		confirmations = append(confirmations, utxo.confirmations)
+	}
+	sort.Ints(confirmations)
+	middle := len(confirmations) / 2
+	if len(confirmations)%2 == 0 {
+		stats.MedianAge = (confirmations[middle-1] + confirmations[middle]) / 2
+	} else {
+		stats.MedianAge = confirmations[middle]
+	}
+	return stats
+}
+
+// lowestFeeRate returns the fee rate of the lowest priority fee target which could be estimated.
+func (account *Account) lowestFeeRate() (btcutil.Amount, error) {
+	for _, feeTarget := range account.feeTargets() {
+		if feeTarget.feeRatePerKb != nil {
+			return *feeTarget.feeRatePerKb, nil
+		}
+	}
+	return 0, errp.New("Fee could not be estimated")
+}
+
+// UTXOStats returns statistics about the spendable outputs of the account. The fee rate to
+// determine dust is taken from the given fee target, or from the lowest priority fee target if
+// feeTargetCode is empty. customFee is in sat/vB and only applies to the custom fee target.
+func (account *Account) UTXOStats(feeTargetCode accounts.FeeTargetCode, customFee string) (*UTXOStats, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account not initialized")
+	}
+	var feeRatePerKb btcutil.Amount
+	var err error
+	if feeTargetCode == "" {
+		feeRatePerKb, err = account.lowestFeeRate()
+	} else {
+		feeRatePerKb, err = account.getFeePerKb(&accounts.TxProposalArgs{
+			FeeTargetCode: feeTargetCode,
+			CustomFee:     customFee,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	spendableOutputs := account.SpendableOutputs()
+	tipHeight := account.coin.TipHeight()
+	utxos, err := transactions.DBView(account.db, func(dbTx transactions.DBTxInterface) ([]utxoStatsInput, error) {
+		utxos := []utxoStatsInput{}
+		for _, output := range spendableOutputs {
+			txInfo, err := dbTx.TxInfo(output.OutPoint.Hash)
+			if err != nil {
+				return nil, err
+			}
+			confirmations := 0
+			if txInfo != nil && txInfo.Height > 0 && tipHeight >= txInfo.Height {
+				confirmations = tipHeight - txInfo.Height + 1
+			}
+			utxos = append(utxos, utxoStatsInput{
+				value:         btcutil.Amount(output.Value),
+				confirmations: confirmations,
+				inputVSize:    maketx.InputVSize(output.Address.Configuration),
+			})
+		}
+		return utxos, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return computeUTXOStats(utxos, feeRatePerKb), nil
+}
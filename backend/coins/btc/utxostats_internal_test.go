@@ -0,0 +1,53 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeUTXOStats(t *testing.T) {
+	require.Equal(t,
+		&UTXOStats{FeeRatePerKb: 10000},
+		computeUTXOStats(nil, 10000))
+
+	// At 10 sat/vB, spending a 68 vbyte input costs 680 sat.
+	stats := computeUTXOStats([]utxoStatsInput{
+		{value: 100000, confirmations: 10, inputVSize: 68},
+		{value: 680, confirmations: 0, inputVSize: 68},
+		{value: 681, confirmations: 100, inputVSize: 68},
+		{value: 1000, confirmations: 5, inputVSize: 148},
+	}, 10000)
+	require.Equal(t, &UTXOStats{
+		Count:        4,
+		Total:        102361,
+		Smallest:     680,
+		Largest:      100000,
+		MedianAge:    7,
+		FeeRatePerKb: 10000,
+		DustCount:    2,
+		DustTotal:    1680,
+	}, stats)
+
+	stats = computeUTXOStats([]utxoStatsInput{
+		{value: 500, confirmations: 3, inputVSize: 68},
+		{value: 600, confirmations: 1, inputVSize: 68},
+		{value: 700, confirmations: 2, inputVSize: 68},
+	}, 1000)
+	require.Equal(t, 2, stats.MedianAge)
+	require.Equal(t, 0, stats.DustCount)
+}
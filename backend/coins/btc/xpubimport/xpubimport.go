@@ -0,0 +1,306 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package xpubimport parses the extended public key export files of other hardware wallets, so
+// that their accounts can be added as watch-only accounts.
+//
+// Supported are:
+//   - the generic JSON export of Coldcard and Passport, containing one section per BIP44 purpose.
+//   - the single key JSON export of Keystone (and the Wasabi export of Coldcard), containing the
+//     fields ExtPubKey, MasterFingerprint and optionally AccountKeyPath.
+//   - output descriptors, one per line, e.g. as exported by Bitcoin Core or Sparrow. See the
+//     descriptor package for the supported descriptors. Lines starting with '#' are ignored.
+package xpubimport
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/descriptor"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrUnknownFormat is returned if the file is not one of the supported export formats.
+const ErrUnknownFormat errp.ErrorCode = "unknownExportFormat"
+
+// Version bytes of the SLIP-0132 extended public key encodings.
+var (
+	mainnetVersions = [][]byte{
+		{0x04, 0x88, 0xb2, 0x1e}, // xpub
+		{0x04, 0x9d, 0x7c, 0xb2}, // ypub
+		{0x04, 0xb2, 0x47, 0x46}, // zpub
+	}
+	testnetVersions = [][]byte{
+		{0x04, 0x35, 0x87, 0xcf}, // tpub
+		{0x04, 0x4a, 0x52, 0x62}, // upub
+		{0x04, 0x5f, 0x1c, 0xf6}, // vpub
+	}
+)
+
+// purposeScriptTypes maps the BIP44 purpose of a keypath to its script type.
+var purposeScriptTypes = map[uint32]signing.ScriptType{
+	44: signing.ScriptTypeP2PKH,
+	49: signing.ScriptTypeP2WPKHP2SH,
+	84: signing.ScriptTypeP2WPKH,
+	86: signing.ScriptTypeP2TR,
+}
+
+// Export is the content of an export file.
+type Export struct {
+	RootFingerprint []byte
+	// Configurations are sorted by keypath. They all belong to the same account number.
+	Configurations signing.Configurations
+}
+
+// genericSection is a section of the generic JSON export, e.g. "bip84".
+type genericSection struct {
+	Deriv string `json:"deriv"`
+	Xpub  string `json:"xpub"`
+}
+
+// genericExport is the generic JSON export of Coldcard and Passport.
+type genericExport struct {
+	Chain string          `json:"chain"`
+	XFP   string          `json:"xfp"`
+	BIP44 *genericSection `json:"bip44"`
+	BIP49 *genericSection `json:"bip49"`
+	BIP84 *genericSection `json:"bip84"`
+	BIP86 *genericSection `json:"bip86"`
+}
+
+// singleExport is the single key JSON export of Keystone and the Wasabi export of Coldcard.
+type singleExport struct {
+	ExtPubKey         string `json:"ExtPubKey"`
+	MasterFingerprint string `json:"MasterFingerprint"`
+	AccountKeyPath    string `json:"AccountKeyPath"`
+}
+
+// Parse parses an export file. The keys must belong to the given network.
+func Parse(data []byte, net *chaincfg.Params) (*Export, error) {
+	var generic genericExport
+	if err := json.Unmarshal(data, &generic); err != nil {
+		if bytes.Contains(data, []byte("(")) {
+			return parseDescriptors(string(data), net)
+		}
+		return nil, errp.WithStack(ErrUnknownFormat)
+	}
+	if generic.XFP != "" {
+		return parseGeneric(&generic, net)
+	}
+	var single singleExport
+	if err := json.Unmarshal(data, &single); err != nil {
+		return nil, errp.WithStack(ErrUnknownFormat)
+	}
+	if single.ExtPubKey != "" && single.MasterFingerprint != "" {
+		return parseSingle(&single, net)
+	}
+	return nil, errp.WithStack(ErrUnknownFormat)
+}
+
+func parseGeneric(generic *genericExport, net *chaincfg.Params) (*Export, error) {
+	isMainnet := net.Net == wire.MainNet
+	if generic.Chain != "" && (generic.Chain == "BTC") != isMainnet {
+		return nil, errp.Newf("the export file is for the chain %s, not for %s", generic.Chain, net.Name)
+	}
+	rootFingerprint, err := parseFingerprint(generic.XFP)
+	if err != nil {
+		return nil, err
+	}
+	var configurations signing.Configurations
+	for _, section := range []*genericSection{generic.BIP44, generic.BIP49, generic.BIP84, generic.BIP86} {
+		if section == nil || section.Xpub == "" {
+			continue
+		}
+		configuration, err := newConfiguration(rootFingerprint, section.Deriv, section.Xpub, net)
+		if err != nil {
+			return nil, err
+		}
+		configurations = append(configurations, configuration)
+	}
+	return newExport(rootFingerprint, configurations)
+}
+
+func parseSingle(single *singleExport, net *chaincfg.Params) (*Export, error) {
+	rootFingerprint, err := parseFingerprint(single.MasterFingerprint)
+	if err != nil {
+		return nil, err
+	}
+	keypath := single.AccountKeyPath
+	if keypath == "" {
+		// The Wasabi export does not contain the keypath, but it is always the first native
+		// segwit account.
+		keypath = "m/84'/0'/0'"
+		if net.Net != wire.MainNet {
+			keypath = "m/84'/1'/0'"
+		}
+	}
+	configuration, err := newConfiguration(rootFingerprint, keypath, single.ExtPubKey, net)
+	if err != nil {
+		return nil, err
+	}
+	return newExport(rootFingerprint, signing.Configurations{configuration})
+}
+
+// parseDescriptors parses output descriptors, one per line. The receive and change descriptors of
+// an account both result in the same configuration, which is only added once.
+func parseDescriptors(data string, net *chaincfg.Params) (*Export, error) {
+	var rootFingerprint []byte
+	configurations := map[string]*signing.Configuration{}
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		configuration, err := descriptor.Parse(line, net)
+		if err != nil {
+			return nil, err
+		}
+		// For multisig descriptors, this is the fingerprint of the first key, see descriptor.Parse().
+		configurationRootFingerprint, err := signing.Configurations{configuration}.RootFingerprint()
+		if err != nil {
+			return nil, err
+		}
+		if rootFingerprint == nil {
+			rootFingerprint = configurationRootFingerprint
+		} else if !bytes.Equal(rootFingerprint, configurationRootFingerprint) {
+			return nil, errp.New("the descriptors belong to different wallets")
+		}
+		// BIP48 multisig keypaths have the script type as fourth element.
+		keypathLength := 3
+		if configuration.BitcoinMultisig != nil {
+			keypathLength = 4
+		}
+		if len(configuration.AbsoluteKeypath()) != keypathLength {
+			return nil, errp.Newf("unsupported keypath: %s", configuration.AbsoluteKeypath().Encode())
+		}
+		key := configuration.String()
+		if existing, ok := configurations[key]; ok {
+			if existing.ExtendedPublicKey().String() != configuration.ExtendedPublicKey().String() {
+				return nil, errp.Newf("conflicting keys for %s", configuration.AbsoluteKeypath().Encode())
+			}
+			continue
+		}
+		configurations[key] = configuration
+	}
+	result := make(signing.Configurations, 0, len(configurations))
+	for _, configuration := range configurations {
+		if configuration.BitcoinMultisig != nil && len(configurations) > 1 {
+			return nil, errp.New("a multisig descriptor can't be combined with other descriptors")
+		}
+		result = append(result, configuration)
+	}
+	return newExport(rootFingerprint, result)
+}
+
+func newExport(rootFingerprint []byte, configurations signing.Configurations) (*Export, error) {
+	if len(configurations) == 0 {
+		return nil, errp.New("the export file does not contain any supported extended public key")
+	}
+	sort.Slice(configurations, func(i, j int) bool {
+		return configurations[i].AbsoluteKeypath().Encode() < configurations[j].AbsoluteKeypath().Encode()
+	})
+	accountNumber, err := configurations[0].AccountNumber()
+	if err != nil {
+		return nil, err
+	}
+	for _, configuration := range configurations[1:] {
+		otherAccountNumber, err := configuration.AccountNumber()
+		if err != nil {
+			return nil, err
+		}
+		if otherAccountNumber != accountNumber {
+			return nil, errp.New("the extended public keys belong to different accounts")
+		}
+	}
+	return &Export{RootFingerprint: rootFingerprint, Configurations: configurations}, nil
+}
+
+func parseFingerprint(fingerprint string) ([]byte, error) {
+	rootFingerprint, err := hex.DecodeString(fingerprint)
+	if err != nil || len(rootFingerprint) != 4 {
+		return nil, errp.Newf("invalid root fingerprint: %s", fingerprint)
+	}
+	return rootFingerprint, nil
+}
+
+// parseKeypath parses keypaths like m/84'/0'/0', 84h/0h/0h or 84'/0'/0'.
+func parseKeypath(keypath string) (signing.AbsoluteKeypath, error) {
+	keypath = strings.ReplaceAll(strings.TrimSpace(keypath), "h", "'")
+	if !strings.HasPrefix(keypath, "m") {
+		keypath = "m/" + keypath
+	}
+	return signing.NewAbsoluteKeypath(keypath)
+}
+
+// newConfiguration creates the signing configuration of an exported key, checking that the key
+// matches the keypath and the network. The key is stored in the xpub/tpub encoding, like the keys
+// of all other accounts.
+func newConfiguration(
+	rootFingerprint []byte,
+	keypathString string,
+	xpub string,
+	net *chaincfg.Params,
+) (*signing.Configuration, error) {
+	keypath, err := parseKeypath(keypathString)
+	if err != nil {
+		return nil, err
+	}
+	elements := keypath.ToUInt32()
+	if len(elements) != 3 || elements[0] < hdkeychain.HardenedKeyStart {
+		return nil, errp.Newf("unsupported keypath: %s", keypathString)
+	}
+	scriptType, ok := purposeScriptTypes[elements[0]-hdkeychain.HardenedKeyStart]
+	if !ok {
+		return nil, errp.Newf("unsupported keypath: %s", keypathString)
+	}
+	extendedPublicKey, err := hdkeychain.NewKeyFromString(strings.TrimSpace(xpub))
+	if err != nil {
+		return nil, errp.WithMessage(err, "invalid extended public key")
+	}
+	if extendedPublicKey.IsPrivate() {
+		return nil, errp.New("the export file contains a private key")
+	}
+	versions := mainnetVersions
+	if net.Net != wire.MainNet {
+		versions = testnetVersions
+	}
+	if !containsVersion(versions, extendedPublicKey.Version()) {
+		return nil, errp.Newf("the extended public key is not for %s", net.Name)
+	}
+	if int(extendedPublicKey.Depth()) != len(elements) ||
+		extendedPublicKey.ChildIndex() != elements[len(elements)-1] {
+		return nil, errp.Newf("the extended public key does not match the keypath %s", keypathString)
+	}
+	extendedPublicKey, err = extendedPublicKey.CloneWithVersion(net.HDPublicKeyID[:])
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return signing.NewBitcoinConfiguration(scriptType, rootFingerprint, keypath, extendedPublicKey), nil
+}
+
+func containsVersion(versions [][]byte, version []byte) bool {
+	for _, v := range versions {
+		if bytes.Equal(v, version) {
+			return true
+		}
+	}
+	return false
+}
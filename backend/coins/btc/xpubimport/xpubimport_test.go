@@ -0,0 +1,156 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xpubimport
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/descriptor"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+// accountXpub derives the account key at the keypath and encodes it with the given version.
+func accountXpub(t *testing.T, keypath string, version []byte) (string, string) {
+	t.Helper()
+	root, err := hdkeychain.NewMaster(make([]byte, 32), &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	absoluteKeypath, err := signing.NewAbsoluteKeypath(keypath)
+	require.NoError(t, err)
+	accountKey, err := absoluteKeypath.Derive(root)
+	require.NoError(t, err)
+	accountKey, err = accountKey.Neuter()
+	require.NoError(t, err)
+	tpub := accountKey.String()
+	accountKey, err = accountKey.CloneWithVersion(version)
+	require.NoError(t, err)
+	return accountKey.String(), tpub
+}
+
+func TestParseGeneric(t *testing.T) {
+	bip44Xpub, bip44Tpub := accountXpub(t, "m/44'/1'/0'", testnetVersions[0])
+	bip84Xpub, bip84Tpub := accountXpub(t, "m/84'/1'/0'", testnetVersions[2])
+	data := fmt.Sprintf(`{
+  "chain": "XTN",
+  "xfp": "0F056943",
+  "account": 0,
+  "bip44": {"name": "p2pkh", "deriv": "m/44'/1'/0'", "xpub": "%s"},
+  "bip84": {"name": "p2wpkh", "deriv": "m/84h/1h/0h", "xpub": "%s"},
+  "bip48_2": {"deriv": "m/48'/1'/0'/2'", "xpub": "ignored"}
+}`, bip44Xpub, bip84Xpub)
+
+	export, err := Parse([]byte(data), &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x0f, 0x05, 0x69, 0x43}, export.RootFingerprint)
+	require.Len(t, export.Configurations, 2)
+	require.Equal(t, signing.ScriptTypeP2PKH, export.Configurations[0].ScriptType())
+	require.Equal(t, "m/44'/1'/0'", export.Configurations[0].AbsoluteKeypath().Encode())
+	require.Equal(t, bip44Tpub, export.Configurations[0].ExtendedPublicKey().String())
+	require.Equal(t, signing.ScriptTypeP2WPKH, export.Configurations[1].ScriptType())
+	require.Equal(t, "m/84'/1'/0'", export.Configurations[1].AbsoluteKeypath().Encode())
+	require.Equal(t, bip84Tpub, export.Configurations[1].ExtendedPublicKey().String())
+
+	// Wrong chain.
+	_, err = Parse([]byte(data), &chaincfg.MainNetParams)
+	require.Error(t, err)
+}
+
+func TestParseSingle(t *testing.T) {
+	vpub, tpub := accountXpub(t, "m/84'/1'/0'", testnetVersions[2])
+
+	for _, keypath := range []string{`"AccountKeyPath": "m/84'/1'/0'",`, `"AccountKeyPath": "84'/1'/0'",`, ""} {
+		data := fmt.Sprintf(`{%s "ExtPubKey": "%s", "MasterFingerprint": "73C5DA0A"}`, keypath, vpub)
+		export, err := Parse([]byte(data), &chaincfg.TestNet3Params)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0x73, 0xc5, 0xda, 0x0a}, export.RootFingerprint)
+		require.Len(t, export.Configurations, 1)
+		require.Equal(t, signing.ScriptTypeP2WPKH, export.Configurations[0].ScriptType())
+		require.Equal(t, "m/84'/1'/0'", export.Configurations[0].AbsoluteKeypath().Encode())
+		require.Equal(t, tpub, export.Configurations[0].ExtendedPublicKey().String())
+	}
+
+	// Keypath does not match the key.
+	_, err := Parse([]byte(fmt.Sprintf(
+		`{"ExtPubKey": "%s", "MasterFingerprint": "73C5DA0A", "AccountKeyPath": "m/84'/1'/1'"}`, vpub)),
+		&chaincfg.TestNet3Params)
+	require.Error(t, err)
+
+	// Unsupported purpose.
+	_, err = Parse([]byte(fmt.Sprintf(
+		`{"ExtPubKey": "%s", "MasterFingerprint": "73C5DA0A", "AccountKeyPath": "m/45'/1'/0'"}`, vpub)),
+		&chaincfg.TestNet3Params)
+	require.Error(t, err)
+
+	// Invalid fingerprint.
+	_, err = Parse([]byte(fmt.Sprintf(`{"ExtPubKey": "%s", "MasterFingerprint": "73C5"}`, vpub)),
+		&chaincfg.TestNet3Params)
+	require.Error(t, err)
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	for _, data := range []string{"", "not json", `{"foo": "bar"}`, `[]`} {
+		_, err := Parse([]byte(data), &chaincfg.TestNet3Params)
+		require.Equal(t, ErrUnknownFormat, errp.Cause(err))
+	}
+}
+
+func TestParseDescriptors(t *testing.T) {
+	_, bip84Tpub := accountXpub(t, "m/84'/1'/0'", testnetVersions[0])
+	_, bip86Tpub := accountXpub(t, "m/86'/1'/0'", testnetVersions[0])
+	data := fmt.Sprintf(`# Exported descriptors
+wpkh([0f056943/84'/1'/0']%[1]s/0/*)
+wpkh([0f056943/84'/1'/0']%[1]s/1/*)
+tr([0f056943/86h/1h/0h]%[2]s/<0;1>/*)
+`, bip84Tpub, bip86Tpub)
+
+	export, err := Parse([]byte(data), &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x0f, 0x05, 0x69, 0x43}, export.RootFingerprint)
+	require.Len(t, export.Configurations, 2)
+	require.Equal(t, signing.ScriptTypeP2WPKH, export.Configurations[0].ScriptType())
+	require.Equal(t, bip84Tpub, export.Configurations[0].ExtendedPublicKey().String())
+	require.Equal(t, signing.ScriptTypeP2TR, export.Configurations[1].ScriptType())
+	require.Equal(t, "m/86'/1'/0'", export.Configurations[1].AbsoluteKeypath().Encode())
+
+	// Different wallets.
+	_, err = Parse([]byte(fmt.Sprintf(
+		"wpkh([0f056943/84'/1'/0']%s/0/*)\ntr([73c5da0a/86'/1'/0']%s/0/*)", bip84Tpub, bip86Tpub)),
+		&chaincfg.TestNet3Params)
+	require.Error(t, err)
+
+	// Multisig, the first key is the one of the wallet.
+	_, bip48Tpub := accountXpub(t, "m/48'/1'/0'/2'", testnetVersions[0])
+	multisig := fmt.Sprintf(
+		"wsh(sortedmulti(1,[73c5da0a/48'/1'/0'/2']%s/0/*,[0f056943/84'/1'/0']%s/0/*))", bip48Tpub, bip84Tpub)
+	export, err = Parse([]byte(multisig), &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x73, 0xc5, 0xda, 0x0a}, export.RootFingerprint)
+	require.Len(t, export.Configurations, 1)
+	require.Equal(t, signing.ScriptTypeP2WSH, export.Configurations[0].ScriptType())
+	_, err = Parse([]byte(fmt.Sprintf(
+		"wsh(sortedmulti(1,[0f056943/84'/1'/0']%s/0/*,[73c5da0a/86'/1'/0']%s/0/*))", bip84Tpub, bip86Tpub)),
+		&chaincfg.TestNet3Params)
+	require.Error(t, err)
+	_, err = Parse([]byte(multisig+"\n"+fmt.Sprintf("wpkh([73c5da0a/84'/1'/0']%s/0/*)", bip84Tpub)),
+		&chaincfg.TestNet3Params)
+	require.Error(t, err)
+	_, err = Parse([]byte(fmt.Sprintf("wsh(multi(1,[73c5da0a/48'/1'/0'/2']%s/0/*))", bip48Tpub)),
+		&chaincfg.TestNet3Params)
+	require.Equal(t, descriptor.ErrUnsupported, errp.Cause(err))
+}
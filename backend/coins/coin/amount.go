@@ -15,6 +15,7 @@
 package coin
 
 import (
+	"encoding/json"
 	"math/big"
 	"strings"
 
@@ -69,11 +70,36 @@ func (amount Amount) BigInt() *big.Int {
 	return new(big.Int).Set(amount.n)
 }
 
+// MarshalJSON implements json.Marshaler. The amount is serialized as a decimal string in the
+// smallest unit, as it can exceed the range of JSON numbers.
+func (amount Amount) MarshalJSON() ([]byte, error) {
+	if amount.n == nil {
+		return json.Marshal("0")
+	}
+	return json.Marshal(amount.n.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (amount *Amount) UnmarshalJSON(jsonBytes []byte) error {
+	var s string
+	if err := json.Unmarshal(jsonBytes, &s); err != nil {
+		return errp.WithStack(err)
+	}
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return errp.Newf("could not parse amount %q", s)
+	}
+	amount.n = n
+	return nil
+}
+
 // SendAmount is either a concrete amount, or "all"/"max". The concrete amount is user input and is
 // parsed/validated in Amount().
 type SendAmount struct {
 	amount  string
 	sendAll bool
+	// smallestUnit is true if amount is given in the smallest unit, e.g. satoshi or wei.
+	smallestUnit bool
 }
 
 // NewSendAmount creates a new SendAmount based on a concrete amount.
@@ -81,18 +107,50 @@ func NewSendAmount(amount string) SendAmount {
 	return SendAmount{amount: amount, sendAll: false}
 }
 
+// NewSendAmountSmallestUnit creates a new SendAmount based on a concrete amount in the smallest
+// unit, e.g. satoshi or wei. The amount must be a non-negative integer in decimal notation without
+// leading zeros.
+func NewSendAmountSmallestUnit(amount string) SendAmount {
+	return SendAmount{amount: amount, sendAll: false, smallestUnit: true}
+}
+
+// parseSmallestUnit strictly parses an integer amount in the smallest unit. Signs, whitespace,
+// leading zeros, decimal points and exponents are rejected.
+func parseSmallestUnit(s string) (Amount, error) {
+	if s == "" || len(s) > 1 && s[0] == '0' {
+		return Amount{}, errp.Newf("invalid amount %q", s)
+	}
+	for _, char := range s {
+		if char < '0' || char > '9' {
+			return Amount{}, errp.Newf("invalid amount %q", s)
+		}
+	}
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return Amount{}, errp.Newf("invalid amount %q", s)
+	}
+	return Amount{n: n}, nil
+}
+
 // NewSendAmountAll creates a new Sendall-amount.
 func NewSendAmountAll() SendAmount {
 	return SendAmount{amount: "", sendAll: true}
 }
 
 // Amount parses the amount and converts it from the default unit to the smallest unit (e.g. satoshi
-// = 1e8). Returns an error if the amount is negative, or depending on allowZero, if it is zero.
+// = 1e8), unless it is already given in the smallest unit. Returns an error if the amount is
+// negative, or depending on allowZero, if it is zero.
 func (sendAmount SendAmount) Amount(unit *big.Int, allowZero bool) (Amount, error) {
 	if sendAmount.sendAll {
 		panic("can only be called if SendAll is false")
 	}
-	amount, err := NewAmountFromString(sendAmount.amount, unit)
+	var amount Amount
+	var err error
+	if sendAmount.smallestUnit {
+		amount, err = parseSmallestUnit(sendAmount.amount)
+	} else {
+		amount, err = NewAmountFromString(sendAmount.amount, unit)
+	}
 	if err != nil {
 		return Amount{}, errp.WithStack(errors.ErrInvalidAmount)
 	}
@@ -15,6 +15,7 @@
 package coin_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"math/big"
@@ -103,3 +104,47 @@ func TestSendAmount(t *testing.T) {
 	require.Equal(t, int64(0), amount.BigInt().Int64())
 
 }
+
+func TestSendAmountSmallestUnit(t *testing.T) {
+	unit := big.NewInt(1e8)
+	amount, err := coin.NewSendAmountSmallestUnit("123456789").Amount(unit, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(123456789), amount.BigInt().Int64())
+
+	// Larger than int64, e.g. wei.
+	amount, err = coin.NewSendAmountSmallestUnit("100000000000000000000").Amount(unit, false)
+	require.NoError(t, err)
+	require.Equal(t, "100000000000000000000", amount.BigInt().String())
+
+	_, err = coin.NewSendAmountSmallestUnit("0").Amount(unit, false)
+	require.Error(t, err)
+	amount, err = coin.NewSendAmountSmallestUnit("0").Amount(unit, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), amount.BigInt().Int64())
+
+	for _, invalid := range []string{
+		"", "-1", "+1", "01", "1.0", "1.5", "1e8", "0x10", " 1", "1 ", "1_000", "١",
+	} {
+		_, err := coin.NewSendAmountSmallestUnit(invalid).Amount(unit, true)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	amount, ok := new(big.Int).SetString("123456789012345678901234567890", 10)
+	require.True(t, ok)
+	jsonBytes, err := json.Marshal(coin.NewAmount(amount))
+	require.NoError(t, err)
+	require.Equal(t, `"123456789012345678901234567890"`, string(jsonBytes))
+
+	var decoded coin.Amount
+	require.NoError(t, json.Unmarshal(jsonBytes, &decoded))
+	require.Equal(t, amount, decoded.BigInt())
+
+	jsonBytes, err = json.Marshal(coin.Amount{})
+	require.NoError(t, err)
+	require.Equal(t, `"0"`, string(jsonBytes))
+
+	require.Error(t, json.Unmarshal([]byte(`"1.5"`), &decoded))
+	require.Error(t, json.Unmarshal([]byte(`15`), &decoded))
+}
@@ -79,6 +79,11 @@ type Coin interface {
 	// SmallestUnit returns the name of the smallest unit of a given coin
 	SmallestUnit() string
 
+	// FeeSubUnit returns the conventional sub-unit fees are shown in next to the fee unit, e.g.
+	// "sat" for Bitcoin or "gwei" for Ethereum, and its exponent: one sub-unit is 10^exponent of the
+	// smallest unit, e.g. 0 for "sat" and 9 for "gwei".
+	FeeSubUnit() (string, uint)
+
 	// Close shuts down all resources obtained by the coin (network connections, databases, etc.).
 	Close() error
 }
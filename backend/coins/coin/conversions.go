@@ -2,6 +2,7 @@ package coin
 
 import (
 	"math/big"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,6 +22,41 @@ func Btc2Sat(amount *big.Rat) *big.Rat {
 	return new(big.Rat).Mul(amount, big.NewRat(btc2SatUnit, 1))
 }
 
+// rateToRat converts an exchange rate to a big.Rat using its shortest decimal representation, so
+// that e.g. a rate of 0.1 is exactly 1/10 instead of the nearest binary floating point number.
+func rateToRat(rate float64) *big.Rat {
+	result, ok := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	if !ok {
+		// Only happens for NaN or infinity.
+		return new(big.Rat)
+	}
+	return result
+}
+
+func unitFactor(coin Coin, isFee bool) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(coin.Decimals(isFee))), nil))
+}
+
+// ToFiat converts the coin amount to fiat using the given exchange rate, without any rounding.
+func ToFiat(amount Amount, coin Coin, isFee bool, rate float64) *big.Rat {
+	unitAmount := new(big.Rat).Quo(new(big.Rat).SetInt(amount.BigInt()), unitFactor(coin, isFee))
+	return unitAmount.Mul(unitAmount, rateToRat(rate))
+}
+
+// FromFiat converts the fiat amount to a coin amount using the given exchange rate, rounded to the
+// smallest unit of the coin. The result is zero if the rate is zero.
+func FromFiat(fiatAmount *big.Rat, coin Coin, isFee bool, rate float64) Amount {
+	rat := rateToRat(rate)
+	if rat.Sign() == 0 {
+		return NewAmountFromInt64(0)
+	}
+	smallestUnitAmount := new(big.Rat).Quo(fiatAmount, rat)
+	smallestUnitAmount.Mul(smallestUnitAmount, unitFactor(coin, isFee))
+	// FloatString rounds half away from zero.
+	result, _ := new(big.Int).SetString(smallestUnitAmount.FloatString(0), 10)
+	return NewAmount(result)
+}
+
 // FormatAsPlainCurrency handles formatting for currencies in a simplified way.
 // This should be used when `FormatAsCurrency` can't be used because a simpler formatting is needed (e.g. to populate forms in the frontend).
 func FormatAsPlainCurrency(amount *big.Rat, currency string) string {
@@ -30,6 +66,8 @@ func FormatAsPlainCurrency(amount *big.Rat, currency string) string {
 		formatted = amount.FloatString(8)
 	case ratesPkg.SAT.String():
 		formatted = amount.FloatString(0)
+	case ratesPkg.XAU.String(), ratesPkg.XAG.String():
+		formatted = amount.FloatString(4)
 	default:
 		formatted = amount.FloatString(2)
 	}
@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin/mocks"
 	"github.com/stretchr/testify/require"
 )
 
@@ -31,3 +32,31 @@ func TestBtc2Sat(t *testing.T) {
 	require.Equal(t, coin.Btc2Sat(new(big.Rat).SetFloat64(1.23456789)).FloatString(0), "123456789")
 	require.Equal(t, coin.Btc2Sat(new(big.Rat).SetFloat64(0.00012345)).FloatString(0), "12345")
 }
+
+func TestToFiat(t *testing.T) {
+	btc := &mocks.CoinMock{DecimalsFunc: func(bool) uint { return 8 }}
+	// 0.29 BTC at 0.1: with float64 arithmetic, this is 0.028999999999999998.
+	require.Equal(t, "29/1000",
+		coin.ToFiat(coin.NewAmountFromInt64(29000000), btc, false, 0.1).RatString())
+	require.Equal(t, "0.00000001",
+		coin.ToFiat(coin.NewAmountFromInt64(1), btc, false, 1).FloatString(8))
+	require.Equal(t, "0", coin.ToFiat(coin.NewAmountFromInt64(1), btc, false, 0).RatString())
+}
+
+func TestFromFiat(t *testing.T) {
+	btc := &mocks.CoinMock{DecimalsFunc: func(bool) uint { return 8 }}
+	// 0.3 / 0.1 is not exactly 3 with float64 arithmetic, and SetFloat64(0.1) is not exactly 1/10.
+	require.Equal(t, big.NewInt(300000000),
+		coin.FromFiat(big.NewRat(3, 10), btc, false, 0.1).BigInt())
+	// Rounded to the nearest sat: 1/3 BTC.
+	require.Equal(t, big.NewInt(33333333),
+		coin.FromFiat(big.NewRat(1, 1), btc, false, 3).BigInt())
+	// Rounded half away from zero: 0.000000015 BTC.
+	require.Equal(t, big.NewInt(2),
+		coin.FromFiat(big.NewRat(15, 1000000000), btc, false, 1).BigInt())
+	require.Equal(t, big.NewInt(0), coin.FromFiat(big.NewRat(1, 1), btc, false, 0).BigInt())
+
+	eth := &mocks.CoinMock{DecimalsFunc: func(bool) uint { return 18 }}
+	require.Equal(t, "500000000000000000",
+		coin.FromFiat(big.NewRat(1000, 1), eth, false, 2000).BigInt().String())
+}
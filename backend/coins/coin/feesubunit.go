@@ -0,0 +1,54 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coin
+
+import (
+	"math/big"
+	"strings"
+)
+
+// feeRateExtraDecimals are the decimal places shown for fee rates in addition to the ones of the
+// fee sub-unit, e.g. 3 for "1.234 sat/vB".
+const feeRateExtraDecimals = 3
+
+func formatSubUnit(amount *big.Rat, decimals int) string {
+	s := amount.FloatString(decimals)
+	if decimals == 0 {
+		return s
+	}
+	// Truncate trailing zeroes, and final '.' if the number has no decimal places.
+	return strings.TrimRight(strings.TrimRight(s, "0"), ".")
+}
+
+// FormatFeeSubUnit formats the given fee, in the smallest unit of the fee unit, in the fee sub-unit
+// of the coin, see `Coin.FeeSubUnit()`. E.g. 21000000000000 wei are formatted as "21000" (gwei).
+func FormatFeeSubUnit(coin Coin, fee Amount) string {
+	_, exponent := coin.FeeSubUnit()
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+	return formatSubUnit(new(big.Rat).SetFrac(fee.BigInt(), factor), int(exponent))
+}
+
+// FormatFeeRateSubUnit formats the fee per size unit in the fee sub-unit of the coin, e.g. the fee
+// per vbyte in sat for Bitcoin, or the fee per gas (the gas price) in gwei for Ethereum. Returns
+// false if size is zero.
+func FormatFeeRateSubUnit(coin Coin, fee Amount, size uint64) (string, bool) {
+	if size == 0 {
+		return "", false
+	}
+	_, exponent := coin.FeeSubUnit()
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+	rate := new(big.Rat).SetFrac(fee.BigInt(), new(big.Int).Mul(factor, new(big.Int).SetUint64(size)))
+	return formatSubUnit(rate, int(exponent)+feeRateExtraDecimals), true
+}
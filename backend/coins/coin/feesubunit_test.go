@@ -0,0 +1,52 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coin_test
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatFeeSubUnit(t *testing.T) {
+	ltc := &mocks.CoinMock{FeeSubUnitFunc: func() (string, uint) { return "litoshi", 0 }}
+	require.Equal(t, "12345", coin.FormatFeeSubUnit(ltc, coin.NewAmountFromInt64(12345)))
+	require.Equal(t, "0", coin.FormatFeeSubUnit(ltc, coin.NewAmountFromInt64(0)))
+
+	eth := &mocks.CoinMock{FeeSubUnitFunc: func() (string, uint) { return "gwei", 9 }}
+	require.Equal(t, "21000", coin.FormatFeeSubUnit(eth, coin.NewAmountFromInt64(21000000000000)))
+	require.Equal(t, "0.000000001", coin.FormatFeeSubUnit(eth, coin.NewAmountFromInt64(1)))
+	require.Equal(t, "1.5", coin.FormatFeeSubUnit(eth, coin.NewAmountFromInt64(1500000000)))
+}
+
+func TestFormatFeeRateSubUnit(t *testing.T) {
+	btc := &mocks.CoinMock{FeeSubUnitFunc: func() (string, uint) { return "sat", 0 }}
+	rate, ok := coin.FormatFeeRateSubUnit(btc, coin.NewAmountFromInt64(2820), 141)
+	require.True(t, ok)
+	require.Equal(t, "20", rate)
+	rate, ok = coin.FormatFeeRateSubUnit(btc, coin.NewAmountFromInt64(1000), 141)
+	require.True(t, ok)
+	require.Equal(t, "7.092", rate)
+	_, ok = coin.FormatFeeRateSubUnit(btc, coin.NewAmountFromInt64(1000), 0)
+	require.False(t, ok)
+
+	// 21000 gas at 12.5 gwei.
+	eth := &mocks.CoinMock{FeeSubUnitFunc: func() (string, uint) { return "gwei", 9 }}
+	rate, ok = coin.FormatFeeRateSubUnit(eth, coin.NewAmountFromInt64(262500000000000), 21000)
+	require.True(t, ok)
+	require.Equal(t, "12.5", rate)
+}
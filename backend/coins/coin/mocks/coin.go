@@ -16,58 +16,61 @@ var _ coin.Coin = &CoinMock{}
 
 // CoinMock is a mock implementation of coin.Coin.
 //
-// 	func TestSomethingThatUsesCoin(t *testing.T) {
+//	func TestSomethingThatUsesCoin(t *testing.T) {
 //
-// 		// make and configure a mocked coin.Coin
-// 		mockedCoin := &CoinMock{
-// 			BlockExplorerTransactionURLPrefixFunc: func() string {
-// 				panic("mock out the BlockExplorerTransactionURLPrefix method")
-// 			},
-// 			CloseFunc: func() error {
-// 				panic("mock out the Close method")
-// 			},
-// 			CodeFunc: func() coin.Code {
-// 				panic("mock out the Code method")
-// 			},
-// 			DecimalsFunc: func(isFee bool) uint {
-// 				panic("mock out the Decimals method")
-// 			},
-// 			FormatAmountFunc: func(amount coin.Amount, isFee bool) string {
-// 				panic("mock out the FormatAmount method")
-// 			},
-// 			GetFormatUnitFunc: func(isFee bool) string {
-// 				panic("mock out the GetFormatUnit method")
-// 			},
-// 			InitializeFunc: func()  {
-// 				panic("mock out the Initialize method")
-// 			},
-// 			NameFunc: func() string {
-// 				panic("mock out the Name method")
-// 			},
-// 			ObserveFunc: func(fn func(observable.Event)) func() {
-// 				panic("mock out the Observe method")
-// 			},
-// 			ParseAmountFunc: func(amount string) (coin.Amount, error) {
-// 				panic("mock out the ParseAmount method")
-// 			},
-// 			SetAmountFunc: func(amount *big.Rat, isFee bool) coin.Amount {
-// 				panic("mock out the SetAmount method")
-// 			},
-// 			SmallestUnitFunc: func() string {
-// 				panic("mock out the SmallestUnit method")
-// 			},
-// 			ToUnitFunc: func(amount coin.Amount, isFee bool) float64 {
-// 				panic("mock out the ToUnit method")
-// 			},
-// 			UnitFunc: func(isFee bool) string {
-// 				panic("mock out the Unit method")
-// 			},
-// 		}
+//		// make and configure a mocked coin.Coin
+//		mockedCoin := &CoinMock{
+//			BlockExplorerTransactionURLPrefixFunc: func() string {
+//				panic("mock out the BlockExplorerTransactionURLPrefix method")
+//			},
+//			CloseFunc: func() error {
+//				panic("mock out the Close method")
+//			},
+//			CodeFunc: func() coin.Code {
+//				panic("mock out the Code method")
+//			},
+//			DecimalsFunc: func(isFee bool) uint {
+//				panic("mock out the Decimals method")
+//			},
+//			FeeSubUnitFunc: func() (string, uint) {
+//				panic("mock out the FeeSubUnit method")
+//			},
+//			FormatAmountFunc: func(amount coin.Amount, isFee bool) string {
+//				panic("mock out the FormatAmount method")
+//			},
+//			GetFormatUnitFunc: func(isFee bool) string {
+//				panic("mock out the GetFormatUnit method")
+//			},
+//			InitializeFunc: func()  {
+//				panic("mock out the Initialize method")
+//			},
+//			NameFunc: func() string {
+//				panic("mock out the Name method")
+//			},
+//			ObserveFunc: func(fn func(observable.Event)) func() {
+//				panic("mock out the Observe method")
+//			},
+//			ParseAmountFunc: func(amount string) (coin.Amount, error) {
+//				panic("mock out the ParseAmount method")
+//			},
+//			SetAmountFunc: func(amount *big.Rat, isFee bool) coin.Amount {
+//				panic("mock out the SetAmount method")
+//			},
+//			SmallestUnitFunc: func() string {
+//				panic("mock out the SmallestUnit method")
+//			},
+//			ToUnitFunc: func(amount coin.Amount, isFee bool) float64 {
+//				panic("mock out the ToUnit method")
+//			},
+//			UnitFunc: func(isFee bool) string {
+//				panic("mock out the Unit method")
+//			},
+//		}
 //
-// 		// use mockedCoin in code that requires coin.Coin
-// 		// and then make assertions.
+//		// use mockedCoin in code that requires coin.Coin
+//		// and then make assertions.
 //
-// 	}
+//	}
 type CoinMock struct {
 	// BlockExplorerTransactionURLPrefixFunc mocks the BlockExplorerTransactionURLPrefix method.
 	BlockExplorerTransactionURLPrefixFunc func() string
@@ -81,6 +84,9 @@ type CoinMock struct {
 	// DecimalsFunc mocks the Decimals method.
 	DecimalsFunc func(isFee bool) uint
 
+	// FeeSubUnitFunc mocks the FeeSubUnit method.
+	FeeSubUnitFunc func() (string, uint)
+
 	// FormatAmountFunc mocks the FormatAmount method.
 	FormatAmountFunc func(amount coin.Amount, isFee bool) string
 
@@ -127,6 +133,9 @@ type CoinMock struct {
 			// IsFee is the isFee argument value.
 			IsFee bool
 		}
+		// FeeSubUnit holds details about calls to the FeeSubUnit method.
+		FeeSubUnit []struct {
+		}
 		// FormatAmount holds details about calls to the FormatAmount method.
 		FormatAmount []struct {
 			// Amount is the amount argument value.
@@ -182,6 +191,7 @@ type CoinMock struct {
 	lockClose                             sync.RWMutex
 	lockCode                              sync.RWMutex
 	lockDecimals                          sync.RWMutex
+	lockFeeSubUnit                        sync.RWMutex
 	lockFormatAmount                      sync.RWMutex
 	lockGetFormatUnit                     sync.RWMutex
 	lockInitialize                        sync.RWMutex
@@ -209,7 +219,8 @@ func (mock *CoinMock) BlockExplorerTransactionURLPrefix() string {
 
 // BlockExplorerTransactionURLPrefixCalls gets all the calls that were made to BlockExplorerTransactionURLPrefix.
 // Check the length with:
-//     len(mockedCoin.BlockExplorerTransactionURLPrefixCalls())
+//
+//	len(mockedCoin.BlockExplorerTransactionURLPrefixCalls())
 func (mock *CoinMock) BlockExplorerTransactionURLPrefixCalls() []struct {
 } {
 	var calls []struct {
@@ -235,7 +246,8 @@ func (mock *CoinMock) Close() error {
 
 // CloseCalls gets all the calls that were made to Close.
 // Check the length with:
-//     len(mockedCoin.CloseCalls())
+//
+//	len(mockedCoin.CloseCalls())
 func (mock *CoinMock) CloseCalls() []struct {
 } {
 	var calls []struct {
@@ -261,7 +273,8 @@ func (mock *CoinMock) Code() coin.Code {
 
 // CodeCalls gets all the calls that were made to Code.
 // Check the length with:
-//     len(mockedCoin.CodeCalls())
+//
+//	len(mockedCoin.CodeCalls())
 func (mock *CoinMock) CodeCalls() []struct {
 } {
 	var calls []struct {
@@ -290,7 +303,8 @@ func (mock *CoinMock) Decimals(isFee bool) uint {
 
 // DecimalsCalls gets all the calls that were made to Decimals.
 // Check the length with:
-//     len(mockedCoin.DecimalsCalls())
+//
+//	len(mockedCoin.DecimalsCalls())
 func (mock *CoinMock) DecimalsCalls() []struct {
 	IsFee bool
 } {
@@ -303,6 +317,33 @@ func (mock *CoinMock) DecimalsCalls() []struct {
 	return calls
 }
 
+// FeeSubUnit calls FeeSubUnitFunc.
+func (mock *CoinMock) FeeSubUnit() (string, uint) {
+	if mock.FeeSubUnitFunc == nil {
+		panic("CoinMock.FeeSubUnitFunc: method is nil but Coin.FeeSubUnit was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockFeeSubUnit.Lock()
+	mock.calls.FeeSubUnit = append(mock.calls.FeeSubUnit, callInfo)
+	mock.lockFeeSubUnit.Unlock()
+	return mock.FeeSubUnitFunc()
+}
+
+// FeeSubUnitCalls gets all the calls that were made to FeeSubUnit.
+// Check the length with:
+//
+//	len(mockedCoin.FeeSubUnitCalls())
+func (mock *CoinMock) FeeSubUnitCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockFeeSubUnit.RLock()
+	calls = mock.calls.FeeSubUnit
+	mock.lockFeeSubUnit.RUnlock()
+	return calls
+}
+
 // FormatAmount calls FormatAmountFunc.
 func (mock *CoinMock) FormatAmount(amount coin.Amount, isFee bool) string {
 	if mock.FormatAmountFunc == nil {
@@ -323,7 +364,8 @@ func (mock *CoinMock) FormatAmount(amount coin.Amount, isFee bool) string {
 
 // FormatAmountCalls gets all the calls that were made to FormatAmount.
 // Check the length with:
-//     len(mockedCoin.FormatAmountCalls())
+//
+//	len(mockedCoin.FormatAmountCalls())
 func (mock *CoinMock) FormatAmountCalls() []struct {
 	Amount coin.Amount
 	IsFee  bool
@@ -356,7 +398,8 @@ func (mock *CoinMock) GetFormatUnit(isFee bool) string {
 
 // GetFormatUnitCalls gets all the calls that were made to GetFormatUnit.
 // Check the length with:
-//     len(mockedCoin.GetFormatUnitCalls())
+//
+//	len(mockedCoin.GetFormatUnitCalls())
 func (mock *CoinMock) GetFormatUnitCalls() []struct {
 	IsFee bool
 } {
@@ -384,7 +427,8 @@ func (mock *CoinMock) Initialize() {
 
 // InitializeCalls gets all the calls that were made to Initialize.
 // Check the length with:
-//     len(mockedCoin.InitializeCalls())
+//
+//	len(mockedCoin.InitializeCalls())
 func (mock *CoinMock) InitializeCalls() []struct {
 } {
 	var calls []struct {
@@ -410,7 +454,8 @@ func (mock *CoinMock) Name() string {
 
 // NameCalls gets all the calls that were made to Name.
 // Check the length with:
-//     len(mockedCoin.NameCalls())
+//
+//	len(mockedCoin.NameCalls())
 func (mock *CoinMock) NameCalls() []struct {
 } {
 	var calls []struct {
@@ -439,7 +484,8 @@ func (mock *CoinMock) Observe(fn func(observable.Event)) func() {
 
 // ObserveCalls gets all the calls that were made to Observe.
 // Check the length with:
-//     len(mockedCoin.ObserveCalls())
+//
+//	len(mockedCoin.ObserveCalls())
 func (mock *CoinMock) ObserveCalls() []struct {
 	Fn func(observable.Event)
 } {
@@ -470,7 +516,8 @@ func (mock *CoinMock) ParseAmount(amount string) (coin.Amount, error) {
 
 // ParseAmountCalls gets all the calls that were made to ParseAmount.
 // Check the length with:
-//     len(mockedCoin.ParseAmountCalls())
+//
+//	len(mockedCoin.ParseAmountCalls())
 func (mock *CoinMock) ParseAmountCalls() []struct {
 	Amount string
 } {
@@ -503,7 +550,8 @@ func (mock *CoinMock) SetAmount(amount *big.Rat, isFee bool) coin.Amount {
 
 // SetAmountCalls gets all the calls that were made to SetAmount.
 // Check the length with:
-//     len(mockedCoin.SetAmountCalls())
+//
+//	len(mockedCoin.SetAmountCalls())
 func (mock *CoinMock) SetAmountCalls() []struct {
 	Amount *big.Rat
 	IsFee  bool
@@ -533,7 +581,8 @@ func (mock *CoinMock) SmallestUnit() string {
 
 // SmallestUnitCalls gets all the calls that were made to SmallestUnit.
 // Check the length with:
-//     len(mockedCoin.SmallestUnitCalls())
+//
+//	len(mockedCoin.SmallestUnitCalls())
 func (mock *CoinMock) SmallestUnitCalls() []struct {
 } {
 	var calls []struct {
@@ -564,7 +613,8 @@ func (mock *CoinMock) ToUnit(amount coin.Amount, isFee bool) float64 {
 
 // ToUnitCalls gets all the calls that were made to ToUnit.
 // Check the length with:
-//     len(mockedCoin.ToUnitCalls())
+//
+//	len(mockedCoin.ToUnitCalls())
 func (mock *CoinMock) ToUnitCalls() []struct {
 	Amount coin.Amount
 	IsFee  bool
@@ -597,7 +647,8 @@ func (mock *CoinMock) Unit(isFee bool) string {
 
 // UnitCalls gets all the calls that were made to Unit.
 // Check the length with:
-//     len(mockedCoin.UnitCalls())
+//
+//	len(mockedCoin.UnitCalls())
 func (mock *CoinMock) UnitCalls() []struct {
 	IsFee bool
 } {
@@ -35,6 +35,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/erc20"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/etherscan"
 	ethtypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/types"
+	keystorePkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
@@ -49,6 +50,12 @@ import (
 
 var pollInterval = 5 * time.Minute
 
+// dataSaverPollInterval is the poll interval in the data saver mode.
+const dataSaverPollInterval = 20 * time.Minute
+
+// idlePollInterval is the poll interval while the app is hidden or not in use.
+const idlePollInterval = 30 * time.Minute
+
 func isMixedCase(s string) bool {
 	return strings.ToLower(s) != s && strings.ToUpper(s) != s
 }
@@ -89,12 +96,16 @@ type Account struct {
 
 	address Address
 
-	// updateLock covers balance, blockNumber, nextNonce, transactions and activeTxProposal.
+	// updateLock covers balance, blockNumber, nextNonce, transactions, pendingTransactions and
+	// activeTxProposal.
 	updateLock   locker.Locker
 	balance      coin.Amount
 	blockNumber  *big.Int
 	nextNonce    uint64
 	transactions []*accounts.TransactionData
+	// pendingTransactions are the stored outgoing txs which are not confirmed yet, sorted
+	// descending by nonce.
+	pendingTransactions []*ethtypes.TransactionWithMetadata
 
 	// if not nil, SendTx() will sign and send this transaction. Set by TxProposal().
 	activeTxProposal *TxProposal
@@ -244,11 +255,48 @@ func (account *Account) poll(initDone func()) {
 				initDone()
 				initDone = nil
 			}
-			timer = time.After(pollInterval)
+			timer = time.After(account.nextPollInterval())
 		}
 	}
 }
 
+// nextPollInterval returns how long to wait until the next regular poll update.
+func (account *Account) nextPollInterval() time.Duration {
+	interval := pollInterval
+	if account.coin.DataSaver() {
+		interval = dataSaverPollInterval
+	}
+	if account.coin.Idle() && interval < idlePollInterval {
+		interval = idlePollInterval
+	}
+	return interval
+}
+
+// PollNow invokes an account update outside of the regular poll updates, e.g. when the app is used
+// again after being idle. It does nothing if an update is already in progress.
+func (account *Account) PollNow() {
+	select {
+	case account.enqueueUpdateCh <- struct{}{}:
+	default:
+	}
+}
+
+// describeContractCalls sets the names of the called contracts and methods of the transactions,
+// if they are known.
+func (account *Account) describeContractCalls(transactions []*accounts.TransactionData) {
+	registry := account.coin.contracts
+	if registry == nil {
+		return
+	}
+	for _, transaction := range transactions {
+		if transaction.MethodID == "" || len(transaction.Addresses) == 0 {
+			continue
+		}
+		transaction.ContractName, _ = registry.Contract(transaction.Addresses[0].Address)
+		transaction.MethodName, _ = registry.Method(transaction.MethodID)
+	}
+}
+
 // updateOutgoingTransactions updates the height of the stored outgoing transactions.
 // We update heights for tx with up to 12 confirmations, so re-orgs are taken into account.
 // tipHeight is the current blockchain height.
@@ -269,7 +317,11 @@ func (account *Account) updateOutgoingTransactions(tipHeight uint64) {
 		return
 	}
 
-	// Update the stored txs' metadata if up to 12 confirmations.
+	numConfirmationsComplete := account.confirmationThreshold()
+	if numConfirmationsComplete == 0 {
+		numConfirmationsComplete = ethtypes.NumConfirmationsComplete
+	}
+	// Update the stored txs' metadata until they are complete.
 	for idx, tx := range outgoingTransactions {
 		txLog := account.log.WithField("idx", idx)
 		remoteTx, err := account.coin.client.TransactionReceiptWithBlockNumber(context.TODO(), tx.Transaction.Hash())
@@ -294,7 +346,7 @@ func (account *Account) updateOutgoingTransactions(tipHeight uint64) {
 			continue
 		}
 		success := remoteTx.Status == types.ReceiptStatusSuccessful
-		if tx.Height == 0 || (tipHeight-remoteTx.BlockNumber) < ethtypes.NumConfirmationsComplete || tx.Success != success {
+		if tx.Height == 0 || (tipHeight-remoteTx.BlockNumber) < uint64(numConfirmationsComplete) || tx.Success != success {
 			tx.Height = remoteTx.BlockNumber
 			tx.GasUsed = remoteTx.GasUsed
 			tx.Success = success
@@ -328,6 +380,7 @@ func (account *Account) outgoingTransactions(allTxs []*accounts.TransactionData)
 	for _, tx := range allTxs {
 		allTxHashes[tx.TxID] = struct{}{}
 	}
+	nonces := replacedNonces(allTxs)
 
 	transactions := []*ethtypes.TransactionWithMetadata{}
 	for _, tx := range outgoingTransactions {
@@ -335,11 +388,25 @@ func (account *Account) outgoingTransactions(allTxs []*accounts.TransactionData)
 		if _, ok := allTxHashes[tx.TxID()]; ok {
 			continue
 		}
+		// Skip txs replaced by a confirmed tx with the same nonce.
+		if _, ok := nonces[tx.Transaction.Nonce()]; ok {
+			continue
+		}
 		transactions = append(transactions, tx)
 	}
 	return transactions, nil
 }
 
+// confirmationThreshold returns the number of confirmations configured by the user after which
+// transactions are complete, or zero for the default. ERC20 tokens use the threshold of Ethereum.
+func (account *Account) confirmationThreshold() int {
+	code := account.coin.Code()
+	if account.coin.erc20Token != nil {
+		code = coin.CodeETH
+	}
+	return account.ConfirmationThreshold(code)
+}
+
 func (account *Account) update() error {
 	defer account.updateLock.Lock()()
 	defer account.Synchronizer.IncRequestsCounter()()
@@ -389,6 +456,12 @@ func (account *Account) update() error {
 			account.nextNonce = localNonce
 		}
 	}
+	account.pendingTransactions = nil
+	for _, tx := range outgoingTransactions {
+		if tx.Height == 0 {
+			account.pendingTransactions = append(account.pendingTransactions, tx)
+		}
+	}
 	outgoingTransactionsData := make([]*accounts.TransactionData, len(outgoingTransactions))
 	for i, tx := range outgoingTransactions {
 		outgoingTransactionsData[i] = tx.TransactionData(
@@ -397,7 +470,9 @@ func (account *Account) update() error {
 			account.address.Address.Hex(),
 		)
 	}
+	account.describeContractCalls(confirmedTansactions)
 	account.transactions = append(outgoingTransactionsData, confirmedTansactions...)
+	accounts.ApplyConfirmationThreshold(account.transactions, account.confirmationThreshold())
 	for _, transaction := range account.transactions {
 		if err := account.notifier.Put([]byte(transaction.TxID)); err != nil {
 			return err
@@ -494,6 +569,11 @@ type TxProposal struct {
 	Signer types.Signer
 	// KeyPath is the location of this account's address/pubkey/privkey.
 	Keypath signing.AbsoluteKeypath
+	// Warnings are risks of the tx that must be shown to the user before signing.
+	Warnings []TxWarning
+	// Replaces is the hash of the pending tx with the same nonce which is replaced by this tx, or
+	// nil. See ReplacementProposal().
+	Replaces *ethcommon.Hash
 }
 
 func (account *Account) newTx(args *accounts.TxProposalArgs) (*TxProposal, error) {
@@ -638,22 +718,30 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (*TxProposal, error
 	}
 
 	return &TxProposal{
-		Coin:    account.coin,
-		Tx:      tx,
-		Fee:     fee,
-		Value:   value,
-		Signer:  types.NewLondonSigner(account.coin.net.ChainID),
-		Keypath: account.signingConfiguration.AbsoluteKeypath(),
+		Coin:     account.coin,
+		Tx:       tx,
+		Fee:      fee,
+		Value:    value,
+		Signer:   types.NewLondonSigner(account.coin.net.ChainID),
+		Keypath:  account.signingConfiguration.AbsoluteKeypath(),
+		Warnings: account.txWarnings(*message.To, message.Data, account.blockNumber),
 	}, nil
 }
 
-// storePendingOutgoingTransaction puts an outgoing tx into the db with height 0 (pending).
-func (account *Account) storePendingOutgoingTransaction(transaction *types.Transaction) error {
+// storePendingOutgoingTransaction puts an outgoing tx into the db with height 0 (pending). If
+// replaces is not nil, the tx it replaces is removed, as it will not confirm anymore.
+func (account *Account) storePendingOutgoingTransaction(
+	transaction *types.Transaction, replaces *ethcommon.Hash) error {
 	dbTx, err := account.db.Begin()
 	if err != nil {
 		return err
 	}
 	defer dbTx.Rollback()
+	if replaces != nil {
+		if err := dbTx.DeleteOutgoingTransaction(*replaces); err != nil {
+			return err
+		}
+	}
 	if err := dbTx.PutOutgoingTransaction(
 		&ethtypes.TransactionWithMetadata{
 			Transaction:       transaction,
@@ -673,6 +761,10 @@ func (account *Account) SendTx() error {
 	unlock := account.updateLock.RLock()
 	txProposal := account.activeTxProposal
 	unlock()
+	interruptedTxProposal, _ := account.BaseAccount.TakeInterruptedProposal().(*TxProposal)
+	if txProposal == nil {
+		txProposal = interruptedTxProposal
+	}
 	if txProposal == nil {
 		return errp.New("No active tx proposal")
 	}
@@ -684,6 +776,9 @@ func (account *Account) SendTx() error {
 
 	account.log.Info("Signing and sending transaction")
 	if err := keystore.SignTransaction(txProposal); err != nil {
+		if errp.Cause(err) == keystorePkg.ErrKeystoreDisconnected {
+			account.BaseAccount.SigningInterrupted(txProposal)
+		}
 		return err
 	}
 	// By experience, at least with the Etherscan backend, this can succeed and still the
@@ -692,7 +787,7 @@ func (account *Account) SendTx() error {
 	if err := account.coin.client.SendTransaction(context.TODO(), txProposal.Tx); err != nil {
 		return errp.WithStack(err)
 	}
-	if err := account.storePendingOutgoingTransaction(txProposal.Tx); err != nil {
+	if err := account.storePendingOutgoingTransaction(txProposal.Tx, txProposal.Replaces); err != nil {
 		return err
 	}
 
@@ -744,9 +839,9 @@ func (account *Account) FeeTargets() ([]accounts.FeeTarget, accounts.FeeTargetCo
 func (account *Account) gasFees(args *accounts.TxProposalArgs) (*big.Int, *big.Int, error) {
 	if args.FeeTargetCode == accounts.FeeTargetCodeCustom {
 		// Convert from Gwei to Wei.
-		amount, err := coin.NewAmountFromString(args.CustomFee, big.NewInt(1e9))
+		amount, err := coin.NewAmountFromString(strings.TrimSpace(args.CustomFee), big.NewInt(1e9))
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, errp.WithStack(errors.ErrInvalidFeeRate)
 		}
 		gasPrice := amount.BigInt()
 		if gasPrice.Cmp(big.NewInt(0)) <= 0 {
@@ -770,6 +865,7 @@ func (account *Account) TxProposal(
 	args *accounts.TxProposalArgs,
 ) (coin.Amount, coin.Amount, coin.Amount, error) {
 	defer account.updateLock.Lock()()
+	account.BaseAccount.DropInterruptedProposal()
 	txProposal, err := account.newTx(args)
 	if err != nil {
 		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
@@ -785,6 +881,26 @@ func (account *Account) TxProposal(
 	return coin.NewAmount(txProposal.Value), coin.NewAmount(txProposal.Fee), coin.NewAmount(total), nil
 }
 
+// ActiveTxProposalWarnings returns the warnings of the active tx proposal, set by TxProposal().
+// Returns nil if there is no active tx proposal.
+func (account *Account) ActiveTxProposalWarnings() []TxWarning {
+	defer account.updateLock.RLock()()
+	if account.activeTxProposal == nil {
+		return nil
+	}
+	return account.activeTxProposal.Warnings
+}
+
+// ActiveTxProposalGasLimit returns the gas limit of the active tx proposal, set by TxProposal().
+// Returns 0 if there is no active tx proposal.
+func (account *Account) ActiveTxProposalGasLimit() uint64 {
+	defer account.updateLock.RLock()()
+	if account.activeTxProposal == nil {
+		return 0
+	}
+	return account.activeTxProposal.Tx.Gas()
+}
+
 // GetUnusedReceiveAddresses implements accounts.Interface.
 func (account *Account) GetUnusedReceiveAddresses() []accounts.AddressList {
 	if !account.isInitialized() {
@@ -872,6 +988,19 @@ type WalletConnectArgs struct {
 	Nonce    string `json:"nonce,omitempty"`
 }
 
+// WalletConnectTxWarnings returns the warnings for an Ethereum Tx received from WalletConnect,
+// which must be shown to the user before calling EthSignWalletConnectTx().
+func (account *Account) WalletConnectTxWarnings(proposedTx WalletConnectArgs) ([]TxWarning, error) {
+	if !IsValidEthAddress(proposedTx.To) {
+		return nil, errp.WithStack(errors.ErrInvalidAddress)
+	}
+	data, err := hex.DecodeString(strings.TrimPrefix(proposedTx.Data, "0x"))
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return account.txWarnings(ethcommon.HexToAddress(proposedTx.To), data, account.blockNumber), nil
+}
+
 // EthSignWalletConnectTx signs an Ethereum Tx received from WalletConnect.
 func (account *Account) EthSignWalletConnectTx(
 	// send: whether transaction should be broadcast after signing
@@ -880,6 +1009,9 @@ func (account *Account) EthSignWalletConnectTx(
 	// TODO L#940 we also need to connect to an appropriate RPC for each L2 network/sidechain
 	chainId uint64,
 	proposedTx WalletConnectArgs,
+	// acceptWarnings: whether the user accepted the warnings returned by WalletConnectTxWarnings().
+	// Signing fails with ErrTxWarningsNotAccepted if there are warnings and they were not accepted.
+	acceptWarnings bool,
 ) (string, string, error) {
 	var nonce uint64
 	var message ethereum.CallMsg
@@ -919,6 +1051,10 @@ func (account *Account) EthSignWalletConnectTx(
 		return "", "", err
 	}
 
+	if !acceptWarnings && len(account.txWarnings(address, data, account.blockNumber)) > 0 {
+		return "", "", errp.WithStack(ErrTxWarningsNotAccepted)
+	}
+
 	message = ethereum.CallMsg{
 		From:     account.address.Address,
 		To:       &address,
@@ -81,6 +81,9 @@ func newAccount(t *testing.T) *Account {
 		PendingNonceAtFunc: func(ctx context.Context, account common.Address) (uint64, error) {
 			return 0, nil
 		},
+		ContractCreationBlockFunc: func(ctx context.Context, address common.Address) (*big.Int, error) {
+			return nil, nil
+		},
 	}
 	coin := NewCoin(client, coin.CodeGOETH, "Goerli", "GOETH", "GOETH", params.GoerliChainConfig, "", nil, nil)
 	acct := NewAccount(
@@ -91,6 +94,7 @@ func newAccount(t *testing.T) *Account {
 				SigningConfigurations: signingConfigurations,
 			},
 			DBFolder:        dbFolder,
+			NotesFolder:     test.TstTempDir("eth-notesfolder"),
 			OnEvent:         func(accountsTypes.Event) {},
 			RateUpdater:     nil,
 			GetNotifier:     func(signing.Configurations) accounts.Notifier { return nil },
@@ -167,6 +171,16 @@ func TestTxProposal(t *testing.T) {
 		})
 		require.Equal(t, errors.ErrInvalidAddress, errp.Cause(err))
 	})
+
+	t.Run("invalid-custom-fee", func(t *testing.T) {
+		_, _, _, err := acct.TxProposal(&accounts.TxProposalArgs{
+			RecipientAddress: "0xa29163852021bf4c139d03dff59ae763ac73e84e",
+			Amount:           coin.NewSendAmount("0.1"),
+			FeeTargetCode:    accounts.FeeTargetCodeCustom,
+			CustomFee:        "twenty",
+		})
+		require.Equal(t, errors.ErrInvalidFeeRate, errp.Cause(err))
+	})
 }
 
 func TestMatchesAddress(t *testing.T) {
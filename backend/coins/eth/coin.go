@@ -16,12 +16,15 @@
 package eth
 
 import (
+	"context"
 	"math/big"
 	"strings"
+	"sync/atomic"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/contracts"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/erc20"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/rpcclient"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
@@ -55,6 +58,16 @@ type Coin struct {
 
 	transactionsSource TransactionsSource
 
+	// dataSaver is true if the accounts should poll less often, see SetDataSaver().
+	dataSaver atomic.Bool
+	// idle is true if the accounts should poll less often because the app is not in use, see
+	// SetIdle().
+	idle atomic.Bool
+
+	// contracts describes the called contracts in the transaction history, nil if unavailable for
+	// this network, see SetContractRegistry().
+	contracts *contracts.Registry
+
 	log *logrus.Entry
 }
 
@@ -111,6 +124,33 @@ func (coin *Coin) ChainID() uint64 { return coin.net.ChainID.Uint64() }
 // Initialize implements coin.Coin.
 func (coin *Coin) Initialize() {}
 
+// SetDataSaver enables or disables the data saver mode, in which the accounts poll the
+// blockchain and the transactions source less often.
+func (coin *Coin) SetDataSaver(enabled bool) {
+	coin.dataSaver.Store(enabled)
+}
+
+// DataSaver returns true if the data saver mode is enabled.
+func (coin *Coin) DataSaver() bool {
+	return coin.dataSaver.Load()
+}
+
+// SetIdle makes the accounts poll less often while the app is hidden or not in use.
+func (coin *Coin) SetIdle(idle bool) {
+	coin.idle.Store(idle)
+}
+
+// Idle returns true if the app is hidden or not in use.
+func (coin *Coin) Idle() bool {
+	return coin.idle.Load()
+}
+
+// SetContractRegistry sets the registry of known contracts used to describe the transactions. It
+// must be called before the accounts are initialized.
+func (coin *Coin) SetContractRegistry(registry *contracts.Registry) {
+	coin.contracts = registry
+}
+
 // Name implements coin.Coin.
 func (coin *Coin) Name() string {
 	return coin.name
@@ -201,11 +241,43 @@ func (coin *Coin) SmallestUnit() string {
 	return "wei"
 }
 
+// FeeSubUnit implements coin.Coin. Gas prices and fees are conventionally shown in gwei (1e9 wei).
+func (coin *Coin) FeeSubUnit() (string, uint) {
+	return "gwei", 9
+}
+
 // ERC20Token returns nil for a normal Ethereum coin, or the erc20 token details for an erc20 token.
 func (coin *Coin) ERC20Token() *erc20.Token {
 	return coin.erc20Token
 }
 
+// FetchERC20Metadata fetches the name, symbol and decimals of the ERC20 token at the contract
+// address.
+func (coin *Coin) FetchERC20Metadata(ctx context.Context, contract common.Address) (*erc20.Metadata, error) {
+	caller, ok := coin.client.(erc20.ContractCaller)
+	if !ok {
+		return nil, errp.New("contract calls are not supported")
+	}
+	creationBlock, err := coin.client.ContractCreationBlock(ctx, contract)
+	if err != nil {
+		return nil, err
+	}
+	if creationBlock == nil {
+		return nil, errp.WithStack(erc20.ErrNotAContract)
+	}
+	return erc20.FetchMetadata(ctx, caller, contract)
+}
+
+// TipHeight returns the current latest block number.
+func (coin *Coin) TipHeight() (*big.Int, error) {
+	return coin.TipHeightContext(context.TODO())
+}
+
+// TipHeightContext is like TipHeight, but the request is cancelled when the context is done.
+func (coin *Coin) TipHeightContext(ctx context.Context) (*big.Int, error) {
+	return coin.client.BlockNumber(ctx)
+}
+
 // Close implements coin.Coin.
 func (coin *Coin) Close() error {
 	// TODO: shut down rpc connection.
@@ -0,0 +1,172 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package contracts is a registry of well-known Ethereum mainnet contracts and contract methods,
+// used to show e.g. "Uniswap V2: swapExactETHForTokens" in the transaction history instead of the
+// contract address.
+package contracts
+
+import (
+	_ "embed" // Needed for the go:embed directive below.
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// defaultRegistry is shipped with the app. It can be extended by an update, see Update().
+//
+//go:embed assets/registry.json
+var defaultRegistry []byte
+
+// methodIDLen is the length of a method selector, the first bytes of the calldata of a contract
+// call.
+const methodIDLen = 4
+
+type registryJSON struct {
+	// Contracts maps checksummed contract addresses to the names of the contracts.
+	Contracts map[string]string `json:"contracts"`
+	// Methods maps 4-byte method selectors, hex encoded with 0x prefix, to the method signatures,
+	// e.g. "transfer(address,uint256)", as listed in 4byte.directory.
+	Methods map[string]string `json:"methods"`
+}
+
+// Registry maps contract addresses and method selectors to human-readable names.
+type Registry struct {
+	// filename is where the update of the registry is persisted.
+	filename string
+
+	contracts map[common.Address]string
+	methods   map[[methodIDLen]byte]string
+	mu        locker.Locker
+}
+
+// NewRegistry returns a registry containing the shipped contracts, extended by the update
+// persisted at filename, if there is one.
+func NewRegistry(filename string) *Registry {
+	registry := &Registry{filename: filename}
+	registry.reset()
+	update, err := os.ReadFile(filename)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		logging.Get().WithGroup("contracts").WithError(err).Error("could not read the contracts update")
+	default:
+		if err := registry.merge(update); err != nil {
+			logging.Get().WithGroup("contracts").WithError(err).Error("invalid contracts update")
+		}
+	}
+	return registry
+}
+
+func (registry *Registry) reset() {
+	registry.contracts = map[common.Address]string{}
+	registry.methods = map[[methodIDLen]byte]string{}
+	if err := registry.merge(defaultRegistry); err != nil {
+		panic(err)
+	}
+}
+
+func parseMethodID(methodID string) ([methodIDLen]byte, error) {
+	var result [methodIDLen]byte
+	decoded, err := hex.DecodeString(strings.TrimPrefix(methodID, "0x"))
+	if err != nil || len(decoded) != methodIDLen {
+		return result, errp.Newf("invalid method selector %q", methodID)
+	}
+	copy(result[:], decoded)
+	return result, nil
+}
+
+// merge adds the contracts and methods of the JSON encoded registry. Nothing is added if the
+// registry is invalid.
+func (registry *Registry) merge(jsonBytes []byte) error {
+	var parsed registryJSON
+	if err := json.Unmarshal(jsonBytes, &parsed); err != nil {
+		return errp.WithStack(err)
+	}
+	contracts := map[common.Address]string{}
+	for address, name := range parsed.Contracts {
+		if !common.IsHexAddress(address) || name == "" {
+			return errp.Newf("invalid contract %q: %q", address, name)
+		}
+		contracts[common.HexToAddress(address)] = name
+	}
+	methods := map[[methodIDLen]byte]string{}
+	for methodID, signature := range parsed.Methods {
+		selector, err := parseMethodID(methodID)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(signature, "(") {
+			return errp.Newf("invalid method signature %q", signature)
+		}
+		methods[selector] = signature
+	}
+	for address, name := range contracts {
+		registry.contracts[address] = name
+	}
+	for selector, signature := range methods {
+		registry.methods[selector] = signature
+	}
+	return nil
+}
+
+// Update replaces the previous update of the registry by the given JSON encoded registry, which
+// has the same format as the shipped one, and persists it. Entries of the update take precedence
+// over the shipped ones.
+func (registry *Registry) Update(jsonBytes []byte) error {
+	defer registry.mu.Lock()()
+	// Validate before changing anything.
+	if err := (&Registry{
+		contracts: map[common.Address]string{},
+		methods:   map[[methodIDLen]byte]string{},
+	}).merge(jsonBytes); err != nil {
+		return err
+	}
+	if err := os.WriteFile(registry.filename, jsonBytes, 0644); err != nil { // #nosec G306
+		return errp.WithStack(err)
+	}
+	registry.reset()
+	return registry.merge(jsonBytes)
+}
+
+// Contract returns the name of the contract at the given address.
+func (registry *Registry) Contract(address string) (string, bool) {
+	if !common.IsHexAddress(address) {
+		return "", false
+	}
+	defer registry.mu.RLock()()
+	name, ok := registry.contracts[common.HexToAddress(address)]
+	return name, ok
+}
+
+// Method returns the name of the method with the given selector, hex encoded with 0x prefix, e.g.
+// "transfer" for "0xa9059cbb".
+func (registry *Registry) Method(methodID string) (string, bool) {
+	selector, err := parseMethodID(methodID)
+	if err != nil {
+		return "", false
+	}
+	defer registry.mu.RLock()()
+	signature, ok := registry.methods[selector]
+	if !ok {
+		return "", false
+	}
+	return signature[:strings.Index(signature, "(")], true
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contracts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	filename := filepath.Join(test.TstTempDir("contracts"), "contracts.json")
+	registry := NewRegistry(filename)
+
+	name, ok := registry.Contract("0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+	require.True(t, ok)
+	require.Equal(t, "Uniswap V2", name)
+	_, ok = registry.Contract("0x0000000000000000000000000000000000000001")
+	require.False(t, ok)
+	_, ok = registry.Contract("invalid")
+	require.False(t, ok)
+
+	method, ok := registry.Method("0x7ff36ab5")
+	require.True(t, ok)
+	require.Equal(t, "swapExactETHForTokens", method)
+	_, ok = registry.Method("0x00000000")
+	require.False(t, ok)
+	_, ok = registry.Method("0x7ff3")
+	require.False(t, ok)
+
+	// Invalid updates are rejected without changes.
+	require.Error(t, registry.Update([]byte(`{"contracts": {"0x1234": "Invalid"}}`)))
+	require.Error(t, registry.Update([]byte(`{"methods": {"0x12345678": "noSignature"}}`)))
+	_, err := os.Stat(filename)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, registry.Update([]byte(`{
+  "contracts": {"0x0000000000000000000000000000000000000001": "Example"},
+  "methods": {"0x12345678": "example(uint256)", "0x7ff36ab5": "swap(uint256)"}
+}`)))
+	name, ok = registry.Contract("0x0000000000000000000000000000000000000001")
+	require.True(t, ok)
+	require.Equal(t, "Example", name)
+	method, ok = registry.Method("0x7ff36ab5")
+	require.True(t, ok)
+	require.Equal(t, "swap", method)
+	// The shipped entries are kept.
+	_, ok = registry.Contract("0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+	require.True(t, ok)
+
+	// The update is persisted.
+	name, ok = NewRegistry(filename).Contract("0x0000000000000000000000000000000000000001")
+	require.True(t, ok)
+	require.Equal(t, "Example", name)
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package erc20
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"unicode"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// The selectors of the optional metadata functions of EIP-20.
+	selectorName     = []byte{0x06, 0xfd, 0xde, 0x03}
+	selectorSymbol   = []byte{0x95, 0xd8, 0x9b, 0x41}
+	selectorDecimals = []byte{0x31, 0x3c, 0xe5, 0x67}
+)
+
+const (
+	// maxMetadataLen is the maximum length of the name and symbol of a token.
+	maxMetadataLen = 64
+	// maxDecimals is the maximum number of decimals of a token.
+	maxDecimals = 36
+)
+
+var (
+	// ErrNotAContract is returned if there is no contract at the address.
+	ErrNotAContract errp.ErrorCode = "erc20NotAContract"
+	// ErrNoMetadata is returned if the contract does not provide a valid symbol or number of
+	// decimals, e.g. because it is not an ERC20 token.
+	ErrNoMetadata errp.ErrorCode = "erc20NoMetadata"
+)
+
+// ContractCaller executes read-only contract calls.
+type ContractCaller interface {
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// Metadata are the name, symbol and decimals of a token, provided by the optional functions of
+// EIP-20.
+type Metadata struct {
+	Name     string
+	Symbol   string
+	Decimals uint
+}
+
+// decodeString decodes the result of a call returning a string. Some older tokens return a
+// bytes32 instead, padded with zeros. Returns false if the result is not a valid string.
+func decodeString(result []byte) (string, bool) {
+	var value []byte
+	if len(result) == 32 {
+		value = bytes.TrimRight(result, "\x00")
+	} else {
+		if len(result) < 64 {
+			return "", false
+		}
+		offset := new(big.Int).SetBytes(result[:32])
+		if !offset.IsInt64() || offset.Int64() > int64(len(result)-32) {
+			return "", false
+		}
+		start := offset.Int64() + 32
+		length := new(big.Int).SetBytes(result[start-32 : start])
+		if !length.IsInt64() || length.Int64() > int64(len(result))-start {
+			return "", false
+		}
+		value = result[start : start+length.Int64()]
+	}
+	decoded := strings.TrimSpace(string(value))
+	if decoded == "" || len(decoded) > maxMetadataLen {
+		return "", false
+	}
+	for _, r := range decoded {
+		if !unicode.IsPrint(r) {
+			return "", false
+		}
+	}
+	return decoded, true
+}
+
+// FetchMetadata fetches the metadata of the token at the contract address. The symbol and the
+// decimals are required, the symbol is used as the name if the contract has no name.
+func FetchMetadata(ctx context.Context, caller ContractCaller, contract common.Address) (*Metadata, error) {
+	call := func(selector []byte) ([]byte, error) {
+		return caller.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: selector}, nil)
+	}
+	result, err := call(selectorDecimals)
+	if err != nil {
+		return nil, err
+	}
+	decimals := new(big.Int).SetBytes(result)
+	if len(result) != 32 || decimals.Cmp(big.NewInt(maxDecimals)) > 0 {
+		return nil, errp.WithStack(ErrNoMetadata)
+	}
+	result, err = call(selectorSymbol)
+	if err != nil {
+		return nil, err
+	}
+	symbol, ok := decodeString(result)
+	if !ok {
+		return nil, errp.WithStack(ErrNoMetadata)
+	}
+	name := symbol
+	// The name is optional.
+	if result, err := call(selectorName); err == nil {
+		if decoded, ok := decodeString(result); ok {
+			name = decoded
+		}
+	}
+	return &Metadata{Name: name, Symbol: symbol, Decimals: uint(decimals.Uint64())}, nil
+}
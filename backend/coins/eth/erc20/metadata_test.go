@@ -0,0 +1,92 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package erc20
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/stretchr/testify/require"
+)
+
+type callerFunc func(msg ethereum.CallMsg) ([]byte, error)
+
+func (f callerFunc) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	return f(msg)
+}
+
+func word(value int64) []byte {
+	return math.U256Bytes(big.NewInt(value))
+}
+
+// encodeString ABI-encodes a string return value.
+func encodeString(s string) []byte {
+	data := append([]byte(s), make([]byte, 32-len(s)%32)...)
+	return append(append(word(32), word(int64(len(s)))...), data...)
+}
+
+func TestFetchMetadata(t *testing.T) {
+	contract := common.HexToAddress("0xdac17f958d2ee523a2206206994597c13d831ec7")
+	results := map[string][]byte{}
+	caller := callerFunc(func(msg ethereum.CallMsg) ([]byte, error) {
+		require.Equal(t, contract, *msg.To)
+		result, ok := results[string(msg.Data)]
+		if !ok {
+			return nil, errors.New("execution reverted")
+		}
+		return result, nil
+	})
+
+	results[string(selectorDecimals)] = word(6)
+	results[string(selectorSymbol)] = encodeString("USDT")
+	results[string(selectorName)] = encodeString("Tether USD")
+	metadata, err := FetchMetadata(context.Background(), caller, contract)
+	require.NoError(t, err)
+	require.Equal(t, &Metadata{Name: "Tether USD", Symbol: "USDT", Decimals: 6}, metadata)
+
+	// bytes32 symbol and no name.
+	delete(results, string(selectorName))
+	results[string(selectorSymbol)] = append([]byte("MKR"), make([]byte, 29)...)
+	metadata, err = FetchMetadata(context.Background(), caller, contract)
+	require.NoError(t, err)
+	require.Equal(t, &Metadata{Name: "MKR", Symbol: "MKR", Decimals: 6}, metadata)
+
+	// Invalid symbols.
+	for _, symbol := range [][]byte{
+		make([]byte, 32),
+		encodeString("\n"),
+		encodeString(string(make([]byte, 65))),
+		append(word(1000), word(4)...),
+		append(word(32), word(1000)...),
+	} {
+		results[string(selectorSymbol)] = symbol
+		_, err = FetchMetadata(context.Background(), caller, contract)
+		require.Equal(t, ErrNoMetadata, errp.Cause(err))
+	}
+
+	results[string(selectorDecimals)] = word(100)
+	_, err = FetchMetadata(context.Background(), caller, contract)
+	require.Equal(t, ErrNoMetadata, errp.Cause(err))
+
+	delete(results, string(selectorDecimals))
+	_, err = FetchMetadata(context.Background(), caller, contract)
+	require.Error(t, err)
+}
@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
@@ -136,6 +137,8 @@ type jsonTransaction struct {
 	contractAddress         *common.Address
 
 	Value jsonBigInt `json:"value"`
+	// Input is the hex encoded calldata. It is "deprecated" for ERC20 token transfers.
+	Input string `json:"input"`
 }
 
 // Transaction implemements accounts.Transaction (TODO).
@@ -145,6 +148,8 @@ type Transaction struct {
 	blockTipHeight  *big.Int
 	// isInternal: true if tx was fetched via `txlistinternal`, false if via `txlist`.
 	isInternal bool
+	// spam is true if the tx is likely a spam token transfer, see markSpam().
+	spam bool
 }
 
 // TransactionData returns the tx data to be shown to the user.
@@ -167,9 +172,24 @@ func (tx *Transaction) TransactionData(isERC20 bool) *accounts.TransactionData {
 		Gas:                      tx.jsonTransaction.GasUsed.BigInt().Uint64(),
 		Nonce:                    &nonce,
 		IsErc20:                  isERC20,
+		MethodID:                 tx.methodID(isERC20),
+		Spam:                     tx.spam,
 	}
 }
 
+// methodID returns the selector of the called contract method, or an empty string if the tx is not
+// a contract call. The input of ERC20 token transfers and internal txs is not provided by
+// Etherscan.
+func (tx *Transaction) methodID(isERC20 bool) string {
+	// 0x followed by the 4 byte selector.
+	const methodIDLen = 2 + 2*4
+	input := tx.jsonTransaction.Input
+	if isERC20 || tx.isInternal || len(input) < methodIDLen || !strings.HasPrefix(input, "0x") {
+		return ""
+	}
+	return strings.ToLower(input[:methodIDLen])
+}
+
 // UnmarshalJSON implements json.Unmarshaler.
 func (tx *Transaction) UnmarshalJSON(jsonBytes []byte) error {
 	if err := json.Unmarshal(jsonBytes, &tx.jsonTransaction); err != nil {
@@ -256,14 +276,15 @@ func (tx *Transaction) addresses() []accounts.AddressAndAmount {
 
 // prepareTransactions casts to []accounts.Transactions and removes duplicate entries. Duplicate
 // entries appear in the etherscan result if the recipient and sender are the same. It also sets the
-// transaction type (send, receive, send to self) based on the account address.
+// transaction type (send, receive, send to self) based on the account address and flags likely spam
+// token transfers.
 func prepareTransactions(
 	isERC20 bool,
 	blockTipHeight *big.Int,
 	isInternal bool,
 	transactions []*Transaction, address common.Address) ([]*accounts.TransactionData, error) {
 	seen := map[string]struct{}{}
-	castTransactions := []*accounts.TransactionData{}
+	prepared := []*Transaction{}
 	ours := address.Hex()
 	for _, transaction := range transactions {
 		if _, ok := seen[transaction.TxID()]; ok {
@@ -294,7 +315,14 @@ func prepareTransactions(
 		}
 		transaction.blockTipHeight = blockTipHeight
 		transaction.isInternal = isInternal
-		castTransactions = append(castTransactions, transaction.TransactionData(isERC20))
+		prepared = append(prepared, transaction)
+	}
+	if isERC20 {
+		markSpam(prepared)
+	}
+	castTransactions := make([]*accounts.TransactionData, len(prepared))
+	for i, transaction := range prepared {
+		castTransactions[i] = transaction.TransactionData(isERC20)
 	}
 	return castTransactions, nil
 }
@@ -447,6 +475,38 @@ func (etherScan *EtherScan) Balance(ctx context.Context, account common.Address)
 	return balance, nil
 }
 
+// ContractCreationBlock implements rpc.Interface.
+func (etherScan *EtherScan) ContractCreationBlock(ctx context.Context, address common.Address) (*big.Int, error) {
+	var result struct {
+		Status  string
+		Message string
+		Result  json.RawMessage
+	}
+
+	params := url.Values{}
+	params.Set("module", "contract")
+	params.Set("action", "getcontractcreation")
+	params.Set("contractaddresses", address.Hex())
+	if err := etherScan.call(params, &result); err != nil {
+		return nil, err
+	}
+	// EtherScan responds with status "0" and a message instead of a list if the address is not a
+	// contract.
+	if result.Status != "1" {
+		return nil, nil
+	}
+	var contracts []struct {
+		BlockNumber jsonBigInt `json:"blockNumber"`
+	}
+	if err := json.Unmarshal(result.Result, &contracts); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if len(contracts) == 0 {
+		return nil, nil
+	}
+	return contracts[0].BlockNumber.BigInt(), nil
+}
+
 // ERC20Balance implements rpc.Interface.
 func (etherScan *EtherScan) ERC20Balance(account common.Address, erc20Token *erc20.Token) (*big.Int, error) {
 	var result struct {
@@ -0,0 +1,68 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherscan
+
+import (
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// lookalikeChars is the number of leading and trailing hex characters of an address that users
+// typically compare, and that address poisoning attackers match with generated addresses.
+const lookalikeChars = 4
+
+// looksAlike returns true if the addresses are different but share the leading and trailing
+// characters.
+func looksAlike(address1, address2 common.Address) bool {
+	if address1 == address2 {
+		return false
+	}
+	hex1 := strings.ToLower(address1.Hex()[2:])
+	hex2 := strings.ToLower(address2.Hex()[2:])
+	return hex1[:lookalikeChars] == hex2[:lookalikeChars] &&
+		hex1[len(hex1)-lookalikeChars:] == hex2[len(hex2)-lookalikeChars:]
+}
+
+// markSpam flags token transfers that are likely spam, which are:
+//   - zero value transfers, which anyone can emit on behalf of any account.
+//   - incoming transfers from an address that looks like one we sent tokens to, so the user might
+//     copy the attacker's address from the history in a later send (address poisoning).
+//
+// The transactions must be of the same token and must have their type set.
+func markSpam(transactions []*Transaction) {
+	recipients := []common.Address{}
+	for _, transaction := range transactions {
+		if transaction.jsonTransaction.Value.BigInt().Sign() == 0 {
+			transaction.spam = true
+			continue
+		}
+		if transaction.txType == accounts.TxTypeSend && transaction.jsonTransaction.to != nil {
+			recipients = append(recipients, *transaction.jsonTransaction.to)
+		}
+	}
+	for _, transaction := range transactions {
+		if transaction.spam || transaction.txType != accounts.TxTypeReceive {
+			continue
+		}
+		for _, recipient := range recipients {
+			if looksAlike(transaction.jsonTransaction.From, recipient) {
+				transaction.spam = true
+				break
+			}
+		}
+	}
+}
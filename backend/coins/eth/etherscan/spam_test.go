@@ -0,0 +1,72 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLooksAlike(t *testing.T) {
+	address := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	require.False(t, looksAlike(address, address))
+	require.True(t, looksAlike(address, common.HexToAddress("0xdac1000000000000000000000000000000001ec7")))
+	require.False(t, looksAlike(address, common.HexToAddress("0xdac1000000000000000000000000000000001ec8")))
+	require.False(t, looksAlike(address, common.HexToAddress("0xdac2000000000000000000000000000000001ec7")))
+}
+
+func TestPrepareTransactionsSpam(t *testing.T) {
+	ours := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	recipient := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	lookalike := common.HexToAddress("0xdac1000000000000000000000000000000001ec7")
+	other := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	makeTx := func(hash int, from, to common.Address, value int64) *Transaction {
+		var tx Transaction
+		require.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(
+			`{"blockNumber":"10","gasUsed":"21000","gasPrice":"1","nonce":"0","hash":"0x%064x",`+
+				`"timeStamp":"1700000000","from":"%s","to":"%s","isError":"0","value":"%d"}`,
+			hash, from.Hex(), to.Hex(), value)), &tx))
+		return &tx
+	}
+	transactions := func() []*Transaction {
+		return []*Transaction{
+			makeTx(1, ours, recipient, 100),
+			makeTx(2, lookalike, ours, 1),
+			makeTx(3, other, ours, 5),
+			makeTx(4, ours, lookalike, 0),
+		}
+	}
+
+	prepared, err := prepareTransactions(true, big.NewInt(20), false, transactions(), ours)
+	require.NoError(t, err)
+	require.Len(t, prepared, 4)
+	require.False(t, prepared[0].Spam)
+	require.True(t, prepared[1].Spam)
+	require.False(t, prepared[2].Spam)
+	require.True(t, prepared[3].Spam)
+
+	// Only token transfers are flagged.
+	prepared, err = prepareTransactions(false, big.NewInt(20), false, transactions(), ours)
+	require.NoError(t, err)
+	for _, transaction := range prepared {
+		require.False(t, transaction.Spam)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/erc20"
+	ethtypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// replacementFeeBumpPercent is the minimum increase of the fees of a replacement tx required by
+// the nodes to accept it in place of the pending tx with the same nonce.
+const replacementFeeBumpPercent = 10
+
+// PendingTransaction is a pending outgoing tx, which can be replaced by a tx with the same nonce.
+type PendingTransaction struct {
+	TxID  string
+	Nonce uint64
+}
+
+// minReplacementFee returns the smallest fee accepted by the nodes for a replacement of a tx
+// paying the given fee, rounded up.
+func minReplacementFee(fee *big.Int) *big.Int {
+	result := new(big.Int).Mul(fee, big.NewInt(100+replacementFeeBumpPercent))
+	result.Add(result, big.NewInt(99))
+	return result.Div(result, big.NewInt(100))
+}
+
+func maxBigInt(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// replacedNonces returns the nonces of our txs among the given confirmed txs. A stored outgoing tx
+// with one of these nonces, but a different hash, was replaced and will never confirm.
+func replacedNonces(confirmedTransactions []*accounts.TransactionData) map[uint64]struct{} {
+	nonces := map[uint64]struct{}{}
+	for _, tx := range confirmedTransactions {
+		if tx.Type != accounts.TxTypeReceive && tx.Nonce != nil {
+			nonces[*tx.Nonce] = struct{}{}
+		}
+	}
+	return nonces
+}
+
+// pendingOutgoingTransaction returns the stored outgoing tx with the given ID. Returns
+// errors.ErrFeeBumpUnavailable if there is no such tx or if it is not pending anymore.
+func (account *Account) pendingOutgoingTransaction(txID string) (*ethtypes.TransactionWithMetadata, error) {
+	for _, tx := range account.pendingTransactions {
+		if strings.EqualFold(tx.TxID(), txID) {
+			return tx, nil
+		}
+	}
+	return nil, errp.WithStack(errors.ErrFeeBumpUnavailable)
+}
+
+// PendingTransactions returns the pending outgoing txs, sorted descending by nonce. These can be
+// replaced using ReplacementProposal().
+func (account *Account) PendingTransactions() []PendingTransaction {
+	defer account.updateLock.RLock()()
+	result := make([]PendingTransaction, len(account.pendingTransactions))
+	for i, tx := range account.pendingTransactions {
+		result[i] = PendingTransaction{TxID: tx.TxID(), Nonce: tx.Transaction.Nonce()}
+	}
+	return result
+}
+
+// newReplacementTx creates a tx with the same nonce as the pending tx with the given ID, paying the
+// fees of the fee target in args, at least the minimum increase required by the nodes. If cancel
+// is false, the replacement sends the same amount to the same recipient. Otherwise, it is a
+// 0-value transfer to ourselves, so that the pending transfer is dropped. For ERC20 tokens, that is
+// a transfer of zero tokens. The other fields of args are ignored.
+func (account *Account) newReplacementTx(txID string, cancel bool, args *accounts.TxProposalArgs) (*TxProposal, error) {
+	if !account.Synced() {
+		return nil, errp.WithStack(errors.ErrAccountNotsynced)
+	}
+	pendingTx, err := account.pendingOutgoingTransaction(txID)
+	if err != nil {
+		return nil, err
+	}
+	oldTx := pendingTx.Transaction
+
+	gasFeeCap, gasTipCap, err := account.gasFees(args)
+	if err != nil {
+		if _, ok := errp.Cause(err).(errors.TxValidationError); ok {
+			return nil, err
+		}
+		account.log.WithError(err).Error("error getting the gas price")
+		return nil, errp.WithStack(errors.ErrFeesNotAvailable)
+	}
+	gasFeeCap = maxBigInt(gasFeeCap, minReplacementFee(oldTx.GasFeeCap()))
+	gasTipCap = maxBigInt(gasTipCap, minReplacementFee(oldTx.GasTipCap()))
+	gasFeeCap = maxBigInt(gasFeeCap, gasTipCap)
+
+	recipient := oldTx.To()
+	txValue := oldTx.Value()
+	data := oldTx.Data()
+	gasLimit := oldTx.Gas()
+	value := pendingTx.TransactionData(0, account.coin.erc20Token, account.address.Address.Hex()).Amount.BigInt()
+	if cancel {
+		value = big.NewInt(0)
+		if account.coin.erc20Token != nil {
+			parsed, err := abi.JSON(strings.NewReader(erc20.IERC20ABI))
+			if err != nil {
+				panic(errp.WithStack(err))
+			}
+			data, err = parsed.Pack("transfer", account.address.Address, big.NewInt(0))
+			if err != nil {
+				panic(errp.WithStack(err))
+			}
+			// Transferring zero tokens to ourselves needs at most as much gas as the original
+			// transfer.
+		} else {
+			recipient = &account.address.Address
+			txValue = big.NewInt(0)
+			data = nil
+			gasLimit = params.TxGas
+		}
+	}
+
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), gasFeeCap)
+	if account.coin.erc20Token == nil {
+		// The balance does not include the amount and the fee of the pending tx, which are spent by
+		// the replacement instead.
+		available := new(big.Int).Add(account.balance.BigInt(), oldTx.Value())
+		available.Add(available, new(big.Int).Mul(new(big.Int).SetUint64(oldTx.Gas()), oldTx.GasPrice()))
+		if new(big.Int).Add(txValue, fee).Cmp(available) > 0 {
+			return nil, errp.WithStack(errors.ErrInsufficientFunds)
+		}
+	}
+
+	var tx *types.Transaction
+	if oldTx.Type() == types.DynamicFeeTxType {
+		tx = types.NewTx(&types.DynamicFeeTx{
+			Nonce:     oldTx.Nonce(),
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+			Gas:       gasLimit,
+			To:        recipient,
+			Value:     txValue,
+			Data:      data,
+		})
+	} else {
+		tx = types.NewTransaction(oldTx.Nonce(), *recipient, txValue, gasLimit, gasFeeCap, data)
+	}
+	replaces := oldTx.Hash()
+	return &TxProposal{
+		Coin:     account.coin,
+		Tx:       tx,
+		Fee:      fee,
+		Value:    value,
+		Signer:   types.NewLondonSigner(account.coin.net.ChainID),
+		Keypath:  account.signingConfiguration.AbsoluteKeypath(),
+		Warnings: account.txWarnings(*recipient, data, account.blockNumber),
+		Replaces: &replaces,
+	}, nil
+}
+
+// ReplacementProposal creates a tx replacing the pending outgoing tx with the given ID, either
+// re-sending it with higher fees or cancelling it, see newReplacementTx(). It returns the amount,
+// the new fee and the total for display in the UI. Like TxProposal(), the proposal is stored
+// internally and can be signed and sent with SendTx(), which then stops tracking the replaced tx.
+// The note of the replaced tx is carried over to the replacement.
+func (account *Account) ReplacementProposal(txID string, cancel bool, args *accounts.TxProposalArgs) (
+	coin.Amount, coin.Amount, coin.Amount, error) {
+	defer account.updateLock.Lock()()
+	txProposal, err := account.newReplacementTx(txID, cancel, args)
+	if err != nil {
+		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
+	}
+	account.activeTxProposal = txProposal
+	account.BaseAccount.ProposeTxNote(account.TxNote(txID))
+
+	total := txProposal.Value
+	if account.coin.erc20Token == nil {
+		total = new(big.Int).Add(txProposal.Value, txProposal.Fee)
+	}
+	return coin.NewAmount(txProposal.Value), coin.NewAmount(txProposal.Fee), coin.NewAmount(total), nil
+}
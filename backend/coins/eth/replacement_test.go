@@ -0,0 +1,158 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/rpcclient"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/rpcclient/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinReplacementFee(t *testing.T) {
+	require.Equal(t, "11000000000", minReplacementFee(big.NewInt(10000000000)).String())
+	// Rounded up.
+	require.Equal(t, "2", minReplacementFee(big.NewInt(1)).String())
+	require.Equal(t, "0", minReplacementFee(big.NewInt(0)).String())
+}
+
+func TestReplacedNonces(t *testing.T) {
+	nonce := func(n uint64) *uint64 { return &n }
+	require.Equal(t,
+		map[uint64]struct{}{3: {}, 4: {}},
+		replacedNonces([]*accounts.TransactionData{
+			{Type: accounts.TxTypeSend, Nonce: nonce(3)},
+			{Type: accounts.TxTypeSendSelf, Nonce: nonce(4)},
+			// The nonce of a received tx is the one of the sender.
+			{Type: accounts.TxTypeReceive, Nonce: nonce(5)},
+			{Type: accounts.TxTypeSend},
+		}))
+}
+
+// nopNotifier is a notifier which does not store anything.
+type nopNotifier struct{}
+
+func (nopNotifier) Put([]byte) error              { return nil }
+func (nopNotifier) Delete([]byte) error           { return nil }
+func (nopNotifier) UnnotifiedCount() (int, error) { return 0, nil }
+func (nopNotifier) MarkAllNotified() error        { return nil }
+
+func TestReplacementProposal(t *testing.T) {
+	acct := newAccount(t)
+	defer acct.Close()
+	acct.Synchronizer.WaitSynchronized()
+	acct.notifier = nopNotifier{}
+	acct.coin.TstSetClient(&mocks.InterfaceMock{
+		BlockNumberFunc: func(ctx context.Context) (*big.Int, error) {
+			return big.NewInt(100), nil
+		},
+		BalanceFunc: func(ctx context.Context, account common.Address) (*big.Int, error) {
+			return big.NewInt(1e18), nil
+		},
+		PendingNonceAtFunc: func(ctx context.Context, account common.Address) (uint64, error) {
+			return 1, nil
+		},
+		TransactionReceiptWithBlockNumberFunc: func(
+			ctx context.Context, hash common.Hash) (*rpcclient.RPCTransactionReceipt, error) {
+			return nil, nil
+		},
+		TransactionByHashFunc: func(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+			return nil, true, nil
+		},
+		ContractCreationBlockFunc: func(ctx context.Context, address common.Address) (*big.Int, error) {
+			return nil, nil
+		},
+	})
+
+	recipient := common.HexToAddress("0xa29163852021BF4C139D03Dff59ae763AC73e84e")
+	pendingTx := types.NewTx(&types.DynamicFeeTx{
+		Nonce:     0,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(10e9),
+		Gas:       21000,
+		To:        &recipient,
+		Value:     big.NewInt(1e17),
+	})
+	require.NoError(t, acct.storePendingOutgoingTransaction(pendingTx, nil))
+	require.NoError(t, acct.update())
+	require.Equal(t,
+		[]PendingTransaction{{TxID: pendingTx.Hash().Hex(), Nonce: 0}},
+		acct.PendingTransactions())
+	require.NoError(t, acct.SetTxNote(pendingTx.Hash().Hex(), "rent"))
+
+	customFee := func(gwei string) *accounts.TxProposalArgs {
+		return &accounts.TxProposalArgs{FeeTargetCode: accounts.FeeTargetCodeCustom, CustomFee: gwei}
+	}
+
+	t.Run("replace", func(t *testing.T) {
+		// The fee cap is raised to the minimum increase of 10%.
+		value, fee, total, err := acct.ReplacementProposal(pendingTx.Hash().Hex(), false, customFee("5"))
+		require.NoError(t, err)
+		require.Equal(t, coin.NewAmountFromInt64(1e17), value)
+		require.Equal(t, coin.NewAmountFromInt64(21000*11e9), fee)
+		require.Equal(t, coin.NewAmountFromInt64(1e17+21000*11e9), total)
+		tx := acct.activeTxProposal.Tx
+		require.Equal(t, uint64(0), tx.Nonce())
+		require.Equal(t, &recipient, tx.To())
+		require.Equal(t, "100000000000000000", tx.Value().String())
+		require.Equal(t, "11000000000", tx.GasFeeCap().String())
+		require.Equal(t, "5000000000", tx.GasTipCap().String())
+		require.Equal(t, pendingTx.Hash(), *acct.activeTxProposal.Replaces)
+		require.Equal(t, "rent", acct.GetAndClearProposedTxNote())
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		value, fee, total, err := acct.ReplacementProposal(pendingTx.Hash().Hex(), true, customFee("20"))
+		require.NoError(t, err)
+		require.Equal(t, coin.NewAmountFromInt64(0), value)
+		require.Equal(t, coin.NewAmountFromInt64(21000*20e9), fee)
+		require.Equal(t, fee, total)
+		tx := acct.activeTxProposal.Tx
+		require.Equal(t, uint64(0), tx.Nonce())
+		require.Equal(t, acct.address.Address, *tx.To())
+		require.Zero(t, tx.Value().Sign())
+		require.Empty(t, tx.Data())
+	})
+
+	t.Run("insufficient-funds", func(t *testing.T) {
+		_, _, _, err := acct.ReplacementProposal(pendingTx.Hash().Hex(), false, customFee("100000"))
+		require.Equal(t, errors.ErrInsufficientFunds, errp.Cause(err))
+	})
+
+	t.Run("unknown-tx", func(t *testing.T) {
+		_, _, _, err := acct.ReplacementProposal(recipient.Hex(), false, customFee("20"))
+		require.Equal(t, errors.ErrFeeBumpUnavailable, errp.Cause(err))
+	})
+
+	t.Run("replaced-tx-is-removed", func(t *testing.T) {
+		_, _, _, err := acct.ReplacementProposal(pendingTx.Hash().Hex(), false, customFee("20"))
+		require.NoError(t, err)
+		replacement := acct.activeTxProposal
+		require.NoError(t, acct.storePendingOutgoingTransaction(replacement.Tx, replacement.Replaces))
+		require.NoError(t, acct.update())
+		require.Equal(t,
+			[]PendingTransaction{{TxID: replacement.Tx.Hash().Hex(), Nonce: 0}},
+			acct.PendingTransactions())
+	})
+}
@@ -31,6 +31,9 @@ var _ rpcclient.Interface = &InterfaceMock{}
 //			BlockNumberFunc: func(ctx context.Context) (*big.Int, error) {
 //				panic("mock out the BlockNumber method")
 //			},
+//			ContractCreationBlockFunc: func(ctx context.Context, address common.Address) (*big.Int, error) {
+//				panic("mock out the ContractCreationBlock method")
+//			},
 //			ERC20BalanceFunc: func(account common.Address, erc20Token *erc20.Token) (*big.Int, error) {
 //				panic("mock out the ERC20Balance method")
 //			},
@@ -68,6 +71,9 @@ type InterfaceMock struct {
 	// BlockNumberFunc mocks the BlockNumber method.
 	BlockNumberFunc func(ctx context.Context) (*big.Int, error)
 
+	// ContractCreationBlockFunc mocks the ContractCreationBlock method.
+	ContractCreationBlockFunc func(ctx context.Context, address common.Address) (*big.Int, error)
+
 	// ERC20BalanceFunc mocks the ERC20Balance method.
 	ERC20BalanceFunc func(account common.Address, erc20Token *erc20.Token) (*big.Int, error)
 
@@ -106,6 +112,13 @@ type InterfaceMock struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// ContractCreationBlock holds details about calls to the ContractCreationBlock method.
+		ContractCreationBlock []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Address is the address argument value.
+			Address common.Address
+		}
 		// ERC20Balance holds details about calls to the ERC20Balance method.
 		ERC20Balance []struct {
 			// Account is the account argument value.
@@ -161,6 +174,7 @@ type InterfaceMock struct {
 	}
 	lockBalance                           sync.RWMutex
 	lockBlockNumber                       sync.RWMutex
+	lockContractCreationBlock             sync.RWMutex
 	lockERC20Balance                      sync.RWMutex
 	lockEstimateGas                       sync.RWMutex
 	lockFeeTargets                        sync.RWMutex
@@ -239,6 +253,42 @@ func (mock *InterfaceMock) BlockNumberCalls() []struct {
 	return calls
 }
 
+// ContractCreationBlock calls ContractCreationBlockFunc.
+func (mock *InterfaceMock) ContractCreationBlock(ctx context.Context, address common.Address) (*big.Int, error) {
+	if mock.ContractCreationBlockFunc == nil {
+		panic("InterfaceMock.ContractCreationBlockFunc: method is nil but Interface.ContractCreationBlock was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Address common.Address
+	}{
+		Ctx:     ctx,
+		Address: address,
+	}
+	mock.lockContractCreationBlock.Lock()
+	mock.calls.ContractCreationBlock = append(mock.calls.ContractCreationBlock, callInfo)
+	mock.lockContractCreationBlock.Unlock()
+	return mock.ContractCreationBlockFunc(ctx, address)
+}
+
+// ContractCreationBlockCalls gets all the calls that were made to ContractCreationBlock.
+// Check the length with:
+//
+//	len(mockedInterface.ContractCreationBlockCalls())
+func (mock *InterfaceMock) ContractCreationBlockCalls() []struct {
+	Ctx     context.Context
+	Address common.Address
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Address common.Address
+	}
+	mock.lockContractCreationBlock.RLock()
+	calls = mock.calls.ContractCreationBlock
+	mock.lockContractCreationBlock.RUnlock()
+	return calls
+}
+
 // ERC20Balance calls ERC20BalanceFunc.
 func (mock *InterfaceMock) ERC20Balance(account common.Address, erc20Token *erc20.Token) (*big.Int, error) {
 	if mock.ERC20BalanceFunc == nil {
@@ -36,6 +36,9 @@ type Interface interface {
 	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
 	// Balance returns the current confirmed balance of the address.
 	Balance(ctx context.Context, account common.Address) (*big.Int, error)
+	// ContractCreationBlock returns the number of the block in which the contract at the given
+	// address was created, or nil if there is no contract at the address.
+	ContractCreationBlock(ctx context.Context, address common.Address) (*big.Int, error)
 	// ERC20Balance returns the current confirmed token balance of the given token for the adddress.
 	ERC20Balance(account common.Address, erc20Token *erc20.Token) (*big.Int, error)
 	// SendTransaction injects the transaction into the pending pool for execution.
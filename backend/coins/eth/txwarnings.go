@@ -0,0 +1,144 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// TxWarningCode identifies a risky pattern of an outgoing transaction.
+type TxWarningCode string
+
+const (
+	// TxWarningUnlimitedApproval means the tx allows the spender to transfer an unlimited amount
+	// of the token on our behalf.
+	TxWarningUnlimitedApproval TxWarningCode = "unlimitedApproval"
+	// TxWarningApprovalForAll means the tx allows the operator to transfer all our NFTs of the
+	// collection on our behalf.
+	TxWarningApprovalForAll TxWarningCode = "approvalForAll"
+	// TxWarningNewContract means the recipient of the tx is a recently created contract.
+	TxWarningNewContract TxWarningCode = "newContract"
+)
+
+// ErrTxWarningsNotAccepted is returned when signing a tx with warnings that the user did not
+// accept.
+var ErrTxWarningsNotAccepted = errors.New("the warnings of the transaction were not accepted")
+
+// TxWarning is a risk of an outgoing transaction that has to be shown to the user before signing.
+type TxWarning struct {
+	Code TxWarningCode `json:"code"`
+	// Address is the approved spender or operator, or the recipient contract.
+	Address string `json:"address"`
+}
+
+// newContractBlocks is the age in blocks under which a contract is considered newly created. It
+// corresponds to about a week.
+const newContractBlocks = 7 * 24 * 60 * 60 / 12
+
+// Method selectors of the calls we analyze.
+var (
+	selectorApprove           = [4]byte{0x09, 0x5e, 0xa7, 0xb3} // approve(address,uint256)
+	selectorIncreaseAllowance = [4]byte{0x39, 0x50, 0x93, 0x51} // increaseAllowance(address,uint256)
+	selectorSetApprovalForAll = [4]byte{0xa2, 0x2c, 0xb4, 0x65} // setApprovalForAll(address,bool)
+	selectorTransfer          = [4]byte{0xa9, 0x05, 0x9c, 0xbb} // transfer(address,uint256)
+	selectorTransferFrom      = [4]byte{0x23, 0xb8, 0x72, 0xdd} // transferFrom(address,address,uint256)
+)
+
+// unlimitedAllowance is the allowance from which on an approval is considered unlimited. Dapps
+// usually approve 2^256-1, but any amount this large is effectively unlimited.
+var unlimitedAllowance = new(big.Int).Lsh(big.NewInt(1), 128)
+
+// callArg returns the ABI encoded 32 byte argument at the given index of the calldata, or nil if
+// the calldata is too short.
+func callArg(data []byte, index int) []byte {
+	start := 4 + 32*index
+	if len(data) < start+32 {
+		return nil
+	}
+	return data[start : start+32]
+}
+
+// analyzeCallData returns the warnings for risky contract calls in the calldata, and the
+// recipient of a token transfer, if the call is one.
+func analyzeCallData(data []byte) ([]TxWarning, *ethcommon.Address) {
+	if len(data) < 4 {
+		return nil, nil
+	}
+	var selector [4]byte
+	copy(selector[:], data)
+	warnings := []TxWarning{}
+	switch selector {
+	case selectorApprove, selectorIncreaseAllowance:
+		spender, amount := callArg(data, 0), callArg(data, 1)
+		if spender != nil && amount != nil && new(big.Int).SetBytes(amount).Cmp(unlimitedAllowance) >= 0 {
+			warnings = append(warnings, TxWarning{
+				Code:    TxWarningUnlimitedApproval,
+				Address: ethcommon.BytesToAddress(spender).Hex(),
+			})
+		}
+	case selectorSetApprovalForAll:
+		operator, approved := callArg(data, 0), callArg(data, 1)
+		if operator != nil && approved != nil && new(big.Int).SetBytes(approved).Sign() != 0 {
+			warnings = append(warnings, TxWarning{
+				Code:    TxWarningApprovalForAll,
+				Address: ethcommon.BytesToAddress(operator).Hex(),
+			})
+		}
+	case selectorTransfer:
+		if recipient := callArg(data, 0); recipient != nil {
+			address := ethcommon.BytesToAddress(recipient)
+			return warnings, &address
+		}
+	case selectorTransferFrom:
+		if recipient := callArg(data, 1); recipient != nil {
+			address := ethcommon.BytesToAddress(recipient)
+			return warnings, &address
+		}
+	}
+	return warnings, nil
+}
+
+// txWarnings analyzes an outgoing tx to the given address with the given calldata for risky
+// patterns. The recipient of ETH or of a token transfer is checked against recently created
+// contracts, which is a common pattern for scams.
+func (account *Account) txWarnings(to ethcommon.Address, data []byte, blockNumber *big.Int) []TxWarning {
+	warnings, recipient := analyzeCallData(data)
+	if warnings == nil {
+		warnings = []TxWarning{}
+	}
+	if len(data) == 0 {
+		recipient = &to
+	}
+	if recipient == nil || blockNumber == nil {
+		return warnings
+	}
+	creationBlock, err := account.coin.client.ContractCreationBlock(context.TODO(), *recipient)
+	if err != nil {
+		account.log.WithError(err).Error("Could not check whether the recipient is a new contract")
+		return warnings
+	}
+	if creationBlock != nil &&
+		new(big.Int).Sub(blockNumber, creationBlock).Cmp(big.NewInt(newContractBlocks)) < 0 {
+		warnings = append(warnings, TxWarning{
+			Code:    TxWarningNewContract,
+			Address: recipient.Hex(),
+		})
+	}
+	return warnings
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeCallData(t *testing.T) {
+	const spender = "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"
+	spenderArg := strings.Repeat("0", 24) + strings.ToLower(spender[2:])
+	decode := func(data string) []byte {
+		decoded, err := hex.DecodeString(data)
+		require.NoError(t, err)
+		return decoded
+	}
+
+	// Unlimited approval.
+	warnings, recipient := analyzeCallData(decode("095ea7b3" + spenderArg + strings.Repeat("f", 64)))
+	require.Equal(t, []TxWarning{{Code: TxWarningUnlimitedApproval, Address: spender}}, warnings)
+	require.Nil(t, recipient)
+
+	// Limited approval.
+	warnings, _ = analyzeCallData(decode("095ea7b3" + spenderArg + strings.Repeat("0", 62) + "64"))
+	require.Empty(t, warnings)
+
+	// Approval of all NFTs.
+	warnings, _ = analyzeCallData(decode("a22cb465" + spenderArg + strings.Repeat("0", 63) + "1"))
+	require.Equal(t, []TxWarning{{Code: TxWarningApprovalForAll, Address: spender}}, warnings)
+
+	// Revoking the approval of all NFTs.
+	warnings, _ = analyzeCallData(decode("a22cb465" + spenderArg + strings.Repeat("0", 64)))
+	require.Empty(t, warnings)
+
+	// Token transfer.
+	warnings, recipient = analyzeCallData(decode("a9059cbb" + spenderArg + strings.Repeat("0", 62) + "64"))
+	require.Empty(t, warnings)
+	require.Equal(t, ethcommon.HexToAddress(spender), *recipient)
+
+	// Truncated calldata.
+	warnings, recipient = analyzeCallData(decode("095ea7b3" + spenderArg))
+	require.Empty(t, warnings)
+	require.Nil(t, recipient)
+}
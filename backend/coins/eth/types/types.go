@@ -29,7 +29,8 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
-// NumConfirmationsComplete indicates after how many confs the tx is considered complete.
+// NumConfirmationsComplete indicates after how many confs the tx is considered complete by default.
+// The user can configure a different one, see accounts.ApplyConfirmationThreshold().
 const NumConfirmationsComplete = 12
 
 // TransactionWithMetadata wraps an outgoing transaction and implements accounts.Transaction.
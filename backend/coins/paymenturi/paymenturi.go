@@ -0,0 +1,103 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package paymenturi composes BIP-21 and EIP-681 payment URIs to request payments to the accounts.
+package paymenturi
+
+import (
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+)
+
+// BIP21Scheme returns the BIP-21 URI scheme of the coin, or false for coins which do not use BIP-21.
+func BIP21Scheme(code coinpkg.Code) (string, bool) {
+	switch code {
+	case coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeRBTC:
+		return "bitcoin", true
+	case coinpkg.CodeLTC, coinpkg.CodeTLTC:
+		return "litecoin", true
+	default:
+		return "", false
+	}
+}
+
+// FormatDecimal formats an amount in the smallest unit (e.g. satoshi) as a decimal in the unit
+// with the given number of decimals (e.g. BTC), without trailing zeros.
+func FormatDecimal(amount *big.Int, decimals uint) string {
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	formatted := new(big.Rat).SetFrac(amount, factor).FloatString(int(decimals))
+	if strings.Contains(formatted, ".") {
+		formatted = strings.TrimSuffix(strings.TrimRight(formatted, "0"), ".")
+	}
+	return formatted
+}
+
+// escape percent-encodes a query value. Spaces are encoded as %20 instead of +, which BIP-21 does
+// not define.
+func escape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// BIP21 composes a BIP-21 URI, e.g. `bitcoin:<address>?amount=0.001&label=Coffee`. amount is in
+// the smallest unit (e.g. satoshi) and omitted if nil. label is omitted if empty.
+func BIP21(scheme string, address string, amount *big.Int, label string) string {
+	var params []string
+	if amount != nil {
+		params = append(params, "amount="+FormatDecimal(amount, 8))
+	}
+	if label != "" {
+		params = append(params, "label="+escape(label))
+	}
+	uri := scheme + ":" + address
+	if len(params) > 0 {
+		uri += "?" + strings.Join(params, "&")
+	}
+	return uri
+}
+
+// EIP681 composes an EIP-681 URI paying Ether, e.g. `ethereum:<address>@1?value=1000000000000000`.
+// value is in wei and omitted if nil. EIP-681 does not define a label, so wallets ignore it, but it
+// is added like in BIP-21 for wallets which show it.
+func EIP681(address string, chainID uint64, value *big.Int, label string) string {
+	var params []string
+	if value != nil {
+		params = append(params, "value="+value.String())
+	}
+	if label != "" {
+		params = append(params, "label="+escape(label))
+	}
+	uri := fmt.Sprintf("ethereum:%s@%d", address, chainID)
+	if len(params) > 0 {
+		uri += "?" + strings.Join(params, "&")
+	}
+	return uri
+}
+
+// EIP681Token composes an EIP-681 URI calling `transfer` of an ERC20 token contract, e.g.
+// `ethereum:<contract>@1/transfer?address=<address>&uint256=1000000`. amount is in the smallest
+// unit of the token and omitted if nil.
+func EIP681Token(contractAddress string, address string, chainID uint64, amount *big.Int, label string) string {
+	params := []string{"address=" + address}
+	if amount != nil {
+		params = append(params, "uint256="+amount.String())
+	}
+	if label != "" {
+		params = append(params, "label="+escape(label))
+	}
+	return fmt.Sprintf("ethereum:%s@%d/transfer?%s", contractAddress, chainID, strings.Join(params, "&"))
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paymenturi
+
+import (
+	"math/big"
+	"testing"
+
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBIP21Scheme(t *testing.T) {
+	scheme, ok := BIP21Scheme(coinpkg.CodeTBTC)
+	require.True(t, ok)
+	require.Equal(t, "bitcoin", scheme)
+	scheme, ok = BIP21Scheme(coinpkg.CodeLTC)
+	require.True(t, ok)
+	require.Equal(t, "litecoin", scheme)
+	_, ok = BIP21Scheme(coinpkg.CodeETH)
+	require.False(t, ok)
+}
+
+func TestFormatDecimal(t *testing.T) {
+	require.Equal(t, "0.001", FormatDecimal(big.NewInt(100000), 8))
+	require.Equal(t, "1", FormatDecimal(big.NewInt(100000000), 8))
+	require.Equal(t, "12.5", FormatDecimal(big.NewInt(1250000000), 8))
+	require.Equal(t, "0", FormatDecimal(big.NewInt(0), 8))
+	require.Equal(t, "42", FormatDecimal(big.NewInt(42), 0))
+}
+
+func TestBIP21(t *testing.T) {
+	require.Equal(t, "bitcoin:bc1qaddress", BIP21("bitcoin", "bc1qaddress", nil, ""))
+	require.Equal(t,
+		"bitcoin:bc1qaddress?amount=0.001&label=Coffee%20%26%20cake",
+		BIP21("bitcoin", "bc1qaddress", big.NewInt(100000), "Coffee & cake"))
+	require.Equal(t, "litecoin:ltc1qaddress?label=Rent", BIP21("litecoin", "ltc1qaddress", nil, "Rent"))
+}
+
+func TestEIP681(t *testing.T) {
+	require.Equal(t, "ethereum:0xAbC@1", EIP681("0xAbC", 1, nil, ""))
+	require.Equal(t,
+		"ethereum:0xAbC@11155111?value=1000000000000000&label=Coffee",
+		EIP681("0xAbC", 11155111, big.NewInt(1e15), "Coffee"))
+	require.Equal(t,
+		"ethereum:0xToken@1/transfer?address=0xAbC&uint256=1500000",
+		EIP681Token("0xToken", "0xAbC", 1, big.NewInt(1500000), ""))
+	require.Equal(t,
+		"ethereum:0xToken@1/transfer?address=0xAbC",
+		EIP681Token("0xToken", "0xAbC", 1, nil, ""))
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/companion"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// errTorOnly is returned when pairing with a companion host in Tor-only mode. The companion host
+// is reached directly in the local network, not through the proxy.
+var errTorOnly errp.ErrorCode = "torOnly"
+
+// CompanionHost returns the host serving the balances to paired mobile apps.
+func (backend *Backend) CompanionHost() *companion.Host {
+	return backend.companionHost
+}
+
+// CompanionClient returns the client showing the balances of a paired desktop app.
+func (backend *Backend) CompanionClient() *companion.Client {
+	return backend.companionClient
+}
+
+// PairCompanionClient pairs the companion client with a host using the pairing URI shown by the
+// host. Pairing is refused in Tor-only mode.
+func (backend *Backend) PairCompanionClient(pairingURI string, name string) error {
+	if backend.config.AppConfig().Backend.Proxy.TorOnly {
+		return errp.WithStack(errTorOnly)
+	}
+	return backend.companionClient.Pair(pairingURI, name)
+}
+
+// SetCompanionHostEnabled persists the setting and starts or stops the companion host accordingly.
+func (backend *Backend) SetCompanionHostEnabled(enabled bool) error {
+	err := backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		appConfig.Backend.CompanionHostEnabled = enabled
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if enabled {
+		return backend.companionHost.Start()
+	}
+	return backend.companionHost.Stop()
+}
@@ -0,0 +1,314 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package companion
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// pollInterval is how often the client fetches the balances and notifications of the host.
+	pollInterval   = 30 * time.Second
+	requestTimeout = 10 * time.Second
+)
+
+// remote is the paired host, as persisted by the client.
+type remote struct {
+	Address     string    `json:"address"`
+	Fingerprint string    `json:"fingerprint"`
+	Token       string    `json:"token"`
+	PairedAt    time.Time `json:"pairedAt"`
+	// LastSeq is the sequence number of the last notification seen.
+	LastSeq int64 `json:"lastSeq"`
+}
+
+// ClientStatus describes the state of the client.
+type ClientStatus struct {
+	Paired  bool   `json:"paired"`
+	Address string `json:"address,omitempty"`
+	// Connected is true if the last request to the host succeeded.
+	Connected  bool       `json:"connected"`
+	LastUpdate *time.Time `json:"lastUpdate,omitempty"`
+	// Summary are the balances of the host's accounts, as of LastUpdate.
+	Summary json.RawMessage `json:"summary,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// Client polls the paired host. The zero value is not usable, use NewClient().
+type Client struct {
+	observable.Implementation
+
+	file *config.File
+
+	remote     *remote
+	httpClient *http.Client
+	summary    json.RawMessage
+	lastUpdate *time.Time
+	err        string
+	quit       chan struct{}
+	mu         locker.Locker
+
+	log *logrus.Entry
+}
+
+// NewClient creates a new client which persists the paired host in the given file.
+func NewClient(filename string) *Client {
+	client := &Client{
+		file: config.NewFile(filepath.Dir(filename), filepath.Base(filename)),
+		log:  logging.Get().WithGroup("companion"),
+	}
+	if client.file.Exists() {
+		var persisted remote
+		if err := client.file.ReadJSON(&persisted); err != nil {
+			client.log.WithError(err).Error("Could not load the paired app")
+		} else {
+			client.setRemote(&persisted)
+		}
+	}
+	return client
+}
+
+// setRemote sets the paired host. The lock must be held when calling this function, unless called
+// from the constructor.
+func (client *Client) setRemote(paired *remote) {
+	client.remote = paired
+	client.summary = nil
+	client.lastUpdate = nil
+	client.err = ""
+	if paired == nil {
+		client.httpClient = nil
+		return
+	}
+	client.httpClient = &http.Client{
+		Timeout:   requestTimeout,
+		Transport: &http.Transport{TLSClientConfig: pinnedTLSConfig(paired.Fingerprint)},
+	}
+}
+
+func (client *Client) notifyStatus() {
+	client.Notify(observable.Event{
+		Subject: "companion/client/status",
+		Action:  action.Reload,
+	})
+}
+
+// Status returns the state of the client.
+func (client *Client) Status() ClientStatus {
+	defer client.mu.RLock()()
+	if client.remote == nil {
+		return ClientStatus{}
+	}
+	return ClientStatus{
+		Paired:     true,
+		Address:    client.remote.Address,
+		Connected:  client.lastUpdate != nil && client.err == "",
+		LastUpdate: client.lastUpdate,
+		Summary:    client.summary,
+		Error:      client.err,
+	}
+}
+
+// Pair pairs with the host using the pairing code scanned from its QR code. name identifies this
+// device in the list of paired devices of the host. A previous pairing is replaced.
+func (client *Client) Pair(pairingCode string, name string) error {
+	uri, err := parsePairingURI(pairingCode)
+	if err != nil {
+		return err
+	}
+	httpClient := &http.Client{
+		Timeout:   requestTimeout,
+		Transport: &http.Transport{TLSClientConfig: pinnedTLSConfig(uri.fingerprint)},
+	}
+	body, err := json.Marshal(map[string]string{"secret": uri.secret, "name": name})
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	response, err := httpClient.Post(
+		fmt.Sprintf("https://%s/api/v1/pair", uri.address), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode != http.StatusOK {
+		return errp.Newf("pairing failed: %s", response.Status)
+	}
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil || result.Token == "" {
+		return errp.New("invalid response of the paired app")
+	}
+	paired := &remote{
+		Address:     uri.address,
+		Fingerprint: uri.fingerprint,
+		Token:       result.Token,
+		PairedAt:    time.Now(),
+	}
+	unlock := client.mu.Lock()
+	err = client.file.WriteJSON(paired)
+	if err == nil {
+		client.setRemote(paired)
+	}
+	unlock()
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	client.log.Infof("paired with %s", uri.address)
+	go client.Update()
+	return nil
+}
+
+// Unpair forgets the paired host.
+func (client *Client) Unpair() error {
+	unlock := client.mu.Lock()
+	client.setRemote(nil)
+	var err error
+	if client.file.Exists() {
+		err = client.file.Remove()
+	}
+	unlock()
+	go client.notifyStatus()
+	return errp.WithStack(err)
+}
+
+func (client *Client) get(paired *remote, httpClient *http.Client, path string, result interface{}) error {
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://%s%s", paired.Address, path), nil)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	request.Header.Set("Authorization", "Bearer "+paired.Token)
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode != http.StatusOK {
+		return errp.Newf("unexpected status: %s", response.Status)
+	}
+	return errp.WithStack(json.NewDecoder(response.Body).Decode(result))
+}
+
+// Update fetches the balances and the new notifications of the paired host. New notifications are
+// sent in "companion/notification" events.
+func (client *Client) Update() {
+	unlock := client.mu.RLock()
+	paired, httpClient := client.remote, client.httpClient
+	var lastSeq int64
+	if paired != nil {
+		lastSeq = paired.LastSeq
+	}
+	unlock()
+	if paired == nil {
+		return
+	}
+	var summary json.RawMessage
+	var notifications []Notification
+	err := client.get(paired, httpClient, "/api/v1/summary", &summary)
+	if err == nil {
+		err = client.get(paired, httpClient,
+			fmt.Sprintf("/api/v1/notifications?after=%d", lastSeq), &notifications)
+	}
+
+	// fresh are the notifications not reported yet. Another update may have run concurrently.
+	fresh := []Notification{}
+	unlock = client.mu.Lock()
+	if client.remote != paired {
+		// Unpaired or paired again in the meantime.
+		unlock()
+		return
+	}
+	if err != nil {
+		client.err = err.Error()
+	} else {
+		now := time.Now()
+		client.summary = summary
+		client.lastUpdate = &now
+		client.err = ""
+		previousSeq := paired.LastSeq
+		for _, notification := range notifications {
+			if notification.Seq > previousSeq {
+				fresh = append(fresh, notification)
+			}
+			if notification.Seq > paired.LastSeq {
+				paired.LastSeq = notification.Seq
+			}
+		}
+		if len(fresh) > 0 {
+			if err := client.file.WriteJSON(paired); err != nil {
+				client.log.WithError(err).Error("Could not persist the paired app")
+			}
+		}
+	}
+	unlock()
+
+	if err != nil {
+		client.log.WithError(err).Info("could not reach the paired app")
+	}
+	for _, notification := range fresh {
+		client.Notify(observable.Event{
+			Subject: "companion/notification",
+			Action:  action.Replace,
+			Object:  notification,
+		})
+	}
+	client.notifyStatus()
+}
+
+// Start periodically updates from the paired host until Stop() is called.
+func (client *Client) Start() {
+	unlock := client.mu.Lock()
+	if client.quit != nil {
+		unlock()
+		return
+	}
+	quit := make(chan struct{})
+	client.quit = quit
+	unlock()
+
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			client.Update()
+			select {
+			case <-ticker.C:
+			case <-quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic updates.
+func (client *Client) Stop() {
+	defer client.mu.Lock()()
+	if client.quit != nil {
+		close(client.quit)
+		client.quit = nil
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package companion lets a mobile app act as a read-only remote view of a desktop app on the same
+// network. The desktop runs a Host, which serves the account balances and incoming payment
+// notifications over TLS with a self-signed certificate. Pairing is done by scanning a QR code
+// shown on the desktop, which contains the address of the host, the fingerprint of its certificate
+// and a one-time pairing secret. The mobile app's Client pins the certificate and exchanges the
+// secret for a long-lived token. Nothing can be changed or sent through the companion API.
+package companion
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+const (
+	// DefaultPort is the port the host listens on. The HWW bridge and the extension API use 8179 and
+	// 8180.
+	DefaultPort = 8181
+
+	uriScheme = "bitbox-companion"
+
+	certFilename = "companion-cert.pem"
+	keyFilename  = "companion-key.pem"
+)
+
+// Notification is an incoming payment reported by the host.
+type Notification struct {
+	// Seq increases with every notification, so clients can fetch the ones they did not see yet.
+	Seq         int64     `json:"seq"`
+	AccountName string    `json:"accountName"`
+	Amount      string    `json:"amount"`
+	Unit        string    `json:"unit"`
+	TxID        string    `json:"txID"`
+	Time        time.Time `json:"time"`
+}
+
+// pairingURI is encoded in the QR code shown by the host.
+type pairingURI struct {
+	// address is host:port of the host.
+	address string
+	// fingerprint is the hex encoded SHA256 hash of the DER encoded certificate of the host.
+	fingerprint string
+	secret      string
+}
+
+func (uri pairingURI) String() string {
+	values := url.Values{}
+	values.Set("address", uri.address)
+	values.Set("fp", uri.fingerprint)
+	values.Set("secret", uri.secret)
+	return uriScheme + ":?" + values.Encode()
+}
+
+func parsePairingURI(uri string) (*pairingURI, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != uriScheme {
+		return nil, errp.New("invalid pairing code")
+	}
+	values := u.Query()
+	parsed := &pairingURI{
+		address:     values.Get("address"),
+		fingerprint: values.Get("fp"),
+		secret:      values.Get("secret"),
+	}
+	if parsed.address == "" || len(parsed.fingerprint) != 2*sha256.Size || parsed.secret == "" {
+		return nil, errp.New("invalid pairing code")
+	}
+	return parsed, nil
+}
+
+func certFingerprint(der []byte) string {
+	hash := sha256.Sum256(der)
+	return hex.EncodeToString(hash[:])
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func randomHex(numBytes int) (string, error) {
+	value := make([]byte, numBytes)
+	if _, err := rand.Read(value); err != nil {
+		return "", errp.WithStack(err)
+	}
+	return hex.EncodeToString(value), nil
+}
+
+// loadOrCreateCert loads the certificate of the host from dir, creating a self-signed one on first
+// use. The certificate is kept so that paired clients keep trusting the host.
+func loadOrCreateCert(dir string) (*tls.Certificate, error) {
+	certFile := filepath.Join(dir, certFilename)
+	keyFile := filepath.Join(dir, keyFilename)
+	if cert, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+		return &cert, nil
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "BitBoxApp companion"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(20, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := os.WriteFile(certFile, certPEM, 0600); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return &cert, nil
+}
+
+// pinnedTLSConfig accepts only the certificate with the given fingerprint.
+func pinnedTLSConfig(fingerprint string) *tls.Config {
+	return &tls.Config{
+		// The self-signed certificate is verified against the pinned fingerprint in
+		// VerifyPeerCertificate.
+		InsecureSkipVerify: true, // #nosec G402
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || certFingerprint(rawCerts[0]) != fingerprint {
+				return errp.New("unexpected certificate of the paired app")
+			}
+			return nil
+		},
+		MinVersion: tls.VersionTLS12,
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package companion
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPairingURI(t *testing.T) {
+	uri := pairingURI{
+		address:     "192.168.1.2:8181",
+		fingerprint: "aa00000000000000000000000000000000000000000000000000000000000000",
+		secret:      "secret",
+	}
+	parsed, err := parsePairingURI(uri.String())
+	require.NoError(t, err)
+	require.Equal(t, uri, *parsed)
+
+	_, err = parsePairingURI("bitcoin:?address=192.168.1.2:8181")
+	require.Error(t, err)
+	_, err = parsePairingURI("bitbox-companion:?address=192.168.1.2:8181&fp=aa&secret=secret")
+	require.Error(t, err)
+}
+
+func TestPairAndUpdate(t *testing.T) {
+	dir := t.TempDir()
+	host := NewHost(dir, "127.0.0.1:0", func() (interface{}, error) {
+		return []map[string]string{{"name": "Bitcoin", "balance": "0.5"}}, nil
+	})
+	host.advertisedHost = func() (string, error) { return "127.0.0.1", nil }
+	_, err := host.StartPairing()
+	require.Error(t, err)
+	require.NoError(t, host.Start())
+	defer func() { require.NoError(t, host.Stop()) }()
+
+	code, err := host.StartPairing()
+	require.NoError(t, err)
+	require.Equal(t, code, host.Status().PairingURI)
+
+	client := NewClient(filepath.Join(dir, "companion.json"))
+	var lock sync.Mutex
+	received := []Notification{}
+	client.Observe(func(event observable.Event) {
+		if event.Subject == "companion/notification" {
+			lock.Lock()
+			defer lock.Unlock()
+			received = append(received, event.Object.(Notification))
+		}
+	})
+
+	// A tampered fingerprint is rejected.
+	uri, err := parsePairingURI(code)
+	require.NoError(t, err)
+	tampered := *uri
+	tampered.fingerprint = "00" + uri.fingerprint[2:]
+	require.Error(t, client.Pair(tampered.String(), "Phone"))
+
+	require.NoError(t, client.Pair(code, "Phone"))
+	require.Len(t, host.Status().Devices, 1)
+	require.Equal(t, "Phone", host.Status().Devices[0].Name)
+	require.Empty(t, host.Status().PairingURI)
+	// The pairing code can only be used once.
+	require.Error(t, NewClient(filepath.Join(dir, "other.json")).Pair(code, "Other"))
+
+	host.AddNotification(Notification{AccountName: "Bitcoin", Amount: "0.1", Unit: "BTC", TxID: "tx"})
+	client.Update()
+	status := client.Status()
+	require.True(t, status.Paired)
+	require.True(t, status.Connected)
+	require.JSONEq(t, `[{"name":"Bitcoin","balance":"0.5"}]`, string(status.Summary))
+	lock.Lock()
+	require.Len(t, received, 1)
+	require.Equal(t, "tx", received[0].TxID)
+	lock.Unlock()
+
+	// Notifications are only reported once, also after restarting the client.
+	client = NewClient(filepath.Join(dir, "companion.json"))
+	client.Update()
+	require.True(t, client.Status().Connected)
+	lock.Lock()
+	require.Len(t, received, 1)
+	lock.Unlock()
+
+	// After unpairing on the host, the token is rejected.
+	require.NoError(t, host.Unpair(host.Status().Devices[0].ID))
+	client.Update()
+	require.False(t, client.Status().Connected)
+	require.NotEmpty(t, client.Status().Error)
+
+	require.NoError(t, client.Unpair())
+	require.False(t, client.Status().Paired)
+}
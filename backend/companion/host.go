@@ -0,0 +1,381 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package companion
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/httpapi"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// pairingTimeout is how long the pairing code shown by the host is valid.
+	pairingTimeout = 5 * time.Minute
+	// maxNotifications is the number of notifications kept for clients which were offline.
+	maxNotifications = 50
+	// maxRequestSize limits the size of request bodies.
+	maxRequestSize = 4096
+)
+
+// PairedDevice is a client which paired with the host.
+type PairedDevice struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	PairedAt time.Time `json:"pairedAt"`
+	// TokenHash is the hex encoded SHA256 hash of the token issued at pairing.
+	TokenHash string `json:"tokenHash"`
+}
+
+// HostStatus describes the current state of the host.
+type HostStatus struct {
+	Running bool   `json:"running"`
+	Address string `json:"address"`
+	// PairingURI is the content of the QR code to scan with the mobile app, while pairing.
+	PairingURI string          `json:"pairingURI,omitempty"`
+	Devices    []*PairedDevice `json:"devices"`
+}
+
+// Host serves the balances and notifications to paired clients. The zero value is not usable, use
+// NewHost().
+type Host struct {
+	observable.Implementation
+
+	dir        string
+	address    string
+	file       *config.File
+	getSummary func() (interface{}, error)
+	// advertisedHost returns the host name or IP clients use to reach the host.
+	advertisedHost func() (string, error)
+
+	devices        []*PairedDevice
+	cert           *tls.Certificate
+	pairingSecret  string
+	pairingExpires time.Time
+	notifications  []Notification
+	nextSeq        int64
+	server         *http.Server
+	listener       net.Listener
+	mu             locker.Locker
+
+	log *logrus.Entry
+}
+
+// NewHost creates a new host listening on the given address once started. The certificate and the
+// paired devices are persisted in dir. getSummary returns the balances shown by the clients.
+func NewHost(dir string, address string, getSummary func() (interface{}, error)) *Host {
+	host := &Host{
+		dir:            dir,
+		address:        address,
+		file:           config.NewFile(dir, "companion-devices.json"),
+		getSummary:     getSummary,
+		advertisedHost: lanIP,
+		devices:        []*PairedDevice{},
+		notifications:  []Notification{},
+		// Sequence numbers continue to increase after a restart, so clients don't miss new
+		// notifications.
+		nextSeq: time.Now().UnixMilli(),
+		log:     logging.Get().WithGroup("companion"),
+	}
+	if host.file.Exists() {
+		if err := host.file.ReadJSON(&host.devices); err != nil {
+			host.log.WithError(err).Error("Could not load the paired devices")
+			host.devices = []*PairedDevice{}
+		}
+	}
+	return host
+}
+
+// lanIP returns the first non-loopback IPv4 address of this computer.
+func lanIP() (string, error) {
+	addresses, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", errp.WithStack(err)
+	}
+	for _, address := range addresses {
+		if ipNet, ok := address.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet.IP.String(), nil
+		}
+	}
+	return "", errp.New("no network connection")
+}
+
+func (host *Host) notifyStatus() {
+	host.Notify(observable.Event{
+		Subject: "companion/host/status",
+		Action:  action.Reload,
+	})
+}
+
+// Start starts listening for requests. It is a no-op if the host is already running.
+func (host *Host) Start() error {
+	defer host.mu.Lock()()
+	if host.server != nil {
+		return nil
+	}
+	cert, err := loadOrCreateCert(host.dir)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", host.address)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	host.cert = cert
+	host.listener = listener
+	host.server = &http.Server{
+		Handler:           host.router(),
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{*cert},
+			MinVersion:   tls.VersionTLS12,
+		},
+	}
+	go func(server *http.Server) {
+		if err := server.ServeTLS(listener, "", ""); err != nil && err != http.ErrServerClosed {
+			host.log.WithError(err).Error("companion host stopped")
+		}
+	}(host.server)
+	host.log.Infof("companion host listening on %s", listener.Addr())
+	go host.notifyStatus()
+	return nil
+}
+
+// Stop stops the host and cancels a pending pairing. Paired devices are kept.
+func (host *Host) Stop() error {
+	defer host.mu.Lock()()
+	if host.server == nil {
+		return nil
+	}
+	// Requests are read-only and short, so they are not waited for. Clients keep connections open
+	// between their updates, which would delay a graceful shutdown.
+	err := host.server.Close()
+	host.server = nil
+	host.listener = nil
+	host.pairingSecret = ""
+	go host.notifyStatus()
+	return errp.WithStack(err)
+}
+
+// Status returns the current state of the host.
+func (host *Host) Status() HostStatus {
+	defer host.mu.RLock()()
+	status := HostStatus{
+		Running: host.server != nil,
+		Devices: append([]*PairedDevice{}, host.devices...),
+	}
+	if host.listener != nil {
+		status.Address = host.listener.Addr().String()
+	}
+	if host.pairingSecret != "" && time.Now().Before(host.pairingExpires) {
+		if uri, err := host.pairingURI(); err == nil {
+			status.PairingURI = uri.String()
+		}
+	}
+	return status
+}
+
+// pairingURI returns the current pairing code. The lock must be held when calling this function.
+func (host *Host) pairingURI() (*pairingURI, error) {
+	ip, err := host.advertisedHost()
+	if err != nil {
+		return nil, err
+	}
+	_, port, err := net.SplitHostPort(host.listener.Addr().String())
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return &pairingURI{
+		address:     net.JoinHostPort(ip, port),
+		fingerprint: certFingerprint(host.cert.Certificate[0]),
+		secret:      host.pairingSecret,
+	}, nil
+}
+
+// StartPairing creates a new pairing code, returned by Status() until a client paired or it
+// expired.
+func (host *Host) StartPairing() (string, error) {
+	secret, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	unlock := host.mu.Lock()
+	if host.server == nil {
+		unlock()
+		return "", errp.New("the companion host is not running")
+	}
+	host.pairingSecret = secret
+	host.pairingExpires = time.Now().Add(pairingTimeout)
+	uri, err := host.pairingURI()
+	unlock()
+	if err != nil {
+		return "", err
+	}
+	go host.notifyStatus()
+	return uri.String(), nil
+}
+
+// Unpair invalidates the token of a paired device.
+func (host *Host) Unpair(id string) error {
+	defer host.mu.Lock()()
+	devices := []*PairedDevice{}
+	for _, device := range host.devices {
+		if device.ID != id {
+			devices = append(devices, device)
+		}
+	}
+	if len(devices) == len(host.devices) {
+		return errp.Newf("unknown device: %s", id)
+	}
+	if err := host.file.WriteJSON(devices); err != nil {
+		return errp.WithStack(err)
+	}
+	host.devices = devices
+	go host.notifyStatus()
+	return nil
+}
+
+// AddNotification queues a notification for the paired clients.
+func (host *Host) AddNotification(notification Notification) {
+	defer host.mu.Lock()()
+	host.nextSeq++
+	notification.Seq = host.nextSeq
+	if notification.Time.IsZero() {
+		notification.Time = time.Now()
+	}
+	host.notifications = append(host.notifications, notification)
+	if len(host.notifications) > maxNotifications {
+		host.notifications = host.notifications[1:]
+	}
+}
+
+func (host *Host) router() http.Handler {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/pair", host.postPair).Methods("POST")
+	router.HandleFunc("/api/v1/summary", host.authenticated(host.getSummaryHandler)).Methods("GET")
+	router.HandleFunc("/api/v1/notifications",
+		host.authenticated(host.getNotifications)).Methods("GET")
+	return router
+}
+
+// authenticated only calls f if the request carries the token of a paired device in the
+// "Authorization: Bearer <token>" header.
+func (host *Host) authenticated(f func(*http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hash := []byte(hashToken(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")))
+		paired := false
+		func() {
+			defer host.mu.RLock()()
+			for _, device := range host.devices {
+				if subtle.ConstantTimeCompare(hash, []byte(device.TokenHash)) == 1 {
+					paired = true
+				}
+			}
+		}()
+		if !paired {
+			httpapi.WriteError(w, http.StatusUnauthorized, "not paired")
+			return
+		}
+		value, err := f(r)
+		if err != nil {
+			host.log.WithError(err).Info("companion request failed")
+			httpapi.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		httpapi.WriteJSON(w, http.StatusOK, value)
+	}
+}
+
+func (host *Host) postPair(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Secret string `json:"secret"`
+		Name   string `json:"name"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+	token, err := randomHex(32)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	unlock := host.mu.Lock()
+	valid := host.pairingSecret != "" && time.Now().Before(host.pairingExpires) &&
+		subtle.ConstantTimeCompare([]byte(request.Secret), []byte(host.pairingSecret)) == 1
+	if !valid {
+		unlock()
+		httpapi.WriteError(w, http.StatusForbidden, "invalid pairing code")
+		return
+	}
+	// The pairing code can only be used once.
+	host.pairingSecret = ""
+	name := request.Name
+	if name == "" {
+		name = "Mobile"
+	}
+	device := &PairedDevice{ID: id, Name: name, PairedAt: time.Now(), TokenHash: hashToken(token)}
+	devices := append(append([]*PairedDevice{}, host.devices...), device)
+	err = host.file.WriteJSON(devices)
+	if err == nil {
+		host.devices = devices
+	}
+	unlock()
+	if err != nil {
+		httpapi.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	host.log.WithField("id", id).Info("companion paired")
+	go host.notifyStatus()
+	httpapi.WriteJSON(w, http.StatusOK, map[string]string{"token": token})
+}
+
+func (host *Host) getSummaryHandler(*http.Request) (interface{}, error) {
+	return host.getSummary()
+}
+
+// getNotifications returns the notifications with a sequence number above the `after` query
+// parameter.
+func (host *Host) getNotifications(r *http.Request) (interface{}, error) {
+	after, _ := strconv.ParseInt(r.URL.Query().Get("after"), 10, 64)
+	defer host.mu.RLock()()
+	result := []Notification{}
+	for _, notification := range host.notifications {
+		if notification.Seq > after {
+			result = append(result, notification)
+		}
+	}
+	return result, nil
+}
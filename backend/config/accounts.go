@@ -57,6 +57,17 @@ type Account struct {
 	// only applies to ETH, and the elements are ERC20 token codes (e.g. "eth-erc20-usdt",
 	// "eth-erc20-bat", etc).
 	ActiveTokens []string `json:"activeTokens,omitempty"`
+	// Alerts are the thresholds at which account alerts are raised. nil if none are configured.
+	Alerts *AccountAlerts `json:"alerts,omitempty"`
+}
+
+// AccountAlerts configures the alerts of an account. Amounts are in the smallest unit of the coin
+// (e.g. satoshi or wei), encoded as decimal strings. Empty means disabled.
+type AccountAlerts struct {
+	// LowBalance raises an alert when the balance drops below this amount.
+	LowBalance string `json:"lowBalance,omitempty"`
+	// LargeOutflow raises an alert for each outgoing transaction sending more than this amount.
+	LargeOutflow string `json:"largeOutflow,omitempty"`
 }
 
 // SetTokenActive activates/deactivates an token on an account. `tokenCode` must be an ERC20 token
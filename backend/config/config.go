@@ -15,12 +15,14 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
+	utilConfig "github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
 )
@@ -30,6 +32,28 @@ type ServerInfo struct {
 	Server  string `json:"server"`
 	TLS     bool   `json:"tls"`
 	PEMCert string `json:"pemCert"`
+	// SSHTunnel, if set, is used to reach the server. Server is then the address of the server as
+	// seen from the SSH server, e.g. "127.0.0.1:50002".
+	SSHTunnel *SSHTunnel `json:"sshTunnel,omitempty"`
+	// Preferred, if set, makes the app connect to this server first, instead of a random one of
+	// the configured servers, e.g. because it is the fastest for the user.
+	Preferred bool `json:"preferred,omitempty"`
+}
+
+// SSHTunnel is an SSH server through which a server is reached. See the sshtunnel package for
+// details.
+type SSHTunnel struct {
+	// Address is the host:port of the SSH server.
+	Address  string `json:"address"`
+	Username string `json:"username"`
+	// PrivateKeyPath is the file of the private key used to authenticate, in the OpenSSH or PEM
+	// format.
+	PrivateKeyPath string `json:"privateKeyPath"`
+	// PrivateKeyPassphrase decrypts the private key, if it is encrypted.
+	PrivateKeyPassphrase string `json:"privateKeyPassphrase"`
+	// HostKey is the pinned public key of the SSH server in the authorized_keys format, e.g.
+	// "ssh-ed25519 AAAA...".
+	HostKey string `json:"hostKey"`
 }
 
 func (s *ServerInfo) String() string {
@@ -59,11 +83,27 @@ const (
 // ethCoinConfig holds configurations for ethereum coins.
 type ethCoinConfig struct {
 	DeprecatedActiveERC20Tokens []string `json:"activeERC20Tokens"`
+	// CustomERC20Tokens are the mainnet ERC20 tokens added by the user in addition to the
+	// built-in ones.
+	CustomERC20Tokens []CustomERC20Token `json:"customERC20Tokens,omitempty"`
+}
+
+// CustomERC20Token is an ERC20 token added by the user by its contract address. The metadata is
+// fetched from the contract when the token is added.
+type CustomERC20Token struct {
+	ContractAddress string `json:"contractAddress"`
+	Name            string `json:"name"`
+	Unit            string `json:"unit"`
+	Decimals        uint   `json:"decimals"`
 }
 
 type proxyConfig struct {
 	UseProxy     bool   `json:"useProxy"`
 	ProxyAddress string `json:"proxyAddress"`
+	// TorOnly routes all connections through the proxy, which is expected to be Tor, even if
+	// UseProxy is false. Features which cannot connect through the proxy, like pairing with a
+	// desktop app in the local network, are disabled.
+	TorOnly bool `json:"torOnly"`
 }
 
 // Backend holds the backend specific configuration.
@@ -102,6 +142,162 @@ type Backend struct {
 
 	// BtcUnit is the unit used to represent Bitcoin amounts. See `coin.BtcUnit` for details.
 	BtcUnit coin.BtcUnit `json:"btcUnit"`
+
+	// RoundChange enables rounding the change amount of BTC/LTC transactions by a random number of
+	// digits, so that a round payment amount does not make the change output trivially
+	// identifiable. The rounding difference is added to the fee. It is at most 10% of the fee and
+	// at most 1000 sat.
+	RoundChange bool `json:"roundChange"`
+
+	// HWWBridgeEnabled enables the local bridge API through which external apps can use the
+	// connected device. See the hwwbridge package for details.
+	HWWBridgeEnabled bool `json:"hwwBridgeEnabled"`
+
+	// ExtensionsEnabled enables the local API through which registered third-party extensions can
+	// integrate with the app. See the extensions package for details.
+	ExtensionsEnabled bool `json:"extensionsEnabled"`
+
+	// CompanionHostEnabled enables the API through which paired mobile apps show the balances and
+	// incoming payments read-only. See the companion package for details.
+	CompanionHostEnabled bool `json:"companionHostEnabled"`
+
+	// Webhooks are called on account events, see the webhooks package for details.
+	Webhooks []Webhook `json:"webhooks"`
+
+	// Rules are automation rules evaluated on account events, see the rules package for details.
+	Rules []Rule `json:"rules"`
+
+	// MobileDataBudgetMB is the amount of data in megabytes the app may use on a mobile data
+	// connection before warning the user. 0 disables the warning.
+	MobileDataBudgetMB uint64 `json:"mobileDataBudgetMB"`
+
+	// MobileDataSaver enables the data saver mode while on a mobile data connection, in which the
+	// app polls less often, defers catching up with the blockchain headers and does not fetch the
+	// historical exchange rates of the charts.
+	MobileDataSaver bool `json:"mobileDataSaver"`
+
+	// LazyAccountInit defers initializing accounts, i.e. subscribing to their addresses and
+	// fetching their transactions, until they are viewed or shown in the summary. Accounts which
+	// are hidden because they are unused are still scanned in the background for account
+	// discovery.
+	LazyAccountInit bool `json:"lazyAccountInit"`
+
+	// MaxFeeEconomyMultiple is the multiple of the economical fee rate estimate above which the fee
+	// rate of a BTC/LTC tx proposal is considered too high, so that the user has to explicitly allow
+	// it. 0 disables the check.
+	MaxFeeEconomyMultiple float64 `json:"maxFeeEconomyMultiple"`
+
+	// MaxFeePercent is the percentage of the sent amount above which the fee of a BTC/LTC tx
+	// proposal is considered too high, so that the user has to explicitly allow it. 0 disables the
+	// check.
+	MaxFeePercent float64 `json:"maxFeePercent"`
+
+	// SpendUnconfirmedChange allows BTC/LTC transactions to spend the unconfirmed change of our own
+	// transactions. If disabled, new transactions only spend confirmed outputs, so they never
+	// depend on an unconfirmed transaction.
+	SpendUnconfirmedChange bool `json:"spendUnconfirmedChange"`
+
+	// LockProposalUTXOs reserves the inputs of a BTC/LTC tx proposal until it is sent, released or
+	// a timeout passes, so that concurrent proposals, e.g. from another window, don't select the
+	// same coins. See accounts.TxProposalArgs.ProposalID.
+	LockProposalUTXOs bool `json:"lockProposalUtxos"`
+
+	// WatchonlyAll loads all persisted accounts as watch-only accounts, independent of the
+	// watchonly setting of their keystore, so that their balances, transactions and receive
+	// addresses remain available without connecting the keystore. Signing still requires the
+	// keystore. Hidden accounts of the accounts discovery are not loaded.
+	WatchonlyAll bool `json:"watchonlyAll"`
+
+	// ConfirmationThresholds maps coin codes to the number of confirmations after which a
+	// transaction is considered complete. Coins without an entry use their default, 6 for
+	// Bitcoin and Litecoin and 12 for Ethereum. ERC20 tokens use the threshold of Ethereum.
+	ConfirmationThresholds map[string]int `json:"confirmationThresholds,omitempty"`
+
+	// ScheduledExport configures the automatic transaction exports and settings backups, see the
+	// scheduledexport package for details.
+	ScheduledExport ScheduledExport `json:"scheduledExport"`
+
+	// MetadataSync configures the encrypted sync of transaction notes and account names between
+	// installations, see the metadatasync package for details.
+	MetadataSync MetadataSync `json:"metadataSync"`
+}
+
+// ScheduledExportInterval is how often the scheduled export runs. See the list of consts below.
+type ScheduledExportInterval string
+
+const (
+	// ScheduledExportDaily runs the export once a day.
+	ScheduledExportDaily ScheduledExportInterval = "daily"
+	// ScheduledExportWeekly runs the export once a week.
+	ScheduledExportWeekly ScheduledExportInterval = "weekly"
+)
+
+// ScheduledExport holds the settings of the scheduled exports.
+type ScheduledExport struct {
+	Enabled bool `json:"enabled"`
+	// Directory is where the exports and backups are written to.
+	Directory string                  `json:"directory"`
+	Interval  ScheduledExportInterval `json:"interval"`
+	// Format is the format of the transaction exports, e.g. "csv", see accounts.ExportFormat.
+	Format string `json:"format"`
+	// BackupPassword encrypts the settings backup. If empty, only the transactions are exported.
+	BackupPassword string `json:"backupPassword"`
+}
+
+// MetadataSyncStore is where the synced metadata is stored. See the list of consts below.
+type MetadataSyncStore string
+
+const (
+	// MetadataSyncStoreFile stores the metadata in a local file, e.g. in a synced folder.
+	MetadataSyncStoreFile MetadataSyncStore = "file"
+	// MetadataSyncStoreWebDAV stores the metadata on a WebDAV server.
+	MetadataSyncStoreWebDAV MetadataSyncStore = "webdav"
+)
+
+// MetadataSync holds the settings of the metadata sync.
+type MetadataSync struct {
+	Enabled bool              `json:"enabled"`
+	Store   MetadataSyncStore `json:"store"`
+	// Path is the file used by MetadataSyncStoreFile.
+	Path string `json:"path"`
+	// URL, Username and Password are used by MetadataSyncStoreWebDAV. Username is optional.
+	URL      string `json:"url"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Passphrase encrypts the synced metadata. It must be the same on all installations.
+	Passphrase string `json:"passphrase"`
+}
+
+// Webhook is an outbound HTTP callback for account events.
+type Webhook struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Secret is used to sign the body of each request with HMAC-SHA256. If empty, requests are not
+	// signed.
+	Secret string `json:"secret"`
+	// Events are the event types sent to this webhook, e.g. "incomingTx".
+	Events []string `json:"events"`
+}
+
+// Rule is an automation rule: when the trigger happens for the account and the condition holds,
+// the actions are run.
+type Rule struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	// AccountCode is the code of the account the rule applies to.
+	AccountCode string `json:"accountCode"`
+	// Trigger is the event evaluating the rule, e.g. "balance".
+	Trigger string `json:"trigger"`
+	// Operator compares the balance or transaction amount with Amount, e.g. "above". If empty, the
+	// rule fires on every trigger.
+	Operator string `json:"operator"`
+	// Amount is the amount in the smallest unit of the coin the balance or transaction amount is
+	// compared with.
+	Amount  string   `json:"amount"`
+	Actions []string `json:"actions"`
+	// WebhookID is the webhook called by the "webhook" action.
+	WebhookID string `json:"webhookID"`
 }
 
 // DeprecatedCoinActive returns the Active setting for a coin by code.  This call is should not be
@@ -120,6 +316,45 @@ func (backend Backend) DeprecatedCoinActive(code coin.Code) bool {
 	}
 }
 
+// ElectrumServers returns the configured Electrum servers of a BTC-based coin. The second return
+// value is false if the coin is not BTC-based.
+func (backend Backend) ElectrumServers(code coin.Code) ([]*ServerInfo, bool) {
+	switch code {
+	case coin.CodeBTC:
+		return backend.BTC.ElectrumServers, true
+	case coin.CodeTBTC:
+		return backend.TBTC.ElectrumServers, true
+	case coin.CodeRBTC:
+		return backend.RBTC.ElectrumServers, true
+	case coin.CodeLTC:
+		return backend.LTC.ElectrumServers, true
+	case coin.CodeTLTC:
+		return backend.TLTC.ElectrumServers, true
+	default:
+		return nil, false
+	}
+}
+
+// SetElectrumServers replaces the configured Electrum servers of a BTC-based coin. It returns false
+// if the coin is not BTC-based.
+func (backend *Backend) SetElectrumServers(code coin.Code, servers []*ServerInfo) bool {
+	switch code {
+	case coin.CodeBTC:
+		backend.BTC.ElectrumServers = servers
+	case coin.CodeTBTC:
+		backend.TBTC.ElectrumServers = servers
+	case coin.CodeRBTC:
+		backend.RBTC.ElectrumServers = servers
+	case coin.CodeLTC:
+		backend.LTC.ElectrumServers = servers
+	case coin.CodeTLTC:
+		backend.TLTC.ElectrumServers = servers
+	default:
+		return false
+	}
+	return true
+}
+
 // AppConfig holds the whole app configuration.
 type AppConfig struct {
 	Backend  Backend     `json:"backend"`
@@ -149,6 +384,7 @@ func NewDefaultAppConfig() AppConfig {
 			Proxy: proxyConfig{
 				UseProxy:     false,
 				ProxyAddress: "",
+				TorOnly:      false,
 			},
 			Authentication:           false,
 			DeprecatedBitcoinActive:  true,
@@ -232,6 +468,21 @@ func NewDefaultAppConfig() AppConfig {
 			FiatList: []string{rates.USD.String(), rates.EUR.String(), rates.CHF.String()},
 			MainFiat: rates.USD.String(),
 			BtcUnit:  coin.BtcUnitDefault,
+
+			MobileDataBudgetMB: 100,
+
+			MaxFeeEconomyMultiple: 10,
+			MaxFeePercent:         25,
+
+			SpendUnconfirmedChange: true,
+
+			ScheduledExport: ScheduledExport{
+				Interval: ScheduledExportDaily,
+				Format:   "csv",
+			},
+			MetadataSync: MetadataSync{
+				Store: MetadataSyncStoreFile,
+			},
 		},
 		Frontend: make(map[string]interface{}),
 	}
@@ -246,6 +497,11 @@ type Config struct {
 	accountsConfigFilename string
 	accountsConfig         AccountsConfig
 	accountsConfigLock     locker.Locker
+
+	// The revisions identify the persisted contents of the respective config, so that concurrent
+	// changes by frontends can be detected, see ErrConflict.
+	appConfigRevision      string
+	accountsConfigRevision string
 }
 
 // NewConfig creates a new Config, stored in the given location. The filename must be writable, but
@@ -299,34 +555,60 @@ func (config *Config) SetTBTCElectrumServers(electrumAddress, electrumCert strin
 	}
 }
 
+// load loads the persisted configs and computes their revisions. If a config file is corrupt, e.g.
+// because the app crashed while writing it, the previous version is recovered, see
+// utilConfig.ReadFileWithRecovery().
 func (config *Config) load() {
-	jsonBytes, err := os.ReadFile(config.appConfigFilename)
+	jsonBytes, err := utilConfig.ReadFileWithRecovery(config.appConfigFilename, json.Valid)
 	if err != nil {
 		return
 	}
 	if err := json.Unmarshal(jsonBytes, &config.appConfig); err != nil {
 		return
 	}
-	jsonBytes, err = os.ReadFile(config.accountsConfigFilename)
+	config.appConfigRevision = revisionOf(jsonBytes)
+	jsonBytes, err = utilConfig.ReadFileWithRecovery(config.accountsConfigFilename, json.Valid)
 	if err != nil {
 		return
 	}
 	if err := json.Unmarshal(jsonBytes, &config.accountsConfig); err != nil {
 		return
 	}
+	config.accountsConfigRevision = revisionOf(jsonBytes)
 }
 
+// ErrConflict is returned if a config is changed based on an outdated revision, e.g. because
+// another frontend changed it in the meantime. The change should be reapplied to the current
+// config.
+const ErrConflict errp.ErrorCode = "configConflict"
+
 // AppConfig returns the app config.
 func (config *Config) AppConfig() AppConfig {
 	defer config.appConfigLock.RLock()()
 	return config.appConfig
 }
 
+// AppConfigWithRevision returns the app config and its revision, see SetAppConfigAtRevision().
+func (config *Config) AppConfigWithRevision() (AppConfig, string) {
+	defer config.appConfigLock.RLock()()
+	return config.appConfig, config.appConfigRevision
+}
+
 // SetAppConfig sets and persists the app config.
 func (config *Config) SetAppConfig(appConfig AppConfig) error {
+	return config.SetAppConfigAtRevision(appConfig, "")
+}
+
+// SetAppConfigAtRevision sets and persists the app config if the current revision matches the
+// given one, so that two frontends can't silently overwrite each other's changes. Otherwise,
+// ErrConflict is returned. An empty revision sets the config unconditionally.
+func (config *Config) SetAppConfigAtRevision(appConfig AppConfig, revision string) error {
 	defer config.appConfigLock.Lock()()
+	if revision != "" && revision != config.appConfigRevision {
+		return errp.WithStack(ErrConflict)
+	}
 	config.appConfig = appConfig
-	return config.save(config.appConfigFilename, config.appConfig)
+	return config.save(config.appConfigFilename, config.appConfig, &config.appConfigRevision)
 }
 
 // ModifyAppConfig calls f with the current config, allowing f to make any changes, and
@@ -336,7 +618,7 @@ func (config *Config) ModifyAppConfig(f func(*AppConfig) error) error {
 	if err := f(&config.appConfig); err != nil {
 		return err
 	}
-	return config.save(config.appConfigFilename, config.appConfig)
+	return config.save(config.appConfigFilename, config.appConfig, &config.appConfigRevision)
 }
 
 // AccountsConfig returns the accounts config.
@@ -348,19 +630,47 @@ func (config *Config) AccountsConfig() AccountsConfig {
 // ModifyAccountsConfig calls f with the current config, allowing f to make any changes, and
 // persists the result if f returns nil error.  It propagates the f's error as is.
 func (config *Config) ModifyAccountsConfig(f func(*AccountsConfig) error) error {
+	return config.ModifyAccountsConfigAtRevision("", f)
+}
+
+// ModifyAccountsConfigAtRevision is like ModifyAccountsConfig(), but returns ErrConflict without
+// calling f if the revision is not empty and does not match the revision of the accounts config,
+// i.e. if the accounts config was changed since the caller read it.
+func (config *Config) ModifyAccountsConfigAtRevision(revision string, f func(*AccountsConfig) error) error {
 	defer config.accountsConfigLock.Lock()()
+	if revision != "" && revision != config.accountsConfigRevision {
+		return errp.WithStack(ErrConflict)
+	}
 	if err := f(&config.accountsConfig); err != nil {
 		return err
 	}
-	return config.save(config.accountsConfigFilename, config.accountsConfig)
+	return config.save(config.accountsConfigFilename, config.accountsConfig, &config.accountsConfigRevision)
 }
 
-func (config *Config) save(filename string, conf interface{}) error {
+// AccountsConfigRevision returns the revision of the accounts config, see
+// ModifyAccountsConfigAtRevision().
+func (config *Config) AccountsConfigRevision() string {
+	defer config.accountsConfigLock.RLock()()
+	return config.accountsConfigRevision
+}
+
+// revisionOf returns the revision of the persisted config contents, the hex encoded SHA256 hash.
+func revisionOf(jsonBytes []byte) string {
+	hash := sha256.Sum256(jsonBytes)
+	return hex.EncodeToString(hash[:])
+}
+
+// save atomically persists the config. The revision is updated once the config was written.
+func (config *Config) save(filename string, conf interface{}, revision *string) error {
 	jsonBytes, err := json.MarshalIndent(conf, "", "    ")
 	if err != nil {
 		return errp.WithStack(err)
 	}
-	return errp.WithStack(os.WriteFile(filename, jsonBytes, 0644)) // #nosec G306
+	if err := utilConfig.WriteFileAtomic(filename, jsonBytes, 0644); err != nil { // #nosec G306
+		return err
+	}
+	*revision = revisionOf(jsonBytes)
+	return nil
 }
 
 // migrateFiatList moves fiatList from appconf.Frontend to appconf.Backend.
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
 	"github.com/stretchr/testify/require"
 )
@@ -70,6 +71,52 @@ func TestSetAppConfig(t *testing.T) {
 	require.Equal(t, map[string]interface{}{"foo": "bar"}, cfg2.AppConfig().Frontend)
 }
 
+func TestConfigRevisions(t *testing.T) {
+	appConfigFilename := test.TstTempFile("appConfig")
+	accountsConfigFilename := test.TstTempFile("accountsConfig")
+
+	cfg, err := NewConfig(appConfigFilename, accountsConfigFilename)
+	require.NoError(t, err)
+
+	// Two frontends read the same revision.
+	appCfg, revision := cfg.AppConfigWithRevision()
+	require.NotEmpty(t, revision)
+	appCfg.Backend.BtcUnit = coin.BtcUnitSats
+	require.NoError(t, cfg.SetAppConfigAtRevision(appCfg, revision))
+	_, newRevision := cfg.AppConfigWithRevision()
+	require.NotEqual(t, revision, newRevision)
+
+	// The second write is based on the outdated revision.
+	appCfg.Backend.MainFiat = "CHF"
+	require.Equal(t, ErrConflict, errp.Cause(cfg.SetAppConfigAtRevision(appCfg, revision)))
+	require.Equal(t, "USD", cfg.AppConfig().Backend.MainFiat)
+	// An empty revision writes unconditionally.
+	require.NoError(t, cfg.SetAppConfigAtRevision(appCfg, ""))
+	require.Equal(t, "CHF", cfg.AppConfig().Backend.MainFiat)
+
+	accountsRevision := cfg.AccountsConfigRevision()
+	addAccount := func(accountsCfg *AccountsConfig) error {
+		accountsCfg.Accounts = append(accountsCfg.Accounts, &Account{Used: true})
+		return nil
+	}
+	require.NoError(t, cfg.ModifyAccountsConfigAtRevision(accountsRevision, addAccount))
+	require.NotEqual(t, accountsRevision, cfg.AccountsConfigRevision())
+	require.Equal(t, ErrConflict,
+		errp.Cause(cfg.ModifyAccountsConfigAtRevision(accountsRevision, addAccount)))
+	require.Len(t, cfg.AccountsConfig().Accounts, 1)
+	require.NoError(t, cfg.ModifyAccountsConfigAtRevision("", addAccount))
+	require.Len(t, cfg.AccountsConfig().Accounts, 2)
+
+	// The revisions are computed when loading the persisted files.
+	loaded := &Config{
+		appConfigFilename:      appConfigFilename,
+		accountsConfigFilename: accountsConfigFilename,
+	}
+	loaded.load()
+	require.Equal(t, cfg.appConfigRevision, loaded.appConfigRevision)
+	require.Equal(t, cfg.accountsConfigRevision, loaded.accountsConfigRevision)
+}
+
 func TestModifyAccountsConfig(t *testing.T) {
 	appConfigFilename := test.TstTempFile("appConfig")
 	accountsConfigFilename := test.TstTempFile("accountsConfig")
@@ -92,6 +139,33 @@ func TestModifyAccountsConfig(t *testing.T) {
 	}))
 }
 
+// TestAccountsConfigRecovery tests that a corrupt accounts config is recovered from the previous
+// version instead of losing all accounts.
+func TestAccountsConfigRecovery(t *testing.T) {
+	appConfigFilename := test.TstTempFile("appConfig")
+	accountsConfigFilename := test.TstTempFile("accountsConfig")
+
+	cfg, err := NewConfig(appConfigFilename, accountsConfigFilename)
+	require.NoError(t, err)
+	require.NoError(t, cfg.ModifyAccountsConfig(func(accountsCfg *AccountsConfig) error {
+		accountsCfg.Accounts = append(accountsCfg.Accounts, &Account{Code: "account-1", Name: "Savings"})
+		return nil
+	}))
+	require.NoError(t, cfg.ModifyAccountsConfig(func(accountsCfg *AccountsConfig) error {
+		accountsCfg.Accounts[0].Inactive = true
+		return nil
+	}))
+
+	// Simulate a crash while writing, leaving a truncated file behind.
+	require.NoError(t, os.WriteFile(accountsConfigFilename, []byte(`{"accounts": [{"co`), 0600))
+
+	cfg2, err := NewConfig(appConfigFilename, accountsConfigFilename)
+	require.NoError(t, err)
+	require.Equal(t,
+		[]*Account{{Code: "account-1", Name: "Savings"}},
+		cfg2.AccountsConfig().Accounts)
+}
+
 // TestMigrationSaved tests that migrations are applied when a config is loaded, and that the
 // migrations are persisted.
 func TestMigrationsAtLoad(t *testing.T) {
@@ -0,0 +1,105 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+// RedactedSecret replaces the passwords, passphrases and secrets in the app config returned by
+// Redacted(), so that they are not logged or sent to the frontend.
+const RedactedSecret = "<redacted>"
+
+// secrets returns the secrets of the backend config by a key which identifies it across copies of
+// the config, e.g. the webhook secrets by the webhook ID.
+func (backend *Backend) secrets() map[string]*string {
+	secrets := map[string]*string{
+		"scheduledExport.backupPassword": &backend.ScheduledExport.BackupPassword,
+		"metadataSync.password":          &backend.MetadataSync.Password,
+		"metadataSync.passphrase":        &backend.MetadataSync.Passphrase,
+	}
+	for i := range backend.Webhooks {
+		webhook := &backend.Webhooks[i]
+		secrets["webhooks."+webhook.ID+".secret"] = &webhook.Secret
+	}
+	for coin, coinConfig := range backend.btcCoinConfigs() {
+		for _, server := range coinConfig.ElectrumServers {
+			if server != nil && server.SSHTunnel != nil {
+				secrets[coin+"."+server.Server+".sshTunnel.privateKeyPassphrase"] = &server.SSHTunnel.PrivateKeyPassphrase
+			}
+		}
+	}
+	return secrets
+}
+
+func (backend *Backend) btcCoinConfigs() map[string]*btcCoinConfig {
+	return map[string]*btcCoinConfig{
+		"btc":  &backend.BTC,
+		"tbtc": &backend.TBTC,
+		"rbtc": &backend.RBTC,
+		"ltc":  &backend.LTC,
+		"tltc": &backend.TLTC,
+	}
+}
+
+// copySecrets returns a copy of the backend config which does not share the secrets with the
+// original, so that they can be changed without changing the original.
+func (backend Backend) copySecrets() Backend {
+	backend.Webhooks = append([]Webhook(nil), backend.Webhooks...)
+	for _, coinConfig := range backend.btcCoinConfigs() {
+		servers := make([]*ServerInfo, len(coinConfig.ElectrumServers))
+		for i, server := range coinConfig.ElectrumServers {
+			if server != nil {
+				serverCopy := *server
+				if server.SSHTunnel != nil {
+					sshTunnel := *server.SSHTunnel
+					serverCopy.SSHTunnel = &sshTunnel
+				}
+				server = &serverCopy
+			}
+			servers[i] = server
+		}
+		if coinConfig.ElectrumServers != nil {
+			coinConfig.ElectrumServers = servers
+		}
+	}
+	return backend
+}
+
+// Redacted returns a copy of the app config in which all secrets which are set are replaced by
+// RedactedSecret.
+func (appConfig AppConfig) Redacted() AppConfig {
+	appConfig.Backend = appConfig.Backend.copySecrets()
+	for _, secret := range appConfig.Backend.secrets() {
+		if *secret != "" {
+			*secret = RedactedSecret
+		}
+	}
+	return appConfig
+}
+
+// WithSecretsOf returns a copy of the app config, e.g. as changed by the frontend based on the
+// config returned by Redacted(), in which the redacted secrets are replaced by the secrets of the
+// current config. Secrets which don't exist in the current config are cleared.
+func (appConfig AppConfig) WithSecretsOf(current AppConfig) AppConfig {
+	appConfig.Backend = appConfig.Backend.copySecrets()
+	currentSecrets := current.Backend.secrets()
+	for key, secret := range appConfig.Backend.secrets() {
+		if *secret != RedactedSecret {
+			continue
+		}
+		*secret = ""
+		if currentSecret, ok := currentSecrets[key]; ok {
+			*secret = *currentSecret
+		}
+	}
+	return appConfig
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedacted(t *testing.T) {
+	appConfig := NewDefaultAppConfig()
+	appConfig.Backend.ScheduledExport.BackupPassword = "backup-password"
+	appConfig.Backend.MetadataSync.Password = "webdav-password"
+	appConfig.Backend.MetadataSync.Passphrase = "sync-passphrase"
+	appConfig.Backend.Webhooks = []Webhook{
+		{ID: "webhook-1", URL: "https://example.com/1", Secret: "webhook-secret"},
+		{ID: "webhook-2", URL: "https://example.com/2"},
+	}
+	appConfig.Backend.BTC.ElectrumServers = []*ServerInfo{
+		{
+			Server: "127.0.0.1:50002",
+			TLS:    true,
+			SSHTunnel: &SSHTunnel{
+				Address:              "example.com:22",
+				PrivateKeyPassphrase: "ssh-passphrase",
+			},
+		},
+	}
+
+	redacted := appConfig.Redacted()
+	require.Equal(t, RedactedSecret, redacted.Backend.ScheduledExport.BackupPassword)
+	require.Equal(t, RedactedSecret, redacted.Backend.MetadataSync.Password)
+	require.Equal(t, RedactedSecret, redacted.Backend.MetadataSync.Passphrase)
+	require.Equal(t, RedactedSecret, redacted.Backend.Webhooks[0].Secret)
+	require.Equal(t, "", redacted.Backend.Webhooks[1].Secret)
+	require.Equal(t, RedactedSecret, redacted.Backend.BTC.ElectrumServers[0].SSHTunnel.PrivateKeyPassphrase)
+	require.Equal(t, "example.com:22", redacted.Backend.BTC.ElectrumServers[0].SSHTunnel.Address)
+	require.NotContains(t, fmt.Sprintf("%+v", redacted.Backend), "webdav-password")
+
+	// The original is unchanged.
+	require.Equal(t, "webhook-secret", appConfig.Backend.Webhooks[0].Secret)
+	require.Equal(t, "ssh-passphrase", appConfig.Backend.BTC.ElectrumServers[0].SSHTunnel.PrivateKeyPassphrase)
+
+	// The redacted secrets sent back by the frontend are restored, changed secrets are kept.
+	redacted.Backend.MetadataSync.Password = "new-webdav-password"
+	redacted.Backend.Webhooks = append(redacted.Backend.Webhooks,
+		Webhook{ID: "webhook-3", Secret: RedactedSecret})
+	restored := redacted.WithSecretsOf(appConfig)
+	require.Equal(t, "backup-password", restored.Backend.ScheduledExport.BackupPassword)
+	require.Equal(t, "new-webdav-password", restored.Backend.MetadataSync.Password)
+	require.Equal(t, "sync-passphrase", restored.Backend.MetadataSync.Passphrase)
+	require.Equal(t, "webhook-secret", restored.Backend.Webhooks[0].Secret)
+	require.Equal(t, "", restored.Backend.Webhooks[2].Secret)
+	require.Equal(t, "ssh-passphrase", restored.Backend.BTC.ElectrumServers[0].SSHTunnel.PrivateKeyPassphrase)
+	require.Equal(t, RedactedSecret, redacted.Backend.Webhooks[0].Secret)
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	ethtypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// maxConfirmationThreshold is the largest number of confirmations the user can require for a
+// transaction to be complete.
+const maxConfirmationThreshold = 1000
+
+// defaultConfirmationThresholds are the coins for which the user can configure after how many
+// confirmations a transaction is complete, with their defaults. ERC20 tokens use the threshold of
+// Ethereum.
+var defaultConfirmationThresholds = []struct {
+	code      coinpkg.Code
+	threshold int
+}{
+	{coinpkg.CodeBTC, transactions.NumConfirmationsComplete},
+	{coinpkg.CodeTBTC, transactions.NumConfirmationsComplete},
+	{coinpkg.CodeRBTC, transactions.NumConfirmationsComplete},
+	{coinpkg.CodeLTC, transactions.NumConfirmationsComplete},
+	{coinpkg.CodeTLTC, transactions.NumConfirmationsComplete},
+	{coinpkg.CodeETH, ethtypes.NumConfirmationsComplete},
+	{coinpkg.CodeGOETH, ethtypes.NumConfirmationsComplete},
+	{coinpkg.CodeSEPETH, ethtypes.NumConfirmationsComplete},
+}
+
+// ConfirmationThreshold is the number of confirmations after which transactions of a coin are
+// complete.
+type ConfirmationThreshold struct {
+	CoinCode         coinpkg.Code `json:"coinCode"`
+	NumConfirmations int          `json:"numConfirmations"`
+	Default          int          `json:"default"`
+}
+
+// ConfirmationThresholds returns the confirmation thresholds of all coins, see
+// SetConfirmationThreshold().
+func (backend *Backend) ConfirmationThresholds() []ConfirmationThreshold {
+	configured := backend.config.AppConfig().Backend.ConfirmationThresholds
+	result := make([]ConfirmationThreshold, len(defaultConfirmationThresholds))
+	for i, entry := range defaultConfirmationThresholds {
+		result[i] = ConfirmationThreshold{
+			CoinCode:         entry.code,
+			NumConfirmations: entry.threshold,
+			Default:          entry.threshold,
+		}
+		if threshold := configured[string(entry.code)]; threshold > 0 {
+			result[i].NumConfirmations = threshold
+		}
+	}
+	return result
+}
+
+// SetConfirmationThreshold sets the number of confirmations after which transactions of the coin
+// are considered complete in the transaction history, the balances of pending transactions and the
+// account events. Zero resets it to the default of the coin.
+func (backend *Backend) SetConfirmationThreshold(code coinpkg.Code, numConfirmations int) error {
+	known := false
+	for _, entry := range defaultConfirmationThresholds {
+		if entry.code == code {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return errp.Newf("unknown coin code %s", code)
+	}
+	if numConfirmations < 0 || numConfirmations > maxConfirmationThreshold {
+		return errp.Newf("the number of confirmations must be between 1 and %d", maxConfirmationThreshold)
+	}
+	err := backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		thresholds := map[string]int{}
+		for coinCode, threshold := range appConfig.Backend.ConfirmationThresholds {
+			thresholds[coinCode] = threshold
+		}
+		if numConfirmations == 0 {
+			delete(thresholds, string(code))
+		} else {
+			thresholds[string(code)] = numConfirmations
+		}
+		appConfig.Backend.ConfirmationThresholds = thresholds
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Reload the accounts, so that the statuses of their transactions are updated.
+	backend.ReinitializeAccounts()
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmationThresholds(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	threshold := func(code coinpkg.Code) ConfirmationThreshold {
+		for _, threshold := range b.ConfirmationThresholds() {
+			if threshold.CoinCode == code {
+				return threshold
+			}
+		}
+		require.Fail(t, "missing coin", code)
+		return ConfirmationThreshold{}
+	}
+	require.Equal(t, ConfirmationThreshold{coinpkg.CodeBTC, 6, 6}, threshold(coinpkg.CodeBTC))
+	require.Equal(t, ConfirmationThreshold{coinpkg.CodeETH, 12, 12}, threshold(coinpkg.CodeETH))
+
+	require.NoError(t, b.SetConfirmationThreshold(coinpkg.CodeBTC, 3))
+	require.NoError(t, b.SetConfirmationThreshold(coinpkg.CodeETH, 64))
+	require.Equal(t, ConfirmationThreshold{coinpkg.CodeBTC, 3, 6}, threshold(coinpkg.CodeBTC))
+	require.Equal(t, ConfirmationThreshold{coinpkg.CodeETH, 64, 12}, threshold(coinpkg.CodeETH))
+	require.Equal(t, ConfirmationThreshold{coinpkg.CodeLTC, 6, 6}, threshold(coinpkg.CodeLTC))
+	require.Equal(t,
+		map[string]int{"btc": 3, "eth": 64},
+		b.Config().AppConfig().Backend.ConfirmationThresholds)
+
+	// Zero resets to the default.
+	require.NoError(t, b.SetConfirmationThreshold(coinpkg.CodeBTC, 0))
+	require.Equal(t, ConfirmationThreshold{coinpkg.CodeBTC, 6, 6}, threshold(coinpkg.CodeBTC))
+	require.Equal(t, map[string]int{"eth": 64}, b.Config().AppConfig().Backend.ConfirmationThresholds)
+
+	require.Error(t, b.SetConfirmationThreshold(coinpkg.CodeBTC, -1))
+	require.Error(t, b.SetConfirmationThreshold(coinpkg.CodeBTC, maxConfirmationThreshold+1))
+	require.Error(t, b.SetConfirmationThreshold("eth-erc20-usdt", 3))
+}
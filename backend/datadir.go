@@ -0,0 +1,338 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+)
+
+// DataDirMoveState is the state of moving the data directory.
+type DataDirMoveState string
+
+const (
+	// DataDirMoveIdle means no move was started.
+	DataDirMoveIdle DataDirMoveState = "idle"
+	// DataDirMoveMoving means the data is being copied and verified.
+	DataDirMoveMoving DataDirMoveState = "moving"
+	// DataDirMoveDone means the data was moved. The backend is closed, so that nothing is written to
+	// the old location anymore, and the app has to be restarted to use the new location, see
+	// ErrRestartRequired.
+	DataDirMoveDone DataDirMoveState = "done"
+	// DataDirMoveFailed means the move failed and was rolled back. The old location remains in use.
+	DataDirMoveFailed DataDirMoveState = "failed"
+)
+
+// ErrRestartRequired is returned by the API once the data directory was moved, until the app is
+// restarted.
+const ErrRestartRequired errp.ErrorCode = "restartRequired"
+
+// DataDirMoveStatus is the progress of moving the data directory, sent in "datadir/move" events.
+type DataDirMoveStatus struct {
+	State       DataDirMoveState `json:"state"`
+	CopiedBytes int64            `json:"copiedBytes"`
+	TotalBytes  int64            `json:"totalBytes"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// DataDirInfo describes the location of the app data.
+type DataDirInfo struct {
+	Path        string            `json:"path"`
+	DefaultPath string            `json:"defaultPath"`
+	Supported   bool              `json:"supported"`
+	MoveStatus  DataDirMoveStatus `json:"moveStatus"`
+	// Error is set if the custom data directory is not available, in which case Path is the
+	// default data directory. See config.CustomAppDirError().
+	Error string `json:"error,omitempty"`
+}
+
+// skipDataDirEntry returns true for files which are not moved: the pointer to the custom data
+// directory, which has to stay in the default location, and the log file, which is in use and is
+// recreated in the new location after the restart.
+func skipDataDirEntry(relPath string) bool {
+	return config.IsDataDirPointer(relPath) || relPath == "log.txt"
+}
+
+// dataDirFiles returns the paths of all files to move, relative to dir, and their total size.
+func dataDirFiles(dir string) ([]string, int64, error) {
+	files := []string{}
+	var total int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || skipDataDirEntry(relPath) || !info.Mode().IsRegular() {
+			return nil
+		}
+		files = append(files, relPath)
+		total += info.Size()
+		return nil
+	})
+	return files, total, errp.WithStack(err)
+}
+
+func copyDataDirFile(source, target string) (int64, error) {
+	in, err := os.Open(source)
+	if err != nil {
+		return 0, errp.WithStack(err)
+	}
+	defer func() { _ = in.Close() }()
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return 0, errp.WithStack(err)
+	}
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return 0, errp.WithStack(err)
+	}
+	n, err := io.Copy(out, in)
+	if err != nil {
+		_ = out.Close()
+		return n, errp.WithStack(err)
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return n, errp.WithStack(err)
+	}
+	return n, errp.WithStack(out.Close())
+}
+
+func fileHash(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	defer func() { _ = f.Close() }()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return hash.Sum(nil), nil
+}
+
+// validateDataDirTarget checks that the data can be moved from source to target. The target must
+// not overlap with the source and must be empty or not exist yet.
+func validateDataDirTarget(source, target string) error {
+	if !filepath.IsAbs(target) {
+		return errp.New("the data directory must be an absolute path")
+	}
+	source, target = filepath.Clean(source), filepath.Clean(target)
+	if source == target {
+		return errp.New("the data directory is already at this location")
+	}
+	isWithin := func(dir, path string) bool {
+		return strings.HasPrefix(path+string(filepath.Separator), dir+string(filepath.Separator))
+	}
+	if isWithin(source, target) || isWithin(target, source) {
+		return errp.New("the new data directory must not be inside the current one or vice versa")
+	}
+	entries, err := os.ReadDir(target)
+	if err != nil && !os.IsNotExist(err) {
+		return errp.WithStack(err)
+	}
+	for _, entry := range entries {
+		// Moving back to the default location, which still contains the pointer file.
+		if !config.IsDataDirPointer(entry.Name()) {
+			return errp.New("the new data directory must be empty")
+		}
+	}
+	return nil
+}
+
+// moveDataDir copies all data files from source to target and verifies the copies. The originals
+// are left in place. onProgress is called after each copied file. If copying or verifying fails,
+// the copies are removed again.
+func moveDataDir(source, target string, onProgress func(copied, total int64)) error {
+	files, total, err := dataDirFiles(source)
+	if err != nil {
+		return err
+	}
+	copied := []string{}
+	rollback := func() {
+		for _, relPath := range copied {
+			_ = os.Remove(filepath.Join(target, relPath))
+		}
+	}
+	var copiedBytes int64
+	onProgress(copiedBytes, total)
+	for _, relPath := range files {
+		n, err := copyDataDirFile(filepath.Join(source, relPath), filepath.Join(target, relPath))
+		if n > 0 || err == nil {
+			copied = append(copied, relPath)
+		}
+		if err != nil {
+			rollback()
+			return err
+		}
+		copiedBytes += n
+		onProgress(copiedBytes, total)
+	}
+	for _, relPath := range files {
+		sourceHash, err := fileHash(filepath.Join(source, relPath))
+		if err != nil {
+			rollback()
+			return err
+		}
+		targetHash, err := fileHash(filepath.Join(target, relPath))
+		if err != nil {
+			rollback()
+			return err
+		}
+		if !bytes.Equal(sourceHash, targetHash) {
+			rollback()
+			return errp.Newf("verification failed for %s", relPath)
+		}
+	}
+	return nil
+}
+
+// removeDataDirFiles removes the data files from dir, e.g. the old location after a successful move.
+// Failures are only logged.
+func (backend *Backend) removeDataDirFiles(dir string) {
+	files, _, err := dataDirFiles(dir)
+	if err != nil {
+		backend.log.WithError(err).Error("Could not list the old data directory")
+		return
+	}
+	for _, relPath := range files {
+		if err := os.Remove(filepath.Join(dir, relPath)); err != nil {
+			backend.log.WithError(err).Errorf("Could not remove %s from the old data directory", relPath)
+		}
+	}
+}
+
+func (backend *Backend) setDataDirMoveStatus(status DataDirMoveStatus) {
+	unlock := backend.dataDirMoveStatusLock.Lock()
+	backend.dataDirMoveStatus = status
+	unlock()
+	backend.Notify(observable.Event{
+		Subject: "datadir/move",
+		Action:  action.Replace,
+		Object:  status,
+	})
+}
+
+// DataDir returns the location of the app data and the status of moving it.
+func (backend *Backend) DataDir() DataDirInfo {
+	defer backend.dataDirMoveStatusLock.RLock()()
+	status := backend.dataDirMoveStatus
+	if status.State == "" {
+		status.State = DataDirMoveIdle
+	}
+	info := DataDirInfo{
+		Path:        backend.arguments.MainDirectoryPath(),
+		DefaultPath: config.DefaultAppDir(),
+		Supported:   config.CustomAppDirSupported(),
+		MoveStatus:  status,
+	}
+	if err := config.CustomAppDirError(); err != nil {
+		info.Error = err.Error()
+	}
+	return info
+}
+
+// RestartRequired returns true if the data directory was moved, after which the backend is closed
+// and the app has to be restarted.
+func (backend *Backend) RestartRequired() bool {
+	defer backend.dataDirMoveStatusLock.RLock()()
+	return backend.dataDirMoveStatus.State == DataDirMoveDone
+}
+
+// MoveDataDir moves the app data (config, headers, caches, notes, ...) to a new directory, e.g. on
+// an encrypted volume or a larger disk. Accounts are closed while the data is moved. Afterwards,
+// the whole backend is closed and the app has to be restarted to use the new location, see
+// RestartRequired(). Progress is reported in "datadir/move" events.
+//
+// The data is copied and verified before the new location is persisted and the old files are
+// removed. If anything fails before that, the copies are removed and the accounts are loaded again
+// from the old location.
+func (backend *Backend) MoveDataDir(target string) error {
+	if !config.CustomAppDirSupported() {
+		return errp.New("the data directory can't be changed on this platform")
+	}
+	if config.RunningProfile() != "" {
+		// The profiles are stored in the data directory of the default profile and are moved with it.
+		return errp.New("the data directory can only be moved in the default profile")
+	}
+	source := backend.arguments.MainDirectoryPath()
+	if err := validateDataDirTarget(source, target); err != nil {
+		return err
+	}
+	unlock := backend.dataDirMoveStatusLock.Lock()
+	if state := backend.dataDirMoveStatus.State; state == DataDirMoveMoving || state == DataDirMoveDone {
+		unlock()
+		return errp.New("the data directory is already being moved")
+	}
+	backend.dataDirMoveStatus = DataDirMoveStatus{State: DataDirMoveMoving}
+	unlock()
+
+	go func() {
+		defer backend.accountsAndKeystoreLock.Lock()()
+		// Close all databases so they are not modified while being copied.
+		backend.uninitAccounts(true)
+		func() {
+			defer backend.coinsLock.Lock()()
+			for _, coin := range backend.coins {
+				if err := coin.Close(); err != nil {
+					backend.log.WithError(err).Error("Could not close coin")
+				}
+			}
+			backend.coins = map[coinpkg.Code]coinpkg.Coin{}
+		}()
+
+		var status DataDirMoveStatus
+		err := moveDataDir(source, target, func(copied, total int64) {
+			status = DataDirMoveStatus{State: DataDirMoveMoving, CopiedBytes: copied, TotalBytes: total}
+			backend.setDataDirMoveStatus(status)
+		})
+		if err == nil {
+			if err = config.SetCustomAppDir(target); err != nil {
+				backend.removeDataDirFiles(target)
+			}
+		}
+		if err != nil {
+			backend.log.WithError(err).Error("Moving the data directory failed")
+			status.State = DataDirMoveFailed
+			status.Error = err.Error()
+			backend.setDataDirMoveStatus(status)
+			backend.initPersistedAccounts()
+			backend.emitAccountsStatusChanged()
+			return
+		}
+		backend.log.Infof("Moved the data directory to %s", target)
+		// Stop everything which could still write to the old location until the app is restarted.
+		if err := backend.closeLocked(); err != nil {
+			backend.log.WithError(err).Error("Could not close the backend after moving the data directory")
+		}
+		backend.removeDataDirFiles(source)
+		status.State = DataDirMoveDone
+		backend.setDataDirMoveStatus(status)
+		backend.emitAccountsStatusChanged()
+	}()
+	return nil
+}
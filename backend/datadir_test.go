@@ -0,0 +1,82 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func TestValidateDataDirTarget(t *testing.T) {
+	source := t.TempDir()
+	target := t.TempDir()
+
+	require.NoError(t, validateDataDirTarget(source, target))
+	require.NoError(t, validateDataDirTarget(source, filepath.Join(target, "new")))
+	require.Error(t, validateDataDirTarget(source, "relative/path"))
+	require.Error(t, validateDataDirTarget(source, source))
+	require.Error(t, validateDataDirTarget(source, filepath.Join(source, "sub")))
+	require.Error(t, validateDataDirTarget(filepath.Join(target, "sub"), target))
+
+	// The pointer file is allowed, as it remains in the default location.
+	writeTestFile(t, filepath.Join(target, "datadir.json"), "{}")
+	require.NoError(t, validateDataDirTarget(source, target))
+
+	writeTestFile(t, filepath.Join(target, "config.json"), "{}")
+	require.Error(t, validateDataDirTarget(source, target))
+}
+
+func TestMoveDataDir(t *testing.T) {
+	source := t.TempDir()
+	target := filepath.Join(t.TempDir(), "bitbox")
+	writeTestFile(t, filepath.Join(source, "config.json"), `{"backend":{}}`)
+	writeTestFile(t, filepath.Join(source, "cache", "headers-btc.bin"), "headers")
+	writeTestFile(t, filepath.Join(source, "datadir.json"), "{}")
+	writeTestFile(t, filepath.Join(source, "log.txt"), "log")
+
+	var copied, total int64
+	require.NoError(t, moveDataDir(source, target, func(c, tot int64) {
+		copied, total = c, tot
+	}))
+	require.Equal(t, int64(21), total)
+	require.Equal(t, total, copied)
+
+	content, err := os.ReadFile(filepath.Join(target, "cache", "headers-btc.bin"))
+	require.NoError(t, err)
+	require.Equal(t, "headers", string(content))
+	require.FileExists(t, filepath.Join(target, "config.json"))
+	require.NoFileExists(t, filepath.Join(target, "datadir.json"))
+	require.NoFileExists(t, filepath.Join(target, "log.txt"))
+	// The originals are only removed by the caller once the new location is persisted.
+	require.FileExists(t, filepath.Join(source, "config.json"))
+
+	// A failed copy is rolled back: config.json already exists in the target.
+	failTarget := t.TempDir()
+	writeTestFile(t, filepath.Join(failTarget, "config.json"), "other")
+	require.Error(t, moveDataDir(source, failTarget, func(int64, int64) {}))
+	require.NoFileExists(t, filepath.Join(failTarget, "cache", "headers-btc.bin"))
+	content, err = os.ReadFile(filepath.Join(failTarget, "config.json"))
+	require.NoError(t, err)
+	require.Equal(t, "other", string(content))
+}
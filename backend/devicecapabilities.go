@@ -0,0 +1,77 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"fmt"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bitbox02"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/device"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+)
+
+// DeviceCapabilities is pushed when a device connects, so that open screens can refresh the
+// availability of firmware dependent features.
+type DeviceCapabilities struct {
+	Capabilities []bitbox02.Capability `json:"capabilities"`
+	// Added are the capabilities the device did not have when it was last connected in this session,
+	// e.g. after a firmware upgrade. All capabilities are added when it connects for the first time.
+	Added []bitbox02.Capability `json:"added"`
+}
+
+// addedCapabilities returns the capabilities which are not in previous.
+func addedCapabilities(previous, current []bitbox02.Capability) []bitbox02.Capability {
+	had := map[bitbox02.Capability]bool{}
+	for _, capability := range previous {
+		had[capability] = true
+	}
+	added := []bitbox02.Capability{}
+	for _, capability := range current {
+		if !had[capability] {
+			added = append(added, capability)
+		}
+	}
+	return added
+}
+
+// notifyDeviceCapabilities pushes the capabilities of a registered device. The capabilities of
+// disconnected devices are remembered, as a firmware upgrade reconnects the device.
+func (backend *Backend) notifyDeviceCapabilities(theDevice device.Interface) {
+	bitbox02Device, ok := theDevice.(*bitbox02.Device)
+	if !ok {
+		return
+	}
+	capabilities := bitbox02Device.Capabilities()
+	unlock := backend.deviceCapabilitiesLock.Lock()
+	if backend.deviceCapabilities == nil {
+		backend.deviceCapabilities = map[string][]bitbox02.Capability{}
+	}
+	added := addedCapabilities(backend.deviceCapabilities[theDevice.Identifier()], capabilities)
+	backend.deviceCapabilities[theDevice.Identifier()] = capabilities
+	unlock()
+
+	if len(added) > 0 {
+		backend.log.WithField("added", added).Info("Device capabilities changed")
+	}
+	backend.Notify(observable.Event{
+		Subject: fmt.Sprintf("devices/bitbox02/%s/capabilities", theDevice.Identifier()),
+		Action:  action.Replace,
+		Object: DeviceCapabilities{
+			Capabilities: capabilities,
+			Added:        added,
+		},
+	})
+}
@@ -0,0 +1,31 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bitbox02"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddedCapabilities(t *testing.T) {
+	current := []bitbox02.Capability{bitbox02.CapabilityTaproot, bitbox02.CapabilityBIP85}
+	require.Equal(t, current, addedCapabilities(nil, current))
+	require.Equal(t,
+		[]bitbox02.Capability{bitbox02.CapabilityBIP85},
+		addedCapabilities([]bitbox02.Capability{bitbox02.CapabilityTaproot}, current))
+	require.Empty(t, addedCapabilities(current, []bitbox02.Capability{bitbox02.CapabilityTaproot}))
+}
@@ -132,6 +132,12 @@ func (keystore *keystore) VerifyAddress(
 		configuration.AbsoluteKeypath().Encode(), fmt.Sprintf("%s-%s", coin.Code(), string(configuration.ScriptType())))
 }
 
+// VerifyMultisigAddress implements keystore.Keystore.
+func (keystore *keystore) VerifyMultisigAddress(
+	coin.Coin, *signing.Configuration, signing.AbsoluteKeypath) error {
+	return errp.New("BitBox v1 does not support multisig accounts")
+}
+
 // CanVerifyExtendedPublicKey implements keystore.Keystore.
 func (keystore *keystore) CanVerifyExtendedPublicKey() bool {
 	return false
@@ -143,6 +149,11 @@ func (keystore *keystore) VerifyExtendedPublicKey(coin coin.Coin, configuration
 	return nil
 }
 
+// RegisterMultisig implements keystore.Keystore.
+func (keystore *keystore) RegisterMultisig(coin.Coin, *signing.Configuration, string) error {
+	return errp.New("BitBox v1 does not support multisig accounts")
+}
+
 // ExtendedPublicKey implements keystore.Keystore.
 func (keystore *keystore) ExtendedPublicKey(
 	coin coin.Coin, keyPath signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error) {
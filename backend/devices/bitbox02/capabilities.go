@@ -0,0 +1,83 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bitbox02
+
+import (
+	"github.com/BitBoxSwiss/bitbox02-api-go/util/semver"
+)
+
+// Capability is a feature which depends on the firmware version or the edition of the device.
+type Capability string
+
+const (
+	// CapabilityGotoStartupSettings means the device can reboot into the startup settings.
+	CapabilityGotoStartupSettings Capability = "gotoStartupSettings"
+	// CapabilityCreate12Words means a 12-word seed can be created in the initial setup.
+	CapabilityCreate12Words Capability = "create12Words"
+	// CapabilityTaproot means Taproot accounts are supported.
+	CapabilityTaproot Capability = "taproot"
+	// CapabilityBackupWithRecoveryWords means the backup can be created using the recovery words
+	// instead of the microSD card in the initial setup.
+	CapabilityBackupWithRecoveryWords Capability = "backupWithRecoveryWords"
+	// CapabilityEIP1559 means EIP-1559 Ethereum transactions can be signed.
+	CapabilityEIP1559 Capability = "eip1559"
+	// CapabilityBIP85 means BIP-85 child seeds can be derived.
+	CapabilityBIP85 Capability = "bip85"
+	// CapabilityLTC means Litecoin is supported, which depends on the edition.
+	CapabilityLTC Capability = "ltc"
+)
+
+// capabilityVersions are the firmware versions which added the capabilities, in the order they are
+// listed by Capabilities().
+var capabilityVersions = []struct {
+	capability Capability
+	version    *semver.SemVer
+}{
+	{CapabilityGotoStartupSettings, semver.NewSemVer(9, 6, 0)},
+	{CapabilityCreate12Words, semver.NewSemVer(9, 6, 0)},
+	{CapabilityTaproot, semver.NewSemVer(9, 10, 0)},
+	{CapabilityBackupWithRecoveryWords, semver.NewSemVer(9, 13, 0)},
+	{CapabilityEIP1559, semver.NewSemVer(9, 16, 0)},
+	{CapabilityBIP85, semver.NewSemVer(9, 18, 0)},
+}
+
+// capabilitiesOf returns the capabilities of a device with the given firmware version.
+func capabilitiesOf(version *semver.SemVer, supportsLTC bool) []Capability {
+	capabilities := []Capability{}
+	for _, entry := range capabilityVersions {
+		if version.AtLeast(entry.version) {
+			capabilities = append(capabilities, entry.capability)
+		}
+	}
+	if supportsLTC {
+		capabilities = append(capabilities, CapabilityLTC)
+	}
+	return capabilities
+}
+
+// Capabilities returns the capabilities of the connected device.
+func (device *Device) Capabilities() []Capability {
+	return capabilitiesOf(device.Version(), device.SupportsLTC())
+}
+
+// HasCapability returns true if the connected device has the capability.
+func (device *Device) HasCapability(capability Capability) bool {
+	for _, deviceCapability := range device.Capabilities() {
+		if deviceCapability == capability {
+			return true
+		}
+	}
+	return false
+}
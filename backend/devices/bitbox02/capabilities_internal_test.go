@@ -0,0 +1,39 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bitbox02
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox02-api-go/util/semver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilitiesOf(t *testing.T) {
+	require.Empty(t, capabilitiesOf(semver.NewSemVer(9, 5, 0), false))
+	require.Equal(t,
+		[]Capability{CapabilityGotoStartupSettings, CapabilityCreate12Words, CapabilityTaproot, CapabilityLTC},
+		capabilitiesOf(semver.NewSemVer(9, 12, 0), true))
+	require.Equal(t,
+		[]Capability{
+			CapabilityGotoStartupSettings,
+			CapabilityCreate12Words,
+			CapabilityTaproot,
+			CapabilityBackupWithRecoveryWords,
+			CapabilityEIP1559,
+			CapabilityBIP85,
+		},
+		capabilitiesOf(semver.NewSemVer(9, 18, 0), false))
+}
@@ -17,8 +17,10 @@
 package bitbox02
 
 import (
+	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	event "github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/device/event"
 	keystoreInterface "github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
@@ -36,13 +38,21 @@ import (
 // constant.
 const ProductName = "bitbox02"
 
+// operationWaitTimeout is how long an operation waits for the operations queued before it, e.g. if
+// a previous signing is neither confirmed nor aborted on the device. The operation then fails with
+// keystore.ErrDeviceBusy.
+const operationWaitTimeout = 5 * time.Minute
+
 // Device implements device.Device.
 type Device struct {
 	firmware.Device
 	deviceID string
 	mu       sync.RWMutex
 	onEvent  func(event.Event, interface{})
-	log      *logrus.Entry
+	// pairingPending is true while the pairing code is displayed on the device.
+	pairingPending bool
+	operations     *operationQueue
+	log            *logrus.Entry
 
 	observable.Implementation
 }
@@ -72,10 +82,17 @@ func NewDevice(
 		deviceID: deviceID,
 		log:      log,
 	}
+	device.operations = newOperationQueue(func() {
+		device.Notify(observable.Event{
+			Subject: fmt.Sprintf("devices/bitbox02/%s/operations", deviceID),
+			Action:  action.Reload,
+		})
+	})
 	device.Device.SetOnEvent(func(ev firmware.Event, meta interface{}) {
 		device.fireEvent(event.Event(ev))
 		switch ev {
 		case firmware.EventStatusChanged:
+			device.updatePairingInteraction()
 			switch device.Device.Status() {
 			case firmware.StatusInitialized:
 				device.fireEvent(event.EventKeystoreAvailable)
@@ -121,12 +138,59 @@ func (device *Device) Keystore() keystoreInterface.Keystore {
 }
 
 func (device *Device) fireEvent(event event.Event) {
+	device.fireEventWithData(event, nil)
+}
+
+func (device *Device) fireEventWithData(event event.Event, data interface{}) {
 	device.mu.RLock()
 	f := device.onEvent
 	device.mu.RUnlock()
 	if f != nil {
 		device.log.Info(fmt.Sprintf("fire event: %s", event))
-		f(event, nil)
+		f(event, data)
+	}
+}
+
+// startOperation queues an operation requiring user interaction and blocks until the device is
+// ready to process it, then fires EventInteractionRequired. The returned function fires
+// EventInteractionDone and must be called when the device is not waiting for the user anymore.
+// keystore.ErrDeviceBusy is returned if the operation conflicts with the queued operations, or if
+// it is not its turn within operationWaitTimeout.
+func (device *Device) startOperation(interaction event.Interaction) (func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), operationWaitTimeout)
+	defer cancel()
+	dequeue, err := device.operations.enqueue(ctx, interaction)
+	if err != nil {
+		return nil, err
+	}
+	device.fireEventWithData(event.EventInteractionRequired, interaction)
+	return func() {
+		device.fireEventWithData(event.EventInteractionDone, interaction)
+		dequeue()
+	}, nil
+}
+
+// Operations returns the operations requiring user interaction which are running or waiting to be
+// processed by the device. The first one is the running one.
+func (device *Device) Operations() []Operation {
+	return device.operations.list()
+}
+
+// updatePairingInteraction fires the interaction events for the pairing confirmation, which is
+// pending while the device is unpaired.
+func (device *Device) updatePairingInteraction() {
+	unpaired := device.Device.Status() == firmware.StatusUnpaired
+	device.mu.Lock()
+	changed := unpaired != device.pairingPending
+	device.pairingPending = unpaired
+	device.mu.Unlock()
+	if !changed {
+		return
+	}
+	if unpaired {
+		device.fireEventWithData(event.EventInteractionRequired, event.InteractionPairing)
+	} else {
+		device.fireEventWithData(event.EventInteractionDone, event.InteractionPairing)
 	}
 }
 
@@ -21,6 +21,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bitbox02"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bitbox02bootloader"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	bitbox02common "github.com/BitBoxSwiss/bitbox02-api-go/api/common"
@@ -56,6 +57,9 @@ type BitBox02 interface {
 	GotoStartupSettings() error
 	RootFingerprint() ([]byte, error)
 	BIP85AppBip39() error
+	Operations() []bitbox02.Operation
+	Capabilities() []bitbox02.Capability
+	HasCapability(bitbox02.Capability) bool
 }
 
 // Handlers provides a web API to the Bitbox.
@@ -94,6 +98,8 @@ func NewHandlers(
 	handleFunc("/goto-startup-settings", handlers.postGotoStartupSettings).Methods("POST")
 	handleFunc("/root-fingerprint", handlers.getRootFingerprint).Methods("GET")
 	handleFunc("/invoke-bip85", handlers.postInvokeBIP85Handler).Methods("POST")
+	handleFunc("/operations", handlers.getOperations).Methods("GET")
+	handleFunc("/capabilities", handlers.getCapabilities).Methods("GET")
 	return handlers
 }
 
@@ -322,10 +328,10 @@ func (handlers *Handlers) getVersionHandler(_ *http.Request) interface{} {
 		CurrentVersion:             currentVersion.String(),
 		NewVersion:                 newVersion.String(),
 		CanUpgrade:                 newVersion.AtLeast(currentVersion) && currentVersion.String() != newVersion.String(),
-		CanGotoStartupSettings:     currentVersion.AtLeast(semver.NewSemVer(9, 6, 0)),
-		CanBackupWithRecoveryWords: currentVersion.AtLeast(semver.NewSemVer(9, 13, 0)),
-		CanCreate12Words:           currentVersion.AtLeast(semver.NewSemVer(9, 6, 0)),
-		CanBIP85:                   currentVersion.AtLeast(semver.NewSemVer(9, 18, 0)),
+		CanGotoStartupSettings:     handlers.device.HasCapability(bitbox02.CapabilityGotoStartupSettings),
+		CanBackupWithRecoveryWords: handlers.device.HasCapability(bitbox02.CapabilityBackupWithRecoveryWords),
+		CanCreate12Words:           handlers.device.HasCapability(bitbox02.CapabilityCreate12Words),
+		CanBIP85:                   handlers.device.HasCapability(bitbox02.CapabilityBIP85),
 	}
 }
 
@@ -380,6 +386,17 @@ func (handlers *Handlers) getRootFingerprint(_ *http.Request) interface{} {
 	}
 }
 
+// getCapabilities returns the features supported by the firmware and edition of the device.
+func (handlers *Handlers) getCapabilities(_ *http.Request) interface{} {
+	return handlers.device.Capabilities()
+}
+
+// getOperations returns the operations requiring user interaction which are running or queued on
+// the device.
+func (handlers *Handlers) getOperations(_ *http.Request) interface{} {
+	return handlers.device.Operations()
+}
+
 func (handlers *Handlers) postInvokeBIP85Handler(_ *http.Request) interface{} {
 	err := handlers.device.BIP85AppBip39()
 	if err != nil {
@@ -27,6 +27,8 @@ import (
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/ltc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/device"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/device/event"
 	keystorePkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
@@ -105,10 +107,9 @@ func (keystore *keystore) SupportsAccount(coin coinpkg.Coin, meta interface{}) b
 	case *btc.Coin:
 		scriptType := meta.(signing.ScriptType)
 		if scriptType == signing.ScriptTypeP2TR {
-			// Taproot available since v9.10.0.
 			switch coin.Code() {
 			case coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeRBTC:
-				return keystore.device.Version().AtLeast(semver.NewSemVer(9, 10, 0))
+				return keystore.device.HasCapability(CapabilityTaproot)
 			default:
 				return false
 			}
@@ -152,6 +153,11 @@ func (keystore *keystore) VerifyAddress(
 	if !canVerifyAddress {
 		panic("CanVerifyAddress must be true")
 	}
+	done, err := keystore.device.startOperation(event.InteractionVerifyAddress)
+	if err != nil {
+		return err
+	}
+	defer done()
 	switch specificCoin := coin.(type) {
 	case *btc.Coin:
 		msgScriptType, ok := btcMsgScriptTypeMap[configuration.ScriptType()]
@@ -194,6 +200,102 @@ func (keystore *keystore) VerifyAddress(
 	return nil
 }
 
+// btcScriptConfig returns the script config of a Bitcoin account configuration.
+func btcScriptConfig(accountConfiguration *signing.Configuration) (*messages.BTCScriptConfig, error) {
+	if multisig := accountConfiguration.BitcoinMultisig; multisig != nil {
+		xpubs := make([]string, len(multisig.KeyInfos))
+		for i, keyInfo := range multisig.KeyInfos {
+			xpubs[i] = keyInfo.ExtendedPublicKey.String()
+		}
+		return firmware.NewBTCScriptConfigMultisig(
+			uint32(multisig.Threshold), xpubs, uint32(multisig.OurKeyIndex))
+	}
+	msgScriptType, ok := btcMsgScriptTypeMap[accountConfiguration.ScriptType()]
+	if !ok {
+		return nil, errp.Newf("Unsupported script type %s", accountConfiguration.ScriptType())
+	}
+	return firmware.NewBTCScriptConfigSimple(msgScriptType), nil
+}
+
+// registerBTCScriptConfig registers the multisig script config on the device, unless it is
+// registered already. If the name is empty, the user enters it on the device. Must be called in an
+// operation started with startOperation().
+func (keystore *keystore) registerBTCScriptConfig(
+	msgCoin messages.BTCCoin,
+	scriptConfig *messages.BTCScriptConfigWithKeypath,
+	name string,
+) error {
+	registered, err := keystore.device.BTCIsScriptConfigRegistered(
+		msgCoin, scriptConfig.ScriptConfig, scriptConfig.Keypath)
+	if err != nil {
+		return err
+	}
+	if registered {
+		return nil
+	}
+	return keystore.device.BTCRegisterScriptConfig(
+		msgCoin, scriptConfig.ScriptConfig, scriptConfig.Keypath, name)
+}
+
+// RegisterMultisig implements keystore.Keystore.
+func (keystore *keystore) RegisterMultisig(
+	coin coinpkg.Coin, configuration *signing.Configuration, name string) error {
+	msgCoin, ok := btcMsgCoinMap[coin.Code()]
+	if !ok || configuration.BitcoinMultisig == nil {
+		return errp.New("unsupported multisig configuration")
+	}
+	scriptConfig, err := btcScriptConfig(configuration)
+	if err != nil {
+		return err
+	}
+	done, err := keystore.device.startOperation(event.InteractionRegisterMultisig)
+	if err != nil {
+		return err
+	}
+	defer done()
+	err = keystore.registerBTCScriptConfig(msgCoin, &messages.BTCScriptConfigWithKeypath{
+		ScriptConfig: scriptConfig,
+		Keypath:      configuration.AbsoluteKeypath().ToUInt32(),
+	}, name)
+	if firmware.IsErrorAbort(err) {
+		return errp.WithStack(errp.ErrUserAbort)
+	}
+	return err
+}
+
+// VerifyMultisigAddress implements keystore.Keystore.
+func (keystore *keystore) VerifyMultisigAddress(
+	coin coinpkg.Coin,
+	accountConfiguration *signing.Configuration,
+	keypath signing.AbsoluteKeypath,
+) error {
+	msgCoin, ok := btcMsgCoinMap[coin.Code()]
+	if !ok || accountConfiguration.BitcoinMultisig == nil {
+		return errp.New("unsupported multisig configuration")
+	}
+	scriptConfig, err := btcScriptConfig(accountConfiguration)
+	if err != nil {
+		return err
+	}
+	done, err := keystore.device.startOperation(event.InteractionVerifyAddress)
+	if err != nil {
+		return err
+	}
+	defer done()
+	err = keystore.registerBTCScriptConfig(msgCoin, &messages.BTCScriptConfigWithKeypath{
+		ScriptConfig: scriptConfig,
+		Keypath:      accountConfiguration.AbsoluteKeypath().ToUInt32(),
+	}, "")
+	if err == nil {
+		_, err = keystore.device.BTCAddress(msgCoin, keypath.ToUInt32(), scriptConfig, true)
+	}
+	if firmware.IsErrorAbort(err) {
+		// No special action on user abort.
+		return nil
+	}
+	return err
+}
+
 // CanVerifyExtendedPublicKey implements keystore.Keystore.
 func (keystore *keystore) CanVerifyExtendedPublicKey() bool {
 	return true
@@ -226,7 +328,12 @@ func (keystore *keystore) VerifyExtendedPublicKey(
 		default:
 			msgXPubType = messages.BTCPubRequest_XPUB
 		}
-		_, err := keystore.device.BTCXPub(
+		done, err := keystore.device.startOperation(event.InteractionVerifyExtendedPublicKey)
+		if err != nil {
+			return err
+		}
+		defer done()
+		_, err = keystore.device.BTCXPub(
 			msgCoin, configuration.AbsoluteKeypath().ToUInt32(), msgXPubType, true)
 		if firmware.IsErrorAbort(err) {
 			// No special action taken on user abort.
@@ -290,6 +397,19 @@ func (keystore *keystore) ExtendedPublicKey(
 	}
 }
 
+// signingError converts an error returned by the device when signing a transaction. A failed
+// communication with the device, which happens when it is unplugged, is reported as
+// keystorePkg.ErrKeystoreDisconnected. Other errors are returned as is.
+func signingError(err error) error {
+	if firmware.IsErrorAbort(err) {
+		return errp.WithStack(keystorePkg.ErrSigningAborted)
+	}
+	if errp.Cause(err) == device.ErrTransport {
+		return errp.WithMessage(keystorePkg.ErrKeystoreDisconnected, err.Error())
+	}
+	return err
+}
+
 func (keystore *keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransaction) error {
 	tx := btcProposedTx.TXProposal.Transaction
 
@@ -297,11 +417,20 @@ func (keystore *keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransact
 	// script type (e.g. p2wpkh, p2tr..) and the account keypath
 	scriptConfigs := []*messages.BTCScriptConfigWithKeypath{}
 	// addScriptConfig returns the index of the scriptConfig in scriptConfigs, adding it if it isn't
-	// present. Must be a Simple configuration.
+	// present. Simple configurations are matched by their script type. The coins spent belong to
+	// one account, so there is at most one multisig configuration.
 	addScriptConfig := func(scriptConfig *messages.BTCScriptConfigWithKeypath) int {
 		for i, sc := range scriptConfigs {
-			if sc.ScriptConfig.Config.(*messages.BTCScriptConfig_SimpleType_).SimpleType == scriptConfig.ScriptConfig.Config.(*messages.BTCScriptConfig_SimpleType_).SimpleType {
-				return i
+			switch config := scriptConfig.ScriptConfig.Config.(type) {
+			case *messages.BTCScriptConfig_SimpleType_:
+				existing, ok := sc.ScriptConfig.Config.(*messages.BTCScriptConfig_SimpleType_)
+				if ok && existing.SimpleType == config.SimpleType {
+					return i
+				}
+			case *messages.BTCScriptConfig_Multisig_:
+				if _, ok := sc.ScriptConfig.Config.(*messages.BTCScriptConfig_Multisig_); ok {
+					return i
+				}
 			}
 		}
 		scriptConfigs = append(scriptConfigs, scriptConfig)
@@ -326,12 +455,12 @@ func (keystore *keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransact
 		inputAddress := btcProposedTx.GetAccountAddress(prevOut.ScriptHashHex())
 
 		accountConfiguration := inputAddress.AccountConfiguration
-		msgScriptType, ok := btcMsgScriptTypeMap[accountConfiguration.ScriptType()]
-		if !ok {
-			return errp.Newf("Unsupported script type %s", accountConfiguration.ScriptType())
+		scriptConfig, err := btcScriptConfig(accountConfiguration)
+		if err != nil {
+			return err
 		}
 		scriptConfigIndex := addScriptConfig(&messages.BTCScriptConfigWithKeypath{
-			ScriptConfig: firmware.NewBTCScriptConfigSimple(msgScriptType),
+			ScriptConfig: scriptConfig,
 			Keypath:      accountConfiguration.AbsoluteKeypath().ToUInt32(),
 		})
 
@@ -394,12 +523,12 @@ func (keystore *keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransact
 		if isOurs {
 			keypath = outputAccountAddress.Configuration.AbsoluteKeypath().ToUInt32()
 			accountConfiguration := outputAccountAddress.AccountConfiguration
-			msgScriptType, ok := btcMsgScriptTypeMap[accountConfiguration.ScriptType()]
-			if !ok {
-				return errp.Newf("Unsupported script type %s", accountConfiguration.ScriptType())
+			scriptConfig, err := btcScriptConfig(accountConfiguration)
+			if err != nil {
+				return err
 			}
 			scriptConfigIndex = addScriptConfig(&messages.BTCScriptConfigWithKeypath{
-				ScriptConfig: firmware.NewBTCScriptConfigSimple(msgScriptType),
+				ScriptConfig: scriptConfig,
 				Keypath:      accountConfiguration.AbsoluteKeypath().ToUInt32(),
 			})
 
@@ -447,6 +576,17 @@ func (keystore *keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransact
 		}
 	}
 
+	// Multisig accounts can only be signed for once they are registered, e.g. when the account was
+	// created with another device.
+	for _, scriptConfig := range scriptConfigs {
+		if _, ok := scriptConfig.ScriptConfig.Config.(*messages.BTCScriptConfig_Multisig_); !ok {
+			continue
+		}
+		if err := keystore.registerBTCScriptConfig(msgCoin, scriptConfig, ""); err != nil {
+			return signingError(err)
+		}
+	}
+
 	// Handle displaying formatting in btc or sats.
 	formatUnit := messages.BTCSignInitRequest_DEFAULT
 	if btcProposedTx.FormatUnit == coinpkg.BtcUnitSats {
@@ -463,11 +603,8 @@ func (keystore *keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransact
 		},
 		formatUnit,
 	)
-	if firmware.IsErrorAbort(err) {
-		return errp.WithStack(keystorePkg.ErrSigningAborted)
-	}
 	if err != nil {
-		return err
+		return signingError(err)
 	}
 	for index, signature := range signatures {
 		btcProposedTx.Signatures[index] = &types.Signature{
@@ -514,11 +651,8 @@ func (keystore *keystore) signETHTransaction(txProposal *eth.TxProposal) error {
 	default:
 		return errp.New("unsupported transaction type")
 	}
-	if firmware.IsErrorAbort(err) {
-		return errp.WithStack(keystorePkg.ErrSigningAborted)
-	}
 	if err != nil {
-		return err
+		return signingError(err)
 	}
 	signedTx, err := txProposal.Tx.WithSignature(txProposal.Signer, signature)
 	if err != nil {
@@ -530,6 +664,11 @@ func (keystore *keystore) signETHTransaction(txProposal *eth.TxProposal) error {
 
 // SignTransaction implements keystore.Keystore.
 func (keystore *keystore) SignTransaction(proposedTx interface{}) error {
+	done, err := keystore.device.startOperation(event.InteractionSign)
+	if err != nil {
+		return err
+	}
+	defer done()
 	switch specificProposedTx := proposedTx.(type) {
 	case *btc.ProposedTransaction:
 		return keystore.signBTCTransaction(specificProposedTx)
@@ -551,6 +690,11 @@ func (keystore *keystore) SignBTCMessage(message []byte, keypath signing.Absolut
 	if !ok {
 		return nil, errp.Newf("scriptType not supported: %s", scriptType)
 	}
+	done, err := keystore.device.startOperation(event.InteractionSign)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 	_, _, electrum65, err := keystore.device.BTCSignMessage(
 		messages.BTCCoin_BTC,
 		&messages.BTCScriptConfigWithKeypath{
@@ -564,6 +708,11 @@ func (keystore *keystore) SignBTCMessage(message []byte, keypath signing.Absolut
 
 // SignETHMessage implements keystore.Keystore.
 func (keystore *keystore) SignETHMessage(message []byte, keypath signing.AbsoluteKeypath) ([]byte, error) {
+	done, err := keystore.device.startOperation(event.InteractionSign)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 	signature, err := keystore.device.ETHSignMessage(params.MainnetChainConfig.ChainID.Uint64(), keypath.ToUInt32(), message)
 	if firmware.IsErrorAbort(err) {
 		return nil, errp.WithStack(keystorePkg.ErrSigningAborted)
@@ -576,6 +725,11 @@ func (keystore *keystore) SignETHMessage(message []byte, keypath signing.Absolut
 
 // SignETHTypedData implements keystore.Keystore.
 func (keystore *keystore) SignETHTypedMessage(chainId uint64, data []byte, keypath signing.AbsoluteKeypath) ([]byte, error) {
+	done, err := keystore.device.startOperation(event.InteractionSign)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 	signature, err := keystore.device.ETHSignTypedMessage(chainId, keypath.ToUInt32(), data)
 	if firmware.IsErrorAbort(err) {
 		return nil, errp.WithStack(keystorePkg.ErrSigningAborted)
@@ -588,6 +742,11 @@ func (keystore *keystore) SignETHTypedMessage(chainId uint64, data []byte, keypa
 
 // SignETHWalletConnectTransaction implements keystore.Keystore.
 func (keystore *keystore) SignETHWalletConnectTransaction(chainId uint64, tx *ethTypes.Transaction, keypath signing.AbsoluteKeypath) ([]byte, error) {
+	done, err := keystore.device.startOperation(event.InteractionSign)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 	signature, err := keystore.device.ETHSign(
 		chainId,
 		keypath.ToUInt32(),
@@ -609,5 +768,5 @@ func (keystore *keystore) SignETHWalletConnectTransaction(chainId uint64, tx *et
 
 // SupportsEIP1559 implements keystore.Keystore.
 func (keystore *keystore) SupportsEIP1559() bool {
-	return keystore.device.Version().AtLeast(semver.NewSemVer(9, 16, 0))
+	return keystore.device.HasCapability(CapabilityEIP1559)
 }
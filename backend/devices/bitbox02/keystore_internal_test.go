@@ -0,0 +1,45 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bitbox02
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/device"
+	keystorePkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox02-api-go/api/firmware"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningError(t *testing.T) {
+	require.Equal(t,
+		keystorePkg.ErrSigningAborted,
+		errp.Cause(signingError(firmware.NewError(firmware.ErrUserAbort, "aborted"))))
+
+	firmwareErr := firmware.NewError(firmware.ErrInvalidInput, "invalid input")
+	require.Equal(t, firmwareErr, signingError(firmwareErr))
+	require.Equal(t, firmware.UnsupportedError("9.10.0"), signingError(firmware.UnsupportedError("9.10.0")))
+
+	// Only failing communication means that the device was disconnected.
+	err := signingError(errp.WithStack(errp.WithMessage(device.ErrTransport, "read failed")))
+	require.Equal(t, keystorePkg.ErrKeystoreDisconnected, errp.Cause(err))
+	require.Contains(t, err.Error(), "read failed")
+
+	validationErr := errp.New("invalid keypath")
+	require.Equal(t, validationErr, signingError(validationErr))
+	require.NotEqual(t, keystorePkg.ErrKeystoreDisconnected, errp.Cause(signingError(errors.New("unknown"))))
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bitbox02
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	event "github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/device/event"
+	keystorePkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// Operation is an operation which requires the user to interact with the device.
+type Operation struct {
+	ID          uint64            `json:"id"`
+	Interaction event.Interaction `json:"interaction"`
+	// Running is true if the device is currently processing this operation. Otherwise, the
+	// operation is waiting for the previous operations to finish.
+	Running  bool      `json:"running"`
+	QueuedAt time.Time `json:"queuedAt"`
+
+	// ready is closed when it is the operation's turn.
+	ready chan struct{}
+}
+
+// operationQueue serializes the operations requiring user interaction, as the device can only
+// process one at a time.
+//
+// Signing operations are queued. Address and xpub verifications are rejected with
+// keystore.ErrDeviceBusy if any other operation is running or queued, as they are triggered by the
+// user on demand and should be shown immediately or not at all.
+type operationQueue struct {
+	mu         sync.Mutex
+	nextID     uint64
+	operations []*Operation
+	onChange   func()
+}
+
+func newOperationQueue(onChange func()) *operationQueue {
+	return &operationQueue{onChange: onChange}
+}
+
+// enqueue adds an operation to the queue and blocks until it is the operation's turn. The returned
+// function must be called when the operation is finished. If ctx is done before it is the
+// operation's turn, the operation is removed from the queue and keystore.ErrDeviceBusy is
+// returned, so that an abandoned operation does not hold up the operations queued after it.
+func (queue *operationQueue) enqueue(ctx context.Context, interaction event.Interaction) (func(), error) {
+	queue.mu.Lock()
+	if len(queue.operations) > 0 && interaction != event.InteractionSign {
+		queue.mu.Unlock()
+		return nil, errp.WithStack(keystorePkg.ErrDeviceBusy)
+	}
+	queue.nextID++
+	operation := &Operation{
+		ID:          queue.nextID,
+		Interaction: interaction,
+		QueuedAt:    time.Now(),
+		ready:       make(chan struct{}),
+	}
+	queue.operations = append(queue.operations, operation)
+	if len(queue.operations) == 1 {
+		queue.start(operation)
+	}
+	queue.changed()
+	queue.mu.Unlock()
+
+	select {
+	case <-operation.ready:
+	case <-ctx.Done():
+		queue.mu.Lock()
+		select {
+		case <-operation.ready:
+			// It became the operation's turn in the meantime.
+			queue.mu.Unlock()
+		default:
+			queue.remove(operation)
+			queue.changed()
+			queue.mu.Unlock()
+			return nil, errp.Wrap(keystorePkg.ErrDeviceBusy, ctx.Err().Error())
+		}
+	}
+
+	return func() {
+		queue.mu.Lock()
+		defer queue.mu.Unlock()
+		queue.remove(operation)
+		if len(queue.operations) > 0 {
+			queue.start(queue.operations[0])
+		}
+		queue.changed()
+	}, nil
+}
+
+// start marks the operation as running and unblocks its enqueue() call. queue.mu must be held.
+func (queue *operationQueue) start(operation *Operation) {
+	operation.Running = true
+	close(operation.ready)
+}
+
+// remove removes the operation from the queue. queue.mu must be held.
+func (queue *operationQueue) remove(operation *Operation) {
+	for i, op := range queue.operations {
+		if op == operation {
+			queue.operations = append(queue.operations[:i:i], queue.operations[i+1:]...)
+			return
+		}
+	}
+}
+
+// changed is called with queue.mu held whenever the queue is modified.
+func (queue *operationQueue) changed() {
+	if queue.onChange != nil {
+		go queue.onChange()
+	}
+}
+
+// list returns a copy of the queued operations, the first one being the running one.
+func (queue *operationQueue) list() []Operation {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	result := make([]Operation, len(queue.operations))
+	for i, operation := range queue.operations {
+		result[i] = *operation
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bitbox02
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	event "github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/device/event"
+	keystorePkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationQueue(t *testing.T) {
+	queue := newOperationQueue(nil)
+	require.Empty(t, queue.list())
+
+	done1, err := queue.enqueue(context.Background(), event.InteractionSign)
+	require.NoError(t, err)
+	operations := queue.list()
+	require.Len(t, operations, 1)
+	require.True(t, operations[0].Running)
+
+	// Verifications are rejected while the device is busy.
+	_, err = queue.enqueue(context.Background(), event.InteractionVerifyAddress)
+	require.Equal(t, keystorePkg.ErrDeviceBusy, errp.Cause(err))
+
+	// Signing requests are queued.
+	started := make(chan func())
+	go func() {
+		done2, err := queue.enqueue(context.Background(), event.InteractionSign)
+		if err != nil {
+			panic(err)
+		}
+		started <- done2
+	}()
+	require.Eventually(t, func() bool { return len(queue.list()) == 2 }, time.Second, time.Millisecond)
+	operations = queue.list()
+	require.True(t, operations[0].Running)
+	require.False(t, operations[1].Running)
+
+	done1()
+	done2 := <-started
+	operations = queue.list()
+	require.Len(t, operations, 1)
+	require.True(t, operations[0].Running)
+	done2()
+
+	require.Empty(t, queue.list())
+	done3, err := queue.enqueue(context.Background(), event.InteractionVerifyAddress)
+	require.NoError(t, err)
+	done3()
+}
+
+func TestOperationQueueCancel(t *testing.T) {
+	queue := newOperationQueue(nil)
+	done1, err := queue.enqueue(context.Background(), event.InteractionSign)
+	require.NoError(t, err)
+
+	// A queued signing whose context is done gives up and leaves the queue.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = queue.enqueue(ctx, event.InteractionSign)
+	require.Equal(t, keystorePkg.ErrDeviceBusy, errp.Cause(err))
+	require.Len(t, queue.list(), 1)
+
+	// The signings queued after it are not held up.
+	started := make(chan func())
+	go func() {
+		done3, err := queue.enqueue(context.Background(), event.InteractionSign)
+		if err != nil {
+			panic(err)
+		}
+		started <- done3
+	}()
+	require.Eventually(t, func() bool { return len(queue.list()) == 2 }, time.Second, time.Millisecond)
+	done1()
+	done3 := <-started
+	operations := queue.list()
+	require.Len(t, operations, 1)
+	require.True(t, operations[0].Running)
+	done3()
+	require.Empty(t, queue.list())
+}
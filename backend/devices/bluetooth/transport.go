@@ -0,0 +1,94 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bluetooth provides the transport to communicate with devices over Bluetooth LE.
+package bluetooth
+
+import (
+	"io"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// reportSize is the size of the HID reports the device communication writes and expects to read,
+// see the u2fhid package of the bitbox02-api-go library.
+const reportSize = 64
+
+// transport carries HID reports over a Bluetooth LE GATT connection. See NewTransport().
+type transport struct {
+	conn io.ReadWriteCloser
+	mtu  int
+	// pending are the received bytes which are not yet returned by Read().
+	pending []byte
+}
+
+// NewTransport makes a connection to a device over Bluetooth LE usable like a HID device, so that
+// the same device communication (including the pairing and its pairing code verification) works
+// over USB and Bluetooth LE.
+//
+// Each conn.Read() returns the payload of one GATT notification and each conn.Write() writes the
+// payload of one GATT write. mtu is the maximum payload size of the connection, which is usually
+// smaller than a HID report. Writes are split into chunks of at most mtu bytes, and received
+// notifications are reassembled into HID reports.
+func NewTransport(conn io.ReadWriteCloser, mtu int) (io.ReadWriteCloser, error) {
+	if mtu <= 0 {
+		return nil, errp.Newf("Invalid Bluetooth MTU %d", mtu)
+	}
+	return &transport{conn: conn, mtu: mtu}, nil
+}
+
+// Write implements io.Writer.
+func (t *transport) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		end := written + t.mtu
+		if end > len(p) {
+			end = len(p)
+		}
+		n, err := t.conn.Write(p[written:end])
+		written += n
+		if err != nil {
+			return written, errp.WithStack(err)
+		}
+		if written != end {
+			return written, errp.WithStack(io.ErrShortWrite)
+		}
+	}
+	return written, nil
+}
+
+// Read implements io.Reader. Like reading from a HID device, each call returns one report. If p is
+// smaller than a report, the rest of the report is discarded.
+func (t *transport) Read(p []byte) (int, error) {
+	bufferSize := reportSize
+	if t.mtu > bufferSize {
+		bufferSize = t.mtu
+	}
+	buffer := make([]byte, bufferSize)
+	for len(t.pending) < reportSize {
+		n, err := t.conn.Read(buffer)
+		t.pending = append(t.pending, buffer[:n]...)
+		if err != nil {
+			return 0, errp.WithStack(err)
+		}
+	}
+	n := copy(p, t.pending[:reportSize])
+	t.pending = t.pending[reportSize:]
+	return n, nil
+}
+
+// Close implements io.Closer.
+func (t *transport) Close() error {
+	return t.conn.Close()
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bluetooth
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox02-api-go/communication/u2fhid"
+	"github.com/stretchr/testify/require"
+)
+
+const cmd = 0x80 + 0x40 + 0x01
+
+// gattConn fakes a GATT connection, recording the writes and returning the queued notifications.
+type gattConn struct {
+	writes        [][]byte
+	notifications [][]byte
+	closed        bool
+}
+
+func (c *gattConn) Write(p []byte) (int, error) {
+	c.writes = append(c.writes, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func (c *gattConn) Read(p []byte) (int, error) {
+	if len(c.notifications) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, c.notifications[0])
+	c.notifications = c.notifications[1:]
+	return n, nil
+}
+
+func (c *gattConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+// hidBuffer records the written HID reports.
+type hidBuffer struct {
+	bytes.Buffer
+}
+
+func (b *hidBuffer) Close() error { return nil }
+
+func TestTransport(t *testing.T) {
+	const mtu = 20
+	msg := strings.Repeat("pairing-code-handshake", 10)
+
+	// The reports a device sends for the message.
+	var reports hidBuffer
+	require.NoError(t, u2fhid.NewCommunication(&reports, cmd).SendFrame(msg))
+
+	conn := &gattConn{}
+	for chunk := reports.Bytes(); len(chunk) > 0; {
+		size := mtu
+		if len(chunk) < size {
+			size = len(chunk)
+		}
+		conn.notifications = append(conn.notifications, chunk[:size])
+		chunk = chunk[size:]
+	}
+	transport, err := NewTransport(conn, mtu)
+	require.NoError(t, err)
+	communication := u2fhid.NewCommunication(transport, cmd)
+
+	require.NoError(t, communication.SendFrame(msg))
+	written := []byte{}
+	for _, write := range conn.writes {
+		require.LessOrEqual(t, len(write), mtu)
+		written = append(written, write...)
+	}
+	require.Equal(t, reports.Bytes(), written)
+
+	received, err := communication.ReadFrame()
+	require.NoError(t, err)
+	require.Equal(t, msg, string(received))
+	require.Empty(t, conn.notifications)
+
+	// Connection lost in the middle of a report.
+	conn.notifications = [][]byte{make([]byte, mtu)}
+	_, err = transport.Read(make([]byte, reportSize))
+	require.Error(t, err)
+
+	require.NoError(t, transport.Close())
+	require.True(t, conn.closed)
+
+	_, err = NewTransport(conn, 0)
+	require.Error(t, err)
+}
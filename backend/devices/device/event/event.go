@@ -25,4 +25,27 @@ const (
 	// reset. NOTE: It is not fired when the keystore is replaced. In that case, only
 	// EventKeystoreAvailable is fired.
 	EventKeystoreGone Event = "keystoreGone"
+	// EventInteractionRequired is fired when the device is waiting for the user to confirm or
+	// abort an operation on the device. The event data is the Interaction.
+	EventInteractionRequired Event = "interactionRequired"
+	// EventInteractionDone is fired when the device is not waiting for the user anymore. The event
+	// data is the Interaction.
+	EventInteractionDone Event = "interactionDone"
+)
+
+// Interaction is the kind of operation for which the device is waiting for the user.
+type Interaction string
+
+const (
+	// InteractionSign is used when a transaction or message is signed.
+	InteractionSign Interaction = "sign"
+	// InteractionVerifyAddress is used when an address is displayed for verification.
+	InteractionVerifyAddress Interaction = "verifyAddress"
+	// InteractionVerifyExtendedPublicKey is used when an xpub is displayed for verification.
+	InteractionVerifyExtendedPublicKey Interaction = "verifyExtendedPublicKey"
+	// InteractionRegisterMultisig is used when the cosigners of a multisig account are displayed
+	// for confirmation before the account is registered on the device.
+	InteractionRegisterMultisig Interaction = "registerMultisig"
+	// InteractionPairing is used when the pairing code is displayed for confirmation.
+	InteractionPairing Interaction = "pairing"
 )
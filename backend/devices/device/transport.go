@@ -0,0 +1,55 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package device
+
+import (
+	"errors"
+	"io"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// ErrTransport is the cause of the errors of reading from or writing to the connection of a device,
+// see WrapTransportErrors(). It means that the communication failed, e.g. because the device was
+// unplugged, as opposed to an error reported by the device.
+var ErrTransport = errors.New("device communication failed")
+
+type transportErrors struct {
+	io.ReadWriteCloser
+}
+
+// WrapTransportErrors returns the connection, with ErrTransport as the cause of all read and write
+// errors.
+func WrapTransportErrors(conn io.ReadWriteCloser) io.ReadWriteCloser {
+	return transportErrors{conn}
+}
+
+// Read implements io.Reader.
+func (conn transportErrors) Read(p []byte) (int, error) {
+	n, err := conn.ReadWriteCloser.Read(p)
+	if err != nil {
+		return n, errp.WithMessage(ErrTransport, err.Error())
+	}
+	return n, nil
+}
+
+// Write implements io.Writer.
+func (conn transportErrors) Write(p []byte) (int, error) {
+	n, err := conn.ReadWriteCloser.Write(p)
+	if err != nil {
+		return n, errp.WithMessage(ErrTransport, err.Error())
+	}
+	return n, nil
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bitbox"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bitbox02"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bitbox02bootloader"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bluetooth"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/device"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
@@ -42,6 +43,10 @@ const (
 
 	bitboxCMD             = 0x80 + 0x40 + 0x01
 	bitbox02BootloaderCMD = 0x80 + 0x40 + 0x03
+
+	// registerRetryInterval is how long to wait before registering a device again whose
+	// registration failed, e.g. because its HID handle is stuck.
+	registerRetryInterval = 10 * time.Second
 )
 
 // DeviceInfo contains the usb descriptor info and a way to open the device for reading and writing.
@@ -56,6 +61,33 @@ type DeviceInfo interface {
 	Open() (io.ReadWriteCloser, error)
 }
 
+// BluetoothDeviceInfo is implemented by the DeviceInfo of a device connected over Bluetooth LE
+// instead of USB. Open() then returns the GATT connection, see bluetooth.NewTransport().
+type BluetoothDeviceInfo interface {
+	DeviceInfo
+	// BluetoothMTU returns the maximum payload size of the GATT connection.
+	BluetoothMTU() int
+}
+
+// openDevice opens the device for reading and writing HID reports, over USB or Bluetooth LE. The
+// read and write errors have device.ErrTransport as the cause.
+func openDevice(deviceInfo DeviceInfo) (io.ReadWriteCloser, error) {
+	conn, err := deviceInfo.Open()
+	if err != nil {
+		return nil, err
+	}
+	bluetoothDeviceInfo, ok := deviceInfo.(BluetoothDeviceInfo)
+	if !ok {
+		return device.WrapTransportErrors(conn), nil
+	}
+	transport, err := bluetooth.NewTransport(conn, bluetoothDeviceInfo.BluetoothMTU())
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return device.WrapTransportErrors(transport), nil
+}
+
 func isBitBox(deviceInfo DeviceInfo) bool {
 	return deviceInfo.VendorID() == bitboxVendorID && deviceInfo.ProductID() == bitboxProductID && (deviceInfo.UsagePage() == 0xffff || deviceInfo.Interface() == 0)
 }
@@ -76,6 +108,12 @@ func isBitBox02Bootloader(deviceInfo DeviceInfo) bool {
 		(deviceInfo.UsagePage() == 0xffff || deviceInfo.Interface() == 0)
 }
 
+// IsBitBoxDevice returns true if the device is a BitBox01, a BitBox02 or a BitBox02 in bootloader
+// mode, i.e. a device which is registered by the manager.
+func IsBitBoxDevice(deviceInfo DeviceInfo) bool {
+	return isBitBox(deviceInfo) || isBitBox02(deviceInfo) || isBitBox02Bootloader(deviceInfo)
+}
+
 // Manager listens for devices and notifies when a device has been inserted or removed.
 type Manager struct {
 	devices           map[string]device.Interface
@@ -86,6 +124,12 @@ type Manager struct {
 	onRegister   func(device.Interface) error
 	onUnregister func(string)
 
+	// rescan requests the listen loop to re-enumerate the devices, see Rescan().
+	rescan chan struct{}
+	// retryAt contains the device IDs whose registration failed, with the time after which
+	// registering them is attempted again.
+	retryAt map[string]time.Time
+
 	socksProxy socksproxy.SocksProxy
 
 	log *logrus.Entry
@@ -111,6 +155,8 @@ func NewManager(
 		deviceInfos:       deviceInfos,
 		onRegister:        onRegister,
 		onUnregister:      onUnregister,
+		rescan:            make(chan struct{}, 1),
+		retryAt:           map[string]time.Time{},
 		socksProxy:        socksProxy,
 
 		log: logging.Get().WithGroup("manager"),
@@ -153,7 +199,7 @@ func (manager *Manager) makeBitBox(deviceInfo DeviceInfo) (*bitbox.Device, error
 	if err != nil {
 		return nil, err
 	}
-	hidDevice, err := deviceInfo.Open()
+	hidDevice, err := openDevice(deviceInfo)
 	if err != nil {
 		return nil, errp.WithMessage(err, "Failed to open device")
 	}
@@ -199,7 +245,7 @@ func (manager *Manager) makeBitBox02(deviceInfo DeviceInfo) (*bitbox02.Device, e
 	if err != nil {
 		return nil, err
 	}
-	hidDevice, err := deviceInfo.Open()
+	hidDevice, err := openDevice(deviceInfo)
 	if err != nil {
 		return nil, errp.WithMessage(err, "Failed to open device")
 	}
@@ -227,7 +273,7 @@ func (manager *Manager) makeBitBox02Bootloader(deviceInfo DeviceInfo) (
 	if err != nil {
 		return nil, err
 	}
-	hidDevice, err := deviceInfo.Open()
+	hidDevice, err := openDevice(deviceInfo)
 	if err != nil {
 		return nil, errp.WithMessage(err, "Failed to open device")
 	}
@@ -255,15 +301,19 @@ func (manager *Manager) checkIfRemoved(deviceID string) bool {
 	return true
 }
 
+func (manager *Manager) unregister(deviceID string) {
+	manager.devices[deviceID].Close()
+	delete(manager.devices, deviceID)
+	manager.onUnregister(deviceID)
+	manager.log.WithField("device-id", deviceID).Info("Unregistered device")
+}
+
 func (manager *Manager) listen() {
 	for {
-		for deviceID, device := range manager.devices {
+		for deviceID := range manager.devices {
 			// Check if device was removed.
 			if manager.checkIfRemoved(deviceID) {
-				device.Close()
-				delete(manager.devices, deviceID)
-				manager.onUnregister(deviceID)
-				manager.log.WithField("device-id", deviceID).Info("Unregistered device")
+				manager.unregister(deviceID)
 			}
 		}
 
@@ -279,6 +329,10 @@ func (manager *Manager) listen() {
 			if len(manager.devices) != 0 {
 				continue
 			}
+			// Skip if the registration failed recently.
+			if retryAt, ok := manager.retryAt[deviceID]; ok && time.Now().Before(retryAt) {
+				continue
+			}
 			var device device.Interface
 			switch {
 			case isBitBox(deviceInfo):
@@ -305,12 +359,40 @@ func (manager *Manager) listen() {
 			default:
 				panic("unrecognized device")
 			}
-			manager.devices[deviceID] = device
 			if err := manager.onRegister(device); err != nil {
-				manager.log.WithError(err).Error("Failed to execute on-register")
+				// The device is plugged in, but not usable, e.g. because its HID handle is stuck.
+				// Instead of keeping it until it is unplugged, it is closed and opened again
+				// later.
+				manager.log.WithError(err).WithField("device-id", deviceID).
+					Error("Failed to execute on-register, retrying later")
+				device.Close()
+				manager.retryAt[deviceID] = time.Now().Add(registerRetryInterval)
+				continue
 			}
+			delete(manager.retryAt, deviceID)
+			manager.devices[deviceID] = device
+		}
+
+		select {
+		case <-manager.rescan:
+			manager.log.Info("Rescanning devices")
+			for deviceID := range manager.devices {
+				manager.unregister(deviceID)
+			}
+			manager.retryAt = map[string]time.Time{}
+		case <-time.After(time.Second):
 		}
-		time.Sleep(time.Second)
+	}
+}
+
+// Rescan closes the registered devices and enumerates and registers the plugged in devices again
+// right away. This helps if a device is plugged in, but was not detected or does not respond,
+// without having to restart the app.
+func (manager *Manager) Rescan() {
+	select {
+	case manager.rescan <- struct{}{}:
+	default:
+		// A rescan is already pending.
 	}
 }
 
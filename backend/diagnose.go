@@ -0,0 +1,115 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"runtime"
+	"sync"
+
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/usb"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/diagnose"
+)
+
+// diagnoseURL is requested to check the internet connection and the clock.
+const diagnoseURL = "https://bitboxapp.shiftcrypto.io/"
+
+func (backend *Backend) diagnoseConnection() []*diagnose.Problem {
+	problems := []*diagnose.Problem{}
+	clockProblem, err := backend.checkClock()
+	if err != nil {
+		problems = append(problems, &diagnose.Problem{
+			Code:        diagnose.ProblemNoConnection,
+			Severity:    diagnose.SeverityCritical,
+			Remediation: diagnose.RemediationCheckInternet,
+			Details:     err.Error(),
+		})
+	}
+	if clockProblem != nil {
+		problems = append(problems, clockProblem)
+	}
+	return problems
+}
+
+func (backend *Backend) diagnoseProxy() []*diagnose.Problem {
+	if problem := diagnose.ProxyReachable(backend.socksProxy.Address()); problem != nil {
+		return []*diagnose.Problem{problem}
+	}
+	return nil
+}
+
+// diagnoseElectrum checks the Electrum servers of the coins of all accounts.
+func (backend *Backend) diagnoseElectrum() []*diagnose.Problem {
+	appConfig := backend.config.AppConfig()
+	coinCodes := map[coinpkg.Code]struct{}{}
+	for _, account := range backend.Accounts() {
+		coinCodes[account.Coin().Code()] = struct{}{}
+	}
+	problems := []*diagnose.Problem{}
+	for code := range coinCodes {
+		servers, ok := appConfig.Backend.ElectrumServers(code)
+		if !ok {
+			continue
+		}
+		serverErrors := make(map[string]error, len(servers))
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, server := range servers {
+			wg.Add(1)
+			go func(server *config.ServerInfo) {
+				defer wg.Done()
+				err := backend.CheckElectrumServer(server)
+				mu.Lock()
+				defer mu.Unlock()
+				serverErrors[server.Server] = err
+			}(server)
+		}
+		wg.Wait()
+		problems = append(problems, diagnose.ElectrumServers(string(code), serverErrors)...)
+	}
+	return problems
+}
+
+func (backend *Backend) diagnoseUSB() []*diagnose.Problem {
+	plugged := 0
+	for _, deviceInfo := range backend.environment.DeviceInfos() {
+		if usb.IsBitBoxDevice(deviceInfo) {
+			plugged++
+		}
+	}
+	if problem := diagnose.USBDevices(plugged, len(backend.DevicesRegistered()), runtime.GOOS); problem != nil {
+		return []*diagnose.Problem{problem}
+	}
+	return nil
+}
+
+// Diagnose checks the internet connection, the clock, the proxy, the Electrum servers and the
+// connected devices, and returns the detected problems, most severe first.
+func (backend *Backend) Diagnose() *diagnose.Report {
+	checks := []diagnose.Check{
+		{Name: "connection", Run: backend.diagnoseConnection},
+	}
+	if backend.socksProxy.Enabled() {
+		checks = append(checks, diagnose.Check{Name: "proxy", Run: backend.diagnoseProxy})
+	}
+	checks = append(checks,
+		diagnose.Check{Name: "electrum", Run: backend.diagnoseElectrum},
+		diagnose.Check{Name: "usb", Run: backend.diagnoseUSB},
+	)
+	report := diagnose.Run(checks)
+	backend.log.WithField("problems", len(report.Problems)).Info("Diagnosis done")
+	return report
+}
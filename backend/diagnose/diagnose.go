@@ -0,0 +1,296 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diagnose runs checks of the network connectivity, the connected devices and the
+// configured servers, and reports the detected problems ordered by severity. Each problem comes
+// with a remediation code, which the frontend uses to guide the user through fixing it.
+package diagnose
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+const (
+	// checkTimeout limits how long a single check may take. Checks which do not finish in time are
+	// reported as failed.
+	checkTimeout = 45 * time.Second
+
+	// MaxClockSkew is the clock difference to a server above which the local clock is considered
+	// wrong. A wrong clock can break TLS connections.
+	MaxClockSkew = 5 * time.Minute
+
+	// MaxFutureBlockTime is how far the timestamp of a Bitcoin block can be ahead of the time of
+	// the network, as enforced by the consensus rules.
+	MaxFutureBlockTime = 2 * time.Hour
+
+	dialTimeout = 10 * time.Second
+)
+
+// Severity is how severely a problem affects the app.
+type Severity string
+
+const (
+	// SeverityCritical means the app cannot work properly, e.g. the accounts cannot sync.
+	SeverityCritical Severity = "critical"
+	// SeverityWarning means parts of the app might not work properly.
+	SeverityWarning Severity = "warning"
+)
+
+var severityOrder = map[Severity]int{
+	SeverityCritical: 0,
+	SeverityWarning:  1,
+}
+
+// ProblemCode identifies a detected problem.
+type ProblemCode string
+
+const (
+	// ProblemNoConnection means the app cannot reach the internet.
+	ProblemNoConnection ProblemCode = "noConnection"
+	// ProblemProxyUnreachable means the configured SOCKS5 proxy, e.g. Tor, does not accept
+	// connections.
+	ProblemProxyUnreachable ProblemCode = "proxyUnreachable"
+	// ProblemClockSkew means the local clock differs too much from the clock of our server.
+	ProblemClockSkew ProblemCode = "clockSkew"
+	// ProblemElectrumUnreachable means no Electrum server of a coin can be reached.
+	ProblemElectrumUnreachable ProblemCode = "electrumUnreachable"
+	// ProblemElectrumServerDown means one of multiple Electrum servers of a coin cannot be
+	// reached.
+	ProblemElectrumServerDown ProblemCode = "electrumServerDown"
+	// ProblemUSBPermissions means a BitBox is plugged in, but the app cannot open it. On Linux,
+	// this is usually caused by missing udev rules.
+	ProblemUSBPermissions ProblemCode = "usbPermissions"
+	// ProblemCheckFailed means a check did not finish in time.
+	ProblemCheckFailed ProblemCode = "checkFailed"
+)
+
+// Remediation tells the user how to fix a problem.
+type Remediation string
+
+const (
+	// RemediationCheckInternet asks the user to check the internet connection.
+	RemediationCheckInternet Remediation = "checkInternetConnection"
+	// RemediationCheckProxy asks the user to start the proxy, e.g. the Tor daemon, or to check
+	// the proxy settings.
+	RemediationCheckProxy Remediation = "checkProxySettings"
+	// RemediationSyncClock asks the user to correct the date and time of the computer.
+	RemediationSyncClock Remediation = "syncClock"
+	// RemediationCheckServers asks the user to check the configured Electrum servers.
+	RemediationCheckServers Remediation = "checkServerSettings"
+	// RemediationInstallUdevRules asks the user to install the udev rules which allow the app to
+	// access the BitBox.
+	RemediationInstallUdevRules Remediation = "installUdevRules"
+	// RemediationReconnectDevice asks the user to replug the BitBox and to close other apps which
+	// might use it.
+	RemediationReconnectDevice Remediation = "reconnectDevice"
+	// RemediationRetry asks the user to run the diagnosis again.
+	RemediationRetry Remediation = "retry"
+)
+
+// Problem is a problem detected by a check.
+type Problem struct {
+	Code        ProblemCode `json:"code"`
+	Severity    Severity    `json:"severity"`
+	Remediation Remediation `json:"remediation"`
+	// Subject is what the problem is about, e.g. a server address or a coin code. Can be empty.
+	Subject string `json:"subject,omitempty"`
+	// Details is the error message, for support. Can be empty.
+	Details string `json:"details,omitempty"`
+}
+
+// Check is one check of the diagnosis.
+type Check struct {
+	// Name identifies the check, e.g. "electrum".
+	Name string
+	// Run returns the detected problems, if any.
+	Run func() []*Problem
+}
+
+// CheckResult is the outcome of a check.
+type CheckResult struct {
+	Name     string `json:"name"`
+	Passed   bool   `json:"passed"`
+	Duration int64  `json:"durationMs"`
+}
+
+// Report is the result of the diagnosis.
+type Report struct {
+	// Problems are the detected problems, most severe first.
+	Problems []*Problem    `json:"problems"`
+	Checks   []CheckResult `json:"checks"`
+}
+
+// Run runs all checks concurrently and returns the detected problems, ordered by severity and
+// then by the order of the checks.
+func Run(checks []Check) *Report {
+	results := make([][]*Problem, len(checks))
+	report := &Report{
+		Problems: []*Problem{},
+		Checks:   make([]CheckResult, len(checks)),
+	}
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			start := time.Now()
+			done := make(chan []*Problem, 1)
+			go func() { done <- check.Run() }()
+			select {
+			case results[i] = <-done:
+			case <-time.After(checkTimeout):
+				results[i] = []*Problem{{
+					Code:        ProblemCheckFailed,
+					Severity:    SeverityWarning,
+					Remediation: RemediationRetry,
+					Subject:     check.Name,
+				}}
+			}
+			report.Checks[i] = CheckResult{
+				Name:     check.Name,
+				Passed:   len(results[i]) == 0,
+				Duration: time.Since(start).Milliseconds(),
+			}
+		}(i, check)
+	}
+	wg.Wait()
+	for _, problems := range results {
+		report.Problems = append(report.Problems, problems...)
+	}
+	sort.SliceStable(report.Problems, func(i, j int) bool {
+		return severityOrder[report.Problems[i].Severity] < severityOrder[report.Problems[j].Severity]
+	})
+	return report
+}
+
+// ServerTime returns the time reported in the Date header of the response of the given URL.
+func ServerTime(httpClient *http.Client, url string) (time.Time, error) {
+	response, err := httpClient.Head(url)
+	if err != nil {
+		return time.Time{}, errp.WithStack(err)
+	}
+	_ = response.Body.Close()
+	serverTime, err := http.ParseTime(response.Header.Get("Date"))
+	if err != nil {
+		return time.Time{}, errp.WithStack(err)
+	}
+	return serverTime, nil
+}
+
+// ClockSkew returns a problem if the local time differs more than MaxClockSkew from the server
+// time, nil otherwise.
+func ClockSkew(localTime, serverTime time.Time) *Problem {
+	skew := localTime.Sub(serverTime)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew <= MaxClockSkew {
+		return nil
+	}
+	return &Problem{
+		Code:        ProblemClockSkew,
+		Severity:    SeverityCritical,
+		Remediation: RemediationSyncClock,
+		Details:     "clock differs by " + skew.Round(time.Second).String(),
+	}
+}
+
+// BlockTimeSkew returns a problem if the local time is behind the timestamp of a verified block
+// header by more than MaxFutureBlockTime, nil otherwise. A clock which is ahead cannot be detected
+// this way, as blocks can be found late.
+func BlockTimeSkew(localTime, blockTime time.Time) *Problem {
+	skew := blockTime.Sub(localTime)
+	if skew <= MaxFutureBlockTime {
+		return nil
+	}
+	return &Problem{
+		Code:        ProblemClockSkew,
+		Severity:    SeverityCritical,
+		Remediation: RemediationSyncClock,
+		Details:     "clock is behind the latest block by " + skew.Round(time.Second).String(),
+	}
+}
+
+// ProxyReachable returns a problem if no TCP connection can be established to the proxy at the
+// given address, nil otherwise.
+func ProxyReachable(address string) *Problem {
+	conn, err := net.DialTimeout("tcp", address, dialTimeout)
+	if err != nil {
+		return &Problem{
+			Code:        ProblemProxyUnreachable,
+			Severity:    SeverityCritical,
+			Remediation: RemediationCheckProxy,
+			Subject:     address,
+			Details:     err.Error(),
+		}
+	}
+	_ = conn.Close()
+	return nil
+}
+
+// ElectrumServers returns the problems of the Electrum servers of a coin, given the error of the
+// connection check of each server, nil meaning the server is reachable. If no server is
+// reachable, the accounts of the coin cannot sync, which is critical.
+func ElectrumServers(coinCode string, serverErrors map[string]error) []*Problem {
+	problems := []*Problem{}
+	servers := make([]string, 0, len(serverErrors))
+	for server := range serverErrors {
+		servers = append(servers, server)
+	}
+	sort.Strings(servers)
+	for _, server := range servers {
+		if err := serverErrors[server]; err != nil {
+			problems = append(problems, &Problem{
+				Code:        ProblemElectrumServerDown,
+				Severity:    SeverityWarning,
+				Remediation: RemediationCheckServers,
+				Subject:     server,
+				Details:     err.Error(),
+			})
+		}
+	}
+	if len(servers) > 0 && len(problems) == len(servers) {
+		return []*Problem{{
+			Code:        ProblemElectrumUnreachable,
+			Severity:    SeverityCritical,
+			Remediation: RemediationCheckServers,
+			Subject:     coinCode,
+		}}
+	}
+	return problems
+}
+
+// USBDevices returns a problem if BitBox devices are plugged in which the app did not register,
+// e.g. because it is not allowed to open them. On Linux, missing udev rules are the usual
+// cause.
+func USBDevices(plugged int, registered int, goos string) *Problem {
+	if plugged <= registered {
+		return nil
+	}
+	remediation := RemediationReconnectDevice
+	if goos == "linux" {
+		remediation = RemediationInstallUdevRules
+	}
+	return &Problem{
+		Code:        ProblemUSBPermissions,
+		Severity:    SeverityCritical,
+		Remediation: remediation,
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnose
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	warning := &Problem{Code: ProblemElectrumServerDown, Severity: SeverityWarning}
+	critical := &Problem{Code: ProblemClockSkew, Severity: SeverityCritical}
+	report := Run([]Check{
+		{Name: "a", Run: func() []*Problem { return []*Problem{warning} }},
+		{Name: "b", Run: func() []*Problem { return nil }},
+		{Name: "c", Run: func() []*Problem { return []*Problem{critical} }},
+	})
+	require.Equal(t, []*Problem{critical, warning}, report.Problems)
+	require.Len(t, report.Checks, 3)
+	require.Equal(t, "a", report.Checks[0].Name)
+	require.False(t, report.Checks[0].Passed)
+	require.True(t, report.Checks[1].Passed)
+	require.False(t, report.Checks[2].Passed)
+
+	require.Equal(t, []*Problem{}, Run(nil).Problems)
+}
+
+func TestClockSkew(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	require.Nil(t, ClockSkew(now, now))
+	require.Nil(t, ClockSkew(now, now.Add(MaxClockSkew)))
+	require.Nil(t, ClockSkew(now, now.Add(-MaxClockSkew)))
+	problem := ClockSkew(now, now.Add(-time.Hour))
+	require.NotNil(t, problem)
+	require.Equal(t, ProblemClockSkew, problem.Code)
+	require.Equal(t, RemediationSyncClock, problem.Remediation)
+	require.Equal(t, "clock differs by 1h0m0s", problem.Details)
+	require.NotNil(t, ClockSkew(now, now.Add(time.Hour)))
+}
+
+func TestBlockTimeSkew(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	require.Nil(t, BlockTimeSkew(now, now))
+	require.Nil(t, BlockTimeSkew(now, now.Add(MaxFutureBlockTime)))
+	// Blocks can be found late, so an old tip does not mean the clock is wrong.
+	require.Nil(t, BlockTimeSkew(now, now.Add(-24*time.Hour)))
+	problem := BlockTimeSkew(now, now.Add(3*time.Hour))
+	require.NotNil(t, problem)
+	require.Equal(t, ProblemClockSkew, problem.Code)
+	require.Equal(t, RemediationSyncClock, problem.Remediation)
+	require.Equal(t, "clock is behind the latest block by 3h0m0s", problem.Details)
+}
+
+func TestServerTime(t *testing.T) {
+	serverTime := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Date", serverTime.Format(http.TimeFormat))
+	}))
+	defer server.Close()
+	result, err := ServerTime(server.Client(), server.URL)
+	require.NoError(t, err)
+	require.True(t, serverTime.Equal(result))
+}
+
+func TestProxyReachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.Nil(t, ProxyReachable(address))
+	require.NoError(t, listener.Close())
+
+	problem := ProxyReachable(address)
+	require.NotNil(t, problem)
+	require.Equal(t, ProblemProxyUnreachable, problem.Code)
+	require.Equal(t, address, problem.Subject)
+}
+
+func TestElectrumServers(t *testing.T) {
+	require.Empty(t, ElectrumServers("btc", map[string]error{}))
+	require.Empty(t, ElectrumServers("btc", map[string]error{"a:50002": nil, "b:50002": nil}))
+
+	problems := ElectrumServers("btc", map[string]error{"a:50002": nil, "b:50002": errors.New("timeout")})
+	require.Len(t, problems, 1)
+	require.Equal(t, ProblemElectrumServerDown, problems[0].Code)
+	require.Equal(t, SeverityWarning, problems[0].Severity)
+	require.Equal(t, "b:50002", problems[0].Subject)
+
+	problems = ElectrumServers("btc", map[string]error{"a:50002": errors.New("timeout"), "b:50002": errors.New("timeout")})
+	require.Equal(t, []*Problem{{
+		Code:        ProblemElectrumUnreachable,
+		Severity:    SeverityCritical,
+		Remediation: RemediationCheckServers,
+		Subject:     "btc",
+	}}, problems)
+}
+
+func TestUSBDevices(t *testing.T) {
+	require.Nil(t, USBDevices(0, 0, "linux"))
+	require.Nil(t, USBDevices(1, 1, "linux"))
+	require.Equal(t, RemediationInstallUdevRules, USBDevices(1, 0, "linux").Remediation)
+	require.Equal(t, RemediationReconnectDevice, USBDevices(1, 0, "windows").Remediation)
+}
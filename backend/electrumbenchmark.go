@@ -0,0 +1,146 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/electrum"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// ElectrumServerBenchmark is the result of benchmarking a configured Electrum server.
+type ElectrumServerBenchmark struct {
+	Server string `json:"server"`
+	// Preferred is true if the app connects to this server first, see config.ServerInfo.
+	Preferred bool  `json:"preferred"`
+	ConnectMs int64 `json:"connectMs"`
+	PingMs    int64 `json:"pingMs"`
+	TipHeight int   `json:"tipHeight"`
+	// Behind is true if the tip of the server is behind the tip of other servers.
+	Behind bool `json:"behind"`
+	// Error is the error of a server which could not be benchmarked, empty otherwise.
+	Error string `json:"error"`
+}
+
+// rankElectrumServerBenchmarks sorts the benchmarks from the best to the worst server. Servers
+// which failed come last, then the servers behind the highest tip. Within these, the servers are
+// sorted by their ping, then their connect time.
+func rankElectrumServerBenchmarks(benchmarks []*ElectrumServerBenchmark) {
+	maxTipHeight := 0
+	for _, benchmark := range benchmarks {
+		if benchmark.Error == "" && benchmark.TipHeight > maxTipHeight {
+			maxTipHeight = benchmark.TipHeight
+		}
+	}
+	for _, benchmark := range benchmarks {
+		benchmark.Behind = benchmark.Error == "" && benchmark.TipHeight < maxTipHeight
+	}
+	rank := func(benchmark *ElectrumServerBenchmark) int {
+		switch {
+		case benchmark.Error != "":
+			return 2
+		case benchmark.Behind:
+			return 1
+		default:
+			return 0
+		}
+	}
+	sort.SliceStable(benchmarks, func(i, j int) bool {
+		a, b := benchmarks[i], benchmarks[j]
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		if a.PingMs != b.PingMs {
+			return a.PingMs < b.PingMs
+		}
+		return a.ConnectMs < b.ConnectMs
+	})
+}
+
+func (backend *Backend) checkElectrumCoin(code coinpkg.Code) error {
+	for _, coinCode := range backend.electrumCoinCodes() {
+		if coinCode == code {
+			return nil
+		}
+	}
+	return errp.Newf("unsupported coin code %s", code)
+}
+
+// BenchmarkElectrumServers connects to all configured Electrum servers of the coin at the same
+// time, measuring how fast they respond. The servers are returned from the best to the worst.
+func (backend *Backend) BenchmarkElectrumServers(code coinpkg.Code) ([]*ElectrumServerBenchmark, error) {
+	if err := backend.checkElectrumCoin(code); err != nil {
+		return nil, err
+	}
+	servers := backend.defaultElectrumXServers(code)
+	benchmarks := make([]*ElectrumServerBenchmark, len(servers))
+	var wg sync.WaitGroup
+	for i, serverInfo := range servers {
+		wg.Add(1)
+		go func(i int, serverInfo *config.ServerInfo) {
+			defer wg.Done()
+			benchmark := &ElectrumServerBenchmark{
+				Server:    serverInfo.Server,
+				Preferred: serverInfo.Preferred,
+			}
+			result, err := electrum.BenchmarkServer(serverInfo, backend.serverDialer(serverInfo))
+			if err != nil {
+				benchmark.Error = err.Error()
+			} else {
+				benchmark.ConnectMs = result.Connect.Milliseconds()
+				benchmark.PingMs = result.Ping.Milliseconds()
+				benchmark.TipHeight = result.TipHeight
+			}
+			benchmarks[i] = benchmark
+		}(i, serverInfo)
+	}
+	wg.Wait()
+	rankElectrumServerBenchmarks(benchmarks)
+	backend.log.WithField("coinCode", code).WithField("servers", len(benchmarks)).
+		Info("Benchmarked the Electrum servers")
+	return benchmarks, nil
+}
+
+// PreferElectrumServer makes the app connect to the given configured Electrum server first,
+// instead of a random one. An empty server removes the preference. Like the server config, this
+// is applied after the app is restarted.
+func (backend *Backend) PreferElectrumServer(code coinpkg.Code, server string) error {
+	if backend.arguments.DevServers() {
+		return errp.New("the dev servers can't be changed")
+	}
+	if err := backend.checkElectrumCoin(code); err != nil {
+		return err
+	}
+	return backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		servers, _ := appConfig.Backend.ElectrumServers(code)
+		found := server == ""
+		newServers := make([]*config.ServerInfo, len(servers))
+		for i, serverInfo := range servers {
+			newServer := *serverInfo
+			newServer.Preferred = server != "" && serverInfo.Server == server
+			found = found || newServer.Preferred
+			newServers[i] = &newServer
+		}
+		if !found {
+			return errp.WithStack(errServerNotFound)
+		}
+		appConfig.Backend.SetElectrumServers(code, newServers)
+		return nil
+	})
+}
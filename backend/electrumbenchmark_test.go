@@ -0,0 +1,77 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/arguments"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankElectrumServerBenchmarks(t *testing.T) {
+	benchmarks := []*ElectrumServerBenchmark{
+		{Server: "failed", Error: "connection refused"},
+		{Server: "behind", PingMs: 10, TipHeight: 99},
+		{Server: "slow", PingMs: 200, TipHeight: 100},
+		{Server: "fast-connect", PingMs: 50, ConnectMs: 100, TipHeight: 100},
+		{Server: "slow-connect", PingMs: 50, ConnectMs: 300, TipHeight: 100},
+	}
+	rankElectrumServerBenchmarks(benchmarks)
+	servers := []string{}
+	for _, benchmark := range benchmarks {
+		servers = append(servers, benchmark.Server)
+	}
+	require.Equal(t, []string{"fast-connect", "slow-connect", "slow", "behind", "failed"}, servers)
+	require.True(t, benchmarks[3].Behind)
+	require.False(t, benchmarks[4].Behind)
+	require.False(t, benchmarks[0].Behind)
+}
+
+func TestPreferElectrumServer(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	// The dev servers can't be changed.
+	require.Error(t, b.PreferElectrumServer(coinpkg.CodeBTC, "btc1.shiftcrypto.io:443"))
+
+	b.arguments = arguments.NewArguments(
+		test.TstTempDir("electrumbenchmark"), false, false, false,
+		&types.GapLimits{Receive: 20, Change: 6})
+	servers, _ := b.config.AppConfig().Backend.ElectrumServers(coinpkg.CodeBTC)
+	require.Greater(t, len(servers), 1)
+	preferred := servers[1].Server
+
+	require.Error(t, b.PreferElectrumServer(coinpkg.CodeETH, preferred))
+	err := b.PreferElectrumServer(coinpkg.CodeBTC, "unknown:50002")
+	require.Equal(t, errServerNotFound, errp.Cause(err))
+
+	require.NoError(t, b.PreferElectrumServer(coinpkg.CodeBTC, preferred))
+	servers, _ = b.config.AppConfig().Backend.ElectrumServers(coinpkg.CodeBTC)
+	for _, serverInfo := range servers {
+		require.Equal(t, serverInfo.Server == preferred, serverInfo.Preferred)
+	}
+
+	// Removing the preference.
+	require.NoError(t, b.PreferElectrumServer(coinpkg.CodeBTC, ""))
+	servers, _ = b.config.AppConfig().Backend.ElectrumServers(coinpkg.CodeBTC)
+	for _, serverInfo := range servers {
+		require.False(t, serverInfo.Preferred)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/electrum"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+const (
+	// errCertificateChanged is returned when re-pinning a certificate if the server presents a
+	// different certificate than the one approved by the user.
+	errCertificateChanged errp.ErrorCode = "certificateChanged"
+	// errServerNotFound is returned if the server is not configured for the coin.
+	errServerNotFound errp.ErrorCode = "serverNotFound"
+)
+
+// PinnedCertificate is the certificate pinned for a configured Electrum server.
+type PinnedCertificate struct {
+	CoinCode coinpkg.Code `json:"coinCode"`
+	Server   string       `json:"server"`
+	TLS      bool         `json:"tls"`
+	// Certificate is nil if the server does not use TLS or if the pinned certificate could not be
+	// parsed.
+	Certificate *electrum.CertificateInfo `json:"certificate"`
+	Expired     bool                      `json:"expired"`
+}
+
+// PinnedCertificateCheck compares the certificate a server presents with the pinned one.
+type PinnedCertificateCheck struct {
+	PinnedCertificate
+	*electrum.CertificateCheck
+}
+
+func (backend *Backend) electrumCoinCodes() []coinpkg.Code {
+	switch {
+	case backend.arguments.Regtest():
+		return []coinpkg.Code{coinpkg.CodeRBTC}
+	case backend.arguments.Testing():
+		return []coinpkg.Code{coinpkg.CodeTBTC, coinpkg.CodeTLTC}
+	default:
+		return []coinpkg.Code{coinpkg.CodeBTC, coinpkg.CodeLTC}
+	}
+}
+
+func newPinnedCertificate(code coinpkg.Code, serverInfo *config.ServerInfo) *PinnedCertificate {
+	pinned := &PinnedCertificate{
+		CoinCode: code,
+		Server:   serverInfo.Server,
+		TLS:      serverInfo.TLS,
+	}
+	if !serverInfo.TLS {
+		return pinned
+	}
+	certificate, err := electrum.ParseCertificate(serverInfo.PEMCert)
+	if err != nil {
+		return pinned
+	}
+	pinned.Certificate = certificate
+	pinned.Expired = certificate.Expired(time.Now())
+	return pinned
+}
+
+// PinnedCertificates lists the certificates pinned for the configured Electrum servers of all
+// BTC-based coins.
+func (backend *Backend) PinnedCertificates() []*PinnedCertificate {
+	result := []*PinnedCertificate{}
+	for _, code := range backend.electrumCoinCodes() {
+		for _, serverInfo := range backend.defaultElectrumXServers(code) {
+			result = append(result, newPinnedCertificate(code, serverInfo))
+		}
+	}
+	return result
+}
+
+func (backend *Backend) electrumServer(code coinpkg.Code, server string) (*config.ServerInfo, error) {
+	supported := false
+	for _, coinCode := range backend.electrumCoinCodes() {
+		supported = supported || coinCode == code
+	}
+	if !supported {
+		return nil, errp.Newf("unsupported coin code %s", code)
+	}
+	for _, serverInfo := range backend.defaultElectrumXServers(code) {
+		if serverInfo.Server == server && serverInfo.TLS {
+			return serverInfo, nil
+		}
+	}
+	return nil, errp.WithStack(errServerNotFound)
+}
+
+// CheckPinnedCertificate downloads the certificate the server currently presents and compares it
+// with the pinned one. Use this to let the user review a new certificate after the server rotated
+// its certificate, before calling RepinCertificate.
+func (backend *Backend) CheckPinnedCertificate(code coinpkg.Code, server string) (*PinnedCertificateCheck, error) {
+	serverInfo, err := backend.electrumServer(code, server)
+	if err != nil {
+		return nil, err
+	}
+	check, err := electrum.CheckCertificate(
+		serverInfo.Server, serverInfo.PEMCert, backend.serverDialer(serverInfo))
+	if err != nil {
+		return nil, err
+	}
+	return &PinnedCertificateCheck{
+		PinnedCertificate: *newPinnedCertificate(code, serverInfo),
+		CertificateCheck:  check,
+	}, nil
+}
+
+// RepinCertificate pins the certificate the server currently presents. fingerprint is the
+// fingerprint approved by the user, as returned by CheckPinnedCertificate. If the server presents
+// a different certificate in the meantime, errCertificateChanged is returned and nothing is
+// changed. The new certificate is used after the app is restarted.
+func (backend *Backend) RepinCertificate(code coinpkg.Code, server string, fingerprint string) error {
+	if backend.arguments.DevServers() {
+		return errp.New("the certificates of the dev servers can't be changed")
+	}
+	serverInfo, err := backend.electrumServer(code, server)
+	if err != nil {
+		return err
+	}
+	check, err := electrum.CheckCertificate(
+		serverInfo.Server, serverInfo.PEMCert, backend.serverDialer(serverInfo))
+	if err != nil {
+		return err
+	}
+	if check.Remote.Fingerprint != fingerprint {
+		return errp.WithStack(errCertificateChanged)
+	}
+	err = backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		servers, _ := appConfig.Backend.ElectrumServers(code)
+		newServers := make([]*config.ServerInfo, len(servers))
+		for i, serverInfo := range servers {
+			newServers[i] = serverInfo
+			if serverInfo.Server == server && serverInfo.TLS {
+				newServers[i] = &config.ServerInfo{
+					Server:    serverInfo.Server,
+					TLS:       true,
+					PEMCert:   check.RemotePEM(),
+					SSHTunnel: serverInfo.SSHTunnel,
+				}
+			}
+		}
+		appConfig.Backend.SetElectrumServers(code, newServers)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	backend.log.
+		WithField("coinCode", code).
+		WithField("server", server).
+		WithField("fingerprint", fingerprint).
+		Info("re-pinned electrum server certificate")
+	return nil
+}
@@ -16,8 +16,31 @@
 package backend
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// customERC20TokenPrefix is the prefix of the codes of the tokens added by the user. The code
+	// is the prefix followed by the lowercase contract address.
+	customERC20TokenPrefix = "eth-erc20-custom-"
+
+	// erc20MetadataTimeout is the timeout for fetching the metadata of a token added by the user.
+	erc20MetadataTimeout = 30 * time.Second
+)
+
+var (
+	errInvalidContractAddress errp.ErrorCode = "invalidContractAddress"
+	errERC20TokenExists       errp.ErrorCode = "erc20TokenExists"
+	errERC20TokenNotFound     errp.ErrorCode = "erc20TokenNotFound"
 )
 
 type erc20Token struct {
@@ -88,8 +111,42 @@ var erc20Tokens = []erc20Token{
 	},
 }
 
-func erc20TokenByCode(code coin.Code) *erc20Token {
-	for _, token := range erc20Tokens {
+// customERC20TokenCode returns the code of the token added by the user with the given contract
+// address.
+func customERC20TokenCode(contractAddress common.Address) coin.Code {
+	return coin.Code(customERC20TokenPrefix + strings.ToLower(contractAddress.Hex()))
+}
+
+// customERC20Tokens returns the tokens added by the user.
+func (backend *Backend) customERC20Tokens() []erc20Token {
+	customTokens := backend.config.AppConfig().Backend.ETH.CustomERC20Tokens
+	tokens := make([]erc20Token, 0, len(customTokens))
+	for _, customToken := range customTokens {
+		if !common.IsHexAddress(customToken.ContractAddress) {
+			backend.log.Errorf("Skipping custom ERC20 token with invalid contract address %s",
+				customToken.ContractAddress)
+			continue
+		}
+		contractAddress := common.HexToAddress(customToken.ContractAddress)
+		tokens = append(tokens, erc20Token{
+			code:  customERC20TokenCode(contractAddress),
+			name:  customToken.Name,
+			unit:  customToken.Unit,
+			token: erc20.NewToken(customToken.ContractAddress, customToken.Decimals),
+		})
+	}
+	return tokens
+}
+
+// allERC20Tokens returns the built-in tokens followed by the tokens added by the user.
+func (backend *Backend) allERC20Tokens() []erc20Token {
+	return append(append([]erc20Token{}, erc20Tokens...), backend.customERC20Tokens()...)
+}
+
+// erc20TokenByCode returns the built-in or user-added token with the given code, or nil if there
+// is none.
+func (backend *Backend) erc20TokenByCode(code coin.Code) *erc20Token {
+	for _, token := range backend.allERC20Tokens() {
 		if code == token.code {
 			token := token
 			return &token
@@ -97,3 +154,124 @@ func erc20TokenByCode(code coin.Code) *erc20Token {
 	}
 	return nil
 }
+
+// ERC20TokenInfo describes a token which can be activated in an Ethereum account.
+type ERC20TokenInfo struct {
+	Code            coin.Code `json:"code"`
+	Name            string    `json:"name"`
+	Unit            string    `json:"unit"`
+	ContractAddress string    `json:"contractAddress"`
+	Decimals        uint      `json:"decimals"`
+	// Custom is true if the token was added by the user.
+	Custom bool `json:"custom"`
+}
+
+// ERC20Tokens returns the built-in tokens followed by the tokens added by the user.
+func (backend *Backend) ERC20Tokens() []ERC20TokenInfo {
+	result := []ERC20TokenInfo{}
+	add := func(tokens []erc20Token, custom bool) {
+		for _, token := range tokens {
+			result = append(result, ERC20TokenInfo{
+				Code:            token.code,
+				Name:            token.name,
+				Unit:            token.unit,
+				ContractAddress: token.token.ContractAddress().Hex(),
+				Decimals:        token.token.Decimals(),
+				Custom:          custom,
+			})
+		}
+	}
+	add(erc20Tokens, false)
+	add(backend.customERC20Tokens(), true)
+	return result
+}
+
+// AddCustomERC20Token adds the mainnet ERC20 token with the given contract address. Its name,
+// symbol and decimals are fetched from the contract. Once added, the token can be activated in
+// Ethereum accounts like the built-in tokens using SetTokenActive().
+func (backend *Backend) AddCustomERC20Token(contractAddress string) (*ERC20TokenInfo, error) {
+	contractAddress = strings.TrimSpace(contractAddress)
+	if !common.IsHexAddress(contractAddress) {
+		return nil, errp.WithStack(errInvalidContractAddress)
+	}
+	address := common.HexToAddress(contractAddress)
+	code := customERC20TokenCode(address)
+	for _, token := range backend.allERC20Tokens() {
+		if token.token.ContractAddress() == address {
+			return nil, errp.WithStack(errERC20TokenExists)
+		}
+	}
+	ethCoin, err := backend.Coin(coin.CodeETH)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), erc20MetadataTimeout)
+	defer cancel()
+	metadata, err := ethCoin.(*eth.Coin).FetchERC20Metadata(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	customToken := config.CustomERC20Token{
+		ContractAddress: address.Hex(),
+		Name:            metadata.Name,
+		Unit:            metadata.Symbol,
+		Decimals:        metadata.Decimals,
+	}
+	err = backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		for _, existing := range appConfig.Backend.ETH.CustomERC20Tokens {
+			if strings.EqualFold(existing.ContractAddress, customToken.ContractAddress) {
+				return errp.WithStack(errERC20TokenExists)
+			}
+		}
+		appConfig.Backend.ETH.CustomERC20Tokens = append(
+			appConfig.Backend.ETH.CustomERC20Tokens, customToken)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	backend.log.Infof("Added custom ERC20 token %s (%s)", code, metadata.Symbol)
+	return &ERC20TokenInfo{
+		Code:            code,
+		Name:            customToken.Name,
+		Unit:            customToken.Unit,
+		ContractAddress: customToken.ContractAddress,
+		Decimals:        customToken.Decimals,
+		Custom:          true,
+	}, nil
+}
+
+// RemoveCustomERC20Token removes the token added by the user with the given code and deactivates
+// it in all accounts. Built-in tokens cannot be removed.
+func (backend *Backend) RemoveCustomERC20Token(code coin.Code) error {
+	err := backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		customTokens := appConfig.Backend.ETH.CustomERC20Tokens
+		for i, customToken := range customTokens {
+			if common.IsHexAddress(customToken.ContractAddress) &&
+				customERC20TokenCode(common.HexToAddress(customToken.ContractAddress)) == code {
+				appConfig.Backend.ETH.CustomERC20Tokens = append(customTokens[:i:i], customTokens[i+1:]...)
+				return nil
+			}
+		}
+		return errp.WithStack(errERC20TokenNotFound)
+	})
+	if err != nil {
+		return err
+	}
+	err = backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		for _, acct := range accountsConfig.Accounts {
+			if acct.CoinCode != coin.CodeETH {
+				continue
+			}
+			if err := acct.SetTokenActive(string(code), false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	backend.ReinitializeAccounts()
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/rpcclient/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// contractCallerMock is an ETH client which also executes contract calls.
+type contractCallerMock struct {
+	mocks.InterfaceMock
+	callContract func(msg ethereum.CallMsg) ([]byte, error)
+}
+
+func (m *contractCallerMock) CallContract(
+	ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return m.callContract(msg)
+}
+
+// abiString encodes a string as returned by a contract function.
+func abiString(value string) []byte {
+	result := common.LeftPadBytes(big.NewInt(32).Bytes(), 32)
+	result = append(result, common.LeftPadBytes(big.NewInt(int64(len(value))).Bytes(), 32)...)
+	return append(result, common.RightPadBytes([]byte(value), (len(value)+31)/32*32)...)
+}
+
+func TestCustomERC20Tokens(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	tokenContract := common.HexToAddress("0x1f9840a85d5AF5bf1D1762F925BDADdC4201F984")
+	notAContract := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	ethCoin, err := b.Coin(coinpkg.CodeETH)
+	require.NoError(t, err)
+	ethCoin.(*eth.Coin).TstSetClient(&contractCallerMock{
+		InterfaceMock: mocks.InterfaceMock{
+			ContractCreationBlockFunc: func(ctx context.Context, address common.Address) (*big.Int, error) {
+				if address == notAContract {
+					return nil, nil
+				}
+				return big.NewInt(10861674), nil
+			},
+		},
+		callContract: func(msg ethereum.CallMsg) ([]byte, error) {
+			switch {
+			case bytes.Equal(msg.Data, []byte{0x06, 0xfd, 0xde, 0x03}):
+				return abiString("Uniswap"), nil
+			case bytes.Equal(msg.Data, []byte{0x95, 0xd8, 0x9b, 0x41}):
+				return abiString("UNI"), nil
+			default:
+				return common.LeftPadBytes(big.NewInt(18).Bytes(), 32), nil
+			}
+		},
+	})
+
+	builtinTokens := len(b.ERC20Tokens())
+
+	_, err = b.AddCustomERC20Token("not an address")
+	require.Equal(t, errInvalidContractAddress, errp.Cause(err))
+	_, err = b.AddCustomERC20Token("0xdac17f958d2ee523a2206206994597c13d831ec7")
+	require.Equal(t, errERC20TokenExists, errp.Cause(err))
+	_, err = b.AddCustomERC20Token(notAContract.Hex())
+	require.Equal(t, erc20.ErrNotAContract, errp.Cause(err))
+
+	code := coinpkg.Code("eth-erc20-custom-0x1f9840a85d5af5bf1d1762f925bdaddc4201f984")
+	token, err := b.AddCustomERC20Token(" 0x1f9840a85d5af5bf1d1762f925bdaddc4201f984 ")
+	require.NoError(t, err)
+	expectedToken := ERC20TokenInfo{
+		Code:            code,
+		Name:            "Uniswap",
+		Unit:            "UNI",
+		ContractAddress: tokenContract.Hex(),
+		Decimals:        18,
+		Custom:          true,
+	}
+	require.Equal(t, expectedToken, *token)
+	tokens := b.ERC20Tokens()
+	require.Len(t, tokens, builtinTokens+1)
+	require.Equal(t, expectedToken, tokens[builtinTokens])
+	require.False(t, tokens[0].Custom)
+
+	_, err = b.AddCustomERC20Token(tokenContract.Hex())
+	require.Equal(t, errERC20TokenExists, errp.Cause(err))
+
+	// The token is loaded as a sub-account of an Ethereum account like the built-in tokens.
+	ks := makeBitBox02Multi()
+	ks.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	b.registerKeystore(ks)
+	require.NoError(t, b.SetTokenActive("v0-55555555-eth-0", string(code), true))
+	tokenAccount := b.Accounts().lookup(Erc20AccountCode("v0-55555555-eth-0", string(code)))
+	require.NotNil(t, tokenAccount)
+	require.Equal(t, "Uniswap", tokenAccount.Config().Config.Name)
+	require.Equal(t, "UNI", tokenAccount.Coin().Unit(false))
+	require.Equal(t, tokenContract, tokenAccount.Coin().(*eth.Coin).ERC20Token().ContractAddress())
+
+	// Built-in tokens cannot be removed.
+	require.Equal(t, errERC20TokenNotFound, errp.Cause(b.RemoveCustomERC20Token("eth-erc20-usdt")))
+
+	require.NoError(t, b.RemoveCustomERC20Token(code))
+	require.Len(t, b.ERC20Tokens(), builtinTokens)
+	require.Empty(t, b.Config().AccountsConfig().Lookup("v0-55555555-eth-0").ActiveTokens)
+	require.Nil(t, b.Accounts().lookup(Erc20AccountCode("v0-55555555-eth-0", string(code))))
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/exchangeimport"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// receivedTransactions returns the transactions received by the synced accounts, with the
+// addresses of the accounts they paid to.
+func (backend *Backend) receivedTransactions() []exchangeimport.Tx {
+	txs := []exchangeimport.Tx{}
+	for _, account := range backend.Accounts() {
+		if account.FatalError() || !account.Synced() {
+			continue
+		}
+		transactions, err := account.Transactions()
+		if err != nil {
+			continue
+		}
+		for _, tx := range transactions {
+			if tx.Type != accounts.TxTypeReceive {
+				continue
+			}
+			var addresses []string
+			for _, addressAndAmount := range tx.Addresses {
+				if addressAndAmount.Ours {
+					addresses = append(addresses, addressAndAmount.Address)
+				}
+			}
+			txs = append(txs, exchangeimport.Tx{
+				AccountCode: string(account.Config().Config.Code),
+				InternalID:  tx.InternalID,
+				TxID:        tx.TxID,
+				Addresses:   addresses,
+			})
+		}
+	}
+	return txs
+}
+
+// ImportExchangeWithdrawals tags the transactions received from the withdrawals listed in the
+// CSV withdrawal history of an exchange with their origin. Accounts which are not synced are
+// skipped, so importing again after they synced tags their transactions as well.
+func (backend *Backend) ImportExchangeWithdrawals(exchange string, csvContent string) (*exchangeimport.Result, error) {
+	exchange = strings.TrimSpace(exchange)
+	if exchange == "" {
+		return nil, errp.New("the exchange name is required")
+	}
+	withdrawals, err := exchangeimport.Parse(strings.NewReader(csvContent), exchange)
+	if err != nil {
+		return nil, err
+	}
+	matches, result := exchangeimport.MatchWithdrawals(withdrawals, backend.receivedTransactions())
+	for _, match := range matches {
+		account, err := backend.GetAccountFromCode(accountsTypes.Code(match.Tx.AccountCode))
+		if err != nil {
+			return nil, err
+		}
+		err = account.SetTxOrigin(match.Tx.InternalID, &notes.TxOrigin{
+			Exchange: match.Withdrawal.Exchange,
+			OrderID:  match.Withdrawal.OrderID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	backend.log.WithField("result", result).Info("Imported exchange withdrawals")
+	return &result, nil
+}
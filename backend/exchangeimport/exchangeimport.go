@@ -0,0 +1,205 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package exchangeimport parses the withdrawal history exported by exchanges as CSV and matches
+// the withdrawals to the transactions received by the accounts.
+package exchangeimport
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// maxRows limits the number of withdrawals imported from one file.
+const maxRows = 100000
+
+// Withdrawal is one row of a withdrawal history.
+type Withdrawal struct {
+	// Exchange can be empty if the file has no exchange column.
+	Exchange string
+	OrderID  string
+	// TxID and Address are the transaction and the destination of the withdrawal. At least one of
+	// them is set.
+	TxID    string
+	Address string
+}
+
+// columnNames are the known names of the columns, normalized by normalizeColumn(). Exchanges name
+// them differently, e.g. "TxID", "Transaction Hash" or "tx_hash".
+var columnNames = map[string][]string{
+	"txid":     {"txid", "transactionid", "txhash", "hash", "transactionhash", "blockchaintxid"},
+	"address":  {"address", "withdrawaladdress", "destination", "destinationaddress", "toaddress", "recipient"},
+	"orderID":  {"orderid", "id", "withdrawalid", "reference", "refid", "referenceid"},
+	"exchange": {"exchange", "source", "platform"},
+}
+
+// normalizeColumn lowercases the column name and drops everything but letters and digits.
+func normalizeColumn(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// findColumns maps the known columns to their index in the header. Missing columns are -1.
+func findColumns(header []string) map[string]int {
+	columns := map[string]int{}
+	for column, names := range columnNames {
+		columns[column] = -1
+		for _, name := range names {
+			for index, headerName := range header {
+				if normalizeColumn(headerName) == name {
+					columns[column] = index
+					break
+				}
+			}
+			if columns[column] != -1 {
+				break
+			}
+		}
+	}
+	return columns
+}
+
+// Parse reads a CSV withdrawal history with a header row. The columns are recognized by their
+// name. exchange is used for the rows which do not name the exchange themselves. Rows with neither
+// a transaction ID nor an address are skipped.
+func Parse(r io.Reader, exchange string) ([]Withdrawal, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errp.New("the file is empty")
+	}
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	columns := findColumns(header)
+	if columns["txid"] == -1 && columns["address"] == -1 {
+		return nil, errp.New("the file has neither a transaction ID nor an address column")
+	}
+	field := func(record []string, column string) string {
+		index := columns[column]
+		if index == -1 || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+
+	withdrawals := []Withdrawal{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		if len(withdrawals) == maxRows {
+			return nil, errp.Newf("the file has more than %d withdrawals", maxRows)
+		}
+		withdrawal := Withdrawal{
+			Exchange: field(record, "exchange"),
+			OrderID:  field(record, "orderID"),
+			TxID:     field(record, "txid"),
+			Address:  field(record, "address"),
+		}
+		if withdrawal.Exchange == "" {
+			withdrawal.Exchange = exchange
+		}
+		if withdrawal.TxID == "" && withdrawal.Address == "" {
+			continue
+		}
+		withdrawals = append(withdrawals, withdrawal)
+	}
+	return withdrawals, nil
+}
+
+// Tx is a transaction received by an account.
+type Tx struct {
+	AccountCode string
+	InternalID  string
+	TxID        string
+	// Addresses are the addresses of the account the transaction paid to.
+	Addresses []string
+}
+
+// Match is a withdrawal matched to the transaction received from it.
+type Match struct {
+	Withdrawal Withdrawal
+	Tx         Tx
+}
+
+// Result counts how many withdrawals could be matched.
+type Result struct {
+	Matched   int `json:"matched"`
+	Unmatched int `json:"unmatched"`
+	// Ambiguous counts the withdrawals without transaction ID to an address which received more
+	// than one transaction, which cannot be told apart.
+	Ambiguous int `json:"ambiguous"`
+}
+
+// normalizeTxID makes transaction IDs comparable, e.g. Ethereum tx hashes with and without the 0x
+// prefix.
+func normalizeTxID(txID string) string {
+	return strings.TrimPrefix(strings.ToLower(txID), "0x")
+}
+
+// MatchWithdrawals matches the withdrawals to the transactions, by transaction ID if the withdrawal
+// has one, and otherwise by the address if exactly one transaction was received on it.
+func MatchWithdrawals(withdrawals []Withdrawal, txs []Tx) ([]Match, Result) {
+	byTxID := map[string]Tx{}
+	byAddress := map[string][]Tx{}
+	for _, tx := range txs {
+		byTxID[normalizeTxID(tx.TxID)] = tx
+		for _, address := range tx.Addresses {
+			key := strings.ToLower(address)
+			byAddress[key] = append(byAddress[key], tx)
+		}
+	}
+
+	matches := []Match{}
+	var result Result
+	for _, withdrawal := range withdrawals {
+		if withdrawal.TxID != "" {
+			if tx, ok := byTxID[normalizeTxID(withdrawal.TxID)]; ok {
+				matches = append(matches, Match{Withdrawal: withdrawal, Tx: tx})
+				result.Matched++
+				continue
+			}
+		}
+		if withdrawal.TxID == "" && withdrawal.Address != "" {
+			candidates := byAddress[strings.ToLower(withdrawal.Address)]
+			switch len(candidates) {
+			case 0:
+			case 1:
+				matches = append(matches, Match{Withdrawal: withdrawal, Tx: candidates[0]})
+				result.Matched++
+				continue
+			default:
+				result.Ambiguous++
+				continue
+			}
+		}
+		result.Unmatched++
+	}
+	return matches, result
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exchangeimport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	withdrawals, err := Parse(strings.NewReader(`Date,Withdrawal ID,Amount,Destination Address,Tx_Hash
+2024-01-01,W-1,0.1,bc1qaddress1,AABB
+2024-01-02,W-2,0.2,bc1qaddress2,
+2024-01-03,W-3,0.3,,
+`), "Kraken")
+	require.NoError(t, err)
+	require.Equal(t, []Withdrawal{
+		{Exchange: "Kraken", OrderID: "W-1", TxID: "AABB", Address: "bc1qaddress1"},
+		{Exchange: "Kraken", OrderID: "W-2", Address: "bc1qaddress2"},
+	}, withdrawals)
+
+	// The exchange column takes precedence.
+	withdrawals, err = Parse(strings.NewReader("txid,exchange\naabb,Bitstamp\n"), "Kraken")
+	require.NoError(t, err)
+	require.Equal(t, []Withdrawal{{Exchange: "Bitstamp", TxID: "aabb"}}, withdrawals)
+
+	_, err = Parse(strings.NewReader("Date,Amount\n2024-01-01,0.1\n"), "Kraken")
+	require.Error(t, err)
+	_, err = Parse(strings.NewReader(""), "Kraken")
+	require.Error(t, err)
+}
+
+func TestMatchWithdrawals(t *testing.T) {
+	txs := []Tx{
+		{AccountCode: "btc", InternalID: "tx1", TxID: "aabb", Addresses: []string{"bc1qaddress1"}},
+		{AccountCode: "btc", InternalID: "tx2", TxID: "ccdd", Addresses: []string{"bc1qaddress2"}},
+		{AccountCode: "btc", InternalID: "tx3", TxID: "eeff", Addresses: []string{"bc1qaddress3"}},
+		{AccountCode: "btc", InternalID: "tx4", TxID: "0011", Addresses: []string{"bc1qaddress3"}},
+		{AccountCode: "eth", InternalID: "0x2233", TxID: "0x2233", Addresses: []string{"0xAbC"}},
+	}
+	withdrawals := []Withdrawal{
+		{Exchange: "Kraken", OrderID: "W-1", TxID: "AABB"},
+		{Exchange: "Kraken", OrderID: "W-2", Address: "bc1qaddress2"},
+		{Exchange: "Kraken", OrderID: "W-3", Address: "bc1qaddress3"},
+		{Exchange: "Kraken", OrderID: "W-4", TxID: "2233"},
+		{Exchange: "Kraken", OrderID: "W-5", TxID: "9999", Address: "bc1qaddress2"},
+		{Exchange: "Kraken", OrderID: "W-6", Address: "0xabc"},
+	}
+	matches, result := MatchWithdrawals(withdrawals, txs)
+	require.Equal(t, Result{Matched: 4, Unmatched: 1, Ambiguous: 1}, result)
+	matched := map[string]string{}
+	for _, match := range matches {
+		matched[match.Withdrawal.OrderID] = match.Tx.InternalID
+	}
+	require.Equal(t, map[string]string{
+		"W-1": "tx1",
+		"W-2": "tx2",
+		"W-4": "0x2233",
+		"W-6": "0x2233",
+	}, matched)
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/extensions"
+)
+
+// portfolioAccount is an account as exposed to extensions with the portfolio permission and to
+// paired companion apps.
+type portfolioAccount struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	CoinCode string `json:"coinCode"`
+	Unit     string `json:"unit"`
+	// Balance is the available balance, formatted in the unit of the coin.
+	Balance string `json:"balance"`
+	// Incoming is the unconfirmed incoming balance, formatted in the unit of the coin.
+	Incoming string `json:"incoming"`
+}
+
+// portfolioBalances returns the balances of the active accounts which finished syncing.
+func (backend *Backend) portfolioBalances() (interface{}, error) {
+	result := []portfolioAccount{}
+	for _, account := range backend.Accounts() {
+		accountConfig := account.Config().Config
+		if accountConfig.Inactive || accountConfig.HiddenBecauseUnused || account.FatalError() {
+			continue
+		}
+		balance, err := account.Balance()
+		if err != nil {
+			continue
+		}
+		coin := account.Coin()
+		result = append(result, portfolioAccount{
+			Code:     string(accountConfig.Code),
+			Name:     accountConfig.Name,
+			CoinCode: string(coin.Code()),
+			Unit:     coin.GetFormatUnit(false),
+			Balance:  coin.FormatAmount(balance.Available(), false),
+			Incoming: coin.FormatAmount(balance.Incoming(), false),
+		})
+	}
+	return result, nil
+}
+
+// Extensions returns the server of the extension API and the registry of extensions.
+func (backend *Backend) Extensions() *extensions.Server {
+	return backend.extensions
+}
+
+// SetExtensionsEnabled persists the setting and starts or stops the extension API accordingly.
+func (backend *Backend) SetExtensionsEnabled(enabled bool) error {
+	err := backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		appConfig.Backend.ExtensionsEnabled = enabled
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if enabled {
+		return backend.extensions.Start()
+	}
+	return backend.extensions.Stop()
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extensions
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/httpapi"
+	"github.com/gorilla/mux"
+)
+
+// apiVersion is the version of the extension API, reported by the /api/info endpoint.
+const apiVersion = "1.0.0"
+
+// maxRequestSize limits the size of request bodies.
+const maxRequestSize = 16384
+
+const (
+	// maxWidgetLines limits the number of lines of a widget.
+	maxWidgetLines = 10
+	// maxWidgetTextLength limits the length of the widget title and of each label and value.
+	maxWidgetTextLength = 100
+)
+
+func (server *Server) router() http.Handler {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/info", server.handle(server.getInfo, "")).Methods("GET")
+	router.HandleFunc("/api/v1/portfolio",
+		server.handle(server.getPortfolioHandler, PermissionPortfolioRead)).Methods("GET")
+	router.HandleFunc("/api/v1/rates",
+		server.handle(server.getRatesHandler, PermissionRatesRead)).Methods("GET")
+	router.HandleFunc("/api/v1/widget",
+		server.handle(server.putWidget, PermissionWidgetsWrite)).Methods("PUT")
+	return router
+}
+
+// handle wraps an API handler. If permission is not empty, the request must carry the token of an
+// extension which was granted the permission in the "Authorization: Bearer <token>" header.
+// Browsers are not supported on purpose: no CORS headers are sent, so websites can't use the API.
+func (server *Server) handle(
+	f func(*Extension, *http.Request) (interface{}, error),
+	permission Permission,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !httpapi.CheckLocalRequest(w, r, false) {
+			return
+		}
+		var extension *Extension
+		if permission != "" {
+			extension = server.authenticate(
+				strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+			if extension == nil {
+				httpapi.WriteError(w, http.StatusUnauthorized, "invalid token")
+				return
+			}
+			if !extension.Allowed(permission) {
+				httpapi.WriteError(w, http.StatusForbidden, "permission not granted")
+				return
+			}
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+		value, err := f(extension, r)
+		if err != nil {
+			log := server.log.WithError(err)
+			if extension != nil {
+				log = log.WithField("id", extension.ID)
+			}
+			log.Info("extension request failed")
+			httpapi.WriteError(w, httpapi.ErrorStatus(err), err.Error())
+			return
+		}
+		httpapi.WriteJSON(w, http.StatusOK, value)
+	}
+}
+
+func (server *Server) getInfo(*Extension, *http.Request) (interface{}, error) {
+	return map[string]string{"version": apiVersion}, nil
+}
+
+func (server *Server) getPortfolioHandler(*Extension, *http.Request) (interface{}, error) {
+	return server.getPortfolio()
+}
+
+func (server *Server) getRatesHandler(*Extension, *http.Request) (interface{}, error) {
+	return server.getRates(), nil
+}
+
+func (server *Server) putWidget(extension *Extension, r *http.Request) (interface{}, error) {
+	var request struct {
+		Title string       `json:"title"`
+		Lines []WidgetLine `json:"lines"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, httpapi.NewRequestError(http.StatusBadRequest, "invalid request")
+	}
+	if request.Title == "" || len(request.Title) > maxWidgetTextLength {
+		return nil, httpapi.NewRequestError(http.StatusBadRequest, "invalid title")
+	}
+	if len(request.Lines) > maxWidgetLines {
+		return nil, httpapi.NewRequestError(http.StatusBadRequest, "too many lines")
+	}
+	for _, line := range request.Lines {
+		if len(line.Label) > maxWidgetTextLength || len(line.Value) > maxWidgetTextLength {
+			return nil, httpapi.NewRequestError(http.StatusBadRequest, "line too long")
+		}
+	}
+	if request.Lines == nil {
+		request.Lines = []WidgetLine{}
+	}
+	server.setWidget(extension, request.Title, request.Lines)
+	return map[string]bool{"success": true}, nil
+}
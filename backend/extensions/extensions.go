@@ -0,0 +1,333 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package extensions implements an opt-in local HTTP API through which third-party apps can
+// integrate with the BitBoxApp without patching the backend, e.g. to show a portfolio widget in the
+// app. Extensions run in their own process and talk JSON to the API over the loopback interface.
+// Every extension is registered by the user in the app, which issues a token scoped to the
+// permissions the user granted. The token is only shown once and only its hash is persisted.
+package extensions
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultAddress is the address the extension API listens on. The HWW bridge uses port 8179, so we
+// use the next one.
+const DefaultAddress = "127.0.0.1:8180"
+
+// Permission is a scope an extension can be granted.
+type Permission string
+
+const (
+	// PermissionPortfolioRead allows reading the accounts and their balances.
+	PermissionPortfolioRead Permission = "portfolio:read"
+	// PermissionRatesRead allows reading the latest exchange rates.
+	PermissionRatesRead Permission = "rates:read"
+	// PermissionWidgetsWrite allows publishing a widget which is shown in the app.
+	PermissionWidgetsWrite Permission = "widgets:write"
+)
+
+var knownPermissions = map[Permission]struct{}{
+	PermissionPortfolioRead: {},
+	PermissionRatesRead:     {},
+	PermissionWidgetsWrite:  {},
+}
+
+// ErrUnknownExtension is returned when referring to an extension which is not registered.
+var ErrUnknownExtension = errp.New("unknown extension")
+
+// Extension is a registered extension.
+type Extension struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	// TokenHash is the hex encoded SHA256 hash of the token issued at registration.
+	TokenHash string `json:"tokenHash"`
+}
+
+// Allowed returns true if the extension was granted the permission.
+func (extension *Extension) Allowed(permission Permission) bool {
+	for _, granted := range extension.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// WidgetLine is one label/value pair shown in a widget.
+type WidgetLine struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// Widget is published by an extension and shown in the app. Every extension can publish one widget,
+// which replaces the previous one.
+type Widget struct {
+	ExtensionID   string       `json:"extensionId"`
+	ExtensionName string       `json:"extensionName"`
+	Title         string       `json:"title"`
+	Lines         []WidgetLine `json:"lines"`
+	UpdatedAt     time.Time    `json:"updatedAt"`
+}
+
+// Status describes the current state of the extension API.
+type Status struct {
+	Running    bool         `json:"running"`
+	Address    string       `json:"address"`
+	Extensions []*Extension `json:"extensions"`
+}
+
+// Server serves the extension API and keeps the registry of extensions. The zero value is not
+// usable, use NewServer().
+type Server struct {
+	observable.Implementation
+
+	address      string
+	file         *config.File
+	getPortfolio func() (interface{}, error)
+	getRates     func() map[string]map[string]float64
+
+	extensions []*Extension
+	// widgets are kept in memory only, extensions publish them again after a restart.
+	widgets map[string]*Widget
+	server  *http.Server
+	mu      locker.Locker
+
+	log *logrus.Entry
+}
+
+// NewServer creates a new server listening on the given address once started. The registry of
+// extensions is persisted in the given file. getPortfolio returns the accounts and balances and
+// getRates the latest exchange rates, which are exposed to extensions with the respective
+// permission.
+func NewServer(
+	address string,
+	filename string,
+	getPortfolio func() (interface{}, error),
+	getRates func() map[string]map[string]float64,
+) *Server {
+	server := &Server{
+		address:      address,
+		file:         config.NewFile(filepath.Dir(filename), filepath.Base(filename)),
+		getPortfolio: getPortfolio,
+		getRates:     getRates,
+		extensions:   []*Extension{},
+		widgets:      map[string]*Widget{},
+		log:          logging.Get().WithGroup("extensions"),
+	}
+	if server.file.Exists() {
+		if err := server.file.ReadJSON(&server.extensions); err != nil {
+			server.log.WithError(err).Error("Could not load the extensions")
+			server.extensions = []*Extension{}
+		}
+	}
+	return server
+}
+
+func (server *Server) notify(subject string) {
+	server.Notify(observable.Event{
+		Subject: subject,
+		Action:  action.Reload,
+	})
+}
+
+// Start starts listening for requests. It is a no-op if the server is already running.
+func (server *Server) Start() error {
+	defer server.mu.Lock()()
+	if server.server != nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", server.address)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	server.server = &http.Server{
+		Handler:           server.router(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func(httpServer *http.Server) {
+		if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			server.log.WithError(err).Error("extension server stopped")
+		}
+	}(server.server)
+	server.log.Infof("extension API listening on %s", server.address)
+	go server.notify("extensions/status")
+	return nil
+}
+
+// Stop stops the server. Registered extensions are kept.
+func (server *Server) Stop() error {
+	defer server.mu.Lock()()
+	if server.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := server.server.Shutdown(ctx)
+	server.server = nil
+	go server.notify("extensions/status")
+	return errp.WithStack(err)
+}
+
+// Status returns the current state of the server and the registered extensions.
+func (server *Server) Status() Status {
+	defer server.mu.RLock()()
+	return Status{
+		Running:    server.server != nil,
+		Address:    server.address,
+		Extensions: append([]*Extension{}, server.extensions...),
+	}
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func randomHex(numBytes int) (string, error) {
+	value := make([]byte, numBytes)
+	if _, err := rand.Read(value); err != nil {
+		return "", errp.WithStack(err)
+	}
+	return hex.EncodeToString(value), nil
+}
+
+// Register registers a new extension with the given permissions and returns it together with its
+// token. The token is not stored and can't be retrieved later.
+func (server *Server) Register(name string, permissions []Permission) (*Extension, string, error) {
+	if name == "" {
+		return nil, "", errp.New("missing extension name")
+	}
+	granted := []Permission{}
+	seen := map[Permission]struct{}{}
+	for _, permission := range permissions {
+		if _, ok := knownPermissions[permission]; !ok {
+			return nil, "", errp.Newf("unknown permission %q", permission)
+		}
+		if _, ok := seen[permission]; ok {
+			continue
+		}
+		seen[permission] = struct{}{}
+		granted = append(granted, permission)
+	}
+	sort.Slice(granted, func(i, j int) bool { return granted[i] < granted[j] })
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	extension := &Extension{
+		ID:          id,
+		Name:        name,
+		Permissions: granted,
+		CreatedAt:   time.Now(),
+		TokenHash:   hashToken(token),
+	}
+	defer server.mu.Lock()()
+	server.extensions = append(server.extensions, extension)
+	if err := server.file.WriteJSON(server.extensions); err != nil {
+		server.extensions = server.extensions[:len(server.extensions)-1]
+		return nil, "", err
+	}
+	server.log.WithField("id", id).WithField("permissions", granted).Info("extension registered")
+	go server.notify("extensions/status")
+	return extension, token, nil
+}
+
+// Revoke unregisters the extension, invalidating its token and removing its widget.
+func (server *Server) Revoke(id string) error {
+	defer server.mu.Lock()()
+	extensions := []*Extension{}
+	for _, extension := range server.extensions {
+		if extension.ID != id {
+			extensions = append(extensions, extension)
+		}
+	}
+	if len(extensions) == len(server.extensions) {
+		return errp.WithStack(ErrUnknownExtension)
+	}
+	if err := server.file.WriteJSON(extensions); err != nil {
+		return err
+	}
+	server.extensions = extensions
+	delete(server.widgets, id)
+	server.log.WithField("id", id).Info("extension revoked")
+	go server.notify("extensions/status")
+	go server.notify("extensions/widgets")
+	return nil
+}
+
+// authenticate returns the extension the token was issued to, or nil if there is none.
+func (server *Server) authenticate(token string) *Extension {
+	if token == "" {
+		return nil
+	}
+	hash := []byte(hashToken(token))
+	defer server.mu.RLock()()
+	for _, extension := range server.extensions {
+		if subtle.ConstantTimeCompare(hash, []byte(extension.TokenHash)) == 1 {
+			return extension
+		}
+	}
+	return nil
+}
+
+// setWidget publishes or replaces the widget of the extension.
+func (server *Server) setWidget(extension *Extension, title string, lines []WidgetLine) {
+	defer server.mu.Lock()()
+	server.widgets[extension.ID] = &Widget{
+		ExtensionID:   extension.ID,
+		ExtensionName: extension.Name,
+		Title:         title,
+		Lines:         lines,
+		UpdatedAt:     time.Now(),
+	}
+	go server.notify("extensions/widgets")
+}
+
+// Widgets returns the widgets published by the registered extensions, sorted by extension name.
+func (server *Server) Widgets() []*Widget {
+	defer server.mu.RLock()()
+	widgets := []*Widget{}
+	for _, widget := range server.widgets {
+		widgets = append(widgets, widget)
+	}
+	sort.Slice(widgets, func(i, j int) bool {
+		return widgets[i].ExtensionName < widgets[j].ExtensionName
+	})
+	return widgets
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extensions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	return NewServer(
+		"127.0.0.1:0",
+		filepath.Join(test.TstTempDir("extensions"), "extensions.json"),
+		func() (interface{}, error) { return map[string]string{"btc": "1.5"}, nil },
+		func() map[string]map[string]float64 {
+			return map[string]map[string]float64{"BTC": {"USD": 50000}}
+		},
+	)
+}
+
+func doRequest(server *Server, method, path, token string, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, "http://127.0.0.1:8180"+path, strings.NewReader(body))
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	server.router().ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestRegister(t *testing.T) {
+	server := newTestServer(t)
+	_, _, err := server.Register("", nil)
+	require.Error(t, err)
+	_, _, err = server.Register("Tracker", []Permission{"accounts:send"})
+	require.Error(t, err)
+
+	extension, token, err := server.Register("Tracker",
+		[]Permission{PermissionRatesRead, PermissionPortfolioRead, PermissionRatesRead})
+	require.NoError(t, err)
+	require.Len(t, token, 64)
+	require.Equal(t, []Permission{PermissionPortfolioRead, PermissionRatesRead}, extension.Permissions)
+	require.NotContains(t, extension.TokenHash, token)
+	require.Equal(t, extension, server.authenticate(token))
+	require.Nil(t, server.authenticate("invalid"))
+
+	// The registry is persisted.
+	reloaded := NewServer("127.0.0.1:0", server.file.Path(), nil, nil)
+	require.Len(t, reloaded.Status().Extensions, 1)
+	require.Equal(t, extension.ID, reloaded.authenticate(token).ID)
+
+	require.NoError(t, server.Revoke(extension.ID))
+	require.Nil(t, server.authenticate(token))
+	require.Empty(t, server.Status().Extensions)
+	require.ErrorIs(t, server.Revoke(extension.ID), ErrUnknownExtension)
+}
+
+func TestPermissions(t *testing.T) {
+	server := newTestServer(t)
+	_, token, err := server.Register("Rates", []Permission{PermissionRatesRead})
+	require.NoError(t, err)
+
+	response := doRequest(server, "GET", "/api/info", "", "")
+	require.Equal(t, http.StatusOK, response.Code)
+	require.JSONEq(t, `{"version":"1.0.0"}`, response.Body.String())
+
+	response = doRequest(server, "GET", "/api/v1/rates", "", "")
+	require.Equal(t, http.StatusUnauthorized, response.Code)
+
+	response = doRequest(server, "GET", "/api/v1/rates", token, "")
+	require.Equal(t, http.StatusOK, response.Code)
+	require.JSONEq(t, `{"BTC":{"USD":50000}}`, response.Body.String())
+
+	response = doRequest(server, "GET", "/api/v1/portfolio", token, "")
+	require.Equal(t, http.StatusForbidden, response.Code)
+
+	// Requests of websites and to a non-local host are rejected.
+	request := httptest.NewRequest("GET", "http://127.0.0.1:8180/api/v1/rates", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Origin", "https://example.com")
+	recorder := httptest.NewRecorder()
+	server.router().ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	request = httptest.NewRequest("GET", "http://attacker.example.com/api/v1/rates", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	recorder = httptest.NewRecorder()
+	server.router().ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestWidget(t *testing.T) {
+	server := newTestServer(t)
+	extension, token, err := server.Register("Tracker", []Permission{PermissionWidgetsWrite})
+	require.NoError(t, err)
+
+	response := doRequest(server, "PUT", "/api/v1/widget", token, `{"title":""}`)
+	require.Equal(t, http.StatusBadRequest, response.Code)
+
+	response = doRequest(server, "PUT", "/api/v1/widget", token,
+		`{"title":"Staking","lines":[{"label":"Rewards","value":"0.1 ETH"}]}`)
+	require.Equal(t, http.StatusOK, response.Code)
+	widgets := server.Widgets()
+	require.Len(t, widgets, 1)
+	require.Equal(t, "Tracker", widgets[0].ExtensionName)
+	require.Equal(t, "Staking", widgets[0].Title)
+	require.Equal(t, []WidgetLine{{Label: "Rewards", Value: "0.1 ETH"}}, widgets[0].Lines)
+
+	// Revoking the extension removes its widget.
+	require.NoError(t, server.Revoke(extension.ID))
+	require.Empty(t, server.Widgets())
+}
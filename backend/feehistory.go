@@ -0,0 +1,65 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/feehistory"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// feeHistoryCoins returns the codes of the Bitcoin-based coins of the loaded accounts. Other coins
+// are not recorded, so that no connection is opened only to record the fees.
+func (backend *Backend) feeHistoryCoins() []string {
+	seen := map[coinpkg.Code]bool{}
+	coinCodes := []string{}
+	for _, account := range backend.Accounts() {
+		if _, ok := account.Coin().(*btc.Coin); !ok {
+			continue
+		}
+		code := account.Coin().Code()
+		if !seen[code] {
+			seen[code] = true
+			coinCodes = append(coinCodes, string(code))
+		}
+	}
+	return coinCodes
+}
+
+// feeHistoryEstimate returns the fee rate in sat/kvB to confirm within the given number of blocks.
+func (backend *Backend) feeHistoryEstimate(coinCode string, blocks int) (int64, error) {
+	coin, err := backend.Coin(coinpkg.Code(coinCode))
+	if err != nil {
+		return 0, err
+	}
+	btcCoin, ok := coin.(*btc.Coin)
+	if !ok {
+		return 0, errp.Newf("unsupported coin %s", coinCode)
+	}
+	// The fee of 1000 vbytes is the fee rate per kvB.
+	feeRatePerKb, err := btcCoin.EstimateFee(1000, blocks)
+	if err != nil {
+		return 0, err
+	}
+	return int64(feeRatePerKb), nil
+}
+
+// FeeHistory returns the fee rates of the coin recorded in the last days.
+func (backend *Backend) FeeHistory(code coinpkg.Code, days int) (*feehistory.Series, error) {
+	return backend.feeHistory.Series(string(code), time.Duration(days)*24*time.Hour)
+}
@@ -0,0 +1,265 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package feehistory records snapshots of the fee estimates, so that the current fees can be
+// compared to the recent past.
+package feehistory
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	utilConfig "github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// recordInterval is the time between two snapshots.
+	recordInterval = 30 * time.Minute
+	// firstRecordDelay gives the accounts time to connect before the first snapshot after startup.
+	firstRecordDelay = time.Minute
+	// retention is how long snapshots are kept.
+	retention = 30 * 24 * time.Hour
+	// maxSnapshots limits the number of snapshots kept per coin, in case the clock jumps.
+	maxSnapshots = int(retention / recordInterval)
+)
+
+// Targets are the confirmation targets in blocks for which the fee rates are recorded.
+var Targets = []int{2, 6, 24}
+
+// summaryTarget is the confirmation target of the summary of the series.
+const summaryTarget = 6
+
+// Snapshot are the fee rates at one point in time.
+type Snapshot struct {
+	// Time is a unix timestamp in seconds.
+	Time int64 `json:"time"`
+	// FeeRates are in sat/kvB, keyed by the confirmation target in blocks. Targets which could not
+	// be estimated are missing.
+	FeeRates map[int]int64 `json:"feeRates"`
+}
+
+// Summary compares the latest fee rate to the recorded history.
+type Summary struct {
+	// Target is the confirmation target in blocks the summary is about.
+	Target  int   `json:"target"`
+	Current int64 `json:"current"`
+	Min     int64 `json:"min"`
+	Median  int64 `json:"median"`
+	Max     int64 `json:"max"`
+	// Percentile is the share of snapshots with a lower fee rate than the current one, from 0 to
+	// 100. A low value means that now is a cheap time to transact.
+	Percentile int `json:"percentile"`
+}
+
+// Series are the snapshots of one coin, oldest first.
+type Series struct {
+	Snapshots []Snapshot `json:"snapshots"`
+	// Summary is nil if there are no snapshots.
+	Summary *Summary `json:"summary"`
+}
+
+// Recorder periodically records the fee rates of the coins. The zero value is not usable, use
+// NewRecorder().
+type Recorder struct {
+	observable.Implementation
+
+	file *utilConfig.File
+	// coins returns the codes of the coins to record.
+	coins func() []string
+	// estimate returns the fee rate in sat/kvB of the coin for the given confirmation target.
+	estimate func(coinCode string, blocks int) (int64, error)
+	now      func() time.Time
+
+	snapshots map[string][]Snapshot
+	mu        locker.Locker
+	quit      chan struct{}
+
+	log *logrus.Entry
+}
+
+// NewRecorder creates a recorder which persists the snapshots in the given file. The snapshots
+// stored by a previous run are loaded.
+func NewRecorder(
+	filename string,
+	coins func() []string,
+	estimate func(coinCode string, blocks int) (int64, error),
+) *Recorder {
+	recorder := &Recorder{
+		file:      utilConfig.NewFile(filepath.Dir(filename), filepath.Base(filename)),
+		coins:     coins,
+		estimate:  estimate,
+		now:       time.Now,
+		snapshots: map[string][]Snapshot{},
+		log:       logging.Get().WithGroup("feehistory"),
+	}
+	if recorder.file.Exists() {
+		if err := recorder.file.ReadJSON(&recorder.snapshots); err != nil {
+			recorder.log.WithError(err).Error("Could not load the fee history")
+			recorder.snapshots = map[string][]Snapshot{}
+		}
+	}
+	return recorder
+}
+
+// Start periodically records the fee rates until Stop() is called.
+func (recorder *Recorder) Start() {
+	unlock := recorder.mu.Lock()
+	if recorder.quit != nil {
+		unlock()
+		return
+	}
+	quit := make(chan struct{})
+	recorder.quit = quit
+	unlock()
+
+	go func() {
+		timer := time.NewTimer(firstRecordDelay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-timer.C:
+				recorder.Record()
+				timer.Reset(recordInterval)
+			case <-quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic recording.
+func (recorder *Recorder) Stop() {
+	defer recorder.mu.Lock()()
+	if recorder.quit != nil {
+		close(recorder.quit)
+		recorder.quit = nil
+	}
+}
+
+// Record takes a snapshot of the fee rates of all coins now and drops the expired snapshots. Coins
+// with a snapshot younger than half the record interval are skipped, so that restarting the app
+// does not skew the history.
+func (recorder *Recorder) Record() {
+	now := recorder.now()
+	newSnapshots := map[string]Snapshot{}
+	for _, coinCode := range recorder.coins() {
+		if !recorder.due(coinCode, now) {
+			continue
+		}
+		snapshot := Snapshot{Time: now.Unix(), FeeRates: map[int]int64{}}
+		for _, blocks := range Targets {
+			feeRate, err := recorder.estimate(coinCode, blocks)
+			if err != nil {
+				recorder.log.WithError(err).WithField("coin", coinCode).Debug("Could not estimate the fee")
+				continue
+			}
+			snapshot.FeeRates[blocks] = feeRate
+		}
+		if len(snapshot.FeeRates) > 0 {
+			newSnapshots[coinCode] = snapshot
+		}
+	}
+
+	unlock := recorder.mu.Lock()
+	for coinCode, snapshot := range newSnapshots {
+		recorder.snapshots[coinCode] = append(recorder.snapshots[coinCode], snapshot)
+	}
+	recorder.prune(now)
+	if err := recorder.file.WriteJSON(recorder.snapshots); err != nil {
+		recorder.log.WithError(err).Error("Could not persist the fee history")
+	}
+	unlock()
+
+	recorder.Notify(observable.Event{
+		Subject: "fee-history",
+		Action:  action.Reload,
+	})
+}
+
+func (recorder *Recorder) due(coinCode string, now time.Time) bool {
+	defer recorder.mu.RLock()()
+	snapshots := recorder.snapshots[coinCode]
+	if len(snapshots) == 0 {
+		return true
+	}
+	return now.Sub(time.Unix(snapshots[len(snapshots)-1].Time, 0)) >= recordInterval/2
+}
+
+// prune drops the snapshots older than the retention and beyond the max number of snapshots. Must
+// be called with the lock held.
+func (recorder *Recorder) prune(now time.Time) {
+	cutoff := now.Add(-retention).Unix()
+	for coinCode, snapshots := range recorder.snapshots {
+		first := sort.Search(len(snapshots), func(index int) bool {
+			return snapshots[index].Time >= cutoff
+		})
+		if len(snapshots)-first > maxSnapshots {
+			first = len(snapshots) - maxSnapshots
+		}
+		if first == len(snapshots) {
+			delete(recorder.snapshots, coinCode)
+			continue
+		}
+		recorder.snapshots[coinCode] = append([]Snapshot(nil), snapshots[first:]...)
+	}
+}
+
+// Series returns the snapshots of the coin recorded in the given duration until now.
+func (recorder *Recorder) Series(coinCode string, duration time.Duration) (*Series, error) {
+	if duration <= 0 || duration > retention {
+		return nil, errp.Newf("the duration must be between 0 and %s", retention)
+	}
+	cutoff := recorder.now().Add(-duration).Unix()
+	defer recorder.mu.RLock()()
+	series := &Series{Snapshots: []Snapshot{}}
+	for _, snapshot := range recorder.snapshots[coinCode] {
+		if snapshot.Time >= cutoff {
+			series.Snapshots = append(series.Snapshots, snapshot)
+		}
+	}
+	series.Summary = summarize(series.Snapshots, summaryTarget)
+	return series, nil
+}
+
+// summarize compares the latest fee rate of the target to the other snapshots. It returns nil if
+// the target was never estimated.
+func summarize(snapshots []Snapshot, target int) *Summary {
+	var feeRates []int64
+	for _, snapshot := range snapshots {
+		if feeRate, ok := snapshot.FeeRates[target]; ok {
+			feeRates = append(feeRates, feeRate)
+		}
+	}
+	if len(feeRates) == 0 {
+		return nil
+	}
+	current := feeRates[len(feeRates)-1]
+	sort.Slice(feeRates, func(i, j int) bool { return feeRates[i] < feeRates[j] })
+	lower := sort.Search(len(feeRates), func(index int) bool { return feeRates[index] >= current })
+	return &Summary{
+		Target:     target,
+		Current:    current,
+		Min:        feeRates[0],
+		Median:     feeRates[len(feeRates)/2],
+		Max:        feeRates[len(feeRates)-1],
+		Percentile: lower * 100 / len(feeRates),
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feehistory
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "fee-history.json")
+	now := time.Unix(1700000000, 0)
+	feeRate := int64(0)
+	newRecorder := func() *Recorder {
+		recorder := NewRecorder(
+			filename,
+			func() []string { return []string{"btc", "ltc"} },
+			func(coinCode string, blocks int) (int64, error) {
+				if coinCode == "ltc" {
+					return 0, errp.New("no estimate")
+				}
+				return feeRate * int64(24/blocks), nil
+			},
+		)
+		recorder.now = func() time.Time { return now }
+		return recorder
+	}
+	recorder := newRecorder()
+
+	for _, rate := range []int64{3000, 1000, 5000, 2000, 4000} {
+		feeRate = rate
+		recorder.Record()
+		// Recording again right away is skipped.
+		recorder.Record()
+		now = now.Add(recordInterval)
+	}
+
+	series, err := recorder.Series("btc", 24*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, series.Snapshots, 5)
+	require.Equal(t, map[int]int64{2: 48000, 6: 16000, 24: 4000}, series.Snapshots[4].FeeRates)
+	require.Equal(t, &Summary{
+		Target:     6,
+		Current:    16000,
+		Min:        4000,
+		Median:     12000,
+		Max:        20000,
+		Percentile: 60,
+	}, series.Summary)
+
+	series, err = recorder.Series("ltc", 24*time.Hour)
+	require.NoError(t, err)
+	require.Empty(t, series.Snapshots)
+	require.Nil(t, series.Summary)
+
+	_, err = recorder.Series("btc", 2*retention)
+	require.Error(t, err)
+
+	// The snapshots are persisted.
+	recorder = newRecorder()
+	series, err = recorder.Series("btc", time.Hour)
+	require.NoError(t, err)
+	require.Len(t, series.Snapshots, 2)
+
+	// Expired snapshots are dropped.
+	now = now.Add(retention - 2*recordInterval)
+	feeRate = 1000
+	recorder.Record()
+	series, err = recorder.Series("btc", retention)
+	require.NoError(t, err)
+	require.Len(t, series.Snapshots, 3)
+}
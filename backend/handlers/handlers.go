@@ -24,18 +24,27 @@ import (
 	"math/big"
 	"net/http"
 	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
 	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/apipairing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/bandwidth"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/banners"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/bitsurance"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/buildinfo"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
 	accountHandlers "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/handlers"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/message"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/util"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/companion"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bitbox"
 	bitboxHandlers "github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bitbox/handlers"
@@ -44,9 +53,19 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bitbox02bootloader"
 	bitbox02bootloaderHandlers "github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bitbox02bootloader/handlers"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/device"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/diagnose"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/exchangeimport"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/exchanges"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/extensions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/feehistory"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/hwwbridge"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/ledger"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/metadatasync"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/scheduledexport"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/sshtunnel"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/taxreport"
 	utilConfig "github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/jsonp"
@@ -73,11 +92,13 @@ type Backend interface {
 	AccountsByKeystore() (backend.KeystoresAccountsListMap, error)
 	Keystore() keystore.Keystore
 	AccountsTotalBalanceByKeystore() (map[string]backend.KeystoreTotalAmount, error)
+	AccountsTotalBalance() (*backend.KeystoreTotalAmount, error)
 	OnAccountInit(f func(accounts.Interface))
 	OnAccountUninit(f func(accounts.Interface))
 	OnDeviceInit(f func(device.Interface))
 	OnDeviceUninit(f func(deviceID string))
 	DevicesRegistered() map[string]device.Interface
+	RescanDevices()
 	Start() <-chan interface{}
 	DeregisterKeystore()
 	Register(device device.Interface) error
@@ -85,21 +106,51 @@ type Backend interface {
 	RatesUpdater() *rates.RateUpdater
 	DownloadCert(string) (string, error)
 	CheckElectrumServer(*config.ServerInfo) error
+	SSHTunnelsStatus() []sshtunnel.Status
+	FetchSSHHostKey(address string) (string, error)
+	PinnedCertificates() []*backend.PinnedCertificate
+	BandwidthStatus() *bandwidth.Status
+	Diagnose() *diagnose.Report
+	ClockSkew() backend.ClockSkewStatus
+	BuildInfo() *buildinfo.Info
+	MobileDataSaverStatus() *backend.MobileDataSaverStatus
+	UpdateMobileDataSaver()
+	SetAppActivity(backend.AppActivity)
+	InitializeAccounts(codes []accountsTypes.Code)
+	UpdateContractRegistry(jsonBytes []byte) error
+	CheckPinnedCertificate(code coinpkg.Code, server string) (*backend.PinnedCertificateCheck, error)
+	RepinCertificate(code coinpkg.Code, server string, fingerprint string) error
+	BenchmarkElectrumServers(code coinpkg.Code) ([]*backend.ElectrumServerBenchmark, error)
+	PreferElectrumServer(code coinpkg.Code, server string) error
 	RegisterTestKeystore(string)
 	NotifyUser(string)
 	SystemOpen(string) error
 	ReinitializeAccounts()
 	CheckForUpdateIgnoringErrors() *backend.UpdateFile
+	StartupStatus() map[backend.StartupTask]backend.StartupTaskStatus
+	Status() *backend.Status
 	Banners() *banners.Banners
 	Environment() backend.Environment
 	ExportLogs() error
+	TaxReport(method taxreport.Method, year int) (*taxreport.Report, int, error)
+	ExportTaxReport(method taxreport.Method, year int) error
+	ExportLedger(format ledger.Format) error
 	ChartData() (*backend.Chart, error)
 	SupportedCoins(keystore.Keystore) []coinpkg.Code
 	CanAddAccount(coinpkg.Code, keystore.Keystore) (string, bool)
 	CreateAndPersistAccountConfig(coinCode coinpkg.Code, name string, keystore keystore.Keystore) (accountsTypes.Code, error)
-	SetAccountActive(accountCode accountsTypes.Code, active bool) error
-	SetTokenActive(accountCode accountsTypes.Code, tokenCode string, active bool) error
-	RenameAccount(accountCode accountsTypes.Code, name string) error
+	ImportWatchonlyAccount(coinCode coinpkg.Code, name string, exportFile []byte) (accountsTypes.Code, error)
+	CreateMultisigAccount(coinCode coinpkg.Code, name string, threshold int, cosigners []string, keystore keystore.Keystore) (accountsTypes.Code, error)
+	SetAccountActiveAtRevision(accountCode accountsTypes.Code, active bool, revision string) error
+	SetTokenActiveAtRevision(accountCode accountsTypes.Code, tokenCode string, active bool, revision string) error
+	ERC20Tokens() []backend.ERC20TokenInfo
+	AddCustomERC20Token(contractAddress string) (*backend.ERC20TokenInfo, error)
+	RemoveCustomERC20Token(code coinpkg.Code) error
+	ConfirmationThresholds() []backend.ConfirmationThreshold
+	SetConfirmationThreshold(code coinpkg.Code, numConfirmations int) error
+	ExploreAddresses(args backend.ExploreAddressesArgs) ([]backend.ExploredAddress, error)
+	VerifyBTCMessage(coinCode coinpkg.Code, address string, msg string, signature string) (message.Format, error)
+	RenameAccountAtRevision(accountCode accountsTypes.Code, name string, revision string) error
 	AOPP() backend.AOPP
 	AOPPCancel()
 	AOPPApprove()
@@ -112,7 +163,53 @@ type Backend interface {
 	ForceAuth()
 	CancelConnectKeystore()
 	SetWatchonly(rootFingerprint []byte, watchonly bool) error
+	SetWatchonlyAll(enabled bool) error
 	LookupEthAccountCode(address string) (accountsTypes.Code, string, error)
+	LikelyCoinForAddress(address string, exclude coinpkg.Code) (coinpkg.Coin, bool)
+	HWWBridge() *hwwbridge.Bridge
+	SetHWWBridgeEnabled(enabled bool) error
+	Extensions() *extensions.Server
+	APIPairing() *apipairing.Manager
+	SetExtensionsEnabled(enabled bool) error
+	CompanionHost() *companion.Host
+	CompanionClient() *companion.Client
+	PairCompanionClient(pairingURI string, name string) error
+	SetCompanionHostEnabled(enabled bool) error
+	Session() backend.Session
+	SetFrontendSessionState(state json.RawMessage) error
+	PaymentRequests() []backend.PaymentRequest
+	DismissPaymentRequest(id int) error
+	DataDir() backend.DataDirInfo
+	RestartRequired() bool
+	Profiles() backend.ProfilesInfo
+	AddProfile(name string) (*utilConfig.Profile, error)
+	SelectProfile(id string) error
+	MoveDataDir(target string) error
+	Invoices() []backend.InvoiceInfo
+	CreateInvoice(args backend.CreateInvoiceArgs) (*backend.InvoiceInfo, error)
+	UpdateInvoice(id string, memo string, callbackURL string) error
+	DeleteInvoice(id string) error
+	Webhooks() []backend.WebhookInfo
+	AddWebhook(args backend.WebhookArgs) (string, error)
+	UpdateWebhook(id string, args backend.WebhookArgs) error
+	DeleteWebhook(id string) error
+	TestWebhook(id string) error
+	Rules() []backend.RuleInfo
+	AddRule(args backend.RuleArgs) (string, error)
+	UpdateRule(id string, args backend.RuleArgs) error
+	DeleteRule(id string) error
+	RuleDrafts() []backend.RuleDraft
+	DismissRuleDraft(id int) error
+	ScheduledExportStatus() *scheduledexport.Status
+	SetScheduledExport(args backend.ScheduledExportArgs) error
+	RunScheduledExport() error
+	MetadataSyncStatus() *metadatasync.Status
+	SetMetadataSync(args backend.MetadataSyncArgs) error
+	RunMetadataSync() error
+	AccountAlerts(accountCode accountsTypes.Code) (*backend.AccountAlertsInfo, error)
+	SetAccountAlerts(accountCode accountsTypes.Code, args backend.AccountAlertsArgs) error
+	FeeHistory(code coinpkg.Code, days int) (*feehistory.Series, error)
+	ImportExchangeWithdrawals(exchange string, csvContent string) (*exchangeimport.Result, error)
 }
 
 // Handlers provides a web api to the backend.
@@ -133,6 +230,9 @@ type ConnectionData struct {
 	port    int
 	token   string
 	devMode bool
+	// authorizePaired checks the tokens issued to paired remote frontends, see the apipairing
+	// package. It is set by NewHandlers().
+	authorizePaired func(token string, method string, path string) bool
 }
 
 // NewConnectionData creates a connection data struct which holds the port and token for the API.
@@ -149,6 +249,18 @@ func (connectionData *ConnectionData) isDev() bool {
 	return connectionData.port == -1 || connectionData.token == ""
 }
 
+// authorized returns true if the value of the Authorization header contains the main token, or a
+// token of a paired remote frontend which is allowed to make the request.
+func (connectionData *ConnectionData) authorized(authorization string, method string, path string) bool {
+	if authorization == "Basic "+connectionData.token {
+		return true
+	}
+	token := strings.TrimPrefix(authorization, "Basic ")
+	return token != authorization &&
+		connectionData.authorizePaired != nil &&
+		connectionData.authorizePaired(token, method, path)
+}
+
 // NewHandlers creates a new Handlers instance.
 func NewHandlers(
 	backend Backend,
@@ -156,6 +268,7 @@ func NewHandlers(
 ) *Handlers {
 	log := logging.Get().WithGroup("handlers")
 	router := mux.NewRouter()
+	connData.authorizePaired = backend.APIPairing().Authorize
 	handlers := &Handlers{
 		Router:        router,
 		backend:       backend,
@@ -191,49 +304,98 @@ func NewHandlers(
 		}
 	}
 
+	// Like `getAPIRouterNoError`, but without requiring the API token. Only for the endpoints with
+	// which remote frontends obtain a token.
+	getPublicAPIRouterNoError := func(subrouter *mux.Router) func(string, func(*http.Request) interface{}) *mux.Route {
+		return func(path string, f func(*http.Request) interface{}) *mux.Route {
+			return subrouter.Handle(
+				path,
+				handlers.apiMiddleware(
+					connData.isDev(),
+					func(r *http.Request) (interface{}, error) {
+						return f(r), nil
+					}))
+		}
+	}
+
 	apiRouter := router.PathPrefix("/api").Subrouter()
 	getAPIRouterNoError(apiRouter)("/qr", handlers.getQRCode).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/config", handlers.getAppConfig).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/config/default", handlers.getDefaultConfig).Methods("GET")
 	getAPIRouter(apiRouter)("/config", handlers.postAppConfig).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/accounts-config-revision", handlers.getAccountsConfigRevision).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/native-locale", handlers.getNativeLocale).Methods("GET")
 	getAPIRouter(apiRouter)("/notify-user", handlers.postNotify).Methods("POST")
 	getAPIRouter(apiRouter)("/open", handlers.postOpen).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/update", handlers.getUpdate).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/startup-status", handlers.getStartupStatus).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/status", handlers.getStatus).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/banners", handlers.getBannerMessages).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/banners/read", handlers.postBannerRead).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/banners/dismiss", handlers.postBannerDismiss).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/banners/{key}", handlers.getBanners).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/using-mobile-data", handlers.getUsingMobileData).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/mobile-data-saver", handlers.getMobileDataSaver).Methods("GET")
+	getAPIRouter(apiRouter)("/app-activity", handlers.postAppActivity).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/authenticate", handlers.postAuthenticate).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/trigger-auth", handlers.postTriggerAuth).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/force-auth", handlers.postForceAuth).Methods("POST")
 	getAPIRouter(apiRouter)("/set-dark-theme", handlers.postDarkTheme).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/detect-dark-theme", handlers.getDetectDarkTheme).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/version", handlers.getVersion).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/buildinfo", handlers.getBuildInfo).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/testing", handlers.getTesting).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/account-add", handlers.postAddAccount).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/account-import", handlers.postImportAccount).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/keystores", handlers.getKeystores).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/accounts", handlers.getAccounts).Methods("GET")
 	getAPIRouter(apiRouter)("/accounts/balance", handlers.getAccountsBalance).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/accounts/balances", handlers.getAccountsBalances).Methods("GET")
 	getAPIRouter(apiRouter)("/accounts/coins-balance", handlers.getCoinsTotalBalance).Methods("GET")
 	getAPIRouter(apiRouter)("/accounts/total-balance", handlers.getAccountsTotalBalance).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/set-account-active", handlers.postSetAccountActive).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/set-token-active", handlers.postSetTokenActive).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/eth/erc20-tokens", handlers.getERC20Tokens).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/eth/erc20-tokens/add", handlers.postAddCustomERC20Token).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/eth/erc20-tokens/remove", handlers.postRemoveCustomERC20Token).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/confirmation-thresholds", handlers.getConfirmationThresholds).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/confirmation-thresholds/set", handlers.postSetConfirmationThreshold).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/explore-addresses", handlers.postExploreAddresses).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/verify-message", handlers.postVerifyMessage).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/rename-account", handlers.postRenameAccount).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/accounts/reinitialize", handlers.postAccountsReinitialize).Methods("POST")
 	getAPIRouter(apiRouter)("/account-summary", handlers.getAccountSummary).Methods("GET")
+	getAPIRouter(apiRouter)("/accounts/initialize", handlers.postInitializeAccounts).Methods("POST")
+	getAPIRouter(apiRouter)("/eth/contract-registry", handlers.postContractRegistry).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/supported-coins", handlers.getSupportedCoins).Methods("GET")
 	getAPIRouter(apiRouter)("/test/register", handlers.postRegisterTestKeystore).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/test/deregister", handlers.postDeregisterTestKeystore).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/rates", handlers.getRates).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/coins/convert-to-plain-fiat", handlers.getConvertToPlainFiat).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/coins/convert-from-fiat", handlers.getConvertFromFiat).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/coins/convert-to-fiat-at", handlers.getConvertToFiatAt).Methods("GET")
 	getAPIRouter(apiRouter)("/coins/tltc/headers/status", handlers.getHeadersStatus(coinpkg.CodeTLTC)).Methods("GET")
 	getAPIRouter(apiRouter)("/coins/tbtc/headers/status", handlers.getHeadersStatus(coinpkg.CodeTBTC)).Methods("GET")
 	getAPIRouter(apiRouter)("/coins/ltc/headers/status", handlers.getHeadersStatus(coinpkg.CodeLTC)).Methods("GET")
 	getAPIRouter(apiRouter)("/coins/btc/headers/status", handlers.getHeadersStatus(coinpkg.CodeBTC)).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/coins/btc/set-unit", handlers.postBtcFormatUnit).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/coins/btc/parse-external-amount", handlers.getBTCParseExternalAmount).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/coins/{code}/validate-address", handlers.getCoinValidateAddress).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/coins/{code}/estimate-fee", handlers.getCoinEstimateFee).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/coins/{code}/tip-height", handlers.getCoinTipHeight).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/coins/{code}/policies", handlers.getCoinPolicies).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/coins/{code}/fee-history", handlers.getCoinFeeHistory).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/certs/download", handlers.postCertsDownload).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/electrum/check", handlers.postElectrumCheck).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/ssh-tunnels/status", handlers.getSSHTunnelsStatus).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/ssh-tunnels/host-key", handlers.postSSHTunnelsHostKey).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/certs/pinned", handlers.getPinnedCerts).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/diagnostics/bandwidth", handlers.getBandwidthStatus).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/diagnose", handlers.getDiagnose).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/clock-skew", handlers.getClockSkew).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/certs/check", handlers.postCertsCheck).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/certs/repin", handlers.postCertsRepin).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/electrum/benchmark", handlers.postElectrumBenchmark).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/socksproxy/check", handlers.postSocksProxyCheck).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/exchange/by-region/{code}", handlers.getExchangesByRegion).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/exchange/deals", handlers.getExchangeDeals).Methods("GET")
@@ -249,12 +411,69 @@ func NewHandlers(
 	getAPIRouter(apiRouter)("/aopp/choose-account", handlers.postAOPPChooseAccount).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/cancel-connect-keystore", handlers.postCancelConnectKeystore).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/set-watchonly", handlers.postSetWatchonly).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/set-watchonly-all", handlers.postSetWatchonlyAll).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/on-auth-setting-changed", handlers.postOnAuthSettingChanged).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/export-log", handlers.postExportLog).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/accounts/eth-account-code", handlers.lookupEthAccountCode).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/payment-requests", handlers.getPaymentRequests).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/payment-requests/dismiss", handlers.postDismissPaymentRequest).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/webhooks", handlers.getWebhooks).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/webhooks/add", handlers.postAddWebhook).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/webhooks/update", handlers.postUpdateWebhook).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/webhooks/delete", handlers.postDeleteWebhook).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/webhooks/test", handlers.postTestWebhook).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/rules", handlers.getRules).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/rules/add", handlers.postAddRule).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/rules/update", handlers.postUpdateRule).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/rules/delete", handlers.postDeleteRule).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/rules/drafts", handlers.getRuleDrafts).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/rules/drafts/dismiss", handlers.postDismissRuleDraft).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/diagnostics/scheduled-export", handlers.getScheduledExportStatus).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/scheduled-export/update", handlers.postSetScheduledExport).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/scheduled-export/run", handlers.postRunScheduledExport).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/reports/tax", handlers.getTaxReport).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/reports/tax", handlers.postExportTaxReport).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/reports/ledger", handlers.postExportLedger).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/metadata-sync/status", handlers.getMetadataSyncStatus).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/metadata-sync/update", handlers.postSetMetadataSync).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/metadata-sync/run", handlers.postRunMetadataSync).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/exchange-withdrawals/import", handlers.postImportExchangeWithdrawals).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/accounts/alerts", handlers.getAccountAlerts).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/accounts/alerts", handlers.postSetAccountAlerts).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/invoices", handlers.getInvoices).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/invoices/create", handlers.postCreateInvoice).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/invoices/update", handlers.postUpdateInvoice).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/invoices/delete", handlers.postDeleteInvoice).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/data-dir", handlers.getDataDir).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/data-dir/move", handlers.postMoveDataDir).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/profiles", handlers.getProfiles).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/profiles/add", handlers.postAddProfile).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/profiles/select", handlers.postSelectProfile).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/session", handlers.getSession).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/session/frontend-state", handlers.postSessionFrontendState).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/hww-bridge/status", handlers.getHWWBridgeStatus).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/hww-bridge/set-enabled", handlers.postHWWBridgeSetEnabled).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/hww-bridge/set-approval", handlers.postHWWBridgeSetApproval).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/extensions/status", handlers.getExtensionsStatus).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/extensions/set-enabled", handlers.postExtensionsSetEnabled).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/extensions/register", handlers.postExtensionsRegister).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/extensions/revoke", handlers.postExtensionsRevoke).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/extensions/widgets", handlers.getExtensionsWidgets).Methods("GET")
+	getPublicAPIRouterNoError(apiRouter)("/pairing/request", handlers.postAPIPairingRequest).Methods("POST")
+	getPublicAPIRouterNoError(apiRouter)("/pairing/request/{id}", handlers.getAPIPairingRequest).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/pairing/clients", handlers.getAPIPairingClients).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/pairing/clients/revoke", handlers.postAPIPairingRevoke).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/companion/host/status", handlers.getCompanionHostStatus).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/companion/host/set-enabled", handlers.postCompanionHostSetEnabled).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/companion/host/start-pairing", handlers.postCompanionHostStartPairing).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/companion/host/unpair", handlers.postCompanionHostUnpair).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/companion/client/status", handlers.getCompanionClientStatus).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/companion/client/pair", handlers.postCompanionClientPair).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/companion/client/unpair", handlers.postCompanionClientUnpair).Methods("POST")
 
 	devicesRouter := getAPIRouterNoError(apiRouter.PathPrefix("/devices").Subrouter())
 	devicesRouter("/registered", handlers.getDevicesRegistered).Methods("GET")
+	devicesRouter("/rescan", handlers.postDevicesRescan).Methods("POST")
 
 	handlersMapLock := locker.Locker{}
 
@@ -264,7 +483,7 @@ func NewHandlers(
 		if _, ok := accountHandlersMap[accountCode]; !ok {
 			accountHandlersMap[accountCode] = accountHandlers.NewHandlers(getAPIRouter(
 				apiRouter.PathPrefix(fmt.Sprintf("/account/%s", accountCode)).Subrouter(),
-			), log)
+			), backend.LikelyCoinForAddress, log)
 		}
 		accHandlers := accountHandlersMap[accountCode]
 		log.WithField("account-handlers", accHandlers).Debug("Account handlers")
@@ -435,8 +654,24 @@ func (handlers *Handlers) getQRCode(r *http.Request) interface{} {
 	}
 }
 
+// appConfigWithRevision is the app config as exchanged with the frontend. The revision is used to
+// detect concurrent changes by another frontend, see config.ErrConflict. The secrets are redacted,
+// see config.AppConfig.Redacted().
+type appConfigWithRevision struct {
+	config.AppConfig
+	Revision string `json:"revision"`
+}
+
 func (handlers *Handlers) getAppConfig(*http.Request) interface{} {
-	return handlers.backend.Config().AppConfig()
+	appConfig, revision := handlers.backend.Config().AppConfigWithRevision()
+	return appConfigWithRevision{AppConfig: appConfig.Redacted(), Revision: revision}
+}
+
+// getAccountsConfigRevision returns the revision of the accounts config. Passing it to account
+// config mutations makes them fail with config.ErrConflict if another frontend changed the accounts
+// config in the meantime.
+func (handlers *Handlers) getAccountsConfigRevision(*http.Request) interface{} {
+	return handlers.backend.Config().AccountsConfigRevision()
 }
 
 func (handlers *Handlers) getDefaultConfig(*http.Request) interface{} {
@@ -444,11 +679,18 @@ func (handlers *Handlers) getDefaultConfig(*http.Request) interface{} {
 }
 
 func (handlers *Handlers) postAppConfig(r *http.Request) (interface{}, error) {
-	appConfig := config.AppConfig{}
+	appConfig := appConfigWithRevision{}
 	if err := json.NewDecoder(r.Body).Decode(&appConfig); err != nil {
 		return nil, errp.WithStack(err)
 	}
-	return nil, handlers.backend.Config().SetAppConfig(appConfig)
+	// The frontend sends back the redacted secrets it got from getAppConfig.
+	newAppConfig := appConfig.AppConfig.WithSecretsOf(handlers.backend.Config().AppConfig())
+	if err := handlers.backend.Config().SetAppConfigAtRevision(
+		newAppConfig, appConfig.Revision); err != nil {
+		return nil, err
+	}
+	handlers.backend.UpdateMobileDataSaver()
+	return nil, nil
 }
 
 // getNativeLocaleHandler returns user preferred UI language as reported
@@ -481,14 +723,69 @@ func (handlers *Handlers) getUpdate(*http.Request) interface{} {
 	return handlers.backend.CheckForUpdateIgnoringErrors()
 }
 
+func (handlers *Handlers) getStartupStatus(*http.Request) interface{} {
+	return handlers.backend.StartupStatus()
+}
+
+func (handlers *Handlers) getStatus(*http.Request) interface{} {
+	return handlers.backend.Status()
+}
+
 func (handlers *Handlers) getBanners(r *http.Request) interface{} {
 	return handlers.backend.Banners().GetMessage(banners.MessageKey(mux.Vars(r)["key"]))
 }
 
+func (handlers *Handlers) getBannerMessages(*http.Request) interface{} {
+	return handlers.backend.Banners().Messages()
+}
+
+func (handlers *Handlers) postBannerRead(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var id string
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.Banners().MarkRead(id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) postBannerDismiss(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var id string
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.Banners().Dismiss(id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
 func (handlers *Handlers) getUsingMobileData(r *http.Request) interface{} {
 	return handlers.backend.Environment().UsingMobileData()
 }
 
+func (handlers *Handlers) getMobileDataSaver(*http.Request) interface{} {
+	return handlers.backend.MobileDataSaverStatus()
+}
+
+func (handlers *Handlers) postAppActivity(r *http.Request) (interface{}, error) {
+	var activity backend.AppActivity
+	if err := json.NewDecoder(r.Body).Decode(&activity); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	handlers.backend.SetAppActivity(activity)
+	return nil, nil
+}
+
 func (handlers *Handlers) postAuthenticate(r *http.Request) interface{} {
 	var force bool
 	if err := json.NewDecoder(r.Body).Decode(&force); err != nil {
@@ -529,6 +826,10 @@ func (handlers *Handlers) getVersion(*http.Request) interface{} {
 	return backend.Version.String()
 }
 
+func (handlers *Handlers) getBuildInfo(*http.Request) interface{} {
+	return handlers.backend.BuildInfo()
+}
+
 func (handlers *Handlers) getTesting(*http.Request) interface{} {
 	return handlers.backend.Testing()
 }
@@ -537,6 +838,15 @@ func (handlers *Handlers) postAddAccount(r *http.Request) interface{} {
 	var jsonBody struct {
 		CoinCode coinpkg.Code `json:"coinCode"`
 		Name     string       `json:"name"`
+		// Descriptor optionally contains output descriptors, one per line. If set, a watch-only
+		// account is added for them instead of an account of the connected keystore.
+		Descriptor string `json:"descriptor"`
+		// Multisig is optionally set to add a multisig account of the connected keystore with the
+		// given cosigner keys, see backend.CreateMultisigAccount().
+		Multisig *struct {
+			Threshold int      `json:"threshold"`
+			Cosigners []string `json:"cosigners"`
+		} `json:"multisig"`
 	}
 
 	type response struct {
@@ -550,11 +860,37 @@ func (handlers *Handlers) postAddAccount(r *http.Request) interface{} {
 		return response{Success: false, ErrorMessage: err.Error()}
 	}
 
+	if jsonBody.Descriptor != "" {
+		accountCode, err := handlers.backend.ImportWatchonlyAccount(
+			jsonBody.CoinCode, jsonBody.Name, []byte(jsonBody.Descriptor))
+		if err != nil {
+			handlers.log.WithError(err).Error("Could not add account from descriptor")
+			if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+				return response{Success: false, ErrorCode: string(errCode)}
+			}
+			return response{Success: false, ErrorMessage: err.Error()}
+		}
+		return response{Success: true, AccountCode: accountCode}
+	}
+
 	keystore := handlers.backend.Keystore()
 	if keystore == nil {
 		return response{Success: false, ErrorMessage: "Keystore not found"}
 	}
 
+	if jsonBody.Multisig != nil {
+		accountCode, err := handlers.backend.CreateMultisigAccount(jsonBody.CoinCode, jsonBody.Name,
+			jsonBody.Multisig.Threshold, jsonBody.Multisig.Cosigners, keystore)
+		if err != nil {
+			handlers.log.WithError(err).Error("Could not add multisig account")
+			if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+				return response{Success: false, ErrorCode: string(errCode)}
+			}
+			return response{Success: false, ErrorMessage: err.Error()}
+		}
+		return response{Success: true, AccountCode: accountCode}
+	}
+
 	accountCode, err := handlers.backend.CreateAndPersistAccountConfig(jsonBody.CoinCode, jsonBody.Name, keystore)
 	if err != nil {
 		handlers.log.WithError(err).Error("Could not add account")
@@ -566,6 +902,39 @@ func (handlers *Handlers) postAddAccount(r *http.Request) interface{} {
 	return response{Success: true, AccountCode: accountCode}
 }
 
+// postImportAccount adds a watch-only account from the extended public key export file of another
+// hardware wallet.
+func (handlers *Handlers) postImportAccount(r *http.Request) interface{} {
+	var jsonBody struct {
+		CoinCode coinpkg.Code `json:"coinCode"`
+		Name     string       `json:"name"`
+		// ExportFile is the content of the export file.
+		ExportFile string `json:"exportFile"`
+	}
+
+	type response struct {
+		Success      bool               `json:"success"`
+		AccountCode  accountsTypes.Code `json:"accountCode,omitempty"`
+		ErrorMessage string             `json:"errorMessage,omitempty"`
+		ErrorCode    string             `json:"errorCode,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+
+	accountCode, err := handlers.backend.ImportWatchonlyAccount(
+		jsonBody.CoinCode, jsonBody.Name, []byte(jsonBody.ExportFile))
+	if err != nil {
+		handlers.log.WithError(err).Error("Could not import account")
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return response{Success: false, ErrorCode: string(errCode)}
+		}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true, AccountCode: accountCode}
+}
+
 func (handlers *Handlers) getKeystores(*http.Request) interface{} {
 	type json struct {
 		Type keystore.Type `json:"type"`
@@ -743,6 +1112,7 @@ func (handlers *Handlers) getAccountsBalance(*http.Request) (interface{}, error)
 				Amount:      currentCoin.FormatAmount(coin.NewAmount(v), false),
 				Unit:        currentCoin.GetFormatUnit(false),
 				Conversions: conversionsPerCoin[k],
+				RatesInfo:   handlers.backend.RatesUpdater().LatestPriceInfo(),
 			}
 		}
 	}
@@ -750,6 +1120,66 @@ func (handlers *Handlers) getAccountsBalance(*http.Request) (interface{}, error)
 	return totalAmount, nil
 }
 
+// getAccountsBalances returns the balance of each shown active account and the total balance of
+// the same accounts in the main fiat currency. Unlike the account summary, it does not compute chart data or
+// walk the transactions, so it is cheap enough to be displayed while the summary is loading.
+func (handlers *Handlers) getAccountsBalances(*http.Request) interface{} {
+	type accountBalance struct {
+		AccountCode accountsTypes.Code              `json:"accountCode"`
+		CoinCode    coinpkg.Code                    `json:"coinCode"`
+		Synced      bool                            `json:"synced"`
+		Balance     accountHandlers.FormattedAmount `json:"balance"`
+	}
+	type response struct {
+		Success      bool                         `json:"success"`
+		ErrorMessage string                       `json:"errorMessage,omitempty"`
+		Accounts     []accountBalance             `json:"accounts"`
+		Total        *backend.KeystoreTotalAmount `json:"total"`
+	}
+
+	result := []accountBalance{}
+	for _, account := range handlers.backend.Accounts() {
+		if account.Config().Config.Inactive || account.Config().Config.HiddenBecauseUnused {
+			continue
+		}
+		if account.FatalError() {
+			continue
+		}
+		if err := account.Initialize(); err != nil {
+			return response{Success: false, ErrorMessage: err.Error()}
+		}
+		balance, err := account.Balance()
+		if err != nil {
+			return response{Success: false, ErrorMessage: err.Error()}
+		}
+		amount := balance.Available()
+		result = append(result, accountBalance{
+			AccountCode: account.Config().Config.Code,
+			CoinCode:    account.Coin().Code(),
+			Synced:      account.Synced(),
+			Balance: accountHandlers.FormattedAmount{
+				Amount: account.Coin().FormatAmount(amount, false),
+				Unit:   account.Coin().GetFormatUnit(false),
+				Conversions: coin.Conversions(
+					amount,
+					account.Coin(),
+					false,
+					account.Config().RateUpdater,
+					util.FormatBtcAsSat(handlers.backend.Config().AppConfig().Backend.BtcUnit)),
+				RatesInfo: account.Config().RateUpdater.LatestPriceInfo(),
+			},
+		})
+	}
+
+	// The total is omitted if it can't be computed, e.g. because the rates are not available yet.
+	total, err := handlers.backend.AccountsTotalBalance()
+	if err != nil {
+		handlers.log.WithError(err).Warning("Could not compute the accounts total balance")
+		total = nil
+	}
+	return response{Success: true, Accounts: result, Total: total}
+}
+
 // getCoinsTotalBalance returns the total balances grouped by coins.
 func (handlers *Handlers) getCoinsTotalBalance(_ *http.Request) (interface{}, error) {
 	totalPerCoin := make(map[coin.Code]*big.Int)
@@ -798,6 +1228,7 @@ func (handlers *Handlers) getCoinsTotalBalance(_ *http.Request) (interface{}, er
 			Amount:      currentCoin.FormatAmount(coin.NewAmount(v), false),
 			Unit:        currentCoin.GetFormatUnit(false),
 			Conversions: conversionsPerCoin[k],
+			RatesInfo:   handlers.backend.RatesUpdater().LatestPriceInfo(),
 		}
 	}
 	return totalAmount, nil
@@ -826,17 +1257,24 @@ func (handlers *Handlers) postSetAccountActive(r *http.Request) interface{} {
 	var jsonBody struct {
 		AccountCode accountsTypes.Code `json:"accountCode"`
 		Active      bool               `json:"active"`
+		// See config.Config.ModifyAccountsConfigAtRevision().
+		Revision string `json:"revision"`
 	}
 
 	type response struct {
 		Success      bool   `json:"success"`
 		ErrorMessage string `json:"errorMessage,omitempty"`
+		ErrorCode    string `json:"errorCode,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
 		return response{Success: false, ErrorMessage: err.Error()}
 	}
-	if err := handlers.backend.SetAccountActive(jsonBody.AccountCode, jsonBody.Active); err != nil {
+	err := handlers.backend.SetAccountActiveAtRevision(jsonBody.AccountCode, jsonBody.Active, jsonBody.Revision)
+	if err != nil {
+		if errp.Cause(err) == config.ErrConflict {
+			return response{Success: false, ErrorCode: string(config.ErrConflict)}
+		}
 		return response{Success: false, ErrorMessage: err.Error()}
 	}
 	return response{Success: true}
@@ -847,6 +1285,89 @@ func (handlers *Handlers) postSetTokenActive(r *http.Request) interface{} {
 		AccountCode accountsTypes.Code `json:"accountCode"`
 		TokenCode   string             `json:"tokenCode"`
 		Active      bool               `json:"active"`
+		// See config.Config.ModifyAccountsConfigAtRevision().
+		Revision string `json:"revision"`
+	}
+
+	type response struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		ErrorCode    string `json:"errorCode,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	err := handlers.backend.SetTokenActiveAtRevision(
+		jsonBody.AccountCode, jsonBody.TokenCode, jsonBody.Active, jsonBody.Revision)
+	if err != nil {
+		if errp.Cause(err) == config.ErrConflict {
+			return response{Success: false, ErrorCode: string(config.ErrConflict)}
+		}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true}
+}
+
+func (handlers *Handlers) getERC20Tokens(*http.Request) interface{} {
+	return handlers.backend.ERC20Tokens()
+}
+
+// postAddCustomERC20Token adds an ERC20 token by its contract address, see
+// backend.AddCustomERC20Token().
+func (handlers *Handlers) postAddCustomERC20Token(r *http.Request) interface{} {
+	var contractAddress string
+
+	type response struct {
+		Success      bool                    `json:"success"`
+		ErrorMessage string                  `json:"errorMessage,omitempty"`
+		ErrorCode    string                  `json:"errorCode,omitempty"`
+		Token        *backend.ERC20TokenInfo `json:"token,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&contractAddress); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	token, err := handlers.backend.AddCustomERC20Token(contractAddress)
+	if err != nil {
+		handlers.log.WithError(err).Error("Could not add the custom ERC20 token")
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return response{Success: false, ErrorCode: string(errCode)}
+		}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true, Token: token}
+}
+
+func (handlers *Handlers) postRemoveCustomERC20Token(r *http.Request) interface{} {
+	var code coinpkg.Code
+
+	type response struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		ErrorCode    string `json:"errorCode,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&code); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.RemoveCustomERC20Token(code); err != nil {
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return response{Success: false, ErrorCode: string(errCode)}
+		}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true}
+}
+
+func (handlers *Handlers) getConfirmationThresholds(*http.Request) interface{} {
+	return handlers.backend.ConfirmationThresholds()
+}
+
+func (handlers *Handlers) postSetConfirmationThreshold(r *http.Request) interface{} {
+	var jsonBody struct {
+		CoinCode         coinpkg.Code `json:"coinCode"`
+		NumConfirmations int          `json:"numConfirmations"`
 	}
 
 	type response struct {
@@ -857,16 +1378,74 @@ func (handlers *Handlers) postSetTokenActive(r *http.Request) interface{} {
 	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
 		return response{Success: false, ErrorMessage: err.Error()}
 	}
-	if err := handlers.backend.SetTokenActive(jsonBody.AccountCode, jsonBody.TokenCode, jsonBody.Active); err != nil {
+	if err := handlers.backend.SetConfirmationThreshold(jsonBody.CoinCode, jsonBody.NumConfirmations); err != nil {
 		return response{Success: false, ErrorMessage: err.Error()}
 	}
 	return response{Success: true}
 }
 
+// postExploreAddresses derives addresses of the connected keystore at an arbitrary keypath, see
+// backend.ExploreAddresses().
+func (handlers *Handlers) postExploreAddresses(r *http.Request) interface{} {
+	var args backend.ExploreAddressesArgs
+
+	type response struct {
+		Success      bool                      `json:"success"`
+		ErrorMessage string                    `json:"errorMessage,omitempty"`
+		ErrorCode    string                    `json:"errorCode,omitempty"`
+		Addresses    []backend.ExploredAddress `json:"addresses,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	exploredAddresses, err := handlers.backend.ExploreAddresses(args)
+	if err != nil {
+		handlers.log.WithError(err).Error("Could not explore the addresses")
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return response{Success: false, ErrorCode: string(errCode)}
+		}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true, Addresses: exploredAddresses}
+}
+
+// postVerifyMessage verifies a Bitcoin message signature, see backend.VerifyBTCMessage().
+func (handlers *Handlers) postVerifyMessage(r *http.Request) interface{} {
+	var request struct {
+		CoinCode  coinpkg.Code `json:"coinCode"`
+		Address   string       `json:"address"`
+		Message   string       `json:"message"`
+		Signature string       `json:"signature"`
+	}
+
+	type response struct {
+		Success      bool           `json:"success"`
+		ErrorMessage string         `json:"errorMessage,omitempty"`
+		ErrorCode    string         `json:"errorCode,omitempty"`
+		Format       message.Format `json:"format,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	format, err := handlers.backend.VerifyBTCMessage(
+		request.CoinCode, request.Address, request.Message, request.Signature)
+	if err != nil {
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return response{Success: false, ErrorCode: string(errCode)}
+		}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true, Format: format}
+}
+
 func (handlers *Handlers) postRenameAccount(r *http.Request) interface{} {
 	var jsonBody struct {
 		AccountCode accountsTypes.Code `json:"accountCode"`
 		Name        string             `json:"name"`
+		// See config.Config.ModifyAccountsConfigAtRevision().
+		Revision string `json:"revision"`
 	}
 
 	type response struct {
@@ -878,7 +1457,8 @@ func (handlers *Handlers) postRenameAccount(r *http.Request) interface{} {
 	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
 		return response{Success: false, ErrorMessage: err.Error()}
 	}
-	if err := handlers.backend.RenameAccount(jsonBody.AccountCode, jsonBody.Name); err != nil {
+	err := handlers.backend.RenameAccountAtRevision(jsonBody.AccountCode, jsonBody.Name, jsonBody.Revision)
+	if err != nil {
 		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
 			return response{Success: false, ErrorCode: string(errCode)}
 		}
@@ -900,6 +1480,11 @@ func (handlers *Handlers) getDevicesRegistered(*http.Request) interface{} {
 	return jsonDevices
 }
 
+func (handlers *Handlers) postDevicesRescan(*http.Request) interface{} {
+	handlers.backend.RescanDevices()
+	return nil
+}
+
 func (handlers *Handlers) postRegisterTestKeystore(r *http.Request) (interface{}, error) {
 	if !handlers.backend.Testing() {
 		return nil, errp.New("Test keystore not available")
@@ -951,51 +1536,237 @@ func (handlers *Handlers) getBTCParseExternalAmount(r *http.Request) interface{}
 	}
 }
 
-func (handlers *Handlers) getConvertToPlainFiat(r *http.Request) interface{} {
-	coinCode := r.URL.Query().Get("from")
-	currency := r.URL.Query().Get("to")
-	amount := r.URL.Query().Get("amount")
-	currentCoin, err := handlers.backend.Coin(coinpkg.Code(coinCode))
+// getCoinValidateAddress checks if the `address` query param is a valid recipient address for the
+// coin. It does not need any account or keystore.
+func (handlers *Handlers) getCoinValidateAddress(r *http.Request) interface{} {
+	type response struct {
+		Success      bool   `json:"success"`
+		Valid        bool   `json:"valid"`
+		ErrorCode    string `json:"errorCode,omitempty"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	coin, err := handlers.backend.Coin(coinpkg.Code(mux.Vars(r)["code"]))
 	if err != nil {
-		handlers.log.WithError(err).Error("Could not get coin " + coinCode)
-		return map[string]interface{}{
-			"success": false,
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	address := r.URL.Query().Get("address")
+	switch specificCoin := coin.(type) {
+	case *btc.Coin:
+		if _, err := specificCoin.DecodeAddress(address); err != nil {
+			if validationErr, ok := errp.Cause(err).(errors.TxValidationError); ok {
+				return response{Success: true, Valid: false, ErrorCode: validationErr.Error()}
+			}
+			return response{Success: false, ErrorMessage: err.Error()}
 		}
+	case *eth.Coin:
+		if !eth.IsValidEthAddress(address) {
+			return response{Success: true, Valid: false, ErrorCode: errors.ErrInvalidAddress.Error()}
+		}
+	default:
+		return response{Success: false, ErrorMessage: "unsupported coin"}
 	}
+	return response{Success: true, Valid: true}
+}
 
-	coinAmount, err := currentCoin.ParseAmount(amount)
+// getCoinEstimateFee estimates the fee of a hypothetical transaction with a virtual size of
+// `vsize` vbytes, to be confirmed within `blocks` blocks. Only Bitcoin-based coins are supported.
+func (handlers *Handlers) getCoinEstimateFee(r *http.Request) interface{} {
+	type response struct {
+		Success      bool   `json:"success"`
+		Fee          string `json:"fee,omitempty"`
+		FeeSat       int64  `json:"feeSat,omitempty"`
+		Unit         string `json:"unit,omitempty"`
+		ErrorCode    string `json:"errorCode,omitempty"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	coin, err := handlers.backend.Coin(coinpkg.Code(mux.Vars(r)["code"]))
 	if err != nil {
-		handlers.log.WithError(err).Error("Error parsing amount " + amount)
-		return map[string]interface{}{
-			"success": false,
-		}
+		return response{Success: false, ErrorMessage: err.Error()}
 	}
-
-	coinUnitAmount := new(big.Rat).SetFloat64(currentCoin.ToUnit(coinAmount, false))
-
-	coinUnit := currentCoin.Unit(false)
-	rate := handlers.backend.RatesUpdater().LatestPrice()[coinUnit][currency]
-
-	convertedAmount := new(big.Rat).Mul(coinUnitAmount, new(big.Rat).SetFloat64(rate))
-
-	return map[string]interface{}{
-		"success":    true,
-		"fiatAmount": coinpkg.FormatAsPlainCurrency(convertedAmount, currency),
+	btcCoin, ok := coin.(*btc.Coin)
+	if !ok {
+		return response{Success: false, ErrorMessage: "unsupported coin"}
 	}
-}
-
-func (handlers *Handlers) getConvertFromFiat(r *http.Request) interface{} {
-	isFee := false
-	from := r.URL.Query().Get("from")
-	to := r.URL.Query().Get("to")
-	currentCoin, err := handlers.backend.Coin(coinpkg.Code(to))
+	vsize, err := strconv.Atoi(r.URL.Query().Get("vsize"))
+	if err != nil || vsize <= 0 {
+		return response{Success: false, ErrorMessage: "invalid vsize"}
+	}
+	blocks, err := strconv.Atoi(r.URL.Query().Get("blocks"))
+	if err != nil || blocks <= 0 {
+		return response{Success: false, ErrorMessage: "invalid blocks"}
+	}
+	fee, err := btcCoin.EstimateFee(vsize, blocks)
 	if err != nil {
-		return map[string]interface{}{
-			"success": false,
-			"errMsg":  "internal error",
+		if validationErr, ok := errp.Cause(err).(errors.TxValidationError); ok {
+			return response{Success: false, ErrorCode: validationErr.Error()}
 		}
+		return response{Success: false, ErrorMessage: err.Error()}
 	}
-
+	feeAmount := coinpkg.NewAmountFromInt64(int64(fee))
+	return response{
+		Success: true,
+		Fee:     btcCoin.FormatAmount(feeAmount, true),
+		FeeSat:  int64(fee),
+		Unit:    btcCoin.GetFormatUnit(true),
+	}
+}
+
+// getCoinTipHeight returns the current block height of the coin.
+func (handlers *Handlers) getCoinTipHeight(r *http.Request) interface{} {
+	type response struct {
+		Success      bool   `json:"success"`
+		TipHeight    int64  `json:"tipHeight"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	coin, err := handlers.backend.Coin(coinpkg.Code(mux.Vars(r)["code"]))
+	if err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	switch specificCoin := coin.(type) {
+	case *btc.Coin:
+		return response{Success: true, TipHeight: int64(specificCoin.TipHeight())}
+	case *eth.Coin:
+		tipHeight, err := specificCoin.TipHeight()
+		if err != nil {
+			return response{Success: false, ErrorMessage: err.Error()}
+		}
+		return response{Success: true, TipHeight: tipHeight.Int64()}
+	default:
+		return response{Success: false, ErrorMessage: "unsupported coin"}
+	}
+}
+
+// getCoinPolicies returns the relay policies of the network of the coin.
+func (handlers *Handlers) getCoinPolicies(r *http.Request) interface{} {
+	type response struct {
+		Success      bool                 `json:"success"`
+		Policies     *btc.NetworkPolicies `json:"policies,omitempty"`
+		ErrorMessage string               `json:"errorMessage,omitempty"`
+	}
+	coin, err := handlers.backend.Coin(coinpkg.Code(mux.Vars(r)["code"]))
+	if err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	btcCoin, ok := coin.(*btc.Coin)
+	if !ok {
+		return response{Success: false, ErrorMessage: "unsupported coin"}
+	}
+	policies, err := btcCoin.NetworkPolicies()
+	if err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true, Policies: policies}
+}
+
+// getCoinFeeHistory returns the fee rates recorded for the coin in the last `days` days.
+func (handlers *Handlers) getCoinFeeHistory(r *http.Request) interface{} {
+	type response struct {
+		Success      bool               `json:"success"`
+		Series       *feehistory.Series `json:"series,omitempty"`
+		ErrorMessage string             `json:"errorMessage,omitempty"`
+	}
+	days, err := strconv.Atoi(r.URL.Query().Get("days"))
+	if err != nil {
+		return response{Success: false, ErrorMessage: "invalid days"}
+	}
+	series, err := handlers.backend.FeeHistory(coinpkg.Code(mux.Vars(r)["code"]), days)
+	if err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true, Series: series}
+}
+
+func (handlers *Handlers) getConvertToPlainFiat(r *http.Request) interface{} {
+	coinCode := r.URL.Query().Get("from")
+	currency := r.URL.Query().Get("to")
+	amount := r.URL.Query().Get("amount")
+	currentCoin, err := handlers.backend.Coin(coinpkg.Code(coinCode))
+	if err != nil {
+		handlers.log.WithError(err).Error("Could not get coin " + coinCode)
+		return map[string]interface{}{
+			"success": false,
+		}
+	}
+
+	coinAmount, err := currentCoin.ParseAmount(amount)
+	if err != nil {
+		handlers.log.WithError(err).Error("Error parsing amount " + amount)
+		return map[string]interface{}{
+			"success": false,
+		}
+	}
+
+	coinUnit := currentCoin.Unit(false)
+	rate := handlers.backend.RatesUpdater().LatestPrice()[coinUnit][currency]
+
+	convertedAmount := coinpkg.ToFiat(coinAmount, currentCoin, false, rate)
+
+	return map[string]interface{}{
+		"success":    true,
+		"fiatAmount": coinpkg.FormatAsPlainCurrency(convertedAmount, currency),
+		// The converted amount in the smallest unit, e.g. sat, as the amount is rounded to it.
+		"amountSmallestUnit": coinAmount.BigInt().String(),
+		"ratesInfo":          handlers.backend.RatesUpdater().LatestPriceInfo(),
+	}
+}
+
+// getConvertToFiatAt converts the amount of the coin to the fiat currency using the historical
+// rate at the given unix timestamp, e.g. to show the value of a transaction when it was received.
+func (handlers *Handlers) getConvertToFiatAt(r *http.Request) interface{} {
+	coinCode := r.URL.Query().Get("coin")
+	currency := r.URL.Query().Get("fiat")
+	amount := r.URL.Query().Get("amount")
+	currentCoin, err := handlers.backend.Coin(coinpkg.Code(coinCode))
+	if err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": "unknown coin",
+		}
+	}
+	coinAmount, err := currentCoin.ParseAmount(amount)
+	if err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": "invalid amount",
+		}
+	}
+	timestamp, err := strconv.ParseInt(r.URL.Query().Get("time"), 10, 64)
+	if err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": "invalid time",
+		}
+	}
+	rate := handlers.backend.RatesUpdater().HistoricalPriceAt(
+		coinCode, currency, time.Unix(timestamp, 0))
+	if rate == 0 {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": "no historical rate available",
+		}
+	}
+	convertedAmount := coinpkg.ToFiat(coinAmount, currentCoin, false, rate)
+	return map[string]interface{}{
+		"success":             true,
+		"rate":                rate,
+		"fiatAmount":          coinpkg.FormatAsPlainCurrency(convertedAmount, currency),
+		"formattedFiatAmount": coinpkg.FormatAsCurrency(convertedAmount, currency),
+		"amountSmallestUnit":  coinAmount.BigInt().String(),
+	}
+}
+
+func (handlers *Handlers) getConvertFromFiat(r *http.Request) interface{} {
+	isFee := false
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	currentCoin, err := handlers.backend.Coin(coinpkg.Code(to))
+	if err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"errMsg":  "internal error",
+		}
+	}
+
 	fiatStr := r.URL.Query().Get("amount")
 	fiatRat, valid := new(big.Rat).SetString(fiatStr)
 	if !valid {
@@ -1016,14 +1787,12 @@ func (handlers *Handlers) getConvertFromFiat(r *http.Request) interface{} {
 	}
 
 	rate := handlers.backend.RatesUpdater().LatestPrice()[unit][from]
-	result := coin.NewAmountFromInt64(0)
-	if rate != 0.0 {
-		amountRat := new(big.Rat).Quo(fiatRat, new(big.Rat).SetFloat64(rate))
-		result = currentCoin.SetAmount(amountRat, false)
-	}
+	result := coinpkg.FromFiat(fiatRat, currentCoin, isFee, rate)
 	return map[string]interface{}{
-		"success": true,
-		"amount":  currentCoin.FormatAmount(result, false),
+		"success":            true,
+		"amount":             currentCoin.FormatAmount(result, false),
+		"amountSmallestUnit": result.BigInt().String(),
+		"ratesInfo":          handlers.backend.RatesUpdater().LatestPriceInfo(),
 	}
 }
 
@@ -1085,6 +1854,136 @@ func (handlers *Handlers) postElectrumCheck(r *http.Request) interface{} {
 	}
 }
 
+func (handlers *Handlers) getSSHTunnelsStatus(*http.Request) interface{} {
+	return handlers.backend.SSHTunnelsStatus()
+}
+
+func (handlers *Handlers) postSSHTunnelsHostKey(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		HostKey      string `json:"hostKey,omitempty"`
+	}
+	var address string
+	if err := json.NewDecoder(r.Body).Decode(&address); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	hostKey, err := handlers.backend.FetchSSHHostKey(address)
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true, HostKey: hostKey}
+}
+
+func (handlers *Handlers) getBandwidthStatus(*http.Request) interface{} {
+	return handlers.backend.BandwidthStatus()
+}
+
+// getDiagnose runs the diagnosis, which can take a while, as it connects to the servers.
+func (handlers *Handlers) getDiagnose(*http.Request) interface{} {
+	return handlers.backend.Diagnose()
+}
+
+func (handlers *Handlers) getClockSkew(*http.Request) interface{} {
+	return handlers.backend.ClockSkew()
+}
+
+func (handlers *Handlers) getPinnedCerts(*http.Request) interface{} {
+	return handlers.backend.PinnedCertificates()
+}
+
+// postCertsCheck downloads the certificate a configured server currently presents, so the user
+// can review it before re-pinning it with postCertsRepin.
+func (handlers *Handlers) postCertsCheck(r *http.Request) interface{} {
+	var jsonBody struct {
+		CoinCode coinpkg.Code `json:"coinCode"`
+		Server   string       `json:"server"`
+	}
+	type response struct {
+		Success      bool                            `json:"success"`
+		Check        *backend.PinnedCertificateCheck `json:"check,omitempty"`
+		ErrorMessage string                          `json:"errorMessage,omitempty"`
+		ErrorCode    string                          `json:"errorCode,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	check, err := handlers.backend.CheckPinnedCertificate(jsonBody.CoinCode, jsonBody.Server)
+	if err != nil {
+		handlers.log.WithError(err).WithField("server", jsonBody.Server).Info("checking certificate failed")
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return response{Success: false, ErrorCode: string(errCode)}
+		}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true, Check: check}
+}
+
+// postCertsRepin pins the certificate the server currently presents. The fingerprint approved by
+// the user must match the certificate.
+func (handlers *Handlers) postCertsRepin(r *http.Request) interface{} {
+	var jsonBody struct {
+		CoinCode    coinpkg.Code `json:"coinCode"`
+		Server      string       `json:"server"`
+		Fingerprint string       `json:"fingerprint"`
+	}
+	type response struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		ErrorCode    string `json:"errorCode,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	err := handlers.backend.RepinCertificate(jsonBody.CoinCode, jsonBody.Server, jsonBody.Fingerprint)
+	if err != nil {
+		handlers.log.WithError(err).WithField("server", jsonBody.Server).Error("re-pinning certificate failed")
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return response{Success: false, ErrorCode: string(errCode)}
+		}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true}
+}
+
+// postElectrumBenchmark benchmarks the configured Electrum servers of a coin and returns them
+// ranked from the best to the worst. If apply is true, the best server is made the preferred one.
+func (handlers *Handlers) postElectrumBenchmark(r *http.Request) interface{} {
+	var jsonBody struct {
+		CoinCode coinpkg.Code `json:"coinCode"`
+		Apply    bool         `json:"apply"`
+	}
+	type response struct {
+		Success      bool                               `json:"success"`
+		Servers      []*backend.ElectrumServerBenchmark `json:"servers,omitempty"`
+		Applied      bool                               `json:"applied"`
+		ErrorMessage string                             `json:"errorMessage,omitempty"`
+		ErrorCode    string                             `json:"errorCode,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	servers, err := handlers.backend.BenchmarkElectrumServers(jsonBody.CoinCode)
+	if err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	applied := false
+	if jsonBody.Apply && len(servers) > 0 && servers[0].Error == "" {
+		if err := handlers.backend.PreferElectrumServer(jsonBody.CoinCode, servers[0].Server); err != nil {
+			handlers.log.WithError(err).WithField("server", servers[0].Server).Error("preferring server failed")
+			if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+				return response{Success: false, Servers: servers, ErrorCode: string(errCode)}
+			}
+			return response{Success: false, Servers: servers, ErrorMessage: err.Error()}
+		}
+		for _, server := range servers {
+			server.Preferred = server == servers[0]
+		}
+		applied = true
+	}
+	return response{Success: true, Servers: servers, Applied: applied}
+}
+
 func (handlers *Handlers) postSocksProxyCheck(r *http.Request) interface{} {
 	type response struct {
 		Success      bool   `json:"success"`
@@ -1151,7 +2050,7 @@ func isAPITokenValid(w http.ResponseWriter, r *http.Request, apiData *Connection
 		methodLogEntry.Error("Missing token in API request. WARNING: this could be an attack on the API")
 		http.Error(w, "missing token "+r.URL.Path, http.StatusUnauthorized)
 		return false
-	} else if len(r.Header.Get("Authorization")) != 0 && r.Header.Get("Authorization") != "Basic "+apiData.token {
+	} else if !apiData.authorized(r.Header.Get("Authorization"), r.Method, r.URL.Path) {
 		methodLogEntry.Error("Incorrect token in API request. WARNING: this could be an attack on the API")
 		http.Error(w, "incorrect token", http.StatusUnauthorized)
 		return false
@@ -1184,6 +2083,11 @@ func (handlers *Handlers) apiMiddleware(devMode bool, h func(*http.Request) (int
 			// allowing it to access the API.
 			w.Header().Set("Access-Control-Allow-Origin", "http://localhost:8080")
 		}
+		if r.Method != http.MethodGet && handlers.backend.RestartRequired() {
+			// The backend is closed after the data directory was moved.
+			writeJSON(w, map[string]string{"error": string(backend.ErrRestartRequired)})
+			return
+		}
 		value, err := h(r)
 		if err != nil {
 			handlers.log.WithError(err).Error("endpoint failed")
@@ -1193,8 +2097,44 @@ func (handlers *Handlers) apiMiddleware(devMode bool, h func(*http.Request) (int
 		writeJSON(w, value)
 	})
 }
-func (handlers *Handlers) getAccountSummary(*http.Request) (interface{}, error) {
-	return handlers.backend.ChartData()
+
+// getAccountSummary returns the chart data. With the `derived` query param set to "true", derived
+// series are included, with the percentage change relative to the optional `rangeStart` (unix
+// timestamp) query param.
+func (handlers *Handlers) getAccountSummary(r *http.Request) (interface{}, error) {
+	chart, err := handlers.backend.ChartData()
+	if err != nil {
+		return nil, err
+	}
+	if r.URL.Query().Get("derived") != "true" {
+		return chart, nil
+	}
+	var rangeStart int64
+	if param := r.URL.Query().Get("rangeStart"); param != "" {
+		rangeStart, err = strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+	}
+	chart.AddDerivedSeries(rangeStart)
+	return chart, nil
+}
+
+func (handlers *Handlers) postContractRegistry(r *http.Request) (interface{}, error) {
+	jsonBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return nil, handlers.backend.UpdateContractRegistry(jsonBytes)
+}
+
+func (handlers *Handlers) postInitializeAccounts(r *http.Request) (interface{}, error) {
+	var codes []accountsTypes.Code
+	if err := json.NewDecoder(r.Body).Decode(&codes); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	handlers.backend.InitializeAccounts(codes)
+	return nil, nil
 }
 
 // getSupportedCoinsHandler returns an array of coin codes for which you can add an account.
@@ -1443,6 +2383,24 @@ func (handlers *Handlers) postSetWatchonly(r *http.Request) interface{} {
 	return response{Success: true}
 }
 
+// postSetWatchonlyAll enables or disables loading all accounts without their keystore.
+func (handlers *Handlers) postSetWatchonlyAll(r *http.Request) interface{} {
+	type response struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var request struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SetWatchonlyAll(request.Enabled); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true}
+}
+
 func (handlers *Handlers) postOnAuthSettingChanged(r *http.Request) interface{} {
 	handlers.backend.Environment().OnAuthSettingChanged(
 		handlers.backend.Config().AppConfig().Backend.Authentication)
@@ -1460,3 +2418,730 @@ func (handlers *Handlers) postExportLog(r *http.Request) interface{} {
 	}
 	return result{Success: true}
 }
+
+func (handlers *Handlers) getPaymentRequests(*http.Request) interface{} {
+	return handlers.backend.PaymentRequests()
+}
+
+func (handlers *Handlers) postDismissPaymentRequest(r *http.Request) interface{} {
+	type result struct {
+		Success bool `json:"success"`
+	}
+	var id int
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		return result{Success: false}
+	}
+	if err := handlers.backend.DismissPaymentRequest(id); err != nil {
+		handlers.log.WithError(err).Error("Could not dismiss payment request")
+		return result{Success: false}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) getWebhooks(*http.Request) interface{} {
+	return handlers.backend.Webhooks()
+}
+
+func (handlers *Handlers) postAddWebhook(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		ID           string `json:"id,omitempty"`
+	}
+	var args backend.WebhookArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	id, err := handlers.backend.AddWebhook(args)
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true, ID: id}
+}
+
+func (handlers *Handlers) postUpdateWebhook(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var request struct {
+		ID string `json:"id"`
+		backend.WebhookArgs
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.UpdateWebhook(request.ID, request.WebhookArgs); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) postDeleteWebhook(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var id string
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.DeleteWebhook(id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) postTestWebhook(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var id string
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.TestWebhook(id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) getRules(*http.Request) interface{} {
+	return handlers.backend.Rules()
+}
+
+func (handlers *Handlers) postAddRule(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		ID           string `json:"id,omitempty"`
+	}
+	var args backend.RuleArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	id, err := handlers.backend.AddRule(args)
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true, ID: id}
+}
+
+func (handlers *Handlers) postUpdateRule(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var request struct {
+		ID string `json:"id"`
+		backend.RuleArgs
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.UpdateRule(request.ID, request.RuleArgs); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) postDeleteRule(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var id string
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.DeleteRule(id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) getRuleDrafts(*http.Request) interface{} {
+	return handlers.backend.RuleDrafts()
+}
+
+func (handlers *Handlers) postDismissRuleDraft(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var id int
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.DismissRuleDraft(id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) getScheduledExportStatus(*http.Request) interface{} {
+	return handlers.backend.ScheduledExportStatus()
+}
+
+func (handlers *Handlers) postSetScheduledExport(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var args backend.ScheduledExportArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SetScheduledExport(args); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) postRunScheduledExport(*http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	if err := handlers.backend.RunScheduledExport(); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+// taxReportArgs parses the method and the year of the tax report. The year is optional and
+// defaults to all years.
+func taxReportArgs(methodArg string, yearArg string) (taxreport.Method, int, error) {
+	method := taxreport.Method(methodArg)
+	if !method.Valid() {
+		return "", 0, errp.Newf("invalid tax report method %q", methodArg)
+	}
+	year := 0
+	if yearArg != "" {
+		var err error
+		year, err = strconv.Atoi(yearArg)
+		if err != nil {
+			return "", 0, errp.WithStack(err)
+		}
+	}
+	return method, year, nil
+}
+
+func (handlers *Handlers) getTaxReport(r *http.Request) interface{} {
+	type result struct {
+		Success      bool              `json:"success"`
+		ErrorMessage string            `json:"errorMessage,omitempty"`
+		Report       *taxreport.Report `json:"report,omitempty"`
+		// SkippedAccounts is the number of accounts which are not synced and not in the report.
+		SkippedAccounts int `json:"skippedAccounts"`
+	}
+	method, year, err := taxReportArgs(r.URL.Query().Get("method"), r.URL.Query().Get("year"))
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	report, skipped, err := handlers.backend.TaxReport(method, year)
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true, Report: report, SkippedAccounts: skipped}
+}
+
+func (handlers *Handlers) postExportTaxReport(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var args struct {
+		Method string `json:"method"`
+		Year   int    `json:"year"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	method, _, err := taxReportArgs(args.Method, "")
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.ExportTaxReport(method, args.Year); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+// postExportLedger exports the transactions of all accounts as a double-entry ledger, see
+// backend.ExportLedger().
+func (handlers *Handlers) postExportLedger(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var args struct {
+		Format ledger.Format `json:"format"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.ExportLedger(args.Format); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) getMetadataSyncStatus(*http.Request) interface{} {
+	return handlers.backend.MetadataSyncStatus()
+}
+
+func (handlers *Handlers) postSetMetadataSync(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var args backend.MetadataSyncArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SetMetadataSync(args); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) postRunMetadataSync(*http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	if err := handlers.backend.RunMetadataSync(); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+// postImportExchangeWithdrawals tags the received transactions matching the withdrawals in the
+// given CSV withdrawal history with their origin.
+func (handlers *Handlers) postImportExchangeWithdrawals(r *http.Request) interface{} {
+	type result struct {
+		Success      bool                   `json:"success"`
+		Result       *exchangeimport.Result `json:"result,omitempty"`
+		ErrorMessage string                 `json:"errorMessage,omitempty"`
+	}
+	var args struct {
+		Exchange string `json:"exchange"`
+		CSV      string `json:"csv"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	importResult, err := handlers.backend.ImportExchangeWithdrawals(args.Exchange, args.CSV)
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true, Result: importResult}
+}
+
+func (handlers *Handlers) getAccountAlerts(r *http.Request) interface{} {
+	type result struct {
+		Success      bool                       `json:"success"`
+		ErrorMessage string                     `json:"errorMessage,omitempty"`
+		Alerts       *backend.AccountAlertsInfo `json:"alerts,omitempty"`
+	}
+	alerts, err := handlers.backend.AccountAlerts(accountsTypes.Code(r.URL.Query().Get("code")))
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true, Alerts: alerts}
+}
+
+func (handlers *Handlers) postSetAccountAlerts(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var request struct {
+		AccountCode accountsTypes.Code `json:"accountCode"`
+		backend.AccountAlertsArgs
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SetAccountAlerts(request.AccountCode, request.AccountAlertsArgs); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) getInvoices(*http.Request) interface{} {
+	return handlers.backend.Invoices()
+}
+
+func (handlers *Handlers) postCreateInvoice(r *http.Request) interface{} {
+	type result struct {
+		Success      bool                 `json:"success"`
+		ErrorMessage string               `json:"errorMessage,omitempty"`
+		Invoice      *backend.InvoiceInfo `json:"invoice,omitempty"`
+	}
+	var args backend.CreateInvoiceArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	invoice, err := handlers.backend.CreateInvoice(args)
+	if err != nil {
+		handlers.log.WithError(err).Error("Could not create invoice")
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true, Invoice: invoice}
+}
+
+func (handlers *Handlers) postUpdateInvoice(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var request struct {
+		ID          string `json:"id"`
+		Memo        string `json:"memo"`
+		CallbackURL string `json:"callbackURL"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.UpdateInvoice(request.ID, request.Memo, request.CallbackURL); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) postDeleteInvoice(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var id string
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.DeleteInvoice(id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) getDataDir(*http.Request) interface{} {
+	return handlers.backend.DataDir()
+}
+
+func (handlers *Handlers) postMoveDataDir(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var request struct {
+		Path string `json:"path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.MoveDataDir(request.Path); err != nil {
+		handlers.log.WithError(err).Error("Could not move the data directory")
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) getProfiles(*http.Request) interface{} {
+	return handlers.backend.Profiles()
+}
+
+func (handlers *Handlers) postAddProfile(r *http.Request) interface{} {
+	type result struct {
+		Success      bool                `json:"success"`
+		ErrorMessage string              `json:"errorMessage,omitempty"`
+		Profile      *utilConfig.Profile `json:"profile,omitempty"`
+	}
+	var name string
+	if err := json.NewDecoder(r.Body).Decode(&name); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	profile, err := handlers.backend.AddProfile(name)
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true, Profile: profile}
+}
+
+func (handlers *Handlers) postSelectProfile(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var id string
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SelectProfile(id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) getSession(*http.Request) interface{} {
+	return handlers.backend.Session()
+}
+
+func (handlers *Handlers) postSessionFrontendState(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var state json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SetFrontendSessionState(state); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) getHWWBridgeStatus(*http.Request) interface{} {
+	return handlers.backend.HWWBridge().Status()
+}
+
+func (handlers *Handlers) postHWWBridgeSetEnabled(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var enabled bool
+	if err := json.NewDecoder(r.Body).Decode(&enabled); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SetHWWBridgeEnabled(enabled); err != nil {
+		handlers.log.WithError(err).Error("Could not change the HWW bridge setting")
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) postHWWBridgeSetApproval(r *http.Request) interface{} {
+	type result struct {
+		Success bool `json:"success"`
+	}
+	var request struct {
+		ID       string `json:"id"`
+		Approved bool   `json:"approved"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.ID == "" {
+		return result{Success: false}
+	}
+	if err := handlers.backend.HWWBridge().SetApproval(request.ID, request.Approved); err != nil {
+		handlers.log.WithError(err).Error("Could not set the HWW bridge approval")
+		return result{Success: false}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) getExtensionsStatus(*http.Request) interface{} {
+	return handlers.backend.Extensions().Status()
+}
+
+func (handlers *Handlers) postExtensionsSetEnabled(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var enabled bool
+	if err := json.NewDecoder(r.Body).Decode(&enabled); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SetExtensionsEnabled(enabled); err != nil {
+		handlers.log.WithError(err).Error("Could not change the extensions setting")
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) postExtensionsRegister(r *http.Request) interface{} {
+	type result struct {
+		Success      bool                  `json:"success"`
+		ErrorMessage string                `json:"errorMessage,omitempty"`
+		Extension    *extensions.Extension `json:"extension,omitempty"`
+		// Token is shown to the user once to be entered in the extension.
+		Token string `json:"token,omitempty"`
+	}
+	var request struct {
+		Name        string                  `json:"name"`
+		Permissions []extensions.Permission `json:"permissions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	extension, token, err := handlers.backend.Extensions().Register(request.Name, request.Permissions)
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true, Extension: extension, Token: token}
+}
+
+func (handlers *Handlers) postExtensionsRevoke(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var id string
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.Extensions().Revoke(id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) getExtensionsWidgets(*http.Request) interface{} {
+	return handlers.backend.Extensions().Widgets()
+}
+
+func (handlers *Handlers) postAPIPairingRequest(r *http.Request) interface{} {
+	type result struct {
+		Success      bool                `json:"success"`
+		Request      *apipairing.Request `json:"request,omitempty"`
+		ErrorMessage string              `json:"errorMessage,omitempty"`
+		ErrorCode    string              `json:"errorCode,omitempty"`
+	}
+	var request struct {
+		Name  string           `json:"name"`
+		Scope apipairing.Scope `json:"scope"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	pairingRequest, err := handlers.backend.APIPairing().Request(request.Name, request.Scope)
+	if err != nil {
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return result{Success: false, ErrorCode: string(errCode)}
+		}
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true, Request: pairingRequest}
+}
+
+func (handlers *Handlers) getAPIPairingRequest(r *http.Request) interface{} {
+	type result struct {
+		Success   bool                `json:"success"`
+		Request   *apipairing.Request `json:"request,omitempty"`
+		ErrorCode string              `json:"errorCode,omitempty"`
+	}
+	request, err := handlers.backend.APIPairing().RequestStatus(mux.Vars(r)["id"])
+	if err != nil {
+		return result{Success: false, ErrorCode: string(apipairing.ErrUnknownRequest)}
+	}
+	return result{Success: true, Request: request}
+}
+
+func (handlers *Handlers) getAPIPairingClients(*http.Request) interface{} {
+	return handlers.backend.APIPairing().Clients()
+}
+
+func (handlers *Handlers) postAPIPairingRevoke(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var id string
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.APIPairing().Revoke(id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) getCompanionHostStatus(*http.Request) interface{} {
+	return handlers.backend.CompanionHost().Status()
+}
+
+func (handlers *Handlers) postCompanionHostSetEnabled(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var enabled bool
+	if err := json.NewDecoder(r.Body).Decode(&enabled); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SetCompanionHostEnabled(enabled); err != nil {
+		handlers.log.WithError(err).Error("Could not change the companion host setting")
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) postCompanionHostStartPairing(*http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		// PairingURI is shown as a QR code to be scanned by the mobile app.
+		PairingURI string `json:"pairingURI,omitempty"`
+	}
+	uri, err := handlers.backend.CompanionHost().StartPairing()
+	if err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true, PairingURI: uri}
+}
+
+func (handlers *Handlers) postCompanionHostUnpair(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var id string
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.CompanionHost().Unpair(id); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) getCompanionClientStatus(*http.Request) interface{} {
+	return handlers.backend.CompanionClient().Status()
+}
+
+func (handlers *Handlers) postCompanionClientPair(r *http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		ErrorCode    string `json:"errorCode,omitempty"`
+	}
+	var request struct {
+		PairingURI string `json:"pairingURI"`
+		Name       string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.PairCompanionClient(request.PairingURI, request.Name); err != nil {
+		handlers.log.WithError(err).Error("Could not pair with the companion host")
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return result{Success: false, ErrorCode: string(errCode)}
+		}
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) postCompanionClientUnpair(*http.Request) interface{} {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	if err := handlers.backend.CompanionClient().Unpair(); err != nil {
+		return result{Success: false, ErrorMessage: err.Error()}
+	}
+	return result{Success: true}
+}
@@ -15,6 +15,8 @@
 package handlers
 
 import (
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -62,7 +64,8 @@ func runWebsocket(conn *websocket.Conn, apiData *ConnectionData, log *logrus.Ent
 				}
 				break
 			}
-			if string(msg) != "Authorization: Basic "+apiData.token {
+			authorization := strings.TrimPrefix(string(msg), "Authorization: ")
+			if authorization == string(msg) || !apiData.authorized(authorization, http.MethodGet, "/api/events") {
 				log.Error("Expected authorization token as first message. Closing websocket.")
 				_ = conn.Close()
 				return
@@ -197,3 +197,18 @@ func TestRunWebsocketCloseSend(t *testing.T) {
 		t.Errorf("client.ReadMessage: %v (%T); want *websocket.CloseError", err, err)
 	}
 }
+
+func TestConnectionDataAuthorized(t *testing.T) {
+	cdata := &ConnectionData{token: "auth-token"}
+	require.True(t, cdata.authorized("Basic auth-token", http.MethodPost, "/api/config"))
+	require.False(t, cdata.authorized("Basic other-token", http.MethodGet, "/api/config"))
+	require.False(t, cdata.authorized("auth-token", http.MethodGet, "/api/config"))
+
+	cdata.authorizePaired = func(token string, method string, path string) bool {
+		return token == "paired-token" && method == http.MethodGet
+	}
+	require.True(t, cdata.authorized("Basic auth-token", http.MethodPost, "/api/config"))
+	require.True(t, cdata.authorized("Basic paired-token", http.MethodGet, "/api/config"))
+	require.False(t, cdata.authorized("Basic paired-token", http.MethodPost, "/api/config"))
+	require.False(t, cdata.authorized("paired-token", http.MethodGet, "/api/config"))
+}
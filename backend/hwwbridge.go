@@ -0,0 +1,70 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/hwwbridge"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// HWWBridge returns the bridge through which external apps can use the connected device.
+func (backend *Backend) HWWBridge() *hwwbridge.Bridge {
+	return backend.hwwBridge
+}
+
+// SetHWWBridgeEnabled persists the setting and starts or stops the bridge accordingly.
+func (backend *Backend) SetHWWBridgeEnabled(enabled bool) error {
+	err := backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		appConfig.Backend.HWWBridgeEnabled = enabled
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if enabled {
+		return backend.hwwBridge.Start()
+	}
+	return backend.hwwBridge.Stop()
+}
+
+// signBridgePSBT signs a PSBT of an external app with the account of the connected keystore which
+// spends the inputs of the PSBT.
+func (backend *Backend) signBridgePSBT(code coinpkg.Code, encoded string) (string, error) {
+	keystore := backend.Keystore()
+	if keystore == nil {
+		return "", errp.New("no keystore connected")
+	}
+	rootFingerprint, err := keystore.RootFingerprint()
+	if err != nil {
+		return "", err
+	}
+	for _, account := range backend.Accounts() {
+		btcAccount, ok := account.(*btc.Account)
+		if !ok || account.Coin().Code() != code || account.Config().Config.Inactive ||
+			!account.Config().Config.SigningConfigurations.ContainsRootFingerprint(rootFingerprint) {
+			continue
+		}
+		signed, _, err := btcAccount.SignPSBT(encoded)
+		if errp.Cause(err) == errors.ErrPSBTForeignInput {
+			continue
+		}
+		return signed, err
+	}
+	return "", errp.WithStack(errors.ErrPSBTForeignInput)
+}
@@ -0,0 +1,252 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hwwbridge
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/httpapi"
+	"github.com/gorilla/mux"
+)
+
+// apiVersion is the version of the bridge API, reported by the /api/info endpoint.
+const apiVersion = "1.0.0"
+
+// maxRequestSize limits the size of request bodies. PSBTs include the previous transactions of
+// their inputs, so they can be large.
+const maxRequestSize = 4 << 20
+
+// bearerPrefix prefixes the token issued by a pairing in the Authorization header.
+const bearerPrefix = "Bearer "
+
+func (bridge *Bridge) router() http.Handler {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/info", bridge.handle(bridge.getInfo, false)).Methods("GET")
+	router.HandleFunc("/api/v1/pair", bridge.handle(bridge.postPair, false)).Methods("POST")
+	router.HandleFunc("/api/v1/pair/{id}/token", bridge.handle(bridge.postPairToken, false)).Methods("POST")
+	router.HandleFunc("/api/v1/keystore", bridge.handle(bridge.getKeystoreInfo, true)).Methods("GET")
+	router.HandleFunc("/api/v1/btc/xpub", bridge.handle(bridge.postBTCXPub, true)).Methods("POST")
+	router.HandleFunc("/api/v1/btc/sign-message", bridge.handle(bridge.postBTCSignMessage, true)).Methods("POST")
+	router.HandleFunc("/api/v1/btc/sign-psbt", bridge.handle(bridge.postBTCSignPSBT, true)).Methods("POST")
+	// CORS preflight requests of browser-based apps.
+	router.Methods("OPTIONS").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !httpapi.IsLocalHost(r.Host) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Vary", "Origin")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return router
+}
+
+// handle wraps an API handler. If authRequired is true, the request is only processed if it is
+// authorized by the token of an app the user approved.
+func (bridge *Bridge) handle(
+	f func(*http.Request) (interface{}, error),
+	authRequired bool,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !httpapi.CheckLocalRequest(w, r, true) {
+			return
+		}
+		origin := r.Header.Get("Origin")
+		if origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Vary", "Origin")
+		}
+		log := bridge.log.WithField("origin", origin)
+		if authRequired {
+			authorization := r.Header.Get("Authorization")
+			if !strings.HasPrefix(authorization, bearerPrefix) {
+				httpapi.WriteError(w, http.StatusUnauthorized, "missing token")
+				return
+			}
+			client, ok := bridge.authorize(strings.TrimPrefix(authorization, bearerPrefix))
+			if !ok {
+				httpapi.WriteError(w, http.StatusUnauthorized, "invalid token")
+				return
+			}
+			log = log.WithField("client", client.Name)
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+		value, err := f(r)
+		if err != nil {
+			status := httpapi.ErrorStatus(err)
+			if errp.Cause(err) == keystore.ErrSigningAborted {
+				status = http.StatusConflict
+			} else if _, ok := errp.Cause(err).(errors.TxValidationError); ok {
+				status = http.StatusBadRequest
+			}
+			log.WithError(err).Info("bridge request failed")
+			httpapi.WriteError(w, status, err.Error())
+			return
+		}
+		httpapi.WriteJSON(w, http.StatusOK, value)
+	}
+}
+
+func (bridge *Bridge) keystore() (keystore.Keystore, error) {
+	ks := bridge.getKeystore()
+	if ks == nil {
+		return nil, httpapi.NewRequestError(http.StatusServiceUnavailable, "no device connected")
+	}
+	return ks, nil
+}
+
+// btcCoin returns the Bitcoin-based coin for the code, if the keystore supports it.
+func (bridge *Bridge) btcCoin(ks keystore.Keystore, code string) (*btc.Coin, error) {
+	c, err := bridge.getCoin(coin.Code(code))
+	if err != nil {
+		return nil, httpapi.NewRequestError(http.StatusBadRequest, "unknown coin")
+	}
+	btcCoin, ok := c.(*btc.Coin)
+	if !ok || !ks.SupportsCoin(btcCoin) {
+		return nil, httpapi.NewRequestError(http.StatusBadRequest, "unsupported coin")
+	}
+	return btcCoin, nil
+}
+
+func (bridge *Bridge) getInfo(*http.Request) (interface{}, error) {
+	return map[string]string{"version": apiVersion}, nil
+}
+
+// postPair requests a pairing. The external app shows the returned code and waits for the user to
+// confirm it with postPairToken.
+func (bridge *Bridge) postPair(r *http.Request) (interface{}, error) {
+	var request struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, httpapi.NewRequestError(http.StatusBadRequest, "invalid request")
+	}
+	client, err := bridge.requestPairing(request.Name, r.Header.Get("Origin"))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"id": client.ID, "code": client.Code}, nil
+}
+
+// postPairToken waits for the user to confirm the pairing and returns the token authorizing the
+// requests of the external app.
+func (bridge *Bridge) postPairToken(r *http.Request) (interface{}, error) {
+	token, err := bridge.waitForApproval(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"token": token}, nil
+}
+
+func (bridge *Bridge) getKeystoreInfo(*http.Request) (interface{}, error) {
+	ks, err := bridge.keystore()
+	if err != nil {
+		return nil, err
+	}
+	rootFingerprint, err := ks.RootFingerprint()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"rootFingerprint": hex.EncodeToString(rootFingerprint)}, nil
+}
+
+func (bridge *Bridge) postBTCXPub(r *http.Request) (interface{}, error) {
+	var request struct {
+		Coin    string `json:"coin"`
+		Keypath string `json:"keypath"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, httpapi.NewRequestError(http.StatusBadRequest, "invalid request")
+	}
+	keypath, err := signing.NewAbsoluteKeypath(request.Keypath)
+	if err != nil {
+		return nil, httpapi.NewRequestError(http.StatusBadRequest, "invalid keypath")
+	}
+	ks, err := bridge.keystore()
+	if err != nil {
+		return nil, err
+	}
+	btcCoin, err := bridge.btcCoin(ks, request.Coin)
+	if err != nil {
+		return nil, err
+	}
+	xpub, err := ks.ExtendedPublicKey(btcCoin, keypath)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"xpub": xpub.String()}, nil
+}
+
+func (bridge *Bridge) postBTCSignMessage(r *http.Request) (interface{}, error) {
+	var request struct {
+		Keypath    string             `json:"keypath"`
+		ScriptType signing.ScriptType `json:"scriptType"`
+		Message    string             `json:"message"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, httpapi.NewRequestError(http.StatusBadRequest, "invalid request")
+	}
+	keypath, err := signing.NewAbsoluteKeypath(request.Keypath)
+	if err != nil {
+		return nil, httpapi.NewRequestError(http.StatusBadRequest, "invalid keypath")
+	}
+	ks, err := bridge.keystore()
+	if err != nil {
+		return nil, err
+	}
+	if !ks.CanSignMessage(coin.CodeBTC) {
+		return nil, httpapi.NewRequestError(http.StatusBadRequest, "message signing not supported")
+	}
+	signature, err := ks.SignBTCMessage([]byte(request.Message), keypath, request.ScriptType)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"signature": base64.StdEncoding.EncodeToString(signature)}, nil
+}
+
+func (bridge *Bridge) postBTCSignPSBT(r *http.Request) (interface{}, error) {
+	var request struct {
+		Coin string `json:"coin"`
+		PSBT string `json:"psbt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, httpapi.NewRequestError(http.StatusBadRequest, "invalid request")
+	}
+	ks, err := bridge.keystore()
+	if err != nil {
+		return nil, err
+	}
+	btcCoin, err := bridge.btcCoin(ks, request.Coin)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := bridge.signPSBT(btcCoin.Code(), request.PSBT)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"psbt": signed}, nil
+}
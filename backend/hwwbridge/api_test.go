@@ -0,0 +1,259 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hwwbridge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/proxy"
+)
+
+const testOrigin = "https://example.com"
+
+var tbtc = btc.NewCoin(coin.CodeTBTC, "Bitcoin Testnet", "TBTC", coin.BtcUnitDefault,
+	&chaincfg.TestNet3Params, ".", []*config.ServerInfo{}, "", proxy.Direct)
+
+func newTestBridge(ks keystore.Keystore) *Bridge {
+	return NewBridge(
+		"127.0.0.1:0",
+		func() keystore.Keystore { return ks },
+		func(code coin.Code) (coin.Coin, error) {
+			if code == coin.CodeTBTC {
+				return tbtc, nil
+			}
+			return nil, errp.New("unknown coin")
+		},
+		func(coin.Code, string) (string, error) { return "", errp.New("no account") },
+	)
+}
+
+func doRequest(bridge *Bridge, method, path, token string, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, "http://127.0.0.1:8179"+path, strings.NewReader(body))
+	request.Header.Set("Origin", testOrigin)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	bridge.router().ServeHTTP(recorder, request)
+	return recorder
+}
+
+// requestPairing requests a pairing and returns its ID and the response of waiting for the token
+// once the user decided.
+func requestPairing(t *testing.T, bridge *Bridge) (string, chan *httptest.ResponseRecorder) {
+	t.Helper()
+	response := doRequest(bridge, "POST", "/api/v1/pair", "", `{"name":"Sparrow"}`)
+	require.Equal(t, http.StatusOK, response.Code)
+	var pairing map[string]string
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &pairing))
+	require.Len(t, pairing["code"], 7)
+	responses := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		responses <- doRequest(bridge, "POST", "/api/v1/pair/"+pairing["id"]+"/token", "", "")
+	}()
+	return pairing["id"], responses
+}
+
+// pair pairs an app approved by the user and returns its token.
+func pair(t *testing.T, bridge *Bridge) string {
+	t.Helper()
+	id, responses := requestPairing(t, bridge)
+	require.NoError(t, bridge.SetApproval(id, true))
+	response := <-responses
+	require.Equal(t, http.StatusOK, response.Code)
+	var result map[string]string
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &result))
+	require.NotEmpty(t, result["token"])
+	return result["token"]
+}
+
+func TestInfo(t *testing.T) {
+	bridge := newTestBridge(nil)
+	response := doRequest(bridge, "GET", "/api/info", "", "")
+	require.Equal(t, http.StatusOK, response.Code)
+	require.JSONEq(t, `{"version":"1.0.0"}`, response.Body.String())
+
+	// Requests to a non-local host are rejected.
+	request := httptest.NewRequest("GET", "http://attacker.example.com/api/info", nil)
+	recorder := httptest.NewRecorder()
+	bridge.router().ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestPairing(t *testing.T) {
+	ks := &mocks.KeystoreMock{
+		RootFingerprintFunc: func() ([]byte, error) {
+			return []byte{0x01, 0x02, 0x03, 0x04}, nil
+		},
+	}
+	bridge := newTestBridge(ks)
+
+	// Requests without a valid token are rejected, without asking the user.
+	response := doRequest(bridge, "GET", "/api/v1/keystore", "", "")
+	require.Equal(t, http.StatusUnauthorized, response.Code)
+	response = doRequest(bridge, "GET", "/api/v1/keystore", "invalid", "")
+	require.Equal(t, http.StatusUnauthorized, response.Code)
+	require.Empty(t, bridge.Status().Pending)
+
+	response = doRequest(bridge, "POST", "/api/v1/pair", "", `{"name":""}`)
+	require.Equal(t, http.StatusBadRequest, response.Code)
+
+	id, responses := requestPairing(t, bridge)
+	pending := bridge.Status().Pending
+	require.Len(t, pending, 1)
+	require.Equal(t, id, pending[0].ID)
+	require.Equal(t, "Sparrow", pending[0].Name)
+	require.Equal(t, testOrigin, pending[0].Origin)
+	require.NoError(t, bridge.SetApproval(id, true))
+	response = <-responses
+	require.Equal(t, http.StatusOK, response.Code)
+	var result map[string]string
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &result))
+	token := result["token"]
+
+	response = doRequest(bridge, "GET", "/api/v1/keystore", token, "")
+	require.Equal(t, http.StatusOK, response.Code)
+	require.JSONEq(t, `{"rootFingerprint":"01020304"}`, response.Body.String())
+	require.Empty(t, bridge.Status().Pending)
+	require.Equal(t, []Client{{ID: id, Name: "Sparrow", Origin: testOrigin}}, bridge.Status().Approved)
+
+	// The token can't be fetched again.
+	response = doRequest(bridge, "POST", "/api/v1/pair/"+id+"/token", "", "")
+	require.Equal(t, http.StatusNotFound, response.Code)
+
+	// Revoked tokens are rejected.
+	require.NoError(t, bridge.SetApproval(id, false))
+	response = doRequest(bridge, "GET", "/api/v1/keystore", token, "")
+	require.Equal(t, http.StatusUnauthorized, response.Code)
+	require.Empty(t, bridge.Status().Approved)
+	require.Error(t, bridge.SetApproval(id, false))
+
+	// Denied pairings don't issue a token.
+	id, responses = requestPairing(t, bridge)
+	require.NoError(t, bridge.SetApproval(id, false))
+	require.Equal(t, http.StatusForbidden, (<-responses).Code)
+	require.Empty(t, bridge.Status().Approved)
+}
+
+func TestWaitForApprovalCanceled(t *testing.T) {
+	bridge := newTestBridge(nil)
+	client, err := bridge.requestPairing("Sparrow", "")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = bridge.waitForApproval(ctx, client.ID)
+	require.Error(t, err)
+	require.Empty(t, bridge.Status().Pending)
+	require.Error(t, bridge.SetApproval(client.ID, true))
+
+	// Pairings nobody waits for expire.
+	client, err = bridge.requestPairing("Sparrow", "")
+	require.NoError(t, err)
+	bridge.pending[client.ID].createdAt = time.Now().Add(-approvalTimeout - time.Second)
+	require.Empty(t, bridge.Status().Pending)
+
+	for i := 0; i < maxPending; i++ {
+		_, err = bridge.requestPairing("Sparrow", "")
+		require.NoError(t, err)
+	}
+	_, err = bridge.requestPairing("Sparrow", "")
+	require.Error(t, err)
+}
+
+func TestSignBTCMessage(t *testing.T) {
+	ks := &mocks.KeystoreMock{
+		CanSignMessageFunc: func(code coin.Code) bool {
+			return code == coin.CodeBTC
+		},
+		SignBTCMessageFunc: func(
+			message []byte, keypath signing.AbsoluteKeypath, scriptType signing.ScriptType) ([]byte, error) {
+			require.Equal(t, "hello", string(message))
+			require.Equal(t, "m/84'/0'/0'/0/0", keypath.Encode())
+			require.Equal(t, signing.ScriptTypeP2WPKH, scriptType)
+			return []byte{0xaa, 0xbb}, nil
+		},
+	}
+	bridge := newTestBridge(ks)
+	token := pair(t, bridge)
+
+	response := doRequest(bridge, "POST", "/api/v1/btc/sign-message", token,
+		`{"keypath":"m/84'/0'/0'/0/0","scriptType":"p2wpkh","message":"hello"}`)
+	require.Equal(t, http.StatusOK, response.Code)
+	var result map[string]string
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &result))
+	require.Equal(t, "qrs=", result["signature"])
+
+	response = doRequest(bridge, "POST", "/api/v1/btc/sign-message", token,
+		`{"keypath":"invalid","scriptType":"p2wpkh","message":"hello"}`)
+	require.Equal(t, http.StatusBadRequest, response.Code)
+
+	ks.SignBTCMessageFunc = func([]byte, signing.AbsoluteKeypath, signing.ScriptType) ([]byte, error) {
+		return nil, errp.WithStack(keystore.ErrSigningAborted)
+	}
+	response = doRequest(bridge, "POST", "/api/v1/btc/sign-message", token,
+		`{"keypath":"m/84'/0'/0'/0/0","scriptType":"p2wpkh","message":"hello"}`)
+	require.Equal(t, http.StatusConflict, response.Code)
+
+	// No keystore connected.
+	bridge = newTestBridge(nil)
+	token = pair(t, bridge)
+	response = doRequest(bridge, "POST", "/api/v1/btc/sign-message", token,
+		`{"keypath":"m/84'/0'/0'/0/0","scriptType":"p2wpkh","message":"hello"}`)
+	require.Equal(t, http.StatusServiceUnavailable, response.Code)
+}
+
+func TestSignBTCPSBT(t *testing.T) {
+	ks := &mocks.KeystoreMock{
+		SupportsCoinFunc: func(coin.Coin) bool { return true },
+	}
+	bridge := newTestBridge(ks)
+	bridge.signPSBT = func(code coin.Code, encoded string) (string, error) {
+		require.Equal(t, coin.CodeTBTC, code)
+		if encoded != "unsigned" {
+			return "", errp.WithStack(errors.ErrPSBTForeignInput)
+		}
+		return "signed", nil
+	}
+	token := pair(t, bridge)
+
+	response := doRequest(bridge, "POST", "/api/v1/btc/sign-psbt", token, `{"coin":"tbtc","psbt":"unsigned"}`)
+	require.Equal(t, http.StatusOK, response.Code)
+	require.JSONEq(t, `{"psbt":"signed"}`, response.Body.String())
+
+	response = doRequest(bridge, "POST", "/api/v1/btc/sign-psbt", token, `{"coin":"tbtc","psbt":"foreign"}`)
+	require.Equal(t, http.StatusBadRequest, response.Code)
+
+	response = doRequest(bridge, "POST", "/api/v1/btc/sign-psbt", token, `{"coin":"eth","psbt":"unsigned"}`)
+	require.Equal(t, http.StatusBadRequest, response.Code)
+
+	response = doRequest(bridge, "POST", "/api/v1/btc/sign-psbt", "", `{"coin":"tbtc","psbt":"unsigned"}`)
+	require.Equal(t, http.StatusUnauthorized, response.Code)
+}
@@ -0,0 +1,372 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hwwbridge implements an opt-in local HTTP API which lets external apps (e.g. Electrum,
+// Sparrow or web wallets) use the hardware wallet connected to the BitBoxApp, similar to the
+// standalone BitBoxBridge. Only a limited set of operations is exposed. An external app first
+// requests a pairing and shows a short code, which the user compares and confirms in the app. The
+// app is then issued a token, which authorizes its requests until the bridge is stopped.
+package hwwbridge
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/httpapi"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultAddress is the address the bridge listens on. The standalone BitBoxBridge uses port
+	// 8178, so we use the next one to allow both to run side by side.
+	DefaultAddress = "127.0.0.1:8179"
+
+	// approvalTimeout is how long a pairing waits for the user to confirm it in the app.
+	approvalTimeout = 2 * time.Minute
+	// maxPending limits the number of pairings waiting for the user, so that the app is not
+	// flooded with requests.
+	maxPending = 5
+	// maxNameLength is the max length of the name of an external app.
+	maxNameLength = 40
+)
+
+// Client is an external app which requested to use the bridge.
+type Client struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Origin is the Origin header of the pairing request, if it was made by a website.
+	Origin string `json:"origin,omitempty"`
+	// Code is shown by the external app while the pairing waits for the user, so that the user can
+	// check that they confirm the right app.
+	Code string `json:"code,omitempty"`
+}
+
+type pendingPairing struct {
+	client    Client
+	createdAt time.Time
+	// done is closed once the pairing is decided or removed.
+	done    chan struct{}
+	decided bool
+	// token is set if the user approved the pairing. The pairing is kept until the external app
+	// fetched the token or the pairing expired.
+	token string
+}
+
+// Status describes the current state of the bridge.
+type Status struct {
+	Running bool   `json:"running"`
+	Address string `json:"address"`
+	// Pending are the pairings waiting for the user to confirm them.
+	Pending []Client `json:"pending"`
+	// Approved are the apps the user approved in this session.
+	Approved []Client `json:"approved"`
+}
+
+// Bridge serves the bridge API. The zero value is not usable, use NewBridge().
+type Bridge struct {
+	observable.Implementation
+
+	address     string
+	getKeystore func() keystore.Keystore
+	getCoin     func(coin.Code) (coin.Coin, error)
+	signPSBT    func(coin.Code, string) (string, error)
+
+	// approved maps the hashes of the issued tokens to the approved apps.
+	approved map[string]Client
+	pending  map[string]*pendingPairing
+	server   *http.Server
+	mu       locker.Locker
+
+	log *logrus.Entry
+}
+
+// NewBridge creates a new bridge listening on the given address once started. getKeystore returns
+// the currently registered keystore, or nil if there is none. getCoin returns the coin for a coin
+// code. signPSBT signs a base64 encoded PSBT with the account of the coin spending its inputs.
+func NewBridge(
+	address string,
+	getKeystore func() keystore.Keystore,
+	getCoin func(coin.Code) (coin.Coin, error),
+	signPSBT func(coin.Code, string) (string, error),
+) *Bridge {
+	return &Bridge{
+		address:     address,
+		getKeystore: getKeystore,
+		getCoin:     getCoin,
+		signPSBT:    signPSBT,
+		approved:    map[string]Client{},
+		pending:     map[string]*pendingPairing{},
+		log:         logging.Get().WithGroup("hwwbridge"),
+	}
+}
+
+func (bridge *Bridge) notifyStatus() {
+	bridge.Notify(observable.Event{
+		Subject: "hww-bridge/status",
+		Action:  action.Reload,
+	})
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func randomHex(numBytes int) (string, error) {
+	value := make([]byte, numBytes)
+	if _, err := rand.Read(value); err != nil {
+		return "", errp.WithStack(err)
+	}
+	return hex.EncodeToString(value), nil
+}
+
+// randomCode returns a random six digit code, e.g. "012 345".
+func randomCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", errp.WithStack(err)
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	return code[:3] + " " + code[3:], nil
+}
+
+func validName(name string) bool {
+	if name == "" || len(name) > maxNameLength {
+		return false
+	}
+	for _, char := range name {
+		if char < ' ' || char > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Start starts listening for requests. It is a no-op if the bridge is already running.
+func (bridge *Bridge) Start() error {
+	defer bridge.mu.Lock()()
+	if bridge.server != nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", bridge.address)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	bridge.server = &http.Server{
+		Handler:           bridge.router(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			bridge.log.WithError(err).Error("bridge server stopped")
+		}
+	}(bridge.server)
+	bridge.log.Infof("bridge listening on %s", bridge.address)
+	go bridge.notifyStatus()
+	return nil
+}
+
+// Stop stops the bridge and revokes all tokens. Pairings waiting for the user are denied.
+func (bridge *Bridge) Stop() error {
+	defer bridge.mu.Lock()()
+	if bridge.server == nil {
+		return nil
+	}
+	// Release waiting requests first so the shutdown does not block on them.
+	for id, pending := range bridge.pending {
+		if !pending.decided {
+			close(pending.done)
+		}
+		delete(bridge.pending, id)
+	}
+	bridge.approved = map[string]Client{}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := bridge.server.Shutdown(ctx)
+	bridge.server = nil
+	go bridge.notifyStatus()
+	return errp.WithStack(err)
+}
+
+// removePending removes a pairing. A token which was not fetched yet is revoked. Must be called
+// with the lock held.
+func (bridge *Bridge) removePending(id string) {
+	pending := bridge.pending[id]
+	if !pending.decided {
+		close(pending.done)
+	}
+	if pending.token != "" {
+		delete(bridge.approved, hashToken(pending.token))
+	}
+	delete(bridge.pending, id)
+	go bridge.notifyStatus()
+}
+
+// removeExpired removes the pairings which were not completed within approvalTimeout, e.g. because
+// the external app did not wait for the decision. Must be called with the lock held.
+func (bridge *Bridge) removeExpired() {
+	for id, pending := range bridge.pending {
+		if time.Since(pending.createdAt) > approvalTimeout {
+			bridge.removePending(id)
+		}
+	}
+}
+
+// Status returns the current state of the bridge.
+func (bridge *Bridge) Status() Status {
+	defer bridge.mu.Lock()()
+	bridge.removeExpired()
+	status := Status{
+		Running:  bridge.server != nil,
+		Address:  bridge.address,
+		Pending:  []Client{},
+		Approved: []Client{},
+	}
+	for _, pending := range bridge.pending {
+		if !pending.decided {
+			status.Pending = append(status.Pending, pending.client)
+		}
+	}
+	for _, client := range bridge.approved {
+		status.Approved = append(status.Approved, client)
+	}
+	sort.Slice(status.Pending, func(i, j int) bool { return status.Pending[i].ID < status.Pending[j].ID })
+	sort.Slice(status.Approved, func(i, j int) bool { return status.Approved[i].ID < status.Approved[j].ID })
+	return status
+}
+
+// requestPairing registers a pairing of an external app, to be confirmed by the user with
+// SetApproval().
+func (bridge *Bridge) requestPairing(name string, origin string) (*Client, error) {
+	if !validName(name) {
+		return nil, httpapi.NewRequestError(http.StatusBadRequest, "invalid name")
+	}
+	defer bridge.mu.Lock()()
+	bridge.removeExpired()
+	if len(bridge.pending) >= maxPending {
+		return nil, httpapi.NewRequestError(http.StatusTooManyRequests, "too many pending pairings")
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	code, err := randomCode()
+	if err != nil {
+		return nil, err
+	}
+	pending := &pendingPairing{
+		client:    Client{ID: id, Name: name, Origin: origin, Code: code},
+		createdAt: time.Now(),
+		done:      make(chan struct{}),
+	}
+	bridge.pending[id] = pending
+	go bridge.notifyStatus()
+	client := pending.client
+	return &client, nil
+}
+
+// SetApproval records the decision of the user for a pending pairing, resuming the external app
+// waiting for it. Passing approved=false for an approved app revokes its token.
+func (bridge *Bridge) SetApproval(id string, approved bool) error {
+	defer bridge.mu.Lock()()
+	if pending, ok := bridge.pending[id]; ok && !pending.decided {
+		if approved {
+			token, err := randomHex(32)
+			if err != nil {
+				return err
+			}
+			pending.token = token
+			client := pending.client
+			client.Code = ""
+			bridge.approved[hashToken(token)] = client
+		}
+		pending.decided = true
+		close(pending.done)
+		go bridge.notifyStatus()
+		return nil
+	}
+	if !approved {
+		if _, ok := bridge.pending[id]; ok {
+			bridge.removePending(id)
+			return nil
+		}
+		for tokenHash, client := range bridge.approved {
+			if client.ID == id {
+				delete(bridge.approved, tokenHash)
+				go bridge.notifyStatus()
+				return nil
+			}
+		}
+	}
+	return httpapi.NewRequestError(http.StatusNotFound, "unknown client")
+}
+
+// waitForApproval blocks until the user approved or denied the pairing, the request is canceled or
+// the pairing times out, and returns the issued token if it was approved. A pairing which is not
+// approved is removed, the external app has to request a new one.
+func (bridge *Bridge) waitForApproval(ctx context.Context, id string) (string, error) {
+	unlock := bridge.mu.Lock()
+	bridge.removeExpired()
+	pending, ok := bridge.pending[id]
+	unlock()
+	if !ok {
+		return "", httpapi.NewRequestError(http.StatusNotFound, "unknown pairing")
+	}
+
+	timer := time.NewTimer(time.Until(pending.createdAt.Add(approvalTimeout)))
+	defer timer.Stop()
+	select {
+	case <-pending.done:
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+
+	defer bridge.mu.Lock()()
+	if bridge.pending[id] != pending {
+		// Removed in the meantime, e.g. by Stop() or another request waiting for it.
+		return "", httpapi.NewRequestError(http.StatusNotFound, "unknown pairing")
+	}
+	if !pending.decided {
+		bridge.removePending(id)
+		return "", httpapi.NewRequestError(http.StatusRequestTimeout, "pairing not confirmed")
+	}
+	// The token is handed out only once.
+	delete(bridge.pending, id)
+	if pending.token == "" {
+		return "", httpapi.NewRequestError(http.StatusForbidden, "pairing denied")
+	}
+	return pending.token, nil
+}
+
+// authorize returns the app the token was issued to, if it is valid.
+func (bridge *Bridge) authorize(token string) (Client, bool) {
+	defer bridge.mu.RLock()()
+	client, ok := bridge.approved[hashToken(token)]
+	return client, ok
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+)
+
+// accountTransactions are the transactions of one account.
+type accountTransactions struct {
+	code         accountsTypes.Code
+	coinCode     coinpkg.Code
+	transactions []*accounts.TransactionData
+}
+
+// internalTransferKey identifies a transaction across the accounts of the same coin.
+type internalTransferKey struct {
+	coinCode coinpkg.Code
+	txID     string
+}
+
+type internalTransferSide struct {
+	code       accountsTypes.Code
+	internalID string
+}
+
+// findInternalTransfers finds the transactions sent by one account to another one of the given
+// accounts. The result maps the account code and the internal tx ID of both sides to the code of
+// the account on the other side. If a transaction paid several of the accounts, the sending side
+// is mapped to the first of them.
+func findInternalTransfers(
+	allTransactions []accountTransactions,
+) map[accountsTypes.Code]map[string]accountsTypes.Code {
+	senders := map[internalTransferKey]internalTransferSide{}
+	receivers := map[internalTransferKey][]internalTransferSide{}
+	for _, account := range allTransactions {
+		for _, tx := range account.transactions {
+			key := internalTransferKey{coinCode: account.coinCode, txID: tx.TxID}
+			side := internalTransferSide{code: account.code, internalID: tx.InternalID}
+			switch tx.Type {
+			case accounts.TxTypeSend:
+				senders[key] = side
+			case accounts.TxTypeReceive:
+				receivers[key] = append(receivers[key], side)
+			}
+		}
+	}
+
+	result := map[accountsTypes.Code]map[string]accountsTypes.Code{}
+	tag := func(side internalTransferSide, counterparty accountsTypes.Code) {
+		if result[side.code] == nil {
+			result[side.code] = map[string]accountsTypes.Code{}
+		}
+		result[side.code][side.internalID] = counterparty
+	}
+	for key, sender := range senders {
+		for _, receiver := range receivers[key] {
+			if receiver.code == sender.code {
+				continue
+			}
+			if _, ok := result[sender.code][sender.internalID]; !ok {
+				tag(sender, receiver.code)
+			}
+			tag(receiver, sender.code)
+		}
+	}
+	return result
+}
+
+// detectInternalTransfers marks the transactions between the loaded accounts as internal
+// transfers on both sides. Accounts which are not synced are skipped and the transfers with them
+// are detected once they are synced.
+func (backend *Backend) detectInternalTransfers() {
+	defer backend.internalTransfersLock.Lock()()
+	var allTransactions []accountTransactions
+	accountsByCode := map[accountsTypes.Code]accounts.Interface{}
+	for _, account := range backend.Accounts() {
+		if account.FatalError() || !account.Synced() {
+			continue
+		}
+		transactions, err := account.Transactions()
+		if err != nil {
+			continue
+		}
+		code := account.Config().Config.Code
+		accountsByCode[code] = account
+		allTransactions = append(allTransactions, accountTransactions{
+			code:         code,
+			coinCode:     account.Coin().Code(),
+			transactions: transactions,
+		})
+	}
+	for code, transfers := range findInternalTransfers(allTransactions) {
+		for internalID, counterparty := range transfers {
+			if err := accountsByCode[code].SetTxInternalTransfer(internalID, counterparty); err != nil {
+				backend.log.WithError(err).Error("Could not mark an internal transfer")
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindInternalTransfers(t *testing.T) {
+	tx := func(txID string, txType accounts.TxType) *accounts.TransactionData {
+		return &accounts.TransactionData{TxID: txID, InternalID: txID + "-internal", Type: txType}
+	}
+	result := findInternalTransfers([]accountTransactions{
+		{
+			code:     "btc-1",
+			coinCode: "btc",
+			transactions: []*accounts.TransactionData{
+				tx("transfer", accounts.TxTypeSend),
+				tx("external", accounts.TxTypeSend),
+				tx("self", accounts.TxTypeSendSelf),
+			},
+		},
+		{
+			code:     "btc-2",
+			coinCode: "btc",
+			transactions: []*accounts.TransactionData{
+				tx("transfer", accounts.TxTypeReceive),
+				tx("deposit", accounts.TxTypeReceive),
+			},
+		},
+		{
+			// Same tx hash, but a different coin, e.g. an ERC20 transfer in the same ETH tx.
+			code:     "eth-1",
+			coinCode: "eth",
+			transactions: []*accounts.TransactionData{
+				tx("transfer", accounts.TxTypeReceive),
+			},
+		},
+	})
+	require.Equal(t, map[accountsTypes.Code]map[string]accountsTypes.Code{
+		"btc-1": {"transfer-internal": "btc-2"},
+		"btc-2": {"transfer-internal": "btc-1"},
+	}, result)
+
+	require.Empty(t, findInternalTransfers(nil))
+}
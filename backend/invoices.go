@@ -0,0 +1,160 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"time"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/invoices"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// InvoiceInfo is an invoice with its amounts formatted in the unit of the account.
+type InvoiceInfo struct {
+	invoices.Invoice
+	FormattedAmount   string `json:"formattedAmount"`
+	FormattedReceived string `json:"formattedReceived"`
+	Unit              string `json:"unit"`
+}
+
+// CreateInvoiceArgs are the arguments to create an invoice.
+type CreateInvoiceArgs struct {
+	AccountCode accountsTypes.Code `json:"accountCode"`
+	// Amount is the requested amount in the unit of the account, e.g. "0.001" for BTC.
+	Amount string `json:"amount"`
+	Memo   string `json:"memo"`
+	// ExpiryMinutes is how long the invoice can be paid.
+	ExpiryMinutes int    `json:"expiryMinutes"`
+	CallbackURL   string `json:"callbackURL"`
+}
+
+// invoiceAccount returns the active account with the given code. Invoices are only supported for
+// BTC and LTC accounts, as they need a fresh address per invoice.
+func (backend *Backend) invoiceAccount(accountCode accountsTypes.Code) (*btc.Account, error) {
+	account := backend.Accounts().lookup(accountCode)
+	if account == nil || account.Config().Config.Inactive {
+		return nil, errp.Newf("unknown account: %s", accountCode)
+	}
+	btcAccount, ok := account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("invoices are only supported for Bitcoin and Litecoin accounts")
+	}
+	return btcAccount, nil
+}
+
+// invoiceUnusedAddresses returns the unused receive addresses of an account, native segwit first.
+func (backend *Backend) invoiceUnusedAddresses(accountCode accountsTypes.Code) ([]string, error) {
+	account, err := backend.invoiceAccount(accountCode)
+	if err != nil {
+		return nil, err
+	}
+	addressLists := account.GetUnusedReceiveAddresses()
+	if addressLists == nil {
+		return nil, errp.New("account not initialized")
+	}
+	preferred, others := []string{}, []string{}
+	for _, addressList := range addressLists {
+		for _, address := range addressList.Addresses {
+			if addressList.ScriptType != nil && *addressList.ScriptType == signing.ScriptTypeP2WPKH {
+				preferred = append(preferred, address.EncodeForHumans())
+			} else {
+				others = append(others, address.EncodeForHumans())
+			}
+		}
+	}
+	return append(preferred, others...), nil
+}
+
+// invoiceReceived returns the total received per receive address of an account, in satoshi.
+func (backend *Backend) invoiceReceived(accountCode accountsTypes.Code) (map[string]int64, error) {
+	account, err := backend.invoiceAccount(accountCode)
+	if err != nil {
+		return nil, err
+	}
+	usages, err := account.ReceiveAddressUsage()
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]int64{}
+	for _, usage := range usages {
+		result[usage.Address] = int64(usage.TotalReceived)
+	}
+	return result, nil
+}
+
+func (backend *Backend) invoiceInfo(invoice invoices.Invoice) InvoiceInfo {
+	info := InvoiceInfo{Invoice: invoice}
+	// The account might have been removed since the invoice was created.
+	if account := backend.Accounts().lookup(invoice.AccountCode); account != nil {
+		coin := account.Coin()
+		info.FormattedAmount = coin.FormatAmount(coinpkg.NewAmountFromInt64(invoice.Amount), false)
+		info.FormattedReceived = coin.FormatAmount(coinpkg.NewAmountFromInt64(invoice.Received), false)
+		info.Unit = coin.GetFormatUnit(false)
+	}
+	return info
+}
+
+// Invoices returns all invoices, newest first.
+func (backend *Backend) Invoices() []InvoiceInfo {
+	result := []InvoiceInfo{}
+	for _, invoice := range backend.invoices.Invoices() {
+		result = append(result, backend.invoiceInfo(invoice))
+	}
+	return result
+}
+
+// CreateInvoice creates an invoice bound to a fresh receive address of the account.
+func (backend *Backend) CreateInvoice(args CreateInvoiceArgs) (*InvoiceInfo, error) {
+	account, err := backend.invoiceAccount(args.AccountCode)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := account.Coin().ParseAmount(args.Amount)
+	if err != nil {
+		return nil, err
+	}
+	amountSat, err := amount.Int64()
+	if err != nil {
+		return nil, err
+	}
+	invoice, err := backend.invoices.Create(invoices.CreateArgs{
+		AccountCode: args.AccountCode,
+		Amount:      amountSat,
+		Memo:        args.Memo,
+		Expiry:      time.Duration(args.ExpiryMinutes) * time.Minute,
+		CallbackURL: args.CallbackURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The payment could already have happened, e.g. if the address was shared before.
+	go backend.invoices.Check(args.AccountCode)
+	info := backend.invoiceInfo(*invoice)
+	return &info, nil
+}
+
+// UpdateInvoice changes the memo and callback URL of an invoice.
+func (backend *Backend) UpdateInvoice(id string, memo string, callbackURL string) error {
+	return backend.invoices.Update(id, memo, callbackURL)
+}
+
+// DeleteInvoice removes an invoice.
+func (backend *Backend) DeleteInvoice(id string) error {
+	return backend.invoices.Delete(id)
+}
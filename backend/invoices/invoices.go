@@ -0,0 +1,432 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package invoices lets small merchants accept payments directly into their wallet. Each invoice is
+// bound to a fresh receive address of an account, and is marked as paid once the address received
+// at least the invoice amount, or as expired once its expiry passes. Status changes are sent as
+// events and, if configured, to a callback URL of the invoice.
+package invoices
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"time"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// checkInterval is how often expired invoices are detected. Payments are detected whenever the
+	// account finishes syncing, see Check().
+	checkInterval = 30 * time.Second
+
+	callbackTimeout = 10 * time.Second
+
+	// maxMemoLength limits the length of the memo of an invoice.
+	maxMemoLength = 500
+)
+
+// Status is the status of an invoice.
+type Status string
+
+const (
+	// StatusOpen means the invoice waits for the payment.
+	StatusOpen Status = "open"
+	// StatusPaid means the address of the invoice received at least the invoice amount before it
+	// expired. Unconfirmed payments count.
+	StatusPaid Status = "paid"
+	// StatusExpired means the invoice was not paid in full before it expired.
+	StatusExpired Status = "expired"
+)
+
+// Invoice is a payment request bound to a receive address.
+type Invoice struct {
+	ID          string             `json:"id"`
+	AccountCode accountsTypes.Code `json:"accountCode"`
+	Address     string             `json:"address"`
+	// Amount is the requested amount in the smallest unit of the coin (e.g. satoshi).
+	Amount int64 `json:"amount"`
+	// Received is the amount received so far on the address, in the smallest unit of the coin.
+	Received    int64      `json:"received"`
+	Memo        string     `json:"memo"`
+	CallbackURL string     `json:"callbackURL"`
+	Status      Status     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	PaidAt      *time.Time `json:"paidAt"`
+}
+
+// CreateArgs are the arguments to create an invoice.
+type CreateArgs struct {
+	AccountCode accountsTypes.Code
+	// Amount is the requested amount in the smallest unit of the coin.
+	Amount      int64
+	Memo        string
+	Expiry      time.Duration
+	CallbackURL string
+}
+
+// Manager stores and watches invoices. The zero value is not usable, use NewManager().
+type Manager struct {
+	observable.Implementation
+
+	file *config.File
+	// unusedAddresses returns unused receive addresses of an account, in order of preference.
+	unusedAddresses func(accountsTypes.Code) ([]string, error)
+	// received returns the total amount received per address of an account, in the smallest unit
+	// of the coin.
+	received   func(accountsTypes.Code) (map[string]int64, error)
+	httpClient *http.Client
+	now        func() time.Time
+
+	invoices []*Invoice
+	mu       locker.Locker
+	quit     chan struct{}
+
+	log *logrus.Entry
+}
+
+// NewManager creates a manager which persists the invoices in the given file. Invoices stored by a
+// previous run are loaded. Callbacks are sent using httpClient.
+func NewManager(
+	filename string,
+	unusedAddresses func(accountsTypes.Code) ([]string, error),
+	received func(accountsTypes.Code) (map[string]int64, error),
+	httpClient *http.Client,
+) *Manager {
+	manager := &Manager{
+		file:            config.NewFile(filepath.Dir(filename), filepath.Base(filename)),
+		unusedAddresses: unusedAddresses,
+		received:        received,
+		httpClient:      httpClient,
+		now:             time.Now,
+		invoices:        []*Invoice{},
+		log:             logging.Get().WithGroup("invoices"),
+	}
+	if manager.file.Exists() {
+		if err := manager.file.ReadJSON(&manager.invoices); err != nil {
+			manager.log.WithError(err).Error("Could not load the invoices")
+			manager.invoices = []*Invoice{}
+		}
+	}
+	return manager
+}
+
+// Start periodically checks for expired invoices until Stop() is called.
+func (manager *Manager) Start() {
+	unlock := manager.mu.Lock()
+	if manager.quit != nil {
+		unlock()
+		return
+	}
+	quit := make(chan struct{})
+	manager.quit = quit
+	unlock()
+
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			manager.checkExpired()
+			select {
+			case <-ticker.C:
+			case <-quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops checking for expired invoices.
+func (manager *Manager) Stop() {
+	defer manager.mu.Lock()()
+	if manager.quit != nil {
+		close(manager.quit)
+		manager.quit = nil
+	}
+}
+
+// save persists the invoices. The lock must be held when calling this function.
+func (manager *Manager) save() error {
+	return manager.file.WriteJSON(manager.invoices)
+}
+
+func (manager *Manager) notify() {
+	manager.Notify(observable.Event{
+		Subject: "invoices",
+		Action:  action.Reload,
+	})
+}
+
+func newInvoiceID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", errp.WithStack(err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+func validateCallbackURL(callbackURL string) error {
+	if callbackURL == "" {
+		return nil
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errp.New("the callback URL must be an http(s) URL")
+	}
+	return nil
+}
+
+// Create creates an invoice bound to an unused receive address of the account, which is not bound
+// to another invoice yet.
+func (manager *Manager) Create(args CreateArgs) (*Invoice, error) {
+	if args.Amount <= 0 {
+		return nil, errp.New("the amount must be positive")
+	}
+	if args.Expiry <= 0 {
+		return nil, errp.New("the expiry must be positive")
+	}
+	if len(args.Memo) > maxMemoLength {
+		return nil, errp.New("the memo is too long")
+	}
+	if err := validateCallbackURL(args.CallbackURL); err != nil {
+		return nil, err
+	}
+	id, err := newInvoiceID()
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := manager.unusedAddresses(args.AccountCode)
+	if err != nil {
+		return nil, err
+	}
+
+	defer manager.mu.Lock()()
+	bound := map[string]bool{}
+	for _, invoice := range manager.invoices {
+		bound[invoice.Address] = true
+	}
+	address := ""
+	for _, candidate := range addresses {
+		if !bound[candidate] {
+			address = candidate
+			break
+		}
+	}
+	if address == "" {
+		return nil, errp.New("no unused address left; wait for open invoices to be paid or delete some")
+	}
+	now := manager.now()
+	invoice := &Invoice{
+		ID:          id,
+		AccountCode: args.AccountCode,
+		Address:     address,
+		Amount:      args.Amount,
+		Memo:        args.Memo,
+		CallbackURL: args.CallbackURL,
+		Status:      StatusOpen,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(args.Expiry),
+	}
+	manager.invoices = append(manager.invoices, invoice)
+	if err := manager.save(); err != nil {
+		manager.invoices = manager.invoices[:len(manager.invoices)-1]
+		return nil, err
+	}
+	manager.notify()
+	result := *invoice
+	return &result, nil
+}
+
+// Invoices returns all invoices, newest first.
+func (manager *Manager) Invoices() []Invoice {
+	defer manager.mu.RLock()()
+	result := make([]Invoice, len(manager.invoices))
+	for i, invoice := range manager.invoices {
+		result[i] = *invoice
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result
+}
+
+func (manager *Manager) find(id string) (int, error) {
+	for i, invoice := range manager.invoices {
+		if invoice.ID == id {
+			return i, nil
+		}
+	}
+	return 0, errp.Newf("unknown invoice: %s", id)
+}
+
+// Update changes the memo and callback URL of an invoice.
+func (manager *Manager) Update(id string, memo string, callbackURL string) error {
+	if len(memo) > maxMemoLength {
+		return errp.New("the memo is too long")
+	}
+	if err := validateCallbackURL(callbackURL); err != nil {
+		return err
+	}
+	defer manager.mu.Lock()()
+	i, err := manager.find(id)
+	if err != nil {
+		return err
+	}
+	invoice := manager.invoices[i]
+	oldMemo, oldCallbackURL := invoice.Memo, invoice.CallbackURL
+	invoice.Memo, invoice.CallbackURL = memo, callbackURL
+	if err := manager.save(); err != nil {
+		invoice.Memo, invoice.CallbackURL = oldMemo, oldCallbackURL
+		return err
+	}
+	manager.notify()
+	return nil
+}
+
+// Delete removes an invoice. Its address can be bound to a new invoice afterwards if it is still
+// unused.
+func (manager *Manager) Delete(id string) error {
+	defer manager.mu.Lock()()
+	i, err := manager.find(id)
+	if err != nil {
+		return err
+	}
+	old := manager.invoices
+	manager.invoices = append(append([]*Invoice{}, old[:i]...), old[i+1:]...)
+	if err := manager.save(); err != nil {
+		manager.invoices = old
+		return err
+	}
+	manager.notify()
+	return nil
+}
+
+func (manager *Manager) hasOpen(accountCode accountsTypes.Code) bool {
+	defer manager.mu.RLock()()
+	for _, invoice := range manager.invoices {
+		if invoice.AccountCode == accountCode && invoice.Status == StatusOpen {
+			return true
+		}
+	}
+	return false
+}
+
+// Check updates the received amounts of the open invoices of an account, and marks them as paid or
+// expired. It should be called whenever the account finished syncing.
+func (manager *Manager) Check(accountCode accountsTypes.Code) {
+	if !manager.hasOpen(accountCode) {
+		return
+	}
+	received, err := manager.received(accountCode)
+	if err != nil {
+		manager.log.WithError(err).Errorf("Could not check the invoices of account %s", accountCode)
+		return
+	}
+	manager.update(func(invoice *Invoice, now time.Time) bool {
+		if invoice.AccountCode != accountCode {
+			return false
+		}
+		changed := invoice.Received != received[invoice.Address]
+		invoice.Received = received[invoice.Address]
+		if invoice.Received >= invoice.Amount && now.Before(invoice.ExpiresAt) {
+			invoice.Status = StatusPaid
+			invoice.PaidAt = &now
+			changed = true
+		}
+		return changed
+	})
+}
+
+func (manager *Manager) checkExpired() {
+	manager.update(func(invoice *Invoice, now time.Time) bool {
+		if now.Before(invoice.ExpiresAt) {
+			return false
+		}
+		invoice.Status = StatusExpired
+		return true
+	})
+}
+
+// update applies f to all open invoices, persists them if f returned true for any of them and
+// sends the callbacks of the invoices which are no longer open.
+func (manager *Manager) update(f func(invoice *Invoice, now time.Time) bool) {
+	unlock := manager.mu.Lock()
+	now := manager.now()
+	changed := false
+	closed := []Invoice{}
+	for _, invoice := range manager.invoices {
+		if invoice.Status != StatusOpen {
+			continue
+		}
+		if f(invoice, now) {
+			changed = true
+		}
+		if invoice.Status != StatusOpen {
+			closed = append(closed, *invoice)
+		}
+	}
+	if changed {
+		if err := manager.save(); err != nil {
+			manager.log.WithError(err).Error("Could not save the invoices")
+		}
+	}
+	unlock()
+
+	if changed {
+		manager.notify()
+	}
+	for _, invoice := range closed {
+		manager.log.Infof("Invoice %s is %s", invoice.ID, invoice.Status)
+		if invoice.CallbackURL != "" {
+			go manager.sendCallback(invoice)
+		}
+	}
+}
+
+// sendCallback posts the invoice as JSON to its callback URL. Failures are only logged.
+func (manager *Manager) sendCallback(invoice Invoice) {
+	body, err := json.Marshal(invoice)
+	if err != nil {
+		manager.log.WithError(err).Error("Could not encode the invoice")
+		return
+	}
+	client := *manager.httpClient
+	client.Timeout = callbackTimeout
+	response, err := client.Post(invoice.CallbackURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		manager.log.WithError(err).Errorf("Callback of invoice %s failed", invoice.ID)
+		return
+	}
+	_ = response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		manager.log.Errorf("Callback of invoice %s failed with status %d", invoice.ID, response.StatusCode)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package invoices
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/stretchr/testify/require"
+)
+
+type testWallet struct {
+	unused   map[accountsTypes.Code][]string
+	received map[accountsTypes.Code]map[string]int64
+}
+
+func (wallet *testWallet) newManager(filename string) *Manager {
+	return NewManager(
+		filename,
+		func(code accountsTypes.Code) ([]string, error) { return wallet.unused[code], nil },
+		func(code accountsTypes.Code) (map[string]int64, error) { return wallet.received[code], nil },
+		http.DefaultClient,
+	)
+}
+
+func TestInvoices(t *testing.T) {
+	wallet := &testWallet{
+		unused: map[accountsTypes.Code][]string{
+			"btc": {"addr1", "addr2", "addr3"},
+		},
+		received: map[accountsTypes.Code]map[string]int64{},
+	}
+	filename := filepath.Join(t.TempDir(), "invoices.json")
+	manager := wallet.newManager(filename)
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	manager.now = func() time.Time { return now }
+
+	_, err := manager.Create(CreateArgs{AccountCode: "btc", Amount: 0, Expiry: time.Hour})
+	require.Error(t, err)
+	_, err = manager.Create(CreateArgs{AccountCode: "btc", Amount: 1000, Expiry: time.Hour, CallbackURL: "ftp://shop"})
+	require.Error(t, err)
+
+	invoice1, err := manager.Create(CreateArgs{AccountCode: "btc", Amount: 1000, Memo: "order 1", Expiry: time.Hour})
+	require.NoError(t, err)
+	require.Equal(t, "addr1", invoice1.Address)
+	require.Equal(t, StatusOpen, invoice1.Status)
+	require.Equal(t, now.Add(time.Hour), invoice1.ExpiresAt)
+
+	now = now.Add(time.Minute)
+	invoice2, err := manager.Create(CreateArgs{AccountCode: "btc", Amount: 2000, Memo: "order 2", Expiry: time.Hour})
+	require.NoError(t, err)
+	// Addresses are not reused for another invoice.
+	require.Equal(t, "addr2", invoice2.Address)
+
+	require.NoError(t, manager.Update(invoice2.ID, "order 2b", ""))
+	require.Error(t, manager.Update("unknown", "", ""))
+
+	invoices := manager.Invoices()
+	require.Len(t, invoices, 2)
+	require.Equal(t, invoice2.ID, invoices[0].ID)
+	require.Equal(t, "order 2b", invoices[0].Memo)
+
+	// A partial payment updates the received amount only.
+	wallet.received["btc"] = map[string]int64{"addr1": 400}
+	manager.Check("btc")
+	invoices = manager.Invoices()
+	require.Equal(t, StatusOpen, invoices[1].Status)
+	require.Equal(t, int64(400), invoices[1].Received)
+
+	wallet.received["btc"] = map[string]int64{"addr1": 1000}
+	manager.Check("btc")
+	invoices = manager.Invoices()
+	require.Equal(t, StatusPaid, invoices[1].Status)
+	require.Equal(t, now, *invoices[1].PaidAt)
+	require.Equal(t, StatusOpen, invoices[0].Status)
+
+	now = now.Add(2 * time.Hour)
+	manager.checkExpired()
+	invoices = manager.Invoices()
+	require.Equal(t, StatusExpired, invoices[0].Status)
+	require.Equal(t, StatusPaid, invoices[1].Status)
+
+	// The invoices are persisted.
+	reloaded := wallet.newManager(filename)
+	require.Equal(t, manager.Invoices(), reloaded.Invoices())
+
+	require.NoError(t, manager.Delete(invoice2.ID))
+	require.Error(t, manager.Delete(invoice2.ID))
+	require.Len(t, manager.Invoices(), 1)
+
+	// Once deleted, the address of an invoice can be bound again.
+	invoice3, err := manager.Create(CreateArgs{AccountCode: "btc", Amount: 3000, Expiry: time.Hour})
+	require.NoError(t, err)
+	require.Equal(t, "addr2", invoice3.Address)
+}
+
+func TestInvoiceCallback(t *testing.T) {
+	callbacks := make(chan Invoice, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var invoice Invoice
+		if err := json.NewDecoder(r.Body).Decode(&invoice); err != nil {
+			panic(err)
+		}
+		callbacks <- invoice
+	}))
+	defer server.Close()
+
+	wallet := &testWallet{
+		unused:   map[accountsTypes.Code][]string{"btc": {"addr1"}},
+		received: map[accountsTypes.Code]map[string]int64{},
+	}
+	manager := wallet.newManager(filepath.Join(t.TempDir(), "invoices.json"))
+	invoice, err := manager.Create(CreateArgs{
+		AccountCode: "btc",
+		Amount:      1000,
+		Expiry:      time.Hour,
+		CallbackURL: server.URL,
+	})
+	require.NoError(t, err)
+	_, err = manager.Create(CreateArgs{AccountCode: "btc", Amount: 1000, Expiry: time.Hour})
+	require.Error(t, err, "all unused addresses are bound")
+
+	wallet.received["btc"] = map[string]int64{"addr1": 1500}
+	manager.Check("btc")
+	select {
+	case paid := <-callbacks:
+		require.Equal(t, invoice.ID, paid.ID)
+		require.Equal(t, StatusPaid, paid.Status)
+		require.Equal(t, int64(1500), paid.Received)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "callback not sent")
+	}
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/ethereum/go-ethereum/core/types"
 )
@@ -38,6 +39,15 @@ const (
 // ErrSigningAborted is used when the user aborts a signing in process (e.g. abort on HW wallet).
 var ErrSigningAborted = errors.New("signing aborted by user")
 
+// ErrDeviceBusy is used when an operation can't be performed because the device is busy with
+// another operation requiring user interaction. It is an error code so that the handlers can pass
+// it to the frontend.
+var ErrDeviceBusy errp.ErrorCode = "deviceBusy"
+
+// ErrKeystoreDisconnected is used when the keystore disconnects during an operation, e.g. when the
+// device is unplugged while signing. The operation can be retried once it is connected again.
+var ErrKeystoreDisconnected = errors.New("keystore disconnected")
+
 // Keystore supports hardened key derivation according to BIP32 and signing of transactions.
 //
 //go:generate moq -pkg mocks -out mocks/keystore.go . Keystore
@@ -80,12 +90,27 @@ type Keystore interface {
 	// Please note that this is only supported if the keystore has a secure output channel.
 	VerifyAddress(*signing.Configuration, coin.Coin) error
 
+	// VerifyMultisigAddress outputs the address of the multisig account configuration at the given
+	// keypath of our key, see RegisterMultisig(). The addresses of multisig accounts can't be
+	// verified with VerifyAddress(), as the keystore needs the account-level keys of all cosigners.
+	VerifyMultisigAddress(
+		coinInstance coin.Coin,
+		accountConfiguration *signing.Configuration,
+		keypath signing.AbsoluteKeypath,
+	) error
+
 	// CanVerifyExtendedPublicKey returns whether the keystore supports to output an xpub/zpub/tbup/ypub securely.
 	CanVerifyExtendedPublicKey() bool
 
 	// VerifyExtendedPublicKey displays the public key on the device for verification
 	VerifyExtendedPublicKey(coin.Coin, *signing.Configuration) error
 
+	// RegisterMultisig registers the multisig configuration of an account under the given name, so
+	// that the keystore can verify its addresses and sign its transactions. The user confirms the
+	// cosigner keys on the device. Registering an already registered configuration does nothing.
+	// Only supported if SupportsAccount() is true for signing.ScriptTypeP2WSH.
+	RegisterMultisig(coinInstance coin.Coin, configuration *signing.Configuration, name string) error
+
 	// ExtendedPublicKey returns the extended public key at the given absolute keypath.
 	ExtendedPublicKey(coin.Coin, signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error)
 
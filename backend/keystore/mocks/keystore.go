@@ -37,6 +37,9 @@ var _ keystore.Keystore = &KeystoreMock{}
 //			NameFunc: func() (string, error) {
 //				panic("mock out the Name method")
 //			},
+//			RegisterMultisigFunc: func(coinInstance coin.Coin, configuration *signing.Configuration, name string) error {
+//				panic("mock out the RegisterMultisig method")
+//			},
 //			RootFingerprintFunc: func() ([]byte, error) {
 //				panic("mock out the RootFingerprint method")
 //			},
@@ -79,6 +82,9 @@ var _ keystore.Keystore = &KeystoreMock{}
 //			VerifyExtendedPublicKeyFunc: func(coinMoqParam coin.Coin, configuration *signing.Configuration) error {
 //				panic("mock out the VerifyExtendedPublicKey method")
 //			},
+//			VerifyMultisigAddressFunc: func(coinInstance coin.Coin, accountConfiguration *signing.Configuration, keypath signing.AbsoluteKeypath) error {
+//				panic("mock out the VerifyMultisigAddress method")
+//			},
 //		}
 //
 //		// use mockedKeystore in code that requires keystore.Keystore
@@ -101,6 +107,9 @@ type KeystoreMock struct {
 	// NameFunc mocks the Name method.
 	NameFunc func() (string, error)
 
+	// RegisterMultisigFunc mocks the RegisterMultisig method.
+	RegisterMultisigFunc func(coinInstance coin.Coin, configuration *signing.Configuration, name string) error
+
 	// RootFingerprintFunc mocks the RootFingerprint method.
 	RootFingerprintFunc func() ([]byte, error)
 
@@ -143,6 +152,9 @@ type KeystoreMock struct {
 	// VerifyExtendedPublicKeyFunc mocks the VerifyExtendedPublicKey method.
 	VerifyExtendedPublicKeyFunc func(coinMoqParam coin.Coin, configuration *signing.Configuration) error
 
+	// VerifyMultisigAddressFunc mocks the VerifyMultisigAddress method.
+	VerifyMultisigAddressFunc func(coinInstance coin.Coin, accountConfiguration *signing.Configuration, keypath signing.AbsoluteKeypath) error
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// CanSignMessage holds details about calls to the CanSignMessage method.
@@ -168,6 +180,15 @@ type KeystoreMock struct {
 		// Name holds details about calls to the Name method.
 		Name []struct {
 		}
+		// RegisterMultisig holds details about calls to the RegisterMultisig method.
+		RegisterMultisig []struct {
+			// CoinInstance is the coinInstance argument value.
+			CoinInstance coin.Coin
+			// Configuration is the configuration argument value.
+			Configuration *signing.Configuration
+			// Name is the name argument value.
+			Name string
+		}
 		// RootFingerprint holds details about calls to the RootFingerprint method.
 		RootFingerprint []struct {
 		}
@@ -248,12 +269,22 @@ type KeystoreMock struct {
 			// Configuration is the configuration argument value.
 			Configuration *signing.Configuration
 		}
+		// VerifyMultisigAddress holds details about calls to the VerifyMultisigAddress method.
+		VerifyMultisigAddress []struct {
+			// CoinInstance is the coinInstance argument value.
+			CoinInstance coin.Coin
+			// AccountConfiguration is the accountConfiguration argument value.
+			AccountConfiguration *signing.Configuration
+			// Keypath is the keypath argument value.
+			Keypath signing.AbsoluteKeypath
+		}
 	}
 	lockCanSignMessage                  sync.RWMutex
 	lockCanVerifyAddress                sync.RWMutex
 	lockCanVerifyExtendedPublicKey      sync.RWMutex
 	lockExtendedPublicKey               sync.RWMutex
 	lockName                            sync.RWMutex
+	lockRegisterMultisig                sync.RWMutex
 	lockRootFingerprint                 sync.RWMutex
 	lockSignBTCMessage                  sync.RWMutex
 	lockSignETHMessage                  sync.RWMutex
@@ -268,6 +299,7 @@ type KeystoreMock struct {
 	lockType                            sync.RWMutex
 	lockVerifyAddress                   sync.RWMutex
 	lockVerifyExtendedPublicKey         sync.RWMutex
+	lockVerifyMultisigAddress           sync.RWMutex
 }
 
 // CanSignMessage calls CanSignMessageFunc.
@@ -424,6 +456,46 @@ func (mock *KeystoreMock) NameCalls() []struct {
 	return calls
 }
 
+// RegisterMultisig calls RegisterMultisigFunc.
+func (mock *KeystoreMock) RegisterMultisig(coinInstance coin.Coin, configuration *signing.Configuration, name string) error {
+	if mock.RegisterMultisigFunc == nil {
+		panic("KeystoreMock.RegisterMultisigFunc: method is nil but Keystore.RegisterMultisig was just called")
+	}
+	callInfo := struct {
+		CoinInstance  coin.Coin
+		Configuration *signing.Configuration
+		Name          string
+	}{
+		CoinInstance:  coinInstance,
+		Configuration: configuration,
+		Name:          name,
+	}
+	mock.lockRegisterMultisig.Lock()
+	mock.calls.RegisterMultisig = append(mock.calls.RegisterMultisig, callInfo)
+	mock.lockRegisterMultisig.Unlock()
+	return mock.RegisterMultisigFunc(coinInstance, configuration, name)
+}
+
+// RegisterMultisigCalls gets all the calls that were made to RegisterMultisig.
+// Check the length with:
+//
+//	len(mockedKeystore.RegisterMultisigCalls())
+func (mock *KeystoreMock) RegisterMultisigCalls() []struct {
+	CoinInstance  coin.Coin
+	Configuration *signing.Configuration
+	Name          string
+} {
+	var calls []struct {
+		CoinInstance  coin.Coin
+		Configuration *signing.Configuration
+		Name          string
+	}
+	mock.lockRegisterMultisig.RLock()
+	calls = mock.calls.RegisterMultisig
+	mock.lockRegisterMultisig.RUnlock()
+	return calls
+}
+
 // RootFingerprint calls RootFingerprintFunc.
 func (mock *KeystoreMock) RootFingerprint() ([]byte, error) {
 	if mock.RootFingerprintFunc == nil {
@@ -886,3 +958,43 @@ func (mock *KeystoreMock) VerifyExtendedPublicKeyCalls() []struct {
 	mock.lockVerifyExtendedPublicKey.RUnlock()
 	return calls
 }
+
+// VerifyMultisigAddress calls VerifyMultisigAddressFunc.
+func (mock *KeystoreMock) VerifyMultisigAddress(coinInstance coin.Coin, accountConfiguration *signing.Configuration, keypath signing.AbsoluteKeypath) error {
+	if mock.VerifyMultisigAddressFunc == nil {
+		panic("KeystoreMock.VerifyMultisigAddressFunc: method is nil but Keystore.VerifyMultisigAddress was just called")
+	}
+	callInfo := struct {
+		CoinInstance         coin.Coin
+		AccountConfiguration *signing.Configuration
+		Keypath              signing.AbsoluteKeypath
+	}{
+		CoinInstance:         coinInstance,
+		AccountConfiguration: accountConfiguration,
+		Keypath:              keypath,
+	}
+	mock.lockVerifyMultisigAddress.Lock()
+	mock.calls.VerifyMultisigAddress = append(mock.calls.VerifyMultisigAddress, callInfo)
+	mock.lockVerifyMultisigAddress.Unlock()
+	return mock.VerifyMultisigAddressFunc(coinInstance, accountConfiguration, keypath)
+}
+
+// VerifyMultisigAddressCalls gets all the calls that were made to VerifyMultisigAddress.
+// Check the length with:
+//
+//	len(mockedKeystore.VerifyMultisigAddressCalls())
+func (mock *KeystoreMock) VerifyMultisigAddressCalls() []struct {
+	CoinInstance         coin.Coin
+	AccountConfiguration *signing.Configuration
+	Keypath              signing.AbsoluteKeypath
+} {
+	var calls []struct {
+		CoinInstance         coin.Coin
+		AccountConfiguration *signing.Configuration
+		Keypath              signing.AbsoluteKeypath
+	}
+	mock.lockVerifyMultisigAddress.RLock()
+	calls = mock.calls.VerifyMultisigAddress
+	mock.lockVerifyMultisigAddress.RUnlock()
+	return calls
+}
@@ -159,6 +159,12 @@ func (keystore *Keystore) VerifyAddress(*signing.Configuration, coin.Coin) error
 	return errp.New("The software-based keystore has no secure output to display the address.")
 }
 
+// VerifyMultisigAddress implements keystore.Keystore.
+func (keystore *Keystore) VerifyMultisigAddress(
+	coin.Coin, *signing.Configuration, signing.AbsoluteKeypath) error {
+	return errp.New("The software-based keystore has no secure output to display the address.")
+}
+
 // CanVerifyExtendedPublicKey implements keystore.Keystore.
 func (keystore *Keystore) CanVerifyExtendedPublicKey() bool {
 	return false
@@ -169,6 +175,11 @@ func (keystore *Keystore) VerifyExtendedPublicKey(coin coin.Coin, configuration
 	return errp.New("The software-based keystore has no secure output to display the public key.")
 }
 
+// RegisterMultisig implements keystore.Keystore.
+func (keystore *Keystore) RegisterMultisig(coin.Coin, *signing.Configuration, string) error {
+	return errp.New("The software-based keystore does not support multisig accounts.")
+}
+
 // ExtendedPublicKey implements keystore.Keystore.
 func (keystore *Keystore) ExtendedPublicKey(
 	coin coin.Coin, absoluteKeypath signing.AbsoluteKeypath,
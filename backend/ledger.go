@@ -0,0 +1,172 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/ledger"
+	utilConfig "github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// ledgerNarrations are the narrations of the transactions without a note.
+var ledgerNarrations = map[accounts.TxType]string{
+	accounts.TxTypeReceive:  "Received",
+	accounts.TxTypeSend:     "Sent",
+	accounts.TxTypeSendSelf: "Sent to yourself",
+}
+
+// ledgerTransactions returns the ledger transactions of an account, valued using the historical
+// rates in the given fiat currency.
+//
+// The account is booked as "Assets:BitBox:<unit>:<account name>". Received and sent amounts are
+// booked against the income or expenses account of the category of the transaction, and transfers
+// between the loaded accounts against ledger.TransfersAccount. Fees are booked against
+// "Expenses:Fees:<unit>". As in the tax report, spam transactions, transactions which cannot be
+// dated and fees paid in a different unit are skipped.
+func (backend *Backend) ledgerTransactions(
+	account accounts.Interface, transactions accounts.OrderedTransactions, fiat string) []ledger.Transaction {
+	accountCoin := account.Coin()
+	unit := accountCoin.Unit(false)
+	decimalsExp := coin.DecimalsExp(accountCoin)
+	assetAccount := ledger.Account("Assets", "BitBox", unit, account.Config().Config.Name)
+	result := []ledger.Transaction{}
+	for _, transaction := range transactions {
+		spam := transaction.Spam
+		if userSpam, ok := account.TxSpam(transaction.InternalID); ok {
+			spam = userSpam
+		}
+		if spam {
+			continue
+		}
+		at := transaction.CreatedTimestamp
+		if transaction.Timestamp != nil {
+			at = transaction.Timestamp
+		}
+		if at == nil {
+			continue
+		}
+		var price *big.Rat
+		if rate := backend.ratesUpdater.HistoricalPriceAt(string(accountCoin.Code()), fiat, *at); rate != 0 {
+			price = new(big.Rat).SetFloat64(rate)
+		}
+		addPosting := func(postings []ledger.Posting, counterparty string, amount coin.Amount, negative bool) []ledger.Posting {
+			value := new(big.Rat).SetFrac(amount.BigInt(), decimalsExp)
+			if value.Sign() == 0 {
+				return postings
+			}
+			if negative {
+				value.Neg(value)
+			}
+			posting := ledger.Posting{
+				Account:      assetAccount,
+				Counterparty: counterparty,
+				Commodity:    unit,
+				Decimals:     accountCoin.Decimals(false),
+				Amount:       value,
+			}
+			if price != nil {
+				posting.Value = new(big.Rat).Mul(new(big.Rat).Abs(value), price)
+			}
+			return append(postings, posting)
+		}
+
+		counterparty := ledger.CategoryAccount(
+			account.TxCategory(transaction.InternalID), transaction.Type == accounts.TxTypeReceive)
+		if account.TxInternalTransfer(transaction.InternalID) != "" {
+			counterparty = ledger.TransfersAccount
+		}
+		postings := []ledger.Posting{}
+		if transaction.Status != accounts.TxStatusFailed {
+			switch transaction.Type {
+			case accounts.TxTypeReceive:
+				postings = addPosting(postings, counterparty, transaction.Amount, false)
+			case accounts.TxTypeSend:
+				postings = addPosting(postings, counterparty, transaction.Amount, true)
+			}
+		}
+		if transaction.Type != accounts.TxTypeReceive && transaction.Fee != nil && !transaction.FeeIsDifferentUnit {
+			postings = addPosting(postings, ledger.Account("Expenses", "Fees", unit), *transaction.Fee, true)
+		}
+		if len(postings) == 0 {
+			continue
+		}
+		narration := account.TxNote(transaction.InternalID)
+		if narration == "" {
+			narration = ledgerNarrations[transaction.Type]
+		}
+		result = append(result, ledger.Transaction{
+			Time:      *at,
+			TxID:      transaction.TxID,
+			Narration: narration,
+			Postings:  postings,
+		})
+	}
+	return result
+}
+
+// ExportLedger writes the transactions of all synced accounts as a double-entry ledger in the
+// given format to a file chosen by the user and opens it. The values are in the main fiat
+// currency.
+func (backend *Backend) ExportLedger(format ledger.Format) error {
+	if !format.Valid() {
+		return errp.Newf("unknown ledger format %q", format)
+	}
+	fiat := backend.config.AppConfig().Backend.MainFiat
+	transactions := []ledger.Transaction{}
+	for _, account := range backend.Accounts() {
+		if account.Config().Config.HiddenBecauseUnused {
+			continue
+		}
+		if account.FatalError() || !account.Synced() {
+			continue
+		}
+		accountTransactions, err := account.Transactions()
+		if err != nil {
+			backend.log.WithError(err).Error("Could not get the transactions for the ledger export")
+			continue
+		}
+		transactions = append(transactions, backend.ledgerTransactions(account, accountTransactions, fiat)...)
+	}
+	name := fmt.Sprintf("%s-ledger.%s", time.Now().Format("2006-01-02-at-15-04-05"), format)
+	exportsDir, err := utilConfig.ExportsDir()
+	if err != nil {
+		return err
+	}
+	path := backend.Environment().GetSaveFilename(filepath.Join(exportsDir, name))
+	if path == "" {
+		return nil
+	}
+	backend.log.Infof("Export ledger to %s.", path)
+	file, err := os.Create(path)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if err := ledger.Write(file, format, fiat, transactions); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return errp.WithStack(err)
+	}
+	return backend.Environment().SystemOpen(path)
+}
@@ -0,0 +1,271 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ledger writes transactions as a double-entry ledger in the plain text formats of
+// Beancount (https://beancount.github.io) and Ledger-CLI (https://ledger-cli.org).
+package ledger
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// Format is a plain text ledger format.
+type Format string
+
+const (
+	// FormatBeancount is the Beancount format.
+	FormatBeancount Format = "beancount"
+	// FormatLedger is the Ledger-CLI format, which is also read by hledger.
+	FormatLedger Format = "ledger"
+)
+
+// Valid returns true if the format is one of the supported formats.
+func (format Format) Valid() bool {
+	return format == FormatBeancount || format == FormatLedger
+}
+
+const (
+	// TransfersAccount is the counterparty of the transfers between the user's own accounts. Both
+	// sides of a transfer are booked against it, so its balance is zero once both are included.
+	TransfersAccount = "Equity:Transfers"
+
+	// uncategorized is the category of the transactions without a category.
+	uncategorized = "Uncategorized"
+)
+
+// rootAccounts are the top-level accounts of a ledger.
+var rootAccounts = []string{"Assets", "Liabilities", "Equity", "Income", "Expenses"}
+
+// Posting is a change of the balance of an account, booked against a counterparty account.
+type Posting struct {
+	// Account is the account of the wallet, e.g. "Assets:BitBox:BTC:Savings".
+	Account string
+	// Counterparty is the account on the other side, e.g. "Expenses:Groceries".
+	Counterparty string
+	// Commodity is the unit of the amount, e.g. "BTC".
+	Commodity string
+	// Decimals is the number of decimals of the commodity, used to format the amount.
+	Decimals uint
+	// Amount is the change of the balance of Account. It is positive for received coins.
+	Amount *big.Rat
+	// Value is the fiat value of the absolute amount at the time of the transaction, or nil if it
+	// is not known. If it is known, the counterparty is booked in fiat at this value.
+	Value *big.Rat
+}
+
+// Transaction is a ledger transaction consisting of one or more postings.
+type Transaction struct {
+	Time      time.Time
+	TxID      string
+	Narration string
+	Postings  []Posting
+}
+
+// component converts a name to an account name component, e.g. "my savings" to "MySavings".
+// Account name components must start with an uppercase letter or a digit and can only contain
+// letters, digits and dashes.
+func component(name string) string {
+	var result strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-')
+	}) {
+		word = strings.Trim(word, "-")
+		if word == "" {
+			continue
+		}
+		first, size := utf8.DecodeRuneInString(word)
+		result.WriteRune(unicode.ToUpper(first))
+		result.WriteString(word[size:])
+	}
+	return result.String()
+}
+
+// Account joins the root account and the names to an account name, e.g. "Assets:BitBox:BTC" for
+// "Assets", "BitBox", "BTC". The names are converted to valid account name components, empty ones
+// are skipped.
+func Account(root string, names ...string) string {
+	parts := []string{root}
+	for _, name := range names {
+		if part := component(name); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ":")
+}
+
+// CategoryAccount returns the counterparty account of a received or sent transaction of the given
+// category, e.g. "Expenses:Food:Groceries" for the sent category "Food:Groceries". Categories
+// starting with a root account, e.g. "Income:Salary", are used as is.
+func CategoryAccount(category string, received bool) string {
+	names := strings.Split(category, ":")
+	root := "Expenses"
+	if received {
+		root = "Income"
+	}
+	for _, rootAccount := range rootAccounts {
+		if component(names[0]) == rootAccount {
+			root = rootAccount
+			names = names[1:]
+			break
+		}
+	}
+	account := Account(root, names...)
+	if account == root {
+		account = Account(root, uncategorized)
+	}
+	return account
+}
+
+// commodity converts a unit to a valid commodity name, e.g. "sat" to "SAT".
+func commodity(unit string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r >= 'a' && r <= 'z':
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, unit)
+}
+
+// writer formats the transactions in one of the formats.
+type writer struct {
+	format Format
+	fiat   string
+}
+
+func (w *writer) date(t time.Time) string {
+	if w.format == FormatBeancount {
+		return t.UTC().Format("2006-01-02")
+	}
+	return t.UTC().Format("2006/01/02")
+}
+
+// amount formats an amount of a commodity. Ledger-CLI requires commodities which contain digits
+// to be quoted.
+func (w *writer) amount(amount *big.Rat, unit string, decimals uint) string {
+	name := commodity(unit)
+	if w.format == FormatLedger && strings.ContainsAny(name, "0123456789") {
+		name = `"` + name + `"`
+	}
+	return amount.FloatString(int(decimals)) + " " + name
+}
+
+func (w *writer) fiatAmount(value *big.Rat) string {
+	return coin.FormatAsPlainCurrency(value, w.fiat) + " " + commodity(w.fiat)
+}
+
+func (w *writer) header(transactions []Transaction) string {
+	var result strings.Builder
+	if w.format == FormatLedger {
+		fmt.Fprintf(&result, "; Exported from the BitBoxApp. Values in %s.\n\n", commodity(w.fiat))
+		return result.String()
+	}
+	result.WriteString("option \"title\" \"BitBoxApp\"\n")
+	fmt.Fprintf(&result, "option \"operating_currency\" \"%s\"\n\n", commodity(w.fiat))
+	if len(transactions) == 0 {
+		return result.String()
+	}
+	// Beancount requires all accounts to be opened before they are used.
+	accounts := map[string]struct{}{}
+	for _, transaction := range transactions {
+		for _, posting := range transaction.Postings {
+			accounts[posting.Account] = struct{}{}
+			accounts[posting.Counterparty] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(accounts))
+	for name := range accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&result, "%s open %s\n", w.date(transactions[0].Time), name)
+	}
+	result.WriteString("\n")
+	return result.String()
+}
+
+// escapeNarration removes line breaks, and escapes the narration for the quoted strings of
+// Beancount.
+func (w *writer) escapeNarration(narration string) string {
+	narration = strings.NewReplacer("\n", " ", "\r", "").Replace(narration)
+	if w.format == FormatBeancount {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(narration) + `"`
+	}
+	return narration
+}
+
+func (w *writer) transaction(transaction Transaction) string {
+	var result strings.Builder
+	indent := "  "
+	if w.format == FormatLedger {
+		indent = "    "
+	}
+	fmt.Fprintf(&result, "%s * %s\n", w.date(transaction.Time), w.escapeNarration(transaction.Narration))
+	if transaction.TxID != "" {
+		if w.format == FormatBeancount {
+			fmt.Fprintf(&result, "%stxid: \"%s\"\n", indent, transaction.TxID)
+		} else {
+			fmt.Fprintf(&result, "%s; txid: %s\n", indent, transaction.TxID)
+		}
+	}
+	for _, posting := range transaction.Postings {
+		amount := w.amount(posting.Amount, posting.Commodity, posting.Decimals)
+		counterAmount := w.amount(new(big.Rat).Neg(posting.Amount), posting.Commodity, posting.Decimals)
+		if posting.Value != nil && commodity(posting.Commodity) != commodity(w.fiat) {
+			value := new(big.Rat).Abs(posting.Value)
+			amount += " @@ " + w.fiatAmount(value)
+			if posting.Amount.Sign() > 0 {
+				value.Neg(value)
+			}
+			counterAmount = w.fiatAmount(value)
+		}
+		fmt.Fprintf(&result, "%s%s  %s\n", indent, posting.Account, amount)
+		fmt.Fprintf(&result, "%s%s  %s\n", indent, posting.Counterparty, counterAmount)
+	}
+	result.WriteString("\n")
+	return result.String()
+}
+
+// Write writes the transactions in the format, sorted by time. The fiat values are in the given
+// fiat currency.
+func Write(w io.Writer, format Format, fiat string, transactions []Transaction) error {
+	if !format.Valid() {
+		return errp.Newf("unknown ledger format %q", format)
+	}
+	transactions = append([]Transaction{}, transactions...)
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].Time.Before(transactions[j].Time)
+	})
+	ledgerWriter := &writer{format: format, fiat: fiat}
+	var result strings.Builder
+	result.WriteString(ledgerWriter.header(transactions))
+	for _, transaction := range transactions {
+		result.WriteString(ledgerWriter.transaction(transaction))
+	}
+	_, err := io.WriteString(w, result.String())
+	return errp.WithStack(err)
+}
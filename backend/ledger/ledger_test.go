@@ -0,0 +1,122 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccount(t *testing.T) {
+	require.Equal(t, "Assets:BitBox:BTC:MySavings", Account("Assets", "BitBox", "BTC", "my savings"))
+	require.Equal(t, "Assets:BitBox:Spärkonto-2:Ärzte", Account("Assets", "BitBox", "Spärkonto-2 ✓", "!", "ärzte"))
+
+	require.Equal(t, "Expenses:Uncategorized", CategoryAccount("", false))
+	require.Equal(t, "Income:Uncategorized", CategoryAccount(" ", true))
+	require.Equal(t, "Expenses:Food:Groceries", CategoryAccount("food:groceries", false))
+	require.Equal(t, "Income:Food", CategoryAccount("Food", true))
+	require.Equal(t, "Income:Salary", CategoryAccount("income:Salary", false))
+	require.Equal(t, "Equity:Uncategorized", CategoryAccount("Equity", true))
+}
+
+func testTransactions() []Transaction {
+	at := time.Date(2024, 3, 2, 23, 30, 0, 0, time.FixedZone("", -2*3600))
+	return []Transaction{
+		{
+			Time:      at,
+			TxID:      "txid-2",
+			Narration: "Dinner \"Luigi's\"\nwith friends",
+			Postings: []Posting{
+				{
+					Account:      "Assets:BitBox:BTC:Savings",
+					Counterparty: "Expenses:Food",
+					Commodity:    "BTC",
+					Decimals:     8,
+					Amount:       big.NewRat(-1, 1000),
+					Value:        big.NewRat(6005, 100),
+				},
+				{
+					Account:      "Assets:BitBox:BTC:Savings",
+					Counterparty: "Expenses:Fees:BTC",
+					Commodity:    "BTC",
+					Decimals:     8,
+					Amount:       big.NewRat(-1, 100000),
+				},
+			},
+		},
+		{
+			Time:      at.Add(-24 * time.Hour),
+			TxID:      "txid-1",
+			Narration: "Received",
+			Postings: []Posting{
+				{
+					Account:      "Assets:BitBox:BTC:Savings",
+					Counterparty: "Income:Salary",
+					Commodity:    "BTC",
+					Decimals:     8,
+					Amount:       big.NewRat(1, 100),
+					Value:        big.NewRat(600, 1),
+				},
+			},
+		},
+	}
+}
+
+func TestWriteBeancount(t *testing.T) {
+	var result bytes.Buffer
+	require.NoError(t, Write(&result, FormatBeancount, "EUR", testTransactions()))
+	require.Equal(t, `option "title" "BitBoxApp"
+option "operating_currency" "EUR"
+
+2024-03-02 open Assets:BitBox:BTC:Savings
+2024-03-02 open Expenses:Fees:BTC
+2024-03-02 open Expenses:Food
+2024-03-02 open Income:Salary
+
+2024-03-02 * "Received"
+  txid: "txid-1"
+  Assets:BitBox:BTC:Savings  0.01000000 BTC @@ 600.00 EUR
+  Income:Salary  -600.00 EUR
+
+2024-03-03 * "Dinner \"Luigi's\" with friends"
+  txid: "txid-2"
+  Assets:BitBox:BTC:Savings  -0.00100000 BTC @@ 60.05 EUR
+  Expenses:Food  60.05 EUR
+  Assets:BitBox:BTC:Savings  -0.00001000 BTC
+  Expenses:Fees:BTC  0.00001000 BTC
+
+`, result.String())
+}
+
+func TestWriteLedger(t *testing.T) {
+	transactions := testTransactions()[1:]
+	transactions[0].Postings[0].Commodity = "tbtc4"
+	var result bytes.Buffer
+	require.NoError(t, Write(&result, FormatLedger, "EUR", transactions))
+	require.Equal(t, `; Exported from the BitBoxApp. Values in EUR.
+
+2024/03/02 * Received
+    ; txid: txid-1
+    Assets:BitBox:BTC:Savings  0.01000000 "TBTC4" @@ 600.00 EUR
+    Income:Salary  -600.00 EUR
+
+`, result.String())
+
+	require.Error(t, Write(&result, "csv", "EUR", nil))
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsMocks "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/mocks"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/ledger"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerTransactions(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+	b.ratesUpdater = rates.MockRateUpdater()
+	btcCoin, err := b.Coin(coinpkg.CodeBTC)
+	require.NoError(t, err)
+
+	account := &accountsMocks.InterfaceMock{
+		CoinFunc: func() coinpkg.Coin { return btcCoin },
+		ConfigFunc: func() *accounts.AccountConfig {
+			return &accounts.AccountConfig{Config: &config.Account{Code: "v0-55555555-btc-0", Name: "my savings"}}
+		},
+		TxSpamFunc: func(txID string) (bool, bool) { return false, false },
+		TxInternalTransferFunc: func(txID string) accountsTypes.Code {
+			if txID == "transfer" {
+				return "v0-55555555-btc-1"
+			}
+			return ""
+		},
+		TxCategoryFunc: func(txID string) string {
+			return map[string]string{"sell": "Food", "buy": "Income:Salary"}[txID]
+		},
+		TxNoteFunc: func(txID string) string {
+			return map[string]string{"sell": "Dinner"}[txID]
+		},
+	}
+
+	bought := time.Unix(1598832062, 0)
+	sold := time.Unix(1598918700, 0)
+	fee := coinpkg.NewAmountFromInt64(1000)
+	tx := func(txID string, txType accounts.TxType, amount int64, at *time.Time) *accounts.TransactionData {
+		return &accounts.TransactionData{
+			TxID:       txID,
+			InternalID: txID,
+			Type:       txType,
+			Status:     accounts.TxStatusComplete,
+			Amount:     coinpkg.NewAmountFromInt64(amount),
+			Fee:        &fee,
+			Timestamp:  at,
+		}
+	}
+	spam := tx("spam", accounts.TxTypeReceive, 1, &sold)
+	spam.Spam = true
+	failed := tx("failed", accounts.TxTypeSend, 1e8, &sold)
+	failed.Status = accounts.TxStatusFailed
+	transactions := accounts.OrderedTransactions{
+		tx("sell", accounts.TxTypeSend, 5e7, &sold),
+		tx("self", accounts.TxTypeSendSelf, 5e7, &sold),
+		tx("transfer", accounts.TxTypeSend, 5e7, &sold),
+		failed,
+		spam,
+		tx("buy", accounts.TxTypeReceive, 1e8, &bought),
+		tx("no-time", accounts.TxTypeReceive, 1e8, nil),
+	}
+
+	const asset = "Assets:BitBox:BTC:MySavings"
+	feePosting := ledger.Posting{
+		Account:      asset,
+		Counterparty: "Expenses:Fees:BTC",
+		Commodity:    "BTC",
+		Decimals:     8,
+		Amount:       big.NewRat(-1000, 1e8),
+		Value:        big.NewRat(2000, 1e8),
+	}
+	require.Equal(t, []ledger.Transaction{
+		{
+			Time:      sold,
+			TxID:      "sell",
+			Narration: "Dinner",
+			Postings: []ledger.Posting{
+				{
+					Account:      asset,
+					Counterparty: "Expenses:Food",
+					Commodity:    "BTC",
+					Decimals:     8,
+					Amount:       big.NewRat(-1, 2),
+					Value:        big.NewRat(1, 1),
+				},
+				feePosting,
+			},
+		},
+		{Time: sold, TxID: "self", Narration: "Sent to yourself", Postings: []ledger.Posting{feePosting}},
+		{
+			Time:      sold,
+			TxID:      "transfer",
+			Narration: "Sent",
+			Postings: []ledger.Posting{
+				{
+					Account:      asset,
+					Counterparty: ledger.TransfersAccount,
+					Commodity:    "BTC",
+					Decimals:     8,
+					Amount:       big.NewRat(-1, 2),
+					Value:        big.NewRat(1, 1),
+				},
+				feePosting,
+			},
+		},
+		{Time: sold, TxID: "failed", Narration: "Sent", Postings: []ledger.Posting{feePosting}},
+		{
+			Time:      bought,
+			TxID:      "buy",
+			Narration: "Received",
+			Postings: []ledger.Posting{
+				{
+					Account:      asset,
+					Counterparty: "Income:Salary",
+					Commodity:    "BTC",
+					Decimals:     8,
+					Amount:       big.NewRat(1, 1),
+					Value:        big.NewRat(1, 1),
+				},
+			},
+		},
+	}, b.ledgerTransactions(account, transactions, "USD"))
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"path/filepath"
+	"strings"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/metadatasync"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+)
+
+const (
+	metadataKeyAccountName = "accountName/"
+	metadataKeyNote        = "note/"
+)
+
+// MetadataSyncArgs are the settings of the metadata sync to apply.
+type MetadataSyncArgs struct {
+	Enabled  bool                     `json:"enabled"`
+	Store    config.MetadataSyncStore `json:"store"`
+	Path     string                   `json:"path"`
+	URL      string                   `json:"url"`
+	Username string                   `json:"username"`
+	// Password and Passphrase keep the current ones if empty.
+	Password   string `json:"password"`
+	Passphrase string `json:"passphrase"`
+}
+
+func (backend *Backend) metadataSyncSettings() config.MetadataSync {
+	return backend.config.AppConfig().Backend.MetadataSync
+}
+
+// metadataSyncValues returns the account names of all accounts and the notes of the transactions
+// of the synced accounts.
+func (backend *Backend) metadataSyncValues() (map[string]string, error) {
+	values := map[string]string{}
+	for _, accountConfig := range backend.config.AccountsConfig().Accounts {
+		values[metadataKeyAccountName+string(accountConfig.Code)] = accountConfig.Name
+	}
+	for _, account := range backend.Accounts() {
+		if account.FatalError() || !account.Synced() {
+			continue
+		}
+		transactions, err := account.Transactions()
+		if err != nil {
+			continue
+		}
+		prefix := metadataKeyNote + string(account.Config().Config.Code) + "/"
+		for _, tx := range transactions {
+			values[prefix+tx.InternalID] = account.TxNote(tx.InternalID)
+		}
+	}
+	return values, nil
+}
+
+// applyMetadataSyncValue changes an account name or a transaction note received from another
+// installation.
+func (backend *Backend) applyMetadataSyncValue(key string, value string) error {
+	switch {
+	case strings.HasPrefix(key, metadataKeyAccountName):
+		if value == "" {
+			// Accounts cannot be unnamed, the local name is kept.
+			return nil
+		}
+		return backend.RenameAccount(accountsTypes.Code(strings.TrimPrefix(key, metadataKeyAccountName)), value)
+	case strings.HasPrefix(key, metadataKeyNote):
+		code, txID, ok := strings.Cut(strings.TrimPrefix(key, metadataKeyNote), "/")
+		if !ok {
+			return errp.Newf("invalid key %s", key)
+		}
+		account, err := backend.GetAccountFromCode(accountsTypes.Code(code))
+		if err != nil {
+			return err
+		}
+		return account.SetTxNote(txID, value)
+	}
+	return errp.Newf("unknown key %s", key)
+}
+
+// MetadataSyncStatus returns the settings of the metadata sync and the result of the last sync.
+func (backend *Backend) MetadataSyncStatus() *metadatasync.Status {
+	return backend.metadataSync.Status()
+}
+
+// SetMetadataSync changes the settings of the metadata sync. If the store or the passphrase
+// changed, the next sync starts over as if syncing for the first time.
+func (backend *Backend) SetMetadataSync(args MetadataSyncArgs) error {
+	path := strings.TrimSpace(args.Path)
+	url := strings.TrimSpace(args.URL)
+	switch args.Store {
+	case config.MetadataSyncStoreFile:
+		if args.Enabled && path == "" {
+			return errp.New("the sync file is required")
+		}
+		if path != "" && !filepath.IsAbs(path) {
+			return errp.Newf("the sync file must be an absolute path: %s", path)
+		}
+	case config.MetadataSyncStoreWebDAV:
+		if args.Enabled && !strings.HasPrefix(url, "https://") {
+			return errp.New("the WebDAV URL must start with https://")
+		}
+	default:
+		return errp.Newf("unknown sync store %q", args.Store)
+	}
+	previous := backend.config.AppConfig().Backend.MetadataSync
+	err := backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		settings := &appConfig.Backend.MetadataSync
+		settings.Store = args.Store
+		settings.Path = path
+		settings.URL = url
+		settings.Username = args.Username
+		if args.Password != "" {
+			settings.Password = args.Password
+		}
+		if args.Passphrase != "" {
+			settings.Passphrase = args.Passphrase
+		}
+		if args.Enabled && settings.Passphrase == "" {
+			return errp.New("the sync passphrase is required")
+		}
+		settings.Enabled = args.Enabled
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	current := backend.config.AppConfig().Backend.MetadataSync
+	if current.Store != previous.Store || current.Path != previous.Path ||
+		current.URL != previous.URL || current.Passphrase != previous.Passphrase {
+		if err := backend.metadataSync.Reset(); err != nil {
+			return err
+		}
+	}
+	backend.Notify(observable.Event{
+		Subject: "metadata-sync/status",
+		Action:  action.Reload,
+	})
+	if current.Enabled {
+		go func() {
+			if err := backend.metadataSync.Run(); err != nil {
+				backend.log.WithError(err).Error("Metadata sync failed")
+			}
+		}()
+	}
+	return nil
+}
+
+// RunMetadataSync syncs the metadata now.
+func (backend *Backend) RunMetadataSync() error {
+	if !backend.config.AppConfig().Backend.MetadataSync.Enabled {
+		return errp.New("the metadata sync is disabled")
+	}
+	return backend.metadataSync.Run()
+}
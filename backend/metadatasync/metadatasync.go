@@ -0,0 +1,417 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metadatasync syncs non-sensitive metadata like transaction notes and account names
+// between the installations of a user. The metadata is kept in a blob encrypted with a passphrase
+// known only to the user, stored in a file (e.g. in a folder synced by a cloud storage client) or
+// on a WebDAV server. Each value carries the time it was last changed; when two installations
+// changed the same value, the later change wins.
+package metadatasync
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/scheduledexport"
+	utilConfig "github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// syncInterval is how often the metadata is synced while the app is running.
+	syncInterval = 15 * time.Minute
+	// maxAttempts is how often a sync is retried if another installation changed the blob
+	// concurrently.
+	maxAttempts = 3
+
+	documentVersion = 1
+)
+
+// Entry is a synced value.
+type Entry struct {
+	Value   string `json:"value,omitempty"`
+	Deleted bool   `json:"deleted,omitempty"`
+	// Modified is when the value was changed, in unix milliseconds. It is zero for values which
+	// existed before the installation started syncing, so that they don't override changes made
+	// elsewhere.
+	Modified int64 `json:"modified"`
+}
+
+func (entry Entry) value() string {
+	if entry.Deleted {
+		return ""
+	}
+	return entry.Value
+}
+
+// wins returns true if the entry takes precedence over the other one. Ties are broken by the
+// value, so that all installations resolve a conflict the same way.
+func (entry Entry) wins(other Entry) bool {
+	if entry.Modified != other.Modified {
+		return entry.Modified > other.Modified
+	}
+	return entry.value() > other.value()
+}
+
+// document is the plaintext of the encrypted blob.
+type document struct {
+	Version int              `json:"version"`
+	Entries map[string]Entry `json:"entries"`
+}
+
+// merge merges the remote entries into the local ones. It returns the merged entries and the
+// number of conflicts, i.e. values which were changed locally and by another installation since
+// the last sync, with base being the entries of the last sync.
+func merge(
+	local map[string]Entry,
+	remote map[string]Entry,
+	base map[string]Entry,
+	changed map[string]bool,
+) (map[string]Entry, int) {
+	merged := make(map[string]Entry, len(local))
+	for key, entry := range local {
+		merged[key] = entry
+	}
+	conflicts := 0
+	for key, remoteEntry := range remote {
+		localEntry, ok := merged[key]
+		if ok && changed[key] && remoteEntry != base[key] && remoteEntry.value() != localEntry.value() {
+			conflicts++
+		}
+		if !ok || remoteEntry.wins(localEntry) {
+			merged[key] = remoteEntry
+		}
+	}
+	return merged, conflicts
+}
+
+// state is persisted between syncs.
+type state struct {
+	// Entries are the merged entries of the last sync.
+	Entries map[string]Entry `json:"entries"`
+	// Local are the local values as of the last sync, to detect local changes.
+	Local     map[string]string `json:"local"`
+	LastSync  *time.Time        `json:"lastSync"`
+	LastError string            `json:"lastError"`
+	// Conflicts is the number of values changed locally and by another installation, which were
+	// resolved in the last sync.
+	Conflicts int `json:"conflicts"`
+}
+
+// Status describes the sync, reported by the sync status endpoint.
+type Status struct {
+	Enabled bool                     `json:"enabled"`
+	Store   config.MetadataSyncStore `json:"store"`
+	Running bool                     `json:"running"`
+	// NumEntries is the number of synced values.
+	NumEntries int        `json:"numEntries"`
+	LastSync   *time.Time `json:"lastSync"`
+	LastError  string     `json:"lastError"`
+	Conflicts  int        `json:"conflicts"`
+}
+
+// Syncer syncs the metadata according to the settings. The zero value is not usable, use
+// NewSyncer().
+type Syncer struct {
+	observable.Implementation
+
+	file       *utilConfig.File
+	settings   func() config.MetadataSync
+	httpClient *http.Client
+	// values returns the local values which can currently be synced, keyed by e.g.
+	// "note/<account code>/<tx ID>". Values which are not set are included as an empty string.
+	// Keys which are not included, e.g. notes of accounts which are not loaded, are left unchanged.
+	values func() (map[string]string, error)
+	// apply changes a local value. An empty value removes it.
+	apply func(key string, value string) error
+	now   func() time.Time
+
+	state   state
+	running bool
+	mu      locker.Locker
+	quit    chan struct{}
+
+	log *logrus.Entry
+}
+
+// NewSyncer creates a syncer which persists its state in the given file. The state stored by a
+// previous run is loaded.
+func NewSyncer(
+	filename string,
+	settings func() config.MetadataSync,
+	httpClient *http.Client,
+	values func() (map[string]string, error),
+	apply func(key string, value string) error,
+) *Syncer {
+	syncer := &Syncer{
+		file:       utilConfig.NewFile(filepath.Dir(filename), filepath.Base(filename)),
+		settings:   settings,
+		httpClient: httpClient,
+		values:     values,
+		apply:      apply,
+		now:        time.Now,
+		log:        logging.Get().WithGroup("metadatasync"),
+	}
+	if syncer.file.Exists() {
+		if err := syncer.file.ReadJSON(&syncer.state); err != nil {
+			syncer.log.WithError(err).Error("Could not load the metadata sync state")
+			syncer.state = state{}
+		}
+	}
+	return syncer
+}
+
+func (syncer *Syncer) storeFor(settings config.MetadataSync) (Store, error) {
+	switch settings.Store {
+	case config.MetadataSyncStoreFile:
+		if !filepath.IsAbs(settings.Path) {
+			return nil, errp.Newf("the sync file must be an absolute path: %s", settings.Path)
+		}
+		return NewFileStore(settings.Path), nil
+	case config.MetadataSyncStoreWebDAV:
+		if settings.URL == "" {
+			return nil, errp.New("no WebDAV URL configured")
+		}
+		return NewWebDAVStore(settings.URL, settings.Username, settings.Password, syncer.httpClient), nil
+	}
+	return nil, errp.Newf("unknown sync store %q", settings.Store)
+}
+
+// Start periodically syncs until Stop() is called. It is a no-op while the sync is disabled.
+func (syncer *Syncer) Start() {
+	unlock := syncer.mu.Lock()
+	if syncer.quit != nil {
+		unlock()
+		return
+	}
+	quit := make(chan struct{})
+	syncer.quit = quit
+	unlock()
+
+	go func() {
+		ticker := time.NewTicker(syncInterval)
+		defer ticker.Stop()
+		for {
+			if syncer.settings().Enabled {
+				if err := syncer.Run(); err != nil {
+					syncer.log.WithError(err).Error("Metadata sync failed")
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic sync.
+func (syncer *Syncer) Stop() {
+	defer syncer.mu.Lock()()
+	if syncer.quit != nil {
+		close(syncer.quit)
+		syncer.quit = nil
+	}
+}
+
+// Status returns the settings and the result of the last sync.
+func (syncer *Syncer) Status() *Status {
+	settings := syncer.settings()
+	defer syncer.mu.RLock()()
+	return &Status{
+		Enabled:    settings.Enabled,
+		Store:      settings.Store,
+		Running:    syncer.running,
+		NumEntries: len(syncer.state.Entries),
+		LastSync:   syncer.state.LastSync,
+		LastError:  syncer.state.LastError,
+		Conflicts:  syncer.state.Conflicts,
+	}
+}
+
+func (syncer *Syncer) notify() {
+	syncer.Notify(observable.Event{
+		Subject: "metadata-sync/status",
+		Action:  action.Reload,
+	})
+}
+
+// localChanges records the local values which changed since the last sync in a copy of the
+// entries of the last sync. It returns the entries and the keys which changed.
+func localChanges(previous state, local map[string]string, now int64) (map[string]Entry, map[string]bool) {
+	entries := make(map[string]Entry, len(previous.Entries))
+	for key, entry := range previous.Entries {
+		entries[key] = entry
+	}
+	changed := map[string]bool{}
+	for key, value := range local {
+		previousValue, known := previous.Local[key]
+		switch {
+		case known && value != previousValue:
+			entries[key] = Entry{Value: value, Deleted: value == "", Modified: now}
+			changed[key] = true
+		case !known && value != "":
+			// The value existed before it could be synced, so its age is unknown.
+			if _, ok := entries[key]; !ok {
+				entries[key] = Entry{Value: value}
+			}
+		}
+	}
+	return entries, changed
+}
+
+// pushAndMerge merges the entries with the blob in the store and uploads the result if it
+// changed. base are the entries of the last sync. It returns the merged entries and the number of conflicts.
+func (syncer *Syncer) pushAndMerge(
+	settings config.MetadataSync,
+	base map[string]Entry,
+	entries map[string]Entry,
+	changed map[string]bool,
+) (map[string]Entry, int, error) {
+	store, err := syncer.storeFor(settings)
+	if err != nil {
+		return nil, 0, err
+	}
+	for attempt := 0; ; attempt++ {
+		blob, version, err := store.Get()
+		if err != nil {
+			return nil, 0, err
+		}
+		remote := document{Entries: map[string]Entry{}}
+		if blob != nil {
+			plaintext, err := scheduledexport.DecryptBackup(settings.Passphrase, blob)
+			if err != nil {
+				return nil, 0, err
+			}
+			if err := json.Unmarshal(plaintext, &remote); err != nil {
+				return nil, 0, errp.WithStack(err)
+			}
+			if remote.Version != documentVersion {
+				return nil, 0, errp.Newf("unsupported sync version %d", remote.Version)
+			}
+		}
+		merged, conflicts := merge(entries, remote.Entries, base, changed)
+		if blob != nil && reflect.DeepEqual(merged, remote.Entries) {
+			return merged, conflicts, nil
+		}
+		plaintext, err := json.Marshal(document{Version: documentVersion, Entries: merged})
+		if err != nil {
+			return nil, 0, errp.WithStack(err)
+		}
+		encrypted, err := scheduledexport.EncryptBackup(settings.Passphrase, plaintext)
+		if err != nil {
+			return nil, 0, err
+		}
+		err = store.Put(encrypted, version)
+		if err == ErrConflict && attempt+1 < maxAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		return merged, conflicts, nil
+	}
+}
+
+// sync runs one sync and returns the new state.
+func (syncer *Syncer) sync(settings config.MetadataSync, previous state, now time.Time) (state, error) {
+	if settings.Passphrase == "" {
+		return previous, errp.New("no sync passphrase configured")
+	}
+	local, err := syncer.values()
+	if err != nil {
+		return previous, err
+	}
+	entries, changed := localChanges(previous, local, now.UnixMilli())
+	merged, conflicts, err := syncer.pushAndMerge(settings, previous.Entries, entries, changed)
+	if err != nil {
+		return previous, err
+	}
+	applied := make(map[string]string, len(local))
+	for key, value := range local {
+		applied[key] = value
+		entry, ok := merged[key]
+		if !ok || entry.value() == value {
+			continue
+		}
+		if err := syncer.apply(key, entry.value()); err != nil {
+			// The value is applied again in the next sync.
+			syncer.log.WithError(err).WithField("key", key).Error("Could not apply a synced value")
+			continue
+		}
+		applied[key] = entry.value()
+	}
+	return state{
+		Entries:   merged,
+		Local:     applied,
+		LastSync:  &now,
+		Conflicts: conflicts,
+	}, nil
+}
+
+// Run syncs now, independent of the schedule.
+func (syncer *Syncer) Run() error {
+	unlock := syncer.mu.Lock()
+	if syncer.running {
+		unlock()
+		return errp.New("a sync is already running")
+	}
+	syncer.running = true
+	previous := syncer.state
+	unlock()
+	syncer.notify()
+
+	now := syncer.now()
+	newState, err := syncer.sync(syncer.settings(), previous, now)
+
+	unlock = syncer.mu.Lock()
+	syncer.running = false
+	if err != nil {
+		syncer.state.LastError = err.Error()
+	} else {
+		syncer.state = newState
+	}
+	if saveErr := syncer.file.WriteJSON(syncer.state); saveErr != nil {
+		syncer.log.WithError(saveErr).Error("Could not persist the metadata sync state")
+	}
+	unlock()
+	syncer.notify()
+
+	if err != nil {
+		return err
+	}
+	syncer.log.WithField("entries", len(newState.Entries)).Info("Metadata sync done")
+	return nil
+}
+
+// Reset forgets the synced state, e.g. after the passphrase or the store changed. The next sync
+// merges the local values with the blob in the store as if syncing for the first time.
+func (syncer *Syncer) Reset() error {
+	defer syncer.mu.Lock()()
+	syncer.state = state{}
+	if !syncer.file.Exists() {
+		return nil
+	}
+	return errp.WithStack(syncer.file.Remove())
+}
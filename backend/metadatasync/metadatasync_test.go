@@ -0,0 +1,182 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadatasync
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/stretchr/testify/require"
+)
+
+// installation is an app with its local metadata.
+type installation struct {
+	syncer *Syncer
+	values map[string]string
+}
+
+func newInstallation(t *testing.T, settings *config.MetadataSync, values map[string]string) *installation {
+	t.Helper()
+	inst := &installation{values: values}
+	inst.syncer = NewSyncer(
+		filepath.Join(t.TempDir(), "metadata-sync.json"),
+		func() config.MetadataSync { return *settings },
+		http.DefaultClient,
+		func() (map[string]string, error) {
+			result := map[string]string{}
+			for key, value := range inst.values {
+				result[key] = value
+			}
+			return result, nil
+		},
+		func(key string, value string) error {
+			inst.values[key] = value
+			return nil
+		},
+	)
+	return inst
+}
+
+func (inst *installation) setTime(unix int64) {
+	inst.syncer.now = func() time.Time { return time.Unix(unix, 0) }
+}
+
+func TestSync(t *testing.T) {
+	settings := &config.MetadataSync{
+		Enabled:    true,
+		Store:      config.MetadataSyncStoreFile,
+		Path:       filepath.Join(t.TempDir(), "metadata.sync"),
+		Passphrase: "passphrase",
+	}
+	desktop := newInstallation(t, settings, map[string]string{
+		"accountName/btc": "Savings",
+		"note/btc/tx1":    "rent",
+		"note/btc/tx2":    "",
+	})
+	laptop := newInstallation(t, settings, map[string]string{
+		"accountName/btc": "Bitcoin",
+		"note/btc/tx1":    "",
+		"note/btc/tx2":    "salary",
+		// The notes of the eth account are not loaded on the desktop.
+		"note/eth/tx3": "gas",
+	})
+
+	// Values which existed before syncing are merged. The first installation wins conflicts.
+	desktop.setTime(1000)
+	require.NoError(t, desktop.syncer.Run())
+	laptop.setTime(1001)
+	require.NoError(t, laptop.syncer.Run())
+	require.NoError(t, desktop.syncer.Run())
+	require.Equal(t, map[string]string{
+		"accountName/btc": "Savings",
+		"note/btc/tx1":    "rent",
+		"note/btc/tx2":    "salary",
+	}, desktop.values)
+	require.Equal(t, map[string]string{
+		"accountName/btc": "Savings",
+		"note/btc/tx1":    "rent",
+		"note/btc/tx2":    "salary",
+		"note/eth/tx3":    "gas",
+	}, laptop.values)
+	require.Equal(t, 4, desktop.syncer.Status().NumEntries)
+
+	// Changes and deletions are synced.
+	desktop.values["note/btc/tx1"] = ""
+	desktop.values["accountName/btc"] = "Cold storage"
+	desktop.setTime(2000)
+	require.NoError(t, desktop.syncer.Run())
+	laptop.setTime(2001)
+	require.NoError(t, laptop.syncer.Run())
+	require.Equal(t, "", laptop.values["note/btc/tx1"])
+	require.Equal(t, "Cold storage", laptop.values["accountName/btc"])
+
+	// The later change wins a conflict.
+	laptop.values["note/btc/tx2"] = "salary march"
+	laptop.setTime(3001)
+	desktop.values["note/btc/tx2"] = "salary april"
+	desktop.setTime(3000)
+	require.NoError(t, desktop.syncer.Run())
+	require.NoError(t, laptop.syncer.Run())
+	require.Equal(t, 1, laptop.syncer.Status().Conflicts)
+	require.NoError(t, desktop.syncer.Run())
+	require.Equal(t, "salary march", desktop.values["note/btc/tx2"])
+	require.Equal(t, "salary march", laptop.values["note/btc/tx2"])
+	require.Equal(t, 0, desktop.syncer.Status().Conflicts)
+
+	// The blob cannot be read without the passphrase.
+	other := newInstallation(t, &config.MetadataSync{
+		Enabled:    true,
+		Store:      config.MetadataSyncStoreFile,
+		Path:       settings.Path,
+		Passphrase: "wrong",
+	}, map[string]string{})
+	require.Error(t, other.syncer.Run())
+	require.NotEmpty(t, other.syncer.Status().LastError)
+	require.Empty(t, other.values)
+}
+
+func TestWebDAVStore(t *testing.T) {
+	var lock sync.Mutex
+	var blob []byte
+	revision := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		etag := strconv.Quote(strconv.Itoa(revision))
+		switch r.Method {
+		case http.MethodGet:
+			if blob == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			_, _ = w.Write(blob)
+		case http.MethodPut:
+			if match := r.Header.Get("If-Match"); match != "" && match != etag {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			if r.Header.Get("If-None-Match") == "*" && blob != nil {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			blob, _ = io.ReadAll(r.Body)
+			revision++
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	store := NewWebDAVStore(server.URL+"/metadata.sync", "", "", server.Client())
+	got, version, err := store.Get()
+	require.NoError(t, err)
+	require.Nil(t, got)
+	require.Equal(t, "", version)
+	require.NoError(t, store.Put([]byte("first"), version))
+	require.Equal(t, ErrConflict, store.Put([]byte("second"), version))
+
+	got, version, err = store.Get()
+	require.NoError(t, err)
+	require.Equal(t, []byte("first"), got)
+	require.NoError(t, store.Put([]byte("second"), version))
+	require.Equal(t, ErrConflict, store.Put([]byte("third"), version))
+}
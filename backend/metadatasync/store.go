@@ -0,0 +1,180 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadatasync
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// maxBlobSize limits the size of the downloaded blob.
+const maxBlobSize = 10 * 1024 * 1024
+
+// ErrConflict is returned by Store.Put() if the blob was changed by another installation since it
+// was fetched.
+var ErrConflict = errors.New("the sync blob was changed concurrently")
+
+// Store holds the encrypted blob shared by the installations.
+type Store interface {
+	// Get returns the blob and its version, which is passed to Put(). If there is no blob yet, nil
+	// and an empty version are returned.
+	Get() ([]byte, string, error)
+	// Put replaces the blob if it still has the given version, and returns ErrConflict otherwise.
+	Put(blob []byte, version string) error
+}
+
+// FileStore keeps the blob in a file, e.g. in a folder synced by a cloud storage client.
+type FileStore struct {
+	path string
+}
+
+// NewFileStore creates a store keeping the blob in the given file.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func contentVersion(blob []byte) string {
+	hash := sha256.Sum256(blob)
+	return hex.EncodeToString(hash[:])
+}
+
+// Get implements Store.
+func (store *FileStore) Get() ([]byte, string, error) {
+	blob, err := os.ReadFile(store.path)
+	if os.IsNotExist(err) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", errp.WithStack(err)
+	}
+	return blob, contentVersion(blob), nil
+}
+
+// Put implements Store. The blob is written via a temporary file, so that a partially written
+// blob is never synced to the other installations.
+func (store *FileStore) Put(blob []byte, version string) error {
+	_, current, err := store.Get()
+	if err != nil {
+		return err
+	}
+	if current != version {
+		return ErrConflict
+	}
+	if err := os.MkdirAll(filepath.Dir(store.path), 0700); err != nil {
+		return errp.WithStack(err)
+	}
+	tmpPath := store.path + ".tmp"
+	if err := os.WriteFile(tmpPath, blob, 0600); err != nil {
+		return errp.WithStack(err)
+	}
+	return errp.WithStack(os.Rename(tmpPath, store.path))
+}
+
+// noETag is the version of a blob fetched from a WebDAV server which does not send ETags.
+const noETag = "none"
+
+// WebDAVStore keeps the blob on a WebDAV server, using the ETag of the resource as the version.
+type WebDAVStore struct {
+	url        string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// NewWebDAVStore creates a store keeping the blob at the given URL. If username is not empty,
+// requests are authenticated with HTTP basic auth.
+func NewWebDAVStore(url string, username string, password string, httpClient *http.Client) *WebDAVStore {
+	return &WebDAVStore{
+		url:        url,
+		username:   username,
+		password:   password,
+		httpClient: httpClient,
+	}
+}
+
+func (store *WebDAVStore) do(method string, body []byte, header http.Header) (*http.Response, error) {
+	request, err := http.NewRequest(method, store.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	if store.username != "" {
+		request.SetBasicAuth(store.username, store.password)
+	}
+	response, err := store.httpClient.Do(request)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return response, nil
+}
+
+// Get implements Store.
+func (store *WebDAVStore) Get() ([]byte, string, error) {
+	response, err := store.do(http.MethodGet, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode == http.StatusNotFound {
+		return nil, "", nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, "", errp.Newf("unexpected status of the WebDAV server: %s", response.Status)
+	}
+	blob, err := io.ReadAll(io.LimitReader(response.Body, maxBlobSize))
+	if err != nil {
+		return nil, "", errp.WithStack(err)
+	}
+	version := response.Header.Get("ETag")
+	if version == "" {
+		// Without ETags, concurrent changes cannot be detected by the server.
+		version = noETag
+	}
+	return blob, version, nil
+}
+
+// Put implements Store.
+func (store *WebDAVStore) Put(blob []byte, version string) error {
+	header := http.Header{}
+	switch version {
+	case "":
+		header.Set("If-None-Match", "*")
+	case noETag:
+	default:
+		header.Set("If-Match", version)
+	}
+	response, err := store.do(http.MethodPut, blob, header)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = response.Body.Close() }()
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	case http.StatusPreconditionFailed:
+		return ErrConflict
+	}
+	return errp.Newf("unexpected status of the WebDAV server: %s", response.Status)
+}
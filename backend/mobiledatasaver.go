@@ -0,0 +1,85 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+)
+
+// MobileDataSaverStatus describes the mobile data saver mode.
+type MobileDataSaverStatus struct {
+	// Enabled is true if the user enabled the mobile data saver option.
+	Enabled bool `json:"enabled"`
+	// Active is true if the option is enabled and the user is on a mobile data connection, in
+	// which case polling is reduced, header catch-up is deferred and no chart history is fetched.
+	Active bool `json:"active"`
+}
+
+func setCoinDataSaver(coin coinpkg.Coin, enabled bool) {
+	switch specificCoin := coin.(type) {
+	case *btc.Coin:
+		specificCoin.SetDataSaver(enabled)
+	case *eth.Coin:
+		specificCoin.SetDataSaver(enabled)
+	}
+}
+
+// MobileDataSaverActive returns true if the mobile data saver mode is currently applied.
+func (backend *Backend) MobileDataSaverActive() bool {
+	defer backend.mobileDataSaverLock.RLock()()
+	return backend.mobileDataSaverActive
+}
+
+// MobileDataSaverStatus returns the mobile data saver option and whether it is currently applied.
+func (backend *Backend) MobileDataSaverStatus() *MobileDataSaverStatus {
+	return &MobileDataSaverStatus{
+		Enabled: backend.config.AppConfig().Backend.MobileDataSaver,
+		Active:  backend.MobileDataSaverActive(),
+	}
+}
+
+// UpdateMobileDataSaver applies or lifts the mobile data saver mode according to the config and
+// the network connection. It must be called whenever one of them changes.
+func (backend *Backend) UpdateMobileDataSaver() {
+	active := backend.config.AppConfig().Backend.MobileDataSaver && backend.environment.UsingMobileData()
+	unlock := backend.mobileDataSaverLock.Lock()
+	changed := active != backend.mobileDataSaverActive
+	backend.mobileDataSaverActive = active
+	unlock()
+	if !changed {
+		return
+	}
+	backend.log.WithField("active", active).Info("mobile data saver mode changed")
+
+	backend.ratesUpdater.SetDataSaver(active)
+	func() {
+		defer backend.coinsLock.RLock()()
+		for _, coin := range backend.coins {
+			setCoinDataSaver(coin, active)
+		}
+	}()
+	func() {
+		defer backend.accountsAndKeystoreLock.RLock()()
+		backend.configureHistoryExchangeRates()
+	}()
+	backend.Notify(observable.Event{
+		Subject: "mobile-data-saver",
+		Action:  action.Reload,
+	})
+}
@@ -82,11 +82,22 @@ type GoDeviceInfoInterface interface {
 	Open() (GoReadWriteCloserInterface, error)
 }
 
+// GoBluetoothDeviceInfoInterface is like GoDeviceInfoInterface for a device connected over
+// Bluetooth LE, see usb.BluetoothDeviceInfo. Each Read of the opened connection returns the payload
+// of one GATT notification, and each Write is one GATT write.
+type GoBluetoothDeviceInfoInterface interface {
+	GoDeviceInfoInterface
+	BluetoothMTU() int
+}
+
 // GoEnvironmentInterface adapts backend.Environment to return only one DeviceInfo instead of a
 // slice of them, as a slice of interfaces does not seem to be supported by gomobile yet.
 type GoEnvironmentInterface interface {
 	NotifyUser(string)
 	DeviceInfo() GoDeviceInfoInterface
+	// BluetoothDeviceInfo returns the device connected over Bluetooth LE, or nil if there is none
+	// or Bluetooth LE is not supported.
+	BluetoothDeviceInfo() GoBluetoothDeviceInfoInterface
 	SystemOpen(string) error
 	UsingMobileData() bool
 	NativeLocale() string
@@ -129,6 +140,21 @@ func (d deviceInfo) Open() (io.ReadWriteCloser, error) {
 	return readWriteCloser{device}, nil
 }
 
+// bluetoothDeviceInfo implements usb.BluetoothDeviceInfo, translating from
+// GoBluetoothDeviceInfoInterface like deviceInfo.
+type bluetoothDeviceInfo struct {
+	GoBluetoothDeviceInfoInterface
+}
+
+// Open implements usb.DeviceInfo.
+func (d bluetoothDeviceInfo) Open() (io.ReadWriteCloser, error) {
+	device, err := d.GoBluetoothDeviceInfoInterface.Open()
+	if err != nil {
+		return nil, err
+	}
+	return readWriteCloser{device}, nil
+}
+
 // GoAPIInterface is used to pas api (GET/POST) responses and websocket push notifications to
 // Android/iOS.
 type GoAPIInterface interface {
@@ -189,11 +215,14 @@ func Serve(dataDir string, environment GoEnvironmentInterface, goAPI GoAPIInterf
 		&bridgecommon.BackendEnvironment{
 			NotifyUserFunc: environment.NotifyUser,
 			DeviceInfosFunc: func() []usb.DeviceInfo {
-				i := environment.DeviceInfo()
-				if i == nil {
-					return []usb.DeviceInfo{}
+				deviceInfos := []usb.DeviceInfo{}
+				if i := environment.DeviceInfo(); i != nil {
+					deviceInfos = append(deviceInfos, deviceInfo{i})
+				}
+				if i := environment.BluetoothDeviceInfo(); i != nil {
+					deviceInfos = append(deviceInfos, bluetoothDeviceInfo{i})
 				}
-				return []usb.DeviceInfo{deviceInfo{i}}
+				return deviceInfos
 			},
 			SystemOpenFunc:           environment.SystemOpen,
 			UsingMobileDataFunc:      environment.UsingMobileData,
@@ -0,0 +1,283 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/paymenturi"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// maxPaymentRequests limits the number of queued payment requests. The oldest one is dropped when a
+// new one arrives and the queue is full.
+const maxPaymentRequests = 10
+
+// paymentURISchemes maps the supported payment URI schemes to the coins of accounts which can pay
+// them. `ethereum:` URIs can also be paid by ERC20 token accounts, see paymentRequestAccountMatches().
+var paymentURISchemes = map[string][]coinpkg.Code{
+	"bitcoin":  {coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeRBTC},
+	"litecoin": {coinpkg.CodeLTC, coinpkg.CodeTLTC},
+	"ethereum": {coinpkg.CodeETH, coinpkg.CodeSEPETH},
+}
+
+// PaymentRequest is a payment URI (BIP-21 `bitcoin:`/`litecoin:` or EIP-681 `ethereum:`) opened
+// through the OS, waiting to be handled by the frontend.
+type PaymentRequest struct {
+	ID int `json:"id"`
+	// URI is the full URI as received.
+	URI    string `json:"uri"`
+	Scheme string `json:"scheme"`
+	// Address is the recipient. For ERC20 token transfers, this is the recipient of the tokens, not
+	// the token contract.
+	Address string `json:"address"`
+	// Amount is the amount in the coin's default unit (e.g. BTC, ETH or the token unit), or empty if
+	// the URI does not specify an amount, or if it is a token amount and no account of the token is
+	// loaded.
+	Amount  string `json:"amount"`
+	Label   string `json:"label"`
+	Message string `json:"message"`
+	// ChainID is the EIP-155 chain ID of `ethereum:` URIs, or 0 if not specified.
+	ChainID uint64 `json:"chainID,omitempty"`
+	// GasLimit is the gas limit of `ethereum:` URIs, or 0 if not specified.
+	GasLimit uint64 `json:"gasLimit,omitempty"`
+	// TokenContract is the ERC20 contract address of `ethereum:` URIs calling `transfer`, and empty
+	// for Ether payments.
+	TokenContract string `json:"tokenContract,omitempty"`
+	// AccountCodes are the active accounts which can pay the request.
+	AccountCodes []accountsTypes.Code `json:"accountCodes"`
+
+	// tokenAmount is the amount of ERC20 token transfers in the smallest unit of the token, converted
+	// to Amount using the decimals of the token account.
+	tokenAmount *big.Int
+}
+
+// parseUint256 parses an EIP-681 number, which can be in scientific notation, e.g. `1.5e18`.
+func parseUint256(value string) (*big.Int, error) {
+	number, ok := new(big.Rat).SetString(value)
+	if !ok || number.Sign() < 0 || !number.IsInt() {
+		return nil, errp.Newf("invalid number: %s", value)
+	}
+	return number.Num(), nil
+}
+
+// parseEIP681 parses the target and the parameters of an `ethereum:` URI:
+// `ethereum:[pay-]<address>[@<chainID>][/<function>]?<parameters>`. Only Ether payments and the
+// ERC20 `transfer` function are supported, not other contract calls.
+func parseEIP681(request *PaymentRequest, target string, query url.Values) error {
+	target = strings.TrimPrefix(target, "pay-")
+	var function string
+	if i := strings.Index(target, "/"); i >= 0 {
+		target, function = target[:i], target[i+1:]
+	}
+	if i := strings.Index(target, "@"); i >= 0 {
+		chainID, err := strconv.ParseUint(target[i+1:], 10, 64)
+		if err != nil || chainID == 0 {
+			return errp.Newf("invalid chain ID: %s", target[i+1:])
+		}
+		request.ChainID = chainID
+		target = target[:i]
+	}
+	if !common.IsHexAddress(target) {
+		return errp.Newf("invalid address: %s", target)
+	}
+	for _, key := range []string{"gas", "gasLimit"} {
+		if gas := query.Get(key); gas != "" {
+			gasLimit, err := parseUint256(gas)
+			if err != nil || !gasLimit.IsUint64() {
+				return errp.Newf("invalid gas limit: %s", gas)
+			}
+			request.GasLimit = gasLimit.Uint64()
+		}
+	}
+	var value *big.Int
+	if valueString := query.Get("value"); valueString != "" {
+		var err error
+		value, err = parseUint256(valueString)
+		if err != nil {
+			return errp.Newf("invalid value: %s", valueString)
+		}
+	}
+
+	switch function {
+	case "":
+		request.Address = target
+		if value != nil {
+			request.Amount = paymenturi.FormatDecimal(value, 18)
+		}
+	case "transfer":
+		if value != nil && value.Sign() != 0 {
+			return errp.New("token transfers cannot send Ether")
+		}
+		recipient := query.Get("address")
+		if !common.IsHexAddress(recipient) {
+			return errp.Newf("invalid token recipient: %s", recipient)
+		}
+		request.TokenContract = target
+		request.Address = recipient
+		if amount := query.Get("uint256"); amount != "" {
+			tokenAmount, err := parseUint256(amount)
+			if err != nil {
+				return err
+			}
+			request.tokenAmount = tokenAmount
+		}
+	default:
+		return errp.Newf("unsupported contract call: %s", function)
+	}
+	return nil
+}
+
+// parsePaymentURI parses a BIP-21 or EIP-681 payment URI. Of the EIP-681 contract calls, only
+// ERC20 token transfers are supported.
+func parsePaymentURI(u *url.URL) (*PaymentRequest, error) {
+	scheme := strings.ToLower(u.Scheme)
+	if _, ok := paymentURISchemes[scheme]; !ok {
+		return nil, errp.Newf("unsupported payment URI scheme: %s", u.Scheme)
+	}
+	target := u.Opaque
+	if target == "" {
+		// `bitcoin://<address>` is not valid BIP-21, but some apps produce it.
+		target = u.Host + u.Path
+	}
+	query := u.Query()
+	request := &PaymentRequest{
+		Scheme:  scheme,
+		Label:   query.Get("label"),
+		Message: query.Get("message"),
+	}
+	if scheme == "ethereum" {
+		if err := parseEIP681(request, target, query); err != nil {
+			return nil, err
+		}
+		return request, nil
+	}
+	if target == "" {
+		return nil, errp.New("missing address")
+	}
+	request.Address = target
+	request.Amount = query.Get("amount")
+	return request, nil
+}
+
+// paymentRequestAccountMatches returns true if the account can pay the payment request. ERC20
+// token transfers are paid by the account of the token, on the requested chain if specified.
+func paymentRequestAccountMatches(request *PaymentRequest, account accounts.Interface) bool {
+	if request.Scheme != "ethereum" {
+		for _, coinCode := range paymentURISchemes[request.Scheme] {
+			if account.Coin().Code() == coinCode {
+				return true
+			}
+		}
+		return false
+	}
+	ethCoin, ok := account.Coin().(*eth.Coin)
+	if !ok {
+		return false
+	}
+	if request.ChainID != 0 && ethCoin.ChainID() != request.ChainID {
+		return false
+	}
+	token := ethCoin.ERC20Token()
+	if request.TokenContract == "" {
+		return token == nil
+	}
+	return token != nil && strings.EqualFold(token.ContractAddress().Hex(), request.TokenContract)
+}
+
+// handlePaymentURI queues a payment URI for the frontend, which prefills the send screen with it.
+func (backend *Backend) handlePaymentURI(uri string, u *url.URL) error {
+	request, err := parsePaymentURI(u)
+	if err != nil {
+		return err
+	}
+	request.URI = uri
+
+	unlock := backend.paymentRequestsLock.Lock()
+	backend.nextPaymentRequestID++
+	request.ID = backend.nextPaymentRequestID
+	backend.paymentRequests = append(backend.paymentRequests, request)
+	if len(backend.paymentRequests) > maxPaymentRequests {
+		backend.paymentRequests = backend.paymentRequests[1:]
+	}
+	unlock()
+
+	backend.Notify(observable.Event{
+		Subject: "payment-requests",
+		Action:  action.Reload,
+	})
+	return nil
+}
+
+// PaymentRequests returns the queued payment requests, oldest first.
+func (backend *Backend) PaymentRequests() []PaymentRequest {
+	var activeAccounts []accounts.Interface
+	for _, account := range backend.Accounts() {
+		if !account.Config().Config.Inactive {
+			activeAccounts = append(activeAccounts, account)
+		}
+	}
+
+	defer backend.paymentRequestsLock.RLock()()
+	result := make([]PaymentRequest, len(backend.paymentRequests))
+	for i, request := range backend.paymentRequests {
+		result[i] = *request
+		result[i].AccountCodes = []accountsTypes.Code{}
+		for _, account := range activeAccounts {
+			if !paymentRequestAccountMatches(request, account) {
+				continue
+			}
+			result[i].AccountCodes = append(result[i].AccountCodes, account.Config().Config.Code)
+			if request.tokenAmount != nil {
+				decimals := account.Coin().Decimals(false)
+				result[i].Amount = paymenturi.FormatDecimal(request.tokenAmount, decimals)
+			}
+		}
+	}
+	return result
+}
+
+// DismissPaymentRequest removes a payment request from the queue, after it was handled or
+// rejected by the user.
+func (backend *Backend) DismissPaymentRequest(id int) error {
+	unlock := backend.paymentRequestsLock.Lock()
+	found := false
+	for i, request := range backend.paymentRequests {
+		if request.ID == id {
+			backend.paymentRequests = append(backend.paymentRequests[:i], backend.paymentRequests[i+1:]...)
+			found = true
+			break
+		}
+	}
+	unlock()
+	if !found {
+		return errp.Newf("unknown payment request: %d", id)
+	}
+	backend.Notify(observable.Event{
+		Subject: "payment-requests",
+		Action:  action.Reload,
+	})
+	return nil
+}
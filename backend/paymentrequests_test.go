@@ -0,0 +1,154 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"math/big"
+	"net/url"
+	"testing"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePaymentURI(t *testing.T) {
+	parse := func(uri string) (*PaymentRequest, error) {
+		u, err := url.Parse(uri)
+		require.NoError(t, err)
+		return parsePaymentURI(u)
+	}
+
+	request, err := parse("bitcoin:bc1qxy2kgdygjrsqtzq2n0yrf2493p83kkfjhx0wlh?amount=0.001&label=Shop&message=Order%2042")
+	require.NoError(t, err)
+	require.Equal(t, &PaymentRequest{
+		Scheme:  "bitcoin",
+		Address: "bc1qxy2kgdygjrsqtzq2n0yrf2493p83kkfjhx0wlh",
+		Amount:  "0.001",
+		Label:   "Shop",
+		Message: "Order 42",
+	}, request)
+
+	request, err = parse("litecoin://ltc1qzr0n0a4xs0404fy5l7pl7pj8yj8q34ml27rlcs")
+	require.NoError(t, err)
+	require.Equal(t, "litecoin", request.Scheme)
+	require.Equal(t, "ltc1qzr0n0a4xs0404fy5l7pl7pj8yj8q34ml27rlcs", request.Address)
+	require.Equal(t, "", request.Amount)
+
+	request, err = parse("ethereum:pay-0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1@1?value=1.5e18")
+	require.NoError(t, err)
+	require.Equal(t, "ethereum", request.Scheme)
+	require.Equal(t, "0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1", request.Address)
+	require.Equal(t, "1.5", request.Amount)
+
+	require.Equal(t, uint64(1), request.ChainID)
+
+	request, err = parse("ethereum:0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1?value=1&gas=21000")
+	require.NoError(t, err)
+	require.Equal(t, "0.000000000000000001", request.Amount)
+	require.Equal(t, uint64(0), request.ChainID)
+	require.Equal(t, uint64(21000), request.GasLimit)
+
+	request, err = parse("ethereum:0xdAC17F958D2ee523a2206206994597C13D831ec7@1/transfer?address=0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1&uint256=2.5e6&gasLimit=1e5")
+	require.NoError(t, err)
+	require.Equal(t, &PaymentRequest{
+		Scheme:        "ethereum",
+		Address:       "0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1",
+		ChainID:       1,
+		GasLimit:      100000,
+		TokenContract: "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+		tokenAmount:   big.NewInt(2500000),
+	}, request)
+
+	_, err = parse("ethereum:0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1/transfer?address=0x1&uint256=1")
+	require.Error(t, err)
+	_, err = parse("ethereum:0xdAC17F958D2ee523a2206206994597C13D831ec7/transfer?address=0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1&value=1")
+	require.Error(t, err)
+	_, err = parse("ethereum:0xdAC17F958D2ee523a2206206994597C13D831ec7/approve?address=0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1")
+	require.Error(t, err)
+	_, err = parse("ethereum:0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1@mainnet")
+	require.Error(t, err)
+	_, err = parse("ethereum:0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1?value=1.5")
+	require.Error(t, err)
+	_, err = parse("ethereum:0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1?value=abc")
+	require.Error(t, err)
+	_, err = parse("ethereum:vitalik.eth")
+	require.Error(t, err)
+	_, err = parse("bitcoin:?amount=1")
+	require.Error(t, err)
+	_, err = parse("dogecoin:D6x")
+	require.Error(t, err)
+}
+
+func TestPaymentRequests(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	require.Empty(t, b.PaymentRequests())
+	b.HandleURI("bitcoin:bc1qxy2kgdygjrsqtzq2n0yrf2493p83kkfjhx0wlh?amount=0.001")
+	// Invalid URIs are ignored.
+	b.HandleURI("ethereum:0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1/transfer")
+	b.HandleURI("ethereum:0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1")
+
+	requests := b.PaymentRequests()
+	require.Len(t, requests, 2)
+	require.Equal(t, "bitcoin:bc1qxy2kgdygjrsqtzq2n0yrf2493p83kkfjhx0wlh?amount=0.001", requests[0].URI)
+	require.Equal(t, "ethereum", requests[1].Scheme)
+	require.Empty(t, requests[0].AccountCodes)
+
+	b.registerKeystore(makeBitBox02Multi())
+	requests = b.PaymentRequests()
+	require.NotEmpty(t, requests[0].AccountCodes)
+	for _, code := range requests[0].AccountCodes {
+		require.Contains(t, string(code), "-btc-")
+	}
+
+	require.NoError(t, b.DismissPaymentRequest(requests[0].ID))
+	require.Error(t, b.DismissPaymentRequest(requests[0].ID))
+	requests = b.PaymentRequests()
+	require.Len(t, requests, 1)
+	require.Equal(t, "ethereum", requests[0].Scheme)
+
+	// ERC20 transfers are paid by the token accounts.
+	var ethAccountCode accountsTypes.Code
+	for _, account := range b.Accounts() {
+		if account.Coin().Code() == coinpkg.CodeETH {
+			ethAccountCode = account.Config().Config.Code
+			break
+		}
+	}
+	require.NotEmpty(t, ethAccountCode)
+	require.NoError(t, b.SetTokenActive(ethAccountCode, "eth-erc20-usdt", true))
+	require.NoError(t, b.DismissPaymentRequest(requests[0].ID))
+	b.HandleURI("ethereum:0xdac17f958d2ee523a2206206994597c13d831ec7@1/transfer?address=0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1&uint256=2.5e6")
+	requests = b.PaymentRequests()
+	require.Len(t, requests, 1)
+	require.Equal(t, []accountsTypes.Code{ethAccountCode + "-eth-erc20-usdt"}, requests[0].AccountCodes)
+	require.Equal(t, "2.5", requests[0].Amount)
+	require.Equal(t, "0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1", requests[0].Address)
+	// Wrong chain.
+	b.HandleURI("ethereum:0xdac17f958d2ee523a2206206994597c13d831ec7@11155111/transfer?address=0x90F8bf6A479f320ead074411a4B0e7944Ea8c9C1&uint256=1")
+	requests = b.PaymentRequests()
+	require.Len(t, requests, 2)
+	require.Empty(t, requests[1].AccountCodes)
+	require.Equal(t, "", requests[1].Amount)
+
+	for i := 0; i < maxPaymentRequests+1; i++ {
+		b.HandleURI("bitcoin:bc1qxy2kgdygjrsqtzq2n0yrf2493p83kkfjhx0wlh")
+	}
+	requests = b.PaymentRequests()
+	require.Len(t, requests, maxPaymentRequests)
+	require.Equal(t, "bitcoin", requests[0].Scheme)
+}
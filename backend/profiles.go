@@ -0,0 +1,76 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+)
+
+// ProfilesInfo describes the profiles of this installation. Each profile has its own config,
+// accounts, notes and caches. The default profile has an empty ID and is not listed.
+type ProfilesInfo struct {
+	Supported bool `json:"supported"`
+	// Running is the ID of the profile in use.
+	Running string `json:"running"`
+	// Selected is the ID of the profile used from the next app start on. The app has to be
+	// restarted if it differs from Running.
+	Selected string           `json:"selected"`
+	Profiles []config.Profile `json:"profiles"`
+}
+
+// Profiles returns the profiles of this installation.
+func (backend *Backend) Profiles() ProfilesInfo {
+	if !config.ProfilesSupported() {
+		return ProfilesInfo{Profiles: []config.Profile{}}
+	}
+	profiles, selected := config.Profiles()
+	return ProfilesInfo{
+		Supported: true,
+		Running:   config.RunningProfile(),
+		Selected:  selected,
+		Profiles:  profiles,
+	}
+}
+
+func (backend *Backend) notifyProfiles() {
+	backend.Notify(observable.Event{
+		Subject: "profiles",
+		Action:  action.Reload,
+	})
+}
+
+// AddProfile adds a new, empty profile and returns it.
+func (backend *Backend) AddProfile(name string) (*config.Profile, error) {
+	profile, err := config.AddProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	backend.log.Infof("Added profile %s", profile.ID)
+	backend.notifyProfiles()
+	return profile, nil
+}
+
+// SelectProfile selects the profile to use from the next app start on. The empty ID selects the
+// default profile.
+func (backend *Backend) SelectProfile(id string) error {
+	if err := config.SelectProfile(id); err != nil {
+		return err
+	}
+	backend.log.Infof("Selected profile %q", id)
+	backend.notifyProfiles()
+	return nil
+}
@@ -100,6 +100,29 @@ var (
 		"SEK": "sek",
 		"PLN": "pln",
 		"CZK": "czk",
+		"AED": "aed",
+		"ARS": "ars",
+		"CLP": "clp",
+		"DKK": "dkk",
+		"HUF": "huf",
+		"IDR": "idr",
+		"INR": "inr",
+		"MXN": "mxn",
+		"MYR": "myr",
+		"NGN": "ngn",
+		"NZD": "nzd",
+		"PHP": "php",
+		"PKR": "pkr",
+		"SAR": "sar",
+		"THB": "thb",
+		"TRY": "try",
+		"TWD": "twd",
+		"UAH": "uah",
+		"VND": "vnd",
+		"ZAR": "zar",
+		// Precious metals, in troy ounces.
+		"XAU": "xau",
+		"XAG": "xag",
 		// Satoshi rates are converted manually in the backend using Bitcoin.
 		"sat": "btc",
 	}
@@ -126,5 +149,27 @@ var (
 		"sek": "SEK",
 		"pln": "PLN",
 		"czk": "CZK",
+		"aed": "AED",
+		"ars": "ARS",
+		"clp": "CLP",
+		"dkk": "DKK",
+		"huf": "HUF",
+		"idr": "IDR",
+		"inr": "INR",
+		"mxn": "MXN",
+		"myr": "MYR",
+		"ngn": "NGN",
+		"nzd": "NZD",
+		"php": "PHP",
+		"pkr": "PKR",
+		"sar": "SAR",
+		"thb": "THB",
+		"try": "TRY",
+		"twd": "TWD",
+		"uah": "UAH",
+		"vnd": "VND",
+		"zar": "ZAR",
+		"xau": "XAU",
+		"xag": "XAG",
 	}
 )
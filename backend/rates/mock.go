@@ -33,6 +33,7 @@ func MockRateUpdater() *RateUpdater {
 			{value: 4, timestamp: time.Date(2020, 9, 02, 23, 0, 0, 0, time.UTC)},
 		},
 	}
+	updater.lastUpdated = time.Unix(1599091262, 0)
 	updater.last = map[string]map[string]float64{
 		"BTC": {
 			"USD": 21.0,
@@ -24,7 +24,9 @@ import (
 	"net/url"
 	"reflect"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
@@ -37,11 +39,13 @@ import (
 )
 
 const (
-	// Latest rates are fetched for all these (coin, fiat) pairs.
-	simplePriceAllIDs        = "bitcoin,litecoin,ethereum,basic-attention-token,dai,chainlink,maker,usd-coin,tether,0x,wrapped-bitcoin,pax-gold"
-	simplePriceAllCurrencies = "usd,eur,chf,gbp,jpy,krw,cny,rub,cad,aud,ils,btc,sgd,hkd,brl,nok,sek,pln,czk"
+	// Latest rates are fetched for all these coins, for the currencies set with SetLatestCurrencies.
+	simplePriceAllIDs = "bitcoin,litecoin,ethereum,basic-attention-token,dai,chainlink,maker,usd-coin,tether,0x,wrapped-bitcoin,pax-gold"
 	// RatesEventSubject is the Subject of the event generated by new rates fetching.
 	RatesEventSubject = "rates"
+	// RatesStaleEventSubject is the Subject of the event generated when the latest rates become
+	// stale or fresh again. The event object is a bool, true if the rates are stale.
+	RatesStaleEventSubject = "rates/stale"
 
 	// ErrRatesNotAvailable is raised when the latest rates have note been fetched yet.
 	ErrRatesNotAvailable errp.ErrorCode = "ratesNotAvailable"
@@ -49,6 +53,20 @@ const (
 
 const interval = time.Minute
 
+// dataSaverInterval is the update interval of the latest rates in the data saver mode, see
+// SetDataSaver.
+const dataSaverInterval = 10 * time.Minute
+
+// idleInterval is the update interval of the latest rates while the app is idle, see SetIdle.
+const idleInterval = 15 * time.Minute
+
+// staleAfterFailures is the number of consecutive failed updates of the latest rates after which
+// the rates are considered stale.
+const staleAfterFailures = 3
+
+// latestRatesSource is the source of the latest rates.
+const latestRatesSource = "coingecko"
+
 // unitSatoshi is 1 BTC (default unit) in Satoshi.
 const unitSatoshi = 1e8
 
@@ -85,10 +103,41 @@ const (
 	SEK Fiat = "SEK"
 	SGD Fiat = "SGD"
 	USD Fiat = "USD"
+	AED Fiat = "AED"
+	ARS Fiat = "ARS"
+	CLP Fiat = "CLP"
+	DKK Fiat = "DKK"
+	HUF Fiat = "HUF"
+	IDR Fiat = "IDR"
+	INR Fiat = "INR"
+	MXN Fiat = "MXN"
+	MYR Fiat = "MYR"
+	NGN Fiat = "NGN"
+	NZD Fiat = "NZD"
+	PHP Fiat = "PHP"
+	PKR Fiat = "PKR"
+	SAR Fiat = "SAR"
+	THB Fiat = "THB"
+	TRY Fiat = "TRY"
+	TWD Fiat = "TWD"
+	UAH Fiat = "UAH"
+	VND Fiat = "VND"
+	ZAR Fiat = "ZAR"
+	// Precious metals, in troy ounces.
+	XAU Fiat = "XAU"
+	XAG Fiat = "XAG"
 	BTC Fiat = "BTC"
 	SAT Fiat = "sat"
 )
 
+// defaultLatestCurrencies are the currencies for which the latest rates are fetched until
+// SetLatestCurrencies is called.
+var defaultLatestCurrencies = []string{
+	USD.String(), EUR.String(), CHF.String(), GBP.String(), JPY.String(), KRW.String(), CNY.String(),
+	RUB.String(), CAD.String(), AUD.String(), ILS.String(), BTC.String(), SGD.String(), HKD.String(),
+	BRL.String(), NOK.String(), SEK.String(), PLN.String(), CZK.String(),
+}
+
 // RateUpdater provides cryptocurrency-to-fiat conversion rates.
 type RateUpdater struct {
 	observable.Implementation
@@ -98,8 +147,26 @@ type RateUpdater struct {
 
 	// last contains most recent conversion to fiat, keyed by a coin.
 	last map[string]map[string]float64
+	// lastInfoMu guards lastUpdated, failedUpdates and stale.
+	lastInfoMu sync.RWMutex
+	// lastUpdated is the time of the last successful update of `last`.
+	lastUpdated time.Time
+	// failedUpdates is the number of consecutive failed updates of `last`.
+	failedUpdates int
+	// stale is true if `last` could not be updated for staleAfterFailures times in a row.
+	stale bool
+	// latestCurrenciesMu guards latestCurrencies.
+	latestCurrenciesMu sync.RWMutex
+	// latestCurrencies are the CoinGecko fiat codes for which the latest rates are fetched.
+	latestCurrencies []string
+	// updateLastNow triggers an immediate update of the latest rates in lastUpdateLoop.
+	updateLastNow chan struct{}
 	// stopLastUpdateLoop is the cancel function of the lastUpdateLoop context.
 	stopLastUpdateLoop context.CancelFunc
+	// dataSaver is true if the latest rates are updated less frequently, see SetDataSaver.
+	dataSaver atomic.Bool
+	// idle is true if the app is hidden or not in use, see SetIdle.
+	idle atomic.Bool
 
 	// historyDB is an internal cached copy of history, transparent to the users.
 	// While RateUpdater can function without a valid historyDB,
@@ -147,16 +214,97 @@ func NewRateUpdater(client *http.Client, dbdir string) *RateUpdater {
 		db = &bbolt.DB{}
 	}
 	apiURL := shiftGeckoMirrorAPIV3
-	return &RateUpdater{
-		last:         make(map[string]map[string]float64),
-		history:      make(map[string][]exchangeRate),
-		historyGo:    make(map[string]context.CancelFunc),
-		historyDB:    db,
-		log:          log,
-		httpClient:   client,
-		coingeckoURL: apiURL,
-		geckoLimiter: ratelimit.NewLimitedCall(apiRateLimit(apiURL)),
+	updater := &RateUpdater{
+		last:          make(map[string]map[string]float64),
+		updateLastNow: make(chan struct{}, 1),
+		history:       make(map[string][]exchangeRate),
+		historyGo:     make(map[string]context.CancelFunc),
+		historyDB:     db,
+		log:           log,
+		httpClient:    client,
+		coingeckoURL:  apiURL,
+		geckoLimiter:  ratelimit.NewLimitedCall(apiRateLimit(apiURL)),
+	}
+	updater.setLatestCurrencies(defaultLatestCurrencies)
+	return updater
+}
+
+// SetLatestCurrencies configures the fiat currencies for which the latest rates are fetched, so that
+// only the rates for the currencies selected by the user are fetched. BTC (and with it sat) rates are
+// always fetched. Unsupported currencies are ignored. If the currencies changed, the latest rates are
+// updated immediately.
+func (updater *RateUpdater) SetLatestCurrencies(fiats []string) {
+	if !updater.setLatestCurrencies(fiats) {
+		return
+	}
+	updater.triggerUpdateLast()
+}
+
+// SetDataSaver enables or disables the data saver mode, in which the latest rates are only updated
+// every dataSaverInterval. When disabling it, the latest rates are updated immediately.
+func (updater *RateUpdater) SetDataSaver(enabled bool) {
+	if updater.dataSaver.Swap(enabled) == enabled || enabled {
+		return
+	}
+	updater.triggerUpdateLast()
+}
+
+// SetIdle throttles the updates of the latest rates to every idleInterval while the app is hidden
+// or not in use. When the app is used again, the latest rates are updated immediately.
+func (updater *RateUpdater) SetIdle(idle bool) {
+	if updater.idle.Swap(idle) == idle || idle {
+		return
+	}
+	updater.triggerUpdateLast()
+}
+
+func (updater *RateUpdater) triggerUpdateLast() {
+	select {
+	case updater.updateLastNow <- struct{}{}:
+	default:
+		// An update is already pending.
+	}
+}
+
+// lastUpdateInterval returns how long to wait until the next update of the latest rates.
+func (updater *RateUpdater) lastUpdateInterval() time.Duration {
+	wait := interval
+	if updater.dataSaver.Load() {
+		wait = dataSaverInterval
+	}
+	if updater.idle.Load() && wait < idleInterval {
+		wait = idleInterval
+	}
+	return wait
+}
+
+// setLatestCurrencies sets latestCurrencies and returns true if they changed.
+func (updater *RateUpdater) setLatestCurrencies(fiats []string) bool {
+	currencies := []string{toGeckoFiat[BTC.String()]}
+	for _, fiat := range fiats {
+		geckoFiat, ok := toGeckoFiat[fiat]
+		if !ok {
+			updater.log.Errorf("SetLatestCurrencies: unsupported fiat %q", fiat)
+			continue
+		}
+		currencies = append(currencies, geckoFiat)
+	}
+	sort.Strings(currencies)
+	// Remove duplicates, e.g. BTC and sat both map to "btc".
+	unique := currencies[:0]
+	for i, currency := range currencies {
+		if i == 0 || currency != currencies[i-1] {
+			unique = append(unique, currency)
+		}
 	}
+
+	updater.latestCurrenciesMu.Lock()
+	defer updater.latestCurrenciesMu.Unlock()
+	if reflect.DeepEqual(unique, updater.latestCurrencies) {
+		return false
+	}
+	updater.latestCurrencies = unique
+	return true
 }
 
 // SetCoingeckoURL overrides the default URL the rates updater connects to. Useful for testing.
@@ -171,6 +319,57 @@ func (updater *RateUpdater) LatestPrice() map[string]map[string]float64 {
 	return updater.last
 }
 
+// LatestPriceInfo contains metadata about the latest rates, so the user can be informed about how
+// recent the displayed fiat amounts are.
+type LatestPriceInfo struct {
+	// Timestamp is the time the rates were fetched.
+	Timestamp time.Time `json:"timestamp"`
+	// Source is the provider of the rates.
+	Source string `json:"source"`
+	// Stale is true if the rates could not be updated for a while.
+	Stale bool `json:"stale"`
+}
+
+// LatestPriceInfo returns metadata about the rates returned by LatestPrice, or nil if the rates
+// have not been fetched yet.
+func (updater *RateUpdater) LatestPriceInfo() *LatestPriceInfo {
+	if len(updater.LatestPrice()) == 0 {
+		return nil
+	}
+	updater.lastInfoMu.RLock()
+	defer updater.lastInfoMu.RUnlock()
+	return &LatestPriceInfo{
+		Timestamp: updater.lastUpdated,
+		Source:    latestRatesSource,
+		Stale:     updater.stale,
+	}
+}
+
+// setLastUpdateResult records the result of an update of the latest rates and emits an event if
+// the rates became stale or fresh again.
+func (updater *RateUpdater) setLastUpdateResult(success bool) {
+	updater.lastInfoMu.Lock()
+	wasStale := updater.stale
+	if success {
+		updater.lastUpdated = time.Now()
+		updater.failedUpdates = 0
+		updater.stale = false
+	} else {
+		updater.failedUpdates++
+		updater.stale = updater.failedUpdates >= staleAfterFailures
+	}
+	stale := updater.stale
+	updater.lastInfoMu.Unlock()
+
+	if stale != wasStale {
+		updater.Notify(observable.Event{
+			Subject: RatesStaleEventSubject,
+			Action:  action.Replace,
+			Object:  stale,
+		})
+	}
+}
+
 // LatestPriceForPair returns the conversion rate for the given (coin, fiat) pair. Returns an error
 // if the rates have not been fetched yet. `coinUnit` values are the same as `coin.Unit`.
 func (updater *RateUpdater) LatestPriceForPair(coinUnit, fiat string) (float64, error) {
@@ -255,22 +454,27 @@ func (updater *RateUpdater) lastUpdateLoop(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(interval):
+		case <-time.After(updater.lastUpdateInterval()):
+			// continue
+		case <-updater.updateLastNow:
 			// continue
 		}
 	}
 }
 
 func (updater *RateUpdater) updateLast(ctx context.Context) {
+	updater.latestCurrenciesMu.RLock()
+	currencies := strings.Join(updater.latestCurrencies, ",")
+	updater.latestCurrenciesMu.RUnlock()
 	param := url.Values{
 		"ids":           {simplePriceAllIDs},
-		"vs_currencies": {simplePriceAllCurrencies},
+		"vs_currencies": {currencies},
 	}
 	endpoint := fmt.Sprintf("%s/simple/price?%s", updater.coingeckoURL, param.Encode())
 	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		updater.log.WithError(err).Error("could not create request")
-		updater.last = nil
+		updater.setLastUpdateResult(false)
 		return
 	}
 
@@ -286,7 +490,7 @@ func (updater *RateUpdater) updateLast(ctx context.Context) {
 		if res.StatusCode != http.StatusOK {
 			return errp.Newf("bad response code %d", res.StatusCode)
 		}
-		const max = 10240
+		const max = 65536
 		responseBody, err := io.ReadAll(io.LimitReader(res.Body, max+1))
 		if err != nil {
 			return errp.WithStack(err)
@@ -301,8 +505,10 @@ func (updater *RateUpdater) updateLast(ctx context.Context) {
 		return nil
 	})
 	if callErr != nil {
+		// The previous rates are kept, so that fiat amounts can still be shown. They are marked
+		// stale if the rates can't be updated for a while.
 		updater.log.WithError(callErr).Errorf("updateLast")
-		updater.last = nil
+		updater.setLastUpdateResult(false)
 		return
 	}
 	// Convert the map with coingecko coin/fiat codes to a map of coin/fiat units.
@@ -347,6 +553,7 @@ func (updater *RateUpdater) updateLast(ctx context.Context) {
 		}
 	}
 
+	updater.setLastUpdateResult(true)
 	if reflect.DeepEqual(rates, updater.last) {
 		return
 	}
@@ -0,0 +1,111 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rates
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateLastStale(t *testing.T) {
+	fail := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintln(w, `{"bitcoin": {"usd": 10000.0}}`)
+	}))
+	defer ts.Close()
+
+	updater := NewRateUpdater(http.DefaultClient, "/dev/null")
+	defer updater.Stop()
+	updater.coingeckoURL = ts.URL
+
+	var staleEvents []bool
+	updater.Observe(func(event observable.Event) {
+		if event.Subject == RatesStaleEventSubject {
+			staleEvents = append(staleEvents, event.Object.(bool))
+		}
+	})
+
+	require.Nil(t, updater.LatestPriceInfo())
+
+	updater.updateLast(context.Background())
+	info := updater.LatestPriceInfo()
+	require.NotNil(t, info)
+	require.Equal(t, "coingecko", info.Source)
+	require.False(t, info.Stale)
+	require.False(t, info.Timestamp.IsZero())
+
+	// The rates are kept on failure, and marked stale after too many failures.
+	fail = true
+	for i := 0; i < staleAfterFailures; i++ {
+		require.False(t, updater.LatestPriceInfo().Stale)
+		updater.updateLast(context.Background())
+	}
+	require.Equal(t, 10000.0, updater.LatestPrice()["BTC"]["USD"])
+	require.True(t, updater.LatestPriceInfo().Stale)
+	require.Equal(t, []bool{true}, staleEvents)
+
+	fail = false
+	updater.updateLast(context.Background())
+	require.False(t, updater.LatestPriceInfo().Stale)
+	require.Equal(t, []bool{true, false}, staleEvents)
+}
+
+func TestSetLatestCurrencies(t *testing.T) {
+	var vsCurrencies string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vsCurrencies = r.URL.Query().Get("vs_currencies")
+		fmt.Fprintln(w, `{"bitcoin": {"btc": 1.0, "xau": 25.0}}`)
+	}))
+	defer ts.Close()
+
+	updater := NewRateUpdater(http.DefaultClient, "/dev/null")
+	defer updater.Stop()
+	updater.coingeckoURL = ts.URL
+
+	// BTC is always included, duplicates and unsupported currencies are ignored.
+	updater.SetLatestCurrencies([]string{XAU.String(), "XYZ", XAU.String(), SAT.String()})
+	updater.updateLast(context.Background())
+	require.Equal(t, "btc,xau", vsCurrencies)
+	require.Equal(t, 25.0, updater.LatestPrice()["BTC"]["XAU"])
+}
+
+func TestLastUpdateInterval(t *testing.T) {
+	updater := NewRateUpdater(http.DefaultClient, "/dev/null")
+	defer updater.Stop()
+
+	require.Equal(t, interval, updater.lastUpdateInterval())
+	updater.SetDataSaver(true)
+	require.Equal(t, dataSaverInterval, updater.lastUpdateInterval())
+	updater.SetIdle(true)
+	require.Equal(t, idleInterval, updater.lastUpdateInterval())
+	updater.SetDataSaver(false)
+	require.Equal(t, idleInterval, updater.lastUpdateInterval())
+
+	// Resuming triggers an immediate update.
+	<-updater.updateLastNow
+	updater.SetIdle(false)
+	require.Equal(t, interval, updater.lastUpdateInterval())
+	require.Len(t, updater.updateLastNow, 1)
+}
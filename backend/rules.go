@@ -0,0 +1,321 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rules"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/webhooks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+)
+
+// maxRuleDrafts limits the number of queued drafts. The oldest one is dropped when a new one is
+// prepared and the queue is full.
+const maxRuleDrafts = 10
+
+// RuleArgs are the settings of an automation rule to add or update.
+type RuleArgs struct {
+	Name        string             `json:"name"`
+	Enabled     bool               `json:"enabled"`
+	AccountCode accountsTypes.Code `json:"accountCode"`
+	Trigger     string             `json:"trigger"`
+	Operator    string             `json:"operator"`
+	// Amount is compared with the balance or the transaction amount, in the unit of the account
+	// (e.g. "0.5" for BTC). It is ignored if there is no operator.
+	Amount    string   `json:"amount"`
+	Actions   []string `json:"actions"`
+	WebhookID string   `json:"webhookID"`
+}
+
+// RuleInfo describes a configured automation rule.
+type RuleInfo struct {
+	ID string `json:"id"`
+	RuleArgs
+	Unit string `json:"unit"`
+}
+
+// RuleFired is sent to the frontend and to the webhook of a rule when the rule fires.
+type RuleFired struct {
+	RuleID      string             `json:"ruleID"`
+	RuleName    string             `json:"ruleName"`
+	AccountCode accountsTypes.Code `json:"accountCode"`
+	AccountName string             `json:"accountName"`
+	Trigger     string             `json:"trigger"`
+	// Amount is the balance or the transaction amount which fired the rule.
+	Amount string `json:"amount"`
+	Unit   string `json:"unit"`
+	TxID   string `json:"txID,omitempty"`
+}
+
+// RuleDraft is a consolidation of all coins of an account into a new address of the same account,
+// prepared by an automation rule. The frontend opens the send screen with it, so the user can
+// review and sign it like any other transaction.
+type RuleDraft struct {
+	ID          int                `json:"id"`
+	RuleID      string             `json:"ruleID"`
+	RuleName    string             `json:"ruleName"`
+	AccountCode accountsTypes.Code `json:"accountCode"`
+	AccountName string             `json:"accountName"`
+	// Amount is the available balance when the draft was prepared.
+	Amount    string    `json:"amount"`
+	Unit      string    `json:"unit"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (backend *Backend) ruleConfigs() []config.Rule {
+	return backend.config.AppConfig().Backend.Rules
+}
+
+// ruleFromArgs validates the args and converts them to a rule with the given ID.
+func (backend *Backend) ruleFromArgs(id string, args RuleArgs) (config.Rule, error) {
+	rule := config.Rule{
+		ID:          id,
+		Name:        strings.TrimSpace(args.Name),
+		Enabled:     args.Enabled,
+		AccountCode: string(args.AccountCode),
+		Trigger:     args.Trigger,
+		Operator:    args.Operator,
+		Actions:     args.Actions,
+		WebhookID:   args.WebhookID,
+	}
+	account := backend.Accounts().lookup(args.AccountCode)
+	if account == nil {
+		return rule, errp.Newf("unknown account: %s", args.AccountCode)
+	}
+	if rule.Operator != string(rules.OperatorAny) {
+		amount, err := account.Coin().ParseAmount(args.Amount)
+		if err != nil {
+			return rule, err
+		}
+		rule.Amount = amount.BigInt().String()
+	}
+	if _, ok := account.Coin().(*btc.Coin); !ok && rules.HasAction(rule, rules.ActionDraft) {
+		return rule, errp.New("consolidation drafts are only supported for Bitcoin and Litecoin accounts")
+	}
+	knownWebhook := func(id string) bool {
+		for _, webhook := range backend.webhookConfigs() {
+			if webhook.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+	return rule, rules.Validate(rule, knownWebhook)
+}
+
+// Rules returns the configured automation rules.
+func (backend *Backend) Rules() []RuleInfo {
+	result := []RuleInfo{}
+	for _, rule := range backend.ruleConfigs() {
+		info := RuleInfo{
+			ID: rule.ID,
+			RuleArgs: RuleArgs{
+				Name:        rule.Name,
+				Enabled:     rule.Enabled,
+				AccountCode: accountsTypes.Code(rule.AccountCode),
+				Trigger:     rule.Trigger,
+				Operator:    rule.Operator,
+				Actions:     rule.Actions,
+				WebhookID:   rule.WebhookID,
+			},
+		}
+		if account := backend.Accounts().lookup(info.AccountCode); account != nil {
+			info.Unit = account.Coin().GetFormatUnit(false)
+			if amount, ok := new(big.Int).SetString(rule.Amount, 10); ok {
+				info.Amount = account.Coin().FormatAmount(coinpkg.NewAmount(amount), false)
+			}
+		}
+		result = append(result, info)
+	}
+	return result
+}
+
+// AddRule adds an automation rule and returns its ID.
+func (backend *Backend) AddRule(args RuleArgs) (string, error) {
+	rule, err := backend.ruleFromArgs(webhooks.NewID(), args)
+	if err != nil {
+		return "", err
+	}
+	err = backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		appConfig.Backend.Rules = append(appConfig.Backend.Rules, rule)
+		return rules.ValidateAll(appConfig.Backend.Rules)
+	})
+	if err != nil {
+		return "", err
+	}
+	return rule.ID, nil
+}
+
+// UpdateRule changes the settings of an automation rule.
+func (backend *Backend) UpdateRule(id string, args RuleArgs) error {
+	rule, err := backend.ruleFromArgs(id, args)
+	if err != nil {
+		return err
+	}
+	backend.ruleEngine.Forget(id)
+	return backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		for i := range appConfig.Backend.Rules {
+			if appConfig.Backend.Rules[i].ID == id {
+				appConfig.Backend.Rules[i] = rule
+				return nil
+			}
+		}
+		return errp.Newf("unknown rule: %s", id)
+	})
+}
+
+// DeleteRule removes an automation rule.
+func (backend *Backend) DeleteRule(id string) error {
+	backend.ruleEngine.Forget(id)
+	return backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		for i, rule := range appConfig.Backend.Rules {
+			if rule.ID == id {
+				appConfig.Backend.Rules = append(appConfig.Backend.Rules[:i], appConfig.Backend.Rules[i+1:]...)
+				return nil
+			}
+		}
+		return errp.Newf("unknown rule: %s", id)
+	})
+}
+
+// evaluateRules evaluates the automation rules of the account with the events detected after it
+// finished syncing, and runs the actions of the rules which fired.
+func (backend *Backend) evaluateRules(account accounts.Interface, balance *big.Int, events accountEvents) {
+	ruleConfigs := backend.ruleConfigs()
+	if len(ruleConfigs) == 0 {
+		return
+	}
+	accountCode := string(account.Config().Config.Code)
+	evaluate := func(trigger rules.Trigger, amount *big.Int, txID string) {
+		event := rules.Event{Trigger: trigger, AccountCode: accountCode, Amount: amount, TxID: txID}
+		for _, rule := range backend.ruleEngine.Evaluate(ruleConfigs, event) {
+			backend.runRuleActions(account, rule, event, balance)
+		}
+	}
+	evaluate(rules.TriggerBalance, balance, "")
+	for _, tx := range events.incoming {
+		evaluate(rules.TriggerIncomingTx, tx.Amount.BigInt(), tx.TxID)
+	}
+	for _, tx := range events.outgoing {
+		evaluate(rules.TriggerOutgoingTx, tx.Amount.BigInt(), tx.TxID)
+	}
+}
+
+func (backend *Backend) runRuleActions(
+	account accounts.Interface, rule config.Rule, event rules.Event, balance *big.Int) {
+	accountConfig := account.Config().Config
+	coin := account.Coin()
+	fired := RuleFired{
+		RuleID:      rule.ID,
+		RuleName:    rule.Name,
+		AccountCode: accountConfig.Code,
+		AccountName: accountConfig.Name,
+		Trigger:     rule.Trigger,
+		Amount:      coin.FormatAmount(coinpkg.NewAmount(event.Amount), false),
+		Unit:        coin.GetFormatUnit(false),
+		TxID:        event.TxID,
+	}
+	backend.log.Infof("Rule %s fired for account %s", rule.ID, accountConfig.Code)
+	for _, ruleAction := range rule.Actions {
+		switch rules.Action(ruleAction) {
+		case rules.ActionNotify:
+			backend.Notify(observable.Event{
+				Subject: "rule-fired",
+				Action:  action.Replace,
+				Object:  fired,
+			})
+		case rules.ActionDraft:
+			backend.addRuleDraft(&RuleDraft{
+				RuleID:      rule.ID,
+				RuleName:    rule.Name,
+				AccountCode: accountConfig.Code,
+				AccountName: accountConfig.Name,
+				Amount:      coin.FormatAmount(coinpkg.NewAmount(balance), false),
+				Unit:        fired.Unit,
+				CreatedAt:   time.Now(),
+			})
+		case rules.ActionWebhook:
+			for _, webhook := range backend.webhookConfigs() {
+				if webhook.ID != rule.WebhookID {
+					continue
+				}
+				go func(webhook config.Webhook) {
+					event := webhooks.NewEvent(webhooks.EventRuleFired, accountConfig.Code, fired)
+					if err := backend.webhooks.Send(webhook, event); err != nil {
+						backend.log.WithError(err).Errorf("Sending rule %s to webhook %s failed", rule.ID, webhook.ID)
+					}
+				}(webhook)
+			}
+		}
+	}
+}
+
+func (backend *Backend) addRuleDraft(draft *RuleDraft) {
+	unlock := backend.ruleDraftsLock.Lock()
+	backend.nextRuleDraftID++
+	draft.ID = backend.nextRuleDraftID
+	backend.ruleDrafts = append(backend.ruleDrafts, draft)
+	if len(backend.ruleDrafts) > maxRuleDrafts {
+		backend.ruleDrafts = backend.ruleDrafts[1:]
+	}
+	unlock()
+
+	backend.Notify(observable.Event{
+		Subject: "rules/drafts",
+		Action:  action.Reload,
+	})
+}
+
+// RuleDrafts returns the drafts prepared by automation rules, oldest first.
+func (backend *Backend) RuleDrafts() []RuleDraft {
+	defer backend.ruleDraftsLock.RLock()()
+	result := make([]RuleDraft, len(backend.ruleDrafts))
+	for i, draft := range backend.ruleDrafts {
+		result[i] = *draft
+	}
+	return result
+}
+
+// DismissRuleDraft removes a draft from the queue, after it was reviewed or rejected by the user.
+func (backend *Backend) DismissRuleDraft(id int) error {
+	unlock := backend.ruleDraftsLock.Lock()
+	found := false
+	for i, draft := range backend.ruleDrafts {
+		if draft.ID == id {
+			backend.ruleDrafts = append(backend.ruleDrafts[:i], backend.ruleDrafts[i+1:]...)
+			found = true
+			break
+		}
+	}
+	unlock()
+	if !found {
+		return errp.Newf("unknown rule draft: %d", id)
+	}
+	backend.Notify(observable.Event{
+		Subject: "rules/drafts",
+		Action:  action.Reload,
+	})
+	return nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rules evaluates user configured automation rules (trigger → condition → actions) on
+// account events, e.g. "when the balance of account X exceeds Y, notify me and prepare a
+// consolidation draft". Actions are limited to safe operations which never move funds on their own:
+// notifying the user, preparing a draft the user can review and sign in the app, and calling a
+// webhook.
+package rules
+
+import (
+	"math/big"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+)
+
+// Trigger is the event which evaluates a rule.
+type Trigger string
+
+const (
+	// TriggerBalance evaluates the rule with the available balance whenever the account finished
+	// syncing. Balance rules are edge-triggered: they fire when the condition starts to hold, not on
+	// every sync while it holds.
+	TriggerBalance Trigger = "balance"
+	// TriggerIncomingTx evaluates the rule with the amount of every new incoming transaction.
+	TriggerIncomingTx Trigger = "incomingTx"
+	// TriggerOutgoingTx evaluates the rule with the amount of every new outgoing transaction.
+	TriggerOutgoingTx Trigger = "outgoingTx"
+)
+
+// Operator compares the balance or transaction amount with the amount of the rule.
+type Operator string
+
+const (
+	// OperatorAny matches any amount.
+	OperatorAny Operator = ""
+	// OperatorAbove matches amounts strictly above the amount of the rule.
+	OperatorAbove Operator = "above"
+	// OperatorBelow matches amounts strictly below the amount of the rule.
+	OperatorBelow Operator = "below"
+)
+
+// Action is run when a rule fires.
+type Action string
+
+const (
+	// ActionNotify notifies the user in the app.
+	ActionNotify Action = "notify"
+	// ActionDraft prepares a draft transaction consolidating the coins of the account, which the
+	// user can review and sign.
+	ActionDraft Action = "draft"
+	// ActionWebhook calls the webhook of the rule.
+	ActionWebhook Action = "webhook"
+)
+
+// maxRules limits the number of rules.
+const maxRules = 50
+
+// Event is an account event the rules are evaluated with.
+type Event struct {
+	Trigger     Trigger
+	AccountCode string
+	// Amount is the available balance for TriggerBalance and the transaction amount otherwise, in
+	// the smallest unit.
+	Amount *big.Int
+	// TxID is the ID of the transaction, empty for TriggerBalance.
+	TxID string
+}
+
+func parseAmount(amount string) (*big.Int, bool) {
+	parsed, ok := new(big.Int).SetString(amount, 10)
+	if !ok || parsed.Sign() < 0 {
+		return nil, false
+	}
+	return parsed, true
+}
+
+// Validate checks a rule. knownWebhook returns true if a webhook with the given ID is configured.
+func Validate(rule config.Rule, knownWebhook func(id string) bool) error {
+	if rule.Name == "" {
+		return errp.New("the rule needs a name")
+	}
+	if rule.AccountCode == "" {
+		return errp.New("the rule needs an account")
+	}
+	switch Trigger(rule.Trigger) {
+	case TriggerBalance, TriggerIncomingTx, TriggerOutgoingTx:
+	default:
+		return errp.Newf("unknown trigger: %s", rule.Trigger)
+	}
+	switch Operator(rule.Operator) {
+	case OperatorAny:
+		if Trigger(rule.Trigger) == TriggerBalance {
+			return errp.New("balance rules need a condition")
+		}
+	case OperatorAbove, OperatorBelow:
+		if _, ok := parseAmount(rule.Amount); !ok {
+			return errp.Newf("invalid amount: %s", rule.Amount)
+		}
+	default:
+		return errp.Newf("unknown operator: %s", rule.Operator)
+	}
+	if len(rule.Actions) == 0 {
+		return errp.New("select at least one action")
+	}
+	for _, action := range rule.Actions {
+		switch Action(action) {
+		case ActionNotify, ActionDraft:
+		case ActionWebhook:
+			if !knownWebhook(rule.WebhookID) {
+				return errp.Newf("unknown webhook: %s", rule.WebhookID)
+			}
+		default:
+			return errp.Newf("unknown action: %s", action)
+		}
+	}
+	return nil
+}
+
+// ValidateAll checks the number of rules.
+func ValidateAll(rules []config.Rule) error {
+	if len(rules) > maxRules {
+		return errp.Newf("at most %d rules are supported", maxRules)
+	}
+	return nil
+}
+
+// HasAction returns true if the rule runs the given action.
+func HasAction(rule config.Rule, action Action) bool {
+	for _, a := range rule.Actions {
+		if Action(a) == action {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns true if the amount fulfills the condition of the rule.
+func matches(rule config.Rule, amount *big.Int) bool {
+	operator := Operator(rule.Operator)
+	if operator == OperatorAny {
+		return true
+	}
+	threshold, ok := parseAmount(rule.Amount)
+	if !ok || amount == nil {
+		return false
+	}
+	switch operator {
+	case OperatorAbove:
+		return amount.Cmp(threshold) > 0
+	case OperatorBelow:
+		return amount.Cmp(threshold) < 0
+	default:
+		return false
+	}
+}
+
+// Engine evaluates rules, remembering the state of balance rules between evaluations. The zero
+// value is not usable, use NewEngine().
+type Engine struct {
+	// matched maps the IDs of balance rules to whether their condition held at the last
+	// evaluation.
+	matched map[string]bool
+	mu      locker.Locker
+}
+
+// NewEngine creates a new engine.
+func NewEngine() *Engine {
+	return &Engine{matched: map[string]bool{}}
+}
+
+// Evaluate returns the enabled rules which fire for the event. The first evaluation of a balance
+// rule only records whether its condition holds, so that restarting the app or changing the rule
+// does not fire rules whose condition already held.
+func (engine *Engine) Evaluate(rules []config.Rule, event Event) []config.Rule {
+	defer engine.mu.Lock()()
+	fired := []config.Rule{}
+	for _, rule := range rules {
+		if !rule.Enabled || rule.AccountCode != event.AccountCode || Trigger(rule.Trigger) != event.Trigger {
+			continue
+		}
+		isMatch := matches(rule, event.Amount)
+		if event.Trigger == TriggerBalance {
+			wasMatch, known := engine.matched[rule.ID]
+			engine.matched[rule.ID] = isMatch
+			if !known || wasMatch {
+				continue
+			}
+		}
+		if isMatch {
+			fired = append(fired, rule)
+		}
+	}
+	return fired
+}
+
+// Forget drops the state of a rule, e.g. after it was changed or deleted.
+func (engine *Engine) Forget(ruleID string) {
+	defer engine.mu.Lock()()
+	delete(engine.matched, ruleID)
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	knownWebhook := func(id string) bool { return id == "hook" }
+	rule := config.Rule{
+		Name:        "Consolidate",
+		AccountCode: "v0-abc-btc-0",
+		Trigger:     string(TriggerBalance),
+		Operator:    string(OperatorAbove),
+		Amount:      "100000000",
+		Actions:     []string{string(ActionNotify), string(ActionDraft)},
+	}
+	require.NoError(t, Validate(rule, knownWebhook))
+
+	invalid := rule
+	invalid.Operator = string(OperatorAny)
+	require.Error(t, Validate(invalid, knownWebhook))
+
+	invalid = rule
+	invalid.Amount = "1.5"
+	require.Error(t, Validate(invalid, knownWebhook))
+
+	invalid = rule
+	invalid.Trigger = "price"
+	require.Error(t, Validate(invalid, knownWebhook))
+
+	invalid = rule
+	invalid.Actions = []string{"send"}
+	require.Error(t, Validate(invalid, knownWebhook))
+
+	withWebhook := rule
+	withWebhook.Actions = []string{string(ActionWebhook)}
+	require.Error(t, Validate(withWebhook, knownWebhook))
+	withWebhook.WebhookID = "hook"
+	require.NoError(t, Validate(withWebhook, knownWebhook))
+
+	// Transaction rules without a condition fire on every transaction.
+	incoming := rule
+	incoming.Trigger = string(TriggerIncomingTx)
+	incoming.Operator = string(OperatorAny)
+	incoming.Amount = ""
+	require.NoError(t, Validate(incoming, knownWebhook))
+}
+
+func TestEvaluateBalance(t *testing.T) {
+	rules := []config.Rule{{
+		ID:          "rule",
+		Enabled:     true,
+		AccountCode: "account",
+		Trigger:     string(TriggerBalance),
+		Operator:    string(OperatorAbove),
+		Amount:      "100",
+		Actions:     []string{string(ActionNotify)},
+	}}
+	engine := NewEngine()
+	balance := func(amount int64) Event {
+		return Event{Trigger: TriggerBalance, AccountCode: "account", Amount: big.NewInt(amount)}
+	}
+	// The first evaluation only records the state.
+	require.Empty(t, engine.Evaluate(rules, balance(200)))
+	require.Empty(t, engine.Evaluate(rules, balance(200)))
+	require.Empty(t, engine.Evaluate(rules, balance(50)))
+	require.Len(t, engine.Evaluate(rules, balance(101)), 1)
+	require.Empty(t, engine.Evaluate(rules, balance(150)))
+	require.Empty(t, engine.Evaluate(rules, balance(100)))
+	require.Len(t, engine.Evaluate(rules, balance(120)), 1)
+
+	// Other accounts and disabled rules are not evaluated.
+	require.Empty(t, engine.Evaluate(rules,
+		Event{Trigger: TriggerBalance, AccountCode: "other", Amount: big.NewInt(0)}))
+	engine.Forget("rule")
+	rules[0].Enabled = false
+	require.Empty(t, engine.Evaluate(rules, balance(0)))
+	require.Empty(t, engine.Evaluate(rules, balance(200)))
+}
+
+func TestEvaluateTransactions(t *testing.T) {
+	rules := []config.Rule{
+		{
+			ID:          "any",
+			Enabled:     true,
+			AccountCode: "account",
+			Trigger:     string(TriggerIncomingTx),
+			Actions:     []string{string(ActionNotify)},
+		},
+		{
+			ID:          "small",
+			Enabled:     true,
+			AccountCode: "account",
+			Trigger:     string(TriggerIncomingTx),
+			Operator:    string(OperatorBelow),
+			Amount:      "1000",
+			Actions:     []string{string(ActionDraft)},
+		},
+	}
+	engine := NewEngine()
+	incoming := func(amount int64) Event {
+		return Event{Trigger: TriggerIncomingTx, AccountCode: "account", Amount: big.NewInt(amount), TxID: "tx"}
+	}
+	require.Len(t, engine.Evaluate(rules, incoming(5000)), 1)
+	fired := engine.Evaluate(rules, incoming(500))
+	require.Len(t, fired, 2)
+	require.Equal(t, "small", fired[1].ID)
+	// Transaction rules fire on every matching transaction.
+	require.Len(t, engine.Evaluate(rules, incoming(500)), 2)
+	require.Empty(t, engine.Evaluate(rules,
+		Event{Trigger: TriggerOutgoingTx, AccountCode: "account", Amount: big.NewInt(500)}))
+}
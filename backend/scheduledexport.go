@@ -0,0 +1,173 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/scheduledexport"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+)
+
+// ScheduledExportArgs are the settings of the scheduled exports to apply.
+type ScheduledExportArgs struct {
+	Enabled   bool                           `json:"enabled"`
+	Directory string                         `json:"directory"`
+	Interval  config.ScheduledExportInterval `json:"interval"`
+	Format    accounts.ExportFormat          `json:"format"`
+	// BackupPassword encrypts the settings backup. An empty password keeps the current one, unless
+	// ClearBackupPassword is true, which disables the settings backup.
+	BackupPassword      string `json:"backupPassword"`
+	ClearBackupPassword bool   `json:"clearBackupPassword"`
+}
+
+func (backend *Backend) scheduledExportSettings() config.ScheduledExport {
+	return backend.config.AppConfig().Backend.ScheduledExport
+}
+
+// scheduledTransactionExports returns the transaction exports of all synced accounts. Accounts
+// which are not synced, e.g. because they are not initialized yet in the lazy account
+// initialization mode, are skipped.
+func (backend *Backend) scheduledTransactionExports(format string) ([]scheduledexport.Export, int, error) {
+	exports := []scheduledexport.Export{}
+	skipped := 0
+	for _, account := range backend.Accounts() {
+		if account.Config().Config.HiddenBecauseUnused {
+			continue
+		}
+		if account.FatalError() || !account.Synced() {
+			skipped++
+			continue
+		}
+		transactions, err := account.Transactions()
+		if err != nil {
+			backend.log.WithError(err).Error("Could not get the transactions for the scheduled export")
+			skipped++
+			continue
+		}
+		account := account
+		exports = append(exports, scheduledexport.Export{
+			Name: string(account.Config().Config.Code),
+			Write: func(w io.Writer) error {
+				return account.Export(w, accounts.ExportFormat(format), transactions)
+			},
+		})
+	}
+	return exports, skipped, nil
+}
+
+// backupNotes returns the notes of all accounts, including accounts which are not loaded, keyed by
+// the name of their notes file. Notes files which can't be read are skipped.
+func (backend *Backend) backupNotes() (map[string]*notes.Data, error) {
+	notesDirectory := backend.arguments.NotesDirectoryPath()
+	entries, err := os.ReadDir(notesDirectory)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	result := map[string]*notes.Data{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		accountNotes, err := notes.LoadNotes(filepath.Join(notesDirectory, entry.Name()))
+		if err != nil {
+			backend.log.WithError(err).Errorf("Could not back up the notes %s", entry.Name())
+			continue
+		}
+		result[name] = accountNotes.Data()
+	}
+	return result, nil
+}
+
+// settingsBackup returns the app and accounts config and the notes of all accounts to back up.
+func (backend *Backend) settingsBackup() ([]byte, error) {
+	notesBackup, err := backend.backupNotes()
+	if err != nil {
+		return nil, err
+	}
+	result, err := json.Marshal(struct {
+		AppConfig      config.AppConfig       `json:"appConfig"`
+		AccountsConfig config.AccountsConfig  `json:"accountsConfig"`
+		Notes          map[string]*notes.Data `json:"notes"`
+	}{
+		AppConfig:      backend.config.AppConfig(),
+		AccountsConfig: backend.config.AccountsConfig(),
+		Notes:          notesBackup,
+	})
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return result, nil
+}
+
+// ScheduledExportStatus returns the settings of the scheduled exports and the result of the last
+// run.
+func (backend *Backend) ScheduledExportStatus() *scheduledexport.Status {
+	return backend.scheduledExport.Status()
+}
+
+// SetScheduledExport changes the settings of the scheduled exports.
+func (backend *Backend) SetScheduledExport(args ScheduledExportArgs) error {
+	directory := strings.TrimSpace(args.Directory)
+	if args.Enabled && directory == "" {
+		return errp.New("the export directory is required")
+	}
+	if directory != "" && !filepath.IsAbs(directory) {
+		return errp.Newf("the export directory must be an absolute path: %s", directory)
+	}
+	if _, ok := scheduledexport.Interval(args.Interval); !ok {
+		return errp.Newf("unknown interval %q", args.Interval)
+	}
+	if !args.Format.Valid() {
+		return errp.Newf("unknown export format %q", args.Format)
+	}
+	err := backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		settings := &appConfig.Backend.ScheduledExport
+		settings.Enabled = args.Enabled
+		settings.Directory = directory
+		settings.Interval = args.Interval
+		settings.Format = string(args.Format)
+		switch {
+		case args.ClearBackupPassword:
+			settings.BackupPassword = ""
+		case args.BackupPassword != "":
+			settings.BackupPassword = args.BackupPassword
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	backend.Notify(observable.Event{
+		Subject: "diagnostics/scheduled-export",
+		Action:  action.Reload,
+	})
+	return nil
+}
+
+// RunScheduledExport runs the scheduled export now.
+func (backend *Backend) RunScheduledExport() error {
+	return backend.scheduledExport.Run()
+}
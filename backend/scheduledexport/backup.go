@@ -0,0 +1,124 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduledexport
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	backupVersion = 1
+	backupKDF     = "scrypt"
+
+	// scrypt parameters recommended for interactive use as of 2017, see the docs of
+	// golang.org/x/crypto/scrypt.
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	saltLen = 16
+)
+
+// ErrWrongPassword is returned by DecryptBackup() if the backup cannot be decrypted with the given
+// password, or if it was tampered with.
+var ErrWrongPassword = errors.New("wrong password")
+
+// encryptedBackup is the file format of an encrypted backup. The key is derived from the password
+// with the stored KDF parameters and the plaintext is encrypted with XChaCha20-Poly1305.
+type encryptedBackup struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// EncryptBackup encrypts the plaintext with the password, returning the JSON encoded backup.
+func EncryptBackup(password string, plaintext []byte) ([]byte, error) {
+	if password == "" {
+		return nil, errp.New("the backup password must not be empty")
+	}
+	backup := encryptedBackup{
+		Version: backupVersion,
+		KDF:     backupKDF,
+		N:       scryptN,
+		R:       scryptR,
+		P:       scryptP,
+		Salt:    make([]byte, saltLen),
+		Nonce:   make([]byte, chacha20poly1305.NonceSizeX),
+	}
+	if _, err := rand.Read(backup.Salt); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if _, err := rand.Read(backup.Nonce); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	key, err := scrypt.Key([]byte(password), backup.Salt, backup.N, backup.R, backup.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	backup.Ciphertext = aead.Seal(nil, backup.Nonce, plaintext, nil)
+	result, err := json.Marshal(backup)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return result, nil
+}
+
+// DecryptBackup decrypts a backup created by EncryptBackup().
+func DecryptBackup(password string, backupJSON []byte) ([]byte, error) {
+	var backup encryptedBackup
+	if err := json.Unmarshal(backupJSON, &backup); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if backup.Version != backupVersion || backup.KDF != backupKDF {
+		return nil, errp.Newf("unsupported backup version %d, kdf %q", backup.Version, backup.KDF)
+	}
+	// Backups can come from folders or servers shared with others, see the metadata sync. Only the
+	// parameters written by EncryptBackup() are accepted, so that a crafted backup cannot make the
+	// key derivation exhaust the memory.
+	if backup.N != scryptN || backup.R != scryptR || backup.P != scryptP {
+		return nil, errp.Newf("unsupported scrypt parameters N=%d, r=%d, p=%d", backup.N, backup.R, backup.P)
+	}
+	if len(backup.Nonce) != chacha20poly1305.NonceSizeX {
+		return nil, errp.New("invalid backup nonce")
+	}
+	key, err := scrypt.Key([]byte(password), backup.Salt, backup.N, backup.R, backup.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	plaintext, err := aead.Open(nil, backup.Nonce, backup.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	return plaintext, nil
+}
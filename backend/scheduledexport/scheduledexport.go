@@ -0,0 +1,328 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scheduledexport periodically writes the transaction exports of all accounts and an
+// encrypted backup of the settings to a directory chosen by the user, e.g. a folder which is
+// synced to another machine or read by accounting software.
+package scheduledexport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	utilConfig "github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// checkInterval is how often the scheduler checks whether an export is due.
+	checkInterval = 15 * time.Minute
+	// retryInterval is how long to wait after a failed export before trying again.
+	retryInterval = time.Hour
+
+	timestampFormat = "2006-01-02-at-15-04-05"
+)
+
+// Interval returns the duration between two scheduled exports, or false if the interval is
+// unknown.
+func Interval(interval config.ScheduledExportInterval) (time.Duration, bool) {
+	switch interval {
+	case config.ScheduledExportDaily:
+		return 24 * time.Hour, true
+	case config.ScheduledExportWeekly:
+		return 7 * 24 * time.Hour, true
+	}
+	return 0, false
+}
+
+// Export is the transaction export of one account.
+type Export struct {
+	// Name identifies the export in the file name, e.g. the account code.
+	Name string
+	// Write writes the export in the configured format.
+	Write func(w io.Writer) error
+}
+
+// state is persisted, so that the schedule survives restarts of the app.
+type state struct {
+	LastRun     *time.Time `json:"lastRun"`
+	LastSuccess *time.Time `json:"lastSuccess"`
+	LastError   string     `json:"lastError"`
+	// LastFiles are the files written by the last successful run.
+	LastFiles []string `json:"lastFiles"`
+	// SkippedAccounts is the number of accounts which could not be exported in the last run,
+	// e.g. because they were not synced yet.
+	SkippedAccounts int `json:"skippedAccounts"`
+}
+
+// Status describes the scheduled exports, reported by the diagnostics endpoint.
+type Status struct {
+	Enabled           bool                           `json:"enabled"`
+	Directory         string                         `json:"directory"`
+	Interval          config.ScheduledExportInterval `json:"interval"`
+	Format            string                         `json:"format"`
+	HasBackupPassword bool                           `json:"hasBackupPassword"`
+	Running           bool                           `json:"running"`
+	// NextRun is nil if the scheduled exports are disabled.
+	NextRun *time.Time `json:"nextRun"`
+
+	state
+}
+
+// Scheduler runs the exports according to the settings. The zero value is not usable, use
+// NewScheduler().
+type Scheduler struct {
+	observable.Implementation
+
+	file     *utilConfig.File
+	settings func() config.ScheduledExport
+	// exports returns the transaction exports of all accounts in the given format, and the number
+	// of accounts which could not be exported.
+	exports func(format string) ([]Export, int, error)
+	// settingsBackup returns the settings to back up, unencrypted.
+	settingsBackup func() ([]byte, error)
+	now            func() time.Time
+
+	state   state
+	running bool
+	mu      locker.Locker
+	quit    chan struct{}
+
+	log *logrus.Entry
+}
+
+// NewScheduler creates a scheduler which persists its state in the given file. The state stored by
+// a previous run is loaded.
+func NewScheduler(
+	filename string,
+	settings func() config.ScheduledExport,
+	exports func(format string) ([]Export, int, error),
+	settingsBackup func() ([]byte, error),
+) *Scheduler {
+	scheduler := &Scheduler{
+		file:           utilConfig.NewFile(filepath.Dir(filename), filepath.Base(filename)),
+		settings:       settings,
+		exports:        exports,
+		settingsBackup: settingsBackup,
+		now:            time.Now,
+		log:            logging.Get().WithGroup("scheduledexport"),
+	}
+	if scheduler.file.Exists() {
+		if err := scheduler.file.ReadJSON(&scheduler.state); err != nil {
+			scheduler.log.WithError(err).Error("Could not load the scheduled export state")
+			scheduler.state = state{}
+		}
+	}
+	return scheduler
+}
+
+// Start periodically runs the exports when they are due until Stop() is called.
+func (scheduler *Scheduler) Start() {
+	unlock := scheduler.mu.Lock()
+	if scheduler.quit != nil {
+		unlock()
+		return
+	}
+	quit := make(chan struct{})
+	scheduler.quit = quit
+	unlock()
+
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			if scheduler.due() {
+				if err := scheduler.Run(); err != nil {
+					scheduler.log.WithError(err).Error("Scheduled export failed")
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduled exports.
+func (scheduler *Scheduler) Stop() {
+	defer scheduler.mu.Lock()()
+	if scheduler.quit != nil {
+		close(scheduler.quit)
+		scheduler.quit = nil
+	}
+}
+
+// nextRun returns when the next export is due. The zero time means as soon as possible. The lock
+// must be held when calling this function.
+func (scheduler *Scheduler) nextRun(settings config.ScheduledExport) time.Time {
+	var next time.Time
+	interval, ok := Interval(settings.Interval)
+	if ok && scheduler.state.LastSuccess != nil {
+		next = scheduler.state.LastSuccess.Add(interval)
+	}
+	if scheduler.state.LastError != "" && scheduler.state.LastRun != nil {
+		if retry := scheduler.state.LastRun.Add(retryInterval); retry.After(next) {
+			next = retry
+		}
+	}
+	return next
+}
+
+func (scheduler *Scheduler) due() bool {
+	settings := scheduler.settings()
+	if !settings.Enabled || settings.Directory == "" {
+		return false
+	}
+	defer scheduler.mu.RLock()()
+	return !scheduler.running && !scheduler.nextRun(settings).After(scheduler.now())
+}
+
+// Status returns the settings and the result of the last run.
+func (scheduler *Scheduler) Status() *Status {
+	settings := scheduler.settings()
+	defer scheduler.mu.RLock()()
+	status := &Status{
+		Enabled:           settings.Enabled,
+		Directory:         settings.Directory,
+		Interval:          settings.Interval,
+		Format:            settings.Format,
+		HasBackupPassword: settings.BackupPassword != "",
+		Running:           scheduler.running,
+		state:             scheduler.state,
+	}
+	if settings.Enabled {
+		next := scheduler.nextRun(settings)
+		if now := scheduler.now(); next.Before(now) {
+			next = now
+		}
+		status.NextRun = &next
+	}
+	return status
+}
+
+func (scheduler *Scheduler) notify() {
+	scheduler.Notify(observable.Event{
+		Subject: "diagnostics/scheduled-export",
+		Action:  action.Reload,
+	})
+}
+
+// writeFile writes the file via a temporary file, so that a partially written export never
+// replaces a previous one, e.g. in a synced folder.
+func writeFile(path string, write func(w io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, buf.Bytes(), 0600); err != nil {
+		return errp.WithStack(err)
+	}
+	return errp.WithStack(os.Rename(tmpPath, path))
+}
+
+// export writes the transaction exports and the settings backup to the configured directory,
+// returning the names of the written files and the number of skipped accounts.
+func (scheduler *Scheduler) export(settings config.ScheduledExport, now time.Time) ([]string, int, error) {
+	if settings.Directory == "" {
+		return nil, 0, errp.New("no export directory configured")
+	}
+	if err := os.MkdirAll(settings.Directory, 0700); err != nil {
+		return nil, 0, errp.WithStack(err)
+	}
+	timestamp := now.Format(timestampFormat)
+	exports, skipped, err := scheduler.exports(settings.Format)
+	if err != nil {
+		return nil, 0, err
+	}
+	files := []string{}
+	for _, export := range exports {
+		name := fmt.Sprintf("%s-%s-export.%s", timestamp, export.Name, settings.Format)
+		if err := writeFile(filepath.Join(settings.Directory, name), export.Write); err != nil {
+			return files, skipped, err
+		}
+		files = append(files, name)
+	}
+	if settings.BackupPassword != "" {
+		plaintext, err := scheduler.settingsBackup()
+		if err != nil {
+			return files, skipped, err
+		}
+		backup, err := EncryptBackup(settings.BackupPassword, plaintext)
+		if err != nil {
+			return files, skipped, err
+		}
+		name := fmt.Sprintf("%s-settings-backup.json", timestamp)
+		err = writeFile(filepath.Join(settings.Directory, name), func(w io.Writer) error {
+			_, err := w.Write(backup)
+			return errp.WithStack(err)
+		})
+		if err != nil {
+			return files, skipped, err
+		}
+		files = append(files, name)
+	}
+	return files, skipped, nil
+}
+
+// Run exports now, independent of the schedule, e.g. to test the settings.
+func (scheduler *Scheduler) Run() error {
+	unlock := scheduler.mu.Lock()
+	if scheduler.running {
+		unlock()
+		return errp.New("an export is already running")
+	}
+	scheduler.running = true
+	unlock()
+	scheduler.notify()
+
+	settings := scheduler.settings()
+	now := scheduler.now()
+	files, skipped, err := scheduler.export(settings, now)
+
+	unlock = scheduler.mu.Lock()
+	scheduler.running = false
+	scheduler.state.LastRun = &now
+	scheduler.state.SkippedAccounts = skipped
+	if err != nil {
+		scheduler.state.LastError = err.Error()
+	} else {
+		scheduler.state.LastError = ""
+		scheduler.state.LastSuccess = &now
+		scheduler.state.LastFiles = files
+	}
+	if saveErr := scheduler.file.WriteJSON(scheduler.state); saveErr != nil {
+		scheduler.log.WithError(saveErr).Error("Could not persist the scheduled export state")
+	}
+	unlock()
+	scheduler.notify()
+
+	if err != nil {
+		return err
+	}
+	scheduler.log.WithField("files", len(files)).Info("Scheduled export done")
+	return nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduledexport
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackup(t *testing.T) {
+	encrypted, err := EncryptBackup("password", []byte("settings"))
+	require.NoError(t, err)
+	require.NotContains(t, string(encrypted), "settings")
+
+	plaintext, err := DecryptBackup("password", encrypted)
+	require.NoError(t, err)
+	require.Equal(t, []byte("settings"), plaintext)
+
+	_, err = DecryptBackup("wrong", encrypted)
+	require.Equal(t, ErrWrongPassword, err)
+
+	// Other scrypt parameters than the ones used by EncryptBackup() are rejected.
+	var backup encryptedBackup
+	require.NoError(t, json.Unmarshal(encrypted, &backup))
+	backup.N = 1 << 40
+	tampered, err := json.Marshal(backup)
+	require.NoError(t, err)
+	_, err = DecryptBackup("password", tampered)
+	require.Error(t, err)
+	require.NotEqual(t, ErrWrongPassword, err)
+
+	_, err = EncryptBackup("", []byte("settings"))
+	require.Error(t, err)
+}
+
+func TestScheduler(t *testing.T) {
+	dir := t.TempDir()
+	settings := config.ScheduledExport{
+		Enabled:        true,
+		Directory:      filepath.Join(dir, "exports"),
+		Interval:       config.ScheduledExportDaily,
+		Format:         "csv",
+		BackupPassword: "password",
+	}
+	var exportsErr error
+	skipped := 1
+	newScheduler := func() *Scheduler {
+		return NewScheduler(
+			filepath.Join(dir, "scheduled-export.json"),
+			func() config.ScheduledExport { return settings },
+			func(format string) ([]Export, int, error) {
+				require.Equal(t, "csv", format)
+				return []Export{{
+					Name: "btc-0",
+					Write: func(w io.Writer) error {
+						_, err := io.WriteString(w, "Time,Type\n")
+						return err
+					},
+				}}, skipped, exportsErr
+			},
+			func() ([]byte, error) { return []byte(`{"settings":true}`), nil },
+		)
+	}
+	scheduler := newScheduler()
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	scheduler.now = func() time.Time { return now }
+
+	// Never ran, so the export is due right away.
+	require.True(t, scheduler.due())
+	require.Equal(t, &now, scheduler.Status().NextRun)
+
+	require.NoError(t, scheduler.Run())
+	status := scheduler.Status()
+	require.Equal(t, &now, status.LastSuccess)
+	require.Equal(t, "", status.LastError)
+	require.Equal(t, 1, status.SkippedAccounts)
+	require.True(t, status.HasBackupPassword)
+	require.Equal(t, []string{
+		"2024-05-06-at-07-08-09-btc-0-export.csv",
+		"2024-05-06-at-07-08-09-settings-backup.json",
+	}, status.LastFiles)
+	exported, err := os.ReadFile(filepath.Join(settings.Directory, status.LastFiles[0]))
+	require.NoError(t, err)
+	require.Equal(t, "Time,Type\n", string(exported))
+	backup, err := os.ReadFile(filepath.Join(settings.Directory, status.LastFiles[1]))
+	require.NoError(t, err)
+	plaintext, err := DecryptBackup("password", backup)
+	require.NoError(t, err)
+	require.Equal(t, `{"settings":true}`, string(plaintext))
+
+	// Next run is one day later.
+	require.False(t, scheduler.due())
+	require.Equal(t, now.Add(24*time.Hour), *scheduler.Status().NextRun)
+	now = now.Add(24 * time.Hour)
+	require.True(t, scheduler.due())
+
+	// A failed run is retried after the retry interval.
+	exportsErr = errp.New("export failed")
+	require.Error(t, scheduler.Run())
+	status = scheduler.Status()
+	require.Equal(t, "export failed", status.LastError)
+	require.Equal(t, &now, status.LastRun)
+	require.Equal(t, now.Add(-24*time.Hour), *status.LastSuccess)
+	require.False(t, scheduler.due())
+	require.Equal(t, now.Add(retryInterval), *status.NextRun)
+
+	// The state survives a restart.
+	restarted := newScheduler()
+	restarted.now = scheduler.now
+	require.Equal(t, status.state.LastError, restarted.Status().LastError)
+	require.False(t, restarted.due())
+
+	// Weekly interval.
+	exportsErr = nil
+	now = now.Add(retryInterval)
+	require.NoError(t, scheduler.Run())
+	settings.Interval = config.ScheduledExportWeekly
+	require.Equal(t, now.Add(7*24*time.Hour), *scheduler.Status().NextRun)
+
+	// Disabled.
+	settings.Enabled = false
+	now = now.Add(30 * 24 * time.Hour)
+	require.False(t, scheduler.due())
+	require.Nil(t, scheduler.Status().NextRun)
+
+	// No settings backup without password.
+	settings.BackupPassword = ""
+	require.NoError(t, scheduler.Run())
+	require.Len(t, scheduler.Status().LastFiles, 1)
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetScheduledExport(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	status := b.ScheduledExportStatus()
+	require.False(t, status.Enabled)
+	require.Equal(t, config.ScheduledExportDaily, status.Interval)
+	require.Equal(t, "csv", status.Format)
+	require.Nil(t, status.NextRun)
+
+	directory := filepath.Join(t.TempDir(), "exports")
+	args := ScheduledExportArgs{
+		Enabled:        true,
+		Directory:      directory,
+		Interval:       config.ScheduledExportWeekly,
+		Format:         accounts.ExportFormatOFX,
+		BackupPassword: "password",
+	}
+	require.NoError(t, b.SetScheduledExport(args))
+	status = b.ScheduledExportStatus()
+	require.True(t, status.Enabled)
+	require.Equal(t, directory, status.Directory)
+	require.Equal(t, config.ScheduledExportWeekly, status.Interval)
+	require.Equal(t, "ofx", status.Format)
+	require.True(t, status.HasBackupPassword)
+	require.NotNil(t, status.NextRun)
+
+	// An empty password keeps the current one.
+	args.BackupPassword = ""
+	require.NoError(t, b.SetScheduledExport(args))
+	require.Equal(t, "password", b.config.AppConfig().Backend.ScheduledExport.BackupPassword)
+	args.ClearBackupPassword = true
+	require.NoError(t, b.SetScheduledExport(args))
+	require.False(t, b.ScheduledExportStatus().HasBackupPassword)
+
+	invalid := args
+	invalid.Directory = "relative/path"
+	require.Error(t, b.SetScheduledExport(invalid))
+	invalid = args
+	invalid.Directory = ""
+	require.Error(t, b.SetScheduledExport(invalid))
+	invalid = args
+	invalid.Interval = "hourly"
+	require.Error(t, b.SetScheduledExport(invalid))
+	invalid = args
+	invalid.Format = "xls"
+	require.Error(t, b.SetScheduledExport(invalid))
+
+	// Without accounts, only the settings backup would be written, which is disabled.
+	require.NoError(t, b.RunScheduledExport())
+	status = b.ScheduledExportStatus()
+	require.Empty(t, status.LastError)
+	require.Empty(t, status.LastFiles)
+}
+
+func TestSettingsBackup(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	accountNotes, err := notes.LoadNotes(filepath.Join(b.arguments.NotesDirectoryPath(), "account-1.json"))
+	require.NoError(t, err)
+	require.NoError(t, accountNotes.SetTxNote("txid", "rent"))
+
+	plaintext, err := b.settingsBackup()
+	require.NoError(t, err)
+	var backup struct {
+		AccountsConfig config.AccountsConfig  `json:"accountsConfig"`
+		Notes          map[string]*notes.Data `json:"notes"`
+	}
+	require.NoError(t, json.Unmarshal(plaintext, &backup))
+	require.Len(t, backup.Notes, 1)
+	require.Equal(t, map[string]string{"txid": "rent"}, backup.Notes["account-1"].TransactionNotes)
+}
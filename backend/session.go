@@ -0,0 +1,137 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"sort"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bitbox02"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/jsonp"
+	"github.com/BitBoxSwiss/bitbox02-api-go/api/firmware"
+)
+
+// maxFrontendStateSize limits the size of the state the frontend can store in the session.
+const maxFrontendStateSize = 65536
+
+// SessionKeystore describes the unlocked keystore.
+type SessionKeystore struct {
+	RootFingerprint jsonp.HexBytes `json:"rootFingerprint"`
+	Name            string         `json:"name"`
+	Type            keystore.Type  `json:"type"`
+}
+
+// SessionDevice describes a registered device.
+type SessionDevice struct {
+	DeviceID    string `json:"deviceID"`
+	ProductName string `json:"productName"`
+	// Status is the BitBox02 status, e.g. "unpaired" or "initialized". Empty for other devices.
+	Status string `json:"status,omitempty"`
+	// PairingPending is true while the pairing code of a BitBox02 is waiting to be confirmed.
+	PairingPending bool `json:"pairingPending"`
+}
+
+// SessionAccount describes the sync state of an active account.
+type SessionAccount struct {
+	Code   accountsTypes.Code `json:"code"`
+	Synced bool               `json:"synced"`
+}
+
+// Session describes what is currently unlocked, paired and syncing in the backend. The backend
+// outlives the frontend webview, which can be reloaded at any time (e.g. on Android), so the
+// frontend uses this to restore its state after a reload.
+type Session struct {
+	// Keystore is nil if no keystore is unlocked.
+	Keystore *SessionKeystore `json:"keystore"`
+	Devices  []SessionDevice  `json:"devices"`
+	Accounts []SessionAccount `json:"accounts"`
+	// Syncing is true if at least one active account is not synced yet.
+	Syncing bool `json:"syncing"`
+	// FrontendState is the opaque state last stored by the frontend with SetFrontendSessionState,
+	// or null.
+	FrontendState json.RawMessage `json:"frontendState"`
+}
+
+// Session returns the current session state.
+func (backend *Backend) Session() Session {
+	session := Session{
+		Devices:  []SessionDevice{},
+		Accounts: []SessionAccount{},
+	}
+
+	if ks := backend.Keystore(); ks != nil {
+		sessionKeystore := &SessionKeystore{Type: ks.Type()}
+		if rootFingerprint, err := ks.RootFingerprint(); err == nil {
+			sessionKeystore.RootFingerprint = rootFingerprint
+		} else {
+			backend.log.WithError(err).Error("Could not get the root fingerprint")
+		}
+		if name, err := ks.Name(); err == nil {
+			sessionKeystore.Name = name
+		}
+		session.Keystore = sessionKeystore
+	}
+
+	for deviceID, theDevice := range backend.DevicesRegistered() {
+		sessionDevice := SessionDevice{
+			DeviceID:    deviceID,
+			ProductName: theDevice.ProductName(),
+		}
+		if bb02, ok := theDevice.(*bitbox02.Device); ok {
+			status := bb02.Status()
+			sessionDevice.Status = string(status)
+			sessionDevice.PairingPending = status == firmware.StatusUnpaired
+		}
+		session.Devices = append(session.Devices, sessionDevice)
+	}
+	sort.Slice(session.Devices, func(i, j int) bool {
+		return session.Devices[i].DeviceID < session.Devices[j].DeviceID
+	})
+
+	for _, account := range backend.Accounts() {
+		if account.Config().Config.Inactive || account.FatalError() {
+			continue
+		}
+		synced := account.Synced()
+		if !synced {
+			session.Syncing = true
+		}
+		session.Accounts = append(session.Accounts, SessionAccount{
+			Code:   account.Config().Config.Code,
+			Synced: synced,
+		})
+	}
+
+	defer backend.frontendSessionStateLock.RLock()()
+	session.FrontendState = backend.frontendSessionState
+	return session
+}
+
+// SetFrontendSessionState stores an opaque JSON value for the frontend, which it can restore
+// after a reload using Session(). It is kept in memory only, and is lost when the app is closed.
+func (backend *Backend) SetFrontendSessionState(state json.RawMessage) error {
+	if len(state) > maxFrontendStateSize {
+		return errp.New("frontend session state too large")
+	}
+	if len(state) > 0 && !json.Valid(state) {
+		return errp.New("frontend session state must be valid JSON")
+	}
+	defer backend.frontendSessionStateLock.Lock()()
+	backend.frontendSessionState = state
+	return nil
+}
@@ -80,6 +80,26 @@ type BitcoinSimple struct {
 	ScriptType ScriptType `json:"scriptType"`
 }
 
+// maxMultisigKeys is the maximum number of keys in a multisig configuration, as a P2WSH
+// CHECKMULTISIG script is limited to 20 keys by consensus and 15 by the BitBox02.
+const maxMultisigKeys = 15
+
+// BitcoinMultisig represents a threshold multisig (m-of-n) Bitcoin/Litecoin signing configuration.
+// The addresses are P2WSH outputs with a sorted multisig witness script (BIP67), so the order of
+// the keys does not matter.
+type BitcoinMultisig struct {
+	Threshold int `json:"threshold"`
+	// KeyInfos are the keys of all cosigners, including our own.
+	KeyInfos []KeyInfo `json:"keyInfos"`
+	// OurKeyIndex is the index of our key in KeyInfos.
+	OurKeyIndex int `json:"ourKeyIndex"`
+}
+
+// ourKeyInfo returns the key info of our own key.
+func (multisig *BitcoinMultisig) ourKeyInfo() *KeyInfo {
+	return &multisig.KeyInfos[multisig.OurKeyIndex]
+}
+
 // EthereumSimple represents a simple (standard single-sig, no exotic signing methods) Ethereum
 // signing configuration.
 type EthereumSimple struct {
@@ -90,8 +110,9 @@ type EthereumSimple struct {
 type Configuration struct {
 	// Poor man's union type: only one of the below can be non-nil.
 
-	BitcoinSimple  *BitcoinSimple  `json:"bitcoinSimple,omitempty"`
-	EthereumSimple *EthereumSimple `json:"ethereumSimple,omitempty"`
+	BitcoinSimple   *BitcoinSimple   `json:"bitcoinSimple,omitempty"`
+	BitcoinMultisig *BitcoinMultisig `json:"bitcoinMultisig,omitempty"`
+	EthereumSimple  *EthereumSimple  `json:"ethereumSimple,omitempty"`
 }
 
 // NewBitcoinConfiguration creates a new configuration.
@@ -116,6 +137,42 @@ func NewBitcoinConfiguration(
 	}
 }
 
+// NewBitcoinMultisigConfiguration creates a new threshold multisig configuration. keyInfos are the
+// keys of all cosigners, and ourKeyIndex is the index of the key of the keystore.
+func NewBitcoinMultisigConfiguration(
+	threshold int,
+	keyInfos []KeyInfo,
+	ourKeyIndex int,
+) (*Configuration, error) {
+	if len(keyInfos) < 2 || len(keyInfos) > maxMultisigKeys {
+		return nil, errp.Newf("a multisig configuration needs between 2 and %d keys", maxMultisigKeys)
+	}
+	if threshold < 1 || threshold > len(keyInfos) {
+		return nil, errp.Newf("the threshold must be between 1 and %d", len(keyInfos))
+	}
+	if ourKeyIndex < 0 || ourKeyIndex >= len(keyInfos) {
+		return nil, errp.New("our key is not part of the multisig configuration")
+	}
+	xpubs := map[string]struct{}{}
+	for _, keyInfo := range keyInfos {
+		if keyInfo.ExtendedPublicKey.IsPrivate() {
+			panic("An extended key is private! Only extended public keys are accepted.")
+		}
+		xpub := keyInfo.ExtendedPublicKey.String()
+		if _, ok := xpubs[xpub]; ok {
+			return nil, errp.Newf("duplicate cosigner key %s", xpub)
+		}
+		xpubs[xpub] = struct{}{}
+	}
+	return &Configuration{
+		BitcoinMultisig: &BitcoinMultisig{
+			Threshold:   threshold,
+			KeyInfos:    keyInfos,
+			OurKeyIndex: ourKeyIndex,
+		},
+	}, nil
+}
+
 // NewEthereumConfiguration creates a new configuration.
 func NewEthereumConfiguration(
 	rootFingerprint []byte,
@@ -138,6 +195,9 @@ func NewEthereumConfiguration(
 
 // ScriptType returns the configuration's keypath.
 func (configuration *Configuration) ScriptType() ScriptType {
+	if configuration.BitcoinMultisig != nil {
+		return ScriptTypeP2WSH
+	}
 	return configuration.BitcoinSimple.ScriptType
 }
 
@@ -146,6 +206,9 @@ func (configuration *Configuration) AbsoluteKeypath() AbsoluteKeypath {
 	if configuration.BitcoinSimple != nil {
 		return configuration.BitcoinSimple.KeyInfo.AbsoluteKeypath
 	}
+	if configuration.BitcoinMultisig != nil {
+		return configuration.BitcoinMultisig.ourKeyInfo().AbsoluteKeypath
+	}
 	return configuration.EthereumSimple.KeyInfo.AbsoluteKeypath
 }
 
@@ -154,6 +217,9 @@ func (configuration *Configuration) ExtendedPublicKey() *hdkeychain.ExtendedKey
 	if configuration.BitcoinSimple != nil {
 		return configuration.BitcoinSimple.KeyInfo.ExtendedPublicKey
 	}
+	if configuration.BitcoinMultisig != nil {
+		return configuration.BitcoinMultisig.ourKeyInfo().ExtendedPublicKey
+	}
 	return configuration.EthereumSimple.KeyInfo.ExtendedPublicKey
 }
 
@@ -161,6 +227,7 @@ func (configuration *Configuration) ExtendedPublicKey() *hdkeychain.ExtendedKey
 // The configuration keypath must be a BIP44 keypath:
 // m/purpose'/coin'/account' for Bitcoin-based coins.
 // m/44'/coin'/0'/0/account for Ethereum.
+// m/48'/coin'/account'/script_type' for Bitcoin-based multisig (BIP48).
 // For invalid keypaths, zero is returned for the account number, along with an error.
 func (configuration *Configuration) AccountNumber() (uint16, error) {
 	if configuration.BitcoinSimple != nil {
@@ -170,6 +237,13 @@ func (configuration *Configuration) AccountNumber() (uint16, error) {
 		}
 		return uint16(keypath[2] - hdkeychain.HardenedKeyStart), nil
 	}
+	if configuration.BitcoinMultisig != nil {
+		keypath := configuration.BitcoinMultisig.ourKeyInfo().AbsoluteKeypath.ToUInt32()
+		if len(keypath) != 4 || keypath[2] < hdkeychain.HardenedKeyStart {
+			return 0, errp.Newf("unexpected bitcoin multisig keypath: %v", keypath)
+		}
+		return uint16(keypath[2] - hdkeychain.HardenedKeyStart), nil
+	}
 	if configuration.EthereumSimple != nil {
 		keypath := configuration.EthereumSimple.KeyInfo.AbsoluteKeypath.ToUInt32()
 		if len(keypath) != 5 || keypath[4] >= hdkeychain.HardenedKeyStart {
@@ -208,6 +282,30 @@ func (configuration *Configuration) Derive(relativeKeypath RelativeKeypath) (*Co
 			derivedPublicKey,
 		), nil
 	}
+	if multisig := configuration.BitcoinMultisig; multisig != nil {
+		if relativeKeypath.Hardened() {
+			return nil, errp.New("A configuration can only be derived with a non-hardened relative keypath.")
+		}
+		keyInfos := make([]KeyInfo, len(multisig.KeyInfos))
+		for i, keyInfo := range multisig.KeyInfos {
+			derivedPublicKey, err := relativeKeypath.Derive(keyInfo.ExtendedPublicKey)
+			if err != nil {
+				return nil, err
+			}
+			keyInfos[i] = KeyInfo{
+				RootFingerprint:   keyInfo.RootFingerprint,
+				AbsoluteKeypath:   keyInfo.AbsoluteKeypath.Append(relativeKeypath),
+				ExtendedPublicKey: derivedPublicKey,
+			}
+		}
+		return &Configuration{
+			BitcoinMultisig: &BitcoinMultisig{
+				Threshold:   multisig.Threshold,
+				KeyInfos:    keyInfos,
+				OurKeyIndex: multisig.OurKeyIndex,
+			},
+		}, nil
+	}
 
 	return nil, errp.New("Can only call this on a bitcoin configuration")
 }
@@ -218,6 +316,12 @@ func (configuration *Configuration) String() string {
 		return fmt.Sprintf("bitcoinSimple;scriptType=%s;%s",
 			configuration.BitcoinSimple.ScriptType, configuration.BitcoinSimple.KeyInfo)
 	}
+	if configuration.BitcoinMultisig != nil {
+		return fmt.Sprintf("bitcoinMultisig;threshold=%d;keys=%d;%s",
+			configuration.BitcoinMultisig.Threshold,
+			len(configuration.BitcoinMultisig.KeyInfos),
+			configuration.BitcoinMultisig.ourKeyInfo())
+	}
 	return fmt.Sprintf("ethereumSimple;%s", configuration.EthereumSimple.KeyInfo)
 }
 
@@ -233,6 +337,9 @@ func (configs Configurations) RootFingerprint() ([]byte, error) {
 		if config.BitcoinSimple != nil {
 			return config.BitcoinSimple.KeyInfo.RootFingerprint, nil
 		}
+		if config.BitcoinMultisig != nil {
+			return config.BitcoinMultisig.ourKeyInfo().RootFingerprint, nil
+		}
 		if config.EthereumSimple != nil {
 			return config.EthereumSimple.KeyInfo.RootFingerprint, nil
 		}
@@ -248,6 +355,11 @@ func (configs Configurations) ContainsRootFingerprint(rootFingerprint []byte) bo
 				return true
 			}
 		}
+		if config.BitcoinMultisig != nil {
+			if bytes.Equal(config.BitcoinMultisig.ourKeyInfo().RootFingerprint, rootFingerprint) {
+				return true
+			}
+		}
 		if config.EthereumSimple != nil {
 			if bytes.Equal(config.EthereumSimple.KeyInfo.RootFingerprint, rootFingerprint) {
 				return true
@@ -264,6 +376,9 @@ func (configs Configurations) FindScriptType(scriptType ScriptType) int {
 		if config.BitcoinSimple != nil && config.BitcoinSimple.ScriptType == scriptType {
 			return idx
 		}
+		if config.BitcoinMultisig != nil && scriptType == ScriptTypeP2WSH {
+			return idx
+		}
 	}
 	return -1
 }
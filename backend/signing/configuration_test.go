@@ -15,6 +15,7 @@
 package signing
 
 import (
+	"bytes"
 	"encoding/json"
 	"testing"
 
@@ -148,3 +149,68 @@ func TestAccountNumber(t *testing.T) {
 	require.Error(t, err)
 	require.Equal(t, uint16(0), num)
 }
+
+func TestBitcoinMultisig(t *testing.T) {
+	keyInfos := make([]KeyInfo, 3)
+	for i := range keyInfos {
+		xprv, err := hdkeychain.NewMaster(bytes.Repeat([]byte{byte(i + 1)}, 32), &chaincfg.TestNet3Params)
+		require.NoError(t, err)
+		xpub, err := xprv.Neuter()
+		require.NoError(t, err)
+		keyInfos[i] = KeyInfo{
+			RootFingerprint:   []byte{byte(i), 2, 3, 4},
+			AbsoluteKeypath:   mustKeypath("m/48'/1'/7'/2'"),
+			ExtendedPublicKey: xpub,
+		}
+	}
+
+	_, err := NewBitcoinMultisigConfiguration(2, keyInfos[:1], 0)
+	require.Error(t, err)
+	_, err = NewBitcoinMultisigConfiguration(0, keyInfos, 0)
+	require.Error(t, err)
+	_, err = NewBitcoinMultisigConfiguration(4, keyInfos, 0)
+	require.Error(t, err)
+	_, err = NewBitcoinMultisigConfiguration(2, keyInfos, 3)
+	require.Error(t, err)
+	_, err = NewBitcoinMultisigConfiguration(2, []KeyInfo{keyInfos[0], keyInfos[1], keyInfos[0]}, 0)
+	require.Error(t, err)
+
+	cfg, err := NewBitcoinMultisigConfiguration(2, keyInfos, 1)
+	require.NoError(t, err)
+	require.Equal(t, ScriptTypeP2WSH, cfg.ScriptType())
+	require.Equal(t, keyInfos[1].ExtendedPublicKey, cfg.ExtendedPublicKey())
+	num, err := cfg.AccountNumber()
+	require.NoError(t, err)
+	require.Equal(t, uint16(7), num)
+	configs := Configurations{cfg}
+	rootFingerprint, err := configs.RootFingerprint()
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3, 4}, rootFingerprint)
+	require.False(t, configs.ContainsRootFingerprint([]byte{0, 2, 3, 4}))
+	require.Equal(t, 0, configs.FindScriptType(ScriptTypeP2WSH))
+	require.Equal(t, -1, configs.FindScriptType(ScriptTypeP2WPKH))
+
+	derived, err := cfg.Derive(NewEmptyRelativeKeypath().Child(0, NonHardened).Child(3, NonHardened))
+	require.NoError(t, err)
+	require.Equal(t, "m/48'/1'/7'/2'/0/3", derived.AbsoluteKeypath().Encode())
+	require.Len(t, derived.BitcoinMultisig.KeyInfos, 3)
+	for _, keyInfo := range derived.BitcoinMultisig.KeyInfos {
+		require.Equal(t, "m/48'/1'/7'/2'/0/3", keyInfo.AbsoluteKeypath.Encode())
+	}
+	_, err = cfg.Derive(NewEmptyRelativeKeypath().Child(0, Hardened))
+	require.Error(t, err)
+
+	jsonBytes, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	var cfgDecoded Configuration
+	require.NoError(t, json.Unmarshal(jsonBytes, &cfgDecoded))
+	require.Nil(t, cfgDecoded.BitcoinSimple)
+	require.NotNil(t, cfgDecoded.BitcoinMultisig)
+	require.Equal(t, cfg.String(), cfgDecoded.String())
+	require.Equal(t, 2, cfgDecoded.BitcoinMultisig.Threshold)
+	require.Equal(t, 1, cfgDecoded.BitcoinMultisig.OurKeyIndex)
+	for i, keyInfo := range cfgDecoded.BitcoinMultisig.KeyInfos {
+		require.Equal(t, keyInfos[i].ExtendedPublicKey.String(), keyInfo.ExtendedPublicKey.String())
+		require.Equal(t, keyInfos[i].RootFingerprint, keyInfo.RootFingerprint)
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sshtunnel connects to servers through SSH tunnels, e.g. to reach an Electrum server
+// running on a node at home which is not exposed to the internet. The SSH connections are
+// established on demand, when the first connection to a server behind the tunnel is made, and
+// reused for all servers reached through the same SSH server. Only public key authentication is
+// supported, and the host key of the SSH server must be pinned.
+package sshtunnel
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+)
+
+// handshakeTimeout limits the time to connect and authenticate to the SSH server.
+const handshakeTimeout = 20 * time.Second
+
+// State is the state of a tunnel. See the list of consts below.
+type State string
+
+const (
+	// StateIdle means the tunnel was not needed yet, or the connection was closed.
+	StateIdle State = "idle"
+	// StateConnecting means the SSH connection is being established.
+	StateConnecting State = "connecting"
+	// StateConnected means the SSH connection is established.
+	StateConnected State = "connected"
+	// StateError means the SSH connection could not be established or was lost.
+	StateError State = "error"
+)
+
+// Status describes a tunnel.
+type Status struct {
+	Address  string `json:"address"`
+	Username string `json:"username"`
+	// Servers are the servers reached through this tunnel.
+	Servers     []string   `json:"servers"`
+	State       State      `json:"state"`
+	Error       string     `json:"error,omitempty"`
+	ConnectedAt *time.Time `json:"connectedAt,omitempty"`
+}
+
+// tunnel is an SSH connection to an SSH server.
+type tunnel struct {
+	state       State
+	err         string
+	connectedAt *time.Time
+	client      *ssh.Client
+	// connecting is closed once a connection attempt finished.
+	connecting chan struct{}
+}
+
+// Manager manages the tunnels. The zero value is not usable, use NewManager().
+type Manager struct {
+	observable.Implementation
+
+	// tunnels returns the tunnel configured for each server address.
+	tunnels func() map[string]*config.SSHTunnel
+
+	// connections is keyed by tunnelKey().
+	connections map[string]*tunnel
+	mu          locker.Locker
+
+	log *logrus.Entry
+}
+
+// NewManager creates a new manager. tunnels returns the tunnel configured for each server address,
+// e.g. "127.0.0.1:50002" for an Electrum server running on the SSH server.
+func NewManager(tunnels func() map[string]*config.SSHTunnel) *Manager {
+	return &Manager{
+		tunnels:     tunnels,
+		connections: map[string]*tunnel{},
+		log:         logging.Get().WithGroup("sshtunnel"),
+	}
+}
+
+// tunnelKey identifies the SSH connection of a tunnel config. Servers reached through the same SSH
+// server with the same credentials share the connection.
+func tunnelKey(tunnelConfig *config.SSHTunnel) string {
+	return strings.Join([]string{
+		tunnelConfig.Username, tunnelConfig.Address, tunnelConfig.PrivateKeyPath, tunnelConfig.HostKey,
+	}, "|")
+}
+
+func (manager *Manager) notify() {
+	manager.Notify(observable.Event{
+		Subject: "ssh-tunnels/status",
+		Action:  action.Reload,
+	})
+}
+
+// clientConfig returns the SSH client config of the tunnel, accepting only the pinned host key.
+func clientConfig(tunnelConfig *config.SSHTunnel) (*ssh.ClientConfig, error) {
+	if tunnelConfig.HostKey == "" {
+		return nil, errp.New("the host key of the SSH server is required")
+	}
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(tunnelConfig.HostKey))
+	if err != nil {
+		return nil, errp.WithMessage(err, "invalid host key")
+	}
+	sshConfig := &ssh.ClientConfig{
+		User:            tunnelConfig.Username,
+		HostKeyCallback: ssh.FixedHostKey(hostKey),
+		Timeout:         handshakeTimeout,
+	}
+	if tunnelConfig.PrivateKeyPath != "" {
+		keyPEM, err := os.ReadFile(tunnelConfig.PrivateKeyPath)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		var signer ssh.Signer
+		if tunnelConfig.PrivateKeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(keyPEM, []byte(tunnelConfig.PrivateKeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(keyPEM)
+		}
+		if err != nil {
+			return nil, errp.WithMessage(err, "invalid private key")
+		}
+		sshConfig.Auth = []ssh.AuthMethod{ssh.PublicKeys(signer)}
+	}
+	return sshConfig, nil
+}
+
+// connect establishes an SSH connection, reaching the SSH server with the given dialer.
+func connect(tunnelConfig *config.SSHTunnel, sshConfig *ssh.ClientConfig, dialer proxy.Dialer) (*ssh.Client, error) {
+	conn, err := dialer.Dial("tcp", tunnelConfig.Address)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		_ = conn.Close()
+		return nil, errp.WithStack(err)
+	}
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, tunnelConfig.Address, sshConfig)
+	if err != nil {
+		_ = conn.Close()
+		return nil, errp.WithStack(err)
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		_ = sshConn.Close()
+		return nil, errp.WithStack(err)
+	}
+	return ssh.NewClient(sshConn, channels, requests), nil
+}
+
+// client returns the SSH client of the tunnel, connecting if needed.
+func (manager *Manager) client(tunnelConfig *config.SSHTunnel, dialer proxy.Dialer) (*ssh.Client, error) {
+	key := tunnelKey(tunnelConfig)
+	unlock := manager.mu.Lock()
+	t, ok := manager.connections[key]
+	if !ok {
+		t = &tunnel{state: StateIdle}
+		manager.connections[key] = t
+	}
+	if t.client != nil {
+		client := t.client
+		unlock()
+		return client, nil
+	}
+	if t.connecting != nil {
+		// Another connection attempt is running, wait for it.
+		connecting := t.connecting
+		unlock()
+		<-connecting
+		unlock = manager.mu.RLock()
+		client, err := t.client, t.err
+		unlock()
+		if client != nil {
+			return client, nil
+		}
+		return nil, errp.New(err)
+	}
+	connecting := make(chan struct{})
+	t.connecting = connecting
+	t.state = StateConnecting
+	unlock()
+	manager.notify()
+
+	client, err := manager.dialSSH(tunnelConfig, dialer)
+
+	unlock = manager.mu.Lock()
+	t.connecting = nil
+	close(connecting)
+	if err != nil {
+		t.state = StateError
+		t.err = err.Error()
+	} else {
+		now := time.Now()
+		t.state = StateConnected
+		t.err = ""
+		t.connectedAt = &now
+		t.client = client
+	}
+	unlock()
+	manager.notify()
+	if err != nil {
+		manager.log.WithError(err).WithField("address", tunnelConfig.Address).Error("SSH tunnel failed")
+		return nil, err
+	}
+	manager.log.WithField("address", tunnelConfig.Address).Info("SSH tunnel connected")
+	go manager.watch(t, client)
+	return client, nil
+}
+
+func (manager *Manager) dialSSH(tunnelConfig *config.SSHTunnel, dialer proxy.Dialer) (*ssh.Client, error) {
+	sshConfig, err := clientConfig(tunnelConfig)
+	if err != nil {
+		return nil, err
+	}
+	return connect(tunnelConfig, sshConfig, dialer)
+}
+
+// watch resets the tunnel when the SSH connection is closed, so that it is established again when
+// it is needed.
+func (manager *Manager) watch(t *tunnel, client *ssh.Client) {
+	err := client.Wait()
+	unlock := manager.mu.Lock()
+	if t.client != client {
+		unlock()
+		return
+	}
+	t.client = nil
+	t.connectedAt = nil
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		t.state = StateError
+		t.err = err.Error()
+	} else {
+		t.state = StateIdle
+	}
+	unlock()
+	manager.notify()
+}
+
+// dial connects to the address through the tunnel.
+func (manager *Manager) dial(tunnelConfig *config.SSHTunnel, dialer proxy.Dialer, network, address string) (net.Conn, error) {
+	client, err := manager.client(tunnelConfig, dialer)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.Dial(network, address)
+	if err != nil {
+		return nil, errp.WithMessage(err, "could not connect through the SSH tunnel")
+	}
+	return conn, nil
+}
+
+type dialerFunc func(network, address string) (net.Conn, error)
+
+// Dial implements proxy.Dialer.
+func (f dialerFunc) Dial(network, address string) (net.Conn, error) {
+	return f(network, address)
+}
+
+// Dialer returns a dialer which connects to the servers with a configured tunnel through the
+// tunnel, and to all other servers directly with the given dialer. The SSH servers are also
+// reached with the given dialer, e.g. through Tor.
+func (manager *Manager) Dialer(dialer proxy.Dialer) proxy.Dialer {
+	return dialerFunc(func(network, address string) (net.Conn, error) {
+		tunnelConfig, ok := manager.tunnels()[address]
+		if !ok {
+			return dialer.Dial(network, address)
+		}
+		return manager.dial(tunnelConfig, dialer, network, address)
+	})
+}
+
+// TunnelDialer returns a dialer which connects to all addresses through the given tunnel, e.g. to
+// check a server before it is configured.
+func (manager *Manager) TunnelDialer(tunnelConfig *config.SSHTunnel, dialer proxy.Dialer) proxy.Dialer {
+	return dialerFunc(func(network, address string) (net.Conn, error) {
+		return manager.dial(tunnelConfig, dialer, network, address)
+	})
+}
+
+// errHostKeyFetched aborts the handshake in FetchHostKey() once the host key was received.
+var errHostKeyFetched = errors.New("host key fetched")
+
+// FetchHostKey connects to the SSH server and returns its host key in the authorized_keys format,
+// to be confirmed by the user and pinned in the tunnel config. The connection is closed before
+// authenticating.
+func FetchHostKey(address string, dialer proxy.Dialer) (string, error) {
+	var hostKey string
+	sshConfig := &ssh.ClientConfig{
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			hostKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+			return errHostKeyFetched
+		},
+		Timeout: handshakeTimeout,
+	}
+	_, err := connect(&config.SSHTunnel{Address: address}, sshConfig, dialer)
+	if hostKey != "" {
+		return hostKey, nil
+	}
+	if err == nil {
+		return "", errp.New("the SSH server did not send a host key")
+	}
+	return "", err
+}
+
+// Status returns the configured tunnels.
+func (manager *Manager) Status() []Status {
+	byKey := map[string]*Status{}
+	for server, tunnelConfig := range manager.tunnels() {
+		key := tunnelKey(tunnelConfig)
+		status, ok := byKey[key]
+		if !ok {
+			status = &Status{
+				Address:  tunnelConfig.Address,
+				Username: tunnelConfig.Username,
+				Servers:  []string{},
+				State:    StateIdle,
+			}
+			byKey[key] = status
+		}
+		status.Servers = append(status.Servers, server)
+	}
+	unlock := manager.mu.RLock()
+	for key, status := range byKey {
+		if t, ok := manager.connections[key]; ok {
+			status.State = t.state
+			status.Error = t.err
+			status.ConnectedAt = t.connectedAt
+		}
+	}
+	unlock()
+	result := []Status{}
+	for _, status := range byKey {
+		sort.Strings(status.Servers)
+		result = append(result, *status)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Address != result[j].Address {
+			return result[i].Address < result[j].Address
+		}
+		return result[i].Username < result[j].Username
+	})
+	return result
+}
+
+// Close closes all SSH connections. They are established again when needed.
+func (manager *Manager) Close() {
+	unlock := manager.mu.Lock()
+	clients := []*ssh.Client{}
+	for _, t := range manager.connections {
+		if t.client != nil {
+			clients = append(clients, t.client)
+		}
+	}
+	unlock()
+	for _, client := range clients {
+		_ = client.Close()
+	}
+}
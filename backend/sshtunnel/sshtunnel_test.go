@@ -0,0 +1,195 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sshtunnel
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+)
+
+// startEchoServer starts a TCP server echoing back what it receives.
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close() //nolint:errcheck
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// startSSHServer starts an SSH server accepting the given client key and forwarding direct-tcpip
+// channels. It returns the address and the host key in the authorized_keys format.
+func startSSHServer(t *testing.T, clientKey ssh.PublicKey) (string, string) {
+	t.Helper()
+	_, hostPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostPrivateKey)
+	require.NoError(t, err)
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if !bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return nil, io.EOF
+			}
+			return nil, nil
+		},
+	}
+	serverConfig.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, channels, requests, err := ssh.NewServerConn(conn, serverConfig)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(requests)
+				for newChannel := range channels {
+					var target struct {
+						Host       string
+						Port       uint32
+						OriginHost string
+						OriginPort uint32
+					}
+					if newChannel.ChannelType() != "direct-tcpip" ||
+						ssh.Unmarshal(newChannel.ExtraData(), &target) != nil {
+						_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+						continue
+					}
+					targetConn, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.FormatUint(uint64(target.Port), 10)))
+					if err != nil {
+						_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					channel, channelRequests, err := newChannel.Accept()
+					if err != nil {
+						continue
+					}
+					go ssh.DiscardRequests(channelRequests)
+					go func() {
+						defer channel.Close()    //nolint:errcheck
+						defer targetConn.Close() //nolint:errcheck
+						go func() { _, _ = io.Copy(targetConn, channel) }()
+						_, _ = io.Copy(channel, targetConn)
+					}()
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String(), strings.TrimSpace(string(ssh.MarshalAuthorizedKey(hostSigner.PublicKey())))
+}
+
+// writeClientKey writes a new client key to a file and returns the path and the public key.
+func writeClientKey(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(privateKey, "")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0600))
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+	return path, signer.PublicKey()
+}
+
+func TestTunnel(t *testing.T) {
+	echoAddress := startEchoServer(t)
+	keyPath, publicKey := writeClientKey(t)
+	sshAddress, hostKey := startSSHServer(t, publicKey)
+
+	hostKeyFetched, err := FetchHostKey(sshAddress, proxy.Direct)
+	require.NoError(t, err)
+	require.Equal(t, hostKey, hostKeyFetched)
+
+	tunnelConfig := &config.SSHTunnel{
+		Address:        sshAddress,
+		Username:       "satoshi",
+		PrivateKeyPath: keyPath,
+		HostKey:        hostKey,
+	}
+	manager := NewManager(func() map[string]*config.SSHTunnel {
+		return map[string]*config.SSHTunnel{echoAddress: tunnelConfig}
+	})
+	defer manager.Close()
+	status := manager.Status()
+	require.Len(t, status, 1)
+	require.Equal(t, StateIdle, status[0].State)
+	require.Equal(t, []string{echoAddress}, status[0].Servers)
+
+	for i := 0; i < 2; i++ {
+		conn, err := manager.Dialer(proxy.Direct).Dial("tcp", echoAddress)
+		require.NoError(t, err)
+		_, err = conn.Write([]byte("ping"))
+		require.NoError(t, err)
+		reply := make([]byte, 4)
+		_, err = io.ReadFull(conn, reply)
+		require.NoError(t, err)
+		require.Equal(t, "ping", string(reply))
+		require.NoError(t, conn.Close())
+	}
+	status = manager.Status()
+	require.Equal(t, StateConnected, status[0].State)
+	require.NotNil(t, status[0].ConnectedAt)
+
+	// A different host key is rejected.
+	_, otherHostKey := startSSHServer(t, publicKey)
+	_, err = manager.TunnelDialer(&config.SSHTunnel{
+		Address:        sshAddress,
+		Username:       "satoshi",
+		PrivateKeyPath: keyPath,
+		HostKey:        otherHostKey,
+	}, proxy.Direct).Dial("tcp", echoAddress)
+	require.Error(t, err)
+
+	// A tunnel without a pinned host key is refused.
+	_, err = manager.TunnelDialer(&config.SSHTunnel{
+		Address:        sshAddress,
+		Username:       "satoshi",
+		PrivateKeyPath: keyPath,
+	}, proxy.Direct).Dial("tcp", echoAddress)
+	require.Error(t, err)
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/sshtunnel"
+	"golang.org/x/net/proxy"
+)
+
+// sshTunnelConfigs returns the SSH tunnels configured for the Electrum servers, keyed by the
+// server address. If several coins use the same server address with different tunnels, the tunnel
+// of the first coin is used.
+func (backend *Backend) sshTunnelConfigs() map[string]*config.SSHTunnel {
+	result := map[string]*config.SSHTunnel{}
+	backendConfig := backend.config.AppConfig().Backend
+	for _, code := range []coinpkg.Code{
+		coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeRBTC, coinpkg.CodeLTC, coinpkg.CodeTLTC,
+	} {
+		servers, _ := backendConfig.ElectrumServers(code)
+		for _, server := range servers {
+			if server.SSHTunnel == nil {
+				continue
+			}
+			if _, ok := result[server.Server]; !ok {
+				result[server.Server] = server.SSHTunnel
+			}
+		}
+	}
+	return result
+}
+
+// serverDialer returns the dialer to reach the server, through its SSH tunnel if one is set.
+func (backend *Backend) serverDialer(serverInfo *config.ServerInfo) proxy.Dialer {
+	dialer := backend.socksProxy.GetTCPProxyDialer()
+	if serverInfo.SSHTunnel != nil {
+		return backend.sshTunnels.TunnelDialer(serverInfo.SSHTunnel, dialer)
+	}
+	return dialer
+}
+
+// SSHTunnelsStatus returns the state of the configured SSH tunnels.
+func (backend *Backend) SSHTunnelsStatus() []sshtunnel.Status {
+	return backend.sshTunnels.Status()
+}
+
+// FetchSSHHostKey returns the host key of an SSH server, to be confirmed by the user before it is
+// pinned in the tunnel config.
+func (backend *Backend) FetchSSHHostKey(address string) (string, error) {
+	return sshtunnel.FetchHostKey(address, backend.socksProxy.GetTCPProxyDialer())
+}
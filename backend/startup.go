@@ -0,0 +1,90 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+)
+
+// StartupTask is a task run in the background by Start(). These tasks may depend on third-party
+// servers, so they must not delay the accounts, which are loaded right away.
+type StartupTask string
+
+const (
+	// StartupTaskRates configures the exchange rates, which are then fetched in the background.
+	StartupTaskRates StartupTask = "rates"
+	// StartupTaskBanners fetches the banners.
+	StartupTaskBanners StartupTask = "banners"
+	// StartupTaskUpdate checks whether a newer version of the app has been released.
+	StartupTaskUpdate StartupTask = "update"
+	// StartupTaskBuildInfo reads and verifies the build info.
+	StartupTaskBuildInfo StartupTask = "buildInfo"
+)
+
+// startupTasks are all startup tasks, in the order in which they are started.
+var startupTasks = []StartupTask{
+	StartupTaskRates,
+	StartupTaskBanners,
+	StartupTaskUpdate,
+	StartupTaskBuildInfo,
+}
+
+// StartupTaskStatus is the readiness of a startup task.
+type StartupTaskStatus struct {
+	// Ready is true if the task is done, also if it failed.
+	Ready bool `json:"ready"`
+	// Error is the error of the failed task, empty if it succeeded or is not done yet.
+	Error string `json:"error"`
+}
+
+// StartupStatus returns the readiness of all startup tasks.
+func (backend *Backend) StartupStatus() map[StartupTask]StartupTaskStatus {
+	defer backend.startupStatusLock.RLock()()
+	result := make(map[StartupTask]StartupTaskStatus, len(startupTasks))
+	for _, task := range startupTasks {
+		result[task] = backend.startupStatus[task]
+	}
+	return result
+}
+
+// runStartupTask runs the task in a goroutine and notifies the frontend when it is done, so it can
+// load what depends on it, e.g. the update notification.
+func (backend *Backend) runStartupTask(task StartupTask, run func() error) {
+	go func() {
+		started := time.Now()
+		err := run()
+		log := backend.log.WithField("task", task).WithField("duration", time.Since(started))
+		status := StartupTaskStatus{Ready: true}
+		if err != nil {
+			log.WithError(err).Warning("Startup task failed")
+			status.Error = err.Error()
+		} else {
+			log.Info("Startup task done")
+		}
+		unlock := backend.startupStatusLock.Lock()
+		if backend.startupStatus == nil {
+			backend.startupStatus = map[StartupTask]StartupTaskStatus{}
+		}
+		backend.startupStatus[task] = status
+		unlock()
+		backend.Notify(observable.Event{
+			Subject: "startup-status",
+			Action:  action.Reload,
+		})
+	}()
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunStartupTask(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	status := b.StartupStatus()
+	require.Len(t, status, len(startupTasks))
+	for _, task := range startupTasks {
+		require.Equal(t, StartupTaskStatus{}, status[task])
+	}
+
+	// A slow task does not block and is not ready until it is done.
+	done := make(chan struct{})
+	b.runStartupTask(StartupTaskBanners, func() error {
+		<-done
+		return nil
+	})
+	b.runStartupTask(StartupTaskUpdate, func() error {
+		return errp.New("offline")
+	})
+	require.Eventually(t, func() bool {
+		return b.StartupStatus()[StartupTaskUpdate].Ready
+	}, time.Second, time.Millisecond)
+	require.Equal(t, "offline", b.StartupStatus()[StartupTaskUpdate].Error)
+	require.False(t, b.StartupStatus()[StartupTaskBanners].Ready)
+
+	close(done)
+	require.Eventually(t, func() bool {
+		return b.StartupStatus()[StartupTaskBanners] == StartupTaskStatus{Ready: true}
+	}, time.Second, time.Millisecond)
+
+	// The update check result is not available before the check is done.
+	require.Nil(t, b.CheckForUpdateIgnoringErrors())
+}
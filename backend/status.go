@@ -0,0 +1,157 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/headers"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/bitbox02"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
+)
+
+// statusRequestTimeout bounds the requests made to get the status, e.g. the Ethereum tip height,
+// so that the status is returned in time even if a server is slow.
+const statusRequestTimeout = 5 * time.Second
+
+// CoinStatus is the connection state of a coin used by the loaded accounts.
+type CoinStatus struct {
+	Code coinpkg.Code `json:"code"`
+	Name string       `json:"name"`
+	// Connected is true if the coin is connected to its backend, i.e. ConnectionError is empty.
+	Connected       bool   `json:"connected"`
+	ConnectionError string `json:"connectionError"`
+	// Server is the connected Electrum server of Bitcoin-based coins. Empty if not connected and
+	// for Ethereum-based coins.
+	Server string `json:"server"`
+	// LatencyMs is the time it took to connect to the Electrum server, or the duration of the tip
+	// height request for Ethereum-based coins. 0 if unknown.
+	LatencyMs int64 `json:"latencyMs"`
+	// TipHeight is 0 if unknown.
+	TipHeight int64 `json:"tipHeight"`
+	// Headers is the headers sync progress of Bitcoin-based coins, nil for other coins.
+	Headers *headers.Status `json:"headers"`
+}
+
+// DeviceStatus is the state of a registered device.
+type DeviceStatus struct {
+	ID          string `json:"id"`
+	ProductName string `json:"productName"`
+	// Status is the firmware status of a BitBox02, e.g. "initialized". Empty for other devices.
+	Status string `json:"status"`
+}
+
+// Status aggregates the connection state of the coins, the freshness of the exchange rates and the
+// state of the devices, for a status screen.
+type Status struct {
+	Coins []CoinStatus `json:"coins"`
+	// Rates is nil if the rates have not been fetched yet.
+	Rates   *rates.LatestPriceInfo `json:"rates"`
+	Devices []DeviceStatus         `json:"devices"`
+}
+
+func btcCoinStatus(coin *btc.Coin, status *CoinStatus) {
+	chain := coin.Blockchain()
+	if chain == nil {
+		status.ConnectionError = "not initialized"
+		return
+	}
+	if err := chain.ConnectionError(); err != nil {
+		status.ConnectionError = err.Error()
+	}
+	if provider, ok := chain.(blockchain.ConnectionInfoProvider); ok {
+		info := provider.ConnectionInfo()
+		status.Server = info.Server
+		status.LatencyMs = info.Latency.Milliseconds()
+	}
+	if coin.Headers() != nil {
+		status.TipHeight = int64(coin.TipHeight())
+		if headersStatus, err := coin.Headers().Status(); err == nil {
+			status.Headers = headersStatus
+		}
+	}
+}
+
+func ethCoinStatus(coin *eth.Coin, status *CoinStatus) {
+	ctx, cancel := context.WithTimeout(context.Background(), statusRequestTimeout)
+	defer cancel()
+	started := time.Now()
+	tipHeight, err := coin.TipHeightContext(ctx)
+	if err != nil {
+		status.ConnectionError = err.Error()
+		return
+	}
+	status.LatencyMs = time.Since(started).Milliseconds()
+	status.TipHeight = tipHeight.Int64()
+}
+
+// Status returns the status of the coins of the loaded accounts, the rates and the devices. ERC20
+// tokens are not listed, as they are connected through their Ethereum coin.
+func (backend *Backend) Status() *Status {
+	coins := map[coinpkg.Code]coinpkg.Coin{}
+	for _, account := range backend.Accounts() {
+		coin := account.Coin()
+		if ethCoin, ok := coin.(*eth.Coin); ok && ethCoin.ERC20Token() != nil {
+			continue
+		}
+		coins[coin.Code()] = coin
+	}
+
+	coinStatuses := make([]CoinStatus, 0, len(coins))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, coin := range coins {
+		wg.Add(1)
+		go func(coin coinpkg.Coin) {
+			defer wg.Done()
+			status := CoinStatus{Code: coin.Code(), Name: coin.Name()}
+			switch specificCoin := coin.(type) {
+			case *btc.Coin:
+				btcCoinStatus(specificCoin, &status)
+			case *eth.Coin:
+				ethCoinStatus(specificCoin, &status)
+			}
+			status.Connected = status.ConnectionError == ""
+			mu.Lock()
+			defer mu.Unlock()
+			coinStatuses = append(coinStatuses, status)
+		}(coin)
+	}
+	wg.Wait()
+	sort.Slice(coinStatuses, func(i, j int) bool { return coinStatuses[i].Code < coinStatuses[j].Code })
+
+	devices := []DeviceStatus{}
+	for deviceID, device := range backend.DevicesRegistered() {
+		deviceStatus := DeviceStatus{ID: deviceID, ProductName: device.ProductName()}
+		if bitbox02Device, ok := device.(*bitbox02.Device); ok {
+			deviceStatus.Status = string(bitbox02Device.Status())
+		}
+		devices = append(devices, deviceStatus)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+
+	return &Status{
+		Coins:   coinStatuses,
+		Rates:   backend.ratesUpdater.LatestPriceInfo(),
+		Devices: devices,
+	}
+}
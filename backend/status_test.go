@@ -0,0 +1,55 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	status := b.Status()
+	require.Empty(t, status.Coins)
+	require.Nil(t, status.Rates)
+	require.Empty(t, status.Devices)
+
+	ks := makeBitBox02Multi()
+	ks.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	b.registerKeystore(ks)
+
+	status = b.Status()
+	codes := []coinpkg.Code{}
+	for _, coinStatus := range status.Coins {
+		codes = append(codes, coinStatus.Code)
+	}
+	// Sorted by code, without the ERC20 tokens.
+	require.Equal(t, []coinpkg.Code{coinpkg.CodeBTC, coinpkg.CodeETH, coinpkg.CodeLTC}, codes)
+
+	// The mocked accounts do not initialize the coin, so it is not connected.
+	require.False(t, status.Coins[0].Connected)
+	require.Nil(t, status.Coins[0].Headers)
+
+	eth := status.Coins[1]
+	require.True(t, eth.Connected)
+	require.Empty(t, eth.Server)
+	require.Equal(t, int64(100), eth.TipHeight)
+}
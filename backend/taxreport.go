@@ -0,0 +1,157 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/taxreport"
+	utilConfig "github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// taxReportEvents returns the acquisitions and disposals of the transactions of an account, valued
+// using the historical rates in the given fiat currency.
+//
+// Received coins are acquisitions, sent coins and the fees are disposals. Spam transactions and
+// internal transfers between the loaded accounts are skipped, except for their fees. Fees paid in a
+// different unit, e.g. the ETH fees of ERC20 transactions, are not included.
+func (backend *Backend) taxReportEvents(
+	account accounts.Interface, transactions accounts.OrderedTransactions, fiat string) []taxreport.Event {
+	accountCoin := account.Coin()
+	decimalsExp := coin.DecimalsExp(accountCoin)
+	events := []taxreport.Event{}
+	addEvent := func(transaction *accounts.TransactionData, amount coin.Amount, negative bool) {
+		value := new(big.Rat).SetFrac(amount.BigInt(), decimalsExp)
+		if value.Sign() == 0 {
+			return
+		}
+		if negative {
+			value.Neg(value)
+		}
+		at := transaction.CreatedTimestamp
+		if transaction.Timestamp != nil {
+			at = transaction.Timestamp
+		}
+		if at == nil {
+			return
+		}
+		var price *big.Rat
+		if rate := backend.ratesUpdater.HistoricalPriceAt(string(accountCoin.Code()), fiat, *at); rate != 0 {
+			price = new(big.Rat).SetFloat64(rate)
+		}
+		events = append(events, taxreport.Event{
+			Time:        *at,
+			Coin:        accountCoin.Unit(false),
+			Decimals:    accountCoin.Decimals(false),
+			AccountCode: string(account.Config().Config.Code),
+			TxID:        transaction.TxID,
+			Amount:      value,
+			Price:       price,
+		})
+	}
+	for _, transaction := range transactions {
+		spam := transaction.Spam
+		if userSpam, ok := account.TxSpam(transaction.InternalID); ok {
+			spam = userSpam
+		}
+		if spam {
+			continue
+		}
+		internalTransfer := account.TxInternalTransfer(transaction.InternalID) != ""
+		if transaction.Status != accounts.TxStatusFailed && !internalTransfer {
+			switch transaction.Type {
+			case accounts.TxTypeReceive:
+				addEvent(transaction, transaction.Amount, false)
+			case accounts.TxTypeSend:
+				addEvent(transaction, transaction.Amount, true)
+			}
+		}
+		if transaction.Type != accounts.TxTypeReceive && transaction.Fee != nil && !transaction.FeeIsDifferentUnit {
+			addEvent(transaction, *transaction.Fee, true)
+		}
+	}
+	return events
+}
+
+// TaxReport computes the realized gains of the coins disposed in the given year (0 for all years)
+// across all accounts, in the main fiat currency. The number of accounts which are skipped because
+// they are not synced is returned as well.
+func (backend *Backend) TaxReport(method taxreport.Method, year int) (*taxreport.Report, int, error) {
+	fiat := backend.config.AppConfig().Backend.MainFiat
+	events := []taxreport.Event{}
+	skipped := 0
+	for _, account := range backend.Accounts() {
+		if account.Config().Config.HiddenBecauseUnused {
+			continue
+		}
+		if account.FatalError() || !account.Synced() {
+			skipped++
+			continue
+		}
+		transactions, err := account.Transactions()
+		if err != nil {
+			backend.log.WithError(err).Error("Could not get the transactions for the tax report")
+			skipped++
+			continue
+		}
+		events = append(events, backend.taxReportEvents(account, transactions, fiat)...)
+	}
+	report, err := taxreport.Compute(events, method, fiat, year)
+	if err != nil {
+		return nil, 0, err
+	}
+	return report, skipped, nil
+}
+
+// ExportTaxReport writes the tax report in CSV format to a file chosen by the user and opens it.
+func (backend *Backend) ExportTaxReport(method taxreport.Method, year int) error {
+	report, _, err := backend.TaxReport(method, year)
+	if err != nil {
+		return err
+	}
+	period := "all"
+	if year != 0 {
+		period = fmt.Sprint(year)
+	}
+	name := fmt.Sprintf("%s-tax-report-%s-%s.csv", time.Now().Format("2006-01-02-at-15-04-05"), period, method)
+	exportsDir, err := utilConfig.ExportsDir()
+	if err != nil {
+		return err
+	}
+	path := backend.Environment().GetSaveFilename(filepath.Join(exportsDir, name))
+	if path == "" {
+		return nil
+	}
+	backend.log.Infof("Export tax report to %s.", path)
+	file, err := os.Create(path)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if err := report.WriteCSV(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return errp.WithStack(err)
+	}
+	return backend.Environment().SystemOpen(path)
+}
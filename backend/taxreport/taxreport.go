@@ -0,0 +1,310 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package taxreport computes the cost basis and the realized gains of disposed coins for tax
+// reports.
+package taxreport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// Method selects which of the acquired coins are disposed first.
+type Method string
+
+const (
+	// MethodFIFO disposes the coins acquired first first (first in, first out).
+	MethodFIFO Method = "fifo"
+	// MethodLIFO disposes the coins acquired last first (last in, first out).
+	MethodLIFO Method = "lifo"
+)
+
+// Valid returns true if the method is one of the supported methods.
+func (method Method) Valid() bool {
+	return method == MethodFIFO || method == MethodLIFO
+}
+
+// Event is an acquisition or a disposal of coins, e.g. a received or a sent transaction.
+type Event struct {
+	Time time.Time
+	// Coin is the unit of the coin, e.g. "BTC". The coins of the same unit are pooled across
+	// accounts.
+	Coin string
+	// Decimals is the number of decimals of the coin unit, used to format the amounts.
+	Decimals uint
+	// AccountCode and TxID identify the transaction.
+	AccountCode string
+	TxID        string
+	// Amount is positive for acquired coins and negative for disposed coins, in the coin unit.
+	Amount *big.Rat
+	// Price is the fiat price of one coin at Time, or nil if it is not known.
+	Price *big.Rat
+}
+
+// Disposal are disposed coins, matched with the acquisition they were acquired in.
+type Disposal struct {
+	Coin        string
+	Decimals    uint
+	AccountCode string
+	TxID        string
+	DisposedAt  time.Time
+	// AcquiredAt is nil if the coins could not be matched with an acquisition, e.g. if they were
+	// acquired in transactions which are not known to the app.
+	AcquiredAt *time.Time
+	Amount     *big.Rat
+	Proceeds   *big.Rat
+	CostBasis  *big.Rat
+	Gain       *big.Rat
+	// Incomplete is true if the proceeds or the cost basis could not be determined, because the
+	// coins could not be matched or a historical price is missing. The unknown values count as
+	// zero.
+	Incomplete bool
+}
+
+// Report are the disposals of a year with their realized gains.
+type Report struct {
+	Method Method
+	Fiat   string
+	// Year is the year of the disposals in the report. 0 means all years.
+	Year      int
+	Disposals []*Disposal
+	Proceeds  *big.Rat
+	CostBasis *big.Rat
+	Gain      *big.Rat
+}
+
+// lot are coins acquired at once, not disposed yet.
+type lot struct {
+	amount     *big.Rat
+	price      *big.Rat
+	acquiredAt time.Time
+}
+
+// mulOrZero returns amount*price, or zero and false if the price is not known.
+func mulOrZero(amount *big.Rat, price *big.Rat) (*big.Rat, bool) {
+	if price == nil {
+		return new(big.Rat), false
+	}
+	return new(big.Rat).Mul(amount, price), true
+}
+
+// Compute matches the disposals with the acquisitions using the method and computes their gains,
+// which are reported for disposals in the given year, or all years if year is 0. The events
+// must contain all acquisitions before the year, as they determine the cost basis.
+func Compute(events []Event, method Method, fiat string, year int) (*Report, error) {
+	if !method.Valid() {
+		return nil, errp.Newf("unknown tax report method %q", method)
+	}
+	events = append([]Event(nil), events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].Time.Equal(events[j].Time) {
+			return events[i].Time.Before(events[j].Time)
+		}
+		// Acquisitions first.
+		return events[i].Amount.Sign() > events[j].Amount.Sign()
+	})
+
+	report := &Report{
+		Method:    method,
+		Fiat:      fiat,
+		Year:      year,
+		Disposals: []*Disposal{},
+		Proceeds:  new(big.Rat),
+		CostBasis: new(big.Rat),
+		Gain:      new(big.Rat),
+	}
+	addDisposal := func(disposal *Disposal) {
+		if year != 0 && disposal.DisposedAt.Year() != year {
+			return
+		}
+		disposal.Gain = new(big.Rat).Sub(disposal.Proceeds, disposal.CostBasis)
+		report.Disposals = append(report.Disposals, disposal)
+		report.Proceeds.Add(report.Proceeds, disposal.Proceeds)
+		report.CostBasis.Add(report.CostBasis, disposal.CostBasis)
+		report.Gain.Add(report.Gain, disposal.Gain)
+	}
+
+	lots := map[string][]*lot{}
+	for _, event := range events {
+		if event.Amount.Sign() > 0 {
+			lots[event.Coin] = append(lots[event.Coin], &lot{
+				amount:     new(big.Rat).Set(event.Amount),
+				price:      event.Price,
+				acquiredAt: event.Time,
+			})
+			continue
+		}
+		remaining := new(big.Rat).Neg(event.Amount)
+		for remaining.Sign() > 0 {
+			coinLots := lots[event.Coin]
+			disposal := &Disposal{
+				Coin:        event.Coin,
+				Decimals:    event.Decimals,
+				AccountCode: event.AccountCode,
+				TxID:        event.TxID,
+				DisposedAt:  event.Time,
+			}
+			if len(coinLots) == 0 {
+				disposal.Amount = remaining
+				disposal.CostBasis = new(big.Rat)
+				disposal.Incomplete = true
+				remaining = new(big.Rat)
+			} else {
+				index := 0
+				if method == MethodLIFO {
+					index = len(coinLots) - 1
+				}
+				matched := coinLots[index]
+				amount := matched.amount
+				if remaining.Cmp(amount) < 0 {
+					amount = remaining
+				}
+				amount = new(big.Rat).Set(amount)
+				acquiredAt := matched.acquiredAt
+				disposal.AcquiredAt = &acquiredAt
+				disposal.Amount = amount
+				var ok bool
+				disposal.CostBasis, ok = mulOrZero(amount, matched.price)
+				disposal.Incomplete = !ok
+				matched.amount = new(big.Rat).Sub(matched.amount, amount)
+				if matched.amount.Sign() == 0 {
+					lots[event.Coin] = append(coinLots[:index:index], coinLots[index+1:]...)
+				}
+				remaining = new(big.Rat).Sub(remaining, amount)
+			}
+			var ok bool
+			disposal.Proceeds, ok = mulOrZero(disposal.Amount, event.Price)
+			disposal.Incomplete = disposal.Incomplete || !ok
+			addDisposal(disposal)
+		}
+	}
+	return report, nil
+}
+
+func formatFiat(amount *big.Rat, fiat string) string {
+	return coin.FormatAsPlainCurrency(amount, fiat)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// MarshalJSON implements json.Marshaler. The amounts are decimal strings.
+func (report *Report) MarshalJSON() ([]byte, error) {
+	type jsonDisposal struct {
+		Coin        string  `json:"coin"`
+		AccountCode string  `json:"accountCode"`
+		TxID        string  `json:"txID"`
+		DisposedAt  string  `json:"disposedAt"`
+		AcquiredAt  *string `json:"acquiredAt"`
+		Amount      string  `json:"amount"`
+		Proceeds    string  `json:"proceeds"`
+		CostBasis   string  `json:"costBasis"`
+		Gain        string  `json:"gain"`
+		Incomplete  bool    `json:"incomplete"`
+	}
+	disposals := make([]jsonDisposal, len(report.Disposals))
+	for i, disposal := range report.Disposals {
+		var acquiredAt *string
+		if disposal.AcquiredAt != nil {
+			formatted := formatTime(disposal.AcquiredAt)
+			acquiredAt = &formatted
+		}
+		disposals[i] = jsonDisposal{
+			Coin:        disposal.Coin,
+			AccountCode: disposal.AccountCode,
+			TxID:        disposal.TxID,
+			DisposedAt:  formatTime(&disposal.DisposedAt),
+			AcquiredAt:  acquiredAt,
+			Amount:      disposal.Amount.FloatString(int(disposal.Decimals)),
+			Proceeds:    formatFiat(disposal.Proceeds, report.Fiat),
+			CostBasis:   formatFiat(disposal.CostBasis, report.Fiat),
+			Gain:        formatFiat(disposal.Gain, report.Fiat),
+			Incomplete:  disposal.Incomplete,
+		}
+	}
+	return json.Marshal(struct {
+		Method    Method         `json:"method"`
+		Fiat      string         `json:"fiat"`
+		Year      int            `json:"year"`
+		Disposals []jsonDisposal `json:"disposals"`
+		Proceeds  string         `json:"proceeds"`
+		CostBasis string         `json:"costBasis"`
+		Gain      string         `json:"gain"`
+	}{
+		Method:    report.Method,
+		Fiat:      report.Fiat,
+		Year:      report.Year,
+		Disposals: disposals,
+		Proceeds:  formatFiat(report.Proceeds, report.Fiat),
+		CostBasis: formatFiat(report.CostBasis, report.Fiat),
+		Gain:      formatFiat(report.Gain, report.Fiat),
+	})
+}
+
+// WriteCSV writes the report in CSV format (comma-separated), with one row per disposal.
+func (report *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{
+		"Disposed at",
+		"Acquired at",
+		"Coin",
+		"Amount",
+		"Proceeds",
+		"Cost basis",
+		"Gain",
+		"Fiat currency",
+		"Account",
+		"Transaction ID",
+		"Incomplete",
+	})
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	for _, disposal := range report.Disposals {
+		incomplete := ""
+		if disposal.Incomplete {
+			incomplete = "yes"
+		}
+		err := writer.Write([]string{
+			formatTime(&disposal.DisposedAt),
+			formatTime(disposal.AcquiredAt),
+			disposal.Coin,
+			disposal.Amount.FloatString(int(disposal.Decimals)),
+			formatFiat(disposal.Proceeds, report.Fiat),
+			formatFiat(disposal.CostBasis, report.Fiat),
+			formatFiat(disposal.Gain, report.Fiat),
+			report.Fiat,
+			disposal.AccountCode,
+			disposal.TxID,
+			incomplete,
+		})
+		if err != nil {
+			return errp.WithStack(err)
+		}
+	}
+	writer.Flush()
+	return errp.WithStack(writer.Error())
+}
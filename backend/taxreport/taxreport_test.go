@@ -0,0 +1,173 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taxreport
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func day(month time.Month, d int) time.Time {
+	return time.Date(2023, month, d, 12, 0, 0, 0, time.UTC)
+}
+
+func event(t time.Time, txID string, amount int64, price *big.Rat) Event {
+	return Event{
+		Time:        t,
+		Coin:        "BTC",
+		Decimals:    8,
+		AccountCode: "v0-55555555-btc-0",
+		TxID:        txID,
+		Amount:      big.NewRat(amount, 1),
+		Price:       price,
+	}
+}
+
+func testEvents() []Event {
+	return []Event{
+		// Out of order on purpose, the events are sorted by time.
+		event(day(3, 1), "sell", -3, big.NewRat(300, 1)),
+		event(day(1, 1), "buy1", 2, big.NewRat(100, 1)),
+		event(day(2, 1), "buy2", 2, big.NewRat(200, 1)),
+	}
+}
+
+func TestComputeFIFO(t *testing.T) {
+	report, err := Compute(testEvents(), MethodFIFO, "USD", 0)
+	require.NoError(t, err)
+	require.Len(t, report.Disposals, 2)
+
+	require.Equal(t, day(1, 1), *report.Disposals[0].AcquiredAt)
+	require.Equal(t, big.NewRat(2, 1), report.Disposals[0].Amount)
+	require.Equal(t, big.NewRat(600, 1), report.Disposals[0].Proceeds)
+	require.Equal(t, big.NewRat(200, 1), report.Disposals[0].CostBasis)
+	require.Equal(t, big.NewRat(400, 1), report.Disposals[0].Gain)
+
+	require.Equal(t, day(2, 1), *report.Disposals[1].AcquiredAt)
+	require.Equal(t, big.NewRat(1, 1), report.Disposals[1].Amount)
+	require.Equal(t, big.NewRat(100, 1), report.Disposals[1].Gain)
+
+	require.Equal(t, big.NewRat(900, 1), report.Proceeds)
+	require.Equal(t, big.NewRat(400, 1), report.CostBasis)
+	require.Equal(t, big.NewRat(500, 1), report.Gain)
+	for _, disposal := range report.Disposals {
+		require.Equal(t, "sell", disposal.TxID)
+		require.False(t, disposal.Incomplete)
+	}
+}
+
+func TestComputeLIFO(t *testing.T) {
+	report, err := Compute(testEvents(), MethodLIFO, "USD", 0)
+	require.NoError(t, err)
+	require.Len(t, report.Disposals, 2)
+	require.Equal(t, day(2, 1), *report.Disposals[0].AcquiredAt)
+	require.Equal(t, big.NewRat(2, 1), report.Disposals[0].Amount)
+	require.Equal(t, day(1, 1), *report.Disposals[1].AcquiredAt)
+	require.Equal(t, big.NewRat(1, 1), report.Disposals[1].Amount)
+	require.Equal(t, big.NewRat(500, 1), report.CostBasis)
+	require.Equal(t, big.NewRat(400, 1), report.Gain)
+}
+
+func TestComputeIncomplete(t *testing.T) {
+	events := []Event{
+		event(day(1, 1), "buy", 1, nil),
+		event(day(2, 1), "sell", -2, big.NewRat(100, 1)),
+	}
+	report, err := Compute(events, MethodFIFO, "USD", 0)
+	require.NoError(t, err)
+	require.Len(t, report.Disposals, 2)
+
+	// The price at the acquisition is not known.
+	require.NotNil(t, report.Disposals[0].AcquiredAt)
+	require.Equal(t, new(big.Rat), report.Disposals[0].CostBasis)
+	require.True(t, report.Disposals[0].Incomplete)
+
+	// The coins were acquired in an unknown transaction.
+	require.Nil(t, report.Disposals[1].AcquiredAt)
+	require.Equal(t, big.NewRat(1, 1), report.Disposals[1].Amount)
+	require.Equal(t, big.NewRat(100, 1), report.Disposals[1].Proceeds)
+	require.True(t, report.Disposals[1].Incomplete)
+}
+
+func TestComputeYear(t *testing.T) {
+	events := append(testEvents(), event(day(3, 1).AddDate(1, 0, 0), "sell2", -1, big.NewRat(400, 1)))
+	report, err := Compute(events, MethodFIFO, "USD", 2024)
+	require.NoError(t, err)
+	require.Len(t, report.Disposals, 1)
+	require.Equal(t, "sell2", report.Disposals[0].TxID)
+	// The lot remaining from 2023.
+	require.Equal(t, day(2, 1), *report.Disposals[0].AcquiredAt)
+	require.Equal(t, big.NewRat(200, 1), report.Gain)
+
+	report, err = Compute(events, MethodFIFO, "USD", 2022)
+	require.NoError(t, err)
+	require.Empty(t, report.Disposals)
+	require.Equal(t, new(big.Rat), report.Gain)
+}
+
+func TestComputeInvalidMethod(t *testing.T) {
+	_, err := Compute(testEvents(), Method("average"), "USD", 0)
+	require.Error(t, err)
+}
+
+func TestReportFormats(t *testing.T) {
+	events := []Event{
+		event(day(1, 1), "buy", 1, big.NewRat(1, 3)),
+		event(day(2, 1), "sell", -1, big.NewRat(1, 1)),
+	}
+	report, err := Compute(events, MethodFIFO, "USD", 2023)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteCSV(&buf))
+	require.Equal(t,
+		strings.Join([]string{
+			"Disposed at,Acquired at,Coin,Amount,Proceeds,Cost basis,Gain,Fiat currency,Account,Transaction ID,Incomplete",
+			"2023-02-01T12:00:00Z,2023-01-01T12:00:00Z,BTC,1.00000000,1.00,0.33,0.67,USD,v0-55555555-btc-0,sell,",
+			"",
+		}, "\n"),
+		buf.String())
+
+	jsonBytes, err := json.Marshal(report)
+	require.NoError(t, err)
+	require.JSONEq(t,
+		`{
+  "method": "fifo",
+  "fiat": "USD",
+  "year": 2023,
+  "disposals": [{
+    "coin": "BTC",
+    "accountCode": "v0-55555555-btc-0",
+    "txID": "sell",
+    "disposedAt": "2023-02-01T12:00:00Z",
+    "acquiredAt": "2023-01-01T12:00:00Z",
+    "amount": "1.00000000",
+    "proceeds": "1.00",
+    "costBasis": "0.33",
+    "gain": "0.67",
+    "incomplete": false
+  }],
+  "proceeds": "1.00",
+  "costBasis": "0.33",
+  "gain": "0.67"
+}`,
+		string(jsonBytes))
+}
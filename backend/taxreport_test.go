@@ -0,0 +1,109 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsMocks "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/mocks"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaxReportEvents(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+	b.ratesUpdater = rates.MockRateUpdater()
+	btcCoin, err := b.Coin(coinpkg.CodeBTC)
+	require.NoError(t, err)
+
+	account := &accountsMocks.InterfaceMock{
+		CoinFunc: func() coinpkg.Coin { return btcCoin },
+		ConfigFunc: func() *accounts.AccountConfig {
+			return &accounts.AccountConfig{Config: &config.Account{Code: "v0-55555555-btc-0"}}
+		},
+		TxSpamFunc: func(txID string) (bool, bool) {
+			return txID == "marked-spam", txID == "marked-spam"
+		},
+		TxInternalTransferFunc: func(txID string) accountsTypes.Code {
+			if txID == "transfer" {
+				return "v0-55555555-btc-1"
+			}
+			return ""
+		},
+	}
+
+	bought := time.Unix(1598832062, 0)
+	sold := time.Unix(1598918700, 0)
+	unknown := time.Unix(1000, 0)
+	fee := coinpkg.NewAmountFromInt64(1000)
+	tx := func(txID string, txType accounts.TxType, amount int64, at *time.Time) *accounts.TransactionData {
+		return &accounts.TransactionData{
+			TxID:       txID,
+			InternalID: txID,
+			Type:       txType,
+			Status:     accounts.TxStatusComplete,
+			Amount:     coinpkg.NewAmountFromInt64(amount),
+			Fee:        &fee,
+			Timestamp:  at,
+		}
+	}
+	failed := tx("failed", accounts.TxTypeSend, 1e8, &sold)
+	failed.Status = accounts.TxStatusFailed
+	spam := tx("spam", accounts.TxTypeReceive, 1, &sold)
+	spam.Spam = true
+	pending := tx("pending", accounts.TxTypeReceive, 2e8, nil)
+	pending.CreatedTimestamp = &unknown
+	transactions := accounts.OrderedTransactions{
+		tx("sell", accounts.TxTypeSend, 5e7, &sold),
+		tx("self", accounts.TxTypeSendSelf, 5e7, &sold),
+		tx("transfer", accounts.TxTypeSend, 5e7, &sold),
+		failed,
+		spam,
+		tx("marked-spam", accounts.TxTypeReceive, 1, &sold),
+		pending,
+		tx("buy", accounts.TxTypeReceive, 1e8, &bought),
+		tx("no-time", accounts.TxTypeReceive, 1e8, nil),
+	}
+
+	type event struct {
+		txID   string
+		amount *big.Rat
+		price  *big.Rat
+	}
+	result := []event{}
+	for _, e := range b.taxReportEvents(account, transactions, "USD") {
+		require.Equal(t, "BTC", e.Coin)
+		require.Equal(t, uint(8), e.Decimals)
+		require.Equal(t, "v0-55555555-btc-0", e.AccountCode)
+		result = append(result, event{txID: e.TxID, amount: e.Amount, price: e.Price})
+	}
+	feeAmount := big.NewRat(-1000, 1e8)
+	require.Equal(t, []event{
+		{"sell", big.NewRat(-1, 2), big.NewRat(2, 1)},
+		{"sell", feeAmount, big.NewRat(2, 1)},
+		{"self", feeAmount, big.NewRat(2, 1)},
+		{"transfer", feeAmount, big.NewRat(2, 1)},
+		{"failed", feeAmount, big.NewRat(2, 1)},
+		{"pending", big.NewRat(2, 1), nil},
+		{"buy", big.NewRat(1, 1), big.NewRat(1, 1)},
+	}, result)
+}
@@ -20,6 +20,8 @@ import (
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
 	"github.com/BitBoxSwiss/bitbox02-api-go/util/semver"
 )
 
@@ -74,12 +76,28 @@ func (backend *Backend) checkForUpdate() (*UpdateFile, error) {
 	return &updateFile, nil
 }
 
-// CheckForUpdateIgnoringErrors suppresses any errors that are triggered, for example, when offline.
-func (backend *Backend) CheckForUpdateIgnoringErrors() *UpdateFile {
+// checkForUpdateAtStartup checks for an update and stores the result, see
+// CheckForUpdateIgnoringErrors(). The frontend is notified to load the result.
+func (backend *Backend) checkForUpdateAtStartup() error {
 	updateFile, err := backend.checkForUpdate()
 	if err != nil {
 		logging.Get().WithGroup("update").WithError(err).Warn("Check for update failed.")
-		return nil
+		return err
 	}
-	return updateFile
+	unlock := backend.updateFileLock.Lock()
+	backend.updateFile = updateFile
+	unlock()
+	backend.Notify(observable.Event{
+		Subject: "update",
+		Action:  action.Reload,
+	})
+	return nil
+}
+
+// CheckForUpdateIgnoringErrors returns the result of the update check run at startup. It does not
+// wait for the check, so it returns nil until the check is done, and if it failed, for example,
+// when offline.
+func (backend *Backend) CheckForUpdateIgnoringErrors() *UpdateFile {
+	defer backend.updateFileLock.RLock()()
+	return backend.updateFile
 }
@@ -0,0 +1,43 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/message"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyBTCMessage(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	// BIP322 test vector.
+	const address = "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l"
+	const signature = "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="
+	format, err := b.VerifyBTCMessage(coinpkg.CodeBTC, address, "Hello World", signature)
+	require.NoError(t, err)
+	require.Equal(t, message.FormatBIP322, format)
+
+	_, err = b.VerifyBTCMessage(coinpkg.CodeBTC, address, "Hello", signature)
+	require.Equal(t, message.ErrInvalidSignature, errp.Cause(err))
+	_, err = b.VerifyBTCMessage(coinpkg.CodeLTC, address, "Hello World", signature)
+	require.Equal(t, message.ErrInvalidAddress, errp.Cause(err))
+	_, err = b.VerifyBTCMessage(coinpkg.CodeETH, address, "Hello World", signature)
+	require.Error(t, err)
+}
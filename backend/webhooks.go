@@ -0,0 +1,292 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/companion"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/webhooks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// WebhookInfo describes a configured webhook. The secret is not returned.
+type WebhookInfo struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	HasSecret bool     `json:"hasSecret"`
+	Events    []string `json:"events"`
+}
+
+// WebhookArgs are the settings of a webhook to add or update.
+type WebhookArgs struct {
+	URL string `json:"url"`
+	// Secret is used to sign requests. When updating a webhook, an empty secret keeps the current
+	// one.
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// webhookTxData is the data of incomingTx and txConfirmed events.
+type webhookTxData struct {
+	AccountName      string          `json:"accountName"`
+	TxID             string          `json:"txID"`
+	Type             accounts.TxType `json:"type"`
+	Amount           string          `json:"amount"`
+	Unit             string          `json:"unit"`
+	NumConfirmations int             `json:"numConfirmations"`
+}
+
+// webhookBalanceData is the data of lowBalance events.
+type webhookBalanceData struct {
+	AccountName string `json:"accountName"`
+	Balance     string `json:"balance"`
+	Threshold   string `json:"threshold"`
+	Unit        string `json:"unit"`
+}
+
+// accountEventsTracker remembers the state of an account between syncs, to detect the changes
+// which trigger webhooks and alerts.
+type accountEventsTracker struct {
+	initialized bool
+	// complete maps the internal IDs of the known transactions to whether they were complete.
+	complete   map[string]bool
+	lowBalance bool
+}
+
+// accountEvents are the changes detected by accountEventsTracker.update().
+type accountEvents struct {
+	incoming  []*accounts.TransactionData
+	confirmed []*accounts.TransactionData
+	// largeOutflows are the new outgoing transactions above the large outflow alert.
+	largeOutflows []*accounts.TransactionData
+	// outgoing are the new outgoing transactions, regardless of the large outflow alert.
+	outgoing   []*accounts.TransactionData
+	lowBalance bool
+}
+
+// update records the current transactions and balance, returning what changed since the last
+// update. The first update only records the transactions, so that the existing history does not
+// trigger events.
+func (tracker *accountEventsTracker) update(
+	transactions []*accounts.TransactionData,
+	balance *big.Int,
+	thresholds alertThresholds,
+) accountEvents {
+	events := accountEvents{}
+	complete := map[string]bool{}
+	for _, tx := range transactions {
+		isComplete := tx.Status == accounts.TxStatusComplete
+		complete[tx.InternalID] = isComplete
+		if !tracker.initialized {
+			continue
+		}
+		wasComplete, known := tracker.complete[tx.InternalID]
+		if !known && tx.Type == accounts.TxTypeReceive {
+			events.incoming = append(events.incoming, tx)
+		}
+		if !known && tx.Type == accounts.TxTypeSend {
+			events.outgoing = append(events.outgoing, tx)
+		}
+		if !known && tx.Type == accounts.TxTypeSend && thresholds.largeOutflow != nil &&
+			tx.Amount.BigInt().Cmp(thresholds.largeOutflow) > 0 {
+			events.largeOutflows = append(events.largeOutflows, tx)
+		}
+		if isComplete && !wasComplete {
+			events.confirmed = append(events.confirmed, tx)
+		}
+	}
+	tracker.complete = complete
+	tracker.initialized = true
+
+	isLow := thresholds.lowBalance != nil && balance.Cmp(thresholds.lowBalance) < 0
+	events.lowBalance = isLow && !tracker.lowBalance
+	tracker.lowBalance = isLow
+	return events
+}
+
+// checkAccountEvents detects new and confirmed transactions, large outflows and a low balance of an
+// account, sends them to the webhooks and paired companion apps, raises the alerts and evaluates
+// the automation rules. It is called whenever an account finished syncing. Transactions which
+// arrived while the app was closed are not reported.
+func (backend *Backend) checkAccountEvents(account accounts.Interface) {
+	if account.FatalError() {
+		return
+	}
+	transactions, err := account.Transactions()
+	if err != nil {
+		backend.log.WithError(err).Error("Could not get the transactions for account events")
+		return
+	}
+	balance, err := account.Balance()
+	if err != nil {
+		backend.log.WithError(err).Error("Could not get the balance for account events")
+		return
+	}
+	accountConfig := account.Config().Config
+	thresholds := backend.alertThresholds(accountConfig.Code)
+
+	unlock := backend.accountEventsTrackersLock.Lock()
+	tracker, ok := backend.accountEventsTrackers[accountConfig.Code]
+	if !ok {
+		tracker = &accountEventsTracker{}
+		backend.accountEventsTrackers[accountConfig.Code] = tracker
+	}
+	events := tracker.update(transactions, balance.Available().BigInt(), thresholds)
+	unlock()
+
+	coin := account.Coin()
+	unit := coin.GetFormatUnit(false)
+	txData := func(tx *accounts.TransactionData) webhookTxData {
+		return webhookTxData{
+			AccountName:      accountConfig.Name,
+			TxID:             tx.TxID,
+			Type:             tx.Type,
+			Amount:           coin.FormatAmount(tx.Amount, false),
+			Unit:             unit,
+			NumConfirmations: tx.NumConfirmations,
+		}
+	}
+	for _, tx := range events.incoming {
+		backend.webhooks.Dispatch(webhooks.NewEvent(webhooks.EventIncomingTx, accountConfig.Code, txData(tx)))
+		backend.companionHost.AddNotification(companion.Notification{
+			AccountName: accountConfig.Name,
+			Amount:      coin.FormatAmount(tx.Amount, false),
+			Unit:        unit,
+			TxID:        tx.TxID,
+		})
+	}
+	for _, tx := range events.confirmed {
+		backend.webhooks.Dispatch(webhooks.NewEvent(webhooks.EventTxConfirmed, accountConfig.Code, txData(tx)))
+	}
+	for _, tx := range events.largeOutflows {
+		backend.webhooks.Dispatch(webhooks.NewEvent(webhooks.EventLargeOutflow, accountConfig.Code, txData(tx)))
+		backend.raiseAccountAlert(AccountAlert{
+			Type:        AccountAlertLargeOutflow,
+			AccountCode: accountConfig.Code,
+			AccountName: accountConfig.Name,
+			Amount:      coin.FormatAmount(tx.Amount, false),
+			Threshold:   coin.FormatAmount(coinpkg.NewAmount(thresholds.largeOutflow), false),
+			Unit:        unit,
+			TxID:        tx.TxID,
+		})
+	}
+	if events.lowBalance {
+		balanceData := webhookBalanceData{
+			AccountName: accountConfig.Name,
+			Balance:     coin.FormatAmount(balance.Available(), false),
+			Threshold:   coin.FormatAmount(coinpkg.NewAmount(thresholds.lowBalance), false),
+			Unit:        unit,
+		}
+		backend.webhooks.Dispatch(webhooks.NewEvent(webhooks.EventLowBalance, accountConfig.Code, balanceData))
+		backend.raiseAccountAlert(AccountAlert{
+			Type:        AccountAlertLowBalance,
+			AccountCode: accountConfig.Code,
+			AccountName: accountConfig.Name,
+			Amount:      balanceData.Balance,
+			Threshold:   balanceData.Threshold,
+			Unit:        unit,
+		})
+	}
+	backend.evaluateRules(account, balance.Available().BigInt(), events)
+}
+
+func (backend *Backend) webhookConfigs() []config.Webhook {
+	return backend.config.AppConfig().Backend.Webhooks
+}
+
+// Webhooks returns the configured webhooks.
+func (backend *Backend) Webhooks() []WebhookInfo {
+	result := []WebhookInfo{}
+	for _, webhook := range backend.config.AppConfig().Backend.Webhooks {
+		result = append(result, WebhookInfo{
+			ID:        webhook.ID,
+			URL:       webhook.URL,
+			HasSecret: webhook.Secret != "",
+			Events:    webhook.Events,
+		})
+	}
+	return result
+}
+
+// AddWebhook adds a webhook and returns its ID.
+func (backend *Backend) AddWebhook(args WebhookArgs) (string, error) {
+	webhook := config.Webhook{
+		ID:     webhooks.NewID(),
+		URL:    strings.TrimSpace(args.URL),
+		Secret: args.Secret,
+		Events: args.Events,
+	}
+	if err := webhooks.Validate(webhook); err != nil {
+		return "", err
+	}
+	err := backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		appConfig.Backend.Webhooks = append(appConfig.Backend.Webhooks, webhook)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return webhook.ID, nil
+}
+
+// UpdateWebhook changes the settings of a webhook.
+func (backend *Backend) UpdateWebhook(id string, args WebhookArgs) error {
+	return backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		for i, webhook := range appConfig.Backend.Webhooks {
+			if webhook.ID != id {
+				continue
+			}
+			webhook.URL = strings.TrimSpace(args.URL)
+			webhook.Events = args.Events
+			if args.Secret != "" {
+				webhook.Secret = args.Secret
+			}
+			if err := webhooks.Validate(webhook); err != nil {
+				return err
+			}
+			appConfig.Backend.Webhooks[i] = webhook
+			return nil
+		}
+		return errp.Newf("unknown webhook: %s", id)
+	})
+}
+
+// DeleteWebhook removes a webhook.
+func (backend *Backend) DeleteWebhook(id string) error {
+	return backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		for i, webhook := range appConfig.Backend.Webhooks {
+			if webhook.ID == id {
+				appConfig.Backend.Webhooks = append(appConfig.Backend.Webhooks[:i], appConfig.Backend.Webhooks[i+1:]...)
+				return nil
+			}
+		}
+		return errp.Newf("unknown webhook: %s", id)
+	})
+}
+
+// TestWebhook sends a test event to a webhook and returns an error if it did not succeed.
+func (backend *Backend) TestWebhook(id string) error {
+	for _, webhook := range backend.config.AppConfig().Backend.Webhooks {
+		if webhook.ID == id {
+			return backend.webhooks.Send(webhook, webhooks.NewEvent(webhooks.EventTest, "", nil))
+		}
+	}
+	return errp.Newf("unknown webhook: %s", id)
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhooks sends account events to user configured URLs, so the backend can be integrated
+// with other automation. Each event is POSTed as JSON. If the webhook has a secret, the body is
+// signed with HMAC-SHA256 and the signature is sent in the X-BitBox-Signature header as
+// `sha256=<hex>`, so the receiver can verify that the request came from the app.
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// EventHeader contains the event type of a request.
+	EventHeader = "X-BitBox-Event"
+	// SignatureHeader contains the signature of the request body, if the webhook has a secret.
+	SignatureHeader = "X-BitBox-Signature"
+
+	requestTimeout = 10 * time.Second
+)
+
+// EventType is the type of an event sent to webhooks.
+type EventType string
+
+const (
+	// EventIncomingTx is sent when a new incoming transaction is seen, confirmed or not.
+	EventIncomingTx EventType = "incomingTx"
+	// EventTxConfirmed is sent when a transaction has all confirmations needed to be complete.
+	EventTxConfirmed EventType = "txConfirmed"
+	// EventLowBalance is sent when the balance of an account drops below its low balance alert.
+	EventLowBalance EventType = "lowBalance"
+	// EventLargeOutflow is sent for a new outgoing transaction above the large outflow alert of the
+	// account.
+	EventLargeOutflow EventType = "largeOutflow"
+	// EventRuleFired is only sent to the webhook of an automation rule when the rule fires.
+	EventRuleFired EventType = "ruleFired"
+	// EventTest is only sent when testing a webhook.
+	EventTest EventType = "test"
+)
+
+// EventTypes are the event types a webhook can subscribe to.
+var EventTypes = []EventType{EventIncomingTx, EventTxConfirmed, EventLowBalance, EventLargeOutflow}
+
+// Event is the body of a webhook request.
+type Event struct {
+	ID          string             `json:"id"`
+	Type        EventType          `json:"type"`
+	Timestamp   time.Time          `json:"timestamp"`
+	AccountCode accountsTypes.Code `json:"accountCode,omitempty"`
+	Data        interface{}        `json:"data"`
+}
+
+// NewEvent creates an event with a random ID.
+func NewEvent(eventType EventType, accountCode accountsTypes.Code, data interface{}) Event {
+	return Event{
+		ID:          NewID(),
+		Type:        eventType,
+		Timestamp:   time.Now(),
+		AccountCode: accountCode,
+		Data:        data,
+	}
+}
+
+// NewID returns a random ID for webhooks and events.
+func NewID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(id)
+}
+
+// Sign returns the signature of a request body, as sent in the SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Validate checks the URL and the event types of a webhook.
+func Validate(webhook config.Webhook) error {
+	u, err := url.Parse(webhook.URL)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errp.New("the webhook URL must be an http(s) URL")
+	}
+	if len(webhook.Events) == 0 {
+		return errp.New("select at least one event")
+	}
+	for _, event := range webhook.Events {
+		known := false
+		for _, eventType := range EventTypes {
+			if EventType(event) == eventType {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return errp.Newf("unknown event: %s", event)
+		}
+	}
+	return nil
+}
+
+func subscribed(webhook config.Webhook, eventType EventType) bool {
+	for _, event := range webhook.Events {
+		if EventType(event) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispatcher sends events to the configured webhooks.
+type Dispatcher struct {
+	getWebhooks func() []config.Webhook
+	httpClient  *http.Client
+	log         *logrus.Entry
+}
+
+// NewDispatcher creates a dispatcher sending events to the webhooks returned by getWebhooks, using
+// httpClient.
+func NewDispatcher(getWebhooks func() []config.Webhook, httpClient *http.Client) *Dispatcher {
+	return &Dispatcher{
+		getWebhooks: getWebhooks,
+		httpClient:  httpClient,
+		log:         logging.Get().WithGroup("webhooks"),
+	}
+}
+
+// Dispatch sends the event in the background to all webhooks subscribed to its type. Failures are
+// only logged.
+func (dispatcher *Dispatcher) Dispatch(event Event) {
+	for _, webhook := range dispatcher.getWebhooks() {
+		if !subscribed(webhook, event.Type) {
+			continue
+		}
+		go func(webhook config.Webhook) {
+			if err := dispatcher.Send(webhook, event); err != nil {
+				dispatcher.log.WithError(err).Errorf("Sending %s to webhook %s failed", event.Type, webhook.ID)
+			}
+		}(webhook)
+	}
+}
+
+// Send sends the event to the webhook, regardless of its subscribed events. Non-2xx responses are
+// errors.
+func (dispatcher *Dispatcher) Send(webhook config.Webhook, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	request, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, string(event.Type))
+	if webhook.Secret != "" {
+		request.Header.Set(SignatureHeader, Sign(webhook.Secret, body))
+	}
+	client := *dispatcher.httpClient
+	client.Timeout = requestTimeout
+	response, err := client.Do(request)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	_ = response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return errp.Newf("unexpected status: %s", response.Status)
+	}
+	return nil
+}
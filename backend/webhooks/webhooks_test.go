@@ -0,0 +1,94 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/webhooks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, webhooks.Validate(config.Webhook{URL: "https://example.com/hook", Events: []string{"incomingTx"}}))
+	require.Error(t, webhooks.Validate(config.Webhook{URL: "example.com/hook", Events: []string{"incomingTx"}}))
+	require.Error(t, webhooks.Validate(config.Webhook{URL: "https://example.com/hook"}))
+	require.Error(t, webhooks.Validate(config.Webhook{URL: "https://example.com/hook", Events: []string{"unknown"}}))
+}
+
+func TestDispatch(t *testing.T) {
+	type request struct {
+		event     string
+		signature string
+		body      []byte
+	}
+	requests := make(chan request, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+		requests <- request{
+			event:     r.Header.Get(webhooks.EventHeader),
+			signature: r.Header.Get(webhooks.SignatureHeader),
+			body:      body,
+		}
+	}))
+	defer server.Close()
+
+	dispatcher := webhooks.NewDispatcher(func() []config.Webhook {
+		return []config.Webhook{
+			{ID: "1", URL: server.URL, Secret: "secret", Events: []string{"incomingTx"}},
+			{ID: "2", URL: server.URL, Events: []string{"lowBalance"}},
+		}
+	}, http.DefaultClient)
+	dispatcher.Dispatch(webhooks.NewEvent(webhooks.EventIncomingTx, "btc-account", map[string]string{"txID": "abc"}))
+
+	select {
+	case received := <-requests:
+		require.Equal(t, "incomingTx", received.event)
+		require.Equal(t, webhooks.Sign("secret", received.body), received.signature)
+		var event webhooks.Event
+		require.NoError(t, json.Unmarshal(received.body, &event))
+		require.Equal(t, webhooks.EventIncomingTx, event.Type)
+		require.Equal(t, "btc-account", string(event.AccountCode))
+		require.Equal(t, map[string]interface{}{"txID": "abc"}, event.Data)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "webhook not called")
+	}
+	// The second webhook is not subscribed to the event.
+	select {
+	case <-requests:
+		require.Fail(t, "unexpected request")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Unsigned webhooks don't send a signature.
+	require.NoError(t, dispatcher.Send(config.Webhook{URL: server.URL}, webhooks.NewEvent(webhooks.EventTest, "", nil)))
+	require.Empty(t, (<-requests).signature)
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{}' | openssl dgst -sha256 -hmac secret
+	require.Equal(t,
+		"sha256=77325902caca812dc259733aacd046b73817372c777b8d95b402647474516e13",
+		webhooks.Sign("secret", []byte("{}")))
+}
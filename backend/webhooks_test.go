@@ -0,0 +1,110 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountEventsTracker(t *testing.T) {
+	tx := func(id string, txType accounts.TxType, status accounts.TxStatus) *accounts.TransactionData {
+		return &accounts.TransactionData{
+			InternalID: id,
+			TxID:       id,
+			Type:       txType,
+			Status:     status,
+			Amount:     coin.NewAmountFromInt64(100),
+		}
+	}
+	threshold := alertThresholds{lowBalance: big.NewInt(1000)}
+	tracker := &accountEventsTracker{}
+
+	// The existing history does not trigger events, but a low balance does.
+	events := tracker.update([]*accounts.TransactionData{
+		tx("a", accounts.TxTypeReceive, accounts.TxStatusPending),
+	}, big.NewInt(500), threshold)
+	require.Empty(t, events.incoming)
+	require.Empty(t, events.confirmed)
+	require.True(t, events.lowBalance)
+
+	events = tracker.update([]*accounts.TransactionData{
+		tx("a", accounts.TxTypeReceive, accounts.TxStatusComplete),
+		tx("b", accounts.TxTypeReceive, accounts.TxStatusPending),
+		tx("c", accounts.TxTypeSend, accounts.TxStatusPending),
+	}, big.NewInt(2000), threshold)
+	require.Len(t, events.incoming, 1)
+	require.Equal(t, "b", events.incoming[0].TxID)
+	require.Len(t, events.confirmed, 1)
+	require.Equal(t, "a", events.confirmed[0].TxID)
+	require.False(t, events.lowBalance)
+
+	// The low balance is only reported when the balance drops below the threshold again.
+	events = tracker.update([]*accounts.TransactionData{
+		tx("a", accounts.TxTypeReceive, accounts.TxStatusComplete),
+		tx("b", accounts.TxTypeReceive, accounts.TxStatusComplete),
+		tx("c", accounts.TxTypeSend, accounts.TxStatusComplete),
+	}, big.NewInt(900), threshold)
+	require.Empty(t, events.incoming)
+	require.Len(t, events.confirmed, 2)
+	require.True(t, events.lowBalance)
+
+	events = tracker.update(nil, big.NewInt(800), threshold)
+	require.False(t, events.lowBalance)
+	events = tracker.update(nil, big.NewInt(800), alertThresholds{})
+	require.False(t, events.lowBalance)
+}
+
+func TestAccountEventsTrackerLargeOutflow(t *testing.T) {
+	tx := func(id string, txType accounts.TxType, amount int64) *accounts.TransactionData {
+		return &accounts.TransactionData{
+			InternalID: id,
+			TxID:       id,
+			Type:       txType,
+			Status:     accounts.TxStatusPending,
+			Amount:     coin.NewAmountFromInt64(amount),
+		}
+	}
+	thresholds := alertThresholds{largeOutflow: big.NewInt(1000)}
+	tracker := &accountEventsTracker{}
+
+	// Existing outgoing transactions are not reported.
+	events := tracker.update([]*accounts.TransactionData{
+		tx("a", accounts.TxTypeSend, 5000),
+	}, big.NewInt(0), thresholds)
+	require.Empty(t, events.largeOutflows)
+
+	events = tracker.update([]*accounts.TransactionData{
+		tx("a", accounts.TxTypeSend, 5000),
+		tx("b", accounts.TxTypeSend, 1000),
+		tx("c", accounts.TxTypeSend, 1001),
+		tx("d", accounts.TxTypeReceive, 5000),
+		tx("e", accounts.TxTypeSendSelf, 5000),
+	}, big.NewInt(0), thresholds)
+	require.Len(t, events.largeOutflows, 1)
+	require.Equal(t, "c", events.largeOutflows[0].TxID)
+	// All new outgoing transactions are reported for the automation rules.
+	require.Len(t, events.outgoing, 2)
+
+	events = tracker.update([]*accounts.TransactionData{
+		tx("f", accounts.TxTypeSend, 5000),
+	}, big.NewInt(0), alertThresholds{})
+	require.Empty(t, events.largeOutflows)
+	require.Len(t, events.outgoing, 1)
+}
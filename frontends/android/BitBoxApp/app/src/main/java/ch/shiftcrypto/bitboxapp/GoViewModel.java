@@ -22,6 +22,7 @@ import java.io.File;
 import java.util.Locale;
 
 import mobileserver.GoAPIInterface;
+import mobileserver.GoBluetoothDeviceInfoInterface;
 import mobileserver.GoDeviceInfoInterface;
 import mobileserver.GoEnvironmentInterface;
 import mobileserver.GoReadWriteCloserInterface;
@@ -117,6 +118,11 @@ public class GoViewModel extends AndroidViewModel {
             return this.device;
         }
 
+        public GoBluetoothDeviceInfoInterface bluetoothDeviceInfo() {
+            // Devices are connected via USB-OTG on Android.
+            return null;
+        }
+
         public void systemOpen(String url) throws Exception {
             Util.systemOpen(getApplication(), url);
         }
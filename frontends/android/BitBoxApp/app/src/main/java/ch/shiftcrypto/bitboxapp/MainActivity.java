@@ -51,9 +51,12 @@ import java.io.IOException;
 import java.io.InputStream;
 import java.io.InputStreamReader;
 import java.util.ArrayList;
+import java.util.Arrays;
 import java.util.HashMap;
+import java.util.HashSet;
 import java.util.Iterator;
 import java.util.List;
+import java.util.Set;
 import java.util.regex.Pattern;
 
 import mobileserver.Mobileserver;
@@ -69,6 +72,10 @@ public class MainActivity extends AppCompatActivity {
     //
     // Unfortunately there seems to be no simple way to include this header only in requests to Moonpay.
     private static final String BASE_URL = "https://shiftcrypto.ch/";
+    // URI schemes registered in AndroidManifest.xml and handled by the backend, see
+    // backend.HandleURI().
+    private static final Set<String> URI_SCHEMES = new HashSet<>(
+            Arrays.asList("aopp", "bitcoin", "litecoin", "ethereum"));
 
     // stores the request from onPermissionRequest until the user has granted or denied the permission.
     private PermissionRequest webViewpermissionRequest;
@@ -401,7 +408,7 @@ public class MainActivity extends AppCompatActivity {
     @Override
     protected void onNewIntent(Intent intent) {
         // This is only called reliably when intents are received (e.g. USB is attached or when
-        // handling 'aopp:' URIs through the android.intent.action.VIEW intent) with
+        // handling 'aopp:' or 'bitcoin:' URIs through the android.intent.action.VIEW intent) with
         // android:launchMode="singleTop"
         super.onNewIntent(intent);
         setIntent(intent); // make sure onResume will have access to this intent
@@ -532,12 +539,13 @@ public class MainActivity extends AppCompatActivity {
             Util.log("usb: detached");
             this.updateDevice();
         }
-        // Handle 'aopp:' URIs. This is called when the app is launched and also if it is already
-        // running and brought to the foreground.
+        // Handle 'aopp:' and payment URIs like 'bitcoin:...'. This is called when the app is
+        // launched and also if it is already running and brought to the foreground. If the backend
+        // is not running yet, the URI is queued until it is.
         if (intent.getAction().equals(Intent.ACTION_VIEW)) {
             Uri uri = intent.getData();
             if (uri != null) {
-                if (uri.getScheme().equals("aopp")) {
+                if (URI_SCHEMES.contains(uri.getScheme())) {
                     Mobileserver.handleURI(uri.toString());
                 }
             }
@@ -56,6 +56,12 @@ class GoEnvironment: NSObject, MobileserverGoEnvironmentInterfaceProtocol {
         return nil
     }
 
+    func bluetoothDeviceInfo() -> MobileserverGoBluetoothDeviceInfoInterfaceProtocol? {
+        // Return an instance conforming to MobileserverGoBluetoothDeviceInfoInterfaceProtocol
+        // once a device is connected over Bluetooth LE.
+        return nil
+    }
+
     func nativeLocale() -> String {
         return Locale.current.identifier
     }
@@ -115,6 +121,12 @@ struct BitBoxAppApp: App {
                     .onReceive(NotificationCenter.default.publisher(for: UIApplication.willEnterForegroundNotification)) { _ in
                             MobileserverTriggerAuth()
                     }
+                    .onOpenURL { url in
+                        // Handle 'aopp:' and payment URIs like 'bitcoin:...' registered in
+                        // Info.plist. If the backend is not running yet, the URI is queued
+                        // until it is.
+                        MobileserverHandleURI(url.absoluteString)
+                    }
             }
         }
     }
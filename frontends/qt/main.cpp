@@ -78,9 +78,9 @@ public:
             QFileOpenEvent* openEvent = static_cast<QFileOpenEvent*>(event);
             if (!openEvent->url().isEmpty()) {
                 // This is only supported on macOS and is used to handle URIs that are opened with
-                // the BitBoxApp, such as "aopp:..." links. The event is received and handled both
-                // if the BitBoxApp is launched and also when it is already running, in which case
-                // it is brought to the foreground automatically.
+                // the BitBoxApp, such as "aopp:..." or "bitcoin:..." links. The event is received
+                // and handled both if the BitBoxApp is launched and also when it is already
+                // running, in which case it is brought to the foreground automatically.
 
                 handleURI(openEvent->url().toString().toUtf8().constData());
             }
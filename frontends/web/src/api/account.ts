@@ -23,7 +23,7 @@ export type CoinCode = 'btc' | 'tbtc' | 'ltc' | 'tltc' | 'eth' | 'goeth' | 'sepe
 
 export type AccountCode = string;
 
-export type Fiat = 'AUD' | 'BRL' | 'BTC' | 'CAD' | 'CHF' | 'CNY' | 'CZK' | 'EUR' | 'GBP' | 'HKD' | 'ILS' | 'JPY' | 'KRW' | 'NOK' | 'PLN' | 'RUB' | 'sat' | 'SEK' | 'SGD' | 'USD';
+export type Fiat = 'AED' | 'ARS' | 'AUD' | 'BRL' | 'BTC' | 'CAD' | 'CHF' | 'CLP' | 'CNY' | 'CZK' | 'DKK' | 'EUR' | 'GBP' | 'HKD' | 'HUF' | 'IDR' | 'ILS' | 'INR' | 'JPY' | 'KRW' | 'MXN' | 'MYR' | 'NGN' | 'NOK' | 'NZD' | 'PHP' | 'PKR' | 'PLN' | 'RUB' | 'SAR' | 'sat' | 'SEK' | 'SGD' | 'THB' | 'TRY' | 'TWD' | 'UAH' | 'USD' | 'VND' | 'XAG' | 'XAU' | 'ZAR';
 
 export type ConversionUnit = Fiat | 'sat'
 
@@ -86,6 +86,26 @@ export type TAccountTotalBalance = {
     total: string;
 };
 
+export type TAccountBalance = {
+  accountCode: AccountCode;
+  coinCode: CoinCode;
+  synced: boolean;
+  balance: IAmount;
+};
+
+export type TAccountsBalancesResponse = {
+  success: true;
+  accounts: TAccountBalance[];
+  total: TAccountTotalBalance | null;
+} | {
+  success: false;
+  errorMessage: string;
+};
+
+export const getAccountsBalances = (): Promise<TAccountsBalancesResponse> => {
+  return apiGet('accounts/balances');
+};
+
 export type TAccountsTotalBalance = {
     [key: string]: TAccountTotalBalance;
 };
@@ -131,7 +151,7 @@ export const getStatus = (code: AccountCode): Promise<IStatus> => {
   return apiGet(`account/${code}/status`);
 };
 
-export type ScriptType = 'p2pkh' | 'p2wpkh-p2sh' | 'p2wpkh' | 'p2tr';
+export type ScriptType = 'p2pkh' | 'p2wpkh-p2sh' | 'p2wpkh' | 'p2tr' | 'p2wsh';
 
 export const allScriptTypes: ScriptType[] = ['p2pkh', 'p2wpkh-p2sh', 'p2wpkh', 'p2tr'];
 
@@ -150,11 +170,24 @@ export type TEthereumSimple = {
     keyInfo: IKeyInfo;
 }
 
+export type TBitcoinMultisig = {
+    threshold: number;
+    // keyInfos are the keys of all cosigners, including our own at ourKeyIndex.
+    keyInfos: IKeyInfo[];
+    ourKeyIndex: number;
+}
+
 export type TSigningConfiguration = {
     bitcoinSimple: TBitcoinSimple;
+    bitcoinMultisig?: never;
     ethereumSimple?: never;
 } | {
     bitcoinSimple?: never;
+    bitcoinMultisig: TBitcoinMultisig;
+    ethereumSimple?: never;
+} | {
+    bitcoinSimple?: never;
+    bitcoinMultisig?: never;
     ethereumSimple: TEthereumSimple;
 }
 
@@ -172,6 +205,14 @@ export const init = (code: AccountCode): Promise<null> => {
   return apiPost(`account/${code}/init`);
 };
 
+/**
+ * Initializes the given accounts in the background if their initialization was deferred
+ * because the lazy account initialization setting is enabled.
+ */
+export const initializeAccounts = (codes: AccountCode[]): Promise<null> => {
+  return apiPost('accounts/initialize', codes);
+};
+
 export interface ISummary {
     chartDataMissing: boolean;
     chartDataDaily: ChartData;
@@ -181,19 +222,73 @@ export interface ISummary {
     formattedChartTotal: string | null;
     chartIsUpToDate: boolean; // only valid if chartDataMissing is false
     lastTimestamp: number;
+    // coins partially or entirely missing from the chart because of missing exchange rates.
+    chartCoinsMissing: {
+      coin: string; // coin or token code, e.g. "btc" or "eth-erc20-usdt"
+      // unix timestamp until which the coin is missing, 0 if it is missing entirely.
+      until: number;
+    }[];
+    clockSkewed: boolean;
+    // only present if requested with derived series, see getSummary.
+    chartDerived?: TChartDerived;
 }
 
-export const getSummary = (): Promise<ISummary> => {
-  return apiGet('account-summary');
+export type TChartPercentEntry = {
+  time: number;
+  // e.g. 12.5 for +12.5%
+  percent: number;
+};
+
+export type TChartDerived = {
+  // 7 day moving average
+  movingAverageDaily: ChartData;
+  movingAverageHourly: ChartData;
+  // change relative to the first non-zero point at or after the range start
+  percentChangeDaily: TChartPercentEntry[];
+  percentChangeHourly: TChartPercentEntry[];
+};
+
+/**
+ * Gets the chart data. If derived is true, the moving averages and the percentage change relative
+ * to rangeStart (unix timestamp, whole chart if not set) are included.
+ */
+export const getSummary = (
+  derived?: boolean,
+  rangeStart?: number,
+): Promise<ISummary> => {
+  if (!derived) {
+    return apiGet('account-summary');
+  }
+  const params = new URLSearchParams({ derived: 'true' });
+  if (rangeStart !== undefined) {
+    params.set('rangeStart', rangeStart.toString());
+  }
+  return apiGet(`account-summary?${params.toString()}`);
 };
 
 export type Conversions = {
     [key in Fiat]: string;
 }
 
+export type TRatesInfo = {
+    timestamp: string;
+    source: string;
+    stale: boolean;
+};
+
+// TSubUnitAmount is an amount or a fee rate in a sub-unit, e.g. 1234 sat, 2.5 sat/vB or 21 gwei.
+export type TSubUnitAmount = {
+  amount: string;
+  unit: string;
+};
+
 export interface IAmount {
     amount: string;
     conversions?: Conversions;
+    ratesInfo?: TRatesInfo;
+    // subUnit is the fee in the conventional sub-unit of the coin (sat, litoshi, gwei), only set
+    // for fees.
+    subUnit?: TSubUnitAmount;
     unit: CoinUnit;
 }
 
@@ -208,16 +303,52 @@ export const getBalance = (code: AccountCode): Promise<IBalance> => {
   return apiGet(`account/${code}/balance`);
 };
 
+export type TCheckpoint = {
+  balance: IBalance;
+  transactions: ITransaction[];
+  // createdAt is when the account was last synced.
+  createdAt: string;
+} | null;
+
+/**
+ * Returns the balance and the recent transactions as of the last sync. They can be shown while
+ * the account is syncing. Returns null if the account was never synced.
+ */
+export const getCheckpoint = (code: AccountCode): Promise<TCheckpoint> => {
+  return apiGet(`account/${code}/checkpoint`);
+};
+
+export type TTxOrigin = {
+    exchange: string;
+    orderID?: string;
+};
+
 export interface ITransaction {
     addresses: string[];
+    // contractName and methodName describe the called contract method of ETH transactions, if known.
+    contractName?: string;
+    methodName?: string;
     amount: IAmount;
     amountAtTime: IAmount | null;
     fee: IAmount;
+    // feeRate is the fee per vbyte (BTC, LTC) or the gas price (ETH) in the fee sub-unit.
+    feeRate: TSubUnitAmount | null;
     feeRatePerKb: IAmount;
     gas: number;
     nonce: number | null;
     internalID: string;
     note: string;
+    // category is the category set by the user, e.g. 'Groceries', used in the ledger export.
+    category: string;
+    // addressLabels maps the addresses of the transaction that have a label to their label.
+    addressLabels: Record<string, string>;
+    // spam is true if the transaction is likely spam or was marked as such by the user.
+    spam: boolean;
+    // origin is where the funds came from, e.g. an imported exchange withdrawal.
+    origin: TTxOrigin | null;
+    // internalTransfer is the code of the account on the other side of a transfer between the
+    // accounts, and empty otherwise. Internal transfers are neither income nor spending.
+    internalTransfer: AccountCode | '';
     numConfirmations: number;
     numConfirmationsComplete: number;
     size: number;
@@ -243,6 +374,20 @@ export const postNotesTx = (code: AccountCode, {
   return apiPost(`account/${code}/notes/tx`, { internalTxID, note });
 };
 
+// postTxCategory sets the category of a transaction. Subcategories are separated by a colon, e.g.
+// 'Food:Groceries'. An empty category removes it.
+export const postTxCategory = (code: AccountCode, internalTxID: string, category: string): Promise<null> => {
+  return apiPost(`account/${code}/notes/tx-category`, { internalTxID, category });
+};
+
+export const postTxSpam = (code: AccountCode, internalTxID: string, spam: boolean): Promise<null> => {
+  return apiPost(`account/${code}/notes/tx-spam`, { internalTxID, spam });
+};
+
+export const postAddressLabel = (code: AccountCode, address: string, label: string): Promise<null> => {
+  return apiPost(`account/${code}/notes/address`, { address, label });
+};
+
 export const proposeTxNote = (code: AccountCode, note: string): Promise<null> => {
   return apiPost(`account/${code}/propose-tx-note`, note);
 };
@@ -261,20 +406,23 @@ export interface IExport {
     errorMessage: string;
 }
 
-export const exportAccount = (code: AccountCode): Promise<IExport | null> => {
-  return apiPost(`account/${code}/export`);
+export type TExportFormat = 'csv' | 'json' | 'ofx' | 'qif';
+
+export const exportAccount = (code: AccountCode, format: TExportFormat): Promise<IExport | null> => {
+  return apiPost(`account/${code}/export`, { format });
 };
 
 export const verifyXPub = (
   code: AccountCode,
   signingConfigIndex: number,
-): Promise<{ success: true; } | { success: false; errorMessage: string; }> => {
+): Promise<{ success: true; } | { success: false; errorMessage: string; errorCode?: 'deviceBusy'; }> => {
   return apiPost(`account/${code}/verify-extended-public-key`, { signingConfigIndex });
 };
 
 export interface IReceiveAddress {
     addressID: string;
     address: string;
+    label: string;
 }
 
 export interface ReceiveAddressList {
@@ -288,13 +436,72 @@ export const getReceiveAddressList = (code: AccountCode) => {
   };
 };
 
+export type TReceiveURIResponse = {
+  success: true;
+  uri: string;
+  // qrCode is the QR code of the URI as a PNG data URL.
+  qrCode: string;
+} | {
+  success: false;
+  errorMessage: string;
+};
+
+/**
+ * Composes the BIP-21 or EIP-681 URI requesting a payment to a receive address. amount is in the
+ * unit amounts are entered in (e.g. BTC or sat) and label can be empty.
+ */
+export const getReceiveURI = (
+  code: AccountCode,
+  addressID: string,
+  amount: string,
+  label: string,
+): Promise<TReceiveURIResponse> => {
+  const params = new URLSearchParams({ addressID, amount, label });
+  return apiGet(`account/${code}/receive-uri?${params.toString()}`);
+};
+
 export type TTxInput = {
   address: string;
   amount: string;
+  // alternative to amount, as an integer string in the smallest unit, e.g. sat or wei.
+  amountSmallestUnit?: string;
   feeTarget: FeeTargetCode;
   customFee: string;
   sendAll: 'yes' | 'no';
   selectedUTXOs: string[],
+  // allowHighFee skips rejecting BTC/LTC proposals with a fee that is considered too high.
+  allowHighFee: boolean;
+  // proposalId identifies the send dialog. If UTXO locking is enabled in the backend config, the
+  // inputs of BTC/LTC proposals are reserved for it.
+  proposalId?: string;
+};
+
+export type TPrivacyIssueCode = 'addressReuse' | 'mergedClusters' | 'roundAmount' | 'changeDetectable';
+
+export type TPrivacyIssue = {
+  code: TPrivacyIssueCode;
+  penalty: number;
+  explanation: string;
+  addresses?: string[];
+};
+
+/**
+ * Privacy rating of a tx proposal from 0 (worst) to 100 (no known issues).
+ */
+export type TPrivacyScore = {
+  score: number;
+  issues: TPrivacyIssue[];
+};
+
+export type TTxWarningCode = 'unlimitedApproval' | 'approvalForAll' | 'newContract';
+
+/**
+ * Risk of an outgoing ETH transaction, which must be shown to the user before signing.
+ */
+export type TTxWarning = {
+  code: TTxWarningCode;
+  // address is the approved spender or operator, or the recipient contract.
+  address: string;
 };
 
 export type TTxProposalResult = {
@@ -302,8 +509,18 @@ export type TTxProposalResult = {
   fee: IAmount;
   success: true;
   total: IAmount;
+  // feeRate is the fee per vbyte (BTC, LTC) or the gas price (ETH) in the fee sub-unit.
+  feeRate?: TSubUnitAmount;
+  // BTC and LTC only.
+  changeRoundingFee?: IAmount;
+  privacyScore?: TPrivacyScore | null;
+  spendsUnconfirmedChange?: boolean;
+  // ETH only.
+  warnings?: TTxWarning[];
 } | {
   errorCode: string;
+  likelyCoinCode?: CoinCode;
+  likelyCoinName?: string;
   success: false;
 };
 
@@ -314,6 +531,131 @@ export const proposeTx = (
   return apiPost(`account/${accountCode}/tx-proposal`, txInput);
 };
 
+export type TUTXOReservation = {
+  outPoint: string;
+  proposalId: string;
+  expiresAt: string;
+};
+
+/**
+ * Returns the coins reserved by pending tx proposals.
+ */
+export const getUTXOReservations = (code: AccountCode): Promise<TUTXOReservation[]> => {
+  return apiGet(`account/${code}/utxo-reservations`);
+};
+
+/**
+ * Releases the coins reserved by a tx proposal, e.g. when its send dialog is closed.
+ */
+export const releaseUTXOReservations = (code: AccountCode, proposalId: string): Promise<null> => {
+  return apiPost(`account/${code}/utxo-reservations/release`, { proposalId });
+};
+
+export type TFeeBumpInput = {
+  // txID is the ID of the unconfirmed transaction to be sped up.
+  txID: string;
+  feeTarget: FeeTargetCode;
+  // customFee is in sat/vB, used if feeTarget is 'custom'.
+  customFee?: string;
+  allowHighFee?: boolean;
+};
+
+export type TFeeBumpProposalResult = {
+  amount: IAmount;
+  fee: IAmount;
+  success: true;
+  total: IAmount;
+} | {
+  errorCode: string;
+  success: false;
+};
+
+/**
+ * Proposes a transaction replacing an unconfirmed outgoing BTC transaction with a higher fee
+ * (BIP125 replace-by-fee). Like proposeTx(), the proposal is signed and broadcast with sendTx().
+ */
+export const proposeFeeBump = (
+  accountCode: AccountCode,
+  input: TFeeBumpInput,
+): Promise<TFeeBumpProposalResult> => {
+  return apiPost(`account/${accountCode}/fee-bump-proposal`, input);
+};
+
+export type TCPFPProposalResult = {
+  // amount is sent back to the account.
+  amount: IAmount;
+  fee: IAmount;
+  // parentFeeRatePerKb and packageFeeRatePerKb are in sat/kvB.
+  parentFeeRatePerKb: IAmount;
+  packageFeeRatePerKb: IAmount;
+  success: true;
+} | {
+  errorCode: string;
+  success: false;
+};
+
+/**
+ * Proposes a transaction spending the unconfirmed outputs of an incoming BTC/LTC transaction back
+ * to the account with a high fee (child-pays-for-parent), so that both confirm sooner. The input
+ * txID is the incoming transaction. Like proposeTx(), the proposal is signed and broadcast with
+ * sendTx().
+ */
+export const proposeCPFP = (
+  accountCode: AccountCode,
+  input: TFeeBumpInput,
+): Promise<TCPFPProposalResult> => {
+  return apiPost(`account/${accountCode}/cpfp-proposal`, input);
+};
+
+export type TEthPendingTransaction = {
+  txID: string;
+  nonce: number;
+};
+
+/**
+ * Returns the pending outgoing transactions of an ETH account, which can be replaced with
+ * proposeEthReplacement().
+ */
+export const getEthPendingTransactions = (
+  accountCode: AccountCode,
+): Promise<TEthPendingTransaction[]> => {
+  return apiGet(`account/${accountCode}/eth-pending-transactions`);
+};
+
+export type TEthReplacementInput = {
+  txID: string;
+  // cancel replaces the transaction with a 0-value transfer to the account itself instead of
+  // re-sending it.
+  cancel: boolean;
+  feeTarget: FeeTargetCode;
+  // customFee is in Gwei, used if feeTarget is 'custom'.
+  customFee?: string;
+};
+
+export type TEthReplacementProposalResult = {
+  amount: IAmount;
+  fee: IAmount;
+  feeRate?: TSubUnitAmount;
+  success: true;
+  total: IAmount;
+  warnings: TTxWarning[];
+} | {
+  errorCode: string;
+  success: false;
+};
+
+/**
+ * Proposes a transaction with the same nonce as a pending outgoing ETH transaction, paying at least
+ * 10% higher fees, which re-sends or cancels it. Like proposeTx(), the proposal is signed and
+ * broadcast with sendTx().
+ */
+export const proposeEthReplacement = (
+  accountCode: AccountCode,
+  input: TEthReplacementInput,
+): Promise<TEthReplacementProposalResult> => {
+  return apiPost(`account/${accountCode}/eth-replacement-proposal`, input);
+};
+
 export interface ISendTx {
     aborted?: boolean;
     success?: boolean;
@@ -321,10 +663,77 @@ export interface ISendTx {
     errorCode?: string;
 }
 
+/**
+ * Signs and sends the last tx proposal. If the device disconnects while
+ * signing, errorCode is 'keystoreDisconnected' and the proposal is kept, so
+ * that calling this again after the device reconnected resumes signing.
+ */
 export const sendTx = (code: AccountCode): Promise<ISendTx> => {
   return apiPost(`account/${code}/sendtx`);
 };
 
+/**
+ * Returns true if there is a tx proposal whose signing was interrupted by the
+ * device disconnecting, which can be signed and sent with sendTx().
+ */
+export const getInterruptedSigning = (code: AccountCode): Promise<boolean> => {
+  return apiGet(`account/${code}/interrupted-signing`);
+};
+
+export type TExportPSBTResponse = {
+  success: true;
+  // psbt is the base64 encoded unsigned BIP174 PSBT of the tx proposal.
+  psbt: string;
+} | {
+  success: false;
+  errorMessage: string;
+};
+
+/**
+ * Exports the last tx proposal, see proposeTx(), as a PSBT to be signed by external tools.
+ */
+export const exportPSBT = (code: AccountCode): Promise<TExportPSBTResponse> => {
+  return apiGet(`account/${code}/psbt`);
+};
+
+export type TImportPSBTResponse = {
+  success: true;
+  txID: string;
+} | {
+  success: false;
+  errorMessage: string;
+  // errorCode is set for invalid PSBTs, see send.error.* in the translations.
+  errorCode?: 'psbtInvalid' | 'psbtForeignInput' | 'psbtIncomplete';
+};
+
+/**
+ * Finalizes and broadcasts a base64 encoded PSBT signed by external tools.
+ */
+export const importPSBT = (code: AccountCode, psbt: string): Promise<TImportPSBTResponse> => {
+  return apiPost(`account/${code}/psbt`, { psbt });
+};
+
+export type TSignPSBTResponse = {
+  success: true;
+  // psbt is the base64 encoded PSBT with our signatures.
+  psbt: string;
+  // complete is true if the PSBT can be broadcasted with importPSBT().
+  complete: boolean;
+} | {
+  success: false;
+  aborted?: boolean;
+  errorMessage?: string;
+  errorCode?: 'psbtInvalid' | 'psbtForeignInput' | 'deviceBusy';
+};
+
+/**
+ * Signs a base64 encoded PSBT with the connected keystore, e.g. to cosign a transaction of a
+ * multisig account. Without a PSBT, the last tx proposal, see proposeTx(), is signed.
+ */
+export const signPSBT = (code: AccountCode, psbt?: string): Promise<TSignPSBTResponse> => {
+  return apiPost(`account/${code}/psbt/sign`, { psbt });
+};
+
 export type FeeTargetCode = 'custom' | 'low' | 'economy' | 'normal' | 'high';
 
 export interface IProposeTxData {
@@ -357,7 +766,38 @@ export const getFeeTargetList = (code: AccountCode): Promise<IFeeTargetList> =>
   return apiGet(`account/${code}/fee-targets`);
 };
 
-export const verifyAddress = (code: AccountCode, addressID: string): Promise<boolean> => {
+export type TFeeEstimateSource = 'electrumMempool' | 'mempoolSpace' | 'node' | 'minRelayFee';
+
+export type TFeeEstimate = {
+  // blocks is the number of blocks within which the transaction is expected to confirm.
+  blocks: number;
+  feeRate: TSubUnitAmount | null;
+  source: TFeeEstimateSource;
+};
+
+export type TFeeEstimatesResponse = {
+  success: true;
+  feeEstimates: TFeeEstimate[];
+} | {
+  success: false;
+  errorMessage: string;
+};
+
+export const getFeeEstimates = (code: AccountCode): Promise<TFeeEstimatesResponse> => {
+  return apiGet(`account/${code}/fee-estimates`);
+};
+
+export type TVerifyAddressResponse = {
+  success: true;
+  // verified is false if the keystore can't verify addresses.
+  verified: boolean;
+} | {
+  success: false;
+  errorMessage?: string;
+  errorCode?: 'deviceBusy';
+};
+
+export const verifyAddress = (code: AccountCode, addressID: string): Promise<TVerifyAddressResponse> => {
   return apiPost(`account/${code}/verify-address`, addressID);
 };
 
@@ -370,12 +810,209 @@ export type TUTXO = {
   note: string;
   scriptType: ScriptType;
   addressReused: boolean;
+  unconfirmed: boolean;
 };
 
 export const getUTXOs = (code: AccountCode): Promise<TUTXO[]> => {
   return apiGet(`account/${code}/utxos`);
 };
 
+export type TReceiveAddressUsage = {
+  address: string;
+  scriptType: ScriptType;
+  keypath: string;
+  used: boolean;
+  txCount: number;
+  totalReceived: IAmount;
+  firstUsed: string | null;
+  lastUsed: string | null;
+};
+
+export const getReceiveAddressUsage = (code: AccountCode): Promise<TReceiveAddressUsage[]> => {
+  return apiGet(`account/${code}/receive-address-usage`);
+};
+
+export const exportReceiveAddressUsage = (code: AccountCode): Promise<IExport | null> => {
+  return apiPost(`account/${code}/receive-address-usage/export`);
+};
+
+export type TAddressCluster = {
+  addresses: string[];
+  // txIDs are the transactions which spent from the addresses together, linking them.
+  txIDs: string[];
+};
+
+export type TClusterAnalysis = {
+  clusters: TAddressCluster[];
+  usedAddresses: number;
+  linkedAddresses: number;
+};
+
+/**
+ * Returns the groups of own addresses which are publicly linkable because they were spent
+ * together (common-input-ownership heuristic).
+ */
+export const getAddressClusters = (code: AccountCode): Promise<TClusterAnalysis> => {
+  return apiGet(`account/${code}/address-clusters`);
+};
+
+export type TUTXOStats = {
+  count: number;
+  total: IAmount;
+  smallest: IAmount;
+  largest: IAmount;
+  medianAge: number;
+  feeRatePerKb: IAmount;
+  dustCount: number;
+  dustTotal: IAmount;
+};
+
+/**
+ * Returns aggregate statistics of the unspent outputs. Outputs are counted as dust if spending them
+ * costs at least as much as they are worth at the fee rate of the given fee target, or of the
+ * lowest fee target if none is given. customFee is in sat/vB.
+ */
+export const getUTXOStats = (
+  code: AccountCode,
+  feeTarget = '',
+  customFee = '',
+): Promise<TUTXOStats> => {
+  const params = new URLSearchParams({ feeTarget, customFee });
+  return apiGet(`account/${code}/utxo-stats?${params.toString()}`);
+};
+
+export type TTxSizeEstimateInput = {
+  // inputs and outputs are the number of inputs/outputs per script type. outputs include change.
+  inputs?: Partial<Record<ScriptType, number>>;
+  // utxos are spendable outputs of the account ('txid:index'), spent in addition to inputs.
+  utxos?: string[];
+  outputs: Partial<Record<ScriptType, number>>;
+};
+
+export type TTxSizeEstimateResult = {
+  success: true;
+  vsize: number;
+  // inputValue is the total value of the given utxos.
+  inputValue: IAmount;
+  fees: {
+    feeTarget: FeeTargetCode;
+    feeRate: TSubUnitAmount;
+    fee: IAmount;
+  }[];
+} | {
+  success: false;
+  errorMessage?: string;
+  errorCode?: string;
+};
+
+/**
+ * Estimates the virtual size of a transaction and its fee at the fee rates of the fee targets,
+ * without creating a proposal, to plan consolidations and batch payments.
+ */
+export const estimateTxSize = (
+  code: AccountCode,
+  input: TTxSizeEstimateInput,
+): Promise<TTxSizeEstimateResult> => {
+  return apiPost(`account/${code}/tx-size-estimate`, input);
+};
+
+export type TBalancePart = {
+  available: IAmount;
+  incoming: IAmount;
+};
+
+export type TBalanceBreakdown = {
+  receive: TBalancePart;
+  change: TBalancePart;
+  // ordered like the script types of the account, followed by other script types holding funds.
+  scriptTypes: (TBalancePart & { scriptType: ScriptType })[];
+  // ordered by balance, largest first.
+  addresses: (TBalancePart & {
+    address: string;
+    keypath: string;
+    scriptType: ScriptType;
+    change: boolean;
+    outputCount: number;
+  })[];
+};
+
+/**
+ * Returns the balance of a BTC/LTC account broken down by receive and change addresses, by script
+ * type and by address.
+ */
+export const getBalanceBreakdown = (code: AccountCode): Promise<TBalanceBreakdown> => {
+  return apiGet(`account/${code}/balance-breakdown`);
+};
+
+export type TAccountDescriptors = {
+  scriptType: ScriptType;
+  receive: string;
+  change: string;
+};
+
+/**
+ * Returns the output descriptors of a BTC/LTC account with checksums, one entry per script type,
+ * e.g. to watch the account in Bitcoin Core.
+ */
+export const getDescriptors = (code: AccountCode): Promise<TAccountDescriptors[]> => {
+  return apiGet(`account/${code}/descriptors`);
+};
+
+export type TSnapshotUTXO = {
+  outPoint: string;
+  address: string;
+  scriptType: ScriptType;
+  keypath: string;
+  // value is in the smallest unit of the coin, e.g. satoshi.
+  value: number;
+  height: number;
+};
+
+export type TSnapshot = {
+  coin: CoinCode;
+  accountCode: AccountCode;
+  accountName: string;
+  blockHeight: number;
+  blockHash?: string;
+  createdAt: string;
+  balance: number;
+  unit: string;
+  utxos: TSnapshotUTXO[];
+};
+
+/**
+ * Returns the balance and UTXO set of the account at a block height. If blockHeight is 0, the
+ * current chain tip is used.
+ */
+export const getSnapshot = (
+  code: AccountCode,
+  blockHeight: number = 0,
+): Promise<{ success: true; snapshot: TSnapshot; balance: IAmount } | { success: false; errorMessage: string }> => {
+  return apiGet(`account/${code}/snapshot?blockHeight=${blockHeight}`);
+};
+
+/**
+ * Signs a snapshot at the block height with the device and exports it as JSON.
+ */
+export const exportBalanceAttestation = (
+  code: AccountCode,
+  blockHeight: number = 0,
+): Promise<(IExport & { errorCode?: 'deviceBusy' }) | null> => {
+  return apiPost(`account/${code}/snapshot/export`, { blockHeight });
+};
+
+/**
+ * Signs a list of the next `count` unused receive addresses with the device and exports it as
+ * JSON. Fails with errorCode `gapLimitExceeded` if count exceeds the receive gap limit.
+ */
+export const exportAddressList = (
+  code: AccountCode,
+  count: number,
+  scriptType?: ScriptType,
+): Promise<(IExport & { errorCode?: 'gapLimitExceeded' | 'deviceBusy' }) | null> => {
+  return apiPost(`account/${code}/address-list/export`, { count, scriptType: scriptType || '' });
+};
+
 type TSecureOutput = {
     hasSecureOutput: boolean;
     optional: boolean;
@@ -390,14 +1027,54 @@ export const hasSecureOutput = (code: AccountCode) => {
 export type TAddAccount = {
   success: boolean;
   accountCode?: string;
-  errorCode?: 'accountAlreadyExists' | 'accountLimitReached';
+  errorCode?: 'accountAlreadyExists' | 'accountLimitReached' | 'unknownExportFormat' | 'invalidDescriptorChecksum' | 'unsupportedDescriptor' | 'userAbort' | 'deviceBusy';
   errorMessage?: string;
 }
 
-export const addAccount = (coinCode: string, name: string): Promise<TAddAccount> => {
+export type TMultisig = {
+  threshold: number;
+  // cosigners are the keys of the other cosigners with key origin, e.g.
+  // [fingerprint/48'/0'/0'/2']xpub...
+  cosigners: string[];
+}
+
+/**
+ * Adds an account of the connected keystore, or a watch-only account if output descriptors
+ * (one per line, e.g. wpkh([fingerprint/84'/0'/0']xpub.../0/*)#checksum) are given.
+ */
+export const addAccount = (coinCode: string, name: string, descriptor?: string): Promise<TAddAccount> => {
+  return apiPost('account-add', {
+    coinCode,
+    name,
+    descriptor,
+  });
+};
+
+/**
+ * Adds a multisig account of the connected keystore with the given cosigners. The user confirms
+ * the cosigners on the device, which registers the account under its name.
+ */
+export const addMultisigAccount = (coinCode: string, name: string, multisig: TMultisig): Promise<TAddAccount> => {
   return apiPost('account-add', {
     coinCode,
     name,
+    multisig,
+  });
+};
+
+/**
+ * Adds a watch-only account from the extended public key export file of another hardware wallet
+ * (Coldcard/Passport generic JSON or Keystone JSON export).
+ */
+export const importAccount = (
+  coinCode: string,
+  name: string,
+  exportFile: string,
+): Promise<TAddAccount> => {
+  return apiPost('account-import', {
+    coinCode,
+    name,
+    exportFile,
   });
 };
 
@@ -405,12 +1082,13 @@ export const connectKeystore = (code: AccountCode): Promise<{ success: boolean;
   return apiPost(`account/${code}/connect-keystore`);
 };
 
-export type TSignMessage = { success: false, aborted?: boolean; errorMessage?: string; } | { success: true; signature: string; }
+export type TSignMessage = { success: false, aborted?: boolean; errorMessage?: string; errorCode?: 'deviceBusy'; } | { success: true; signature: string; }
 
 export type TSignWalletConnectTx = {
   success: false,
   aborted?: boolean;
   errorMessage?: string;
+  errorCode?: 'deviceBusy';
 } | {
   success: true;
   txHash: string;
@@ -425,8 +1103,20 @@ export const ethSignTypedMessage = (code: AccountCode, chainId: number, data: an
   return apiPost(`account/${code}/eth-sign-typed-msg`, { chainId, data });
 };
 
-export const ethSignWalletConnectTx = (code: AccountCode, send: boolean, chainId: number, tx: any): Promise<TSignWalletConnectTx> => {
-  return apiPost(`account/${code}/eth-sign-wallet-connect-tx`, { send, chainId, tx });
+export const ethSignWalletConnectTx = (code: AccountCode, send: boolean, chainId: number, tx: any, acceptWarnings: boolean): Promise<TSignWalletConnectTx> => {
+  return apiPost(`account/${code}/eth-sign-wallet-connect-tx`, { send, chainId, tx, acceptWarnings });
+};
+
+export type TWalletConnectTxWarnings = {
+  success: true;
+  warnings: TTxWarning[];
+} | {
+  success: false;
+  errorMessage?: string;
+};
+
+export const ethWalletConnectTxWarnings = (code: AccountCode, tx: any): Promise<TWalletConnectTxWarnings> => {
+  return apiPost(`account/${code}/eth-wallet-connect-tx-warnings`, tx);
 };
 
 export type AddressSignResponse = {
@@ -436,9 +1126,32 @@ export type AddressSignResponse = {
 } | {
   success: false;
   errorMessage?: string;
-  errorCode?: 'userAbort' | 'wrongKeystore';
+  errorCode?: 'userAbort' | 'wrongKeystore' | 'deviceBusy';
 }
 
 export const signAddress = (format: ScriptType | '', msg: string, code: AccountCode): Promise<AddressSignResponse> => {
   return apiPost(`account/${code}/sign-address`, { format, msg, code });
 };
+
+export type TMessageSignatureFormat = 'legacy' | 'bip322';
+
+export type TSignMessageResponse = {
+  success: true;
+  signedMessage: {
+    address: string;
+    format: TMessageSignatureFormat;
+    signature: string;
+  };
+} | {
+  success: false;
+  errorMessage?: string;
+  errorCode?: 'userAbort' | 'wrongKeystore' | 'invalidAddress' | 'addressNotInAccount' | 'deviceBusy';
+};
+
+/**
+ * Signs a message with an address of the account to prove its ownership. If the address is
+ * empty, the first unused native segwit receive address is used.
+ */
+export const signMessage = (code: AccountCode, address: string, message: string): Promise<TSignMessageResponse> => {
+  return apiPost(`account/${code}/sign-message`, { address, message });
+};
@@ -63,6 +63,22 @@ export const statusChanged = (
   return unsubscribe;
 };
 
+/**
+ * Fired when the account was initialized after it was loaded. Accounts are
+ * initialized in the background, a few at a time, so each account becomes
+ * ready individually.
+ * Returns a method to unsubscribe.
+ */
+export const initialized = (
+  cb: (code: accountAPI.AccountCode) => void,
+): TUnsubscribe => {
+  return subscribeLegacy('initialized', event => {
+    if (event.type === 'account' && event.code) {
+      cb(event.code);
+    }
+  });
+};
+
 /**
  * Fired when the account is fully synced.
  * Returns a method to unsubscribe.
@@ -76,3 +92,35 @@ export const syncdone = (
     }
   });
 };
+
+/**
+ * Fired when a chain reorganization replaced blocks containing transactions
+ * of the account. Transactions which vanished are removed and the others are
+ * confirmed again, so the balance can change suddenly.
+ * Returns a method to unsubscribe.
+ */
+export const reorg = (
+  cb: (code: accountAPI.AccountCode) => void,
+): TUnsubscribe => {
+  return subscribeLegacy('reorg', event => {
+    if (event.type === 'account' && event.code) {
+      cb(event.code);
+    }
+  });
+};
+
+/**
+ * Fired when the device disconnected while signing a transaction of the
+ * account. The transaction can be signed and sent with sendTx() once the
+ * device is connected again, see getInterruptedSigning().
+ * Returns a method to unsubscribe.
+ */
+export const signingInterrupted = (
+  cb: (code: accountAPI.AccountCode) => void,
+): TUnsubscribe => {
+  return subscribeLegacy('signingInterrupted', event => {
+    if (event.type === 'account' && event.code) {
+      cb(event.code);
+    }
+  });
+};
@@ -0,0 +1,60 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import type { AccountCode } from './account';
+import { apiGet, apiPost } from '../utils/request';
+import type { TUnsubscribe } from '../utils/transport-common';
+import { subscribeEndpoint } from './subscribe';
+
+export type TAccountAlertType = 'lowBalance' | 'largeOutflow';
+
+export type TAccountAlert = {
+    type: TAccountAlertType;
+    accountCode: AccountCode;
+    accountName: string;
+    // amount is the balance for lowBalance and the sent amount for largeOutflow alerts.
+    amount: string;
+    threshold: string;
+    unit: string;
+    txID?: string;
+};
+
+/**
+ * Alert thresholds in the unit of the account. Empty values are disabled.
+ */
+export type TAccountAlerts = {
+    lowBalance: string;
+    largeOutflow: string;
+};
+
+export const getAccountAlerts = (
+  code: AccountCode,
+): Promise<{ success: true; alerts: TAccountAlerts & { unit: string } } | { success: false; errorMessage: string }> => {
+  return apiGet(`accounts/alerts?code=${code}`);
+};
+
+export const setAccountAlerts = (
+  accountCode: AccountCode,
+  alerts: TAccountAlerts,
+): Promise<{ success: true } | { success: false; errorMessage: string }> => {
+  return apiPost('accounts/alerts', { accountCode, ...alerts });
+};
+
+export const subscribeAccountAlert = (
+  cb: (alert: TAccountAlert) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('account-alert', cb);
+};
@@ -14,7 +14,7 @@
  * limitations under the License.
  */
 
-import { AccountCode, CoinCode } from './account';
+import { AccountCode, CoinCode, ScriptType, TMessageSignatureFormat } from './account';
 import { apiGet, apiPost } from '../utils/request';
 import { FailResponse, SuccessResponse } from './response';
 import { TSubscriptionCallback, subscribeEndpoint } from './subscribe';
@@ -36,16 +36,130 @@ export const getSupportedCoins = (): Promise<ICoin[]> => {
   return apiGet('supported-coins');
 };
 
-export const setAccountActive = (accountCode: AccountCode, active: boolean): Promise<ISuccess> => {
-  return apiPost('set-account-active', { accountCode, active });
+/**
+ * Returns the revision of the accounts config. Passing it to setAccountActive, setTokenActive or
+ * renameAccount makes them fail with errorCode 'configConflict' if the accounts config was
+ * changed in the meantime, e.g. by another open frontend.
+ */
+export const getAccountsConfigRevision = (): Promise<string> => {
+  return apiGet('accounts-config-revision');
+};
+
+export const setAccountActive = (accountCode: AccountCode, active: boolean, revision?: string): Promise<ISuccess> => {
+  return apiPost('set-account-active', { accountCode, active, revision });
+};
+
+export const setTokenActive = (accountCode: AccountCode, tokenCode: string, active: boolean, revision?: string): Promise<ISuccess> => {
+  return apiPost('set-token-active', { accountCode, tokenCode, active, revision });
+};
+
+export type TERC20Token = {
+  code: string;
+  name: string;
+  unit: string;
+  contractAddress: string;
+  decimals: number;
+  // custom is true if the token was added by the user by its contract address.
+  custom: boolean;
+};
+
+export const getERC20Tokens = (): Promise<TERC20Token[]> => {
+  return apiGet('eth/erc20-tokens');
+};
+
+export type TAddCustomERC20TokenResponse = {
+  success: true;
+  token: TERC20Token;
+} | {
+  success: false;
+  errorMessage?: string;
+  // errorCode is one of 'invalidContractAddress', 'erc20TokenExists', 'erc20NotAContract' or
+  // 'erc20NoMetadata'.
+  errorCode?: string;
+};
+
+export const addCustomERC20Token = (contractAddress: string): Promise<TAddCustomERC20TokenResponse> => {
+  return apiPost('eth/erc20-tokens/add', contractAddress);
 };
 
-export const setTokenActive = (accountCode: AccountCode, tokenCode: string, active: boolean): Promise<ISuccess> => {
-  return apiPost('set-token-active', { accountCode, tokenCode, active });
+export const removeCustomERC20Token = (tokenCode: string): Promise<ISuccess> => {
+  return apiPost('eth/erc20-tokens/remove', tokenCode);
 };
 
-export const renameAccount = (accountCode: AccountCode, name: string): Promise<ISuccess> => {
-  return apiPost('rename-account', { accountCode, name });
+export type TConfirmationThreshold = {
+  coinCode: CoinCode;
+  // numConfirmations is the number of confirmations after which a transaction is complete.
+  numConfirmations: number;
+  default: number;
+};
+
+export const getConfirmationThresholds = (): Promise<TConfirmationThreshold[]> => {
+  return apiGet('confirmation-thresholds');
+};
+
+// setConfirmationThreshold sets the threshold of a coin. ERC20 tokens use the threshold of ETH.
+// 0 resets it to the default.
+export const setConfirmationThreshold = (coinCode: CoinCode, numConfirmations: number): Promise<ISuccess> => {
+  return apiPost('confirmation-thresholds/set', { coinCode, numConfirmations });
+};
+
+export type TExploreAddressesInput = {
+  coinCode: CoinCode;
+  scriptType: ScriptType;
+  // keypath is the keypath of the account, e.g. "m/84'/0'/0'". The addresses are derived at
+  // <keypath>/<0 or 1 for change>/<index>.
+  keypath: string;
+  change: boolean;
+  startIndex: number;
+  // count is at most 100.
+  count: number;
+};
+
+export type TExploredAddress = {
+  keypath: string;
+  address: string;
+};
+
+export type TExploreAddressesResponse = {
+  success: true;
+  addresses: TExploredAddress[];
+} | {
+  success: false;
+  errorMessage?: string;
+  // errorCode is one of 'keystoreUnavailable', 'unsupportedScriptType', 'invalidKeypath' and
+  // 'invalidCount'.
+  errorCode?: string;
+};
+
+// exploreAddresses derives addresses of the connected keystore at an arbitrary keypath and script
+// type without adding an account, to locate funds sent to derivations the app does not use.
+export const exploreAddresses = (input: TExploreAddressesInput): Promise<TExploreAddressesResponse> => {
+  return apiPost('explore-addresses', input);
+};
+
+export type TVerifyMessageInput = {
+  coinCode: CoinCode;
+  address: string;
+  message: string;
+  signature: string;
+};
+
+export type TVerifyMessageResponse = {
+  success: true;
+  format: TMessageSignatureFormat;
+} | {
+  success: false;
+  errorMessage?: string;
+  errorCode?: 'invalidAddress' | 'invalidSignature';
+};
+
+// verifyMessage verifies a legacy or BIP322 message signature of any address.
+export const verifyMessage = (input: TVerifyMessageInput): Promise<TVerifyMessageResponse> => {
+  return apiPost('verify-message', input);
+};
+
+export const renameAccount = (accountCode: AccountCode, name: string, revision?: string): Promise<ISuccess> => {
+  return apiPost('rename-account', { accountCode, name, revision });
 };
 
 export const reinitializeAccounts = (): Promise<null> => {
@@ -107,6 +221,14 @@ export const setWatchonly = (rootFingerprint: string, watchonly: boolean): Promi
   return apiPost('set-watchonly', { rootFingerprint, watchonly });
 };
 
+/**
+ * Enables or disables the global watch-only mode, which loads all accounts without their
+ * keystore. Signing still requires connecting the keystore.
+ */
+export const setWatchonlyAll = (enabled: boolean): Promise<ISuccess> => {
+  return apiPost('set-watchonly-all', { enabled });
+};
+
 export const authenticate = (force: boolean = false): Promise<void> => {
   return apiPost('authenticate', force);
 };
@@ -132,3 +254,53 @@ export const onAuthSettingChanged = (): Promise<void> => {
 export const exportLogs = (): Promise<ISuccess> => {
   return apiPost('export-log');
 };
+
+export type TSession = {
+    keystore: {
+        rootFingerprint: string;
+        name: string;
+        type: 'hardware' | 'software';
+    } | null;
+    devices: {
+        deviceID: string;
+        productName: string;
+        status?: string;
+        pairingPending: boolean;
+    }[];
+    accounts: {
+        code: AccountCode;
+        synced: boolean;
+    }[];
+    syncing: boolean;
+    frontendState: unknown;
+};
+
+export const getSession = (): Promise<TSession> => {
+  return apiGet('session');
+};
+
+export const setFrontendSessionState = (state: unknown): Promise<ISuccess> => {
+  return apiPost('session/frontend-state', state);
+};
+
+export type TStartupTask = 'rates' | 'banners' | 'update' | 'buildInfo';
+
+export type TStartupTaskStatus = {
+  // ready is true if the task is done, also if it failed.
+  ready: boolean;
+  error: string;
+};
+
+/**
+ * Returns the readiness of the tasks the backend runs in the background at startup, which may
+ * depend on third-party servers.
+ */
+export const getStartupStatus = (): Promise<Record<TStartupTask, TStartupTaskStatus>> => {
+  return apiGet('startup-status');
+};
+
+export const subscribeStartupStatus = (
+  cb: TSubscriptionCallback<Record<TStartupTask, TStartupTaskStatus>>
+) => (
+  subscribeEndpoint('startup-status', cb)
+);
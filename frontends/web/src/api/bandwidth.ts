@@ -0,0 +1,50 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { apiGet } from '../utils/request';
+import { subscribeEndpoint, TSubscriptionCallback } from './subscribe';
+
+export type TBandwidthSubsystem = 'electrum' | 'rates' | 'explorer';
+
+// All amounts are in bytes, counted since the app started.
+export type TBandwidthUsage = {
+  subsystem: TBandwidthSubsystem;
+  // endpoint is the address of an Electrum server or the host of HTTP requests.
+  endpoint: string;
+  sent: number;
+  received: number;
+};
+
+export type TBandwidthStatus = {
+  usage: TBandwidthUsage[];
+  sent: number;
+  received: number;
+  mobileData: number;
+  // mobileDataBudget is 0 if there is no budget.
+  mobileDataBudget: number;
+  mobileDataBudgetExceeded: boolean;
+};
+
+export const getBandwidthStatus = (): Promise<TBandwidthStatus> => {
+  return apiGet('diagnostics/bandwidth');
+};
+
+export const subscribeBandwidthStatus = (
+  cb: TSubscriptionCallback<TBandwidthStatus>
+) => (
+  subscribeEndpoint('diagnostics/bandwidth', cb)
+);
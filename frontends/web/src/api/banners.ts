@@ -14,7 +14,7 @@
  * limitations under the License.
  */
 
-import { apiGet } from '../utils/request';
+import { apiGet, apiPost } from '../utils/request';
 import { statusType } from '../components/status/status';
 import { subscribeEndpoint, TUnsubscribe } from './subscribe';
 
@@ -27,6 +27,8 @@ export type TBannerInfo = {
   };
   dismissible?: boolean;
   type?: statusType;
+  // read is true if the user has seen the message, see markBannerRead().
+  read: boolean;
 }
 
 export const getBanner = (msgKey: string): Promise<TBannerInfo> => {
@@ -39,3 +41,27 @@ export const syncBanner = (
 ): TUnsubscribe => {
   return subscribeEndpoint(`banners/${msgKey}`, cb);
 };
+
+/**
+ * Returns the in-app messages targeted at this app version and platform, most severe first.
+ * Dismissed messages are omitted.
+ */
+export const getBannerMessages = (): Promise<TBannerInfo[]> => {
+  return apiGet('banners');
+};
+
+export const syncBannerMessages = (
+  cb: (banners: TBannerInfo[]) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('banners', cb);
+};
+
+type TBannerResult = { success: true } | { success: false; errorMessage: string };
+
+export const markBannerRead = (id: string): Promise<TBannerResult> => {
+  return apiPost('banners/read', id);
+};
+
+export const dismissBanner = (id: string): Promise<TBannerResult> => {
+  return apiPost('banners/dismiss', id);
+};
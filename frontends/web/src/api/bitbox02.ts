@@ -16,6 +16,8 @@
 
 import { apiGet, apiPost } from '../utils/request';
 import { SuccessResponse, FailResponse } from './response';
+import type { TUnsubscribe } from '../utils/transport-common';
+import { subscribeEndpoint } from './subscribe';
 
 // BitBox02 error codes.
 export const errUserAbort = 104;
@@ -184,4 +186,42 @@ export const invokeBIP85 = (deviceID: string): Promise<SuccessResponse | FailRes
 
 export const gotoStartupSettings = (deviceID: string) => {
   return apiPost(`devices/bitbox02/${deviceID}/goto-startup-settings`);
-};
\ No newline at end of file
+};
+export type TOperation = {
+    id: number;
+    interaction: 'sign' | 'verifyAddress' | 'verifyExtendedPublicKey' | 'registerMultisig' | 'pairing';
+    running: boolean;
+    queuedAt: string;
+};
+
+export const getOperations = (
+  deviceID: string,
+): Promise<TOperation[]> => {
+  return apiGet(`devices/bitbox02/${deviceID}/operations`);
+};
+
+export type TCapability = 'gotoStartupSettings' | 'create12Words' | 'taproot'
+  | 'backupWithRecoveryWords' | 'eip1559' | 'bip85' | 'ltc';
+
+export const getCapabilities = (
+  deviceID: string,
+): Promise<TCapability[]> => {
+  return apiGet(`devices/bitbox02/${deviceID}/capabilities`);
+};
+
+export type TDeviceCapabilities = {
+  capabilities: TCapability[];
+  // added are the capabilities the device did not have when it was last connected, e.g. after a
+  // firmware upgrade.
+  added: TCapability[];
+};
+
+/**
+ * Fires when the device connects, e.g. again after a firmware upgrade.
+ */
+export const subscribeCapabilities = (
+  deviceID: string,
+  cb: (capabilities: TDeviceCapabilities) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint(`devices/bitbox02/${deviceID}/capabilities`, cb);
+};
@@ -14,10 +14,11 @@
  * limitations under the License.
  */
 
-import { subscribeEndpoint, TSubscriptionCallback } from './subscribe';
-import type { CoinCode, Fiat } from './account';
+import { subscribeEndpoint, TSubscriptionCallback, TUnsubscribe } from './subscribe';
+import type { CoinCode, Fiat, TRatesInfo } from './account';
 import type { ISuccess } from './backend';
 import { apiPost, apiGet } from '../utils/request';
+import { apiSubscribe } from '../utils/event';
 
 export type BtcUnit = 'default' | 'sat';
 
@@ -34,6 +35,10 @@ export const subscribeCoinHeaders = (coinCode: CoinCode) => (
   )
 );
 
+export const subscribeRatesStale = (
+  cb: TSubscriptionCallback<boolean>
+) => subscribeEndpoint('rates/stale', cb);
+
 export const setBtcUnit = (unit: BtcUnit): Promise<ISuccess> => {
   return apiPost('coins/btc/set-unit', { unit });
 };
@@ -56,6 +61,9 @@ type TConvertCurrency = {
 type TConvertFromCurrencyResponse = {
   success: true;
   amount: string;
+  // the amount in the smallest unit of the coin, e.g. sat.
+  amountSmallestUnit: string;
+  ratesInfo: TRatesInfo | null;
 } | {
   success: false;
   errMsg: string; // TODO: backend should return useful errorMessage
@@ -72,6 +80,9 @@ export const convertFromCurrency = ({
 type TConvertToCurrencyResponse = {
   success: true;
   fiatAmount: string;
+  // the converted amount in the smallest unit of the coin, e.g. sat.
+  amountSmallestUnit: string;
+  ratesInfo: TRatesInfo | null;
 } | {
   success: false;
   // errMsg: string; // TODO: backend should return useful errorMessage
@@ -84,3 +95,134 @@ export const convertToCurrency = ({
 }: TConvertCurrency): Promise<TConvertToCurrencyResponse> => {
   return apiGet(`coins/convert-to-plain-fiat?from=${coinCode}&to=${fiatUnit}&amount=${amount}`);
 };
+
+type TConvertToFiatAtResponse = {
+  success: true;
+  rate: number;
+  fiatAmount: string;
+  formattedFiatAmount: string;
+  amountSmallestUnit: string;
+} | {
+  success: false;
+  errorMessage: string;
+};
+
+/**
+ * Converts the coin amount to fiat using the historical exchange rate at the given time, e.g. to
+ * show the value of a transaction when it was received. time is a unix timestamp in seconds.
+ */
+export const convertToFiatAt = (
+  coinCode: CoinCode,
+  fiatUnit: Fiat,
+  amount: string,
+  time: number,
+): Promise<TConvertToFiatAtResponse> => {
+  const params = new URLSearchParams({ coin: coinCode, fiat: fiatUnit, amount, time: time.toString() });
+  return apiGet(`coins/convert-to-fiat-at?${params.toString()}`);
+};
+
+type TValidateAddressResponse = {
+  success: true;
+  valid: boolean;
+  errorCode?: string;
+} | {
+  success: false;
+  errorMessage: string;
+};
+
+export const validateAddress = (coinCode: CoinCode, address: string): Promise<TValidateAddressResponse> => {
+  return apiGet(`coins/${coinCode}/validate-address?address=${encodeURIComponent(address)}`);
+};
+
+type TEstimateFeeResponse = {
+  success: true;
+  fee: string;
+  feeSat: number;
+  unit: string;
+} | {
+  success: false;
+  errorCode?: string;
+  errorMessage?: string;
+};
+
+export const estimateFee = (coinCode: CoinCode, vsize: number, blocks: number): Promise<TEstimateFeeResponse> => {
+  return apiGet(`coins/${coinCode}/estimate-fee?vsize=${vsize}&blocks=${blocks}`);
+};
+
+type TTipHeightResponse = {
+  success: true;
+  tipHeight: number;
+} | {
+  success: false;
+  errorMessage: string;
+};
+
+export const getTipHeight = (coinCode: CoinCode): Promise<TTipHeightResponse> => {
+  return apiGet(`coins/${coinCode}/tip-height`);
+};
+
+export type TPolicySource = 'server' | 'default';
+
+/**
+ * Relay policies of the network. Fee rates are in sat/kvB.
+ */
+export type TNetworkPolicies = {
+  minRelayFeeRate: number;
+  minRelayFeeRateSource: TPolicySource;
+  dustRelayFeeRate: number;
+  dustRelayFeeRateSource: TPolicySource;
+  maxStandardTxWeight: number;
+  maxStandardTxWeightSource: TPolicySource;
+};
+
+type TPoliciesResponse = {
+  success: true;
+  policies: TNetworkPolicies;
+} | {
+  success: false;
+  errorMessage: string;
+};
+
+export const getPolicies = (coinCode: CoinCode): Promise<TPoliciesResponse> => {
+  return apiGet(`coins/${coinCode}/policies`);
+};
+
+/**
+ * Fee rates in sat/kvB at one point in time, keyed by the confirmation target in blocks.
+ */
+export type TFeeSnapshot = {
+  time: number;
+  feeRates: Record<string, number>;
+};
+
+export type TFeeHistory = {
+  snapshots: TFeeSnapshot[];
+  summary: {
+    target: number;
+    current: number;
+    min: number;
+    median: number;
+    max: number;
+    // share of the snapshots with a lower fee rate than the current one, 0-100.
+    percentile: number;
+  } | null;
+};
+
+type TFeeHistoryResponse = {
+  success: true;
+  series: TFeeHistory;
+} | {
+  success: false;
+  errorMessage: string;
+};
+
+export const getFeeHistory = (coinCode: CoinCode, days: number): Promise<TFeeHistoryResponse> => {
+  return apiGet(`coins/${coinCode}/fee-history?days=${days}`);
+};
+
+/**
+ * Calls the callback whenever new fee rates were recorded.
+ */
+export const subscribeFeeHistory = (cb: () => void): TUnsubscribe => {
+  return apiSubscribe('fee-history', () => cb());
+};
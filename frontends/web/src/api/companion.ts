@@ -0,0 +1,116 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { apiGet, apiPost } from '../utils/request';
+import type { TUnsubscribe } from '../utils/transport-common';
+import { subscribeEndpoint } from './subscribe';
+import { SuccessResponse } from './response';
+
+type TFailure = { success: false; errorMessage: string };
+
+export type TCompanionDevice = {
+    id: string;
+    name: string;
+    pairedAt: string;
+};
+
+export type TCompanionHostStatus = {
+    running: boolean;
+    address: string;
+    pairingURI?: string;
+    devices: TCompanionDevice[];
+};
+
+export type TCompanionAccount = {
+    code: string;
+    name: string;
+    coinCode: string;
+    unit: string;
+    balance: string;
+    incoming: string;
+};
+
+export type TCompanionClientStatus = {
+    paired: boolean;
+    address?: string;
+    connected: boolean;
+    lastUpdate?: string;
+    summary?: TCompanionAccount[];
+    error?: string;
+};
+
+export type TCompanionNotification = {
+    seq: number;
+    accountName: string;
+    amount: string;
+    unit: string;
+    txID: string;
+    time: string;
+};
+
+export const getCompanionHostStatus = (): Promise<TCompanionHostStatus> => {
+  return apiGet('companion/host/status');
+};
+
+export const setCompanionHostEnabled = (
+  enabled: boolean,
+): Promise<SuccessResponse | TFailure> => {
+  return apiPost('companion/host/set-enabled', enabled);
+};
+
+export const startCompanionPairing = (): Promise<(SuccessResponse & { pairingURI: string }) | TFailure> => {
+  return apiPost('companion/host/start-pairing');
+};
+
+export const unpairCompanionDevice = (
+  id: string,
+): Promise<SuccessResponse | TFailure> => {
+  return apiPost('companion/host/unpair', id);
+};
+
+export const subscribeCompanionHostStatus = (
+  cb: (status: TCompanionHostStatus) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('companion/host/status', cb);
+};
+
+export const getCompanionClientStatus = (): Promise<TCompanionClientStatus> => {
+  return apiGet('companion/client/status');
+};
+
+type TPairFailure = {
+    success: false;
+    errorMessage?: string;
+    errorCode?: 'torOnly';
+};
+
+export const pairCompanion = (
+  pairingURI: string,
+  name: string,
+): Promise<SuccessResponse | TPairFailure> => {
+  return apiPost('companion/client/pair', { pairingURI, name });
+};
+
+export const unpairCompanion = (): Promise<SuccessResponse | TFailure> => {
+  return apiPost('companion/client/unpair');
+};
+
+export const subscribeCompanionClientStatus = (
+  cb: (status: TCompanionClientStatus) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('companion/client/status', cb);
+};
@@ -0,0 +1,56 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { apiGet, apiPost } from '../utils/request';
+import type { TUnsubscribe } from '../utils/transport-common';
+import { subscribeEndpoint } from './subscribe';
+
+export type TDataDirMoveStatus = {
+    state: 'idle' | 'moving' | 'done' | 'failed';
+    copiedBytes: number;
+    totalBytes: number;
+    error?: string;
+};
+
+export type TDataDir = {
+    path: string;
+    defaultPath: string;
+    supported: boolean;
+    moveStatus: TDataDirMoveStatus;
+    // set if the custom data directory is not available, e.g. an unmounted
+    // volume. The default data directory is used instead.
+    error?: string;
+};
+
+export const getDataDir = (): Promise<TDataDir> => {
+  return apiGet('data-dir');
+};
+
+/**
+ * Starts moving the app data to the given absolute path. Progress is reported
+ * with subscribeDataDirMove(). Once the state is 'done', the backend is closed and
+ * the app must be restarted. Until then, requests other than GET fail with the
+ * 'restartRequired' error.
+ */
+export const moveDataDir = (path: string): Promise<{ success: boolean; errorMessage?: string }> => {
+  return apiPost('data-dir/move', { path });
+};
+
+export const subscribeDataDirMove = (
+  cb: (status: TDataDirMoveStatus) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('datadir/move', cb);
+};
@@ -14,7 +14,7 @@
  * limitations under the License.
  */
 
-import { apiGet } from '../utils/request';
+import { apiGet, apiPost } from '../utils/request';
 
 export type TProductName = 'bitbox' | 'bitbox02' | 'bitbox02-bootloader';
 
@@ -26,6 +26,14 @@ export const getDeviceList = (): Promise<TDevices> => {
   return apiGet('devices/registered');
 };
 
+/**
+ * Re-enumerates the plugged in devices and registers them again, e.g. if a plugged in device was
+ * not detected.
+ */
+export const rescanDevices = (): Promise<null> => {
+  return apiPost('devices/rescan');
+};
+
 export const hasMobileChannel = (deviceID: string) => {
   return (): Promise<boolean> => {
     return apiGet(`devices/${deviceID}/has-mobile-channel`);
@@ -103,4 +103,22 @@ export const signConfirm = (
     }
   });
   return unsubscribe;
-};
\ No newline at end of file
+};
+
+export type TDeviceInteraction = 'sign' | 'verifyAddress' | 'verifyExtendedPublicKey' | 'registerMultisig' | 'pairing';
+
+/**
+ * Fires when a device is waiting for the user to confirm or abort an
+ * operation on the device.
+ * Returns a method to unsubscribe.
+ */
+export const interactionRequired = (
+  cb: (deviceID: string, interaction: TDeviceInteraction) => void,
+): TUnsubscribe => {
+  const unsubscribe = subscribeLegacy('interactionRequired', event => {
+    if (event.type === 'device' && event.deviceID) {
+      cb(event.deviceID, event.meta);
+    }
+  });
+  return unsubscribe;
+};
@@ -0,0 +1,113 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { apiGet } from '../utils/request';
+import type { CoinCode, TRatesInfo } from './account';
+import type { TStatus as THeadersStatus } from './coins';
+import { subscribeEndpoint, TSubscriptionCallback } from './subscribe';
+
+export type TDiagnoseSeverity = 'critical' | 'warning';
+
+export type TDiagnoseProblemCode = 'noConnection' | 'proxyUnreachable' | 'clockSkew'
+  | 'electrumUnreachable' | 'electrumServerDown' | 'usbPermissions' | 'checkFailed';
+
+export type TDiagnoseRemediation = 'checkInternetConnection' | 'checkProxySettings' | 'syncClock'
+  | 'checkServerSettings' | 'installUdevRules' | 'reconnectDevice' | 'retry';
+
+export type TDiagnoseProblem = {
+  code: TDiagnoseProblemCode;
+  severity: TDiagnoseSeverity;
+  remediation: TDiagnoseRemediation;
+  // subject is what the problem is about, e.g. a server address or a coin code.
+  subject?: string;
+  // details is the error message, for support.
+  details?: string;
+};
+
+export type TDiagnoseCheck = {
+  name: string;
+  passed: boolean;
+  durationMs: number;
+};
+
+export type TDiagnoseReport = {
+  // problems are ordered by severity, most severe first.
+  problems: TDiagnoseProblem[];
+  checks: TDiagnoseCheck[];
+};
+
+/**
+ * Runs the connectivity, server and device checks. This can take a while, as the backend connects
+ * to the configured servers.
+ */
+export const diagnose = (): Promise<TDiagnoseReport> => {
+  return apiGet('diagnose');
+};
+
+export type TClockSkew = {
+  skewed: boolean;
+  // seconds is the local time minus the reference time, only valid if skewed is true.
+  seconds: number;
+  // source is the reference clock, empty if the clock was not checked yet.
+  source: '' | 'server' | 'blockHeaders';
+};
+
+export const getClockSkew = (): Promise<TClockSkew> => {
+  return apiGet('clock-skew');
+};
+
+export const subscribeClockSkew = (
+  cb: TSubscriptionCallback<TClockSkew>
+) => (
+  subscribeEndpoint('clock-skew', cb)
+);
+
+export type TCoinStatus = {
+  code: CoinCode;
+  name: string;
+  connected: boolean;
+  connectionError: string;
+  // server is the connected Electrum server, empty if not connected and for Ethereum.
+  server: string;
+  // latencyMs is 0 if unknown.
+  latencyMs: number;
+  // tipHeight is 0 if unknown.
+  tipHeight: number;
+  // headers is null for coins which are not Bitcoin-based.
+  headers: THeadersStatus | null;
+};
+
+export type TDeviceStatus = {
+  id: string;
+  productName: string;
+  // status is the firmware status of a BitBox02, empty for other devices.
+  status: string;
+};
+
+export type TAppStatus = {
+  coins: TCoinStatus[];
+  // rates is null if the rates have not been fetched yet.
+  rates: TRatesInfo | null;
+  devices: TDeviceStatus[];
+};
+
+/**
+ * Returns the connection state of the coins, the freshness of the rates and the state of the
+ * devices in one call, for the status screen.
+ */
+export const getStatus = (): Promise<TAppStatus> => {
+  return apiGet('status');
+};
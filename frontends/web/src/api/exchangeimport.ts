@@ -0,0 +1,43 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { apiPost } from '../utils/request';
+
+export type TExchangeImportResult = {
+    matched: number;
+    unmatched: number;
+    // withdrawals without txid to an address which received several transactions.
+    ambiguous: number;
+};
+
+type TExchangeImportResponse = {
+    success: true;
+    result: TExchangeImportResult;
+} | {
+    success: false;
+    errorMessage: string;
+};
+
+/**
+ * Tags the received transactions matching the withdrawals of the CSV withdrawal history of an
+ * exchange with their origin. `csv` is the content of the file.
+ */
+export const importExchangeWithdrawals = (
+  exchange: string,
+  csv: string,
+): Promise<TExchangeImportResponse> => {
+  return apiPost('exchange-withdrawals/import', { exchange, csv });
+};
@@ -66,7 +66,7 @@ export const getMoonpayBuyInfo = (code: AccountCode) => {
 export type AddressVerificationResponse = {
   success: boolean;
   errorMessage?: string;
-  errorCode?: 'addressNotFound' | 'userAbort';
+  errorCode?: 'addressNotFound' | 'userAbort' | 'deviceBusy';
 }
 
 export const verifyAddress = (address: string, accountCode: AccountCode): Promise<AddressVerificationResponse> => {
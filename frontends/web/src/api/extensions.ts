@@ -0,0 +1,92 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { apiGet, apiPost } from '../utils/request';
+import type { TUnsubscribe } from '../utils/transport-common';
+import { subscribeEndpoint } from './subscribe';
+import { SuccessResponse } from './response';
+
+export type TExtensionPermission = 'portfolio:read' | 'rates:read' | 'widgets:write';
+
+export type TExtension = {
+    id: string;
+    name: string;
+    permissions: TExtensionPermission[];
+    createdAt: string;
+};
+
+export type TExtensionsStatus = {
+    running: boolean;
+    address: string;
+    extensions: TExtension[];
+};
+
+export type TExtensionWidget = {
+    extensionId: string;
+    extensionName: string;
+    title: string;
+    lines: { label: string; value: string }[];
+    updatedAt: string;
+};
+
+export const getExtensionsStatus = (): Promise<TExtensionsStatus> => {
+  return apiGet('extensions/status');
+};
+
+export const setExtensionsEnabled = (
+  enabled: boolean,
+): Promise<SuccessResponse | { success: false; errorMessage: string }> => {
+  return apiPost('extensions/set-enabled', enabled);
+};
+
+export type TRegisterExtensionResponse = {
+  success: true;
+  extension: TExtension;
+  token: string;
+} | {
+  success: false;
+  errorMessage: string;
+};
+
+export const registerExtension = (
+  name: string,
+  permissions: TExtensionPermission[],
+): Promise<TRegisterExtensionResponse> => {
+  return apiPost('extensions/register', { name, permissions });
+};
+
+export const revokeExtension = (
+  id: string,
+): Promise<SuccessResponse | { success: false; errorMessage: string }> => {
+  return apiPost('extensions/revoke', id);
+};
+
+export const subscribeExtensionsStatus = (
+  cb: (status: TExtensionsStatus) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('extensions/status', cb);
+};
+
+export const getExtensionWidgets = (): Promise<TExtensionWidget[]> => {
+  return apiGet('extensions/widgets');
+};
+
+export const subscribeExtensionWidgets = (
+  cb: (widgets: TExtensionWidget[]) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('extensions/widgets', cb);
+};
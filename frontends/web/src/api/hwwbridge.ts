@@ -0,0 +1,61 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { apiGet, apiPost } from '../utils/request';
+import type { TUnsubscribe } from '../utils/transport-common';
+import { subscribeEndpoint } from './subscribe';
+import { SuccessResponse } from './response';
+
+export type THWWBridgeClient = {
+    id: string;
+    name: string;
+    origin?: string;
+    // code is shown by the external app while its pairing is pending.
+    code?: string;
+};
+
+export type THWWBridgeStatus = {
+    running: boolean;
+    address: string;
+    pending: THWWBridgeClient[];
+    approved: THWWBridgeClient[];
+};
+
+export const getHWWBridgeStatus = (): Promise<THWWBridgeStatus> => {
+  return apiGet('hww-bridge/status');
+};
+
+export const setHWWBridgeEnabled = (
+  enabled: boolean,
+): Promise<SuccessResponse | { success: false; errorMessage: string }> => {
+  return apiPost('hww-bridge/set-enabled', enabled);
+};
+
+/**
+ * Confirms or denies a pending pairing. Passing approved=false for an approved app revokes it.
+ */
+export const setHWWBridgeApproval = (
+  id: string,
+  approved: boolean,
+): Promise<{ success: boolean }> => {
+  return apiPost('hww-bridge/set-approval', { id, approved });
+};
+
+export const subscribeHWWBridgeStatus = (
+  cb: (status: THWWBridgeStatus) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('hww-bridge/status', cb);
+};
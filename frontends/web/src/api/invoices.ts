@@ -0,0 +1,78 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import type { AccountCode } from './account';
+import { apiGet, apiPost } from '../utils/request';
+import type { TUnsubscribe } from '../utils/transport-common';
+import { subscribeEndpoint } from './subscribe';
+
+export type TInvoiceStatus = 'open' | 'paid' | 'expired';
+
+export type TInvoice = {
+    id: string;
+    accountCode: AccountCode;
+    address: string;
+    // amount and received are in the smallest unit of the coin, e.g. satoshi.
+    amount: number;
+    received: number;
+    memo: string;
+    callbackURL: string;
+    status: TInvoiceStatus;
+    createdAt: string;
+    expiresAt: string;
+    paidAt: string | null;
+    formattedAmount: string;
+    formattedReceived: string;
+    unit: string;
+};
+
+export type TCreateInvoiceArgs = {
+    accountCode: AccountCode;
+    amount: string;
+    memo: string;
+    expiryMinutes: number;
+    callbackURL: string;
+};
+
+type TInvoiceResult = { success: true } | { success: false; errorMessage: string };
+
+export const getInvoices = (): Promise<TInvoice[]> => {
+  return apiGet('invoices');
+};
+
+export const createInvoice = (
+  args: TCreateInvoiceArgs,
+): Promise<{ success: true; invoice: TInvoice } | { success: false; errorMessage: string }> => {
+  return apiPost('invoices/create', args);
+};
+
+export const updateInvoice = (
+  id: string,
+  memo: string,
+  callbackURL: string,
+): Promise<TInvoiceResult> => {
+  return apiPost('invoices/update', { id, memo, callbackURL });
+};
+
+export const deleteInvoice = (id: string): Promise<TInvoiceResult> => {
+  return apiPost('invoices/delete', id);
+};
+
+export const subscribeInvoices = (
+  cb: (invoices: TInvoice[]) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('invoices', cb);
+};
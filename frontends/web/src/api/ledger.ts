@@ -0,0 +1,29 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { apiPost } from '../utils/request';
+
+export type TLedgerFormat = 'beancount' | 'ledger';
+
+/**
+ * Exports the transactions of all accounts as a double-entry ledger file chosen by the user,
+ * grouped by account and by the categories of the transactions.
+ */
+export const exportLedger = (
+  format: TLedgerFormat,
+): Promise<{ success: true } | { success: false; errorMessage: string }> => {
+  return apiPost('reports/ledger', { format });
+};
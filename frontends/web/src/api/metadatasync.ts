@@ -0,0 +1,64 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { apiGet, apiPost } from '../utils/request';
+import type { TUnsubscribe } from '../utils/transport-common';
+import { subscribeEndpoint } from './subscribe';
+import { SuccessResponse } from './response';
+
+export type TMetadataSyncStore = 'file' | 'webdav';
+
+export type TMetadataSyncStatus = {
+    enabled: boolean;
+    store: TMetadataSyncStore;
+    running: boolean;
+    numEntries: number;
+    lastSync: string | null;
+    lastError: string;
+    conflicts: number;
+};
+
+export type TMetadataSyncArgs = {
+    enabled: boolean;
+    store: TMetadataSyncStore;
+    path: string;
+    url: string;
+    username: string;
+    // an empty password or passphrase keeps the current one.
+    password: string;
+    passphrase: string;
+};
+
+export const getMetadataSyncStatus = (): Promise<TMetadataSyncStatus> => {
+  return apiGet('metadata-sync/status');
+};
+
+export const setMetadataSync = (
+  args: TMetadataSyncArgs,
+): Promise<SuccessResponse | { success: false; errorMessage: string }> => {
+  return apiPost('metadata-sync/update', args);
+};
+
+export const runMetadataSync = (): Promise<SuccessResponse | { success: false; errorMessage: string }> => {
+  return apiPost('metadata-sync/run');
+};
+
+export const subscribeMetadataSyncStatus = (
+  cb: (status: TMetadataSyncStatus) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('metadata-sync/status', cb);
+};
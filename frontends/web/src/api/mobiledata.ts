@@ -26,3 +26,20 @@ export const subscribeUsingMobileData = (
 ) => (
   subscribeEndpoint('using-mobile-data', cb)
 );
+
+export type TMobileDataSaver = {
+  // enabled is the user setting.
+  enabled: boolean;
+  // active is true if enabled and the user is on mobile data.
+  active: boolean;
+};
+
+export const getMobileDataSaver = (): Promise<TMobileDataSaver> => {
+  return apiGet('mobile-data-saver');
+};
+
+export const subscribeMobileDataSaver = (
+  cb: TSubscriptionCallback<TMobileDataSaver>
+) => (
+  subscribeEndpoint('mobile-data-saver', cb)
+);
@@ -14,7 +14,9 @@
  * limitations under the License.
  */
 
-import { apiPost } from '../utils/request';
+import { apiGet, apiPost } from '../utils/request';
+import type { TUnsubscribe } from '../utils/transport-common';
+import { subscribeEndpoint } from './subscribe';
 import { SuccessResponse } from './response';
 
 type TCertResponse = {
@@ -29,10 +31,21 @@ export const downloadCert = (electrumServer: string): Promise<TCertResponse> =>
   return apiPost('certs/download', electrumServer);
 };
 
+export type TSSHTunnel = {
+  address: string;
+  username: string;
+  privateKeyPath: string;
+  privateKeyPassphrase: string;
+  hostKey: string;
+};
+
 export type TElectrumServer = {
   server: string;
   tls: boolean;
   pemCert: string;
+  sshTunnel?: TSSHTunnel;
+  // preferred makes the app connect to this server first instead of a random one.
+  preferred?: boolean;
 };
 
 type TCheckElectrumResponse = SuccessResponse | {
@@ -43,3 +56,134 @@ type TCheckElectrumResponse = SuccessResponse | {
 export const checkElectrum = (server: TElectrumServer): Promise<TCheckElectrumResponse> => {
   return apiPost('electrum/check', server);
 };
+
+export type TCertificateInfo = {
+  subject: string;
+  issuer: string;
+  fingerprint: string;
+  notBefore: string;
+  notAfter: string;
+  isCA: boolean;
+};
+
+export type TPinnedCertificate = {
+  coinCode: 'btc' | 'tbtc' | 'rbtc' | 'ltc' | 'tltc';
+  server: string;
+  tls: boolean;
+  certificate: TCertificateInfo | null;
+  expired: boolean;
+};
+
+export const getPinnedCerts = (): Promise<TPinnedCertificate[]> => {
+  return apiGet('certs/pinned');
+};
+
+export type TPinnedCertificateCheck = TPinnedCertificate & {
+  remote: TCertificateInfo;
+  matches: boolean;
+  verifyError?: string;
+};
+
+type TCertsCheckResponse = {
+  success: true;
+  check: TPinnedCertificateCheck;
+} | {
+  success: false;
+  errorMessage?: string;
+  errorCode?: 'serverNotFound';
+};
+
+export const checkPinnedCert = (
+  coinCode: TPinnedCertificate['coinCode'],
+  server: string,
+): Promise<TCertsCheckResponse> => {
+  return apiPost('certs/check', { coinCode, server });
+};
+
+type TCertsRepinResponse = SuccessResponse | {
+  success: false;
+  errorMessage?: string;
+  errorCode?: 'serverNotFound' | 'certificateChanged';
+};
+
+/**
+ * Pins the certificate the server currently presents. `fingerprint` is the fingerprint of the
+ * remote certificate returned by `checkPinnedCert` and approved by the user.
+ * The new certificate is used after restarting the app.
+ */
+export const repinCert = (
+  coinCode: TPinnedCertificate['coinCode'],
+  server: string,
+  fingerprint: string,
+): Promise<TCertsRepinResponse> => {
+  return apiPost('certs/repin', { coinCode, server, fingerprint });
+};
+
+export type TElectrumServerBenchmark = {
+  server: string;
+  preferred: boolean;
+  connectMs: number;
+  pingMs: number;
+  tipHeight: number;
+  // behind is true if the tip of the server is behind the tip of other servers.
+  behind: boolean;
+  // error is empty if the server could be benchmarked.
+  error: string;
+};
+
+type TElectrumBenchmarkResponse = {
+  success: true;
+  // servers are ranked from the best to the worst.
+  servers: TElectrumServerBenchmark[];
+  applied: boolean;
+} | {
+  success: false;
+  servers?: TElectrumServerBenchmark[];
+  errorMessage?: string;
+  errorCode?: 'serverNotFound';
+};
+
+/**
+ * Benchmarks the configured Electrum servers of the coin. If `apply` is true, the best server is
+ * made the preferred one, which is used after restarting the app.
+ */
+export const benchmarkElectrumServers = (
+  coinCode: TPinnedCertificate['coinCode'],
+  apply: boolean,
+): Promise<TElectrumBenchmarkResponse> => {
+  return apiPost('electrum/benchmark', { coinCode, apply });
+};
+
+export type TSSHTunnelStatus = {
+  address: string;
+  username: string;
+  servers: string[];
+  state: 'idle' | 'connecting' | 'connected' | 'error';
+  error?: string;
+  connectedAt?: string;
+};
+
+export const getSSHTunnelsStatus = (): Promise<TSSHTunnelStatus[]> => {
+  return apiGet('ssh-tunnels/status');
+};
+
+export const subscribeSSHTunnelsStatus = (
+  cb: (status: TSSHTunnelStatus[]) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('ssh-tunnels/status', cb);
+};
+
+type THostKeyResponse = {
+  success: true;
+  hostKey: string;
+} | {
+  success: false;
+  errorMessage: string;
+};
+
+/**
+ * Fetches the host key of an SSH server, to be confirmed by the user and pinned in the tunnel.
+ */
+export const fetchSSHHostKey = (address: string): Promise<THostKeyResponse> => {
+  return apiPost('ssh-tunnels/host-key', address);
+};
@@ -0,0 +1,84 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { apiGet, apiPost } from '../utils/request';
+import type { TUnsubscribe } from '../utils/transport-common';
+import { subscribeEndpoint } from './subscribe';
+import { SuccessResponse } from './response';
+
+export type TPairingScope = 'read-only' | 'full';
+
+export type TPairingRequestStatus = 'pending' | 'approved' | 'rejected' | 'failed' | 'expired';
+
+export type TPairingRequest = {
+  id: string;
+  name: string;
+  scope: TPairingScope;
+  // code is shown by the remote frontend and must match the code shown on the BitBox.
+  code: string;
+  status: TPairingRequestStatus;
+  // error is an error code if the status is 'failed'.
+  error?: string;
+  // token is the issued API token, only returned once after the pairing was approved.
+  token?: string;
+};
+
+export type TPairedClient = {
+  id: string;
+  name: string;
+  scope: TPairingScope;
+  createdAt: string;
+  message: string;
+  signature: string;
+};
+
+export type TPairingRequestResponse = {
+  success: true;
+  request: TPairingRequest;
+} | {
+  success: false;
+  errorCode?: string;
+  errorMessage?: string;
+};
+
+// requestPairing is called by a remote frontend without a token, see also getPairingRequest.
+export const requestPairing = (
+  name: string,
+  scope: TPairingScope,
+): Promise<TPairingRequestResponse> => {
+  return apiPost('pairing/request', { name, scope });
+};
+
+export const getPairingRequest = (id: string): Promise<TPairingRequestResponse> => {
+  return apiGet(`pairing/request/${id}`);
+};
+
+export const getPairedClients = (): Promise<TPairedClient[]> => {
+  return apiGet('pairing/clients');
+};
+
+export const subscribePairedClients = (
+  cb: (clients: TPairedClient[]) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('pairing/clients', cb);
+};
+
+export const revokePairedClient = (
+  id: string,
+): Promise<SuccessResponse | { success: false; errorMessage: string }> => {
+  return apiPost('pairing/clients/revoke', id);
+};
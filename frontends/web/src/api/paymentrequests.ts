@@ -0,0 +1,52 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import type { AccountCode } from './account';
+import { apiGet, apiPost } from '../utils/request';
+import type { TUnsubscribe } from '../utils/transport-common';
+import { subscribeEndpoint } from './subscribe';
+
+export type TPaymentRequest = {
+    id: number;
+    uri: string;
+    scheme: 'bitcoin' | 'litecoin' | 'ethereum';
+    address: string;
+    amount: string;
+    label: string;
+    message: string;
+    // chainID, gasLimit and tokenContract are only set for `ethereum:` URIs which specify them.
+    // For ERC20 transfers, address is the recipient of the tokens and accountCodes are the
+    // accounts of the token.
+    chainID?: number;
+    gasLimit?: number;
+    tokenContract?: string;
+    accountCodes: AccountCode[];
+};
+
+export const getPaymentRequests = (): Promise<TPaymentRequest[]> => {
+  return apiGet('payment-requests');
+};
+
+export const dismissPaymentRequest = (id: number): Promise<{ success: boolean }> => {
+  return apiPost('payment-requests/dismiss', id);
+};
+
+export const subscribePaymentRequests = (
+  cb: (requests: TPaymentRequest[]) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('payment-requests', cb);
+};
@@ -0,0 +1,61 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { apiGet, apiPost } from '../utils/request';
+import type { TUnsubscribe } from '../utils/transport-common';
+import { subscribeEndpoint } from './subscribe';
+
+export type TProfile = {
+    id: string;
+    name: string;
+};
+
+/**
+ * The default profile has an empty ID and is not listed in profiles.
+ */
+export type TProfiles = {
+    supported: boolean;
+    // running is the ID of the profile in use.
+    running: string;
+    // selected is the ID of the profile used from the next app start on.
+    selected: string;
+    profiles: TProfile[];
+};
+
+export const getProfiles = (): Promise<TProfiles> => {
+  return apiGet('profiles');
+};
+
+export const addProfile = (
+  name: string,
+): Promise<{ success: true; profile: TProfile } | { success: false; errorMessage: string }> => {
+  return apiPost('profiles/add', name);
+};
+
+/**
+ * Selects the profile used from the next app start on. The app must be
+ * restarted if the selected profile differs from the running one.
+ */
+export const selectProfile = (id: string): Promise<{ success: boolean; errorMessage?: string }> => {
+  return apiPost('profiles/select', id);
+};
+
+export const subscribeProfiles = (
+  cb: (profiles: TProfiles) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('profiles', cb);
+};
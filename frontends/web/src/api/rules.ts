@@ -0,0 +1,112 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import type { AccountCode } from './account';
+import { apiGet, apiPost } from '../utils/request';
+import type { TUnsubscribe } from '../utils/transport-common';
+import { subscribeEndpoint } from './subscribe';
+
+export type TRuleTrigger = 'balance' | 'incomingTx' | 'outgoingTx';
+
+// An empty operator matches any amount. Balance rules need an operator.
+export type TRuleOperator = '' | 'above' | 'below';
+
+export type TRuleAction = 'notify' | 'draft' | 'webhook';
+
+export type TRuleArgs = {
+    name: string;
+    enabled: boolean;
+    accountCode: AccountCode;
+    trigger: TRuleTrigger;
+    operator: TRuleOperator;
+    // amount is in the unit of the account, e.g. "0.5" for BTC.
+    amount: string;
+    actions: TRuleAction[];
+    // webhookID is the webhook called by the webhook action.
+    webhookID: string;
+};
+
+export type TRule = TRuleArgs & {
+    id: string;
+    unit: string;
+};
+
+export type TRuleFired = {
+    ruleID: string;
+    ruleName: string;
+    accountCode: AccountCode;
+    accountName: string;
+    trigger: TRuleTrigger;
+    amount: string;
+    unit: string;
+    txID?: string;
+};
+
+/**
+ * A consolidation of all coins of the account into a new address of the same account, to be
+ * reviewed and signed by the user in the send screen.
+ */
+export type TRuleDraft = {
+    id: number;
+    ruleID: string;
+    ruleName: string;
+    accountCode: AccountCode;
+    accountName: string;
+    amount: string;
+    unit: string;
+    createdAt: string;
+};
+
+type TRuleResult = { success: true } | { success: false; errorMessage: string };
+
+export const getRules = (): Promise<TRule[]> => {
+  return apiGet('rules');
+};
+
+export const addRule = (
+  args: TRuleArgs,
+): Promise<{ success: true; id: string } | { success: false; errorMessage: string }> => {
+  return apiPost('rules/add', args);
+};
+
+export const updateRule = (id: string, args: TRuleArgs): Promise<TRuleResult> => {
+  return apiPost('rules/update', { id, ...args });
+};
+
+export const deleteRule = (id: string): Promise<TRuleResult> => {
+  return apiPost('rules/delete', id);
+};
+
+export const getRuleDrafts = (): Promise<TRuleDraft[]> => {
+  return apiGet('rules/drafts');
+};
+
+export const dismissRuleDraft = (id: number): Promise<TRuleResult> => {
+  return apiPost('rules/drafts/dismiss', id);
+};
+
+export const subscribeRuleDrafts = (
+  cb: (drafts: TRuleDraft[]) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('rules/drafts', cb);
+};
+
+export const subscribeRuleFired = (
+  cb: (fired: TRuleFired) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint('rule-fired', cb);
+};
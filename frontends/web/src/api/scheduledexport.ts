@@ -0,0 +1,69 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { apiGet, apiPost } from '../utils/request';
+import { TExportFormat } from './account';
+import { subscribeEndpoint, TSubscriptionCallback } from './subscribe';
+
+export type TScheduledExportInterval = 'daily' | 'weekly';
+
+export type TScheduledExportStatus = {
+  enabled: boolean;
+  directory: string;
+  interval: TScheduledExportInterval;
+  format: TExportFormat;
+  hasBackupPassword: boolean;
+  running: boolean;
+  // nextRun is null if the scheduled exports are disabled.
+  nextRun: string | null;
+  lastRun: string | null;
+  lastSuccess: string | null;
+  lastError: string;
+  // lastFiles are the files written by the last successful run.
+  lastFiles: string[] | null;
+  // skippedAccounts is the number of accounts which were not synced during the last run.
+  skippedAccounts: number;
+};
+
+export type TScheduledExportArgs = {
+  enabled: boolean;
+  directory: string;
+  interval: TScheduledExportInterval;
+  format: TExportFormat;
+  // An empty password keeps the current one, unless clearBackupPassword is true.
+  backupPassword: string;
+  clearBackupPassword: boolean;
+};
+
+type TScheduledExportResult = { success: true } | { success: false; errorMessage: string };
+
+export const getScheduledExportStatus = (): Promise<TScheduledExportStatus> => {
+  return apiGet('diagnostics/scheduled-export');
+};
+
+export const subscribeScheduledExportStatus = (
+  cb: TSubscriptionCallback<TScheduledExportStatus>
+) => (
+  subscribeEndpoint('diagnostics/scheduled-export', cb)
+);
+
+export const setScheduledExport = (args: TScheduledExportArgs): Promise<TScheduledExportResult> => {
+  return apiPost('scheduled-export/update', args);
+};
+
+export const runScheduledExport = (): Promise<TScheduledExportResult> => {
+  return apiPost('scheduled-export/run');
+};
@@ -23,3 +23,14 @@ export const notifyUser = (text: string) => {
 export const open = (href: string) => {
   return apiPost('open', href);
 };
+
+export type TAppActivity = {
+  // visible is false if the app is hidden, e.g. minimized or in the background.
+  visible: boolean;
+  // idle is true if the user did not interact with the app for a while.
+  idle: boolean;
+};
+
+export const setAppActivity = (activity: TAppActivity) => {
+  return apiPost('app-activity', activity);
+};
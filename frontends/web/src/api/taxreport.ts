@@ -0,0 +1,76 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { apiGet, apiPost } from '../utils/request';
+
+export type TTaxReportMethod = 'fifo' | 'lifo';
+
+export type TTaxReportDisposal = {
+  coin: string;
+  accountCode: string;
+  txID: string;
+  disposedAt: string;
+  // acquiredAt is null if the disposed coins could not be matched with a known acquisition.
+  acquiredAt: string | null;
+  amount: string;
+  proceeds: string;
+  costBasis: string;
+  gain: string;
+  // incomplete is true if the cost basis or the proceeds are not known and counted as zero.
+  incomplete: boolean;
+};
+
+export type TTaxReport = {
+  method: TTaxReportMethod;
+  fiat: string;
+  // year 0 means all years.
+  year: number;
+  disposals: TTaxReportDisposal[];
+  proceeds: string;
+  costBasis: string;
+  gain: string;
+};
+
+export type TTaxReportResult = {
+  success: true;
+  report: TTaxReport;
+  // skippedAccounts is the number of accounts which are not synced and not in the report.
+  skippedAccounts: number;
+} | {
+  success: false;
+  errorMessage: string;
+};
+
+export const getTaxReport = (
+  method: TTaxReportMethod,
+  year?: number,
+): Promise<TTaxReportResult> => {
+  const params = new URLSearchParams({ method });
+  if (year) {
+    params.set('year', String(year));
+  }
+  return apiGet(`reports/tax?${params.toString()}`);
+};
+
+/**
+ * Exports the tax report as a CSV file chosen by the user.
+ */
+export const exportTaxReport = (
+  method: TTaxReportMethod,
+  year?: number,
+): Promise<{ success: true } | { success: false; errorMessage: string }> => {
+  return apiPost('reports/tax', { method, year: year || 0 });
+};
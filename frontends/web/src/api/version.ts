@@ -15,6 +15,7 @@
  */
 
 import { apiGet } from '../utils/request';
+import { subscribeEndpoint, TSubscriptionCallback } from './subscribe';
 
 /**
  * Describes the file that is loaded from 'https://bitbox.swiss/updates/desktop.json'.
@@ -29,6 +30,37 @@ export const getVersion = (): Promise<string> => {
   return apiGet('version');
 };
 
+/**
+ * Returns the result of the update check run in the background at startup, null until it is
+ * done or if there is no update.
+ */
 export const getUpdate = (): Promise<TUpdateFile | null> => {
   return apiGet('update');
 };
+
+export const subscribeUpdate = (
+  cb: TSubscriptionCallback<TUpdateFile | null>
+) => (
+  subscribeEndpoint('update', cb)
+);
+
+export type TBuildVerification = 'unavailable' | 'match' | 'mismatch' | 'invalidSignature';
+
+export type TBuildInfo = {
+  version: string;
+  // empty if unknown.
+  commit: string;
+  commitTime: string;
+  // true if built with uncommitted changes.
+  modified: boolean;
+  buildTime: string;
+  goVersion: string;
+  // hex encoded SHA256 hash of the executable.
+  binaryHash: string;
+  // result of comparing binaryHash with the signed hashes of the published release.
+  verification: TBuildVerification;
+};
+
+export const getBuildInfo = (): Promise<TBuildInfo> => {
+  return apiGet('buildinfo');
+};
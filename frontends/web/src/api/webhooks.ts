@@ -0,0 +1,57 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { apiGet, apiPost } from '../utils/request';
+
+export type TWebhookEvent = 'incomingTx' | 'txConfirmed' | 'lowBalance' | 'largeOutflow';
+
+export type TWebhook = {
+    id: string;
+    url: string;
+    hasSecret: boolean;
+    events: TWebhookEvent[];
+};
+
+export type TWebhookArgs = {
+    url: string;
+    // An empty secret keeps the current one when updating a webhook.
+    secret: string;
+    events: TWebhookEvent[];
+};
+
+type TWebhookResult = { success: true } | { success: false; errorMessage: string };
+
+export const getWebhooks = (): Promise<TWebhook[]> => {
+  return apiGet('webhooks');
+};
+
+export const addWebhook = (
+  args: TWebhookArgs,
+): Promise<{ success: true; id: string } | { success: false; errorMessage: string }> => {
+  return apiPost('webhooks/add', args);
+};
+
+export const updateWebhook = (id: string, args: TWebhookArgs): Promise<TWebhookResult> => {
+  return apiPost('webhooks/update', { id, ...args });
+};
+
+export const deleteWebhook = (id: string): Promise<TWebhookResult> => {
+  return apiPost('webhooks/delete', id);
+};
+
+export const testWebhook = (id: string): Promise<TWebhookResult> => {
+  return apiPost('webhooks/test', id);
+};
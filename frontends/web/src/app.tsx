@@ -21,18 +21,24 @@ import { useNavigate } from 'react-router';
 import { useSync } from './hooks/api';
 import { useDefault } from './hooks/default';
 import { usePrevious } from './hooks/previous';
+import { useAppActivity } from './hooks/activity';
 import { AppRouter } from './routes/router';
 import { Wizard as BitBox02Wizard } from './routes/device/bitbox02/wizard';
 import { getAccounts } from './api/account';
+import { getDataDir } from './api/datadir';
 import { syncAccountsList } from './api/accountsync';
 import { getDeviceList } from './api/devices';
-import { syncDeviceList } from './api/devicessync';
+import { interactionRequired, syncDeviceList } from './api/devicessync';
 import { syncNewTxs } from './api/transactions';
 import { notifyUser } from './api/system';
+import { subscribeAccountAlert } from './api/alerts';
+import { subscribeRuleFired } from './api/rules';
+import { TPaymentRequest, getPaymentRequests, subscribePaymentRequests } from './api/paymentrequests';
 import { ConnectedApp } from './connected';
-import { Alert } from './components/alert/Alert';
+import { Alert, alertUser } from './components/alert/Alert';
 import { Aopp } from './components/aopp/aopp';
-import { Banner } from './components/banner/banner';
+import { Banner, BannerMessages } from './components/banner/banner';
+import { ClockSkewWarning } from './components/clockskewwarning';
 import { Confirm } from './components/confirm/Confirm';
 import { KeystoreConnectPrompt } from './components/keystoreconnectprompt';
 import { MobileDataWarning } from './components/mobiledatawarning';
@@ -53,6 +59,8 @@ export const App = () => {
 
   const prevDevices = usePrevious(devices);
 
+  useAppActivity();
+
   useEffect(() => {
     return syncNewTxs((meta) => {
       notifyUser(t('notification.newTxs', {
@@ -62,6 +70,60 @@ export const App = () => {
     });
   }, [t]);
 
+  useEffect(() => {
+    return subscribeAccountAlert(({ type, accountName, amount, threshold, unit }) => {
+      notifyUser(t(`notification.${type}`, {
+        accountName,
+        amount: `${amount} ${unit}`,
+        threshold: `${threshold} ${unit}`,
+      }));
+    });
+  }, [t]);
+
+  // A custom data directory on a drive which is not connected is not silently replaced by the
+  // default one.
+  useEffect(() => {
+    getDataDir().then(({ error }) => {
+      if (error) {
+        alertUser(t('dataDir.unavailable', { error }));
+      }
+    }).catch(console.error);
+  }, [t]);
+
+  // The app might be in the background, so the user is notified to look at the device.
+  useEffect(() => {
+    return interactionRequired(() => {
+      notifyUser(t('notification.interactionRequired'));
+    });
+  }, [t]);
+
+  useEffect(() => {
+    return subscribeRuleFired(({ ruleName, accountName, amount, unit }) => {
+      notifyUser(t('notification.ruleFired', {
+        ruleName,
+        accountName,
+        amount: `${amount} ${unit}`,
+      }));
+    });
+  }, [t]);
+
+  // Payment URIs opened through the OS are routed to the send screen of the first account which can
+  // pay them. The send screen then consumes the request.
+  const hasAccounts = accounts.length > 0;
+  useEffect(() => {
+    if (!hasAccounts) {
+      return;
+    }
+    const routeToSend = (requests: TPaymentRequest[]) => {
+      const request = requests.find(({ accountCodes }) => accountCodes.length > 0);
+      if (request) {
+        navigate(`/account/${request.accountCodes[0]}/send`);
+      }
+    };
+    getPaymentRequests().then(routeToSend).catch(console.error);
+    return subscribePaymentRequests(routeToSend);
+  }, [hasAccounts, navigate]);
+
   const maybeRoute = useCallback(() => {
     const currentURL = window.location.pathname;
     const isIndex = currentURL === '/' || currentURL === '/index.html' || currentURL === '/android_asset/web/index.html' || currentURL.endsWith('/assets/web/index.html');
@@ -157,7 +219,9 @@ export const App = () => {
             <Update />
             <Banner msgKey="bitbox01" />
             <Banner msgKey="bitbox02" />
+            <BannerMessages />
             <MobileDataWarning />
+            <ClockSkewWarning />
             <WCSigningRequest />
             <Aopp />
             <KeystoreConnectPrompt />
@@ -17,6 +17,7 @@
 import { useState } from 'react';
 import { useTranslation } from 'react-i18next';
 import * as accountAPI from '../../api/account';
+import { alertUser } from '../alert/Alert';
 import { Button } from '../forms';
 import { WaitDialog } from '../wait-dialog/wait-dialog';
 
@@ -31,8 +32,11 @@ export const VerifyAddress = ({ accountCode, address, addressID }: TProps) => {
   const { t } = useTranslation();
   const verifyAddress = async () => {
     setVerifying(true);
-    await accountAPI.verifyAddress(accountCode, addressID);
+    const result = await accountAPI.verifyAddress(accountCode, addressID);
     setVerifying(false);
+    if (!result.success && result.errorCode === 'deviceBusy') {
+      alertUser(t('error.deviceBusy'));
+    }
   };
 
   return <div className="flex flex-column">
@@ -17,7 +17,7 @@
 
 import { useEffect, useState } from 'react';
 import { useTranslation } from 'react-i18next';
-import { getBanner, syncBanner, TBannerInfo } from '../../api/banners';
+import { dismissBanner, getBanner, getBannerMessages, markBannerRead, syncBanner, syncBannerMessages, TBannerInfo } from '../../api/banners';
 import { Status } from '../status/status';
 import { A } from '../anchor/anchor';
 import style from './banner.module.css';
@@ -54,3 +54,46 @@ export const Banner = ({ msgKey }: TBannerProps) => {
     </Status>
   );
 };
+
+/**
+ * Shows the in-app messages which are not tied to a connected device. Shown messages are marked
+ * as read, and dismissible messages can be dismissed permanently.
+ */
+export const BannerMessages = () => {
+  const { i18n, t } = useTranslation();
+  const [banners, setBanners] = useState<TBannerInfo[]>([]);
+
+  useEffect(() => {
+    getBannerMessages().then(setBanners);
+    return syncBannerMessages(setBanners);
+  }, []);
+
+  useEffect(() => {
+    banners
+      .filter(({ read }) => !read)
+      .forEach(({ id }) => markBannerRead(id).catch(console.error));
+  }, [banners]);
+
+  if (!i18n.options.fallbackLng) {
+    return null;
+  }
+
+  return (
+    <>
+      {banners.map(({ id, message, link, dismissible, type }) => (
+        <Status
+          key={id}
+          onDismiss={dismissible ? () => dismissBanner(id).catch(console.error) : undefined}
+          type={type ? type : 'warning'}>
+          { message[i18n.resolvedLanguage] || message[(i18n.options.fallbackLng as string[])[0]] }
+          &nbsp;
+          { link && (
+            <A href={link.href} className={style.link}>
+              { link.text || t('clickHere') }
+            </A>
+          )}
+        </Status>
+      ))}
+    </>
+  );
+};
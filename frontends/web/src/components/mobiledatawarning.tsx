@@ -18,20 +18,32 @@
 import { useTranslation } from 'react-i18next';
 import { useSync } from '../hooks/api';
 import { getUsingMobileData, subscribeUsingMobileData } from '../api/mobiledata';
+import { getBandwidthStatus, subscribeBandwidthStatus } from '../api/bandwidth';
 import { Status } from './status/status';
 
 export const MobileDataWarning = () => {
   const { t } = useTranslation();
   const isUsingMobileData = useSync(getUsingMobileData, subscribeUsingMobileData);
+  const bandwidthStatus = useSync(getBandwidthStatus, subscribeBandwidthStatus);
   if (isUsingMobileData === undefined) {
     return null;
   }
   return (
-    <Status
-      dismissible="mobile-data-warning"
-      type="warning"
-      hidden={!isUsingMobileData}>
-      {t('mobile.usingMobileDataWarning')}
-    </Status>
+    <>
+      <Status
+        dismissible="mobile-data-warning"
+        type="warning"
+        hidden={!isUsingMobileData}>
+        {t('mobile.usingMobileDataWarning')}
+      </Status>
+      <Status
+        dismissible=""
+        type="warning"
+        hidden={!isUsingMobileData || !bandwidthStatus?.mobileDataBudgetExceeded}>
+        {t('mobile.mobileDataBudgetExceeded', {
+          budget: Math.round((bandwidthStatus?.mobileDataBudget || 0) / 1000000),
+        })}
+      </Status>
+    </>
   );
 };
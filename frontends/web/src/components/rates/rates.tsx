@@ -26,24 +26,46 @@ type FiatWithDisplayName = {
 }
 
 export const currenciesWithDisplayName: FiatWithDisplayName[] = [
+  { currency: 'AED', displayName: 'UAE Dirham' },
+  { currency: 'ARS', displayName: 'Argentine Peso' },
   { currency: 'AUD', displayName: 'Australian Dollar' },
   { currency: 'BRL', displayName: 'Brazilian Real' },
   { currency: 'CAD', displayName: 'Canadian Dollar' },
   { currency: 'CHF', displayName: 'Swiss franc' },
+  { currency: 'CLP', displayName: 'Chilean Peso' },
   { currency: 'CNY', displayName: 'Chinese Yuan' },
   { currency: 'CZK', displayName: 'Czech Koruna' },
+  { currency: 'DKK', displayName: 'Danish Krone' },
   { currency: 'EUR', displayName: 'Euro' },
   { currency: 'GBP', displayName: 'British Pound' },
   { currency: 'HKD', displayName: 'Hong Kong Dollar' },
+  { currency: 'HUF', displayName: 'Hungarian Forint' },
+  { currency: 'IDR', displayName: 'Indonesian Rupiah' },
   { currency: 'ILS', displayName: 'Israeli New Shekel' },
+  { currency: 'INR', displayName: 'Indian Rupee' },
   { currency: 'JPY', displayName: 'Japanese Yen' },
   { currency: 'KRW', displayName: 'South Korean Won' },
+  { currency: 'MXN', displayName: 'Mexican Peso' },
+  { currency: 'MYR', displayName: 'Malaysian Ringgit' },
+  { currency: 'NGN', displayName: 'Nigerian Naira' },
   { currency: 'NOK', displayName: 'Norwegian Krone' },
+  { currency: 'NZD', displayName: 'New Zealand Dollar' },
+  { currency: 'PHP', displayName: 'Philippine Peso' },
+  { currency: 'PKR', displayName: 'Pakistani Rupee' },
   { currency: 'PLN', displayName: 'Polish Zloty' },
   { currency: 'RUB', displayName: 'Russian ruble' },
+  { currency: 'SAR', displayName: 'Saudi Riyal' },
   { currency: 'SEK', displayName: 'Swedish Krona' },
   { currency: 'SGD', displayName: 'Singapore Dollar' },
+  { currency: 'THB', displayName: 'Thai Baht' },
+  { currency: 'TRY', displayName: 'Turkish Lira' },
+  { currency: 'TWD', displayName: 'New Taiwan Dollar' },
+  { currency: 'UAH', displayName: 'Ukrainian Hryvnia' },
   { currency: 'USD', displayName: 'United States Dollar' },
+  { currency: 'VND', displayName: 'Vietnamese Dong' },
+  { currency: 'ZAR', displayName: 'South African Rand' },
+  { currency: 'XAG', displayName: 'Silver (troy ounce)' },
+  { currency: 'XAU', displayName: 'Gold (troy ounce)' },
   { currency: 'BTC', displayName: 'Bitcoin' },
   { currency: 'sat', displayName: 'Satoshi' }
 ];
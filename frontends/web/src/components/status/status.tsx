@@ -29,6 +29,8 @@ type TPRops = {
     // shown again. Use an empty string if it should be dismissible without storing it in the
     // config, so the status will be shown again the next time.
     dismissible?: string;
+    // called instead of storing the dismissal in the config, e.g. if the backend persists it.
+    onDismiss?: () => void;
     className?: string;
     children: ReactNode;
 }
@@ -37,6 +39,7 @@ export const Status = ({
   hidden,
   type = 'warning',
   dismissible,
+  onDismiss,
   className,
   children,
 }: TPRops) => {
@@ -54,6 +57,11 @@ export const Status = ({
   }, [checkConfig]);
 
   const dismiss = async () => {
+    if (onDismiss) {
+      onDismiss();
+      setShow(false);
+      return;
+    }
     if (!dismissible) {
       return;
     }
@@ -70,11 +78,11 @@ export const Status = ({
   }
 
   return (
-    <div className={[style.container, style[type], className ? className : '', dismissible ? style.withCloseBtn : ''].join(' ')}>
+    <div className={[style.container, style[type], className ? className : '', dismissible || onDismiss ? style.withCloseBtn : ''].join(' ')}>
       <div className={style.status}>
         {children}
         <button
-          hidden={!dismissible}
+          hidden={!dismissible && !onDismiss}
           className={`${style.close} ${style[`close-${type}`]}`}
           onClick={dismiss}>
           <CloseXWhite />
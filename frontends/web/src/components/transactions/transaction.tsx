@@ -27,6 +27,7 @@ import { FiatConversion } from '../rates/rates';
 import { Amount } from '../../components/amount/amount';
 import { ArrowIn, ArrowOut, ArrowSelf } from './components/icons';
 import { Note } from './note';
+import { Button } from '../forms';
 import parentStyle from './transactions.module.css';
 import style from './transaction.module.css';
 
@@ -48,8 +49,11 @@ export const Transaction = ({
   numConfirmationsComplete,
   time,
   addresses,
+  contractName,
+  methodName,
   status,
   note = '',
+  spam,
 }: Props) => {
   const { i18n, t } = useTranslation();
   const [transactionDialog, setTransactionDialog] = useState<boolean>(false);
@@ -90,6 +94,7 @@ export const Transaction = ({
   const sDate = time ? parseTimeShort(time) : '---';
   const statusText = t(`transaction.status.${status}`);
   const progress = numConfirmations < numConfirmationsComplete ? (numConfirmations / numConfirmationsComplete) * 100 : 100;
+  const contractCall = contractName && methodName ? `${contractName}: ${methodName}` : contractName;
 
   return (
     <div className={[style.container, index === 0 ? style.first : ''].join(' ')}>
@@ -114,7 +119,7 @@ export const Transaction = ({
                 {t(type === 'receive' ? 'transaction.tx.received' : 'transaction.tx.sent')}
               </span>
               <span className={style.address}>
-                {addresses[0]}
+                {contractCall || addresses[0]}
                 {addresses.length > 1 && (
                   <span className={style.badge}>
                     (+{addresses.length - 1})
@@ -255,6 +260,12 @@ export const Transaction = ({
                 )
               }
             </div>
+            {contractCall ? (
+              <div className={style.detail}>
+                <label>{t('transaction.details.contractCall')}</label>
+                <p>{contractCall}</p>
+              </div>
+            ) : null}
             <div className={[style.detail, style.addresses].join(' ')}>
               <label>{t('transaction.details.address')}</label>
               <div className={style.detailAddresses}>
@@ -332,6 +343,13 @@ export const Transaction = ({
                   value={transactionInfo.txID} />
               </div>
             </div>
+            <div className={[style.detail, 'flex-center'].join(' ')}>
+              <Button
+                transparent
+                onClick={() => accountApi.postTxSpam(accountCode, internalID, !spam).catch(console.error)}>
+                {spam ? t('transaction.details.markNotSpam') : t('transaction.details.markSpam')}
+              </Button>
+            </div>
             <div className={[style.detail, 'flex-center'].join(' ')}>
               <A
                 href={explorerURL + transactionInfo.txID}
@@ -15,17 +15,18 @@
  * limitations under the License.
  */
 
+import { useState } from 'react';
 import { useTranslation } from 'react-i18next';
-import { AccountCode, TTransactions } from '../../api/account';
+import { AccountCode, TExportFormat, TTransactions } from '../../api/account';
 import { Transaction } from './transaction';
-import { Button } from '../forms';
+import { Button, Select } from '../forms';
 import style from './transactions.module.css';
 
 type TProps = {
     accountCode: AccountCode;
     explorerURL: string;
     transactions?: TTransactions;
-    handleExport: () => void;
+    handleExport: (format: TExportFormat) => void;
 };
 
 export const Transactions = ({
@@ -35,6 +36,15 @@ export const Transactions = ({
   handleExport,
 }: TProps) => {
   const { t } = useTranslation();
+  const [showSpam, setShowSpam] = useState(false);
+  const [exportFormat, setExportFormat] = useState<TExportFormat>('csv');
+
+  const spamCount = transactions && transactions.success
+    ? transactions.list.filter(({ spam }) => spam).length
+    : 0;
+  const list = transactions && transactions.success
+    ? transactions.list.filter(({ spam }) => showSpam || !spam)
+    : [];
 
   return (
     <div className={style.container}>
@@ -42,12 +52,25 @@ export const Transactions = ({
         <label className="labelXLarge">
           {t('accountSummary.transactionHistory')}
         </label>
-        <Button
-          transparent
-          onClick={handleExport}
-          title={t('account.exportTransactions')}>
-          {t('account.export')}
-        </Button>
+        <div className={style.export}>
+          <Select
+            id="exportFormat"
+            title={t('account.exportFormat')}
+            value={exportFormat}
+            onChange={e => setExportFormat(e.target.value as TExportFormat)}
+            options={[
+              { value: 'csv', text: 'CSV' },
+              { value: 'json', text: 'JSON' },
+              { value: 'ofx', text: 'OFX' },
+              { value: 'qif', text: 'QIF' },
+            ]} />
+          <Button
+            transparent
+            onClick={() => handleExport(exportFormat)}
+            title={t('account.exportTransactions')}>
+            {t('account.export')}
+          </Button>
+        </div>
       </div>
       <div className={[style.columns, style.headers, style.showOnMedium].join(' ')}>
         <div className={style.type}>{t('transaction.details.type')}</div>
@@ -58,8 +81,8 @@ export const Transactions = ({
         <div className={style.currency}>{t('transaction.details.amount')}</div>
         <div className={style.action}>&nbsp;</div>
       </div>
-      { (transactions && transactions.success && transactions.list.length > 0)
-        ? transactions.list.map((props, index) => (
+      { list.length > 0
+        ? list.map((props, index) => (
           <Transaction
             accountCode={accountCode}
             key={props.internalID}
@@ -75,6 +98,17 @@ export const Transactions = ({
             ) }
           </div>
         ) }
+      { spamCount > 0 ? (
+        <div className="flex flex-row flex-center">
+          <Button
+            transparent
+            onClick={() => setShowSpam(!showSpam)}>
+            {showSpam
+              ? t('transactions.hideSpam')
+              : t('transactions.showSpam', { count: spamCount })}
+          </Button>
+        </div>
+      ) : null }
     </div>
   );
 };
@@ -0,0 +1,42 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { useTranslation } from 'react-i18next';
+import type { TTxWarning } from '../../api/account';
+import { Message } from '../message/message';
+
+type TProps = {
+  warnings: TTxWarning[];
+}
+
+/**
+ * Shows the risks of an outgoing ETH transaction, which the user has to see before signing.
+ */
+export const TxWarnings = ({ warnings }: TProps) => {
+  const { t } = useTranslation();
+  if (warnings.length === 0) {
+    return null;
+  }
+  return (
+    <Message type="warning">
+      {warnings.map(({ code, address }) => (
+        <p key={`${code}-${address}`}>
+          {t(`send.txWarning.${code}`, { address })}
+        </p>
+      ))}
+    </Message>
+  );
+};
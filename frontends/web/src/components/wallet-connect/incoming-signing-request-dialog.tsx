@@ -18,6 +18,7 @@ import { useTranslation } from 'react-i18next';
 import { useDarkmode } from '../../hooks/darkmode';
 import { Dialog, DialogButtons } from '../dialog/dialog';
 import { Button } from '../forms';
+import { TxWarnings } from '../tx-warnings/tx-warnings';
 import { SUPPORTED_CHAINS, truncateAddress } from '../../utils/walletconnect';
 import { TRequestDialogContent } from '../../utils/walletconnect-eth-sign-handlers';
 import { AnimatedChecked, PointToBitBox02, WalletConnectDark, WalletConnectLight } from '../icon';
@@ -63,7 +64,7 @@ export const WCIncomingSignRequestDialog = ({
 }: TRequestDialogProps) => {
   const { t } = useTranslation();
   const { isDarkMode } = useDarkmode();
-  const { accountAddress, accountName, signingData, chain, method, currentSession } = content;
+  const { accountAddress, accountName, signingData, chain, method, currentSession, warnings } = content;
 
   const formattedChain = chain in SUPPORTED_CHAINS ? SUPPORTED_CHAINS[chain].name : chain;
   const chainIcon = chain in SUPPORTED_CHAINS ? SUPPORTED_CHAINS[chain].icon : null;
@@ -123,6 +124,8 @@ export const WCIncomingSignRequestDialog = ({
               }
             </ul>
 
+            {warnings && <TxWarnings warnings={warnings} />}
+
             {stage === 'confirming' && (
               <ConfirmOnBB02 />
             )}
@@ -37,7 +37,7 @@ export const WCSigningRequest = () => {
   const requestDataRef = useRef<TSigningRequestData>();
 
   const launchSignDialog = ({ topic, id, apiCaller, dialogContent }: TLaunchSignDialog) => {
-    const { signingData, currentSession, accountAddress, accountName, chain, method } = dialogContent;
+    const { signingData, currentSession, accountAddress, accountName, chain, method, warnings } = dialogContent;
 
     // storing data to be used whenever
     // user accepts or rejects later
@@ -54,7 +54,8 @@ export const WCSigningRequest = () => {
       signingData,
       chain,
       currentSession,
-      method
+      method,
+      warnings,
     });
 
     // opening the dialog
@@ -117,8 +118,12 @@ export const WCSigningRequest = () => {
         await web3wallet?.respondSessionRequest({ topic, response: rejectMessage(id) });
       } else {
         setStage('initial');
-        const { errorMessage } = error;
-        alertUser(errorMessage ? errorMessage : t('pairing.error.text'));
+        const { errorMessage, errorCode } = error;
+        if (errorCode === 'deviceBusy') {
+          alertUser(t('error.deviceBusy'));
+        } else {
+          alertUser(errorMessage ? errorMessage : t('pairing.error.text'));
+        }
       }
     }
   };
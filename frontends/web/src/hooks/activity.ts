@@ -0,0 +1,71 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { useEffect } from 'react';
+import { setAppActivity } from '../api/system';
+
+// The app is considered idle after this many milliseconds without user interaction.
+const idleTimeout = 5 * 60 * 1000;
+
+const interactionEvents = ['mousemove', 'mousedown', 'keydown', 'touchstart', 'wheel'];
+
+/**
+ * useAppActivity reports to the backend whether the app is visible and in use, so that the backend
+ * can throttle background syncing while the app is hidden or idle.
+ */
+export const useAppActivity = () => {
+  useEffect(() => {
+    let idle = false;
+    let visible = document.visibilityState === 'visible';
+    let idleTimer: ReturnType<typeof setTimeout> | undefined;
+
+    const report = () => {
+      setAppActivity({ visible, idle }).catch(console.error);
+    };
+    const startIdleTimer = () => {
+      clearTimeout(idleTimer);
+      idleTimer = setTimeout(() => {
+        idle = true;
+        report();
+      }, idleTimeout);
+    };
+    const handleInteraction = () => {
+      startIdleTimer();
+      if (idle) {
+        idle = false;
+        report();
+      }
+    };
+    const handleVisibilityChange = () => {
+      visible = document.visibilityState === 'visible';
+      if (visible) {
+        idle = false;
+        startIdleTimer();
+      }
+      report();
+    };
+
+    startIdleTimer();
+    report();
+    document.addEventListener('visibilitychange', handleVisibilityChange);
+    interactionEvents.forEach(event => window.addEventListener(event, handleInteraction, { passive: true }));
+    return () => {
+      clearTimeout(idleTimer);
+      document.removeEventListener('visibilitychange', handleVisibilityChange);
+      interactionEvents.forEach(event => window.removeEventListener(event, handleInteraction));
+    };
+  }, []);
+};
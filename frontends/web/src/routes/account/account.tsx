@@ -65,6 +65,8 @@ export const Account = ({
   const [status, setStatus] = useState<accountApi.IStatus>();
   const [syncedAddressesCount, setSyncedAddressesCount] = useState<number>();
   const [transactions, setTransactions] = useState<accountApi.TTransactions>();
+  // cached is true if the balance and transactions are from the last sync, shown while syncing.
+  const [cached, setCached] = useState(false);
   const [usesProxy, setUsesProxy] = useState<boolean>();
   const [insured, setInsured] = useState<boolean>(false);
   const [uncoveredFunds, setUncoveredFunds] = useState<string[]>([]);
@@ -121,6 +123,10 @@ export const Account = ({
     setInsured(false);
   }, [t, account, code, checkUncoveredUTXOs]);
 
+  useEffect(() => {
+    accountApi.initializeAccounts([code]).catch(console.error);
+  }, [code]);
+
   useEffect(() => {
     maybeCheckBitsuranceStatus();
     getConfig().then(({ backend }) => setUsesProxy(backend.proxy.useProxy));
@@ -141,6 +147,7 @@ export const Account = ({
             return;
           }
           setBalance(newBalance);
+          setCached(false);
         }),
         accountApi.getTransactionList(code).then(newTransactions => {
           if (currentCode !== code) {
@@ -154,6 +161,18 @@ export const Account = ({
     } else {
       setBalance(undefined);
       setTransactions(undefined);
+      setCached(false);
+      if (!status.synced && status.offlineError === null) {
+        accountApi.getCheckpoint(code).then(checkpoint => {
+          if (checkpoint === null) {
+            return;
+          }
+          setBalance(checkpoint.balance);
+          setTransactions({ success: true, list: checkpoint.transactions });
+          setCached(true);
+        })
+          .catch(console.error);
+      }
     }
   }, []);
 
@@ -185,11 +204,11 @@ export const Account = ({
     return () => unsubscribe(subscriptions);
   }, [code, onAccountChanged, onStatusChanged, status]);
 
-  const exportAccount = () => {
+  const exportAccount = (format: accountApi.TExportFormat) => {
     if (status === undefined || status.fatalError) {
       return;
     }
-    accountApi.exportAccount(code)
+    accountApi.exportAccount(code, format)
       .then(result => {
         if (result !== null && !result.success) {
           alertUser(result.errorMessage);
@@ -204,6 +223,7 @@ export const Account = ({
     setStatus(undefined);
     setSyncedAddressesCount(0);
     setTransactions(undefined);
+    setCached(false);
     onStatusChanged();
   }, [code, onStatusChanged]);
 
@@ -234,7 +254,7 @@ export const Account = ({
       <Spinner guideExists text={offlineErrorTextLines.join('\n')} />
     );
   }
-  if (!status.synced) {
+  if (!status.synced && !cached) {
     const text =
       (syncedAddressesCount !== undefined && syncedAddressesCount > 1) ? (
         '\n' + t('account.syncedAddressesCount', {
@@ -268,7 +288,7 @@ export const Account = ({
   const actionButtonsProps = {
     code,
     coinCode: account.coinCode,
-    canSend: balance && balance.hasAvailable,
+    canSend: balance && balance.hasAvailable && !cached,
     exchangeBuySupported,
     account
   };
@@ -279,6 +299,9 @@ export const Account = ({
         <Status hidden={!hasCard} type="warning">
           {t('warning.sdcard')}
         </Status>
+        <Status hidden={!cached} type="info">
+          {t('account.cachedSyncing')}
+        </Status>
         <Dialog open={insured && uncoveredFunds.length !== 0} medium title={t('account.warning')} onClose={() => setUncoveredFunds([])}>
           <MultilineMarkup tagName="p" markup={t('account.uncoveredFunds', {
             name: account.name,
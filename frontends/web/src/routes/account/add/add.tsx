@@ -27,7 +27,7 @@ import { CoinDropDown } from './components/coin-dropdown';
 import { Check } from '../../../components/icon/icon';
 import { AddAccountGuide } from './add-account-guide';
 import { route } from '../../../utils/route';
-import { addAccount, CoinCode, TAddAccount, IAccount } from '../../../api/account';
+import { addAccount, importAccount, CoinCode, TAddAccount, IAccount } from '../../../api/account';
 import styles from './add.module.css';
 
 type TAddAccountGuide = {
@@ -44,6 +44,8 @@ export const AddAccount = ({ accounts }: TAddAccountGuide) => {
   const [step, setStep] = useState<TStep>('select-coin');
   const [supportedCoins, setSupportedCoins] = useState<backendAPI.ICoin[]>([]);
   const [adding, setAdding] = useState(false);
+  // Content of an export file of another hardware wallet, to add a watch-only account instead.
+  const [exportFile, setExportFile] = useState<string>();
 
   const inputRef = useRef<HTMLInputElement>(null);
 
@@ -92,6 +94,7 @@ export const AddAccount = ({ accounts }: TAddAccountGuide) => {
     case 'choose-name':
       setStep('select-coin');
       setErrorMessage(undefined);
+      setExportFile(undefined);
       break;
     case 'success':
       setStep('choose-name');
@@ -107,7 +110,9 @@ export const AddAccount = ({ accounts }: TAddAccountGuide) => {
       break;
     case 'choose-name':
       setAdding(true);
-      const responseData: TAddAccount = await addAccount(coinCode, accountName);
+      const responseData: TAddAccount = exportFile !== undefined
+        ? await importAccount(coinCode, accountName, exportFile)
+        : await addAccount(coinCode, accountName);
       setAdding(false);
       if (responseData.success) {
         setAccountCode(responseData.accountCode);
@@ -149,12 +154,26 @@ export const AddAccount = ({ accounts }: TAddAccountGuide) => {
       );
     case 'choose-name':
       return (
-        <Input
-          autoFocus
-          ref={inputRef}
-          id="accountName"
-          onInput={e => setAccountName(e.target.value)}
-          value={accountName} />
+        <>
+          <Input
+            autoFocus
+            ref={inputRef}
+            id="accountName"
+            onInput={e => setAccountName(e.target.value)}
+            value={accountName} />
+          {['btc', 'tbtc', 'rbtc'].includes(coinCode) && (
+            <label>
+              {t('addAccount.chooseName.importFile')}
+              <input
+                accept=".json,application/json"
+                type="file"
+                onChange={async e => {
+                  const file = e.target.files?.[0];
+                  setExportFile(file ? await file.text() : undefined);
+                }} />
+            </label>
+          )}
+        </>
       );
     case 'success':
       return (
@@ -194,6 +213,7 @@ export const AddAccount = ({ accounts }: TAddAccountGuide) => {
     setAccountName('');
     setCoinCode('choose');
     setErrorMessage(undefined);
+    setExportFile(undefined);
     setStep('select-coin');
     await startProcess();
   };
@@ -42,6 +42,13 @@ export const SigningConfiguration = ({ account, info, code, signingConfigIndex,
     if (info.bitcoinSimple !== undefined) {
       return info.bitcoinSimple;
     }
+    if (info.bitcoinMultisig !== undefined) {
+      // Our own key, the cosigner keys are part of the descriptors.
+      return {
+        keyInfo: info.bitcoinMultisig.keyInfos[info.bitcoinMultisig.ourKeyIndex],
+        scriptType: 'p2wsh',
+      };
+    }
     return info.ethereumSimple;
   };
 
@@ -101,7 +108,7 @@ export const SigningConfiguration = ({ account, info, code, signingConfigIndex,
             try {
               const result = await verifyXPub(code, signingConfigIndex);
               if (!result.success) {
-                alertUser(result.errorMessage);
+                alertUser(result.errorCode === 'deviceBusy' ? t('error.deviceBusy') : result.errorMessage);
               }
             } finally {
               setVerifying(false);
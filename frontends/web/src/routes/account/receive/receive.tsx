@@ -21,6 +21,7 @@ import { useLoad } from '../../../hooks/api';
 import { useEsc } from '../../../hooks/keyboard';
 import * as accountApi from '../../../api/account';
 import { route } from '../../../utils/route';
+import { alertUser } from '../../../components/alert/Alert';
 import { getScriptName, isEthereumBased } from '../utils';
 import { CopyableInput } from '../../../components/copy/Copy';
 import { Dialog, DialogButtons } from '../../../components/dialog/dialog';
@@ -126,7 +127,10 @@ export const Receive = ({
     // For devices with a display, the dialog is dismissed by tapping the device.
     setVerifying('secure');
     try {
-      await accountApi.verifyAddress(code, receiveAddresses[addressesIndex].addresses[activeIndex].addressID);
+      const result = await accountApi.verifyAddress(code, receiveAddresses[addressesIndex].addresses[activeIndex].addressID);
+      if (!result.success && result.errorCode === 'deviceBusy') {
+        alertUser(t('error.deviceBusy'));
+      }
     } finally {
       setVerifying(false);
     }
@@ -0,0 +1,45 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { useTranslation } from 'react-i18next';
+import type { TPrivacyScore } from '../../../../api/account';
+import style from './privacy-score.module.css';
+
+type TProps = {
+    privacyScore: TPrivacyScore;
+}
+
+export const PrivacyScore = ({ privacyScore }: TProps) => {
+  const { t } = useTranslation();
+  return (
+    <div className={style.privacyScore}>
+      <label>
+        {t('send.privacy.label', { score: privacyScore.score })}
+      </label>
+      {privacyScore.issues.length === 0 ? (
+        <p className={style.description}>{t('send.privacy.noIssues')}</p>
+      ) : (
+        <ul className={style.issues}>
+          {privacyScore.issues.map(issue => (
+            <li key={issue.code} className={style.description}>
+              {t(`send.privacy.issue.${issue.code}`, { defaultValue: issue.explanation })}
+            </li>
+          ))}
+        </ul>
+      )}
+    </div>
+  );
+};
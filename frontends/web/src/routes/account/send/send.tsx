@@ -25,7 +25,7 @@ import { getDeviceInfo } from '../../../api/bitbox01';
 import { alertUser } from '../../../components/alert/Alert';
 import { Balance } from '../../../components/balance/balance';
 import { HideAmountsButton } from '../../../components/hideamountsbutton/hideamountsbutton';
-import { Button, ButtonLink } from '../../../components/forms';
+import { Button, ButtonLink, Checkbox } from '../../../components/forms';
 import { Column, ColumnButtons, Grid, GuideWrapper, GuidedContent, Header, Main } from '../../../components/layout';
 import { Status } from '../../../components/status/status';
 import { translate, TranslateProps } from '../../../decorators/translate';
@@ -38,12 +38,16 @@ import { isBitcoinBased, findAccount } from '../utils';
 import { ConfirmingWaitDialog } from './components/dialogs/confirm-wait-dialog';
 import { SendGuide } from './send-guide';
 import { MessageWaitDialog } from './components/dialogs/message-wait-dialog';
+import { Message } from '../../../components/message/message';
 import { ReceiverAddressInput } from './components/inputs/receiver-address-input';
 import { CoinInput } from './components/inputs/coin-input';
 import { FiatInput } from './components/inputs/fiat-input';
 import { NoteInput } from './components/inputs/note-input';
+import { PrivacyScore } from './components/privacy-score';
+import { TxWarnings } from '../../../components/tx-warnings/tx-warnings';
 import { TSelectedUTXOs, UTXOs } from './utxos';
 import { TProposalError, txProposalErrorHandling } from './services';
+import { dismissPaymentRequest, getPaymentRequests, subscribePaymentRequests } from '../../../api/paymentrequests';
 import style from './send.module.css';
 
 interface SendProps {
@@ -61,6 +65,9 @@ export type State = {
     balance?: accountApi.IBalance;
     proposedFee?: accountApi.IAmount;
     proposedTotal?: accountApi.IAmount;
+    privacyScore?: accountApi.TPrivacyScore | null;
+    txWarnings?: accountApi.TTxWarning[];
+    spendsUnconfirmedChange?: boolean;
     recipientAddress: string;
     proposedAmount?: accountApi.IAmount;
     valid: boolean;
@@ -77,6 +84,9 @@ export type State = {
     addressError?: TProposalError['addressError'];
     amountError?: TProposalError['amountError'];
     feeError?: TProposalError['feeError'];
+    // feeTooHigh is true if the proposal was rejected for its high fee, which the user can allow.
+    feeTooHigh: boolean;
+    allowHighFee: boolean;
     paired?: boolean;
     noMobileChannelError?: boolean;
     signProgress?: TSignProgress;
@@ -116,6 +126,8 @@ class Send extends Component<Props, State> {
     activeScanQR: false,
     note: '',
     customFee: '',
+    feeTooHigh: false,
+    allowHighFee: false,
   };
 
   private isBitcoinBased = () => {
@@ -165,11 +177,27 @@ class Send extends Component<Props, State> {
           updateBalance(code);
         }
       }),
+      subscribePaymentRequests(() => this.handlePaymentRequest()),
     ];
 
     this.registerEvents();
+    this.handlePaymentRequest();
   }
 
+  // Prefill the form with a payment URI opened through the OS, e.g. a `bitcoin:` link.
+  private handlePaymentRequest = async () => {
+    try {
+      const requests = await getPaymentRequests();
+      const request = requests.find(({ accountCodes }) => accountCodes.includes(this.props.code));
+      if (request) {
+        await dismissPaymentRequest(request.id);
+        this.prefill(request.address, request.amount);
+      }
+    } catch (err) {
+      console.error(err);
+    }
+  };
+
   public componentWillUnmount() {
     this.unregisterEvents();
     unsubscribe(this.unsubscribeList);
@@ -209,10 +237,15 @@ class Send extends Component<Props, State> {
           proposedAmount: undefined,
           proposedFee: undefined,
           proposedTotal: undefined,
+          privacyScore: undefined,
+          txWarnings: undefined,
+          spendsUnconfirmedChange: undefined,
           fiatAmount: '',
           amount: '',
           note: '',
           customFee: '',
+          feeTooHigh: false,
+          allowHighFee: false,
         });
         this.selectedUTXOs = {};
         setTimeout(() => this.setState({
@@ -227,6 +260,9 @@ class Send extends Component<Props, State> {
         case 'erc20InsufficientGasFunds':
           alertUser(this.props.t(`send.error.${result.errorCode}`));
           break;
+        case 'deviceBusy':
+          alertUser(this.props.t('error.deviceBusy'));
+          break;
         default:
           const { errorMessage } = result;
           alertUser(this.props.t('unknownError', errorMessage && { errorMessage }));
@@ -256,15 +292,20 @@ class Send extends Component<Props, State> {
       customFee: this.state.customFee,
       sendAll: (this.state.sendAll ? 'yes' : 'no'),
       selectedUTXOs: Object.keys(this.selectedUTXOs),
+      allowHighFee: this.state.allowHighFee,
     };
   };
 
   private validateAndDisplayFee = (updateFiat: boolean = true) => {
     this.setState({
       proposedTotal: undefined,
+      privacyScore: undefined,
+      txWarnings: undefined,
+      spendsUnconfirmedChange: undefined,
       addressError: undefined,
       amountError: undefined,
       feeError: undefined,
+      feeTooHigh: false,
     });
     const txInput = this.getValidTxInputData();
     if (!txInput) {
@@ -320,14 +361,17 @@ class Send extends Component<Props, State> {
         proposedFee: result.fee,
         proposedAmount: result.amount,
         proposedTotal: result.total,
+        privacyScore: result.privacyScore,
+        txWarnings: result.warnings,
+        spendsUnconfirmedChange: result.spendsUnconfirmedChange,
         isUpdatingProposal: false,
       });
       if (updateFiat) {
         this.convertToFiat(result.amount.amount);
       }
     } else {
-      const errorHandling = txProposalErrorHandling(this.registerEvents, this.unregisterEvents, result.errorCode);
-      this.setState({ ...errorHandling, isUpdatingProposal: false });
+      const errorHandling = txProposalErrorHandling(this.registerEvents, this.unregisterEvents, result.errorCode, result.likelyCoinName);
+      this.setState({ ...errorHandling, feeTooHigh: result.errorCode === 'feeTooHigh', isUpdatingProposal: false });
     }
   };
 
@@ -374,7 +418,7 @@ class Send extends Component<Props, State> {
 
   private feeTargetChange = (feeTarget: accountApi.FeeTargetCode) => {
     this.setState(
-      { feeTarget, customFee: '' },
+      { feeTarget, customFee: '', allowHighFee: false },
       () => this.validateAndDisplayFee(this.state.sendAll),
     );
   };
@@ -424,6 +468,10 @@ class Send extends Component<Props, State> {
     } catch {
       address = uri;
     }
+    this.prefill(address, amount);
+  };
+
+  private prefill = async (address: string, amount: string) => {
     let updateState = {
       recipientAddress: address,
       sendAll: false,
@@ -485,6 +533,11 @@ class Send extends Component<Props, State> {
       balance,
       proposedFee,
       proposedTotal,
+      privacyScore,
+      txWarnings,
+      spendsUnconfirmedChange,
+      feeTooHigh,
+      allowHighFee,
       recipientAddress,
       proposedAmount,
       valid,
@@ -618,6 +671,25 @@ class Send extends Component<Props, State> {
                       onFeeTargetChange={this.feeTargetChange}
                       onCustomFee={customFee => this.setState({ customFee }, this.validateAndDisplayFee)}
                       error={feeError} />
+                    { privacyScore && !isUpdatingProposal && (
+                      <PrivacyScore privacyScore={privacyScore} />
+                    )}
+                    { spendsUnconfirmedChange && !isUpdatingProposal && (
+                      <Message type="info">
+                        {t('send.spendsUnconfirmedChange')}
+                      </Message>
+                    )}
+                    { (feeTooHigh || allowHighFee) && (
+                      <Checkbox
+                        id="allowHighFee"
+                        checkboxStyle="warning"
+                        label={t('send.allowHighFee')}
+                        checked={allowHighFee}
+                        onChange={event => this.setState({ allowHighFee: event.target.checked }, this.validateAndDisplayFee)} />
+                    )}
+                    { txWarnings && !isUpdatingProposal && (
+                      <TxWarnings warnings={txWarnings} />
+                    )}
                   </Column>
                   <Column>
                     <NoteInput
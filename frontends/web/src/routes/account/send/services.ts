@@ -24,15 +24,23 @@ export type TProposalError = {
     feeError: string;
 }
 
-export const txProposalErrorHandling = (registerEvents: () => void, unregisterEvents: () => void, errorCode?: string) => {
+export const txProposalErrorHandling = (registerEvents: () => void, unregisterEvents: () => void, errorCode?: string, likelyCoinName?: string) => {
   const { t } = i18n;
   switch (errorCode) {
   case 'invalidAddress':
-    return { addressError: t('send.error.invalidAddress') };
+  case 'invalidAddressWrongNetwork':
+  case 'invalidAddressBech32Variant':
+  case 'invalidAddressEthereum':
+  case 'invalidAddressTaprootNotSupported':
+    return { addressError: t(`send.error.${errorCode}`) };
+  case 'invalidAddressOtherCoin':
+    return { addressError: t('send.error.invalidAddressOtherCoin', { coinName: likelyCoinName }) };
   case 'invalidAmount':
   case 'insufficientFunds':
+  case 'selectedUTXOUnavailable':
     return { amountError: t(`send.error.${errorCode}`), proposedFee: undefined };
   case 'feeTooLow':
+  case 'feeTooHigh':
   case 'feesNotAvailable':
     return { feeError: t(`send.error.${errorCode}`) };
   default:
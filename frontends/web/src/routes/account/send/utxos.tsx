@@ -141,6 +141,12 @@ export const UTXOs = ({
                           </Badge> :
                           null
                         }
+                        {utxo.unconfirmed ?
+                          <Badge type="warning">
+                            {t('send.coincontrol.unconfirmed')}
+                          </Badge> :
+                          null
+                        }
                       </div>
                     </div>
                     <div className={style.transaction}>
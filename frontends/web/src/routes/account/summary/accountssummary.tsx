@@ -19,7 +19,7 @@ import { useState, useEffect, useRef, useCallback, useContext } from 'react';
 import { useTranslation } from 'react-i18next';
 import * as accountApi from '../../../api/account';
 import { TDevices } from '../../../api/devices';
-import { statusChanged, syncdone } from '../../../api/accountsync';
+import { initialized, statusChanged, syncdone } from '../../../api/accountsync';
 import { unsubscribe } from '../../../utils/subscriptions';
 import { useMountedRef } from '../../../hooks/mount';
 import { useSDCard } from '../../../hooks/sdcard';
@@ -157,6 +157,7 @@ export const AccountsSummary = ({
     // for subscriptions and unsubscriptions
     // runs only on component mount and unmount.
     const subscriptions = [
+      initialized(update),
       statusChanged(update),
       syncdone(update)
     ];
@@ -187,6 +188,7 @@ export const AccountsSummary = ({
   }, [summaryData, getAccountSummary]);
 
   useEffect(() => {
+    accountApi.initializeAccounts(accounts.map(account => account.code)).catch(console.error);
     accounts.forEach(account => {
       onStatusChanged(account.code);
     });
@@ -78,6 +78,8 @@ class Chart extends Component<Props, State> {
       formattedChartTotal: null,
       chartIsUpToDate: false,
       lastTimestamp: 0,
+      chartCoinsMissing: [],
+      clockSkewed: false,
     },
     hideAmounts: false,
   };
@@ -505,6 +507,8 @@ class Chart extends Component<Props, State> {
       data: {
         lastTimestamp,
         chartDataMissing,
+        chartCoinsMissing,
+        clockSkewed,
         chartFiat,
         chartIsUpToDate,
         chartTotal,
@@ -570,12 +574,21 @@ class Chart extends Component<Props, State> {
         <div className={styles.chartCanvas} style={{ minHeight: chartHeight }}>
           {chartDataMissing ? (
             <div className={styles.chartUpdatingMessage} style={{ height: chartHeight }}>
-              {t('chart.dataMissing')}
-            </div>
-          ) : hasData ? !chartIsUpToDate && (
-            <div className={styles.chartUpdatingMessage}>
-              {t('chart.dataOldTimestamp', { time: new Date(lastTimestamp).toLocaleString(this.props.i18n.language), })}
+              {clockSkewed ? t('chart.clockSkewed') : t('chart.dataMissing')}
             </div>
+          ) : hasData ? (
+            <>
+              {!chartIsUpToDate && (
+                <div className={styles.chartUpdatingMessage}>
+                  {t('chart.dataOldTimestamp', { time: new Date(lastTimestamp).toLocaleString(this.props.i18n.language), })}
+                </div>
+              )}
+              {chartCoinsMissing.length > 0 && (
+                <div className={styles.chartUpdatingMessage}>
+                  {t('chart.coinsMissing', { coins: chartCoinsMissing.map(({ coin }) => coin.toUpperCase()).join(', ') })}
+                </div>
+              )}
+            </>
           ) : noDataPlaceholder}
           <div ref={this.ref} className={styles.invisible}></div>
           <span
@@ -87,6 +87,8 @@ export const getScriptName = (scriptType: ScriptType): string => {
     return 'Native segwit (bech32, P2WPKH)';
   case 'p2tr':
     return 'Taproot (bech32m, P2TR)';
+  case 'p2wsh':
+    return 'Multisig (bech32, P2WSH)';
   }
 };
 
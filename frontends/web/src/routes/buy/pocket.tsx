@@ -128,7 +128,9 @@ export const Pocket = ({ code }: TProps) => {
       .then(response => {
         setVerifying(false);
         if (!response.success) {
-          if (response.errorCode === 'addressNotFound') {
+          if (response.errorCode === 'deviceBusy') {
+            alertUser(t('error.deviceBusy'));
+          } else if (response.errorCode === 'addressNotFound') {
             // This should not happen, unless the user receives a tx on the same address between the message signing
             // and the address verification.
             alertUser(t('buy.pocket.usedAddress', { address:  address }));
@@ -31,10 +31,14 @@ import { MobileHeader } from './components/mobile-header';
 import { Guide } from '../../components/guide/guide';
 import { Entry } from '../../components/guide/entry';
 import { EnableAuthSetting } from './components/advanced-settings/enable-auth-setting';
+import { EnableMobileDataSaverSetting } from './components/advanced-settings/enable-mobile-data-saver-setting';
+import { EnableLazyAccountInitSetting } from './components/advanced-settings/enable-lazy-account-init-setting';
+import { EnableSpendUnconfirmedChangeSetting } from './components/advanced-settings/enable-spend-unconfirmed-change-setting';
 
 export type TProxyConfig = {
   proxyAddress: string;
   useProxy: boolean;
+  torOnly?: boolean;
 }
 
 export type TFrontendConfig = {
@@ -45,6 +49,9 @@ export type TFrontendConfig = {
 export type TBackendConfig = {
   proxy?: TProxyConfig
   authentication?: boolean;
+  mobileDataSaver?: boolean;
+  lazyAccountInit?: boolean;
+  spendUnconfirmedChange?: boolean;
 
 }
 
@@ -88,6 +95,9 @@ export const AdvancedSettings = ({ deviceIDs, hasAccounts }: TPagePropsWithSetti
                 <EnableCustomFeesToggleSetting frontendConfig={frontendConfig} onChangeConfig={setConfig} />
                 <EnableCoinControlSetting frontendConfig={frontendConfig} onChangeConfig={setConfig} />
                 <EnableAuthSetting backendConfig={backendConfig} onChangeConfig={setConfig} />
+                <EnableMobileDataSaverSetting backendConfig={backendConfig} onChangeConfig={setConfig} />
+                <EnableLazyAccountInitSetting backendConfig={backendConfig} onChangeConfig={setConfig} />
+                <EnableSpendUnconfirmedChangeSetting backendConfig={backendConfig} onChangeConfig={setConfig} />
                 <EnableTorProxySetting proxyConfig={proxyConfig} onChangeConfig={setConfig} />
                 <ConnectFullNodeSetting />
                 <ExportLogSetting />
@@ -14,9 +14,9 @@
  * limitations under the License.
  */
 
-import { useLoad } from '../../../../hooks/api';
+import { useLoad, useSync } from '../../../../hooks/api';
 import { useTranslation } from 'react-i18next';
-import { getUpdate, getVersion } from '../../../../api/version';
+import { getUpdate, getVersion, subscribeUpdate } from '../../../../api/version';
 import { open } from '../../../../api/system';
 import { SettingsItem } from '../settingsItem/settingsItem';
 import { StyledSkeleton } from '../../bb02-settings';
@@ -27,7 +27,7 @@ export const AppVersion = () => {
   const { t } = useTranslation();
 
   const version = useLoad(getVersion);
-  const update = useLoad(getUpdate);
+  const update = useSync(getUpdate, subscribeUpdate);
 
   const secondaryText = !!update ? t('settings.info.out-of-date') : t('settings.info.up-to-date');
   const icon = !!update ? <RedDot width={8} height={8} /> : <Checked />;
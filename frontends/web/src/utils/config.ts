@@ -24,6 +24,10 @@ type TConfig = {
 
 let pendingConfig: TConfig = {};
 
+// number of times a config change is reapplied on top of a config changed concurrently
+// by another frontend, see 'configConflict' in the backend.
+const maxConflictRetries = 3;
+
 /**
  * get current configs
  * i.e. await getConfig()
@@ -38,7 +42,7 @@ export const getConfig = (): Promise<any> => {
  * i.e. await setConfig({ frontend: { language }})
  * returns a promise and passes the new config
  */
-export const setConfig = (object: TConfig) => {
+export const setConfig = (object: TConfig, retries = maxConflictRetries): Promise<any> => {
   return getConfig()
     .then((currentConfig = {}) => {
       const nextConfig = Object.assign(currentConfig, {
@@ -50,6 +54,15 @@ export const setConfig = (object: TConfig) => {
         .then(() => {
           pendingConfig = {};
           return nextConfig;
+        })
+        .catch((error) => {
+          // the config was changed by another frontend since it was read,
+          // reapply the change on top of the latest config.
+          if (error === 'configConflict' && retries > 0) {
+            pendingConfig = {};
+            return setConfig(object, retries - 1);
+          }
+          throw error;
         });
     });
 };
@@ -1,7 +1,7 @@
 import { t } from 'i18next';
 import { SessionTypes } from '@walletconnect/types';
 import { EIP155_SIGNING_METHODS, decodeEthMessage } from './walletconnect';
-import { ethSignMessage, ethSignTypedMessage, ethSignWalletConnectTx, getEthAccountCodeAndNameByAddress } from '../api/account';
+import { TTxWarning, ethSignMessage, ethSignTypedMessage, ethSignWalletConnectTx, ethWalletConnectTxWarnings, getEthAccountCodeAndNameByAddress } from '../api/account';
 import { alertUser } from '../components/alert/Alert';
 
 type TWCParams = {
@@ -27,6 +27,8 @@ export type TRequestDialogContent = {
   signingData: string; // data / message coming from dapp
   currentSession: SessionTypes.Struct;
   method: string;
+  // warnings about risks of the transaction, which are shown before signing.
+  warnings?: TTxWarning[];
 }
 
 export type TLaunchSignDialog = {
@@ -181,10 +183,13 @@ const ethSignOrSendTransactionHandler = async (
   const accountAddress = requestParams[0].from; // this is our wallet address
   const data = requestParams[0];
   const { accountName, accountCode } = await fetchAccountNameAndAddress(accountAddress);
+  const warningsResult = await ethWalletConnectTxWarnings(accountCode, data);
+  const warnings = warningsResult.success ? warningsResult.warnings : [];
   const apiCaller = async () => {
     // If the typed data to be signed includes its own chainId, we use that, otherwise use the id in the params
     const chainId = Number(params.chainId.replace(/^eip155:/, ''));
-    const result = await ethSignWalletConnectTx(accountCode, isSendAndSign, chainId, data);
+    // The warnings are shown in the signing dialog, so accepting the request accepts them.
+    const result = await ethSignWalletConnectTx(accountCode, isSendAndSign, chainId, data, true);
     if (result.success) {
       const response = { id, jsonrpc: '2.0', result: isSendAndSign ? result.txHash : result.rawTx };
       return { response, success: true };
@@ -207,6 +212,7 @@ const ethSignOrSendTransactionHandler = async (
       accountAddress,
       chain: params.chainId,
       method: formattedMethod,
+      warnings,
     }
   });
 };
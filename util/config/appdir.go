@@ -23,9 +23,32 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 )
 
+// dataDirPointerFilename is the name of the file in the default app folder which points to a
+// custom app folder chosen by the user.
+const dataDirPointerFilename = "datadir.json"
+
 var mu sync.RWMutex
 var appFolder string
 
+// baseAppFolder is the app folder without the profile subfolder, see activeProfileFolder().
+var baseAppFolder string
+
+// runningProfile is the ID of the profile whose folder is returned by AppDir(), or the empty string
+// for the default profile.
+var runningProfile string
+
+// appFolderExplicit is true if the app folder was set using SetAppDir(), e.g. on mobile. A custom
+// app folder is not supported in this case.
+var appFolderExplicit bool
+
+// customAppFolderErr is set by AppDir() if the custom app folder is not available, see
+// CustomAppDirError().
+var customAppFolderErr error
+
+type dataDirPointer struct {
+	Path string `json:"path"`
+}
+
 // SetAppDir sets the app folder (retrieved by AppDir()) and can only be called once.
 func SetAppDir(folder string) {
 	mu.Lock()
@@ -34,6 +57,7 @@ func SetAppDir(folder string) {
 		panic("app folder already set")
 	}
 	appFolder = folder
+	appFolderExplicit = true
 }
 
 func defaultAppFolder() string {
@@ -67,7 +91,8 @@ func defaultAppFolder() string {
 }
 
 // AppDir returns the absolute path to the default BitBox desktop app directory
-// in the user standard config location.
+// in the user standard config location. If the user selected a profile other than the default one,
+// the directory of the profile is returned, see SelectProfile().
 func AppDir() string {
 	mu.RLock()
 	folder := appFolder
@@ -76,11 +101,89 @@ func AppDir() string {
 		return folder
 	}
 	mu.Lock()
+	defer mu.Unlock()
 	appFolder = defaultAppFolder()
-	mu.Unlock()
+	custom, err := customAppFolder(appFolder)
+	if err != nil {
+		customAppFolderErr = err
+	} else if custom != "" {
+		appFolder = custom
+	}
+	baseAppFolder = appFolder
+	if id, folder := activeProfileFolder(appFolder); folder != "" {
+		runningProfile = id
+		appFolder = folder
+	}
 	return appFolder
 }
 
+// customAppFolder returns the custom app folder configured in the default app folder, or the empty
+// string if there is none. An error is returned if the custom folder is configured but does not
+// exist, e.g. an encrypted volume which is not mounted.
+func customAppFolder(defaultFolder string) (string, error) {
+	file := NewFile(defaultFolder, dataDirPointerFilename)
+	if !file.Exists() {
+		return "", nil
+	}
+	var pointer dataDirPointer
+	if err := file.ReadJSON(&pointer); err != nil {
+		return "", errp.WithMessage(err, "could not read the custom data directory setting")
+	}
+	if pointer.Path == "" {
+		return "", nil
+	}
+	if fi, err := os.Stat(pointer.Path); err != nil || !fi.IsDir() {
+		return "", errp.Newf("the data directory %s is not available", pointer.Path)
+	}
+	return pointer.Path, nil
+}
+
+// CustomAppDirError returns an error if a custom app directory is configured, but could not be
+// used, e.g. because it is on a volume which is not mounted. AppDir() then returns the default app
+// directory, and the user has to be told that their data is not loaded.
+func CustomAppDirError() error {
+	mu.RLock()
+	defer mu.RUnlock()
+	return customAppFolderErr
+}
+
+// DefaultAppDir returns the absolute path to the default app directory, regardless of a custom app
+// directory set with SetCustomAppDir().
+func DefaultAppDir() string {
+	return defaultAppFolder()
+}
+
+// CustomAppDirSupported returns true if the app directory can be changed with SetCustomAppDir().
+// This is not the case if the app directory is determined by the platform, e.g. on mobile.
+func CustomAppDirSupported() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return !appFolderExplicit
+}
+
+// SetCustomAppDir persists a custom app directory, which is returned by AppDir() from the next app
+// start on. Passing the default app directory removes the custom setting.
+func SetCustomAppDir(folder string) error {
+	if !CustomAppDirSupported() {
+		return errp.New("custom app directory not supported")
+	}
+	defaultFolder := defaultAppFolder()
+	file := NewFile(defaultFolder, dataDirPointerFilename)
+	if filepath.Clean(folder) == filepath.Clean(defaultFolder) {
+		if file.Exists() {
+			return errp.WithStack(file.Remove())
+		}
+		return nil
+	}
+	return errp.WithStack(file.WriteJSON(dataDirPointer{Path: folder}))
+}
+
+// IsDataDirPointer returns true if the filename is the file pointing to the custom app directory,
+// which must remain in the default app directory.
+func IsDataDirPointer(name string) bool {
+	return name == dataDirPointerFilename
+}
+
 // ExportsDir returns the absolute path to the folder which can be used to export files.
 func ExportsDir() (string, error) {
 	if runtime.GOOS == "android" {
@@ -43,6 +43,10 @@ func TestAppDir(t *testing.T) {
 		if dir := config.AppDir(); dir != wantAppDir {
 			t.Errorf("%s: AppDir: %q; want %q", idx, dir, wantAppDir)
 		}
+		wantErr := os.Getenv("BITBOX_TEST_APP_DIR_ERR") == "true"
+		if err := config.CustomAppDirError(); (err != nil) != wantErr {
+			t.Errorf("%s: CustomAppDirError: %v; want error: %v", idx, err, wantErr)
+		}
 		return
 	}
 
@@ -55,12 +59,39 @@ func TestAppDir(t *testing.T) {
 		t.Fatalf("os.MkdirAll: %v", err)
 	}
 
-	tt := []struct{ home, xdg, wantDir string }{
-		{homeDir, "", legacyConfigDir},
-		{homeDir, "/xdg-config", legacyConfigDir},
-		{"/any-home", "/xdg-config", "/xdg-config/bitbox"},
-		{"/any-home", "", "/any-home/.config/bitbox"},
-		{"", "", ".config/bitbox"},
+	// A home dir whose app folder points to a custom data directory, and one pointing to a data
+	// directory which does not exist (e.g. an unmounted volume).
+	customHomeDir := test.TstTempDir("test_app_dir_custom")
+	defer func() { _ = os.RemoveAll(customHomeDir) }()
+	customDataDir := test.TstTempDir("test_app_dir_custom_data")
+	defer func() { _ = os.RemoveAll(customDataDir) }()
+	missingHomeDir := test.TstTempDir("test_app_dir_missing")
+	defer func() { _ = os.RemoveAll(missingHomeDir) }()
+	for home, dataDir := range map[string]string{
+		customHomeDir:  customDataDir,
+		missingHomeDir: missingHomeDir + "/does-not-exist",
+	} {
+		if err := os.MkdirAll(home+"/.config/bitbox", 0750); err != nil {
+			t.Fatalf("os.MkdirAll: %v", err)
+		}
+		pointer := config.NewFile(home+"/.config/bitbox", "datadir.json")
+		if err := pointer.WriteJSON(map[string]string{"path": dataDir}); err != nil {
+			t.Fatalf("WriteJSON: %v", err)
+		}
+	}
+
+	tt := []struct {
+		home, xdg, wantDir string
+		wantErr            bool
+	}{
+		{homeDir, "", legacyConfigDir, false},
+		{customHomeDir, "", customDataDir, false},
+		// The default folder is used, but the missing custom folder is reported.
+		{missingHomeDir, "", missingHomeDir + "/.config/bitbox", true},
+		{homeDir, "/xdg-config", legacyConfigDir, false},
+		{"/any-home", "/xdg-config", "/xdg-config/bitbox", false},
+		{"/any-home", "", "/any-home/.config/bitbox", false},
+		{"", "", ".config/bitbox", false},
 	}
 	var failed bool
 	for i, test := range tt {
@@ -71,6 +102,7 @@ func TestAppDir(t *testing.T) {
 			fmt.Sprintf("XDG_CONFIG_HOME=%s", test.xdg),
 			fmt.Sprintf("BITBOX_TEST_APP_DIR=%s", test.wantDir),
 			fmt.Sprintf("BITBOX_TEST_APP_DIR_IDX=%d", i),
+			fmt.Sprintf("BITBOX_TEST_APP_DIR_ERR=%t", test.wantErr),
 		}
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
@@ -0,0 +1,120 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+const (
+	journalSuffix = ".journal"
+	backupSuffix  = ".bak"
+)
+
+// WriteFileAtomic writes the data to the file such that a crash or power loss never leaves a
+// partially written file behind. The data is first written and synced to a journal file next to
+// the file. Then the previous version of the file is kept as a backup file and the journal file is
+// renamed to the file, so the file is replaced in a single step and never missing. Use
+// ReadFileWithRecovery() to read the file.
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	journal := filename + journalSuffix
+	file, err := os.OpenFile(journal, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return errp.WithStack(err)
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return errp.WithStack(err)
+	}
+	if err := file.Close(); err != nil {
+		return errp.WithStack(err)
+	}
+	if err := backupFile(filename); err != nil {
+		return err
+	}
+	if err := os.Rename(journal, filename); err != nil {
+		return errp.WithStack(err)
+	}
+	syncDir(filepath.Dir(filename))
+	return nil
+}
+
+// backupFile keeps the current version of the file as the backup file. The backup is a hard link
+// to the file, or a copy on filesystems which don't support hard links. Nothing is done if the
+// file does not exist.
+func backupFile(filename string) error {
+	backup := filename + backupSuffix
+	if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+		return errp.WithStack(err)
+	}
+	err := os.Link(filename, backup)
+	if err == nil || os.IsNotExist(err) {
+		return nil
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	return errp.WithStack(os.WriteFile(backup, data, info.Mode().Perm()))
+}
+
+// syncDir persists the renames in the directory. This is best effort, as directories can't be
+// synced on all platforms, e.g. on Windows.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+// ReadFileWithRecovery reads a file written by WriteFileAtomic(). If the file is missing or its
+// contents are not valid according to `valid`, the journal file of an interrupted write and then
+// the backup file of the previous version are read instead. `valid` can be nil to accept any
+// contents. If none of the files exist, an error satisfying os.IsNotExist() is returned.
+func ReadFileWithRecovery(filename string, valid func([]byte) bool) ([]byte, error) {
+	var readErr error
+	for _, candidate := range []string{filename, filename + journalSuffix, filename + backupSuffix} {
+		data, err := os.ReadFile(candidate)
+		if err != nil {
+			if !os.IsNotExist(err) && readErr == nil {
+				readErr = errp.WithStack(err)
+			}
+			continue
+		}
+		if valid == nil || valid(data) {
+			return data, nil
+		}
+		if readErr == nil {
+			readErr = errp.Newf("%s is corrupt", candidate)
+		}
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return nil, &os.PathError{Op: "open", Path: filename, Err: os.ErrNotExist}
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "accounts.json")
+
+	_, err := config.ReadFileWithRecovery(filename, json.Valid)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, config.WriteFileAtomic(filename, []byte(`{"version":1}`), 0600))
+	data, err := config.ReadFileWithRecovery(filename, json.Valid)
+	require.NoError(t, err)
+	require.Equal(t, `{"version":1}`, string(data))
+
+	require.NoError(t, config.WriteFileAtomic(filename, []byte(`{"version":2}`), 0600))
+	data, err = config.ReadFileWithRecovery(filename, json.Valid)
+	require.NoError(t, err)
+	require.Equal(t, `{"version":2}`, string(data))
+	_, err = os.Stat(filename + ".journal")
+	require.True(t, os.IsNotExist(err))
+	backup, err := os.ReadFile(filename + ".bak")
+	require.NoError(t, err)
+	require.Equal(t, `{"version":1}`, string(backup))
+
+	// A corrupt file is recovered from the backup of the previous version.
+	require.NoError(t, os.WriteFile(filename, []byte(`{"vers`), 0600))
+	data, err = config.ReadFileWithRecovery(filename, json.Valid)
+	require.NoError(t, err)
+	require.Equal(t, `{"version":1}`, string(data))
+
+	// Without validation, the corrupt file is returned.
+	data, err = config.ReadFileWithRecovery(filename, nil)
+	require.NoError(t, err)
+	require.Equal(t, `{"vers`, string(data))
+
+	// A write interrupted before the journal was renamed to a missing file is completed from the
+	// journal.
+	require.NoError(t, os.Remove(filename))
+	require.NoError(t, os.WriteFile(filename+".journal", []byte(`{"version":3}`), 0600))
+	data, err = config.ReadFileWithRecovery(filename, json.Valid)
+	require.NoError(t, err)
+	require.Equal(t, `{"version":3}`, string(data))
+
+	// All versions corrupt.
+	require.NoError(t, os.WriteFile(filename+".journal", []byte(``), 0600))
+	require.NoError(t, os.WriteFile(filename+".bak", []byte(`{`), 0600))
+	_, err = config.ReadFileWithRecovery(filename, json.Valid)
+	require.Error(t, err)
+	require.False(t, os.IsNotExist(err))
+}
@@ -0,0 +1,184 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+const (
+	// profilesFilename is the name of the file in the base app folder listing the profiles.
+	profilesFilename = "profiles.json"
+	// profilesFolder is the folder in the base app folder containing one folder per profile.
+	profilesFolder = "profiles"
+	// maxProfileNameLength limits the length of profile names.
+	maxProfileNameLength = 50
+)
+
+// Profile is an isolated set of app data (config, accounts, notes, caches) in its own folder, e.g.
+// for each member of a family sharing a computer. The default profile, which has an empty ID, uses
+// the app folder itself and is not listed.
+type Profile struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type profilesList struct {
+	// Selected is the ID of the profile to use from the next app start on.
+	Selected string    `json:"selected"`
+	Profiles []Profile `json:"profiles"`
+}
+
+func readProfiles(baseFolder string) profilesList {
+	list := profilesList{Profiles: []Profile{}}
+	file := NewFile(baseFolder, profilesFilename)
+	if !file.Exists() {
+		return list
+	}
+	if err := file.ReadJSON(&list); err != nil {
+		return profilesList{Profiles: []Profile{}}
+	}
+	if list.Profiles == nil {
+		list.Profiles = []Profile{}
+	}
+	return list
+}
+
+func (list profilesList) lookup(id string) *Profile {
+	for i := range list.Profiles {
+		if list.Profiles[i].ID == id {
+			return &list.Profiles[i]
+		}
+	}
+	return nil
+}
+
+func profileFolder(baseFolder, id string) string {
+	return filepath.Join(baseFolder, profilesFolder, id)
+}
+
+// activeProfileFolder returns the ID and the folder of the selected profile, creating the folder if
+// needed. An empty folder is returned for the default profile, or if the selected profile can't be
+// used, in which case the default profile is used.
+func activeProfileFolder(baseFolder string) (string, string) {
+	list := readProfiles(baseFolder)
+	if list.Selected == "" || list.lookup(list.Selected) == nil {
+		return "", ""
+	}
+	folder := profileFolder(baseFolder, list.Selected)
+	if err := os.MkdirAll(folder, 0700); err != nil {
+		return "", ""
+	}
+	return list.Selected, folder
+}
+
+// addProfile adds a profile with a random ID to the profiles list in baseFolder.
+func addProfile(baseFolder, name string) (*Profile, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxProfileNameLength {
+		return nil, errp.New("invalid profile name")
+	}
+	list := readProfiles(baseFolder)
+	for _, profile := range list.Profiles {
+		if strings.EqualFold(profile.Name, name) {
+			return nil, errp.Newf("a profile named %q already exists", name)
+		}
+	}
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	profile := Profile{ID: hex.EncodeToString(id), Name: name}
+	list.Profiles = append(list.Profiles, profile)
+	if err := NewFile(baseFolder, profilesFilename).WriteJSON(list); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return &profile, nil
+}
+
+// selectProfile persists the profile to use from the next app start on. The empty ID selects the
+// default profile.
+func selectProfile(baseFolder, id string) error {
+	list := readProfiles(baseFolder)
+	if id != "" && list.lookup(id) == nil {
+		return errp.Newf("unknown profile: %s", id)
+	}
+	list.Selected = id
+	return errp.WithStack(NewFile(baseFolder, profilesFilename).WriteJSON(list))
+}
+
+// BaseAppDir returns the app directory without the folder of the profile, which contains the list
+// of profiles.
+func BaseAppDir() string {
+	AppDir()
+	mu.RLock()
+	defer mu.RUnlock()
+	if baseAppFolder == "" {
+		// The app folder was set using SetAppDir().
+		return appFolder
+	}
+	return baseAppFolder
+}
+
+// ProfilesSupported returns true if profiles can be used. This is not the case if the app
+// directory is determined by the platform, e.g. on mobile.
+func ProfilesSupported() bool {
+	return CustomAppDirSupported()
+}
+
+// Profiles returns the profiles other than the default one and the ID of the profile selected for
+// the next app start.
+func Profiles() ([]Profile, string) {
+	list := readProfiles(BaseAppDir())
+	return list.Profiles, list.Selected
+}
+
+// RunningProfile returns the ID of the profile in use, or the empty string for the default
+// profile.
+func RunningProfile() string {
+	AppDir()
+	mu.RLock()
+	defer mu.RUnlock()
+	return runningProfile
+}
+
+// AddProfile adds a new, empty profile with the given name.
+func AddProfile(name string) (*Profile, error) {
+	if !ProfilesSupported() {
+		return nil, errp.New("profiles are not supported on this platform")
+	}
+	baseFolder := BaseAppDir()
+	mu.Lock()
+	defer mu.Unlock()
+	return addProfile(baseFolder, name)
+}
+
+// SelectProfile persists the profile which is used from the next app start on. The empty ID
+// selects the default profile.
+func SelectProfile(id string) error {
+	if !ProfilesSupported() {
+		return errp.New("profiles are not supported on this platform")
+	}
+	baseFolder := BaseAppDir()
+	mu.Lock()
+	defer mu.Unlock()
+	return selectProfile(baseFolder, id)
+}
@@ -0,0 +1,58 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	baseFolder := t.TempDir()
+
+	// Without profiles, the default profile is used.
+	id, folder := activeProfileFolder(baseFolder)
+	require.Empty(t, id)
+	require.Empty(t, folder)
+	require.Empty(t, readProfiles(baseFolder).Profiles)
+
+	_, err := addProfile(baseFolder, " ")
+	require.Error(t, err)
+	alice, err := addProfile(baseFolder, "Alice")
+	require.NoError(t, err)
+	require.Len(t, alice.ID, 8)
+	_, err = addProfile(baseFolder, "alice")
+	require.Error(t, err)
+	bob, err := addProfile(baseFolder, "Bob")
+	require.NoError(t, err)
+	require.Equal(t, []Profile{*alice, *bob}, readProfiles(baseFolder).Profiles)
+
+	require.Error(t, selectProfile(baseFolder, "unknown"))
+	require.NoError(t, selectProfile(baseFolder, bob.ID))
+	id, folder = activeProfileFolder(baseFolder)
+	require.Equal(t, bob.ID, id)
+	require.Equal(t, filepath.Join(baseFolder, "profiles", bob.ID), folder)
+	info, err := os.Stat(folder)
+	require.NoError(t, err)
+	require.True(t, info.IsDir())
+
+	require.NoError(t, selectProfile(baseFolder, ""))
+	id, folder = activeProfileFolder(baseFolder)
+	require.Empty(t, id)
+	require.Empty(t, folder)
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httpapi provides helpers for the JSON APIs the app serves to other apps and devices.
+package httpapi
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// RequestError is an error with an associated HTTP status code. Handlers return it to respond with
+// a status code other than 500 Internal Server Error.
+type RequestError struct {
+	Status int
+	err    error
+}
+
+func (err *RequestError) Error() string {
+	return err.err.Error()
+}
+
+// NewRequestError creates a RequestError.
+func NewRequestError(status int, message string) *RequestError {
+	return &RequestError{Status: status, err: errp.New(message)}
+}
+
+// ErrorStatus returns the status code of a RequestError, and 500 Internal Server Error for all
+// other errors.
+func ErrorStatus(err error) int {
+	if reqErr, ok := err.(*RequestError); ok {
+		return reqErr.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// WriteJSON writes the value as a JSON response with the given status code.
+func WriteJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// WriteError writes the error response `{"error": message}` with the given status code.
+func WriteError(w http.ResponseWriter, status int, message string) {
+	WriteJSON(w, status, map[string]string{"error": message})
+}
+
+// IsLocalHost returns true if the Host header names the loopback interface. This protects against
+// DNS rebinding attacks, where a website resolves its own domain to 127.0.0.1.
+func IsLocalHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	switch host {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	default:
+		return false
+	}
+}
+
+// CheckLocalRequest returns true if the request was made to the loopback interface, see
+// IsLocalHost(). If allowBrowsers is false, requests made by websites, which carry an Origin
+// header, are rejected too. Rejected requests are responded to with 403 Forbidden.
+func CheckLocalRequest(w http.ResponseWriter, r *http.Request, allowBrowsers bool) bool {
+	if !IsLocalHost(r.Host) || (!allowBrowsers && r.Header.Get("Origin") != "") {
+		WriteError(w, http.StatusForbidden, "invalid host")
+		return false
+	}
+	return true
+}
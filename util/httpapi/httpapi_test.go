@@ -0,0 +1,60 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLocalHost(t *testing.T) {
+	require.True(t, IsLocalHost("localhost"))
+	require.True(t, IsLocalHost("127.0.0.1:8080"))
+	require.True(t, IsLocalHost("[::1]:8080"))
+	require.False(t, IsLocalHost("example.com"))
+	require.False(t, IsLocalHost("localhost.example.com:8080"))
+	require.False(t, IsLocalHost("192.168.1.2:8080"))
+}
+
+func TestCheckLocalRequest(t *testing.T) {
+	check := func(host string, origin string, allowBrowsers bool) (bool, int) {
+		request := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+		request.Host = host
+		if origin != "" {
+			request.Header.Set("Origin", origin)
+		}
+		recorder := httptest.NewRecorder()
+		return CheckLocalRequest(recorder, request, allowBrowsers), recorder.Code
+	}
+	ok, _ := check("127.0.0.1:8080", "", false)
+	require.True(t, ok)
+	ok, _ = check("127.0.0.1:8080", "https://example.com", true)
+	require.True(t, ok)
+	ok, status := check("127.0.0.1:8080", "https://example.com", false)
+	require.False(t, ok)
+	require.Equal(t, http.StatusForbidden, status)
+	ok, status = check("example.com", "", true)
+	require.False(t, ok)
+	require.Equal(t, http.StatusForbidden, status)
+}
+
+func TestErrorStatus(t *testing.T) {
+	require.Equal(t, http.StatusNotFound, ErrorStatus(NewRequestError(http.StatusNotFound, "unknown")))
+	require.Equal(t, http.StatusInternalServerError, ErrorStatus(errp.New("error")))
+}
@@ -15,10 +15,13 @@
 package socksproxy
 
 import (
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
 
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/proxy"
@@ -49,6 +52,16 @@ func NewSocksProxy(useProxy bool, proxyAddress string) SocksProxy {
 	return proxy
 }
 
+// Enabled returns true if connections are made through the proxy.
+func (socksProxy SocksProxy) Enabled() bool {
+	return socksProxy.useProxy
+}
+
+// Address returns the address of the proxy, e.g. "127.0.0.1:9050".
+func (socksProxy SocksProxy) Address() string {
+	return socksProxy.proxyAddress
+}
+
 // Validate validates the socks5 proxy endpoint.
 // We check if we could instantiate a proxied http client.
 // Currently, no actual connectivity checks as performed.
@@ -64,18 +77,55 @@ func (socksProxy SocksProxy) Validate() error {
 	return err
 }
 
+// IsOnionAddress returns true if the host of the address, which can include a port, is a Tor onion
+// service. Onion services can only be reached through Tor.
+func IsOnionAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	return strings.HasSuffix(strings.ToLower(strings.TrimSuffix(host, ".")), ".onion")
+}
+
+// onionDialer routes onion addresses through the Tor proxy and all other addresses through the
+// direct dialer.
+type onionDialer struct {
+	direct       proxy.Dialer
+	tor          proxy.Dialer
+	proxyAddress string
+}
+
+// Dial implements proxy.Dialer.
+func (dialer *onionDialer) Dial(network, address string) (net.Conn, error) {
+	if !IsOnionAddress(address) {
+		return dialer.direct.Dial(network, address)
+	}
+	conn, err := dialer.tor.Dial(network, address)
+	if err != nil {
+		return nil, errp.WithMessage(err, fmt.Sprintf(
+			"%s is an onion address and can only be reached through a Tor proxy, but the Tor proxy at %s is not reachable",
+			address, dialer.proxyAddress))
+	}
+	return conn, nil
+}
+
 // GetTCPProxyDialer returns a tcp connection. The connection is proxied, if useProxy is true.
+// Connections to onion addresses are always proxied, as they can't be reached otherwise.
 func (socksProxy *SocksProxy) GetTCPProxyDialer() proxy.Dialer {
+	// Create a proxy that uses Tor's SocksPort.
+	dialer, err := proxy.SOCKS5("tcp", socksProxy.proxyAddress, nil, nil)
+	if err != nil {
+		// TODO: Remove this panic.
+		socksProxy.log.WithError(err).Panic("Failed to create SOCKS5 TCP dialer")
+	}
 	if socksProxy.useProxy {
-		// Create a proxy that uses Tor's SocksPort.
-		dialer, err := proxy.SOCKS5("tcp", socksProxy.proxyAddress, nil, nil)
-		if err != nil {
-			// TODO: Remove this panic.
-			socksProxy.log.WithError(err).Panic("Failed to create SOCKS5 TCP dialer")
-		}
 		return dialer
 	}
-	return &net.Dialer{}
+	return &onionDialer{
+		direct:       &net.Dialer{},
+		tor:          dialer,
+		proxyAddress: socksProxy.proxyAddress,
+	}
 }
 
 // GetHTTPClient returns a http client. Requests made with this client are proxied, if useProxy is true.
@@ -28,3 +28,20 @@ func TestValidate(t *testing.T) {
 	require.Error(t, NewSocksProxy(true, "127.0.0.1:XXXX").Validate())
 	require.Error(t, NewSocksProxy(true, "127.0.0.1:9050 ").Validate())
 }
+
+func TestIsOnionAddress(t *testing.T) {
+	require.True(t, IsOnionAddress("bitboxqgc4y3rx3w5lm3g7dxvl3wsemtfnkzdpqlb5rzq5c5f5ttrhad.onion:50002"))
+	require.True(t, IsOnionAddress("bitboxqgc4y3rx3w5lm3g7dxvl3wsemtfnkzdpqlb5rzq5c5f5ttrhad.ONION"))
+	require.True(t, IsOnionAddress("example.onion.:443"))
+	require.False(t, IsOnionAddress("btc1.shiftcrypto.io:443"))
+	require.False(t, IsOnionAddress("onion.example.com:443"))
+	require.False(t, IsOnionAddress("127.0.0.1:50001"))
+}
+
+func TestOnionWithoutProxy(t *testing.T) {
+	// Nothing listens on this port, so the Tor proxy is not reachable.
+	socksProxy := NewSocksProxy(false, "127.0.0.1:1")
+	_, err := socksProxy.GetTCPProxyDialer().Dial("tcp", "example.onion:50002")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "can only be reached through a Tor proxy")
+}